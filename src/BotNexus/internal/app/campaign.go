@@ -0,0 +1,175 @@
+package app
+
+import (
+	"BotMatrix/common/campaign"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// campaignSender 通过机器人 WebSocket 发送群发消息，并等待机器人按 echo 返回的结果
+type campaignSender struct {
+	m *Manager
+}
+
+func (s campaignSender) Send(ctx context.Context, t campaign.Target, message string) error {
+	m := s.m
+	bot, ok := m.GetBot(t.Platform, t.BotID)
+	if !ok {
+		// 目标未记录平台时按 SelfID 查找
+		m.Mutex.RLock()
+		for _, b := range m.Bots {
+			if b.SelfID == t.BotID {
+				bot, ok = b, true
+				break
+			}
+		}
+		m.Mutex.RUnlock()
+	}
+	var key, owner string
+	if !ok {
+		// 集群模式下机器人可能连接在其他节点，经该节点发送
+		if key, owner = m.remoteBotOwner(botKeys(t.Platform, t.BotID)...); owner == "" {
+			return fmt.Errorf("bot %s is offline", t.BotID)
+		}
+	}
+
+	action := "send_group_msg"
+	var params map[string]any
+	switch t.Type {
+	case campaign.TargetPrivate:
+		action = "send_private_msg"
+		params = map[string]any{"user_id": t.ID, "message": message}
+	case campaign.TargetGuild:
+		action = "send_msg"
+		params = map[string]any{"message_type": "guild", "channel_id": t.ID, "message": message}
+		if t.GuildID != "" {
+			params["guild_id"] = t.GuildID
+		}
+	default:
+		params = map[string]any{"group_id": t.ID, "message": message}
+	}
+
+	echo := fmt.Sprintf("campaign|%d|%s", time.Now().UnixNano(), action)
+	if owner != "" {
+		resp, err := m.callRemoteBot(ctx, owner, key, types.InternalAction{Action: action, Params: params, Echo: echo}, echo)
+		if err != nil {
+			return err
+		}
+		return campaignResult(resp)
+	}
+
+	respChan := make(chan types.InternalMessage, 1)
+	m.PendingMutex.Lock()
+	m.PendingRequests[echo] = respChan
+	m.PendingTimestamps[echo] = time.Now()
+	m.PendingMutex.Unlock()
+	defer func() {
+		m.PendingMutex.Lock()
+		delete(m.PendingRequests, echo)
+		delete(m.PendingTimestamps, echo)
+		m.PendingMutex.Unlock()
+	}()
+
+	bot.Mutex.Lock()
+	var err error
+	if bot.Conn == nil {
+		err = fmt.Errorf("bot %s connection is closed", t.BotID)
+	} else {
+		err = bot.Conn.WriteJSON(types.InternalAction{Action: action, Params: params, Echo: echo})
+	}
+	bot.Mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-respChan:
+		return campaignResult(resp)
+	case <-ctx.Done():
+		return fmt.Errorf("bot %s did not respond: %w", t.BotID, ctx.Err())
+	}
+}
+
+// campaignResult 将机器人的动作结果转换为投递错误
+func campaignResult(resp types.InternalMessage) error {
+	if status := utils.ToString(resp.Status); resp.Retcode != 0 || (status != "" && status != "ok") {
+		if resp.Msg != "" {
+			return fmt.Errorf("%s (retcode %d): %s", status, resp.Retcode, resp.Msg)
+		}
+		return fmt.Errorf("%s (retcode %d)", status, resp.Retcode)
+	}
+	return nil
+}
+
+// campaignDirectory 以联系人缓存作为群发圈选的数据源。
+// 群属性: platform、bot_id、group_name；好友属性: platform、bot_id、nickname；
+// 私聊对象同时是缓存中的群成员时附加 role (owner/admin/member)
+type campaignDirectory struct {
+	m *Manager
+}
+
+func (d campaignDirectory) Contacts(context.Context) ([]campaign.Contact, error) {
+	m := d.m
+	platforms := make(map[string]string)
+	m.Mutex.RLock()
+	for _, b := range m.Bots {
+		platforms[b.SelfID] = b.Platform
+	}
+	m.Mutex.RUnlock()
+
+	m.CacheMutex.RLock()
+	defer m.CacheMutex.RUnlock()
+
+	roles := make(map[string]string)
+	for _, member := range m.MemberCache {
+		// 同一用户在多个群中时取最高角色
+		if rank(member.Role) > rank(roles[member.BotID+"|"+member.UserID]) {
+			roles[member.BotID+"|"+member.UserID] = member.Role
+		}
+	}
+
+	contacts := make([]campaign.Contact, 0, len(m.GroupCache)+len(m.FriendCache))
+	for _, g := range m.GroupCache {
+		platform := platforms[g.BotID]
+		contacts = append(contacts, campaign.Contact{
+			BotID:    g.BotID,
+			Platform: platform,
+			Type:     campaign.TargetGroup,
+			ID:       g.GroupID,
+			Name:     g.GroupName,
+			Attrs:    map[string]string{"platform": platform, "bot_id": g.BotID, "group_name": g.GroupName},
+		})
+	}
+	for _, f := range m.FriendCache {
+		platform := platforms[f.BotID]
+		attrs := map[string]string{"platform": platform, "bot_id": f.BotID, "nickname": f.Nickname}
+		if role := roles[f.BotID+"|"+f.UserID]; role != "" {
+			attrs["role"] = role
+		}
+		contacts = append(contacts, campaign.Contact{
+			BotID:    f.BotID,
+			Platform: platform,
+			Type:     campaign.TargetPrivate,
+			ID:       f.UserID,
+			Name:     f.Nickname,
+			Attrs:    attrs,
+		})
+	}
+	return contacts, nil
+}
+
+func rank(role string) int {
+	switch strings.ToLower(role) {
+	case "owner":
+		return 3
+	case "admin":
+		return 2
+	case "member":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"BotMatrix/common"
+	"BotMatrix/common/bot"
+	"BotMatrix/common/campaign"
+	"BotMatrix/common/onebot"
+	"BotMatrix/common/types"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+// runConsoleBot 与 ConsoleBot 相同：收到动作后打印消息并按 echo 回复 ok；群号为 failGroup 时回复 failed
+func runConsoleBot(t *testing.T, conn *websocket.Conn, failGroup string, received chan<- onebot.Request) {
+	t.Helper()
+	go func() {
+		for {
+			var req onebot.Request
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			received <- req
+			var resp any = onebot.Response{Status: "ok", Data: map[string]any{"message_id": time.Now().Unix()}, Echo: req.Echo}
+			if params, ok := req.Params.(map[string]any); ok && params["group_id"] == failGroup {
+				resp = map[string]any{"status": "failed", "retcode": 100, "msg": "group muted", "echo": req.Echo}
+			}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestCampaignDeliveryThroughConsoleBot(t *testing.T) {
+	m := &Manager{Manager: bot.NewManager()}
+	m.Core = common.NewCorePlugin(m.Manager)
+
+	// Nexus 侧：接受 ConsoleBot 的 WebSocket 连接并进入正常的机器人读循环
+	upgrader := websocket.Upgrader{}
+	connected := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		b := &types.BotClient{Conn: conn, SelfID: "10001", Platform: "console", Protocol: "v11", Connected: time.Now()}
+		m.Mutex.Lock()
+		m.Bots["console:10001"] = b
+		m.Mutex.Unlock()
+		close(connected)
+		m.handleBotConnection(b)
+	}))
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	received := make(chan onebot.Request, 10)
+	runConsoleBot(t, client, "g-muted", received)
+	<-connected
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	m.CacheMutex.Lock()
+	m.GroupCache["g1"] = types.GroupInfo{GroupID: "g1", GroupName: "测试群", BotID: "10001"}
+	m.FriendCache["u1"] = types.FriendInfo{UserID: "u1", Nickname: "Alice", BotID: "10001"}
+	m.CacheMutex.Unlock()
+
+	svc := campaign.New(db, campaignDirectory{m}, campaignSender{m}, campaign.Options{DefaultRate: 6000, MaxAttempts: 1, SendTimeout: 5 * time.Second})
+	if err := svc.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := svc.Create(context.Background(), campaign.Spec{
+		Template: "{{.name}} 您好",
+		Audience: campaign.Segment{GroupIDs: []string{"g1"}, UserIDs: []string{"u1"}, Targets: []campaign.Target{{BotID: "10001", Type: campaign.TargetGroup, ID: "g-muted"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		svc.Tick(context.Background())
+		p, err := svc.Progress(context.Background(), c.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Queued == 0 {
+			if p != (campaign.Progress{Total: 3, Sent: 2, Failed: 1}) {
+				t.Fatalf("progress = %+v", p)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery did not finish: %+v", p)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	got := map[string]string{}
+	for len(received) > 0 {
+		req := <-received
+		params := req.Params.(map[string]any)
+		got[req.Action+":"+params["message"].(string)] = req.Echo.(string)
+	}
+	for _, want := range []string{"send_private_msg:Alice 您好", "send_group_msg:测试群 您好", "send_group_msg:g-muted 您好"} {
+		if _, ok := got[want]; !ok {
+			t.Errorf("ConsoleBot did not receive %q, got %v", want, got)
+		}
+	}
+	failed, _ := svc.Recipients(context.Background(), c.ID, campaign.DeliveryFailed, 0, 10)
+	if len(failed) != 1 || !strings.Contains(failed[0].Error, "group muted") {
+		t.Fatalf("failed recipients = %+v", failed)
+	}
+}
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"BotMatrix/common/cluster"
+	"BotMatrix/common/config"
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/tracing"
+	"BotMatrix/common/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// 集群中只由 leader 执行的单例任务
+const (
+	jobTaskScheduler = "task_scheduler"    // 定时任务扫描
+	jobCampaign      = "campaign_delivery" // 群发活动投递
+	jobArchivePurge  = "archive_purge"     // 消息归档保留策略清理
+	jobStatsSave     = "stats_save"        // Redis 统计固化到数据库
+)
+
+// 节点间消息类型
+const (
+	clusterBotCall      = "bot_call"
+	clusterRulesChanged = "routing_rules_changed"
+)
+
+// botCall 发给持有机器人连接的节点，由其原样写入机器人 WebSocket
+type botCall struct {
+	Bot     string          `json:"bot"`            // Bots 中的键 (platform:self_id)
+	Request json.RawMessage `json:"request"`        // 发给机器人的 OneBot 动作
+	Echo    string          `json:"echo,omitempty"` // 非空时等待机器人按该 echo 返回的结果
+}
+
+// initCluster 按 cluster 配置加入集群，需在 Redis 连接建立后调用。未启用时各节点按单机运行
+func (m *Manager) initCluster() {
+	cfg := config.GlobalConfig.Cluster
+	if !cfg.Enabled {
+		return
+	}
+	if m.Rdb == nil {
+		clog.Error("[Cluster] 集群模式需要 Redis，当前按单节点运行")
+		return
+	}
+	node := cluster.New(m.Rdb, cluster.Options{
+		NodeID:   cfg.NodeID,
+		Addr:     cfg.Addr,
+		LeaseTTL: time.Duration(cfg.LeaseSeconds) * time.Second,
+	})
+	if err := m.joinCluster(node); err != nil {
+		clog.Error("[Cluster] 加入集群失败，当前按单节点运行", zap.Error(err))
+		return
+	}
+	clog.Info("[Cluster] 已加入集群", zap.String("node_id", node.ID()))
+}
+
+// joinCluster 注册节点间消息处理器、参与单例任务竞选并启动节点
+func (m *Manager) joinCluster(node *cluster.Node) error {
+	node.Elect(jobTaskScheduler, jobCampaign, jobArchivePurge, jobStatsSave)
+	node.Handle(clusterBotCall, m.handleClusterBotCall)
+	node.Handle(clusterRulesChanged, func(context.Context, string, json.RawMessage) (any, error) {
+		return nil, m.LoadRoutingRulesFromDB()
+	})
+	if err := node.Start(context.Background()); err != nil {
+		return err
+	}
+	m.Cluster = node
+	m.Manager.IsLeader = m.isLeader
+	m.Manager.RoutingRulesChanged = func() {
+		if err := node.Broadcast(context.Background(), clusterRulesChanged, nil); err != nil {
+			clog.Warn("[Cluster] 通知其他节点重新加载路由规则失败", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// isLeader 报告本节点是否执行单例任务，单机运行时总是执行
+func (m *Manager) isLeader(job string) bool {
+	return m.Cluster == nil || m.Cluster.IsLeader(job)
+}
+
+// leaderGate 返回单例任务的执行判断，用于在加入集群之前创建的服务
+func (m *Manager) leaderGate(job string) func() bool {
+	return func() bool { return m.isLeader(job) }
+}
+
+// claimConnection 在集群中登记本节点持有的机器人或 Worker 连接
+func (m *Manager) claimConnection(kind, id string) {
+	if m.Cluster == nil {
+		return
+	}
+	if err := m.Cluster.Claim(context.Background(), kind, id); err != nil {
+		clog.Warn("[Cluster] 登记连接失败", zap.String("kind", kind), zap.String("id", id), zap.Error(err))
+	}
+}
+
+// releaseConnection 注销连接登记，连接已重连到其他节点时保留对方的登记
+func (m *Manager) releaseConnection(kind, id string) {
+	if m.Cluster == nil {
+		return
+	}
+	if err := m.Cluster.Release(context.Background(), kind, id); err != nil {
+		clog.Warn("[Cluster] 注销连接失败", zap.String("kind", kind), zap.String("id", id), zap.Error(err))
+	}
+}
+
+// remoteBotOwner 按顺序查找持有机器人连接的其他节点，返回命中的键与节点 ID；本节点持有或无人持有时返回空串
+func (m *Manager) remoteBotOwner(keys ...string) (key, owner string) {
+	if m.Cluster == nil {
+		return "", ""
+	}
+	for _, k := range keys {
+		if k == "" {
+			continue
+		}
+		o, err := m.Cluster.Owner(context.Background(), cluster.KindBot, k)
+		if err != nil {
+			clog.Warn("[Cluster] 查询机器人所在节点失败", zap.String("bot", k), zap.Error(err))
+			return "", ""
+		}
+		if o != "" && o != m.Cluster.ID() {
+			return k, o
+		}
+	}
+	return "", ""
+}
+
+// botKeys 返回 platform/selfID 可能对应的 Bots 键，与本地查找的顺序一致
+func botKeys(platform, selfID string) []string {
+	if strings.Contains(selfID, ":") {
+		return []string{selfID}
+	}
+	if platform == "" {
+		platform = "qq"
+	}
+	return []string{fmt.Sprintf("%s:%s", platform, selfID), selfID}
+}
+
+// callRemoteBot 经持有连接的节点向机器人发送动作。echo 非空时等待机器人的结果，直到 ctx 结束
+func (m *Manager) callRemoteBot(ctx context.Context, owner, key string, request any, echo string) (types.InternalMessage, error) {
+	var resp types.InternalMessage
+	data, err := json.Marshal(request)
+	if err != nil {
+		return resp, err
+	}
+	call := botCall{Bot: key, Request: data, Echo: echo}
+	if echo == "" {
+		return resp, m.Cluster.Call(ctx, owner, clusterBotCall, call, nil)
+	}
+	err = m.Cluster.Call(ctx, owner, clusterBotCall, call, &resp)
+	return resp, err
+}
+
+// handleClusterBotCall 将其他节点转发的动作写入本节点持有的机器人连接
+func (m *Manager) handleClusterBotCall(ctx context.Context, from string, payload json.RawMessage) (any, error) {
+	var call botCall
+	if err := json.Unmarshal(payload, &call); err != nil {
+		return nil, err
+	}
+	m.Mutex.RLock()
+	bot, ok := m.Bots[call.Bot]
+	m.Mutex.RUnlock()
+	if !ok || bot.Conn == nil {
+		return nil, fmt.Errorf("bot %s is not connected to node %s", call.Bot, m.Cluster.ID())
+	}
+
+	var respChan chan types.InternalMessage
+	if call.Echo != "" {
+		respChan = make(chan types.InternalMessage, 1)
+		m.PendingMutex.Lock()
+		m.PendingRequests[call.Echo] = respChan
+		m.PendingTimestamps[call.Echo] = time.Now()
+		m.PendingMutex.Unlock()
+		defer func() {
+			m.PendingMutex.Lock()
+			delete(m.PendingRequests, call.Echo)
+			delete(m.PendingTimestamps, call.Echo)
+			m.PendingMutex.Unlock()
+		}()
+	}
+
+	var action struct {
+		Action string `json:"action"`
+	}
+	json.Unmarshal(call.Request, &action)
+	bot.Mutex.Lock()
+	err := bot.Conn.WriteMessage(websocket.TextMessage, call.Request)
+	bot.Mutex.Unlock()
+	recordBotAction(bot, action.Action, err)
+	if err != nil {
+		return nil, err
+	}
+	clog.Info("[Cluster] 已转发其他节点的动作", zap.String("from", from), zap.String("bot", call.Bot), zap.String("action", action.Action))
+	if respChan == nil {
+		return nil, nil
+	}
+
+	timeout := time.NewTimer(30 * time.Second)
+	defer timeout.Stop()
+	select {
+	case resp := <-respChan:
+		return resp, nil
+	case <-timeout.C:
+		return nil, fmt.Errorf("bot %s did not respond", call.Bot)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// forwardWorkerRequestToRemoteBot 机器人连接在其他节点时经该节点转发 Worker 请求，并按原 echo 把结果回复给 Worker
+func (m *Manager) forwardWorkerRequestToRemoteBot(worker *types.WorkerClient, action types.InternalAction, originalEcho, internalEcho, key, owner string) {
+	action.Echo = internalEcho
+	actionCtx, span := tracing.Start(tracing.ExtractAction(context.Background(), action), "nexus.send_action "+action.Action,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("botmatrix.worker_id", worker.ID),
+			attribute.String("botmatrix.self_id", key),
+			attribute.String("botmatrix.node", owner),
+		))
+	tracing.InjectAction(actionCtx, &action)
+	clog.Info("[Cluster] 经其他节点转发 Worker 请求", zap.String("worker", worker.ID), zap.String("bot", key), zap.String("node", owner))
+
+	go func() {
+		defer func() {
+			m.PendingMutex.Lock()
+			delete(m.PendingRequests, internalEcho)
+			delete(m.PendingTimestamps, internalEcho)
+			m.PendingMutex.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		resp, err := m.callRemoteBot(ctx, owner, key, action, internalEcho)
+		tracing.End(span, err)
+		if err != nil {
+			clog.Warn("[Cluster] 转发 Worker 请求失败", zap.String("bot", key), zap.String("node", owner), zap.Error(err))
+			resp = types.InternalMessage{Status: "failed", Retcode: 1400, Msg: err.Error()}
+		}
+
+		var finalResponse map[string]any
+		if worker.Protocol == "v12" {
+			finalResponse = resp.ToV12Map()
+		} else {
+			finalResponse = resp.ToV11Map()
+		}
+		finalResponse["echo"] = originalEcho
+		worker.Mutex.Lock()
+		worker.Conn.WriteJSON(finalResponse)
+		worker.Mutex.Unlock()
+	}()
+}
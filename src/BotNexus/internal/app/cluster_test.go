@@ -0,0 +1,148 @@
+package app
+
+import (
+	"BotMatrix/common"
+	"BotMatrix/common/bot"
+	"BotMatrix/common/campaign"
+	"BotMatrix/common/cluster"
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/onebot"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+var clusterLogOnce sync.Once
+
+// startClusterNode 启动一个进程内的 Nexus 节点，与其他节点共享同一个 Redis
+func startClusterNode(t *testing.T, mr *miniredis.Miniredis, id string) *Manager {
+	t.Helper()
+	clusterLogOnce.Do(clog.InitDefaultLogger)
+	m := &Manager{Manager: bot.NewManager()}
+	m.Core = common.NewCorePlugin(m.Manager)
+	m.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { m.Rdb.Close() })
+	node := cluster.New(m.Rdb, cluster.Options{NodeID: id, LeaseTTL: 3 * time.Second, RenewInterval: time.Hour})
+	if err := m.joinCluster(node); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(node.Stop)
+	return m
+}
+
+// waitAction 等待机器人收到指定动作，忽略连接后 Nexus 主动查询信息的请求
+func waitAction(t *testing.T, received <-chan onebot.Request, action string) onebot.Request {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case req := <-received:
+			if req.Action == action {
+				return req
+			}
+		case <-timeout:
+			t.Fatalf("bot did not receive %s", action)
+		}
+	}
+}
+
+func TestClusterForwardsActionsToOwningNode(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := startClusterNode(t, mr, "node-a")
+	b := startClusterNode(t, mr, "node-b")
+
+	// 机器人只连接到节点 A
+	srv := httptest.NewServer(http.HandlerFunc(a.handleBotWebSocket))
+	defer srv.Close()
+	header := http.Header{"X-Self-ID": {"10001"}, "X-Platform": {"console"}}
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	received := make(chan onebot.Request, 10)
+	runConsoleBot(t, client, "g-muted", received)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !a.Cluster.Owns(cluster.KindBot, "console:10001") {
+		if time.Now().After(deadline) {
+			t.Fatal("node A did not claim the bot connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if key, owner := b.remoteBotOwner(botKeys("console", "10001")...); key != "console:10001" || owner != "node-a" {
+		t.Fatalf("remote owner seen from B = %q, %q", key, owner)
+	}
+	if _, owner := a.remoteBotOwner(botKeys("console", "10001")...); owner != "" {
+		t.Fatalf("A should not treat its own bot as remote, got %q", owner)
+	}
+
+	// 节点 B 上的任务动作经 A 发给机器人
+	if err := b.SendBotAction("console:10001", "send_group_msg", map[string]any{"group_id": "g1", "message": "from B"}); err != nil {
+		t.Fatal(err)
+	}
+	req := waitAction(t, received, "send_group_msg")
+	if params, _ := req.Params.(map[string]any); params["message"] != "from B" {
+		t.Fatalf("forwarded params = %+v", req.Params)
+	}
+
+	// 节点 B 上的群发投递等待 A 转回机器人的结果
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sender := campaignSender{m: b}
+	if err := sender.Send(ctx, campaign.Target{BotID: "10001", Platform: "console", Type: campaign.TargetGroup, ID: "g1"}, "hello"); err != nil {
+		t.Fatalf("remote campaign send: %v", err)
+	}
+	err = sender.Send(ctx, campaign.Target{BotID: "10001", Platform: "console", Type: campaign.TargetGroup, ID: "g-muted"}, "hello")
+	if err == nil || !strings.Contains(err.Error(), "group muted") {
+		t.Fatalf("remote failure = %v", err)
+	}
+	if err := sender.Send(ctx, campaign.Target{BotID: "20002", Platform: "console", Type: campaign.TargetGroup, ID: "g1"}, "hello"); err == nil {
+		t.Fatal("expected offline error for a bot no node holds")
+	}
+
+	// 机器人断开后 A 注销登记
+	client.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		if _, owner := b.remoteBotOwner(botKeys("console", "10001")...); owner == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bot registration not released after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClusterSingletonJobFailover(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := startClusterNode(t, mr, "node-a")
+	b := startClusterNode(t, mr, "node-b")
+
+	for _, job := range []string{jobTaskScheduler, jobCampaign, jobArchivePurge, jobStatsSave} {
+		if a.isLeader(job) == b.isLeader(job) {
+			t.Fatalf("%s: leader a=%v b=%v", job, a.isLeader(job), b.isLeader(job))
+		}
+	}
+	if !a.Manager.IsLeader(jobStatsSave) || !a.leaderGate(jobCampaign)() {
+		t.Fatal("first node should run the singleton jobs")
+	}
+
+	// 节点 A 下线 (如滚动发布) 后 B 在下一次续租时接管
+	a.Cluster.Stop()
+	b.Cluster.Heartbeat(context.Background())
+	for _, job := range []string{jobTaskScheduler, jobCampaign, jobArchivePurge, jobStatsSave} {
+		if !b.isLeader(job) {
+			t.Fatalf("node B did not take over %s", job)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package app
 
 import (
+	"BotMatrix/common/cluster"
 	"BotMatrix/common/config"
 	"BotMatrix/common/log"
+	"BotMatrix/common/metrics"
 	"BotMatrix/common/models"
 	"BotMatrix/common/onebot"
 	"BotMatrix/common/tasks"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"bytes"
@@ -23,6 +26,8 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -86,6 +91,7 @@ func (m *Manager) handleBotWebSocket(w http.ResponseWriter, r *http.Request) {
 	botKey := fmt.Sprintf("%s:%s", bot.Platform, bot.SelfID)
 	m.Bots[botKey] = bot
 	m.Mutex.Unlock()
+	m.claimConnection(cluster.KindBot, botKey)
 
 	// Update online status to online
 	if m.DigitalEmployeeService != nil {
@@ -170,6 +176,8 @@ func (m *Manager) fetchBotInfo(bot *types.BotClient) {
 					newKey := fmt.Sprintf("%s:%s", bot.Platform, newSelfID)
 					m.Bots[newKey] = bot
 					m.Mutex.Unlock()
+					m.releaseConnection(cluster.KindBot, oldKey)
+					m.claimConnection(cluster.KindBot, newKey)
 
 					bot.Mutex.Lock() // Re-lock
 					log.Printf("[Bot] Updated Bot ID from %s to %s via get_login_info", oldID, newSelfID)
@@ -311,7 +319,7 @@ func (m *Manager) handleBotConnection(bot *types.BotClient) {
 			log.Printf("[Nexus][%s:%s] Converted v11 to internal: message=%v, raw=%s", bot.Platform, bot.SelfID, internalMsg.Message, internalMsg.RawMessage)
 		}
 
-		m.handleBotMessage(bot, internalMsg)
+		m.handleTracedBotMessage(bot, internalMsg)
 
 		// Update activity time
 		bot.LastHeartbeat = time.Now()
@@ -372,10 +380,30 @@ func (m *Manager) sendWorkerHeartbeat(worker *types.WorkerClient, stop chan stru
 	}
 }
 
+// handleTracedBotMessage 为机器人上报的事件开始 Nexus 侧 span (延续适配器写入的 traceparent)，
+// 并把该 span 写回消息，后续路由、入队与转发都成为它的子 span
+func (m *Manager) handleTracedBotMessage(bot *types.BotClient, msg types.InternalMessage) {
+	if msg.PostType == "" || msg.PostType == "meta_event" {
+		m.handleBotMessage(bot, msg)
+		return
+	}
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), msg), "nexus.handle_message",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("botmatrix.platform", bot.Platform),
+			attribute.String("botmatrix.self_id", bot.SelfID),
+			attribute.String("botmatrix.post_type", msg.PostType),
+			attribute.String("botmatrix.message_type", msg.MessageType),
+		))
+	defer span.End()
+	tracing.Inject(ctx, &msg)
+	m.handleBotMessage(bot, msg)
+}
+
 // handleBotMessage handles Bot messages
 func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessage) {
 	// 1. Core plugin intercept
-	if allowed, reason, err := m.Core.ProcessMessage(msg); !allowed {
+	if allowed, reason, err := m.Core.ProcessMessage(&msg); !allowed {
 		log.Printf("[Core] Message blocked: %s (reason: %s)", bot.SelfID, reason)
 		if err != nil {
 			log.Printf("[Core] Error processing message: %v", err)
@@ -399,6 +427,18 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 		}
 	}
 
+	// 跨平台账号绑定指令，由 Nexus 直接回复，不转发给 Worker
+	if m.Core.IdentifyMessageType(msg) == "identity_bind" {
+		resp, err := m.Core.HandleIdentityBindCommand(msg)
+		if err != nil {
+			log.Printf("[Core] Identity bind command failed: %v", err)
+		}
+		if resp != "" {
+			m.sendBotMessage(bot, msg, resp)
+		}
+		return
+	}
+
 	// 2. Check if self_id is included and update (if current is temporary ID)
 	msgSelfID := msg.SelfID
 	if msgSelfID != "" {
@@ -412,6 +452,8 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 			newKey := fmt.Sprintf("%s:%s", bot.Platform, bot.SelfID)
 			m.Bots[newKey] = bot
 			m.Mutex.Unlock()
+			m.releaseConnection(cluster.KindBot, oldKey)
+			m.claimConnection(cluster.KindBot, newKey)
 			log.Printf("[Bot] Updated Bot ID from %s to %s", oldID, msgSelfID)
 		}
 	}
@@ -437,6 +479,7 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 				// Use | as separator: workerID|originalEcho
 				if parts := strings.Split(echo, "|"); len(parts) >= 2 {
 					workerID := parts[0]
+					metrics.WorkerRTT.WithLabelValues(metrics.Value(workerID)).Observe(rtt.Seconds())
 					m.Mutex.RLock()
 					for _, w := range m.Workers {
 						if w.ID == workerID {
@@ -488,6 +531,7 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 	m.Mutex.Lock()
 	bot.RecvCount++
 	m.Mutex.Unlock()
+	metrics.MessagesReceived.WithLabelValues(metrics.Platform(bot.Platform), metrics.Value(bot.SelfID)).Inc()
 
 	// Only log non-spammy message types to console
 	postTypeLower := strings.ToLower(postType)
@@ -538,7 +582,7 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 				return
 			}
 		} else {
-			if config.GlobalConfig.LogLevel == "DEBUG" {
+			if config.Current().LogLevel == "DEBUG" {
 				log.Printf("[Bot] Self-message %s skipping idempotency and rate limit checks", msgID)
 			}
 		}
@@ -574,6 +618,9 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 
 	if postType == "message" || postType == "message_sent" {
 		m.handleBotMessageEvent(bot, msg)
+		if m.Archive != nil {
+			m.Archive.Enqueue(msg)
+		}
 	}
 
 	// 5.5 Broadcast to subscribers (Web UI Monitor)
@@ -599,19 +646,7 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 			}
 
 			// 影子执行 (Shadow Mode): 如果有影子 Worker，额外推送一份
-			var shadowWorkerID string
-			if sID, ok := msg.Extras["shadow_worker_id"].(string); ok {
-				shadowWorkerID = sID
-			}
-
-			if shadowWorkerID != "" {
-				log.Printf("[Shadow] Pushing shadow copy to worker: %s", shadowWorkerID)
-				// 克隆消息并打上影子标记
-				shadowMsg := msg // Struct copy
-				// How to mark shadow? InternalMessage doesn't have IsShadow field.
-				// We can add it to Original if it's a map, but we'll use ToV11Map later anyway.
-				m.PushToRedisQueue(shadowWorkerID, shadowMsg)
-			}
+			m.dispatchShadowCopy(bot, msg, targetWorkerID, true)
 			return
 		}
 		log.Printf("[REDIS] Failed to push to queue: %v. Falling back to WebSocket direct forwarding.", err)
@@ -621,13 +656,7 @@ func (m *Manager) handleBotMessage(bot *types.BotClient, msg types.InternalMessa
 	m.forwardMessageToWorker(msg)
 
 	// 影子执行 (Shadow Mode) - Fallback 路径
-	var shadowWorkerID string
-	if sID, ok := msg.Extras["shadow_worker_id"].(string); ok {
-		shadowWorkerID = sID
-	}
-	if shadowWorkerID != "" {
-		m.forwardMessageToWorkerWithTarget(msg, shadowWorkerID)
-	}
+	m.dispatchShadowCopy(bot, msg, m.getTargetWorkerID(msg), false)
 }
 
 // enrichMessageWithCache supplements the message with cached group and user information
@@ -686,7 +715,13 @@ func (m *Manager) enrichMessageWithCache(msg *types.InternalMessage) {
 }
 
 // getTargetWorkerID helper method: Get target Worker ID based on core routing rules (Group/Bot/User)
-func (m *Manager) getTargetWorkerID(msg types.InternalMessage) string {
+func (m *Manager) getTargetWorkerID(msg types.InternalMessage) (workerID string) {
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), msg), "nexus.route_message")
+	defer func() {
+		span.SetAttributes(attribute.String("botmatrix.worker_id", workerID))
+		span.End()
+	}()
+
 	var matchKeys []string
 
 	// Extract match keys
@@ -702,7 +737,6 @@ func (m *Manager) getTargetWorkerID(msg types.InternalMessage) string {
 
 	// 1. Prioritize getting dynamic routing rules from Redis
 	if m.Rdb != nil {
-		ctx := context.Background()
 		for _, key := range matchKeys {
 			if wID, err := m.Rdb.HGet(ctx, config.REDIS_KEY_DYNAMIC_RULES, key).Result(); err == nil && wID != "" {
 				log.Printf("[REDIS] Dynamic route matched (Redis): %s -> %s", key, wID)
@@ -966,11 +1000,20 @@ func (m *Manager) sendBotMessage(bot *types.BotClient, originalMsg types.Interna
 	}
 
 	err := bot.Conn.WriteJSON(action)
+	recordBotAction(bot, action.Action, err)
 	if err != nil {
 		log.Printf("[Bot] Failed to send message to Bot %s: %v", bot.SelfID, err)
 	}
 }
 
+// recordBotAction 统计发送给机器人的消息类动作
+func recordBotAction(bot *types.BotClient, action string, err error) {
+	switch action {
+	case "send_msg", "send_group_msg", "send_private_msg":
+		metrics.MessagesSent.WithLabelValues(metrics.Platform(bot.Platform), metrics.Value(bot.SelfID), metrics.Status(err)).Inc()
+	}
+}
+
 // handleBotMessageEvent handles Bot message events
 func (m *Manager) handleBotMessageEvent(bot *types.BotClient, msg types.InternalMessage) {
 	// Extract message info
@@ -1108,16 +1151,20 @@ func (m *Manager) handleBotMessageEvent(bot *types.BotClient, msg types.Internal
 // removeBot removes Bot connection
 func (m *Manager) removeBot(botID string) {
 	m.Mutex.Lock()
-	defer m.Mutex.Unlock()
-
 	if _, exists := m.Bots[botID]; exists {
 		delete(m.Bots, botID)
 		log.Printf("Removed Bot %s from active connections", botID)
 	}
+	m.Mutex.Unlock()
+
+	// 超时检测可能已先移除连接，集群登记总是注销
+	m.releaseConnection(cluster.KindBot, botID)
 }
 
 // cacheMessage caches messages that cannot be processed immediately
 func (m *Manager) cacheMessage(msg types.InternalMessage) {
+	metrics.RoutingDecisions.WithLabelValues(metrics.RouteCached).Inc()
+
 	m.CacheMutex.Lock()
 	defer m.CacheMutex.Unlock()
 
@@ -1259,11 +1306,14 @@ func (m *Manager) forwardMessageToWorkerWithTarget(msg types.InternalMessage, ta
 			finalMsg = v11Map
 		}
 
+		span := m.startForwardSpan(msg, finalMsg, targetWorkerID)
 		w.Mutex.Lock()
 		err := w.Conn.WriteJSON(finalMsg)
 		w.Mutex.Unlock()
+		tracing.End(span, err)
 
 		if err == nil {
+			metrics.RoutingDecisions.WithLabelValues(metrics.RouteDirect).Inc()
 			m.Mutex.Lock()
 			w.HandledCount++
 			m.Mutex.Unlock()
@@ -1276,6 +1326,17 @@ func (m *Manager) forwardMessageToWorkerWithTarget(msg types.InternalMessage, ta
 	}
 }
 
+// startForwardSpan 开始经 WebSocket 直接转发给 Worker 的 span，并把追踪上下文写入下发的消息
+func (m *Manager) startForwardSpan(msg types.InternalMessage, finalMsg any, workerID string) trace.Span {
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), msg), "nexus.forward_to_worker",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("botmatrix.worker_id", workerID)))
+	if payload, ok := finalMsg.(map[string]any); ok {
+		tracing.InjectMap(ctx, payload)
+	}
+	return span
+}
+
 // forwardMessageToWorkerWithRetry message forwarding with retry limit
 func (m *Manager) forwardMessageToWorkerWithRetry(msg types.InternalMessage, retryCount int) {
 	if retryCount > 3 {
@@ -1308,11 +1369,14 @@ func (m *Manager) forwardMessageToWorkerWithRetry(msg types.InternalMessage, ret
 				finalMsg = v11Map
 			}
 
+			span := m.startForwardSpan(msg, finalMsg, targetWorkerID)
 			w.Mutex.Lock()
 			err := w.Conn.WriteJSON(finalMsg)
 			w.Mutex.Unlock()
+			tracing.End(span, err)
 
 			if err == nil {
+				metrics.RoutingDecisions.WithLabelValues(metrics.RouteRule).Inc()
 				m.WorkerRequestMutex.Lock()
 				m.WorkerRequestTimes[echo] = time.Now()
 				m.WorkerRequestMutex.Unlock()
@@ -1411,6 +1475,7 @@ func (m *Manager) forwardMessageToWorkerWithRetry(msg types.InternalMessage, ret
 		selectedWorker.Mutex.Unlock()
 
 		if err == nil {
+			metrics.RoutingDecisions.WithLabelValues(metrics.RouteLoadBalance).Inc()
 			m.WorkerRequestMutex.Lock()
 			m.WorkerRequestTimes[echo] = time.Now()
 			m.WorkerRequestMutex.Unlock()
@@ -1423,6 +1488,7 @@ func (m *Manager) forwardMessageToWorkerWithRetry(msg types.InternalMessage, ret
 			log.Printf("[ROUTING] Forwarded to worker %s (AvgRTT: %v, Handled: %d)", selectedWorker.ID, selectedWorker.AvgRTT, selectedWorker.HandledCount)
 		} else {
 			log.Printf("[ROUTING] [ERROR] Failed to forward to selected worker %s: %v. Removing and retrying...", selectedWorker.ID, err)
+			metrics.RoutingDecisions.WithLabelValues(metrics.RouteRetry).Inc()
 			m.removeWorker(selectedWorker.ID)
 			m.forwardMessageToWorkerWithRetry(msg, retryCount+1)
 		}
@@ -1470,6 +1536,7 @@ func (m *Manager) handleWorkerWebSocket(w http.ResponseWriter, r *http.Request)
 	m.Mutex.Lock()
 	m.Workers = append(m.Workers, worker)
 	m.Mutex.Unlock()
+	m.claimConnection(cluster.KindWorker, workerID)
 
 	// 广播 Worker 状态更新事件
 	go m.BroadcastEvent(types.WorkerUpdateEvent{
@@ -1834,6 +1901,11 @@ func (m *Manager) handleWorkerMessage(worker *types.WorkerClient, msg types.Work
 		m.forwardWorkerRequestToBot(worker, internalAction, echo)
 		return
 	} else if msgType == "skill_result" {
+		if msg.Status == "failed" {
+			if sm := m.shadowManager(); sm != nil && sm.IsShadowWorker(worker.ID) {
+				sm.ReportError(worker.ID, msg.Error)
+			}
+		}
 		if config.ENABLE_SKILL {
 			// 处理通过 WebSocket 上报的技能执行结果
 			skillResult := types.SkillResult{
@@ -1846,6 +1918,12 @@ func (m *Manager) handleWorkerMessage(worker *types.WorkerClient, msg types.Work
 			}
 			m.HandleSkillResult(skillResult)
 		}
+	} else if msgType == "tool_approval" {
+		// 高风险工具等待审批：推送给管理后台，由具有审批权限的管理员处理
+		if msg.Approval != nil {
+			log.Printf("Worker %s requested tool approval: run=%s tools=%d", worker.ID, msg.Approval.RunID, len(msg.Approval.Tools))
+			m.BroadcastEvent(msg.Approval)
+		}
 	} else {
 		log.Printf("Worker %s event/response: type=%s", worker.ID, msgType)
 
@@ -1861,6 +1939,7 @@ func (m *Manager) handleWorkerMessage(worker *types.WorkerClient, msg types.Work
 				duration := time.Since(startTime)
 				delete(m.WorkerRequestTimes, echo)
 				m.WorkerRequestMutex.Unlock()
+				metrics.WorkerProcessDuration.WithLabelValues(worker.ID).Observe(duration.Seconds())
 
 				worker.Mutex.Lock()
 				worker.LastProcessTime = duration
@@ -1950,10 +2029,24 @@ func (m *Manager) removeWorker(workerID string) {
 	m.Workers = newWorkers
 
 	log.Printf("Removed Worker %s from active connections", workerID)
+	go m.releaseConnection(cluster.KindWorker, workerID)
+
+	if sm := m.shadowManager(); sm != nil && sm.IsShadowWorker(workerID) {
+		go func() {
+			sm.ReportError(workerID, "worker disconnected")
+			sm.ReleaseWorker(workerID)
+		}()
+	}
 }
 
 // forwardWorkerRequestToBot forwards Worker request to Bot
 func (m *Manager) forwardWorkerRequestToBot(worker *types.WorkerClient, action types.InternalAction, originalEcho string) {
+	// 影子 Worker 的动作只记录不发送
+	if m.captureShadowAction(worker.ID, action) {
+		replyShadowCaptured(worker, originalEcho)
+		return
+	}
+
 	// Construct internal echo, including worker ID for tracking and recording RTT
 	// Add timestamp to ensure internalEcho is unique even if originalEcho is empty or duplicated
 	internalEcho := fmt.Sprintf("%s|%s|%d", worker.ID, originalEcho, time.Now().UnixNano())
@@ -2013,7 +2106,16 @@ func (m *Manager) forwardWorkerRequestToBot(worker *types.WorkerClient, action t
 		}
 	}
 
-	// 3. Fallback scheme: if still not found, select the first available Bot
+	// 3. In cluster mode the Bot may be connected to another node, forward through that node
+	if targetBot == nil && selfID != "" {
+		if key, owner := m.remoteBotOwner(botKeys(action.Platform, selfID)...); owner != "" {
+			m.Mutex.RUnlock()
+			m.forwardWorkerRequestToRemoteBot(worker, action, originalEcho, internalEcho, key, owner)
+			return
+		}
+	}
+
+	// 4. Fallback scheme: if still not found, select the first available Bot
 	if targetBot == nil {
 		for _, bot := range m.Bots {
 			targetBot = bot
@@ -2110,6 +2212,15 @@ func (m *Manager) forwardWorkerRequestToBot(worker *types.WorkerClient, action t
 		}()
 	}
 
+	// 出站动作 span，追踪上下文随动作下发给机器人
+	actionCtx, span := tracing.Start(tracing.ExtractAction(context.Background(), action), "nexus.send_action "+action.Action,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("botmatrix.worker_id", worker.ID),
+			attribute.String("botmatrix.self_id", targetBot.SelfID),
+		))
+	tracing.InjectAction(actionCtx, &action)
+
 	targetBot.Mutex.Lock()
 	var err error
 	if targetBot.Conn != nil {
@@ -2138,6 +2249,8 @@ func (m *Manager) forwardWorkerRequestToBot(worker *types.WorkerClient, action t
 		err = fmt.Errorf("bot connection is closed")
 	}
 	targetBot.Mutex.Unlock()
+	recordBotAction(targetBot, action.Action, err)
+	tracing.End(span, err)
 
 	// Broadcast outgoing routing events: Nexus -> Group -> User
 	if err == nil {
@@ -2222,6 +2335,7 @@ func (m *Manager) forwardWorkerRequestToBot(worker *types.WorkerClient, action t
 			fallbackBot.Mutex.Lock()
 			err = fallbackBot.Conn.WriteJSON(action)
 			fallbackBot.Mutex.Unlock()
+			recordBotAction(fallbackBot, action.Action, err)
 			if err == nil {
 				targetBot = fallbackBot
 			}
@@ -2502,9 +2616,16 @@ func (m *Manager) CheckRateLimit(userID, groupID string) bool {
 	ctx := context.Background()
 
 	// 1. Get dynamic rate limit configuration from local cache
-	// Default values: 20 per minute for users, 100 per minute for groups
+	// Default values: rate_limit in config.json, otherwise 20 per minute for users, 100 per minute for groups
 	userLimit := int64(20)
 	groupLimit := int64(100)
+	rl := config.Current().RateLimit
+	if rl.UserPerMinute > 0 {
+		userLimit = int64(rl.UserPerMinute)
+	}
+	if rl.GroupPerMinute > 0 {
+		groupLimit = int64(rl.GroupPerMinute)
+	}
 
 	m.ConfigCacheMu.RLock()
 	if val, ok := m.ConfigCache["ratelimit:user_limit_per_min"]; ok {
@@ -2544,6 +2665,7 @@ func (m *Manager) CheckRateLimit(userID, groupID string) bool {
 		}
 		if count > userLimit {
 			log.Printf("[RATELIMIT] User %s exceeded limit (%d/%d min)", userID, count, userLimit)
+			metrics.RateLimitRejections.WithLabelValues(metrics.ScopeUser).Inc()
 			return false
 		}
 	}
@@ -2557,6 +2679,7 @@ func (m *Manager) CheckRateLimit(userID, groupID string) bool {
 		}
 		if count > groupLimit {
 			log.Printf("[RATELIMIT] Group %s exceeded limit (%d/%d min)", groupID, count, groupLimit)
+			metrics.RateLimitRejections.WithLabelValues(metrics.ScopeGroup).Inc()
 			return false
 		}
 	}
@@ -2592,17 +2715,27 @@ func (m *Manager) PushToRedisQueue(targetWorkerID string, msg types.InternalMess
 	// 如果未来有纯 v12 的 Worker，可以根据 targetWorkerID 的协议动态选择
 	msgMap := msg.ToV11Map()
 
-	data, err := json.Marshal(msgMap)
-	if err != nil {
-		return err
-	}
-
-	ctx := context.Background()
 	queueKey := config.REDIS_KEY_QUEUE_DEFAULT
 	if targetWorkerID != "" {
 		queueKey = fmt.Sprintf(config.REDIS_KEY_QUEUE_WORKER, targetWorkerID)
 	}
 
+	// 入队 span 的追踪上下文随负载写入 Stream，Worker 消费时继续同一条 trace
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), msg), "redis.xadd "+queueKey,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", queueKey),
+		))
+	var err error
+	defer func() { tracing.End(span, err) }()
+	tracing.InjectMap(ctx, msgMap)
+
+	data, err := json.Marshal(msgMap)
+	if err != nil {
+		return err
+	}
+
 	// 使用指数退避重试策略 (最多 3 次)
 	var lastErr error
 	for i := 0; i < 3; i++ {
@@ -2622,7 +2755,8 @@ func (m *Manager) PushToRedisQueue(targetWorkerID string, msg types.InternalMess
 		time.Sleep(time.Duration(100*(i+1)) * time.Millisecond)
 	}
 
-	return fmt.Errorf("failed to push to redis after 3 attempts: %v", lastErr)
+	err = fmt.Errorf("failed to push to redis after 3 attempts: %v", lastErr)
+	return err
 }
 
 // UpdateContext 更新会话上下文 (支持更丰富的状态存储)
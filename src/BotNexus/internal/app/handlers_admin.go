@@ -1,15 +1,20 @@
 package app
 
 import (
+	"BotMatrix/common/archive"
 	"BotMatrix/common/bot"
+	"BotMatrix/common/campaign"
 	"BotMatrix/common/config"
 	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/secrets"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -96,6 +101,7 @@ func HandleLogin(m *bot.Manager) http.HandlerFunc {
 		var loginData struct {
 			Username string `json:"username"`
 			Password string `json:"password"`
+			TOTPCode string `json:"totp_code"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&loginData); err != nil {
@@ -123,6 +129,23 @@ func HandleLogin(m *bot.Manager) http.HandlerFunc {
 			return
 		}
 
+		// 强制 SSO 时仅保留默认 admin 账号作为应急入口
+		if ssoEnforced(m) && user.Username != "admin" {
+			w.WriteHeader(http.StatusForbidden)
+			utils.SendJSONResponse(w, false, utils.T(lang, "sso_required|请使用单点登录"), nil)
+			return
+		}
+
+		if enabled, ok := checkLoginTOTP(m, user.ID, loginData.TOTPCode); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			msg := "totp_invalid|动态验证码错误"
+			if loginData.TOTPCode == "" {
+				msg = "totp_required|请输入动态验证码"
+			}
+			utils.SendJSONResponse(w, false, utils.T(lang, msg), map[string]bool{"totp_required": enabled})
+			return
+		}
+
 		token, err := m.GenerateToken(user)
 		if err != nil {
 			log.Printf(utils.T("", "token_generation_failed|Token生成失败")+": %v", err)
@@ -224,7 +247,7 @@ func HandleTokenLogin(m *bot.Manager) http.HandlerFunc {
 			Username:       user.Username,
 			IsAdmin:        user.IsAdmin,
 			SessionVersion: 0, // 默认版本
-		}, config.GlobalConfig.JWTSecret)
+		}, config.Current().JWTSecret)
 		if err != nil {
 			utils.SendJSONResponse(w, false, utils.T(lang, "token_gen_failed"), nil)
 			return
@@ -267,6 +290,12 @@ func HandleRegister(m *bot.Manager) http.HandlerFunc {
 			return
 		}
 
+		if ssoEnforced(m) {
+			w.WriteHeader(http.StatusForbidden)
+			utils.SendJSONResponse(w, false, utils.T(lang, "sso_required|请使用单点登录"), nil)
+			return
+		}
+
 		if regData.Username == "" || regData.Password == "" {
 			utils.SendJSONResponse(w, false, "Username and password are required", nil)
 			return
@@ -913,8 +942,13 @@ func HandleGetBots(m *bot.Manager) http.HandlerFunc {
 			LastHeartbeat string `json:"last_heartbeat"`
 			IsAlive       bool   `json:"is_alive"`
 		}
+		// 经 RBAC 中间件进入时只返回有权查看的机器人
+		authz := rbac.FromContext(r.Context())
 		bots := make([]BotInfo, 0, len(m.Bots))
 		for id, bot := range m.Bots {
+			if authz != nil && !authz.Allowed(rbac.BotsRead, rbac.Scope{Type: rbac.ScopeBot, ID: bot.SelfID}) {
+				continue
+			}
 			remoteAddr := ""
 			if bot.Conn != nil {
 				remoteAddr = bot.Conn.RemoteAddr().String()
@@ -2310,56 +2344,65 @@ func HandleUpdateGroupSetup(m *bot.Manager) http.HandlerFunc {
 	}
 }
 
-// HandleGetMessages 获取最新消息列表
+// parseArchiveQuery 从请求参数解析消息归档查询条件，时间参数支持 RFC3339 或 Unix 秒
+func parseArchiveQuery(r *http.Request) (archive.Query, error) {
+	v := r.URL.Query()
+	q := archive.Query{
+		BotID:       v.Get("bot_id"),
+		Platform:    v.Get("platform"),
+		UserID:      v.Get("user_id"),
+		GroupID:     v.Get("group_id"),
+		MessageType: v.Get("type"),
+		Text:        v.Get("q"),
+		Cursor:      v.Get("cursor"),
+	}
+	if l, err := strconv.Atoi(v.Get("limit")); err == nil {
+		q.Limit = l
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		raw := v.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			*p.dst = time.Unix(sec, 0)
+		} else if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			*p.dst = t
+		} else {
+			return q, fmt.Errorf("invalid %s: %s", p.name, raw)
+		}
+	}
+	return q, nil
+}
+
+// HandleGetMessages 查询消息归档
 // @Summary 获取消息列表
-// @Description 从数据库获取最新的聊天消息记录，包括私聊和群聊
+// @Description 按机器人、平台、用户、群、时间范围与消息类型过滤归档消息，支持全文检索与游标分页
 // @Tags Admin
 // @Produce json
 // @Security BearerAuth
-// @Param limit query int false "获取的消息数量 (默认 50)"
+// @Param bot_id query string false "机器人 ID"
+// @Param platform query string false "平台"
+// @Param user_id query string false "用户 ID"
+// @Param group_id query string false "群 ID"
+// @Param type query string false "消息类型 (private, group)"
+// @Param since query string false "起始时间 (RFC3339 或 Unix 秒，包含)"
+// @Param until query string false "结束时间 (RFC3339 或 Unix 秒，不包含)"
+// @Param q query string false "全文检索关键词"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param limit query int false "每页数量 (默认 50，最大 500)"
 // @Success 200 {object} utils.JSONResponse "消息列表"
 // @Router /api/admin/messages [get]
-func HandleGetMessages(m *bot.Manager) http.HandlerFunc {
+func HandleGetMessages(m *Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limitStr := r.URL.Query().Get("limit")
-		limit := 50
-		if limitStr != "" {
-			if l, err := strconv.Atoi(limitStr); err == nil {
-				limit = l
-			}
-		}
-
-		if m.DB == nil {
-			utils.SendJSONResponse(w, false, "Database not initialized", struct {
-				Messages []interface{} `json:"messages"`
-			}{
-				Messages: []interface{}{},
-			})
-			return
-		}
-
-		query := `
-		SELECT id, message_id, bot_id, user_id, group_id, type, content, created_at
-		FROM messages
-		ORDER BY created_at DESC
-		LIMIT ?
-		`
-		rows, err := m.DB.Query(m.PrepareQuery(query), limit)
-		if err != nil {
-			log.Printf("获取最新消息失败: %v", err)
-			utils.SendJSONResponse(w, false, err.Error(), struct {
-				Messages []interface{} `json:"messages"`
-			}{
-				Messages: []interface{}{},
-			})
-			return
-		}
-		defer rows.Close()
-
 		type MessageInfo struct {
-			ID          int    `json:"id"`
+			ID          uint   `json:"id"`
 			MessageID   string `json:"message_id"`
 			BotID       string `json:"bot_id"`
+			Platform    string `json:"platform"`
 			UserID      string `json:"user_id"`
 			UserName    string `json:"user_name"`
 			UserAvatar  string `json:"user_avatar"`
@@ -2370,31 +2413,57 @@ func HandleGetMessages(m *bot.Manager) http.HandlerFunc {
 			Content     string `json:"content"`
 			CreatedAt   string `json:"created_at"`
 		}
+		type result struct {
+			Messages   []MessageInfo `json:"messages"`
+			NextCursor string        `json:"next_cursor,omitempty"`
+		}
 
-		messages := []MessageInfo{}
-		for rows.Next() {
-			var id int
-			var messageID, botID, userID, groupID, msgType, content string
-			var createdAt time.Time
-			err := rows.Scan(&id, &messageID, &botID, &userID, &groupID, &msgType, &content, &createdAt)
-			if err != nil {
-				log.Printf("扫描消息失败: %v", err)
-				continue
+		if m.Archive == nil {
+			utils.SendJSONResponse(w, false, "Database not initialized", result{Messages: []MessageInfo{}})
+			return
+		}
+
+		q, err := parseArchiveQuery(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, err.Error(), result{Messages: []MessageInfo{}})
+			return
+		}
+		page, err := m.Archive.Search(r.Context(), q)
+		if err != nil {
+			if err == archive.ErrInvalidCursor {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				log.Printf("查询消息归档失败: %v", err)
 			}
+			utils.SendJSONResponse(w, false, err.Error(), result{Messages: []MessageInfo{}})
+			return
+		}
+
+		messages := make([]MessageInfo, 0, len(page.Messages))
+		for _, msg := range page.Messages {
+			userID, groupID := msg.UserID, msg.GroupID
 
-			// 获取昵称和群名
-			userName := userID
-			groupName := groupID
+			// 获取昵称和群名，缓存优先，其次使用归档时记录的名称
+			userName := msg.UserName
+			if userName == "" {
+				userName = userID
+			}
+			groupName := msg.GroupName
+			if groupName == "" {
+				groupName = groupID
+			}
 			userAvatar := ""
 			groupAvatar := ""
 
-			m.Mutex.RLock()
-			bot, hasBot := m.Bots[botID]
-			platform := ""
-			if hasBot {
-				platform = bot.Platform
+			platform := msg.Platform
+			if platform == "" {
+				m.Mutex.RLock()
+				if bot, ok := m.Bots[msg.BotID]; ok {
+					platform = bot.Platform
+				}
+				m.Mutex.RUnlock()
 			}
-			m.Mutex.RUnlock()
 
 			m.CacheMutex.RLock()
 			if friend, ok := m.FriendCache[userID]; ok {
@@ -2412,7 +2481,6 @@ func HandleGetMessages(m *bot.Manager) http.HandlerFunc {
 					if a := member.Avatar; a != "" {
 						userAvatar = a
 					}
-					// 也可以尝试更新下昵称，如果之前没找到的话
 					if userName == userID {
 						if n := member.Nickname; n != "" {
 							userName = n
@@ -2432,26 +2500,131 @@ func HandleGetMessages(m *bot.Manager) http.HandlerFunc {
 			m.CacheMutex.RUnlock()
 
 			messages = append(messages, MessageInfo{
-				ID:          id,
-				MessageID:   messageID,
-				BotID:       botID,
+				ID:          msg.ID,
+				MessageID:   msg.MessageID,
+				BotID:       msg.BotID,
+				Platform:    platform,
 				UserID:      userID,
 				UserName:    userName,
 				UserAvatar:  GetAvatarURL(platform, userID, false, userAvatar),
 				GroupID:     groupID,
 				GroupName:   groupName,
 				GroupAvatar: GetAvatarURL(platform, groupID, true, groupAvatar),
-				Type:        msgType,
-				Content:     content,
-				CreatedAt:   createdAt.Format("2006-01-02 15:04:05"),
+				Type:        msg.MessageType,
+				Content:     msg.Content,
+				CreatedAt:   msg.CreatedAt.Local().Format("2006-01-02 15:04:05"),
 			})
 		}
 
-		utils.SendJSONResponse(w, true, "", struct {
-			Messages []MessageInfo `json:"messages"`
-		}{
-			Messages: messages,
-		})
+		utils.SendJSONResponse(w, true, "", result{Messages: messages, NextCursor: page.NextCursor})
+	}
+}
+
+// HandleExportMessages 导出会话消息
+// @Summary 导出会话
+// @Description 按时间正序导出一个群聊 (bot_id + group_id) 或私聊 (bot_id + user_id) 的全部归档消息
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param bot_id query string true "机器人 ID"
+// @Param group_id query string false "群 ID"
+// @Param user_id query string false "用户 ID (私聊)"
+// @Param since query string false "起始时间 (RFC3339 或 Unix 秒)"
+// @Param until query string false "结束时间 (RFC3339 或 Unix 秒)"
+// @Param format query string false "json (默认) 或 ndjson"
+// @Success 200 {file} file "会话消息"
+// @Router /api/admin/messages/export [get]
+func HandleExportMessages(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if m.Archive == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, "Database not initialized", nil)
+			return
+		}
+		q, err := parseArchiveQuery(r)
+		if err == nil && (q.BotID == "" || (q.GroupID == "" && q.UserID == "")) {
+			err = archive.ErrThreadRequired
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = archive.FormatJSON
+		}
+		if err == nil && format != archive.FormatJSON && format != archive.FormatNDJSON {
+			err = fmt.Errorf("unsupported format: %s", format)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format")+": "+err.Error(), nil)
+			return
+		}
+
+		thread := q.GroupID
+		if thread == "" {
+			thread = q.UserID
+		}
+		contentType := "application/json"
+		if format == archive.FormatNDJSON {
+			contentType = "application/x-ndjson"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("messages_%s_%s.%s", q.BotID, thread, format)))
+		if _, err := m.Archive.ExportThread(r.Context(), w, q, format); err != nil {
+			// 响应已开始写出，只能记录日志
+			log.Printf("导出会话消息失败: %v", err)
+		}
+	}
+}
+
+// HandleMessageRetention 查看或设置消息保留策略
+// @Summary 消息保留策略
+// @Description GET 返回默认保留天数与各机器人的策略；POST 设置机器人的保留天数 (0 永久保留，-1 删除策略)
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body object false "POST: {bot_id, days}"
+// @Success 200 {object} utils.JSONResponse "保留策略"
+// @Router /api/admin/messages/retention [get]
+// @Router /api/admin/messages/retention [post]
+func HandleMessageRetention(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if m.Archive == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, "Database not initialized", nil)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			policies, err := m.Archive.RetentionPolicies(r.Context())
+			if err != nil {
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", map[string]any{
+				"default_days": m.Archive.DefaultRetentionDays(),
+				"policies":     policies,
+			})
+		case http.MethodPost:
+			var req struct {
+				BotID string `json:"bot_id"`
+				Days  int    `json:"days"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.BotID == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+				return
+			}
+			if err := m.Archive.SetRetention(r.Context(), req.BotID, req.Days); err != nil {
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
 	}
 }
 
@@ -2983,7 +3156,7 @@ func HandleProxyAvatar(m *bot.Manager) http.HandlerFunc {
 
 // HandleBatchSend 处理批量发送消息
 // @Summary 批量发送消息
-// @Description 向多个目标（群或私聊）批量发送相同内容的群发消息
+// @Description 向多个目标（群或私聊）批量发送相同内容的群发消息；数据库可用时创建群发活动并返回 campaign_id，可通过 /api/admin/campaigns 跟踪进度
 // @Tags Admin
 // @Accept json
 // @Produce json
@@ -3052,6 +3225,38 @@ func HandleSendAction(m *Manager) http.HandlerFunc {
 				return
 			}
 
+			// 群发活动可用时创建立即发送的活动，按机器人限速投递并记录每个目标的投递状态
+			if m.Campaigns != nil {
+				spec := campaign.Spec{Name: "batch_send", Template: campaign.Literal(message)}
+				for _, t := range targets {
+					target, ok := t.(map[string]any)
+					if !ok {
+						continue
+					}
+					targetType := utils.ToString(target["type"])
+					if targetType != campaign.TargetPrivate && targetType != campaign.TargetGuild {
+						targetType = campaign.TargetGroup
+					}
+					spec.Audience.Targets = append(spec.Audience.Targets, campaign.Target{
+						BotID:   utils.ToString(target["bot_id"]),
+						Type:    targetType,
+						ID:      utils.ToString(target["id"]),
+						GuildID: utils.ToString(target["guild_id"]),
+					})
+				}
+				if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+					spec.CreatedBy = claims.Username
+				}
+				c, err := m.Campaigns.Create(r.Context(), spec)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					utils.SendJSONResponse(w, false, err.Error(), nil)
+					return
+				}
+				utils.SendJSONResponse(w, true, utils.T(lang, "batch_send_start", len(targets)), map[string]any{"campaign_id": c.ID})
+				return
+			}
+
 			go func() {
 				log.Printf("[BatchSend] Starting batch send for %d targets", len(targets))
 				success := 0
@@ -3337,7 +3542,7 @@ func HandleGetChatStats(m *bot.Manager) http.HandlerFunc {
 
 // HandleGetConfig 获取配置
 // @Summary 获取配置
-// @Description 获取当前应用的完整配置信息
+// @Description 获取当前应用的完整配置信息，密码、令牌与密钥等敏感字段以 "********" 返回 (secret:// 引用原样返回)
 // @Tags System
 // @Produce json
 // @Security BearerAuth
@@ -3345,13 +3550,17 @@ func HandleGetChatStats(m *bot.Manager) http.HandlerFunc {
 // @Router /api/admin/config [get]
 func HandleGetConfig(m *bot.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("[DEBUG] HandleGetConfig returning config: %+v", m.Config)
+		redacted := secrets.Redact(config.Current())
+		if config.Current().LogLevel == "DEBUG" {
+			data, _ := json.Marshal(redacted)
+			log.Printf("[DEBUG] HandleGetConfig returning config: %s", data)
+		}
 
 		utils.SendJSONResponse(w, true, "", struct {
-			Config *config.AppConfig `json:"config"`
-			Path   string            `json:"path"`
+			Config any    `json:"config"`
+			Path   string `json:"path"`
 		}{
-			Config: m.Config,
+			Config: redacted,
 			Path:   config.GetResolvedConfigPath(),
 		})
 	}
@@ -3359,51 +3568,83 @@ func HandleGetConfig(m *bot.Manager) http.HandlerFunc {
 
 // HandleUpdateConfig 更新配置
 // @Summary 更新配置
-// @Description 更新当前应用的全局配置信息并持久化到文件
+// @Description 以当前配置为基础合并提交的字段，校验通过后持久化到文件并立即生效；校验失败时返回不合法的字段路径，订阅者拒绝时整体回滚。敏感字段提交 "********" 表示保持原值
 // @Tags System
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param body body config.AppConfig true "新的配置对象"
-// @Success 200 {object} utils.JSONResponse "更新后的配置"
+// @Success 200 {object} utils.JSONResponse "更新后的配置与重载记录"
+// @Failure 400 {object} utils.JSONResponse "配置格式错误或校验失败"
 // @Router /api/admin/config [post]
 func HandleUpdateConfig(m *bot.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		lang := utils.GetLangFromRequest(r)
 
 		bodyBytes, _ := io.ReadAll(r.Body)
-		log.Printf("[DEBUG] HandleUpdateConfig received body: %s", string(bodyBytes))
-
-		// 使用当前配置作为基础，只更新提交的字段
-		updatedConfig := *m.Config
-		if err := json.Unmarshal(bodyBytes, &updatedConfig); err != nil {
-			log.Printf("[ERROR] HandleUpdateConfig decode error: %v", err)
-			w.WriteHeader(http.StatusBadRequest)
-			utils.SendJSONResponse(w, false, utils.T(lang, "config_format_error"), nil)
-			return
+		source := "api"
+		if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+			source = "api:" + claims.Username
 		}
 
-		log.Printf("[INFO] Updating config: LogLevel=%s, AutoReply=%v, EnableSkill=%v, PGPort=%d", updatedConfig.LogLevel, updatedConfig.AutoReply, updatedConfig.EnableSkill, updatedConfig.PGPort)
-
-		// 更新管理器中的配置
-		*m.Config = updatedConfig
+		// 管理界面回传的脱敏占位符保持原值
+		current, _ := json.Marshal(config.Current())
+		if restored, err := secrets.RestoreMasked(bodyBytes, current); err == nil {
+			bodyBytes = restored
+		}
 
-		if err := m.SaveConfig(); err != nil {
-			log.Printf("[ERROR] HandleUpdateConfig save error: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			utils.SendJSONResponse(w, false, fmt.Sprintf(utils.T(lang, "config_save_failed"), err), nil)
+		rec, err := config.Default().Update(bodyBytes, source)
+		if err != nil {
+			log.Printf("[ERROR] HandleUpdateConfig: %v", err)
+			var verr *config.ValidationError
+			switch {
+			case errors.As(err, &verr):
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, err.Error(), struct {
+					Fields []config.FieldError `json:"fields"`
+				}{Fields: verr.Fields})
+			case rec.Status == config.ReloadRejected:
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, utils.T(lang, "config_format_error"), nil)
+			case rec.Status == config.ReloadRolledBack:
+				w.WriteHeader(http.StatusConflict)
+				utils.SendJSONResponse(w, false, err.Error(), rec)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, fmt.Sprintf(utils.T(lang, "config_save_failed"), err), nil)
+			}
 			return
 		}
 
-		log.Printf("[INFO] Config updated successfully, file path: %s", config.GetResolvedConfigPath())
+		log.Printf("[INFO] Config updated successfully (v%d, %v), file path: %s", rec.Version, rec.Keys, config.GetResolvedConfigPath())
 		utils.SendJSONResponse(w, true, utils.T(lang, "config_updated"), struct {
-			Config *config.AppConfig `json:"config"`
+			Config any                 `json:"config"`
+			Reload config.ReloadRecord `json:"reload"`
 		}{
-			Config: m.Config,
+			Config: secrets.Redact(config.Current()),
+			Reload: rec,
 		})
 	}
 }
 
+// HandleConfigHistory 获取配置重载记录
+// @Summary 配置重载记录
+// @Description 返回最近的配置重载结果 (来源、变更字段、需要重启的字段、被拒绝或回滚的原因)，最新的在前
+// @Tags System
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.JSONResponse "重载记录"
+// @Router /api/admin/config/history [get]
+func HandleConfigHistory(w http.ResponseWriter, r *http.Request) {
+	utils.SendJSONResponse(w, true, "", struct {
+		Version int64                 `json:"version"`
+		History []config.ReloadRecord `json:"history"`
+	}{
+		Version: config.Default().Version(),
+		History: config.Default().History(),
+	})
+}
+
 // HandleGetRedisConfig 获取 Redis 动态配置
 // @Summary 获取 Redis 动态配置
 // @Description 获取存储在 Redis 中的限流、TTL 和路由规则等动态配置
@@ -3686,6 +3927,16 @@ func HandleAdminManageUsers(m *bot.Manager) http.HandlerFunc {
 			return
 		}
 
+		// 通过 users:manage 授权的非管理员不能授予管理员身份，也不能操作管理员账号
+		if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && !claims.IsAdmin {
+			target, exists := m.GetOrLoadUser(req.Username)
+			if req.IsAdmin || (exists && target.IsAdmin) {
+				w.WriteHeader(http.StatusForbidden)
+				utils.SendJSONResponse(w, false, utils.T(lang, "admin_required|需要管理员权限"), nil)
+				return
+			}
+		}
+
 		switch req.Action {
 		case "create":
 			handleAdminCreateUser(m, w, lang, req.Username, req.Password, req.IsAdmin, req.IsSuperPoints, req.QQ)
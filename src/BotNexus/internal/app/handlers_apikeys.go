@@ -0,0 +1,184 @@
+package app
+
+import (
+	"BotMatrix/common/apikey"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// apiKeyService 返回 API Key 服务，数据库未初始化时写入错误响应并返回 nil
+func apiKeyService(m *Manager, w http.ResponseWriter) *apikey.Service {
+	svc := m.APIKeys()
+	if svc == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		utils.SendJSONResponse(w, false, "Database is not initialized", nil)
+	}
+	return svc
+}
+
+func operatorName(r *http.Request) string {
+	if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok {
+		return claims.Username
+	}
+	return ""
+}
+
+// HandleServiceAccounts 服务账号管理：GET 列出，POST 创建，DELETE ?id= 删除并撤销其全部 Key
+func HandleServiceAccounts(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc := apiKeyService(m, w)
+		if svc == nil {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			accounts, err := svc.ServiceAccounts()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", accounts)
+		case http.MethodPost:
+			var req struct {
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, "Invalid request body", nil)
+				return
+			}
+			account, err := svc.CreateServiceAccount(req.Name)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Service account created", account)
+		case http.MethodDelete:
+			id, _ := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+			if err := svc.DeleteServiceAccount(uint(id)); err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Service account deleted", nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleAPIKeys Key 管理：GET ?account_id= 列出，POST 签发 (完整 Key 仅在响应中返回一次)，DELETE ?id= 撤销
+func HandleAPIKeys(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc := apiKeyService(m, w)
+		if svc == nil {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			accountID, _ := strconv.ParseUint(r.URL.Query().Get("account_id"), 10, 64)
+			keys, err := svc.Keys(uint(accountID))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", keys)
+		case http.MethodPost:
+			var req struct {
+				AccountID uint `json:"account_id"`
+				apikey.IssueOptions
+				ExpiresInDays int `json:"expires_in_days"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AccountID == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, "account_id is required", nil)
+				return
+			}
+			if req.ExpiresAt == nil && req.ExpiresInDays > 0 {
+				expires := time.Now().AddDate(0, 0, req.ExpiresInDays)
+				req.ExpiresAt = &expires
+			}
+			plaintext, key, err := svc.Issue(req.AccountID, req.IssueOptions, operatorName(r))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "API key issued, store it now: it will not be shown again", map[string]any{
+				"key":     plaintext,
+				"api_key": key,
+			})
+		case http.MethodDelete:
+			id, _ := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+			if err := svc.Revoke(uint(id)); err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "API key revoked", nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleRotateAPIKey 轮换 Key：签发新 Key，旧 Key 在 overlap_hours 后失效
+func HandleRotateAPIKey(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		svc := apiKeyService(m, w)
+		if svc == nil {
+			return
+		}
+		var req struct {
+			ID           uint `json:"id"`
+			OverlapHours int  `json:"overlap_hours"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "id is required", nil)
+			return
+		}
+		plaintext, key, err := svc.Rotate(req.ID, time.Duration(req.OverlapHours)*time.Hour, operatorName(r))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "API key rotated, store it now: it will not be shown again", map[string]any{
+			"key":     plaintext,
+			"api_key": key,
+		})
+	}
+}
+
+// HandleAPIKeyAudit 查询 Key 的调用审计：GET ?key_id=&limit=
+func HandleAPIKeyAudit(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc := apiKeyService(m, w)
+		if svc == nil {
+			return
+		}
+		keyID, _ := strconv.ParseUint(r.URL.Query().Get("key_id"), 10, 64)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		logs, err := svc.AuditLogs(uint(keyID), limit)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", logs)
+	}
+}
@@ -1,9 +1,11 @@
 package app
 
 import (
+	"BotMatrix/common/ai"
 	"BotMatrix/common/bot"
 	"BotMatrix/common/database"
 	"BotMatrix/common/models"
+	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"net/http"
 	"time"
@@ -30,6 +32,42 @@ func HandleToolAuditActions(m *bot.Manager) http.HandlerFunc {
 	}
 }
 
+// auditOperator 当前审批人
+func auditOperator(r *http.Request) string {
+	if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+		return claims.Username
+	}
+	return "admin"
+}
+
+// decideToolCall 记录审批结果；挂起的智能体运行由 AI 服务的审批轮询检测到后恢复执行
+func decideToolCall(w http.ResponseWriter, r *http.Request, log *models.ToolAuditLog, status, reason string) bool {
+	now := time.Now()
+	if log.ExpireTime != nil && now.After(*log.ExpireTime) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.JSONResponse{Code: 400, Message: "Approval request has expired"})
+		return false
+	}
+
+	// 条件更新，避免并发审批重复生效
+	res := database.GetDB().Model(&models.ToolAuditLog{}).
+		Where(&models.ToolAuditLog{Id: log.Id, Status: ai.ToolApprovalPending}).
+		Updates(map[string]any{
+			"Status":          status,
+			"ApprovedAt":      &now,
+			"ApprovedBy":      auditOperator(r),
+			"RejectionReason": reason,
+		})
+	if res.Error != nil {
+		utils.WriteJSON(w, http.StatusInternalServerError, utils.JSONResponse{Code: 500, Message: res.Error.Error()})
+		return false
+	}
+	if res.RowsAffected == 0 {
+		utils.WriteJSON(w, http.StatusConflict, utils.JSONResponse{Code: 409, Message: "Approval request was already decided"})
+		return false
+	}
+	return true
+}
+
 // handleGetToolAuditLogs 获取工具调用审计日志列表
 func handleGetToolAuditLogs(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
@@ -82,18 +120,12 @@ func handleApproveToolCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if log.Status != "PendingApproval" {
+	if log.Status != ai.ToolApprovalPending {
 		utils.WriteJSON(w, http.StatusBadRequest, utils.JSONResponse{Code: 400, Message: "Only logs in PendingApproval status can be approved"})
 		return
 	}
 
-	now := time.Now()
-	log.Status = "Approved"
-	log.ApprovedAt = &now
-	log.ApprovedBy = "admin"
-
-	if err := db.Save(&log).Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.JSONResponse{Code: 500, Message: err.Error()})
+	if !decideToolCall(w, r, &log, ai.ToolApprovalApproved, "") {
 		return
 	}
 
@@ -123,19 +155,12 @@ func handleRejectToolCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if log.Status != "PendingApproval" {
+	if log.Status != ai.ToolApprovalPending {
 		utils.WriteJSON(w, http.StatusBadRequest, utils.JSONResponse{Code: 400, Message: "Only logs in PendingApproval status can be rejected"})
 		return
 	}
 
-	now := time.Now()
-	log.Status = "Rejected"
-	log.ApprovedAt = &now
-	log.ApprovedBy = "admin"
-	log.RejectionReason = req.Reason
-
-	if err := db.Save(&log).Error; err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.JSONResponse{Code: 500, Message: err.Error()})
+	if !decideToolCall(w, r, &log, ai.ToolApprovalRejected, req.Reason) {
 		return
 	}
 
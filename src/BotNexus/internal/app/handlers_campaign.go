@@ -0,0 +1,169 @@
+package app
+
+import (
+	"BotMatrix/common/campaign"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// campaignError 将活动服务的错误映射为 HTTP 状态码
+func campaignError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, campaign.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, campaign.ErrInvalidState):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	utils.SendJSONResponse(w, false, err.Error(), nil)
+}
+
+// HandleCampaigns 列出或创建群发活动
+// @Summary 群发活动
+// @Description GET 按状态列出活动及投递进度；POST 圈选受众并创建活动 (name, template, vars, audience, send_at, time_zone, quiet_start, quiet_end, rate_per_minute)
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "活动状态 (GET)"
+// @Param body body object false "活动参数 (POST)"
+// @Success 200 {object} utils.JSONResponse "活动列表或新建的活动"
+// @Router /api/admin/campaigns [get]
+// @Router /api/admin/campaigns [post]
+func HandleCampaigns(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if m.Campaigns == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, "database not available", nil)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			list, err := m.Campaigns.List(r.Context(), r.URL.Query().Get("status"), limit)
+			if err != nil {
+				campaignError(w, err)
+				return
+			}
+			type item struct {
+				campaign.Campaign
+				Progress campaign.Progress `json:"progress"`
+			}
+			items := make([]item, 0, len(list))
+			for _, c := range list {
+				p, _ := m.Campaigns.Progress(r.Context(), c.ID)
+				items = append(items, item{Campaign: c, Progress: p})
+			}
+			utils.SendJSONResponse(w, true, "", items)
+		case http.MethodPost:
+			var spec campaign.Spec
+			if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+				return
+			}
+			if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+				spec.CreatedBy = claims.Username
+			}
+			c, err := m.Campaigns.Create(r.Context(), spec)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			p, _ := m.Campaigns.Progress(r.Context(), c.ID)
+			utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), map[string]any{"campaign": c, "progress": p})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleCampaignDetail 查看与控制单个群发活动
+// @Summary 群发活动详情与控制
+// @Description GET /{id} 返回活动与投递进度；GET /{id}/recipients 按状态分页列出接收者；POST /{id}/pause、/{id}/resume、/{id}/cancel 暂停、恢复或取消活动
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "活动 ID"
+// @Param status query string false "接收者投递状态 (queued, sent, failed, skipped)"
+// @Param offset query int false "接收者分页偏移"
+// @Param limit query int false "接收者分页大小 (默认 100)"
+// @Success 200 {object} utils.JSONResponse "活动详情"
+// @Router /api/admin/campaigns/{id} [get]
+// @Router /api/admin/campaigns/{id}/recipients [get]
+// @Router /api/admin/campaigns/{id}/{action} [post]
+func HandleCampaignDetail(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if m.Campaigns == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, "database not available", nil)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/campaigns/"), "/"), "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil || id <= 0 || len(parts) > 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+			return
+		}
+		sub := ""
+		if len(parts) == 2 {
+			sub = parts[1]
+		}
+		ctx := r.Context()
+
+		switch {
+		case r.Method == http.MethodGet && sub == "":
+			c, err := m.Campaigns.Get(ctx, uint(id))
+			if err != nil {
+				campaignError(w, err)
+				return
+			}
+			p, _ := m.Campaigns.Progress(ctx, c.ID)
+			var audience campaign.Segment
+			json.Unmarshal([]byte(c.Audience), &audience)
+			utils.SendJSONResponse(w, true, "", map[string]any{"campaign": c, "progress": p, "audience": audience})
+		case r.Method == http.MethodGet && sub == "recipients":
+			q := r.URL.Query()
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			list, err := m.Campaigns.Recipients(ctx, uint(id), q.Get("status"), offset, limit)
+			if err != nil {
+				campaignError(w, err)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", list)
+		case r.Method == http.MethodPost:
+			switch sub {
+			case "pause":
+				err = m.Campaigns.Pause(ctx, uint(id))
+			case "resume":
+				err = m.Campaigns.Resume(ctx, uint(id))
+			case "cancel":
+				err = m.Campaigns.Cancel(ctx, uint(id))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+				return
+			}
+			if err != nil {
+				campaignError(w, err)
+				return
+			}
+			utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
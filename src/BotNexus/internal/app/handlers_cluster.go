@@ -0,0 +1,54 @@
+package app
+
+import (
+	"BotMatrix/common/cluster"
+	"BotMatrix/common/utils"
+	"net/http"
+)
+
+// HandleClusterStatus 返回集群节点与单例任务的 leader
+// @Summary 集群状态
+// @Description 列出租约有效的 Nexus 节点及其持有的机器人与 Worker 连接、各单例任务的 leader 节点。未启用集群时 enabled 为 false
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.JSONResponse "集群状态"
+// @Router /api/admin/cluster [get]
+func HandleClusterStatus(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if m.Cluster == nil {
+			utils.SendJSONResponse(w, true, "", map[string]any{"enabled": false})
+			return
+		}
+		nodes, err := m.Cluster.Nodes(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		if nodes == nil {
+			nodes = []cluster.NodeInfo{}
+		}
+		leaders := make(map[string]string)
+		for _, job := range []string{jobTaskScheduler, jobCampaign, jobArchivePurge, jobStatsSave} {
+			leader, err := m.Cluster.Leader(r.Context(), job)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			leaders[job] = leader
+		}
+		utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), map[string]any{
+			"enabled": true,
+			"node_id": m.Cluster.ID(),
+			"nodes":   nodes,
+			"leaders": leaders,
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"BotMatrix/common/ai/employee"
+	"BotMatrix/common/models"
+	"BotMatrix/common/utils"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// experimentService 返回 A/B 实验服务 (共享护栏检查节流状态)
+func (m *Manager) experimentService() *employee.ExperimentService {
+	m.experimentsOnce.Do(func() {
+		m.experiments = employee.NewExperimentService(m.GORMDB)
+	})
+	return m.experiments
+}
+
+// HandleListExperiments 获取 A/B 实验列表及其变体
+// @Summary 获取 A/B 实验列表
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.JSONResponse "实验列表"
+// @Router /api/admin/experiments [get]
+func HandleListExperiments(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var exps []models.ABExperiment
+		query := m.GORMDB.Order("id DESC")
+		if status := r.URL.Query().Get("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		if err := query.Find(&exps).Error; err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+
+		result := make([]map[string]any, 0, len(exps))
+		for _, exp := range exps {
+			var variants []models.ABVariant
+			m.GORMDB.Where("experiment_id = ?", exp.ID).Order("id ASC").Find(&variants)
+			result = append(result, map[string]any{"experiment": exp, "variants": variants})
+		}
+		utils.SendJSONResponse(w, true, "", result)
+	}
+}
+
+// HandleSaveExperiment 创建或更新 A/B 实验及其变体
+// @Summary 保存 A/B 实验
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body object true "实验定义 (experiment, variants)"
+// @Success 200 {object} utils.JSONResponse "保存结果"
+// @Router /api/admin/experiments [post]
+func HandleSaveExperiment(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Experiment models.ABExperiment `json:"experiment"`
+			Variants   []models.ABVariant  `json:"variants"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		if req.Experiment.Name == "" || req.Experiment.TargetJobID == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "name and target_job_id are required", nil)
+			return
+		}
+		for _, v := range req.Variants {
+			if v.ConfigOverride != "" && !json.Valid([]byte(v.ConfigOverride)) {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, "invalid config_override for variant "+v.Name, nil)
+				return
+			}
+		}
+
+		err := m.GORMDB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&req.Experiment).Error; err != nil {
+				return err
+			}
+			for i := range req.Variants {
+				req.Variants[i].ExperimentID = req.Experiment.ID
+				if err := tx.Save(&req.Variants[i]).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "Saved", req)
+	}
+}
+
+// HandleUpdateExperimentStatus 手动启动/停止实验
+// @Summary 更新 A/B 实验状态
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body object true "状态参数 (id, status: running/halted, reason)"
+// @Success 200 {object} utils.JSONResponse "更新结果"
+// @Router /api/admin/experiments/status [post]
+func HandleUpdateExperimentStatus(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint   `json:"id"`
+			Status string `json:"status"`
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "Missing id", nil)
+			return
+		}
+
+		updates := map[string]any{"status": req.Status}
+		switch req.Status {
+		case "running":
+			now := time.Now()
+			updates["start_date"] = &now
+			updates["end_date"] = nil
+			updates["halt_reason"] = ""
+		case "halted":
+			now := time.Now()
+			updates["end_date"] = &now
+			updates["halt_reason"] = req.Reason
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "status must be running or halted", nil)
+			return
+		}
+
+		if err := m.GORMDB.Model(&models.ABExperiment{}).Where("id = ?", req.ID).Updates(updates).Error; err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "Updated", nil)
+	}
+}
+
+// HandleGetExperimentReport 获取实验统计报告 (各变体指标、置信区间与显著性)
+// @Summary 获取 A/B 实验报告
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id query int true "实验 ID"
+// @Success 200 {object} utils.JSONResponse "实验报告"
+// @Router /api/admin/experiments/report [get]
+func HandleGetExperimentReport(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := utils.ParseInt(r.URL.Query().Get("id"), 0)
+		if id <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "Missing id", nil)
+			return
+		}
+		report, err := m.experimentService().Analyze(uint(id))
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", report)
+	}
+}
+
+// HandlePromoteExperimentVariant 将胜出变体 (或指定变体) 发布到岗位模板并结束实验
+// @Summary 发布 A/B 实验胜出变体
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body object true "发布参数 (id, variant_id 可选)"
+// @Success 200 {object} utils.JSONResponse "更新后的岗位"
+// @Router /api/admin/experiments/promote [post]
+func HandlePromoteExperimentVariant(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID        uint `json:"id"`
+			VariantID uint `json:"variant_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "Missing id", nil)
+			return
+		}
+		job, err := m.experimentService().Promote(req.ID, req.VariantID)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "Promoted", job)
+	}
+}
+
+// HandleRecordExperimentFeedback 记录用户对数字员工回复的反馈评分，计入所在变体
+// @Summary 记录 A/B 实验用户反馈
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body object true "反馈参数 (bot_id, user_id, group_id, score)"
+// @Success 200 {object} utils.JSONResponse "命中的变体"
+// @Router /api/admin/experiments/feedback [post]
+func HandleRecordExperimentFeedback(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			BotID   string  `json:"bot_id"`
+			UserID  string  `json:"user_id"`
+			GroupID string  `json:"group_id"`
+			Score   float64 `json:"score"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.BotID == "" || req.UserID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "bot_id and user_id are required", nil)
+			return
+		}
+		assignment, err := m.experimentService().RecordFeedback(r.Context(), req.BotID, req.UserID, req.GroupID, req.Score)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "Recorded", assignment)
+	}
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// rbacService 返回授权服务，数据库未初始化时写入错误响应并返回 nil
+func rbacService(m *Manager, w http.ResponseWriter) *rbac.Service {
+	svc := m.RBAC()
+	if svc == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		utils.SendJSONResponse(w, false, "Database is not initialized", nil)
+	}
+	return svc
+}
+
+// HandleRBACPermissions 返回权限目录
+func HandleRBACPermissions(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.SendJSONResponse(w, true, "", rbac.Catalog)
+	}
+}
+
+// HandleRBACRoles 角色管理：GET 列出内置与自定义角色，POST 创建或更新自定义角色，DELETE ?name= 删除自定义角色
+func HandleRBACRoles(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc := rbacService(m, w)
+		if svc == nil {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			roles, err := svc.Roles()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", roles)
+		case http.MethodPost:
+			var role rbac.Role
+			if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, "Invalid request body", nil)
+				return
+			}
+			if err := svc.SaveRole(role); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Role saved", role)
+		case http.MethodDelete:
+			name := r.URL.Query().Get("name")
+			if err := svc.DeleteRole(name); err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, rbac.ErrRoleNotFound) {
+					status = http.StatusNotFound
+				}
+				w.WriteHeader(status)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Role deleted", nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleRBACBindings 授权管理：GET ?user_id= 列出授权，POST 授予角色，DELETE ?id= 撤销授权
+func HandleRBACBindings(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc := rbacService(m, w)
+		if svc == nil {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			userID, _ := strconv.ParseUint(r.URL.Query().Get("user_id"), 10, 64)
+			bindings, err := svc.Bindings(uint(userID))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", bindings)
+		case http.MethodPost:
+			var req struct {
+				UserID    uint   `json:"user_id"`
+				Role      string `json:"role"`
+				ScopeType string `json:"scope_type"`
+				ScopeID   string `json:"scope_id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == 0 || req.Role == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, "user_id and role are required", nil)
+				return
+			}
+			createdBy := ""
+			if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok {
+				createdBy = claims.Username
+			}
+			binding, err := svc.Bind(req.UserID, req.Role, rbac.Scope{Type: req.ScopeType, ID: req.ScopeID}, createdBy)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Role granted", binding)
+		case http.MethodDelete:
+			id, _ := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+			if err := svc.Unbind(uint(id)); err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Role revoked", nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleEffectivePermissions 返回当前用户的有效权限及生效范围；
+// 管理接口下可通过 ?user_id= 查看其他用户
+func HandleEffectivePermissions(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			utils.SendJSONResponse(w, false, "Unauthorized", nil)
+			return
+		}
+
+		userID, isAdmin := uint(claims.UserID), claims.IsAdmin
+		if id := r.URL.Query().Get("user_id"); id != "" {
+			if !rbac.FromContext(r.Context()).Allowed(rbac.RBACManage, rbac.Global) {
+				w.WriteHeader(http.StatusForbidden)
+				utils.SendJSONResponse(w, false, "Permission denied", nil)
+				return
+			}
+			var user models.User
+			if m.GORMDB == nil || m.GORMDB.First(&user, id).Error != nil {
+				w.WriteHeader(http.StatusNotFound)
+				utils.SendJSONResponse(w, false, "User not found", nil)
+				return
+			}
+			userID, isAdmin = user.ID, user.IsAdmin
+		}
+
+		authz, err := m.Authorize(&types.UserClaims{UserID: int64(userID), IsAdmin: isAdmin})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", map[string]any{
+			"user_id":     userID,
+			"is_admin":    isAdmin,
+			"permissions": authz.Effective(),
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"BotMatrix/common/secrets"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// secretError 将密钥存储的错误映射为 HTTP 状态码
+func secretError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, secrets.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, secrets.ErrInvalidName):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	utils.SendJSONResponse(w, false, err.Error(), nil)
+}
+
+// requireSecretStore 未配置主密钥时返回 503
+func requireSecretStore(w http.ResponseWriter, m *Manager) bool {
+	if m.Secrets == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		utils.SendJSONResponse(w, false, "secret store is not configured (set "+secrets.EnvMasterKey+")", nil)
+		return false
+	}
+	return true
+}
+
+// HandleSecrets 列出或写入密钥
+// @Summary 密钥存储
+// @Description GET 列出密钥元数据 (不返回明文)；POST 写入或覆盖密钥 (name, value, description)，返回可填入配置的 secret:// 引用
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param prefix query string false "密钥名前缀 (GET)"
+// @Param body body object false "密钥 (POST)"
+// @Success 200 {object} utils.JSONResponse "密钥列表或引用"
+// @Router /api/admin/secrets [get]
+// @Router /api/admin/secrets [post]
+func HandleSecrets(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if !requireSecretStore(w, m) {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			list, err := m.Secrets.List(r.Context(), r.URL.Query().Get("prefix"))
+			if err != nil {
+				secretError(w, err)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", list)
+		case http.MethodPost:
+			var req struct {
+				Name        string `json:"name"`
+				Value       string `json:"value"`
+				Description string `json:"description"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Value == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+				return
+			}
+			by := ""
+			if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+				by = claims.Username
+			}
+			if err := m.Secrets.Put(r.Context(), req.Name, req.Value, by); err != nil {
+				secretError(w, err)
+				return
+			}
+			if req.Description != "" {
+				m.GORMDB.Model(&secrets.Secret{}).Where("name = ?", req.Name).Update("description", req.Description)
+			}
+			utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), map[string]string{"ref": secrets.Ref(req.Name)})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// HandleDeleteSecret 删除密钥
+// @Summary 删除密钥
+// @Description 删除指定名称的密钥，仍引用该密钥的配置将无法解析
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "密钥名，可包含 /"
+// @Success 200 {object} utils.JSONResponse "删除成功"
+// @Router /api/admin/secrets/{name} [delete]
+func HandleDeleteSecret(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !requireSecretStore(w, m) {
+			return
+		}
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/secrets/"), "/")
+		if name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+			return
+		}
+		if err := m.Secrets.Delete(r.Context(), name); err != nil {
+			secretError(w, err)
+			return
+		}
+		utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), nil)
+	}
+}
+
+// HandleRotateSecrets 轮换主密钥
+// @Summary 轮换主密钥
+// @Description 用当前主密钥与新的数据密钥重新加密全部密钥。调用前将新主密钥放在 BOTMATRIX_MASTER_KEY 首位并保留旧密钥，完成后即可移除旧密钥
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.JSONResponse "重新加密的条数"
+// @Router /api/admin/secrets/rotate [post]
+func HandleRotateSecrets(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !requireSecretStore(w, m) {
+			return
+		}
+		n, err := m.Secrets.Rotate(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), map[string]int{"rotated": n})
+			return
+		}
+		utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), map[string]int{"rotated": n})
+	}
+}
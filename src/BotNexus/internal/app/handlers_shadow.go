@@ -0,0 +1,150 @@
+package app
+
+import (
+	"BotMatrix/common/tasks"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// shadowManager 返回影子执行管理器，任务系统未启用时为 nil
+func (m *Manager) shadowManager() *tasks.ShadowManager {
+	if m.TaskManager == nil || m.TaskManager.Interceptors == nil {
+		return nil
+	}
+	return m.TaskManager.Interceptors.Shadow
+}
+
+// dispatchShadowCopy 将命中影子规则的消息额外投递给影子 Worker，并开启对比会话
+func (m *Manager) dispatchShadowCopy(bot *types.BotClient, msg types.InternalMessage, primaryWorkerID string, viaRedis bool) {
+	sm := m.shadowManager()
+	shadowWorkerID, _ := msg.Extras["shadow_worker_id"].(string)
+	if sm == nil || shadowWorkerID == "" || shadowWorkerID == primaryWorkerID {
+		return
+	}
+	ruleID, _ := msg.Extras["shadow_rule_id"].(uint)
+
+	// 副本使用独立的 Extras，并打上影子标记，Worker 可据此跳过不可逆的外部调用
+	shadowMsg := msg
+	shadowMsg.Extras = make(map[string]any, len(msg.Extras))
+	for k, v := range msg.Extras {
+		shadowMsg.Extras[k] = v
+	}
+	delete(shadowMsg.Extras, "shadow_worker_id")
+	shadowMsg.Extras["is_shadow"] = true
+
+	log.Printf("[Shadow] Pushing shadow copy to worker: %s", shadowWorkerID)
+	if viaRedis {
+		if err := m.PushToRedisQueue(shadowWorkerID, shadowMsg); err != nil {
+			sm.ReportError(shadowWorkerID, err.Error())
+			return
+		}
+	} else {
+		if m.findWorkerByID(shadowWorkerID) == nil {
+			sm.ReportError(shadowWorkerID, "worker not connected")
+			return
+		}
+		m.forwardMessageToWorkerWithTarget(shadowMsg, shadowWorkerID)
+	}
+
+	sm.Begin(ruleID, primaryWorkerID, shadowWorkerID, &tasks.InterceptorContext{
+		Platform: bot.Platform,
+		SelfID:   bot.SelfID,
+		UserID:   msg.UserID,
+		GroupID:  msg.GroupID,
+		Message:  &msg,
+	})
+}
+
+// captureShadowAction 记录 Worker 发出的动作；影子 Worker 的动作被截获，返回 true。
+// workerID 为空 (如未携带 worker_id 的 Redis 动作) 时，落在进行中对比会话内的动作同样被截获
+func (m *Manager) captureShadowAction(workerID string, action types.InternalAction) bool {
+	sm := m.shadowManager()
+	if sm == nil {
+		return false
+	}
+
+	target := tasks.ShadowTarget{
+		SelfID:  action.SelfID,
+		GroupID: action.GroupID,
+		UserID:  action.UserID,
+	}
+	if target.SelfID == "" {
+		target.SelfID = utils.ToString(action.Params["self_id"])
+	}
+	if target.GroupID == "" {
+		target.GroupID = utils.ToString(action.Params["group_id"])
+	}
+	if target.UserID == "" {
+		target.UserID = utils.ToString(action.Params["user_id"])
+	}
+
+	message := utils.ToString(action.Message)
+	if message == "" {
+		message = utils.ToString(action.Params["message"])
+	}
+	return sm.RecordAction(workerID, target, tasks.ShadowAction{
+		Action:  action.Action,
+		Message: message,
+		Params:  action.Params,
+	})
+}
+
+// replyShadowCaptured 对被截获的影子动作返回模拟成功响应，避免影子 Worker 等待超时
+func replyShadowCaptured(worker *types.WorkerClient, echo string) {
+	response := types.InternalMessage{
+		Status: "ok",
+		Echo:   echo,
+		Extras: map[string]any{
+			"status":  "ok",
+			"retcode": 0,
+			"data": map[string]any{
+				"message_id": fmt.Sprintf("shadow_%d", time.Now().UnixNano()),
+			},
+		},
+	}
+
+	var finalResponse any
+	if worker.Protocol == "v12" {
+		finalResponse = response.ToV12Map()
+	} else {
+		finalResponse = response.ToV11Map()
+	}
+
+	worker.Mutex.Lock()
+	worker.Conn.WriteJSON(finalResponse)
+	worker.Mutex.Unlock()
+}
+
+// HandleGetShadowReport 获取影子规则的差异报告 (延迟、回复文本、动作)
+func HandleGetShadowReport(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sm := m.shadowManager()
+		if sm == nil {
+			utils.SendJSONResponse(w, false, "Task system is not enabled", nil)
+			return
+		}
+
+		ruleID := utils.ParseInt(r.URL.Query().Get("rule_id"), 0)
+		if ruleID <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "Missing rule_id", nil)
+			return
+		}
+
+		var since time.Time
+		if hours := utils.ParseInt(r.URL.Query().Get("hours"), 0); hours > 0 {
+			since = time.Now().Add(-time.Duration(hours) * time.Hour)
+		}
+
+		report, err := sm.Report(uint(ruleID), since, utils.ParseInt(r.URL.Query().Get("limit"), 50))
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", report)
+	}
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"BotMatrix/common/bot"
+	"BotMatrix/common/sso"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// oidcStateCookie 保存登录流程 state、nonce 与 PKCE verifier 的签名 Cookie
+const oidcStateCookie = "bm_oidc_state"
+
+// ssoEnforced 判断是否强制使用 SSO 登录
+func ssoEnforced(m *bot.Manager) bool {
+	return m.Config != nil && m.Config.OIDC.Enabled && m.Config.OIDC.Enforce
+}
+
+// HandleOIDCConfig 返回登录页需要的 SSO 配置
+func HandleOIDCConfig(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, _ := m.SSO()
+		utils.SendJSONResponse(w, true, "", map[string]any{
+			"enabled": p != nil,
+			"enforce": p != nil && p.Config().Enforce,
+		})
+	}
+}
+
+// HandleOIDCLogin 发起授权码 + PKCE 登录：GET /api/auth/oidc/login?redirect=/console
+func HandleOIDCLogin(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, _ := m.SSO()
+		if p == nil {
+			w.WriteHeader(http.StatusNotFound)
+			utils.SendJSONResponse(w, false, "SSO is not enabled", nil)
+			return
+		}
+		state, err := sso.NewLoginState(r.URL.Query().Get("redirect"), time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		authURL, err := p.AuthCodeURL(r.Context(), state)
+		if err != nil {
+			log.Printf("[SSO] %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+			utils.SendJSONResponse(w, false, "Identity provider is unavailable", nil)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    state.Encode(m.JWTSecret()),
+			Path:     "/api/auth/oidc",
+			MaxAge:   int(sso.StateTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(p.Config().RedirectURL, "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// HandleOIDCCallback 处理 IdP 回调：校验 state，换取并校验 ID Token，开通用户后签发 BotMatrix Token。
+// Token 通过 URL 片段交给前端 /auth/sso 页面，不会出现在服务端与代理的访问日志中
+func HandleOIDCCallback(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fail := func(reason string, err error) {
+			log.Printf("[SSO] Login failed: %s: %v", reason, err)
+			http.Redirect(w, r, "/login?sso_error="+url.QueryEscape(reason), http.StatusFound)
+		}
+		http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api/auth/oidc", MaxAge: -1})
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Cache-Control", "no-store")
+
+		p, svc := m.SSO()
+		if p == nil {
+			fail("sso_disabled", errors.New("SSO is not enabled"))
+			return
+		}
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			fail(e, errors.New(q.Get("error_description")))
+			return
+		}
+		cookie, err := r.Cookie(oidcStateCookie)
+		if err != nil {
+			fail("invalid_state", err)
+			return
+		}
+		state, err := sso.DecodeLoginState(cookie.Value, m.JWTSecret(), q.Get("state"), time.Now())
+		if err != nil {
+			fail("invalid_state", err)
+			return
+		}
+		tokens, err := p.Exchange(r.Context(), q.Get("code"), state.Verifier)
+		if err != nil {
+			fail("token_exchange_failed", err)
+			return
+		}
+		identity, err := p.VerifyIDToken(r.Context(), tokens.IDToken, state.Nonce)
+		if err != nil {
+			fail("invalid_id_token", err)
+			return
+		}
+		account, err := svc.Provision(identity)
+		if err != nil {
+			if errors.Is(err, sso.ErrUserDisabled) {
+				fail("user_disabled", err)
+			} else {
+				fail("provision_failed", err)
+			}
+			return
+		}
+		user, ok := m.ReloadUser(account.Username)
+		if !ok {
+			fail("provision_failed", errors.New("user not found after provisioning"))
+			return
+		}
+		token, err := m.GenerateToken(user)
+		if err != nil {
+			fail("token_generation_failed", err)
+			return
+		}
+		log.Printf("[SSO] User %s logged in via %s", user.Username, identity.Issuer)
+		http.Redirect(w, r, "/auth/sso?redirect="+url.QueryEscape(state.Redirect)+"#token="+url.QueryEscape(token), http.StatusFound)
+	}
+}
+
+// HandleOIDCBackchannelLogout 接收 IdP 的后端通道登出通知 (logout_token)，使对应用户的全部会话失效
+func HandleOIDCBackchannelLogout(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		p, svc := m.SSO()
+		if p == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		issuer, subject, sid, err := p.VerifyLogoutToken(r.Context(), r.FormValue("logout_token"))
+		if err != nil {
+			log.Printf("[SSO] Rejected logout token: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		users, err := svc.SessionUsers(issuer, subject, sid)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for _, u := range users {
+			if err := m.RevokeSessions(u.Username); err != nil {
+				log.Printf("[SSO] Failed to revoke sessions of %s: %v", u.Username, err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			log.Printf("[SSO] Sessions of %s revoked by back-channel logout", u.Username)
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// HandleLogout 登出：使当前用户已签发的全部 Token 失效。
+// SSO 用户同时返回 IdP 的登出地址，由前端跳转以结束 IdP 会话
+func HandleLogout(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims)
+		if !ok || claims.APIKeyID != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "Only user sessions can log out", nil)
+			return
+		}
+		if err := m.RevokeSessions(claims.Username); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		data := map[string]any{}
+		if p, svc := m.SSO(); p != nil && svc.Linked(uint(claims.UserID)) {
+			if u := p.EndSessionURL(r.Context()); u != "" {
+				data["end_session_url"] = u
+			}
+		}
+		utils.SendJSONResponse(w, true, "Logged out", data)
+	}
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"BotMatrix/common/bot"
+	"BotMatrix/common/models"
+	"BotMatrix/common/totp"
+	"BotMatrix/common/types"
+	"BotMatrix/common/utils"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// totpIssuer 验证器应用中显示的发行方名称
+const totpIssuer = "BotMatrix"
+
+// verifyTOTP 校验用户的动态验证码并记录时间步；条件更新保证同一验证码即使并发提交也只能使用一次
+func verifyTOTP(db *gorm.DB, user *models.User, code string) bool {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false
+	}
+	res := db.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", user.ID, step).Update("totp_last_step", step)
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// checkLoginTOTP 密码校验通过后检查第二因素，返回是否启用了 TOTP 以及验证码是否有效
+func checkLoginTOTP(m *bot.Manager, userID int64, code string) (enabled, ok bool) {
+	if m.GORMDB == nil {
+		return false, true
+	}
+	var user models.User
+	if err := m.GORMDB.Select("id", "totp_secret", "totp_enabled", "totp_last_step").First(&user, userID).Error; err != nil || !user.TOTPEnabled {
+		return false, true
+	}
+	if code == "" {
+		return true, false
+	}
+	return true, verifyTOTP(m.GORMDB, &user, code)
+}
+
+// HandleTOTP 当前用户的 TOTP 第二因素 (仅本地账号)：
+// GET 查询状态，POST {action: setup} 生成待验证密钥，POST {action: enable|disable, code} 启用或关闭
+func HandleTOTP(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims)
+		if !ok || claims.APIKeyID != 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			utils.SendJSONResponse(w, false, "Unauthorized", nil)
+			return
+		}
+		if m.GORMDB == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, "Database is not initialized", nil)
+			return
+		}
+		var user models.User
+		if err := m.GORMDB.First(&user, claims.UserID).Error; err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			utils.SendJSONResponse(w, false, "User not found", nil)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			utils.SendJSONResponse(w, true, "", map[string]bool{"enabled": user.TOTPEnabled})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if _, svc := m.SSO(); user.IsService || (svc != nil && svc.Linked(user.ID)) {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "TOTP is only available for local accounts", nil)
+			return
+		}
+
+		var req struct {
+			Action string `json:"action"`
+			Code   string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "Invalid request body", nil)
+			return
+		}
+
+		switch req.Action {
+		case "setup":
+			if user.TOTPEnabled {
+				w.WriteHeader(http.StatusConflict)
+				utils.SendJSONResponse(w, false, "TOTP is already enabled", nil)
+				return
+			}
+			secret, err := totp.GenerateSecret()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			if err := m.GORMDB.Model(&user).Updates(map[string]any{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "Scan the code and confirm with action=enable", map[string]string{
+				"secret":      secret,
+				"otpauth_url": totp.URL(totpIssuer, user.Username, secret),
+			})
+		case "enable", "disable":
+			if user.TOTPSecret == "" || (req.Action == "enable") == user.TOTPEnabled {
+				w.WriteHeader(http.StatusConflict)
+				utils.SendJSONResponse(w, false, "TOTP is not in a state that allows "+req.Action, nil)
+				return
+			}
+			if !verifyTOTP(m.GORMDB, &user, req.Code) {
+				w.WriteHeader(http.StatusForbidden)
+				utils.SendJSONResponse(w, false, "Invalid verification code", nil)
+				return
+			}
+			updates := map[string]any{"totp_enabled": true}
+			if req.Action == "disable" {
+				updates = map[string]any{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}
+			}
+			if err := m.GORMDB.Model(&user).Updates(updates).Error; err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "TOTP "+req.Action+"d", nil)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "action must be setup, enable or disable", nil)
+		}
+	}
+}
+
+// HandleResetUserTOTP 管理员为丢失验证器的用户关闭 TOTP：DELETE /api/admin/users/totp?username=
+func HandleResetUserTOTP(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		username := r.URL.Query().Get("username")
+		res := m.GORMDB.Model(&models.User{}).Where("username = ?", username).
+			Updates(map[string]any{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0})
+		if res.Error != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, res.Error.Error(), nil)
+			return
+		}
+		if res.RowsAffected == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			utils.SendJSONResponse(w, false, "User not found", nil)
+			return
+		}
+		m.RevokeSessions(username)
+		utils.SendJSONResponse(w, true, "TOTP reset", nil)
+	}
+}
@@ -16,30 +16,43 @@ import (
 	"BotMatrix/common/ai/employee"
 	"BotMatrix/common/ai/mcp"
 	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/ai/rag/ingest"
+	"BotMatrix/common/archive"
 	"BotMatrix/common/bot"
+	"BotMatrix/common/campaign"
+	"BotMatrix/common/cluster"
 	"BotMatrix/common/config"
 	clog "BotMatrix/common/log"
+	"BotMatrix/common/metrics"
 	"BotMatrix/common/middleware"
 	"BotMatrix/common/models"
 	"BotMatrix/common/plugin/core"
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/secrets"
 	"BotMatrix/common/tasks"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 
 	// "BotNexus/internal/config"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/botuniverse/go-libonebot"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -145,9 +158,16 @@ type Manager struct {
 	DigitalEmployeeKPIService  employee.DigitalEmployeeKPIService
 	CognitiveMemoryService     employee.CognitiveMemoryService
 	KnowledgeBase              types.KnowledgeBase
+	Archive                    *archive.Service
+	Campaigns                  *campaign.Service
+	Secrets                    *secrets.Store
+	Cluster                    *cluster.Node
 	pendingSkillRes            sync.Map // map[string]chan any
 	MCPManager                 *mcp.MCPManager
 
+	experimentsOnce sync.Once
+	experiments     *employee.ExperimentService
+
 	// 测试钩子
 	OnCommandSent func(workerID string, msg types.WorkerCommand)
 }
@@ -171,8 +191,21 @@ func Run() {
 	// 初始化配置 (优先从当前目录 config.json 加载)
 	config.InitConfig("config.json")
 
-	// 初始化日志系统
+	// 初始化日志系统，级别随 log_level 热更新
 	clog.InitDefaultLogger()
+	if err := clog.SetLevel(config.Current().LogLevel); err != nil {
+		clog.Warn("日志级别配置无效，使用 info", zap.Error(err))
+	}
+	config.OnChange("log_level", func(ev config.ChangeEvent) error {
+		return clog.SetLevel(ev.New.LogLevel)
+	})
+
+	// 初始化分布式追踪 (tracing.exporter 为空时只透传上游追踪上下文)
+	if shutdown, err := tracing.Init(context.Background(), tracing.FromConfig("BotNexus", config.GlobalConfig.Tracing)); err != nil {
+		clog.Error("分布式追踪初始化失败", zap.Error(err))
+	} else {
+		defer shutdown(context.Background())
+	}
 
 	// 初始化翻译器
 	utils.InitTranslator("locales", "zh-CN")
@@ -194,6 +227,17 @@ func Run() {
 		clog.Error("加载中心插件失败", zap.Error(err))
 	}
 
+	// 退出时主动离开集群，其他节点无需等待租约过期即可接管单例任务
+	if manager.Cluster != nil {
+		go func() {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+			<-sig
+			manager.Cluster.Stop()
+			os.Exit(0)
+		}()
+	}
+
 	// 启动超时检测 (Nexus 维护连接状态)
 	go manager.StartWorkerTimeoutDetection()
 	go manager.StartBotTimeoutDetection()
@@ -207,6 +251,13 @@ func Run() {
 	// 启动配置缓存刷新 (从 Redis 同步 RateLimit 和 TTL 配置)
 	go manager.StartConfigCacheRefresh()
 
+	// 监听 config.json，变更校验通过后热更新
+	go func() {
+		if err := config.Default().Watch(context.Background()); err != nil {
+			clog.Warn("配置文件监听启动失败，修改配置需通过管理接口或重启", zap.Error(err))
+		}
+	}()
+
 	// 统计信息重置和保存已迁移至 BotWorker
 	// go manager.StartPeriodicStatsSave()
 
@@ -231,16 +282,24 @@ func Run() {
 	mux.HandleFunc("/api/login", HandleLogin(manager.Manager))
 	mux.HandleFunc("/api/register", HandleRegister(manager.Manager))
 	mux.HandleFunc("/api/auth/token-login", HandleTokenLogin(manager.Manager))
+	mux.HandleFunc("/api/auth/oidc/config", HandleOIDCConfig(manager))
+	mux.HandleFunc("/api/auth/oidc/login", HandleOIDCLogin(manager))
+	mux.HandleFunc("/api/auth/oidc/callback", HandleOIDCCallback(manager))
+	mux.HandleFunc("/api/auth/oidc/backchannel-logout", HandleOIDCBackchannelLogout(manager))
+	mux.HandleFunc("/api/logout", manager.JWTMiddleware(HandleLogout(manager)))
 	mux.HandleFunc("/api/proxy/avatar", HandleProxyAvatar(manager.Manager))
+	mux.Handle("/metrics", metrics.Handler(manager.Config.MetricsToken))
 
 	// AI 相关的请求转发给 Worker 处理 (Nexus 仅做代理)
 	mux.HandleFunc("/api/ai/chat/stream", manager.SkillMiddleware(ai.HandleAIChatStream(manager)))
 	mux.HandleFunc("/api/ai/", manager.handleWorkerProxy)
 	mux.HandleFunc("/api/knowledge/", manager.handleWorkerProxy)
-	mux.HandleFunc("/api/admin/ai/", manager.handleWorkerProxy)
-	mux.HandleFunc("/api/admin/employees", manager.handleWorkerProxy)
-	mux.HandleFunc("/api/admin/departments", manager.handleWorkerProxy)
-	mux.HandleFunc("/api/admin/role-templates", manager.handleWorkerProxy)
+	mux.HandleFunc("/api/knowledge/jobs", manager.JWTMiddleware(ai.HandleKnowledgeJobs(manager)))
+	mux.HandleFunc("/api/knowledge/jobs/retry/", manager.JWTMiddleware(ai.HandleKnowledgeJobRetry(manager)))
+	mux.HandleFunc("/api/admin/ai/", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, manager.handleWorkerProxy))
+	mux.HandleFunc("/api/admin/employees", manager.RequireReadWrite(rbac.EmployeesRead, rbac.EmployeesManage, manager.handleWorkerProxy))
+	mux.HandleFunc("/api/admin/departments", manager.RequireReadWrite(rbac.EmployeesRead, rbac.EmployeesManage, manager.handleWorkerProxy))
+	mux.HandleFunc("/api/admin/role-templates", manager.RequireReadWrite(rbac.EmployeesRead, rbac.EmployeesManage, manager.handleWorkerProxy))
 
 	// --- 2. 普通用户接口 (仅需 JWT 认证) ---
 	// 用户个人信息
@@ -248,6 +307,8 @@ func Run() {
 	mux.HandleFunc("/api/user/info", manager.JWTMiddleware(HandleGetUserInfo(manager.Manager)))
 	mux.HandleFunc("/api/user/profile", manager.JWTMiddleware(HandleUpdateUserProfile(manager.Manager)))
 	mux.HandleFunc("/api/user/password", manager.JWTMiddleware(HandleChangePassword(manager.Manager)))
+	mux.HandleFunc("/api/me/permissions", manager.JWTMiddleware(HandleEffectivePermissions(manager)))
+	mux.HandleFunc("/api/me/totp", manager.JWTMiddleware(HandleTOTP(manager)))
 
 	// 基础统计与状态 (用户可见版本)
 	mux.HandleFunc("/api/stats", manager.JWTMiddleware(HandleGetStats(manager.Manager)))
@@ -259,8 +320,8 @@ func Run() {
 	mux.HandleFunc("/api/bots", manager.JWTMiddleware(HandleGetBots(manager.Manager)))
 	mux.HandleFunc("/api/workers", manager.JWTMiddleware(HandleGetWorkers(manager.Manager)))
 	mux.HandleFunc("/api/contacts", manager.JWTMiddleware(HandleGetContacts(manager.Manager)))
-	mux.HandleFunc("/api/action", manager.JWTMiddleware(HandleSendAction(manager)))
-	mux.HandleFunc("/api/smart_action", manager.JWTMiddleware(HandleSendAction(manager)))
+	mux.HandleFunc("/api/action", manager.RequireKeyPermission(rbac.MessagesSend, HandleSendAction(manager)))
+	mux.HandleFunc("/api/smart_action", manager.RequireKeyPermission(rbac.MessagesSend, HandleSendAction(manager)))
 
 	// 任务与能力
 	mux.HandleFunc("/api/tasks", manager.JWTMiddleware(manager.SkillMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -277,15 +338,16 @@ func Run() {
 	mux.HandleFunc("/api/system/capabilities", manager.JWTMiddleware(manager.SkillMiddleware(common.HandleGetCapabilities(manager.Manager))))
 	mux.HandleFunc("/api/tags", manager.JWTMiddleware(manager.SkillMiddleware(common.HandleManageTags(manager.Manager))))
 
-	// --- 3. 管理员接口 (需 Admin 权限) ---
+	// --- 3. 管理员接口 (按 RBAC 权限校验，is_admin 用户拥有全部权限) ---
 	// 系统监控与统计 (高权限版本)
-	mux.HandleFunc("/api/admin/stats", manager.AdminMiddleware(HandleGetStats(manager.Manager)))
-	mux.HandleFunc("/api/admin/system/stats", manager.AdminMiddleware(HandleGetSystemStats(manager.Manager)))
-	mux.HandleFunc("/api/admin/nexus/status", manager.AdminMiddleware(HandleGetNexusStatus(manager.Manager)))
+	mux.HandleFunc("/api/admin/stats", manager.RequirePermission(rbac.SystemRead, HandleGetStats(manager.Manager)))
+	mux.HandleFunc("/api/admin/system/stats", manager.RequirePermission(rbac.SystemRead, HandleGetSystemStats(manager.Manager)))
+	mux.HandleFunc("/api/admin/nexus/status", manager.RequirePermission(rbac.SystemRead, HandleGetNexusStatus(manager.Manager)))
+	mux.HandleFunc("/api/admin/cluster", manager.RequirePermission(rbac.SystemRead, HandleClusterStatus(manager)))
 
 	// 资源管理
-	mux.HandleFunc("/api/admin/bots", manager.AdminMiddleware(HandleGetBots(manager.Manager)))
-	mux.HandleFunc("/api/admin/setup/members", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/bots", manager.RequireScopedPermission(rbac.BotsRead, HandleGetBots(manager.Manager)))
+	mux.HandleFunc("/api/admin/setup/members", manager.RequireReadWrite(rbac.BotsRead, rbac.BotsManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleGetMemberSetup(manager.Manager)(w, r)
@@ -295,7 +357,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/setup/groups", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/setup/groups", manager.RequireReadWrite(rbac.BotsRead, rbac.BotsManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleGetGroupSetup(manager.Manager)(w, r)
@@ -305,8 +367,8 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/workers", manager.AdminMiddleware(HandleGetWorkers(manager.Manager)))
-	mux.HandleFunc("/api/admin/users", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/workers", manager.RequirePermission(rbac.SystemRead, HandleGetWorkers(manager.Manager)))
+	mux.HandleFunc("/api/admin/users", manager.RequirePermission(rbac.UsersManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleAdminListUsers(manager.Manager)(w, r)
@@ -316,25 +378,40 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
+	mux.HandleFunc("/api/admin/users/totp", manager.RequirePermission(rbac.UsersManage, HandleResetUserTOTP(manager)))
+
+	// 角色与授权
+	mux.HandleFunc("/api/admin/rbac/permissions", manager.RequirePermission(rbac.RBACManage, HandleRBACPermissions(manager)))
+	mux.HandleFunc("/api/admin/rbac/roles", manager.RequirePermission(rbac.RBACManage, HandleRBACRoles(manager)))
+	mux.HandleFunc("/api/admin/rbac/bindings", manager.RequirePermission(rbac.RBACManage, HandleRBACBindings(manager)))
+	mux.HandleFunc("/api/admin/rbac/effective", manager.RequirePermission(rbac.RBACManage, HandleEffectivePermissions(manager)))
+	mux.HandleFunc("/api/admin/service-accounts", manager.RequirePermission(rbac.APIKeysManage, HandleServiceAccounts(manager)))
+	mux.HandleFunc("/api/admin/api-keys", manager.RequirePermission(rbac.APIKeysManage, HandleAPIKeys(manager)))
+	mux.HandleFunc("/api/admin/api-keys/rotate", manager.RequirePermission(rbac.APIKeysManage, HandleRotateAPIKey(manager)))
+	mux.HandleFunc("/api/admin/api-keys/audit", manager.RequirePermission(rbac.APIKeysManage, HandleAPIKeyAudit(manager)))
 
 	// 消息与联系人管理
-	mux.HandleFunc("/api/admin/contacts", manager.AdminMiddleware(HandleGetContacts(manager.Manager)))
-	mux.HandleFunc("/api/admin/contacts/sync", manager.AdminMiddleware(HandleGetContacts(manager.Manager)))
-	mux.HandleFunc("/api/admin/group/members", manager.AdminMiddleware(HandleGetGroupMembers(manager.Manager)))
-	mux.HandleFunc("/api/admin/messages", manager.AdminMiddleware(HandleGetMessages(manager.Manager)))
-	mux.HandleFunc("/api/admin/batch_send", manager.AdminMiddleware(HandleBatchSend(manager)))
+	mux.HandleFunc("/api/admin/contacts", manager.RequirePermission(rbac.BotsRead, HandleGetContacts(manager.Manager)))
+	mux.HandleFunc("/api/admin/contacts/sync", manager.RequirePermission(rbac.BotsRead, HandleGetContacts(manager.Manager)))
+	mux.HandleFunc("/api/admin/group/members", manager.RequirePermission(rbac.BotsRead, HandleGetGroupMembers(manager.Manager)))
+	mux.HandleFunc("/api/admin/messages", manager.RequirePermission(rbac.MessagesRead, HandleGetMessages(manager)))
+	mux.HandleFunc("/api/admin/messages/export", manager.RequirePermission(rbac.MessagesRead, HandleExportMessages(manager)))
+	mux.HandleFunc("/api/admin/messages/retention", manager.RequireReadWrite(rbac.MessagesRead, rbac.SystemManage, HandleMessageRetention(manager)))
+	mux.HandleFunc("/api/admin/batch_send", manager.RequirePermission(rbac.MessagesSend, HandleBatchSend(manager)))
+	mux.HandleFunc("/api/admin/campaigns", manager.RequireReadWrite(rbac.MessagesRead, rbac.MessagesSend, HandleCampaigns(manager)))
+	mux.HandleFunc("/api/admin/campaigns/", manager.RequireReadWrite(rbac.MessagesRead, rbac.MessagesSend, HandleCampaignDetail(manager)))
 
 	// 系统日志
-	mux.HandleFunc("/api/admin/logs", manager.AdminMiddleware(HandleGetLogs(manager.Manager)))
-	mux.HandleFunc("/api/admin/logs/clear", manager.AdminMiddleware(HandleClearLogs(manager.Manager)))
+	mux.HandleFunc("/api/admin/logs", manager.RequirePermission(rbac.SystemRead, HandleGetLogs(manager.Manager)))
+	mux.HandleFunc("/api/admin/logs/clear", manager.RequirePermission(rbac.SystemManage, HandleClearLogs(manager.Manager)))
 
 	// 数字员工审计与审批
-	mux.HandleFunc("/api/admin/audit/tools", manager.AdminMiddleware(HandleToolAuditActions(manager.Manager)))
-	mux.HandleFunc("/api/admin/audit/tools/approve", manager.AdminMiddleware(HandleToolAuditActions(manager.Manager)))
-	mux.HandleFunc("/api/admin/audit/tools/reject", manager.AdminMiddleware(HandleToolAuditActions(manager.Manager)))
+	mux.HandleFunc("/api/admin/audit/tools", manager.RequireReadWrite(rbac.AuditRead, rbac.AuditApprove, HandleToolAuditActions(manager.Manager)))
+	mux.HandleFunc("/api/admin/audit/tools/approve", manager.RequirePermission(rbac.AuditApprove, HandleToolAuditActions(manager.Manager)))
+	mux.HandleFunc("/api/admin/audit/tools/reject", manager.RequirePermission(rbac.AuditApprove, HandleToolAuditActions(manager.Manager)))
 
 	// 系统配置
-	mux.HandleFunc("/api/admin/config", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/config", manager.RequireReadWrite(rbac.ConfigRead, rbac.ConfigWrite, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleGetConfig(manager.Manager)(w, r)
@@ -344,7 +421,11 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/redis/config", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/config/history", manager.RequirePermission(rbac.ConfigRead, HandleConfigHistory))
+	mux.HandleFunc("/api/admin/secrets", manager.RequireReadWrite(rbac.ConfigRead, rbac.ConfigWrite, HandleSecrets(manager)))
+	mux.HandleFunc("/api/admin/secrets/rotate", manager.RequirePermission(rbac.SystemManage, HandleRotateSecrets(manager)))
+	mux.HandleFunc("/api/admin/secrets/", manager.RequirePermission(rbac.ConfigWrite, HandleDeleteSecret(manager)))
+	mux.HandleFunc("/api/admin/redis/config", manager.RequireReadWrite(rbac.ConfigRead, rbac.ConfigWrite, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleGetRedisConfig(manager.Manager)(w, r)
@@ -354,7 +435,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/routing", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/routing", manager.RequireReadWrite(rbac.ConfigRead, rbac.ConfigWrite, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleGetRoutingRules(manager.Manager)(w, r)
@@ -366,22 +447,71 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/manual", manager.AdminMiddleware(HandleGetManual(manager.Manager)))
+	mux.HandleFunc("/api/admin/manual", manager.RequirePermission(rbac.SystemRead, HandleGetManual(manager.Manager)))
+
+	// 数字员工 A/B 实验
+	mux.HandleFunc("/api/admin/experiments", manager.RequireReadWrite(rbac.EmployeesRead, rbac.EmployeesManage, manager.DigitalEmployeeMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			HandleListExperiments(manager)(w, r)
+		case http.MethodPost:
+			HandleSaveExperiment(manager)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.HandleFunc("/api/admin/experiments/status", manager.RequirePermission(rbac.EmployeesManage, manager.DigitalEmployeeMiddleware(HandleUpdateExperimentStatus(manager))))
+	mux.HandleFunc("/api/admin/experiments/report", manager.RequirePermission(rbac.EmployeesRead, manager.DigitalEmployeeMiddleware(HandleGetExperimentReport(manager))))
+	mux.HandleFunc("/api/admin/experiments/promote", manager.RequirePermission(rbac.EmployeesManage, manager.DigitalEmployeeMiddleware(HandlePromoteExperimentVariant(manager))))
+	mux.HandleFunc("/api/admin/experiments/feedback", manager.RequirePermission(rbac.EmployeesManage, manager.DigitalEmployeeMiddleware(HandleRecordExperimentFeedback(manager))))
+
+	// 跨平台身份
+	mux.HandleFunc("/api/admin/identities", manager.RequireReadWrite(rbac.IdentitiesRead, rbac.IdentitiesWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			common.HandleListIdentities(manager.Manager)(w, r)
+		case http.MethodPost:
+			common.HandleSaveIdentity(manager.Manager)(w, r)
+		case http.MethodDelete:
+			common.HandleDeleteIdentity(manager.Manager)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/admin/identities/accounts", manager.RequirePermission(rbac.IdentitiesRead, common.HandleGetIdentityAccounts(manager.Manager)))
+	mux.HandleFunc("/api/admin/identities/merge", manager.RequirePermission(rbac.IdentitiesWrite, common.HandleMergeIdentities(manager.Manager)))
+	mux.HandleFunc("/api/admin/identities/split", manager.RequirePermission(rbac.IdentitiesWrite, common.HandleSplitIdentity(manager.Manager)))
+	mux.HandleFunc("/api/admin/identities/logs", manager.RequirePermission(rbac.IdentitiesRead, common.HandleListIdentityLogs(manager.Manager)))
+
+	// 影子执行
+	mux.HandleFunc("/api/admin/shadow/rules", manager.RequirePermission(rbac.ShadowManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			common.HandleListShadowRules(manager.Manager)(w, r)
+		case http.MethodPost:
+			common.HandleSaveShadowRule(manager.Manager)(w, r)
+		case http.MethodDelete:
+			common.HandleDeleteShadowRule(manager.Manager)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/admin/shadow/report", manager.RequirePermission(rbac.ShadowManage, HandleGetShadowReport(manager)))
 
 	// 基础设施与插件
-	mux.HandleFunc("/api/admin/docker/list", manager.AdminMiddleware(HandleDockerList(manager.Manager)))
-	mux.HandleFunc("/api/admin/docker/action", manager.AdminMiddleware(HandleDockerAction(manager.Manager)))
-	mux.HandleFunc("/api/admin/docker/logs", manager.AdminMiddleware(HandleDockerLogs(manager.Manager)))
-	mux.HandleFunc("/api/admin/docker/add-bot", manager.AdminMiddleware(HandleDockerAddBot(manager.Manager)))
-	mux.HandleFunc("/api/admin/docker/add-worker", manager.AdminMiddleware(HandleDockerAddWorker(manager.Manager)))
+	mux.HandleFunc("/api/admin/docker/list", manager.RequirePermission(rbac.DockerManage, HandleDockerList(manager.Manager)))
+	mux.HandleFunc("/api/admin/docker/action", manager.RequirePermission(rbac.DockerManage, HandleDockerAction(manager.Manager)))
+	mux.HandleFunc("/api/admin/docker/logs", manager.RequirePermission(rbac.DockerManage, HandleDockerLogs(manager.Manager)))
+	mux.HandleFunc("/api/admin/docker/add-bot", manager.RequirePermission(rbac.DockerManage, HandleDockerAddBot(manager.Manager)))
+	mux.HandleFunc("/api/admin/docker/add-worker", manager.RequirePermission(rbac.DockerManage, HandleDockerAddWorker(manager.Manager)))
 
-	mux.HandleFunc("/api/admin/plugins/list", manager.AdminMiddleware(HandleListPlugins(manager)))
-	mux.HandleFunc("/api/admin/plugins/action", manager.AdminMiddleware(HandlePluginAction(manager)))
-	mux.HandleFunc("/api/admin/plugins/install", manager.AdminMiddleware(HandleInstallPlugin(manager)))
-	mux.HandleFunc("/api/admin/plugins/delete", manager.AdminMiddleware(HandleDeletePlugin(manager)))
+	mux.HandleFunc("/api/admin/plugins/list", manager.RequirePermission(rbac.PluginsRead, HandleListPlugins(manager)))
+	mux.HandleFunc("/api/admin/plugins/action", manager.RequirePermission(rbac.PluginsManage, HandlePluginAction(manager)))
+	mux.HandleFunc("/api/admin/plugins/install", manager.RequirePermission(rbac.PluginsInstall, HandleInstallPlugin(manager)))
+	mux.HandleFunc("/api/admin/plugins/delete", manager.RequirePermission(rbac.PluginsInstall, HandleDeletePlugin(manager)))
 
 	// 裂变营销系统
-	mux.HandleFunc("/api/admin/fission/config", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/fission/config", manager.RequireReadWrite(rbac.FissionRead, rbac.FissionManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			common.HandleGetFissionConfig(manager.Manager)(w, r)
@@ -391,7 +521,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/fission/tasks", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/fission/tasks", manager.RequireReadWrite(rbac.FissionRead, rbac.FissionManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			common.HandleGetFissionTasks(manager.Manager)(w, r)
@@ -401,16 +531,24 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/fission/tasks/", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/fission/tasks/", manager.RequirePermission(rbac.FissionManage, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodDelete {
 			common.HandleDeleteFissionTask(manager.Manager)(w, r)
 		} else {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/fission/stats", manager.AdminMiddleware(common.HandleGetFissionStats(manager.Manager)))
-	mux.HandleFunc("/api/admin/fission/invitations", manager.AdminMiddleware(common.HandleGetInvitations(manager.Manager)))
-	mux.HandleFunc("/api/admin/fission/leaderboard", manager.AdminMiddleware(common.HandleGetFissionLeaderboard(manager.Manager)))
+	mux.HandleFunc("/api/admin/fission/stats", manager.RequirePermission(rbac.FissionRead, common.HandleGetFissionStats(manager.Manager)))
+	mux.HandleFunc("/api/admin/fission/invitations", manager.RequirePermission(rbac.FissionRead, common.HandleGetInvitations(manager.Manager)))
+	mux.HandleFunc("/api/admin/fission/leaderboard", manager.RequirePermission(rbac.FissionRead, common.HandleGetFissionLeaderboard(manager.Manager)))
+	mux.HandleFunc("/api/admin/fission/reviews", manager.RequirePermission(rbac.FissionRead, common.HandleGetFissionReviews(manager.Manager)))
+	mux.HandleFunc("/api/admin/fission/reviews/", manager.RequirePermission(rbac.FissionManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			common.HandleReviewFissionInvitation(manager.Manager)(w, r)
+		} else {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
 
 	// --- MCP Server 接口 (Global Agent Mesh 核心) ---
 	mux.HandleFunc("/api/mcp/v1/sse", mcp.HandleMCPSSE(manager))
@@ -430,7 +568,7 @@ func Run() {
 	mux.HandleFunc("/api/b2b/dispatch/approve", manager.AdminMiddleware(manager.DigitalEmployeeMiddleware(employee.HandleB2BApproveDispatch(manager.B2BService))))
 	mux.HandleFunc("/api/b2b/dispatch/list", manager.JWTMiddleware(manager.DigitalEmployeeMiddleware(employee.HandleB2BListDispatches(manager.B2BService))))
 
-	mux.HandleFunc("/api/admin/debug/fix-data", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/debug/fix-data", manager.RequirePermission(rbac.SystemManage, func(w http.ResponseWriter, r *http.Request) {
 		// 将所有 agent 的 model_id 设置为 1 (假设 ID 1 的模型存在)
 		if err := manager.GORMDB.Model(&models.AIAgentGORM{}).Where("model_id = ?", 0).Update("model_id", 1).Error; err != nil {
 			utils.SendJSONResponse(w, false, err.Error(), nil)
@@ -439,7 +577,7 @@ func Run() {
 		utils.SendJSONResponse(w, true, "Fixed model_id for agents", nil)
 	}))
 
-	mux.HandleFunc("/api/admin/ai/providers", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/ai/providers", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			// HandleListAIProviders(manager)(w, r)
@@ -449,7 +587,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/ai/providers/", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/ai/providers/", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodDelete {
 			// HandleDeleteAIProvider(manager)(w, r)
 		} else {
@@ -457,7 +595,7 @@ func Run() {
 		}
 	}))
 
-	mux.HandleFunc("/api/admin/ai/models", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/ai/models", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			// HandleListAIModels(manager)(w, r)
@@ -467,7 +605,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/ai/models/", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/ai/models/", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodDelete {
 			// HandleDeleteAIModel(manager)(w, r)
 		} else {
@@ -475,7 +613,7 @@ func Run() {
 		}
 	}))
 
-	mux.HandleFunc("/api/admin/ai/agents", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/ai/agents", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			// HandleListAIAgents(manager)(w, r)
@@ -485,7 +623,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/ai/agents/", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/ai/agents/", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			// HandleGetAIAgent(manager)(w, r)
@@ -552,7 +690,7 @@ func Run() {
 	*/
 
 	// --- 认知记忆管理接口 ---
-	mux.HandleFunc("/api/admin/memories", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/memories", manager.RequireReadWrite(rbac.AIRead, rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			HandleListMemories(manager)(w, r)
@@ -560,7 +698,7 @@ func Run() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}))
-	mux.HandleFunc("/api/admin/memories/", manager.AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/admin/memories/", manager.RequirePermission(rbac.AIManage, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodDelete:
 			HandleDeleteMemory(manager)(w, r)
@@ -729,6 +867,21 @@ func NewManager() *Manager {
 	if err := m.InitDB(); err != nil {
 		clog.Error(utils.T("", "db_init_failed", err))
 	} else {
+		// 密钥存储 (信封加密)，需在加载 AI、B2B 等配置前就绪
+		if keys, err := secrets.LoadKeyring(config.GlobalConfig.SecretsMasterKeyFile); err == nil {
+			store := secrets.New(m.GORMDB, keys, secrets.Options{})
+			if err := store.Migrate(); err != nil {
+				clog.Error("密钥存储初始化失败", zap.Error(err))
+			} else {
+				secrets.SetDefault(store)
+				m.Secrets = store
+			}
+		} else if errors.Is(err, secrets.ErrNoMasterKey) {
+			clog.Warn("未配置主密钥 (BOTMATRIX_MASTER_KEY)，API Key 与私钥仍以明文保存")
+		} else {
+			clog.Error("加载主密钥失败", zap.Error(err))
+		}
+
 		// 从数据库加载路由规则
 		if err := m.LoadRoutingRulesFromDB(); err != nil {
 			clog.Error(utils.T("", "load_route_rules_failed"), zap.Error(err))
@@ -751,6 +904,44 @@ func NewManager() *Manager {
 		}
 		// 初始化默认岗位模板
 		employee.InitDefaultRoleTemplates(m.GORMDB)
+
+		// 消息归档 (检索、保留策略与会话导出)
+		if m.GORMDB != nil {
+			svc := archive.New(m.GORMDB, archive.Options{
+				Search:               config.GlobalConfig.MessageArchiveSearch,
+				DefaultRetentionDays: config.Current().MessageArchiveRetentionDays,
+				ShouldPurge:          m.leaderGate(jobArchivePurge),
+			})
+			if err := svc.Start(context.Background()); err != nil {
+				clog.Error("消息归档启动失败", zap.Error(err))
+			} else {
+				m.Archive = svc
+				config.OnChange("message_archive_retention_days", func(ev config.ChangeEvent) error {
+					svc.SetDefaultRetentionDays(ev.New.MessageArchiveRetentionDays)
+					return nil
+				})
+			}
+		}
+
+		// 群发活动 (按机器人限速投递)
+		if m.GORMDB != nil {
+			svc := campaign.New(m.GORMDB, campaignDirectory{m}, campaignSender{m}, campaign.Options{
+				DefaultRate:   config.Current().CampaignRatePerMinute,
+				PlatformRates: config.Current().CampaignPlatformRates,
+				ShouldRun:     m.leaderGate(jobCampaign),
+			})
+			if err := svc.Start(context.Background()); err != nil {
+				clog.Error("群发活动启动失败", zap.Error(err))
+			} else {
+				m.Campaigns = svc
+				setRates := func(ev config.ChangeEvent) error {
+					svc.SetRates(ev.New.CampaignRatePerMinute, ev.New.CampaignPlatformRates)
+					return nil
+				}
+				config.OnChange("campaign_rate_per_minute", setRates)
+				config.OnChange("campaign_platform_rates", setRates)
+			}
+		}
 	}
 
 	// 初始化Redis (用于统计信息等非持久化数据)
@@ -768,8 +959,13 @@ func NewManager() *Manager {
 		m.Rdb = nil
 	} else {
 		clog.Info(utils.T("", "redis_connected"))
+		// 抓取 /metrics 时统计各 Worker 队列的积压与待确认消息数
+		metrics.Registry.MustRegister(metrics.NewStreamCollector(m.Rdb, config.REDIS_KEY_QUEUE_GROUP, "botmatrix:queue:*"))
 	}
 
+	// 集群模式：登记连接、跨节点转发动作并选举单例任务的 leader
+	m.initCluster()
+
 	// 初始化 Docker 客户端
 	if err := m.InitDockerClient(); err != nil {
 		clog.Error("Docker 初始化失败", zap.Error(err))
@@ -777,6 +973,8 @@ func NewManager() *Manager {
 
 	// 初始化核心插件
 	m.Core = common.NewCorePlugin(m.Manager)
+	// 定期从 Redis 同步核心配置，其他实例修改的敏感词库随之生效
+	m.Core.StartRedisSync(context.Background(), 30*time.Second)
 
 	// 初始化平台适配器与服务实现
 	m.PlatformAdapters = make(map[string]PlatformAdapter)
@@ -807,7 +1005,7 @@ func NewManager() *Manager {
 			if m.AIIntegrationService != nil {
 				m.TaskManager.AI.SetAIService(m.AIIntegrationService)
 
-				// 初始化 RAG 知识库 (PostgreSQL + pgvector)
+				// 初始化 RAG 知识库 (PostgreSQL + pgvector，或 SQLite + 内置 HNSW 索引)
 				// 优先从配置中获取模型 ID，如果没有则尝试查找包含 embedding 关键字的模型
 				var embedModel models.AIModelGORM
 				var findErr error
@@ -827,6 +1025,21 @@ func NewManager() *Manager {
 
 					es := rag.NewTaskAIEmbeddingService(m.AIIntegrationService, embedModel.ID, embedModel.ModelName)
 					kb := rag.NewPostgresKnowledgeBase(m.GORMDB, es, m.AIIntegrationService, chatModel.ID)
+					kb.SetVectorStoreConfig(config.GlobalConfig.RAGVectorStore, config.GlobalConfig.RAGVectorPath)
+					kb.SetSearchOptions(rag.SearchOptions{
+						MinSimilarity: config.GlobalConfig.RAGMinSimilarity,
+						MinScore:      config.GlobalConfig.RAGMinScore,
+					})
+					switch config.GlobalConfig.RAGReranker {
+					case "cross-encoder":
+						rerankKey, err := secrets.Resolve(context.Background(), config.GlobalConfig.RAGRerankAPIKey)
+						if err != nil {
+							clog.Warn("[RAG] 解析重排接口密钥失败", zap.Error(err))
+						}
+						kb.SetReranker(rag.NewCrossEncoderReranker(config.GlobalConfig.RAGRerankEndpoint, rerankKey, config.GlobalConfig.RAGRerankModel))
+					case "llm":
+						kb.SetReranker(rag.NewLLMReranker(m.AIIntegrationService, chatModel.ID))
+					}
 
 					// 将向量服务注入认知记忆系统
 					if aiSvc, ok := m.AIIntegrationService.(*ai.AIServiceImpl); ok {
@@ -837,6 +1050,18 @@ func NewManager() *Manager {
 
 					if err := kb.Setup(); err == nil {
 						m.TaskManager.AI.Manifest.KnowledgeBase = kb
+						m.KnowledgeBase = kb
+
+						// 异步入库队列与数据源增量同步
+						queue := ingest.NewQueue(m.GORMDB, rag.NewIndexer(kb, m.AIIntegrationService, chatModel.ID), ingest.Options{
+							Workers: config.GlobalConfig.IngestWorkers,
+						})
+						if err := queue.Start(context.Background()); err == nil {
+							kb.SetIngestor(queue)
+							ingest.StartSources(context.Background(), queue, config.GlobalConfig.KnowledgeSources)
+						} else {
+							clog.Warn("[RAG] 入库队列启动失败，上传将同步索引", zap.Error(err))
+						}
 
 						// 注入到 MCP 管理器，供知识库工具使用
 						if aiSvc, ok := m.AIIntegrationService.(*ai.AIServiceImpl); ok {
@@ -854,7 +1079,8 @@ func NewManager() *Manager {
 					clog.Warn("[RAG] 未找到可用的向量模型，RAG 功能将受限")
 				}
 			}
-			// Nexus 作为任务系统的管理中心和调度触发端
+			// Nexus 作为任务系统的管理中心和调度触发端，集群中只由 leader 扫描
+			m.TaskManager.Scheduler.ShouldRun = m.leaderGate(jobTaskScheduler)
 			m.TaskManager.Start(true)
 			clog.Info("[Nexus] TaskManager started (Scheduler Enabled)")
 
@@ -1138,6 +1364,19 @@ func (m *Manager) handleWorkerAction(msg map[string]any) {
 	}
 	params := msg["params"]
 
+	// 影子 Worker 的动作只记录不发送；未携带 worker_id 的动作无法判断来源，处于影子对比中的会话一律截获
+	workerID, _ := msg["worker_id"].(string)
+	action := types.InternalAction{Action: actionType, SelfID: selfID}
+	if paramsMap, ok := params.(map[string]any); ok {
+		action.Params = paramsMap
+	}
+	action.GroupID, _ = msg["group_id"].(string)
+	action.UserID, _ = msg["user_id"].(string)
+	action.Message = msg["message"]
+	if m.captureShadowAction(workerID, action) {
+		return
+	}
+
 	clog.Info("[WorkerAction] Received action from worker",
 		zap.String("action", actionType),
 		zap.String("platform", platform),
@@ -1148,6 +1387,14 @@ func (m *Manager) handleWorkerAction(msg map[string]any) {
 	bot, exists := m.GetBot(platform, selfID)
 
 	if !exists {
+		// 集群模式下 Worker 动作经 Redis 广播到所有节点，由持有机器人连接的节点发送
+		if _, owner := m.remoteBotOwner(botKeys(platform, selfID)...); owner != "" {
+			clog.Debug("[WorkerAction] Bot is connected to another node",
+				zap.String("self_id", selfID),
+				zap.String("node", owner))
+			return
+		}
+
 		// 尝试从持久化缓存中查找以获取更多信息
 		m.CacheMutex.RLock()
 		cachedBot, cachedExists := m.BotCache[selfID]
@@ -1166,11 +1413,18 @@ func (m *Manager) handleWorkerAction(msg map[string]any) {
 		return
 	}
 
-	// 构造 OneBot 请求
+	// 构造 OneBot 请求，出站动作 span 延续 Worker 写入的追踪上下文
+	actionCtx, span := tracing.Start(tracing.ExtractMap(context.Background(), msg), "nexus.send_action "+actionType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("botmatrix.platform", platform),
+			attribute.String("botmatrix.self_id", selfID),
+		))
 	req := map[string]any{
 		"action":  actionType,
 		"self_id": selfID,
 	}
+	tracing.InjectMap(actionCtx, req)
 
 	if paramsMap, ok := params.(map[string]any); ok {
 		req["params"] = paramsMap
@@ -1194,6 +1448,7 @@ func (m *Manager) handleWorkerAction(msg map[string]any) {
 	bot.Mutex.Lock()
 	err := bot.Conn.WriteJSON(req)
 	bot.Mutex.Unlock()
+	tracing.End(span, err)
 
 	if err != nil {
 		clog.Error("[WorkerAction] Failed to send action to bot", zap.Error(err))
@@ -1253,10 +1508,6 @@ func (m *Manager) SendBotAction(botID string, action string, params any) error {
 	}
 	m.Mutex.RUnlock()
 
-	if !exists {
-		return fmt.Errorf("bot %s not found (tried both direct and qq: prefix)", botID)
-	}
-
 	echo := fmt.Sprintf("task|%d|%s", time.Now().UnixNano(), action)
 	msg := struct {
 		Action string `json:"action"`
@@ -1268,6 +1519,19 @@ func (m *Manager) SendBotAction(botID string, action string, params any) error {
 		Echo:   echo,
 	}
 
+	if !exists {
+		// 集群模式下转发给持有机器人连接的节点
+		if key, owner := m.remoteBotOwner(botKeys("", botID)...); owner != "" {
+			clog.Info("[BotAction] Forwarding action to node",
+				zap.String("bot_id", botID),
+				zap.String("action", action),
+				zap.String("node", owner))
+			_, err := m.callRemoteBot(context.Background(), owner, key, msg, "")
+			return err
+		}
+		return fmt.Errorf("bot %s not found (tried both direct and qq: prefix)", botID)
+	}
+
 	bot.Mutex.Lock()
 	defer bot.Mutex.Unlock()
 
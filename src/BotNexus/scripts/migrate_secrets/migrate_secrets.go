@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"BotMatrix/common/config"
+	"BotMatrix/common/secrets"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 将业务表中的明文 API Key、私钥迁移到密钥存储，或在更换主密钥后重新加密全部密钥。
+//
+//	BOTMATRIX_MASTER_KEY=... go run ./scripts/migrate_secrets -dry-run
+//	BOTMATRIX_MASTER_KEY=<新密钥>,<旧密钥> go run ./scripts/migrate_secrets -rotate
+func main() {
+	configPath := flag.String("config", "config.json", "配置文件路径")
+	dryRun := flag.Bool("dry-run", false, "只列出明文密钥的位置，不做修改")
+	rotate := flag.Bool("rotate", false, "用当前主密钥重新加密全部密钥")
+	generate := flag.Bool("generate-key", false, "生成新的主密钥并退出")
+	flag.Parse()
+
+	if *generate {
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			log.Fatalf("failed to generate key: %v", err)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	config.InitConfig(*configPath)
+	keys, err := secrets.LoadKeyring(config.GlobalConfig.SecretsMasterKeyFile)
+	if err != nil {
+		log.Fatalf("failed to load master key: %v", err)
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		config.GlobalConfig.PGHost, config.GlobalConfig.PGPort, config.GlobalConfig.PGUser, config.GlobalConfig.PGPassword, config.GlobalConfig.PGDBName, config.GlobalConfig.PGSSLMode)
+	db, err := gorm.Open(postgres.Open(connStr), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+
+	store := secrets.New(db, keys, secrets.Options{})
+	if err := store.Migrate(); err != nil {
+		log.Fatalf("failed to migrate secrets table: %v", err)
+	}
+	ctx := context.Background()
+
+	if *rotate {
+		n, err := store.Rotate(ctx)
+		fmt.Printf("Re-encrypted %d secrets with master key %s\n", n, keys.PrimaryID())
+		if err != nil {
+			log.Fatalf("rotate stopped: %v", err)
+		}
+		return
+	}
+
+	findings, err := secrets.MigratePlaintext(ctx, db, store, "migrate_secrets", *dryRun)
+	for _, f := range findings {
+		fmt.Printf("%s #%d %s -> %s\n", f.Table, f.RowID, f.Column, secrets.Ref(f.Name))
+	}
+	if err != nil {
+		log.Fatalf("migration stopped: %v", err)
+	}
+	if *dryRun {
+		fmt.Printf("\n%d plaintext secrets found (dry run, nothing changed)\n", len(findings))
+	} else {
+		fmt.Printf("\n%d plaintext secrets migrated\n", len(findings))
+	}
+}
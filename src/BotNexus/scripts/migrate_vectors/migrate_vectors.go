@@ -0,0 +1,116 @@
+package main
+
+// 在 pgvector 与内置 HNSW 索引之间迁移知识库向量
+//
+// 用法:
+//
+//	go run ./scripts/migrate_vectors -from pgvector -to embedded -path data/knowledge_vectors.hnsw
+//	go run ./scripts/migrate_vectors -from embedded -to pgvector
+//	go run ./scripts/migrate_vectors -from embedded -to embedded -sqlite botnexus.db   (从 SQLite 的 embedding 列重建索引)
+//
+// 默认连接 config.json 中的 PostgreSQL，指定 -sqlite 时使用 SQLite 数据库文件
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	from := flag.String("from", rag.VectorStorePgVector, "source vector store (pgvector|embedded)")
+	to := flag.String("to", rag.VectorStoreEmbedded, "target vector store (pgvector|embedded)")
+	path := flag.String("path", "", "embedded index file (default "+rag.DefaultVectorStorePath+")")
+	sqliteFile := flag.String("sqlite", "", "use a SQLite database file instead of PostgreSQL")
+	flag.Parse()
+
+	config.InitConfig("config.json")
+	if *path == "" {
+		*path = config.GlobalConfig.RAGVectorPath
+	}
+	if *path == "" {
+		*path = rag.DefaultVectorStorePath
+	}
+	if *from == *to && *from != rag.VectorStoreEmbedded {
+		log.Fatalf("source and target are both %s", *from)
+	}
+
+	var db *gorm.DB
+	var err error
+	if *sqliteFile != "" {
+		db, err = gorm.Open(sqlite.Open(*sqliteFile), &gorm.Config{})
+	} else {
+		connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			config.GlobalConfig.PGHost, config.GlobalConfig.PGPort, config.GlobalConfig.PGUser, config.GlobalConfig.PGPassword, config.GlobalConfig.PGDBName, config.GlobalConfig.PGSSLMode)
+		db, err = gorm.Open(postgres.Open(connStr), &gorm.Config{})
+	}
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+
+	ctx := context.Background()
+	if *from == *to {
+		// embedded -> embedded: 丢弃旧索引文件，从 embedding 列重建
+		if err := removeIfExists(*path); err != nil {
+			log.Fatalf("failed to remove %s: %v", *path, err)
+		}
+		store, err := rag.OpenEmbeddedVectorStore(db, *path)
+		if err != nil {
+			log.Fatalf("failed to rebuild index: %v", err)
+		}
+		fmt.Printf("Done. %d vectors indexed into %s.\n", store.Len(), *path)
+		return
+	}
+
+	src, err := openStore(db, *from, *path, true)
+	if err != nil {
+		log.Fatalf("failed to open source %s: %v", *from, err)
+	}
+	defer src.Close()
+
+	dst, err := openStore(db, *to, *path, false)
+	if err != nil {
+		log.Fatalf("failed to open target %s: %v", *to, err)
+	}
+
+	fmt.Printf("Migrating vectors %s -> %s ...\n", src.Name(), dst.Name())
+	count, err := rag.MigrateVectors(ctx, src, dst)
+	if err != nil {
+		dst.Close()
+		log.Fatalf("migration failed after %d vectors: %v", count, err)
+	}
+	if err := dst.Close(); err != nil {
+		log.Fatalf("failed to close target: %v", err)
+	}
+	fmt.Printf("Done. %d vectors migrated.\n", count)
+}
+
+// openStore 打开向量存储。内置索引作为迁移源时只读取索引文件；作为迁移目标时从空索引开始，避免混入旧数据
+func openStore(db *gorm.DB, kind, path string, source bool) (rag.VectorStore, error) {
+	if kind != rag.VectorStoreEmbedded {
+		return rag.NewVectorStore(db, kind, path)
+	}
+	if !source {
+		if err := removeIfExists(path); err != nil {
+			return nil, err
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return rag.OpenEmbeddedVectorStore(nil, path)
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
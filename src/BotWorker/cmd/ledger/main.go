@@ -0,0 +1,56 @@
+// ledger 积分账本对账工具：核对每笔日志借贷平衡，以及账户余额快照、原有积分字段与分录合计是否一致。
+// 存在差异时以非零状态码退出，可用于定时任务告警。
+//
+//	go run ./cmd/ledger -config config.json [-init-schema]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"botworker/internal/config"
+	"botworker/internal/db"
+)
+
+func main() {
+	initSchema := flag.Bool("init-schema", false, "对账前创建积分账本表")
+
+	cfg, _, err := config.LoadFromCLI()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(2)
+	}
+
+	conn, err := db.NewDBConnection(&cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	defer conn.Close()
+
+	if *initSchema {
+		if err := db.EnsureLedgerSchema(conn); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	report, err := db.ReconcileLedger(conn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "对账失败: %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("日志 %d 笔，账户 %d 个，系统账户净额 %d\n", report.Journals, report.Accounts, report.SystemNet)
+	for _, id := range report.UnbalancedJournals {
+		fmt.Printf("日志不平衡: journal=%d\n", id)
+	}
+	for _, m := range report.Mismatches {
+		fmt.Printf("余额不一致: %s 快照=%d 分录=%d 原字段=%d\n", m.Account, m.Snapshot, m.Journal, m.Legacy)
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+	fmt.Println("对账通过")
+}
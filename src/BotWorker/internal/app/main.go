@@ -4,14 +4,19 @@ import (
 	"BotMatrix/common/ai"
 	"BotMatrix/common/ai/mcp"
 	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/ai/rag/ingest"
 	"BotMatrix/common/bot"
 	common_config "BotMatrix/common/config"
 	"BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"BotMatrix/common/onebot"
 	"BotMatrix/common/plugin/core"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"botworker/internal/config"
 	"botworker/internal/db"
+	"botworker/internal/fission"
 	"botworker/internal/redis"
 	"botworker/internal/server"
 	"botworker/plugins"
@@ -69,6 +74,9 @@ func Run() {
 	// 同步配置到 common 包
 	common_config.InitConfig(path)
 
+	// 初始化分布式追踪，退出时由 WaitExitSignal 导出剩余 span
+	botService.InitTracing(tracing.FromConfig("BotWorker", common_config.GlobalConfig.Tracing))
+
 	// 设置标准处理器
 	setupHandlers()
 
@@ -363,7 +371,13 @@ func startBot(ctx context.Context) error {
 		log.Warn("无法连接到数据库", zap.Error(err))
 	} else {
 		log.Info("成功连接到数据库")
+		// 积分读写全部经过账本，缺表时直接退出，避免运行中每笔积分操作失败
+		if err := db.CheckLedgerSchema(database); err != nil {
+			log.Fatal("积分账本未初始化", zap.Error(err))
+		}
 		plugins.SetGlobalDB(database)
+		// 发放延迟期满、审核通过及待重试的裂变奖励
+		go fission.NewService(database).RunOutbox(ctx, time.Minute)
 		/* 按照要求，不再通过代码修改数据库结构
 		if err := db.InitDatabase(database); err != nil {
 			log.Warn("初始化数据库表失败", zap.Error(err))
@@ -419,8 +433,28 @@ func startBot(ctx context.Context) error {
 		}
 
 		// 5. 初始化核心 AI 服务
-		aiService = ai.NewAIService(plugins.GlobalGORMDB, provider, mcpManager)
+		coreAISvc := ai.NewAIService(plugins.GlobalGORMDB, provider, mcpManager)
+		aiService = coreAISvc
 		log.Info("核心 AI 服务初始化成功")
+
+		// 高风险工具人工审批：挂起时通知 Nexus 管理后台的审批人，审批完成后恢复运行并回复
+		coreAISvc.SetApprovalHooks(ai.ApprovalHooks{
+			OnPending: func(run *models.AgentRun, logs []models.ToolAuditLog) {
+				log.Info("[Agent] Tool approval requested", zap.String("run", run.RunID), zap.Int("tools", len(logs)))
+				workerServer.NotifyToolApproval(ai.NewToolApprovalEvent(run, logs))
+			},
+			OnResumed: func(run *models.AgentRun, content string) {
+				workerServer.SendMessage(&onebot.SendMessageParams{
+					MessageType: run.MessageType,
+					UserID:      run.UserID,
+					GroupID:     run.GroupID,
+					Platform:    run.Platform,
+					SelfID:      run.BotID,
+					Message:     content,
+				})
+			},
+		})
+		coreAISvc.StartApprovalWatcher(context.Background(), 5*time.Second)
 	} else {
 		// 回退到基础 AI 服务
 		aiService = NewWorkerAIService(cfg)
@@ -513,9 +547,20 @@ func startBot(ctx context.Context) error {
 			}
 		}
 
-		if docsDir != "" {
-			indexer := rag.NewIndexer(kb, aiService, 0)
-			go rag.StartAutoSync(ctx, indexer, docsDir, 1*time.Hour)
+		// 入库队列与 Nexus 共用数据库中的任务表，上传与同步的文档由任一进程领取执行
+		queue := ingest.NewQueue(plugins.GlobalGORMDB, rag.NewIndexer(kb, aiService, 0), ingest.Options{})
+		if err := queue.Start(ctx); err != nil {
+			log.Warn("RAG 入库队列启动失败", zap.Error(err))
+			queue = nil
+		} else {
+			kb.SetIngestor(queue)
+		}
+
+		if docsDir != "" && queue != nil {
+			// 按哈希增量同步，监听文件变更，并移除已删除文档的索引
+			conn := ingest.NewDirectoryConnector(filepath.Join(docsDir, "docs", "zh-CN"), ".md")
+			syncer := ingest.NewSyncer(queue, conn, ingest.SyncOptions{DocType: "system"})
+			go syncer.Run(ctx, 1*time.Hour, 2*time.Second)
 			log.Info("RAG 系统文档自动同步任务已启动", zap.String("base_dir", docsDir))
 		} else {
 			log.Warn("未找到 docs 目录，跳过 RAG 系统文档自动同步")
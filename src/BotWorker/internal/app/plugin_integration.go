@@ -443,6 +443,11 @@ func (pb *PluginBridge) LoadExternalPlugins() error {
 			params["bot_id"] = selfID
 		}
 
+		// 卡片动作记录来源插件，以便按钮回调能路由回来
+		if a.Type == "send_card" || a.Type == "update_card" {
+			params["plugin_id"] = p.ID
+		}
+
 		// 调用通用动作转发
 		actionType := a.Type
 		if actionType == "send_message" || actionType == "reply" {
@@ -485,6 +490,12 @@ func (pb *PluginBridge) LoadExternalPlugins() error {
 				payload["meta_event_type"] = e.MetaEventType
 			}
 
+			// 卡片按钮回调：只路由回发出该卡片的插件
+			if e.NoticeType == "card_action" && e.CardAction != nil {
+				pb.dispatchCardAction(e)
+				return nil
+			}
+
 			coreEvent := &core.EventMessage{
 				ID:      fmt.Sprintf("ob_%d", e.Time),
 				Type:    "event",
@@ -504,6 +515,37 @@ func (pb *PluginBridge) LoadExternalPlugins() error {
 	return nil
 }
 
+// dispatchCardAction 将卡片回调作为 on_card_action 事件发送给卡片的来源插件
+func (pb *PluginBridge) dispatchCardAction(e *onebot.Event) {
+	ca := e.CardAction
+	coreEvent := &core.EventMessage{
+		ID:            fmt.Sprintf("card_%d", core.NextID()),
+		Type:          "event",
+		Name:          "on_card_action",
+		CorrelationId: ca.CardID,
+		Payload: map[string]any{
+			"card_id":    ca.CardID,
+			"action":     ca.Action,
+			"value":      ca.Value,
+			"form_value": ca.FormValue,
+			"platform":   ca.Platform,
+			"from":       ca.UserID,
+			"user_id":    ca.UserID,
+			"group_id":   ca.GroupID,
+			"message_id": ca.MessageID,
+			"self_id":    fmt.Sprintf("%v", e.SelfID),
+		},
+	}
+
+	if ca.PluginID == "" {
+		log.Printf("[PluginBridge] 卡片 %s 的回调没有来源插件，广播给订阅者", ca.CardID)
+		pb.pluginManager.DispatchEvent(coreEvent)
+		return
+	}
+	log.Printf("[PluginBridge] 卡片回调 %s (%s) -> 插件 %s", ca.CardID, ca.Action, ca.PluginID)
+	pb.pluginManager.DispatchEventToPlugin(ca.PluginID, "", coreEvent)
+}
+
 func (pb *PluginBridge) handleStorageAction(p *core.Plugin, a *core.Action) {
 	if plugins.GlobalRedis == nil {
 		fmt.Printf("Redis 未初始化，无法执行存储操作 %s\n", a.Type)
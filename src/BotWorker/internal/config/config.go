@@ -58,6 +58,9 @@ type HTTPConfig struct {
 
 	// 写入超时时间
 	WriteTimeout time.Duration `json:"write_timeout"`
+
+	// /metrics 抓取令牌 (Bearer)，为空时不校验
+	MetricsToken string `json:"metrics_token"`
 }
 
 // WebSocketConfig 定义WebSocket服务器配置
@@ -527,6 +530,9 @@ func LoadFromCLI() (*Config, string, error) {
 	if *httpAddr != "" {
 		config.HTTP.Addr = *httpAddr
 	}
+	if val := os.Getenv("METRICS_TOKEN"); val != "" {
+		config.HTTP.MetricsToken = val
+	}
 
 	if *wsAddr != "" {
 		config.WebSocket.Addr = *wsAddr
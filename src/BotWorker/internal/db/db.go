@@ -218,30 +218,43 @@ func UpdateUserSuperPoints(db *sql.DB, userID int64, isSuperPoints bool) error {
 	return nil
 }
 
-// UpdateUserPoints 更新用户通用积分
+// UpdateUserPoints 将用户全局积分设置为指定值，差额通过积分账本发放或扣除。
+// 插件桥接接口没有业务标识，使用一次性幂等键，调用方重试会重复生效
 func UpdateUserPoints(db *sql.DB, userID int64, points int) error {
-	query := fmt.Sprintf(`
-	UPDATE %s
-	SET credit = $2
-	WHERE id = $1
-	`, TableUser)
-	result, err := db.Exec(query, userID, points)
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("更新用户积分失败: %w", err)
+		return fmt.Errorf("开始事务失败: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("获取影响行数失败: %w", err)
+	if err := setBalanceTx(tx, UserAccount(userID), int64(points), "plugin_set", "插件设置积分"); err != nil {
+		return err
 	}
+	return tx.Commit()
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("用户不存在: %d", userID)
+// setBalanceTx 将账户余额调整为 target：锁定账户后按差额记一笔调整日志，不直接写余额字段
+func setBalanceTx(tx *sql.Tx, acct Account, target int64, category, reason string) error {
+	_, current, err := lockAccountTx(tx, acct)
+	if err != nil {
+		return err
+	}
+	if delta := target - current; delta != 0 {
+		if _, err := PostJournalTx(tx, mintOrBurn(NewOperationKey(category), OpAdjust, 0, acct, delta, reason, category)); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
+// setUserBalancesTx 将用户的全局积分与存款调整为 user 中的值
+func setUserBalancesTx(tx *sql.Tx, user *User) error {
+	if err := setBalanceTx(tx, UserAccount(user.UserID), user.Points, "user_set", "设置用户积分"); err != nil {
+		return err
+	}
+	return setBalanceTx(tx, SavingsAccount(user.UserID), user.SavingsPoints, "user_set_savings", "设置用户存款")
+}
+
 // CreateGroupWithTargetID 创建带有 TargetGroupID 和 GroupOpenID 的群组
 func CreateGroupWithTargetID(db *sql.DB, groupID int64, targetID int64, openID string, name string) error {
 	query := fmt.Sprintf(`
@@ -416,21 +429,18 @@ func UpdateGroupMemberShutup(db *sql.DB, userID int64, groupID int64, isShutup b
 	return err
 }
 
-// CreateUser 创建新用户
+// CreateUser 创建新用户 (已存在则更新资料)，积分与存款经由积分账本调整为 user 中的值
 func CreateUser(db *sql.DB, user *User) error {
-	query := fmt.Sprintf(`
-	INSERT INTO %s ("Id", "UserOpenid", "Name", "IsSuper", "Credit", "SaveCredit")
-	VALUES ($1, $2, $3, $4, $5, $6)
-	ON CONFLICT ("Id") DO UPDATE
-	SET "UserOpenid" = $2, "Name" = $3, "IsSuper" = $4, "Credit" = $5, "SaveCredit" = $6
-	`, TableUser)
-
-	_, err := db.Exec(query, user.UserID, user.UserOpenID, user.Nickname, user.IsSuperPoints, user.Points, user.SavingsPoints)
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("创建用户失败: %w", err)
+		return fmt.Errorf("开始事务失败: %w", err)
 	}
+	defer tx.Rollback()
 
-	return nil
+	if err := CreateUserTx(tx, user); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // GetUserByUserID 根据用户ID获取用户信息
@@ -456,15 +466,21 @@ func GetUserByUserID(db *sql.DB, userID int64) (*User, error) {
 	return user, nil
 }
 
-// UpdateUser 更新用户信息
+// UpdateUser 更新用户信息，积分与存款经由积分账本调整为 user 中的值
 func UpdateUser(db *sql.DB, user *User) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := fmt.Sprintf(`
 	UPDATE %s
-	SET "Name" = $2, "IsSuper" = $3, "Credit" = $4, "SaveCredit" = $5
+	SET "Name" = $2, "IsSuper" = $3
 	WHERE "Id" = $1
 	`, TableUser)
 
-	result, err := db.Exec(query, user.UserID, user.Nickname, user.IsSuperPoints, user.Points, user.SavingsPoints)
+	result, err := tx.Exec(query, user.UserID, user.Nickname, user.IsSuperPoints)
 	if err != nil {
 		return fmt.Errorf("更新用户失败: %w", err)
 	}
@@ -478,7 +494,10 @@ func UpdateUser(db *sql.DB, user *User) error {
 		return fmt.Errorf("用户不存在: %d", user.UserID)
 	}
 
-	return nil
+	if err := setUserBalancesTx(tx, user); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // Message 定义消息记录模型
@@ -578,21 +597,21 @@ func CreateOrUpdateSession(db *sql.DB, session *Session) error {
 	return nil
 }
 
-// CreateUserTx 在事务中创建新用户
+// CreateUserTx 在事务中创建新用户，语义同 CreateUser
 func CreateUserTx(tx *sql.Tx, user *User) error {
 	query := fmt.Sprintf(`
-	INSERT INTO %s ("Id", "UserOpenid", "Name", "IsSuper", "Credit", "SaveCredit")
-	VALUES ($1, $2, $3, $4, $5, $6)
+	INSERT INTO %s ("Id", "UserOpenid", "Name", "IsSuper", "InsertDate")
+	VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
 	ON CONFLICT ("Id") DO UPDATE
-	SET "UserOpenid" = $2, "Name" = $3, "IsSuper" = $4, "Credit" = $5, "SaveCredit" = $6
+	SET "UserOpenid" = $2, "Name" = $3, "IsSuper" = $4
 	`, TableUser)
 
-	_, err := tx.Exec(query, user.UserID, user.UserOpenID, user.Nickname, user.IsSuperPoints, user.Points, user.SavingsPoints)
+	_, err := tx.Exec(query, user.UserID, user.UserOpenID, user.Nickname, user.IsSuperPoints)
 	if err != nil {
 		return fmt.Errorf("创建用户失败: %w", err)
 	}
 
-	return nil
+	return setUserBalancesTx(tx, user)
 }
 
 // CreateOrUpdateSessionTx 在事务中创建或更新会话状态
@@ -1704,168 +1723,80 @@ func GetFrozenPoints(db *sql.DB, userID int64, groupID int64) (int64, error) {
 	return points, nil
 }
 
-// AddPoints 增加或扣除用户积分 (自动路由全局或群积分)
-func AddPoints(db *sql.DB, botUin int64, userID int64, groupID int64, amount int64, reason string, category string) error {
+// pointsAccount 返回用户在当前场景下的可用积分账户：群积分系统开启时为群积分，否则为全局积分
+func pointsAccount(db *sql.DB, userID int64, groupID int64) (Account, bool, error) {
 	isGroupActive, err := IsGroupCreditSystemEnabled(db, groupID)
 	if err != nil {
-		return fmt.Errorf("检查群积分模式失败: %w", err)
+		return Account{}, false, fmt.Errorf("检查群积分模式失败: %w", err)
 	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
-	}
-	defer tx.Rollback()
-
-	var logType string
-
 	if isGroupActive {
-		logType = "group"
-
-		// 更新 GroupMember 表积分
-		query := fmt.Sprintf(`
-		UPDATE %s
-		SET "Credit" = "Credit" + $3
-		WHERE "UserId" = $1 AND "GroupId" = $2
-		`, TableGroupMember)
-		result, err := tx.Exec(query, userID, groupID, amount)
-		if err != nil {
-			return fmt.Errorf("更新 GroupMember 积分失败: %w", err)
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			// 如果记录不存在，不自动插入，因为这通常由加群事件触发
-			return fmt.Errorf("群成员记录不存在: user=%d, group=%d", userID, groupID)
-		}
-	} else {
-		logType = "global"
-
-		// 更新 User 表积分
-		query := fmt.Sprintf(`
-		UPDATE %s
-		SET "Credit" = "Credit" + $2
-		WHERE "Id" = $1
-		`, TableUser)
-		result, err := tx.Exec(query, userID, amount)
-		if err != nil {
-			return fmt.Errorf("更新 User 积分失败: %w", err)
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			insertQuery := fmt.Sprintf(`
-			INSERT INTO %s ("Id", "Credit", "InsertDate")
-			VALUES ($1, $2, CURRENT_TIMESTAMP)
-			`, TableUser)
-			if _, err := tx.Exec(insertQuery, userID, amount); err != nil {
-				return fmt.Errorf("插入 User 并初始化积分失败: %w", err)
-			}
-		}
+		return GroupMemberAccount(userID, groupID), true, nil
 	}
+	return UserAccount(userID), false, nil
+}
 
-	// 记录日志到 Credit 表
-	var newPoints int64
-	if isGroupActive {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember), userID, groupID).Scan(&newPoints)
-	} else {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), userID).Scan(&newPoints)
+// mintOrBurn 构造发放 (amount > 0，来自系统铸币账户) 或扣除 (amount < 0，转入系统销毁账户) 日志
+func mintOrBurn(key string, op string, botUin int64, acct Account, amount int64, reason string, category string) Journal {
+	counter := MintAccount
+	if amount < 0 {
+		counter = BurnAccount
+	}
+	return Journal{
+		Key:       key,
+		Operation: op,
+		Reason:    reason,
+		Category:  category,
+		BotUin:    botUin,
+		Postings:  []Posting{{Account: acct, Amount: amount}, {Account: counter, Amount: -amount}},
 	}
+}
 
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, groupID, botUin, amount, newPoints, fmt.Sprintf("%s [%s] (%s)", reason, category, logType))
+// AddPoints 增加或扣除用户积分 (自动路由全局或群积分)。key 为幂等键，同一 key 重复调用只入账一次
+func AddPoints(db *sql.DB, key string, botUin int64, userID int64, groupID int64, amount int64, reason string, category string) error {
+	if amount == 0 {
+		return nil
+	}
+	acct, _, err := pointsAccount(db, userID, groupID)
 	if err != nil {
-		return fmt.Errorf("记录积分日志到 Credit 表失败: %w", err)
+		return err
 	}
-
-	return tx.Commit()
+	_, err = PostJournal(db, mintOrBurn(key, OpAdd, botUin, acct, amount, reason, category))
+	return err
 }
 
-func applySavingsInterestTx(tx *sql.Tx, botUin int64, userID int64) (int, error) {
-	var savings int
-	var lastInterest sql.NullTime
+// savingsDailyRate 存款日利率
+const savingsDailyRate = 0.0005
 
-	// 1. 获取存款金额
-	query := fmt.Sprintf(`SELECT "SaveCredit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-	err := tx.QueryRow(query, userID).Scan(&savings)
+// applySavingsInterestTx 按整天数结算存款利息。幂等键为 "saving_interest:<用户>:<结息日>"，同一天不会重复发放
+func applySavingsInterestTx(tx *sql.Tx, botUin int64, userID int64) (int64, error) {
+	_, savings, err := lockAccountTx(tx, SavingsAccount(userID))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("查询存款失败: %w", err)
+		return 0, err
 	}
 
-	// 2. 获取上次结息时间
+	var lastInterest sql.NullTime
 	err = tx.QueryRow(fmt.Sprintf(`SELECT "LastInterestAt" FROM %s WHERE "UserId" = $1 FOR UPDATE`, TableSavings), userID).Scan(&lastInterest)
 	if err != nil && err != sql.ErrNoRows {
 		return 0, fmt.Errorf("查询结息元数据失败: %w", err)
 	}
 
 	now := time.Now()
-	if savings <= 0 {
-		// 如果没有存款，只更新结息时间
-		upsertQuery := fmt.Sprintf(`
-		INSERT INTO %s ("UserId", "LastInterestAt", "UpdateDate")
-		VALUES ($1, $2, CURRENT_TIMESTAMP)
-		ON CONFLICT ("UserId") DO UPDATE
-		SET "LastInterestAt" = $2, "UpdateDate" = CURRENT_TIMESTAMP
-		`, TableSavings)
-		_, err = tx.Exec(upsertQuery, userID, now)
-		if err != nil {
-			return 0, fmt.Errorf("更新结息时间失败: %w", err)
-		}
-		return 0, nil
-	}
-
-	var fromTime time.Time
+	days := 0
 	if lastInterest.Valid {
-		fromTime = lastInterest.Time
-	} else {
-		fromTime = now
-	}
-
-	days := int(now.Sub(fromTime).Hours() / 24)
-	if days <= 0 {
-		// 如果不足一天，只更新结息时间（或者不更新也可以，这里选择更新）
-		upsertQuery := fmt.Sprintf(`
-		INSERT INTO %s ("UserId", "LastInterestAt", "UpdateDate")
-		VALUES ($1, $2, CURRENT_TIMESTAMP)
-		ON CONFLICT ("UserId") DO UPDATE
-		SET "LastInterestAt" = $2, "UpdateDate" = CURRENT_TIMESTAMP
-		`, TableSavings)
-		_, err = tx.Exec(upsertQuery, userID, now)
-		if err != nil {
-			return 0, fmt.Errorf("更新结息时间失败: %w", err)
-		}
-		return 0, nil
+		days = int(now.Sub(lastInterest.Time).Hours() / 24)
 	}
-
-	dailyRate := 0.0005
-	interest := int(float64(savings) * dailyRate * float64(days))
-	if interest <= 0 {
-		upsertQuery := fmt.Sprintf(`
-		INSERT INTO %s ("UserId", "LastInterestAt", "UpdateDate")
-		VALUES ($1, $2, CURRENT_TIMESTAMP)
-		ON CONFLICT ("UserId") DO UPDATE
-		SET "LastInterestAt" = $2, "UpdateDate" = CURRENT_TIMESTAMP
-		`, TableSavings)
-		_, err = tx.Exec(upsertQuery, userID, now)
-		if err != nil {
-			return 0, fmt.Errorf("更新结息时间失败: %w", err)
-		}
+	if lastInterest.Valid && savings > 0 && days <= 0 {
+		// 不足一天，保留上次结息时间继续累计
 		return 0, nil
 	}
 
-	newSavings := savings + interest
-
-	// 3. 更新存款和结息时间
-	updateUserQuery := fmt.Sprintf(`UPDATE %s SET "SaveCredit" = $1 WHERE "Id" = $2`, TableUser)
-	_, err = tx.Exec(updateUserQuery, newSavings, userID)
-	if err != nil {
-		return 0, fmt.Errorf("更新存款利息失败: %w", err)
+	interest := int64(float64(savings) * savingsDailyRate * float64(days))
+	if interest > 0 {
+		key := fmt.Sprintf("saving_interest:%d:%s", userID, now.Format("2006-01-02"))
+		journal := mintOrBurn(key, OpInterest, botUin, SavingsAccount(userID), interest, "存积分利息", "saving_interest")
+		if _, err := PostJournalTx(tx, journal); err != nil {
+			return 0, err
+		}
 	}
 
 	upsertQuery := fmt.Sprintf(`
@@ -1874,28 +1805,13 @@ func applySavingsInterestTx(tx *sql.Tx, botUin int64, userID int64) (int, error)
 	ON CONFLICT ("UserId") DO UPDATE
 	SET "LastInterestAt" = $2, "UpdateDate" = CURRENT_TIMESTAMP
 	`, TableSavings)
-	_, err = tx.Exec(upsertQuery, userID, now)
-	if err != nil {
-		return 0, fmt.Errorf("更新结息元数据失败: %w", err)
+	if _, err := tx.Exec(upsertQuery, userID, now); err != nil {
+		return 0, fmt.Errorf("更新结息时间失败: %w", err)
 	}
-
-	// 4. 记录日志到 Credit 表
-	var newPoints int64
-	tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), userID).Scan(&newPoints)
-
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, 0, botUin, int64(interest), newPoints, "存积分利息 [saving_interest]")
-	if err != nil {
-		return 0, fmt.Errorf("记录利息日志失败: %w", err)
-	}
-
 	return interest, nil
 }
 
-func DepositPointsToSavings(db *sql.DB, botUin int64, userID int64, amount int) error {
+func DepositPointsToSavings(db *sql.DB, key string, botUin int64, userID int64, amount int) error {
 	if amount <= 0 {
 		return fmt.Errorf("存入积分必须大于0")
 	}
@@ -1906,48 +1822,29 @@ func DepositPointsToSavings(db *sql.DB, botUin int64, userID int64, amount int)
 	}
 	defer tx.Rollback()
 
-	var points int
-	query := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-	err = tx.QueryRow(query, userID).Scan(&points)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("用户不存在或没有积分")
-		}
-		return fmt.Errorf("查询用户积分失败: %w", err)
-	}
-
-	if points < amount {
-		return fmt.Errorf("积分不足，当前积分为: %d", points)
-	}
-
-	updateQuery := fmt.Sprintf(`UPDATE %s SET "Credit" = "Credit" - $1, "SaveCredit" = "SaveCredit" + $1 WHERE "Id" = $2`, TableUser)
-	_, err = tx.Exec(updateQuery, amount, userID)
-	if err != nil {
-		return fmt.Errorf("存款失败: %w", err)
-	}
-
-	_, err = applySavingsInterestTx(tx, botUin, userID)
-	if err != nil {
+	// 先按原余额结息，再存入
+	if _, err := applySavingsInterestTx(tx, botUin, userID); err != nil {
 		return err
 	}
 
-	// 记录日志到 Credit 表
-	var newPoints int64
-	tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), userID).Scan(&newPoints)
-
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, 0, botUin, -amount, newPoints, "存入积分 [saving_deposit]")
+	_, err = PostJournalTx(tx, Journal{
+		Key:       key,
+		Operation: OpDeposit,
+		Reason:    "存入积分",
+		Category:  "saving_deposit",
+		BotUin:    botUin,
+		Postings: []Posting{
+			{Account: UserAccount(userID), Amount: -int64(amount)},
+			{Account: SavingsAccount(userID), Amount: int64(amount)},
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("记录存入积分日志失败: %w", err)
+		return err
 	}
-
 	return tx.Commit()
 }
 
-func WithdrawPointsFromSavings(db *sql.DB, botUin int64, userID int64, amount int) error {
+func WithdrawPointsFromSavings(db *sql.DB, key string, botUin int64, userID int64, amount int) error {
 	if amount <= 0 {
 		return fmt.Errorf("取出积分必须大于0")
 	}
@@ -1958,44 +1855,24 @@ func WithdrawPointsFromSavings(db *sql.DB, botUin int64, userID int64, amount in
 	}
 	defer tx.Rollback()
 
-	_, err = applySavingsInterestTx(tx, botUin, userID)
-	if err != nil {
+	if _, err := applySavingsInterestTx(tx, botUin, userID); err != nil {
 		return err
 	}
 
-	var balance int
-	query := fmt.Sprintf(`SELECT "SaveCredit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-	err = tx.QueryRow(query, userID).Scan(&balance)
+	_, err = PostJournalTx(tx, Journal{
+		Key:       key,
+		Operation: OpWithdraw,
+		Reason:    "取出积分",
+		Category:  "saving_withdraw",
+		BotUin:    botUin,
+		Postings: []Posting{
+			{Account: SavingsAccount(userID), Amount: -int64(amount)},
+			{Account: UserAccount(userID), Amount: int64(amount)},
+		},
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("没有存款记录")
-		}
-		return fmt.Errorf("查询存款失败: %w", err)
-	}
-
-	if balance < amount {
-		return fmt.Errorf("存款余额不足，当前余额为: %d", balance)
-	}
-
-	updateQuery := fmt.Sprintf(`UPDATE %s SET "SaveCredit" = "SaveCredit" - $1, "Credit" = "Credit" + $1 WHERE "Id" = $2`, TableUser)
-	_, err = tx.Exec(updateQuery, amount, userID)
-	if err != nil {
-		return fmt.Errorf("取款失败: %w", err)
-	}
-
-	// 记录日志到 Credit 表
-	var newPoints int64
-	tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), userID).Scan(&newPoints)
-
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, 0, botUin, amount, newPoints, "取出积分 [saving_withdraw]")
-	if err != nil {
-		return fmt.Errorf("记录取出积分日志失败: %w", err)
+		return err
 	}
-
 	return tx.Commit()
 }
 
@@ -2006,342 +1883,88 @@ func GetSavingsPoints(db *sql.DB, botUin int64, userID int64) (int, error) {
 	}
 	defer tx.Rollback()
 
-	_, err = applySavingsInterestTx(tx, botUin, userID)
-	if err != nil {
+	if _, err := applySavingsInterestTx(tx, botUin, userID); err != nil {
 		return 0, err
 	}
 
-	var balance int
-	query := fmt.Sprintf(`SELECT "SaveCredit" FROM %s WHERE "Id" = $1`, TableUser)
-	err = tx.QueryRow(query, userID).Scan(&balance)
+	_, balance, err := lockAccountTx(tx, SavingsAccount(userID))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			balance = 0
-		} else {
-			return 0, fmt.Errorf("查询存款失败: %w", err)
-		}
+		return 0, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("提交事务失败: %w", err)
 	}
 
-	return balance, nil
+	return int(balance), nil
 }
 
-func FreezePoints(db *sql.DB, botUin int64, userID int64, groupID int64, amount int64, reason string) error {
-	if amount <= 0 {
-		return fmt.Errorf("冻结积分数量必须大于0")
-	}
-
-	isGroupActive, err := IsGroupCreditSystemEnabled(db, groupID)
+// frozenPostings 可用积分与冻结积分之间的划转，amount 为正表示冻结
+func frozenPostings(db *sql.DB, userID int64, groupID int64, amount int64) ([]Posting, error) {
+	acct, isGroupActive, err := pointsAccount(db, userID, groupID)
 	if err != nil {
-		return fmt.Errorf("检查群积分模式失败: %w", err)
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
-
-	var logType string
-
+	frozen := FrozenAccount(userID, 0)
 	if isGroupActive {
-		logType = "group"
-
-		var available int64
-		query := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2 FOR UPDATE`, TableGroupMember)
-		err = tx.QueryRow(query, userID, groupID).Scan(&available)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("群成员不存在或没有积分")
-			}
-			return fmt.Errorf("查询群成员积分失败: %w", err)
-		}
-
-		if available < amount {
-			return fmt.Errorf("群可用积分不足，当前积分为: %d", available)
-		}
-
-		updateQuery := fmt.Sprintf(`
-		UPDATE %s
-		SET "Credit" = "Credit" - $3, "FreezeCredit" = "FreezeCredit" + $3
-		WHERE "UserId" = $1 AND "GroupId" = $2
-		`, TableGroupMember)
-		_, err = tx.Exec(updateQuery, userID, groupID, amount)
-		if err != nil {
-			return fmt.Errorf("更新群成员冻结积分失败: %w", err)
-		}
-	} else {
-		logType = "global"
-
-		var available int64
-		query := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-		err = tx.QueryRow(query, userID).Scan(&available)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("用户不存在或没有积分")
-			}
-			return fmt.Errorf("查询全局用户积分失败: %w", err)
-		}
-
-		if available < amount {
-			return fmt.Errorf("全局可用积分不足，当前积分为: %d", available)
-		}
-
-		updateQuery := fmt.Sprintf(`
-		UPDATE %s
-		SET "Credit" = "Credit" - $2, "FreezeCredit" = "FreezeCredit" + $2, "UpgradeDate" = CURRENT_TIMESTAMP
-		WHERE "Id" = $1
-		`, TableUser)
-		_, err = tx.Exec(updateQuery, userID, amount)
-		if err != nil {
-			return fmt.Errorf("更新全局冻结积分失败: %w", err)
-		}
+		frozen = FrozenAccount(userID, groupID)
 	}
+	return []Posting{{Account: acct, Amount: -amount}, {Account: frozen, Amount: amount}}, nil
+}
 
-	// 记录日志到 Credit 表
-	var newPoints int64
-	if isGroupActive {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember), userID, groupID).Scan(&newPoints)
-	} else {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), userID).Scan(&newPoints)
+func FreezePoints(db *sql.DB, key string, botUin int64, userID int64, groupID int64, amount int64, reason string) error {
+	if amount <= 0 {
+		return fmt.Errorf("冻结积分数量必须大于0")
 	}
 
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, groupID, botUin, -amount, newPoints, reason+fmt.Sprintf(" [freeze] (%s)", logType))
+	postings, err := frozenPostings(db, userID, groupID, amount)
 	if err != nil {
-		return fmt.Errorf("记录冻结积分日志失败: %w", err)
+		return err
 	}
-
-	return tx.Commit()
+	_, err = PostJournal(db, Journal{Key: key, Operation: OpFreeze, Reason: reason, Category: "freeze", BotUin: botUin, Postings: postings})
+	return err
 }
 
-func UnfreezePoints(db *sql.DB, botUin int64, userID int64, groupID int64, amount int64, reason string) error {
+func UnfreezePoints(db *sql.DB, key string, botUin int64, userID int64, groupID int64, amount int64, reason string) error {
 	if amount <= 0 {
 		return fmt.Errorf("解冻积分数量必须大于0")
 	}
 
-	isGroupActive, err := IsGroupCreditSystemEnabled(db, groupID)
-	if err != nil {
-		return fmt.Errorf("检查群积分模式失败: %w", err)
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
-	}
-	defer tx.Rollback()
-
-	var logType string
-
-	if isGroupActive {
-		logType = "group"
-
-		var frozen int64
-		query := fmt.Sprintf(`SELECT "FreezeCredit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2 FOR UPDATE`, TableGroupMember)
-		err = tx.QueryRow(query, userID, groupID).Scan(&frozen)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("没有群成员冻结积分记录")
-			}
-			return fmt.Errorf("查询群成员冻结积分失败: %w", err)
-		}
-
-		if frozen < amount {
-			return fmt.Errorf("群成员冻结积分不足，当前冻结积分为: %d", frozen)
-		}
-
-		updateQuery := fmt.Sprintf(`
-		UPDATE %s
-		SET "FreezeCredit" = "FreezeCredit" - $3, "Credit" = "Credit" + $3
-		WHERE "UserId" = $1 AND "GroupId" = $2
-		`, TableGroupMember)
-		_, err = tx.Exec(updateQuery, userID, groupID, amount)
-		if err != nil {
-			return fmt.Errorf("更新群成员解冻积分失败: %w", err)
-		}
-	} else {
-		logType = "global"
-
-		var frozen int64
-		query := fmt.Sprintf(`SELECT "FreezeCredit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-		err = tx.QueryRow(query, userID).Scan(&frozen)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("没有全局冻结积分记录")
-			}
-			return fmt.Errorf("查询全局冻结积分失败: %w", err)
-		}
-
-		if frozen < amount {
-			return fmt.Errorf("全局冻结积分不足，当前冻结积分为: %d", frozen)
-		}
-
-		updateQuery := fmt.Sprintf(`
-		UPDATE %s
-		SET "FreezeCredit" = "FreezeCredit" - $2, "Credit" = "Credit" + $2, "UpgradeDate" = CURRENT_TIMESTAMP
-		WHERE "Id" = $1
-		`, TableUser)
-		_, err = tx.Exec(updateQuery, userID, amount)
-		if err != nil {
-			return fmt.Errorf("更新全局解冻积分失败: %w", err)
-		}
-	}
-
-	// 记录日志到 Credit 表
-	var newPoints int64
-	if isGroupActive {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember), userID, groupID).Scan(&newPoints)
-	} else {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), userID).Scan(&newPoints)
-	}
-
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, groupID, botUin, amount, newPoints, reason+fmt.Sprintf(" [unfreeze] (%s)", logType))
+	postings, err := frozenPostings(db, userID, groupID, -amount)
 	if err != nil {
-		return fmt.Errorf("记录解冻积分日志失败: %w", err)
+		return err
 	}
-
-	return tx.Commit()
+	_, err = PostJournal(db, Journal{Key: key, Operation: OpUnfreeze, Reason: reason, Category: "unfreeze", BotUin: botUin, Postings: postings})
+	return err
 }
 
 // TransferPoints 积分转账
-func TransferPoints(db *sql.DB, botUin int64, fromUserID, toUserID int64, groupID int64, amount int64, reason string, category string) error {
+func TransferPoints(db *sql.DB, key string, botUin int64, fromUserID, toUserID int64, groupID int64, amount int64, reason string, category string) error {
 	if amount <= 0 {
 		return fmt.Errorf("转账金额必须大于0")
 	}
-
-	isGroupActive, err := IsGroupCreditSystemEnabled(db, groupID)
-	if err != nil {
-		return fmt.Errorf("检查群积分模式失败: %w", err)
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
-	}
-	defer tx.Rollback()
-
-	var logType string
-
-	if isGroupActive {
-		logType = "group"
-
-		// 1. 检查并锁定转出者积分
-		var fromPoints int64
-		queryFrom := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2 FOR UPDATE`, TableGroupMember)
-		err = tx.QueryRow(queryFrom, fromUserID, groupID).Scan(&fromPoints)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("转出用户在群内不存在或没有积分")
-			}
-			return fmt.Errorf("查询转出用户群积分失败: %w", err)
-		}
-
-		if fromPoints < amount {
-			return fmt.Errorf("群积分不足，当前积分为: %d", fromPoints)
-		}
-
-		// 2. 更新转出者积分
-		updateFrom := fmt.Sprintf(`UPDATE %s SET "Credit" = "Credit" - $3 WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember)
-		_, err = tx.Exec(updateFrom, fromUserID, groupID, amount)
-		if err != nil {
-			return fmt.Errorf("扣除群积分失败: %w", err)
-		}
-
-		// 3. 增加接收者积分
-		updateTo := fmt.Sprintf(`UPDATE %s SET "Credit" = "Credit" + $3 WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember)
-		result, err := tx.Exec(updateTo, toUserID, groupID, amount)
-		if err != nil {
-			return fmt.Errorf("增加接收者群积分失败: %w", err)
-		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			// 群模式下，如果接收者不在群里，通常不自动创建记录
-			return fmt.Errorf("接收者不在群内，无法完成转账")
-		}
-	} else {
-		logType = "global"
-
-		// 1. 检查并锁定转出者积分
-		var fromPoints int64
-		queryFrom := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-		err = tx.QueryRow(queryFrom, fromUserID).Scan(&fromPoints)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return fmt.Errorf("转出用户不存在或没有积分")
-			}
-			return fmt.Errorf("查询转出用户全局积分失败: %w", err)
-		}
-
-		if fromPoints < amount {
-			return fmt.Errorf("全局积分不足，当前积分为: %d", fromPoints)
-		}
-
-		// 2. 更新转出者积分
-		updateFrom := fmt.Sprintf(`UPDATE %s SET "Credit" = "Credit" - $1 WHERE "Id" = $2`, TableUser)
-		_, err = tx.Exec(updateFrom, amount, fromUserID)
-		if err != nil {
-			return fmt.Errorf("扣除全局积分失败: %w", err)
-		}
-
-		// 3. 增加接收者积分（如果不存在则插入）
-		updateTo := fmt.Sprintf(`UPDATE %s SET "Credit" = "Credit" + $1 WHERE "Id" = $2`, TableUser)
-		result, err := tx.Exec(updateTo, amount, toUserID)
-		if err != nil {
-			return fmt.Errorf("增加接收者全局积分失败: %w", err)
-		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			insertQuery := fmt.Sprintf(`
-			INSERT INTO %s ("Id", "Credit", "InsertDate")
-			VALUES ($1, $2, CURRENT_TIMESTAMP)
-			`, TableUser)
-			if _, err := tx.Exec(insertQuery, toUserID, amount); err != nil {
-				return fmt.Errorf("插入接收者全局积分失败: %w", err)
-			}
-		}
+	if fromUserID == toUserID {
+		return fmt.Errorf("不能给自己转账")
 	}
 
-	// 4. 记录日志到 Credit 表 (两条记录)
-	creditLogQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
-	`, TableCredit)
-
-	// 为转出者记录
-	var fromNewPoints int64
-	if isGroupActive {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember), fromUserID, groupID).Scan(&fromNewPoints)
-	} else {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), fromUserID).Scan(&fromNewPoints)
-	}
-	_, err = tx.Exec(creditLogQuery, fromUserID, groupID, botUin, -amount, fromNewPoints, fmt.Sprintf("转账给 %d: %s [%s] (%s)", toUserID, reason, category, logType))
+	from, _, err := pointsAccount(db, fromUserID, groupID)
 	if err != nil {
-		return fmt.Errorf("记录转出日志失败: %w", err)
-	}
-
-	// 为接收者记录
-	var toNewPoints int64
-	if isGroupActive {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2`, TableGroupMember), toUserID, groupID).Scan(&toNewPoints)
-	} else {
-		tx.QueryRow(fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1`, TableUser), toUserID).Scan(&toNewPoints)
+		return err
 	}
-	_, err = tx.Exec(creditLogQuery, toUserID, groupID, botUin, amount, toNewPoints, fmt.Sprintf("来自 %d 的转账: %s [%s] (%s)", fromUserID, reason, category, logType))
+	to, _, err := pointsAccount(db, toUserID, groupID)
 	if err != nil {
-		return fmt.Errorf("记录转入日志失败: %w", err)
+		return err
 	}
 
-	return tx.Commit()
+	_, err = PostJournal(db, Journal{
+		Key:       key,
+		Operation: OpTransfer,
+		Reason:    reason,
+		Category:  category,
+		BotUin:    botUin,
+		Postings:  []Posting{{Account: from, Amount: -amount}, {Account: to, Amount: amount}},
+	})
+	return err
 }
 
 // ------------------- 群管理员相关操作 -------------------
@@ -2381,7 +2004,7 @@ func RemoveGroupAdmin(db *sql.DB, groupID, userID int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("该用户不是群管理员: %s", userID)
+		return fmt.Errorf("该用户不是群管理员: %d", userID)
 	}
 
 	return nil
@@ -2852,20 +2475,14 @@ func GetLocalPoints(db *sql.DB, botUin int64, userID int64) (int64, error) {
 	return points, nil
 }
 
-// UpdateLocalPoints 更新本机积分
-func UpdateLocalPoints(db *sql.DB, botUin int64, userID int64, amount int64) error {
-	query := fmt.Sprintf(`
-	INSERT INTO %s ("BotUin", "UserId", "Credit", "UpdateDate")
-	VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
-	ON CONFLICT ("BotUin", "UserId") DO UPDATE
-	SET "Credit" = %s."Credit" + $3, "UpdateDate" = CURRENT_TIMESTAMP
-	`, TableFriend, TableFriend)
-
-	_, err := db.Exec(query, botUin, userID, amount)
-	if err != nil {
-		return fmt.Errorf("更新本机积分失败: %w", err)
+// UpdateLocalPoints 增加或扣除本机积分。插件桥接接口，opKey 由请求的 echo 或消息 ID 派生，重试的请求不会重复入账；
+// 为空时使用一次性幂等键
+func UpdateLocalPoints(db *sql.DB, opKey string, botUin int64, userID int64, amount int64) error {
+	if amount == 0 {
+		return nil
 	}
-	return nil
+	_, err := PostJournal(db, mintOrBurn(pluginOperationKey("plugin_local", opKey), OpAdjust, botUin, LocalAccount(botUin, userID), amount, "插件操作", "local"))
+	return err
 }
 
 // ------------------- 消费统计与个人激活相关操作 -------------------
@@ -2928,42 +2545,6 @@ func CheckPersonalActivation(db *sql.DB, botUin int64, userID int64) (bool, erro
 
 // ------------------- 打赏与手动调整 -------------------
 
-// updatePointsTx 在事务中更新全局积分
-func updatePointsTx(tx *sql.Tx, userID int64, amount int64, reason string, category string, botUin int64) error {
-	var current int64
-	query := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "Id" = $1 FOR UPDATE`, TableUser)
-	err := tx.QueryRow(query, userID).Scan(&current)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("查询全局积分失败: %w", err)
-	}
-
-	if amount < 0 && current < -amount {
-		return fmt.Errorf("全局积分不足，当前积分为: %d", current)
-	}
-
-	updateQuery := fmt.Sprintf(`
-	INSERT INTO %s ("Id", "Credit", "InsertDate", "UpgradeDate")
-	VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	ON CONFLICT ("Id") DO UPDATE
-	SET "Credit" = %s."Credit" + $2, "UpgradeDate" = CURRENT_TIMESTAMP
-	RETURNING "Credit"
-	`, TableUser, TableUser)
-
-	var newBalance int64
-	err = tx.QueryRow(updateQuery, userID, amount).Scan(&newBalance)
-	if err != nil {
-		return fmt.Errorf("更新全局积分失败: %w", err)
-	}
-
-	// 记录日志
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate", "Category")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, $7)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, 0, botUin, amount, newBalance, reason, category)
-	return err
-}
-
 // GetGroupPoints 获取用户在本群的积分
 func GetGroupPoints(db *sql.DB, userID int64, groupID int64) (int64, error) {
 	var points int64
@@ -2978,68 +2559,55 @@ func GetGroupPoints(db *sql.DB, userID int64, groupID int64) (int64, error) {
 	return points, nil
 }
 
-// UpdateGroupPoints 更新用户在本群的积分
-func UpdateGroupPoints(db *sql.DB, userID int64, groupID int64, amount int64) error {
-	return updateGroupPoints(db, userID, groupID, amount, "插件操作", "group")
+// UpdateGroupPoints 增加或扣除用户在本群的积分。插件桥接接口，幂等键规则同 UpdateLocalPoints
+func UpdateGroupPoints(db *sql.DB, opKey string, userID int64, groupID int64, amount int64) error {
+	if amount == 0 {
+		return nil
+	}
+	_, err := PostJournal(db, mintOrBurn(pluginOperationKey("plugin_group", opKey), OpAdjust, 0, GroupMemberAccount(userID, groupID), amount, "插件操作", "group"))
+	return err
 }
 
-// updateGroupPointsTx 在事务中更新群积分
-func updateGroupPointsTx(tx *sql.Tx, userID int64, groupID int64, amount int64, reason string, category string) error {
-	var current int64
-	query := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "UserId" = $1 AND "GroupId" = $2 FOR UPDATE`, TableGroupMember)
-	err := tx.QueryRow(query, userID, groupID).Scan(&current)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("查询群积分失败: %w", err)
+// pluginOperationKey 插件桥接操作的幂等键，未提供请求标识时退回一次性幂等键
+func pluginOperationKey(prefix, opKey string) string {
+	if opKey == "" {
+		return NewOperationKey(prefix)
 	}
+	return prefix + ":" + opKey
+}
 
-	if amount < 0 && current < -amount {
-		return fmt.Errorf("群积分不足，当前积分为: %d", current)
+// tierAccount 按积分层级返回账户：global 全局积分，group 群积分，local 本机积分
+func tierAccount(tier string, botUin int64, userID int64, groupID int64) (Account, error) {
+	switch tier {
+	case "global":
+		return UserAccount(userID), nil
+	case "group":
+		return GroupMemberAccount(userID, groupID), nil
+	case "local":
+		return LocalAccount(botUin, userID), nil
+	default:
+		return Account{}, fmt.Errorf("无效的积分层级: %s", tier)
 	}
+}
 
-	updateQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "Credit", "InsertDate", "UpdateDate")
-	VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	ON CONFLICT ("UserId", "GroupId") DO UPDATE
-	SET "Credit" = %s."Credit" + $3, "UpdateDate" = CURRENT_TIMESTAMP
-	RETURNING "Credit"
-	`, TableGroupMember, TableGroupMember)
-
-	var newBalance int64
-	err = tx.QueryRow(updateQuery, userID, groupID, amount).Scan(&newBalance)
-	if err != nil {
-		return fmt.Errorf("更新群积分失败: %w", err)
+// TipPoints 打赏积分，非超级积分用户收取 20% 手续费 (转入系统销毁账户)
+func TipPoints(db *sql.DB, key string, botUin int64, fromUserID, toUserID int64, groupID int64, amount int64, tier string) error {
+	if amount <= 0 {
+		return fmt.Errorf("打赏金额必须大于0")
+	}
+	if fromUserID == toUserID {
+		return fmt.Errorf("不能打赏给自己")
 	}
 
-	// 记录日志
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate", "Category")
-	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, $7)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, groupID, 0, amount, newBalance, reason, category)
-	return err
-}
-
-// updateGroupPoints 非事务版本更新群积分
-func updateGroupPoints(db *sql.DB, userID int64, groupID int64, amount int64, reason string, category string) error {
-	tx, err := db.Begin()
+	from, err := tierAccount(tier, botUin, fromUserID, groupID)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
-
-	if err := updateGroupPointsTx(tx, userID, groupID, amount, reason, category); err != nil {
+	to, err := tierAccount(tier, botUin, toUserID, groupID)
+	if err != nil {
 		return err
 	}
 
-	return tx.Commit()
-}
-
-// TipPoints 打赏积分
-func TipPoints(db *sql.DB, botUin int64, fromUserID, toUserID int64, groupID int64, amount int64, tier string) error {
-	if amount <= 0 {
-		return fmt.Errorf("打赏金额必须大于0")
-	}
-
 	// 1. 获取转出者是否为超级用户（免手续费）
 	user, err := GetUserByUserID(db, fromUserID)
 	if err != nil {
@@ -3055,103 +2623,33 @@ func TipPoints(db *sql.DB, botUin int64, fromUserID, toUserID int64, groupID int
 			fee = 1 // 最小手续费
 		}
 	}
-	netAmount := amount - fee
 
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
+	postings := []Posting{{Account: from, Amount: -amount}, {Account: to, Amount: amount - fee}}
+	if fee > 0 {
+		postings = append(postings, Posting{Account: BurnAccount, Amount: fee})
 	}
-	defer tx.Rollback()
-
-	reason := fmt.Sprintf("打赏给用户 %d", toUserID)
-	receiveReason := fmt.Sprintf("收到来自用户 %d 的打赏", fromUserID)
-
-	switch tier {
-	case "global":
-		// 扣除转出者
-		if err := updatePointsTx(tx, fromUserID, -amount, reason, "tip_out", 0); err != nil {
-			return err
-		}
-		// 增加接收者
-		if err := updatePointsTx(tx, toUserID, netAmount, receiveReason, "tip_in", 0); err != nil {
-			return err
-		}
-	case "group":
-		// 扣除转出者
-		if err := updateGroupPointsTx(tx, fromUserID, groupID, -amount, reason, "tip_out"); err != nil {
-			return err
-		}
-		// 增加接收者
-		if err := updateGroupPointsTx(tx, toUserID, groupID, netAmount, receiveReason, "tip_in"); err != nil {
-			return err
-		}
-	case "local":
-		// 扣除转出者
-		if err := updateLocalPointsTx(tx, botUin, fromUserID, -amount, reason, "tip_out"); err != nil {
-			return err
-		}
-		// 增加接收者
-		if err := updateLocalPointsTx(tx, botUin, toUserID, netAmount, receiveReason, "tip_in"); err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("无效的积分层级: %s", tier)
-	}
-
-	return tx.Commit()
+	_, err = PostJournal(db, Journal{
+		Key:       key,
+		Operation: OpTip,
+		Reason:    fmt.Sprintf("用户 %d 打赏用户 %d", fromUserID, toUserID),
+		Category:  "tip",
+		BotUin:    botUin,
+		Postings:  postings,
+	})
+	return err
 }
 
-// updateLocalPointsTx 在事务中更新本机积分
-func updateLocalPointsTx(tx *sql.Tx, botUin int64, userID int64, amount int64, reason string, category string) error {
-	// 检查余额
-	var current int64
-	query := fmt.Sprintf(`SELECT "Credit" FROM %s WHERE "BotUin" = $1 AND "UserId" = $2 FOR UPDATE`, TableFriend)
-	err := tx.QueryRow(query, botUin, userID).Scan(&current)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("查询本机积分失败: %w", err)
-	}
-
-	if amount < 0 && current < -amount {
-		return fmt.Errorf("本机积分不足，当前积分为: %d", current)
-	}
-
-	// 更新积分
-	updateQuery := fmt.Sprintf(`
-	INSERT INTO %s ("BotUin", "UserId", "Credit", "UpdateDate")
-	VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
-	ON CONFLICT ("BotUin", "UserId") DO UPDATE
-	SET "Credit" = %s."Credit" + $3, "UpdateDate" = CURRENT_TIMESTAMP
-	RETURNING "Credit"
-	`, TableFriend, TableFriend)
-
-	var newBalance int64
-	err = tx.QueryRow(updateQuery, botUin, userID, amount).Scan(&newBalance)
+// AdjustPoints 手动调整积分 (增量)
+func AdjustPoints(db *sql.DB, key string, botUin int64, userID int64, groupID int64, amount int64, tier string, reason string) error {
+	acct, err := tierAccount(tier, botUin, userID, groupID)
 	if err != nil {
-		return fmt.Errorf("更新本机积分失败: %w", err)
+		return err
 	}
-
-	// 记录日志
-	logQuery := fmt.Sprintf(`
-	INSERT INTO %s ("UserId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate", "Category")
-	VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, $6)
-	`, TableCredit)
-	_, err = tx.Exec(logQuery, userID, botUin, amount, newBalance, reason, category)
-	return err
-}
-
-// AdjustPoints 手动调整积分
-func AdjustPoints(db *sql.DB, botUin int64, userID int64, groupID int64, amount int64, tier string, reason string) error {
-	switch tier {
-	case "global":
-		return UpdateUserPoints(db, userID, int(amount)) // 注意：UpdateUserPoints 是覆盖式的，而通常调整应该是增量式的。
-		// 应该使用增量式更新，这里我重新写一个增量式的。
-	case "group":
-		return updateGroupPoints(db, userID, groupID, amount, reason, "admin_adjust")
-	case "local":
-		return UpdateLocalPoints(db, botUin, userID, amount)
-	default:
-		return fmt.Errorf("无效的积分层级: %s", tier)
+	if amount == 0 {
+		return nil
 	}
+	_, err = PostJournal(db, mintOrBurn(key, OpAdjust, botUin, acct, amount, reason, "admin_adjust"))
+	return err
 }
 
 // ------------------- 裂变系统相关操作 -------------------
@@ -3165,6 +2663,9 @@ type FissionConfig struct {
 	MinLevelRequired    int       `json:"min_level_required"`
 	MaxDailyInvites     int       `json:"max_daily_invites"`
 	AntiFraudEnabled    bool      `json:"anti_fraud_enabled"`
+	RiskHoldScore       int       `json:"risk_hold_score"`
+	RiskReviewScore     int       `json:"risk_review_score"`
+	RewardHoldHours     int       `json:"reward_hold_hours"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 }
@@ -3172,13 +2673,15 @@ type FissionConfig struct {
 // GetFissionConfig 获取裂变配置
 func GetFissionConfig(db *sql.DB) (*FissionConfig, error) {
 	query := `
-	SELECT id, enabled, invite_reward_points, new_user_reward_points, min_level_required, max_daily_invites, anti_fraud_enabled, created_at, updated_at
+	SELECT id, enabled, invite_reward_points, new_user_reward_points, min_level_required, max_daily_invites, anti_fraud_enabled,
+	       risk_hold_score, risk_review_score, reward_hold_hours, created_at, updated_at
 	FROM fission_configs
 	LIMIT 1
 	`
 	config := &FissionConfig{}
 	err := db.QueryRow(query).Scan(
-		&config.ID, &config.Enabled, &config.InviteRewardPoints, &config.NewUserRewardPoints, &config.MinLevelRequired, &config.MaxDailyInvites, &config.AntiFraudEnabled, &config.CreatedAt, &config.UpdatedAt,
+		&config.ID, &config.Enabled, &config.InviteRewardPoints, &config.NewUserRewardPoints, &config.MinLevelRequired, &config.MaxDailyInvites, &config.AntiFraudEnabled,
+		&config.RiskHoldScore, &config.RiskReviewScore, &config.RewardHoldHours, &config.CreatedAt, &config.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -3292,55 +2795,36 @@ func CreateFissionRewardLog(db *sql.DB, userID int64, rewardType string, amount
 	return nil
 }
 
-// CompleteFissionTask 完成裂变任务并分发奖励
+// CompleteFissionTask 完成裂变任务，将奖励写入发件箱等待发放。每个任务对同一用户只奖励一次
 func CompleteFissionTask(db *sql.DB, userID int64, taskType string) error {
-	// 1. 获取所有进行中的该类型的任务
-	query := `
-	SELECT id, name, reward_points, reward_duration 
-	FROM fission_tasks 
-	WHERE task_type = $1 AND status = 'active'
-	`
-	rows, err := db.Query(query, taskType)
+	tasks, err := GetActiveFissionTasks(db)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var taskID int
-		var taskName string
-		var points, duration int
-		if err := rows.Scan(&taskID, &taskName, &points, &duration); err != nil {
+	for _, t := range tasks {
+		if t.TaskType != taskType || t.RewardPoints <= 0 {
 			continue
 		}
-
-		// 2. 检查用户是否已经完成过此任务
-		var exists bool
-		checkQuery := `SELECT EXISTS(SELECT 1 FROM fission_reward_logs WHERE user_id = $1 AND reason LIKE $2)`
-		_ = db.QueryRow(checkQuery, userID, "%"+taskName+"%").Scan(&exists)
-		if exists {
-			continue
-		}
-
-		// 3. 发放奖励
-		reason := fmt.Sprintf("完成裂变任务: %s", taskName)
-		if points > 0 {
-			_ = AddPoints(db, 0, userID, 0, int64(points), reason, "fission_task")
-			_ = CreateFissionRewardLog(db, userID, "points", points, reason)
-		}
-
-		// 4. 如果是 register 任务，且该用户是被邀请者，也要给邀请者额外奖励（如果配置了）
-		if taskType == "register" {
-			inviterID, _, status, err := GetInvitationByInviteeID(db, userID)
-			if err == nil && inviterID != 0 && status == "completed" {
-				// 可以根据需要增加邀请者的额外奖励逻辑
-			}
+		err := EnqueueFissionReward(db, FissionReward{
+			UserID:   userID,
+			Amount:   t.RewardPoints,
+			Reason:   fmt.Sprintf("完成裂变任务: %s", t.Name),
+			Category: "fission_task",
+			Key:      FissionTaskRewardKey(userID, t.ID),
+		})
+		if err != nil {
+			return err
 		}
 	}
-
 	return nil
 }
 
+// FissionTaskRewardKey 任务奖励的幂等键
+func FissionTaskRewardKey(userID int64, taskID int) string {
+	return fmt.Sprintf("fission_task:%d:%d", userID, taskID)
+}
+
 // GetInvitationByInviteeID 根据被邀请者ID获取邀请记录
 func GetInvitationByInviteeID(db *sql.DB, inviteeID int64) (int64, string, string, error) {
 	query := `SELECT inviter_id, invite_code, status FROM invitations WHERE invitee_id = $1`
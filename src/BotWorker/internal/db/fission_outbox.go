@@ -0,0 +1,328 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ------------------- 裂变绑定与奖励发件箱 -------------------
+//
+// 绑定邀请与写入待发奖励在同一事务内完成，奖励先进入 fission_reward_outbox，再由 DispatchFissionRewards
+// 逐条通过积分账本入账。发放失败会按退避时间重试；奖励的幂等键同时用作账本幂等键，进程在入账后崩溃也不会重复发放。
+
+// 邀请状态
+const (
+	InvitationPending   = "pending"   // 已绑定，奖励待发放
+	InvitationHeld      = "held"      // 风险分偏高，奖励延迟发放，期间可人工拦截
+	InvitationReview    = "review"    // 风险分过高，奖励需人工审核通过后发放
+	InvitationCompleted = "completed" // 奖励已全部发放
+	InvitationRejected  = "rejected"  // 审核拒绝，奖励已取消
+)
+
+// 发件箱状态
+const (
+	OutboxPending   = "pending"   // 到达 AvailableAt 后自动发放
+	OutboxReview    = "review"    // 等待人工审核，不会自动发放
+	OutboxDone      = "done"      // 已入账
+	OutboxCancelled = "cancelled" // 审核拒绝
+	OutboxFailed    = "failed"    // 重试次数用尽
+)
+
+// outboxMaxAttempts 单条奖励的最大发放次数
+const outboxMaxAttempts = 5
+
+// FissionReward 一条待发放的裂变奖励
+type FissionReward struct {
+	UserID   int64
+	Amount   int
+	Reason   string
+	Category string
+	// Key 幂等键，如 "fission_invite:<被邀请者>"
+	Key string
+}
+
+// InvitationBind 一次邀请绑定及其奖励
+type InvitationBind struct {
+	InviterID   int64
+	InviteeID   int64
+	Platform    string
+	InviteCode  string
+	IPAddress   string
+	DeviceID    string
+	Status      string // InvitationPending、InvitationHeld 或 InvitationReview
+	RiskScore   int
+	RiskSignals string // JSON
+	// AvailableAt 奖励最早发放时间
+	AvailableAt time.Time
+	Rewards     []FissionReward
+}
+
+// BindInvitation 在一个事务内写入邀请记录与全部待发奖励。被邀请者已绑定过时返回 false
+func BindInvitation(db *sql.DB, b InvitationBind) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var invitationID int64
+	err = tx.QueryRow(`
+	INSERT INTO invitations (inviter_id, invitee_id, platform, invite_code, status, ip_address, device_id, risk_score, risk_signals, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT (invitee_id) DO NOTHING
+	RETURNING id
+	`, b.InviterID, b.InviteeID, b.Platform, b.InviteCode, b.Status, b.IPAddress, b.DeviceID, b.RiskScore, b.RiskSignals).Scan(&invitationID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("创建邀请记录失败: %w", err)
+	}
+
+	status := OutboxPending
+	if b.Status == InvitationReview {
+		status = OutboxReview
+	}
+	for _, r := range b.Rewards {
+		if err := enqueueFissionRewardTx(tx, invitationID, r, status, b.AvailableAt); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交绑定事务失败: %w", err)
+	}
+	return true, nil
+}
+
+// EnqueueFissionReward 写入一条与邀请无关的奖励 (如任务奖励)，幂等键已存在时忽略
+func EnqueueFissionReward(db *sql.DB, r FissionReward) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := enqueueFissionRewardTx(tx, 0, r, OutboxPending, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func enqueueFissionRewardTx(tx *sql.Tx, invitationID int64, r FissionReward, status string, availableAt time.Time) error {
+	if r.Amount <= 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+	INSERT INTO fission_reward_outbox (invitation_id, user_id, amount, reason, category, idempotency_key, status, available_at, attempts, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT (idempotency_key) DO NOTHING
+	`, invitationID, r.UserID, r.Amount, r.Reason, r.Category, r.Key, status, availableAt)
+	if err != nil {
+		return fmt.Errorf("写入待发奖励失败: %w", err)
+	}
+	return nil
+}
+
+// DispatchFissionRewards 发放已到期的奖励，返回成功入账的条数。多个进程可同时执行，已被锁定的奖励会被跳过
+func DispatchFissionRewards(db *sql.DB, limit int) (int, error) {
+	rows, err := db.Query(`
+	SELECT id FROM fission_reward_outbox
+	WHERE status = $1 AND available_at <= CURRENT_TIMESTAMP
+	ORDER BY id
+	LIMIT $2
+	`, OutboxPending, limit)
+	if err != nil {
+		return 0, fmt.Errorf("查询待发奖励失败: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, id := range ids {
+		ok, err := settleFissionReward(db, id)
+		if err != nil {
+			if markErr := markFissionRewardFailed(db, id, err); markErr != nil {
+				return settled, markErr
+			}
+			continue
+		}
+		if ok {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// settleFissionReward 在一个事务内入账奖励、写入奖励日志与裂变统计，并在邀请的全部奖励发放后将其标记为完成
+func settleFissionReward(db *sql.DB, id int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var invitationID int64
+	var r FissionReward
+	err = tx.QueryRow(`
+	SELECT invitation_id, user_id, amount, reason, category, idempotency_key
+	FROM fission_reward_outbox
+	WHERE id = $1 AND status = $2
+	FOR UPDATE SKIP LOCKED
+	`, id, OutboxPending).Scan(&invitationID, &r.UserID, &r.Amount, &r.Reason, &r.Category, &r.Key)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("锁定待发奖励失败: %w", err)
+	}
+
+	if _, err := PostJournalTx(tx, mintOrBurn(r.Key, OpAdd, 0, UserAccount(r.UserID), int64(r.Amount), r.Reason, r.Category)); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`
+	INSERT INTO fission_reward_logs (user_id, type, amount, reason, created_at)
+	VALUES ($1, 'points', $2, $3, CURRENT_TIMESTAMP)
+	`, r.UserID, r.Amount, r.Reason); err != nil {
+		return false, fmt.Errorf("记录裂变奖励日志失败: %w", err)
+	}
+
+	inviteIncr := 0
+	if r.Category == "fission_invite" {
+		inviteIncr = 1
+	}
+	if _, err := tx.Exec(`
+	INSERT INTO user_fission_records (user_id, invite_count, total_rewards, points, updated_at)
+	VALUES ($1, $2, $3, $3, CURRENT_TIMESTAMP)
+	ON CONFLICT (user_id) DO UPDATE
+	SET invite_count = user_fission_records.invite_count + $2,
+	    total_rewards = user_fission_records.total_rewards + $3,
+	    points = user_fission_records.points + $3,
+	    updated_at = CURRENT_TIMESTAMP
+	`, r.UserID, inviteIncr, r.Amount); err != nil {
+		return false, fmt.Errorf("更新用户裂变记录失败: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE fission_reward_outbox SET status = $1, attempts = attempts + 1, last_error = '', updated_at = CURRENT_TIMESTAMP WHERE id = $2`, OutboxDone, id); err != nil {
+		return false, fmt.Errorf("更新奖励状态失败: %w", err)
+	}
+
+	if invitationID > 0 {
+		if _, err := tx.Exec(`
+		UPDATE invitations SET status = $1, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status IN ($3, $4)
+		AND NOT EXISTS (
+			SELECT 1 FROM fission_reward_outbox WHERE invitation_id = $2 AND status NOT IN ($5, $6)
+		)
+		`, InvitationCompleted, invitationID, InvitationPending, InvitationHeld, OutboxDone, OutboxCancelled); err != nil {
+			return false, fmt.Errorf("更新邀请状态失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交奖励事务失败: %w", err)
+	}
+	return true, nil
+}
+
+// markFissionRewardFailed 记录发放失败，按次数指数退避，超过上限后不再自动重试
+func markFissionRewardFailed(db *sql.DB, id int64, cause error) error {
+	_, err := db.Exec(`
+	UPDATE fission_reward_outbox
+	SET attempts = attempts + 1,
+	    last_error = $2,
+	    status = CASE WHEN attempts + 1 >= $3 THEN $4 ELSE status END,
+	    available_at = CURRENT_TIMESTAMP + (POWER(2, attempts) * INTERVAL '1 minute'),
+	    updated_at = CURRENT_TIMESTAMP
+	WHERE id = $1
+	`, id, cause.Error(), outboxMaxAttempts, OutboxFailed)
+	if err != nil {
+		return fmt.Errorf("记录奖励发放失败: %w", err)
+	}
+	return nil
+}
+
+// ------------------- 邀请关系图 -------------------
+
+// InvitationEdge 邀请关系图中的一条边 (邀请者 → 被邀请者)
+type InvitationEdge struct {
+	ID        int64
+	InviterID int64
+	InviteeID int64
+	IPAddress string
+	DeviceID  string
+	Status    string
+	CreatedAt time.Time
+}
+
+// GetInvitationAncestors 沿邀请链向上追溯 userID 的上级，返回由近及远的边，最多 depth 条
+func GetInvitationAncestors(db *sql.DB, userID int64, depth int) ([]InvitationEdge, error) {
+	var edges []InvitationEdge
+	seen := map[int64]bool{userID: true}
+	current := userID
+	for i := 0; i < depth; i++ {
+		var e InvitationEdge
+		err := db.QueryRow(`
+		SELECT id, inviter_id, invitee_id, COALESCE(ip_address, ''), COALESCE(device_id, ''), status, created_at
+		FROM invitations WHERE invitee_id = $1
+		`, current).Scan(&e.ID, &e.InviterID, &e.InviteeID, &e.IPAddress, &e.DeviceID, &e.Status, &e.CreatedAt)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询邀请链失败: %w", err)
+		}
+		edges = append(edges, e)
+		if seen[e.InviterID] {
+			// 数据中已存在环，停止追溯
+			break
+		}
+		seen[e.InviterID] = true
+		current = e.InviterID
+	}
+	return edges, nil
+}
+
+// GetInvitationSubtree 返回以 rootID 为根、深度不超过 depth 的邀请子树中的全部边
+func GetInvitationSubtree(db *sql.DB, rootID int64, depth int) ([]InvitationEdge, error) {
+	rows, err := db.Query(`
+	WITH RECURSIVE tree AS (
+		SELECT id, inviter_id, invitee_id, ip_address, device_id, status, created_at, 1 AS depth
+		FROM invitations WHERE inviter_id = $1
+		UNION ALL
+		SELECT i.id, i.inviter_id, i.invitee_id, i.ip_address, i.device_id, i.status, i.created_at, t.depth + 1
+		FROM invitations i JOIN tree t ON i.inviter_id = t.invitee_id
+		WHERE t.depth < $2
+	)
+	SELECT id, inviter_id, invitee_id, COALESCE(ip_address, ''), COALESCE(device_id, ''), status, created_at
+	FROM tree
+	LIMIT 5000
+	`, rootID, depth)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请子树失败: %w", err)
+	}
+	defer rows.Close()
+
+	var edges []InvitationEdge
+	for rows.Next() {
+		var e InvitationEdge
+		if err := rows.Scan(&e.ID, &e.InviterID, &e.InviteeID, &e.IPAddress, &e.DeviceID, &e.Status, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描邀请子树失败: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
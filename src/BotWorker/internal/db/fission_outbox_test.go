@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const fissionTestSchema = `
+CREATE TABLE invitations (id INTEGER PRIMARY KEY AUTOINCREMENT, inviter_id INTEGER NOT NULL, invitee_id INTEGER NOT NULL UNIQUE,
+	platform TEXT, invite_code TEXT, status TEXT NOT NULL, ip_address TEXT, device_id TEXT, risk_score INTEGER, risk_signals TEXT,
+	completed_at TIMESTAMP, created_at TIMESTAMP, updated_at TIMESTAMP);
+CREATE TABLE fission_reward_outbox (id INTEGER PRIMARY KEY AUTOINCREMENT, invitation_id INTEGER NOT NULL DEFAULT 0, user_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL, reason TEXT, category TEXT, idempotency_key TEXT NOT NULL UNIQUE, status TEXT NOT NULL,
+	available_at TIMESTAMP NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP, updated_at TIMESTAMP);
+CREATE TABLE fission_reward_logs (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER, type TEXT, amount INTEGER, reason TEXT, created_at TIMESTAMP);
+CREATE TABLE user_fission_records (user_id INTEGER PRIMARY KEY, invite_count INTEGER NOT NULL DEFAULT 0, total_rewards INTEGER NOT NULL DEFAULT 0,
+	points INTEGER NOT NULL DEFAULT 0, updated_at TIMESTAMP);
+`
+
+func invitationStatus(t *testing.T, db *sql.DB, inviteeID int64) string {
+	t.Helper()
+	var status string
+	if err := db.QueryRow(`SELECT status FROM invitations WHERE invitee_id = $1`, inviteeID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func bindWithRewards(t *testing.T, db *sql.DB, inviter, invitee int64, status string, availableAt time.Time) {
+	t.Helper()
+	ok, err := BindInvitation(db, InvitationBind{
+		InviterID: inviter, InviteeID: invitee, Status: status, AvailableAt: availableAt.UTC(),
+		Rewards: []FissionReward{
+			{UserID: inviter, Amount: 10, Reason: "邀请奖励", Category: "fission_invite", Key: "fission_invite:" + strconv.FormatInt(invitee, 10)},
+			{UserID: invitee, Amount: 5, Reason: "新人奖励", Category: "fission_welcome", Key: "fission_welcome:" + strconv.FormatInt(invitee, 10)},
+			{UserID: invitee, Amount: 0, Reason: "空奖励", Category: "noop", Key: "noop:" + strconv.FormatInt(invitee, 10)},
+		},
+	})
+	if err != nil || !ok {
+		t.Fatalf("bind %d -> %d: ok=%v err=%v", inviter, invitee, ok, err)
+	}
+}
+
+func TestFissionOutboxSettlement(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name        string
+		status      string
+		availableAt time.Time
+		settled     int
+		inviter     int64 // 邀请者应得积分
+		invitee     int64
+		invitation  string
+	}{
+		{name: "pending rewards settle", status: InvitationPending, availableAt: past, settled: 2, inviter: 10, invitee: 5, invitation: InvitationCompleted},
+		{name: "held rewards wait until available", status: InvitationHeld, availableAt: time.Now().Add(time.Hour), invitation: InvitationHeld},
+		{name: "held rewards settle after the delay", status: InvitationHeld, availableAt: past, settled: 2, inviter: 10, invitee: 5, invitation: InvitationCompleted},
+		{name: "review rewards are never dispatched automatically", status: InvitationReview, availableAt: past, invitation: InvitationReview},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newLedgerTestDB(t, fissionTestSchema)
+			bindWithRewards(t, db, 1, 2, tt.status, tt.availableAt)
+
+			var queued int
+			db.QueryRow(`SELECT COUNT(*) FROM fission_reward_outbox`).Scan(&queued)
+			if queued != 2 {
+				t.Fatalf("queued rewards = %d, want 2 (zero amounts skipped)", queued)
+			}
+
+			settled, err := DispatchFissionRewards(db, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settled != tt.settled {
+				t.Fatalf("settled = %d, want %d", settled, tt.settled)
+			}
+			mustBalance(t, db, UserAccount(1), tt.inviter)
+			mustBalance(t, db, UserAccount(2), tt.invitee)
+			if got := invitationStatus(t, db, 2); got != tt.invitation {
+				t.Fatalf("invitation status = %s, want %s", got, tt.invitation)
+			}
+
+			// 再次派发不会重复入账
+			if again, err := DispatchFissionRewards(db, 10); err != nil || again != 0 {
+				t.Fatalf("second dispatch settled %d (%v)", again, err)
+			}
+			mustBalance(t, db, UserAccount(1), tt.inviter)
+			mustReconcile(t, db)
+		})
+	}
+}
+
+func TestFissionOutboxSettlementRecordsStats(t *testing.T) {
+	db := newLedgerTestDB(t, fissionTestSchema)
+	past := time.Now().Add(-time.Hour)
+	bindWithRewards(t, db, 1, 2, InvitationPending, past)
+	bindWithRewards(t, db, 1, 3, InvitationPending, past)
+
+	if ok, err := BindInvitation(db, InvitationBind{InviterID: 9, InviteeID: 2, Status: InvitationPending}); err != nil || ok {
+		t.Fatalf("rebinding an invitee should be ignored: ok=%v err=%v", ok, err)
+	}
+	if settled, err := DispatchFissionRewards(db, 10); err != nil || settled != 4 {
+		t.Fatalf("settled = %d (%v)", settled, err)
+	}
+
+	var invites, total int
+	db.QueryRow(`SELECT invite_count, total_rewards FROM user_fission_records WHERE user_id = 1`).Scan(&invites, &total)
+	if invites != 2 || total != 20 {
+		t.Fatalf("inviter record = %d invites, %d rewards", invites, total)
+	}
+	var logs int
+	db.QueryRow(`SELECT COUNT(*) FROM fission_reward_logs`).Scan(&logs)
+	if logs != 4 {
+		t.Fatalf("reward logs = %d, want 4", logs)
+	}
+
+	// 奖励的幂等键同时是账本幂等键：发件箱状态丢失后重新发放也不会重复入账
+	db.Exec(`UPDATE fission_reward_outbox SET status = $1`, OutboxPending)
+	DispatchFissionRewards(db, 10)
+	mustBalance(t, db, UserAccount(1), 20)
+	mustReconcile(t, db)
+}
@@ -0,0 +1,562 @@
+package db
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ------------------- 积分账本 (复式记账) -------------------
+//
+// 每次积分变动都是一笔日志 (journal)，由若干分录 (entry) 组成，同一日志内分录金额之和恒为 0：
+// 发放积分记为 "系统铸币账户 → 用户账户"，扣除记为 "用户账户 → 系统销毁账户"，转账、冻结、存取款则在用户账户之间流转。
+// 日志以调用方提供的幂等键去重，消息重试不会重复入账。
+// 账户余额快照 (ledger_accounts."Balance") 与原有的 Credit/FreezeCredit/SaveCredit 字段在同一事务中更新，
+// 后者作为兼容旧代码的只读缓存保留，可通过 ReconcileLedger 与分录核对。
+
+const (
+	TableLedgerAccount = "ledger_accounts"
+	TableLedgerJournal = "ledger_journals"
+	TableLedgerEntry   = "ledger_entries"
+)
+
+// LedgerSchema 积分账本表结构。InitDatabase 不自动建表，可由运维执行或通过 ledger 命令的 -init-schema 创建
+const LedgerSchema = `
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+	"Id"         BIGSERIAL PRIMARY KEY,
+	"Type"       VARCHAR(32) NOT NULL,
+	"UserId"     BIGINT NOT NULL DEFAULT 0,
+	"GroupId"    BIGINT NOT NULL DEFAULT 0,
+	"BotUin"     BIGINT NOT NULL DEFAULT 0,
+	"Balance"    BIGINT NOT NULL DEFAULT 0,
+	"UpdateDate" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE ("Type", "UserId", "GroupId", "BotUin")
+);
+CREATE TABLE IF NOT EXISTS ledger_journals (
+	"Id"             BIGSERIAL PRIMARY KEY,
+	"IdempotencyKey" VARCHAR(191) NOT NULL UNIQUE,
+	"Operation"      VARCHAR(32) NOT NULL,
+	"Reason"         TEXT NOT NULL DEFAULT '',
+	"Category"       VARCHAR(64) NOT NULL DEFAULT '',
+	"BotUin"         BIGINT NOT NULL DEFAULT 0,
+	"InsertDate"     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS ledger_entries (
+	"Id"           BIGSERIAL PRIMARY KEY,
+	"JournalId"    BIGINT NOT NULL REFERENCES ledger_journals ("Id"),
+	"AccountId"    BIGINT NOT NULL REFERENCES ledger_accounts ("Id"),
+	"Amount"       BIGINT NOT NULL,
+	"BalanceAfter" BIGINT
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries ("AccountId");
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_journal ON ledger_entries ("JournalId");
+`
+
+// EnsureLedgerSchema 创建积分账本表 (已存在则跳过)
+func EnsureLedgerSchema(db *sql.DB) error {
+	if _, err := db.Exec(LedgerSchema); err != nil {
+		return fmt.Errorf("创建积分账本表失败: %w", err)
+	}
+	return nil
+}
+
+// CheckLedgerSchema 检查积分账本表是否存在。所有积分读写都经过账本，缺表时 Worker 应拒绝启动而不是在首次记账时失败
+func CheckLedgerSchema(db *sql.DB) error {
+	for _, table := range []string{TableLedgerAccount, TableLedgerJournal, TableLedgerEntry} {
+		rows, err := db.Query(fmt.Sprintf(`SELECT 1 FROM %s LIMIT 0`, table))
+		if err != nil {
+			return fmt.Errorf("%w: 缺少 %s 表，请先执行 go run ./cmd/ledger -init-schema 或导入 LedgerSchema (%v)", ErrLedgerSchemaMissing, table, err)
+		}
+		rows.Close()
+	}
+	return nil
+}
+
+// AccountType 账本账户类型
+type AccountType string
+
+const (
+	AccountUser        AccountType = "user"         // 全局积分 (users."Credit")
+	AccountGroupMember AccountType = "group_member" // 群积分 (group_members."Credit")
+	AccountLocal       AccountType = "local"        // 本机积分 (friends."Credit")
+	AccountFrozen      AccountType = "frozen"       // 冻结积分，GroupID 为 0 表示全局冻结 ("FreezeCredit")
+	AccountSavings     AccountType = "savings"      // 存款 (users."SaveCredit")
+	AccountSystemMint  AccountType = "system_mint"  // 系统铸币：所有发放积分的来源，余额为负
+	AccountSystemBurn  AccountType = "system_burn"  // 系统销毁：所有扣除积分与手续费的去向
+)
+
+// 日志操作类型
+const (
+	OpOpening  = "opening"  // 首次接入账本时按原字段余额建立期初余额
+	OpAdd      = "add"      // 发放或扣除
+	OpTransfer = "transfer" // 转账
+	OpTip      = "tip"      // 打赏 (含手续费)
+	OpFreeze   = "freeze"
+	OpUnfreeze = "unfreeze"
+	OpDeposit  = "deposit"  // 存入存款
+	OpWithdraw = "withdraw" // 取出存款
+	OpInterest = "interest" // 存款利息
+	OpAdjust   = "adjust"   // 管理员或插件调整
+)
+
+// Account 账本账户，由类型与归属唯一确定
+type Account struct {
+	Type    AccountType
+	UserID  int64
+	GroupID int64
+	BotUin  int64
+}
+
+// UserAccount 用户全局积分账户
+func UserAccount(userID int64) Account { return Account{Type: AccountUser, UserID: userID} }
+
+// GroupMemberAccount 用户在群内的积分账户
+func GroupMemberAccount(userID, groupID int64) Account {
+	return Account{Type: AccountGroupMember, UserID: userID, GroupID: groupID}
+}
+
+// LocalAccount 用户在某个机器人下的本机积分账户
+func LocalAccount(botUin, userID int64) Account {
+	return Account{Type: AccountLocal, UserID: userID, BotUin: botUin}
+}
+
+// FrozenAccount 冻结积分账户，groupID 为 0 表示全局
+func FrozenAccount(userID, groupID int64) Account {
+	return Account{Type: AccountFrozen, UserID: userID, GroupID: groupID}
+}
+
+// SavingsAccount 用户存款账户
+func SavingsAccount(userID int64) Account { return Account{Type: AccountSavings, UserID: userID} }
+
+var (
+	MintAccount = Account{Type: AccountSystemMint}
+	BurnAccount = Account{Type: AccountSystemBurn}
+)
+
+func (a Account) String() string {
+	return fmt.Sprintf("%s:u%d:g%d:b%d", a.Type, a.UserID, a.GroupID, a.BotUin)
+}
+
+// IsSystem 系统账户不维护余额快照，也不受余额不能为负的限制
+func (a Account) IsSystem() bool {
+	return a.Type == AccountSystemMint || a.Type == AccountSystemBurn
+}
+
+// Posting 一条分录：Amount 为正表示账户增加，为负表示减少
+type Posting struct {
+	Account Account
+	Amount  int64
+}
+
+// Journal 一笔积分变动
+type Journal struct {
+	// Key 调用方提供的幂等键，同一业务操作重试时必须相同，如 "fission_invite:10001"
+	Key       string
+	Operation string
+	Reason    string
+	Category  string
+	BotUin    int64
+	Postings  []Posting
+}
+
+var (
+	ErrIdempotencyKeyRequired = errors.New("积分操作缺少幂等键")
+	ErrUnbalancedJournal      = errors.New("积分分录借贷不平衡")
+	ErrLedgerSchemaMissing    = errors.New("积分账本表未初始化")
+)
+
+// InsufficientPointsError 账户余额不足
+type InsufficientPointsError struct {
+	Account Account
+	Balance int64
+}
+
+func (e *InsufficientPointsError) Error() string {
+	return fmt.Sprintf("%s不足，当前积分为: %d", accountLabel(e.Account.Type), e.Balance)
+}
+
+func accountLabel(t AccountType) string {
+	switch t {
+	case AccountGroupMember:
+		return "群积分"
+	case AccountLocal:
+		return "本机积分"
+	case AccountFrozen:
+		return "冻结积分"
+	case AccountSavings:
+		return "存款余额"
+	default:
+		return "全局积分"
+	}
+}
+
+// Validate 检查日志是否可以入账：幂等键非空、至少两条分录、金额之和为 0
+func (j Journal) Validate() error {
+	if strings.TrimSpace(j.Key) == "" {
+		return ErrIdempotencyKeyRequired
+	}
+	if len(j.Postings) < 2 {
+		return fmt.Errorf("%w: 至少需要两条分录", ErrUnbalancedJournal)
+	}
+	var sum int64
+	for _, p := range j.Postings {
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("%w: 合计 %d", ErrUnbalancedJournal, sum)
+	}
+	return nil
+}
+
+// NewOperationKey 为没有天然业务标识的操作生成一次性幂等键。这类操作重试时无法去重，仅在调用方未提供请求标识时兜底
+func NewOperationKey(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + ":" + hex.EncodeToString(b)
+}
+
+// PostJournal 在独立事务中入账。幂等键已存在时不做任何修改并返回 applied=false
+func PostJournal(db *sql.DB, j Journal) (applied bool, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if applied, err = PostJournalTx(tx, j); err != nil || !applied {
+		return applied, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交积分事务失败: %w", err)
+	}
+	return true, nil
+}
+
+// PostJournalTx 在调用方事务中入账，语义同 PostJournal
+func PostJournalTx(tx *sql.Tx, j Journal) (bool, error) {
+	if err := j.Validate(); err != nil {
+		return false, err
+	}
+
+	var journalID int64
+	err := tx.QueryRow(fmt.Sprintf(`
+	INSERT INTO %s ("IdempotencyKey", "Operation", "Reason", "Category", "BotUin", "InsertDate")
+	VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+	ON CONFLICT ("IdempotencyKey") DO NOTHING
+	RETURNING "Id"
+	`, TableLedgerJournal), j.Key, j.Operation, j.Reason, j.Category, j.BotUin).Scan(&journalID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("写入积分日志失败: %w", err)
+	}
+
+	// 同一账户的分录合并，并按固定顺序加锁，避免并发转账互相等待造成死锁
+	amounts := make(map[Account]int64, len(j.Postings))
+	for _, p := range j.Postings {
+		amounts[p.Account] += p.Amount
+	}
+	accounts := make([]Account, 0, len(amounts))
+	for a := range amounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, k int) bool { return accounts[i].String() < accounts[k].String() })
+
+	for _, a := range accounts {
+		amount := amounts[a]
+		accountID, balance, err := lockAccountTx(tx, a)
+		if err != nil {
+			return false, err
+		}
+
+		var balanceAfter sql.NullInt64
+		if !a.IsSystem() {
+			newBalance := balance + amount
+			if newBalance < 0 {
+				return false, &InsufficientPointsError{Account: a, Balance: balance}
+			}
+			if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET "Balance" = $1, "UpdateDate" = CURRENT_TIMESTAMP WHERE "Id" = $2`, TableLedgerAccount), newBalance, accountID); err != nil {
+				return false, fmt.Errorf("更新账户余额失败: %w", err)
+			}
+			if err := syncLegacyBalanceTx(tx, a, newBalance); err != nil {
+				return false, err
+			}
+			balanceAfter = sql.NullInt64{Int64: newBalance, Valid: true}
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf(`
+		INSERT INTO %s ("JournalId", "AccountId", "Amount", "BalanceAfter")
+		VALUES ($1, $2, $3, $4)
+		`, TableLedgerEntry), journalID, accountID, amount, balanceAfter); err != nil {
+			return false, fmt.Errorf("写入积分分录失败: %w", err)
+		}
+
+		if amount != 0 && j.Operation != OpOpening && writesCreditLog(a.Type) {
+			if err := insertCreditLogTx(tx, a, j, amount, balanceAfter.Int64); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// lockAccountTx 锁定账户并返回其 ID 与余额快照。账户首次出现时按原字段余额建立期初余额
+func lockAccountTx(tx *sql.Tx, a Account) (int64, int64, error) {
+	res, err := tx.Exec(fmt.Sprintf(`
+	INSERT INTO %s ("Type", "UserId", "GroupId", "BotUin", "Balance", "UpdateDate")
+	VALUES ($1, $2, $3, $4, 0, CURRENT_TIMESTAMP)
+	ON CONFLICT ("Type", "UserId", "GroupId", "BotUin") DO NOTHING
+	`, TableLedgerAccount), a.Type, a.UserID, a.GroupID, a.BotUin)
+	if err != nil {
+		return 0, 0, fmt.Errorf("创建积分账户失败: %w", err)
+	}
+	created, _ := res.RowsAffected()
+
+	if created > 0 && !a.IsSystem() {
+		opening, err := legacyBalanceTx(tx, a)
+		if err != nil {
+			return 0, 0, err
+		}
+		if opening != 0 {
+			counter := MintAccount
+			if opening < 0 {
+				counter = BurnAccount
+			}
+			if _, err := PostJournalTx(tx, Journal{
+				Key:       "opening:" + a.String(),
+				Operation: OpOpening,
+				Reason:    "期初余额",
+				Category:  OpOpening,
+				Postings:  []Posting{{Account: a, Amount: opening}, {Account: counter, Amount: -opening}},
+			}); err != nil {
+				return 0, 0, fmt.Errorf("建立期初余额失败: %w", err)
+			}
+		}
+	}
+
+	var id, balance int64
+	query := fmt.Sprintf(`SELECT "Id", "Balance" FROM %s WHERE "Type" = $1 AND "UserId" = $2 AND "GroupId" = $3 AND "BotUin" = $4`, TableLedgerAccount)
+	if !a.IsSystem() {
+		// 系统账户不维护余额，无需加锁，避免所有发放操作在同一行上排队
+		query += " FOR UPDATE"
+	}
+	if err := tx.QueryRow(query, a.Type, a.UserID, a.GroupID, a.BotUin).Scan(&id, &balance); err != nil {
+		return 0, 0, fmt.Errorf("查询积分账户失败: %w", err)
+	}
+	return id, balance, nil
+}
+
+// legacyField 账户在原有表中对应的余额字段
+type legacyField struct {
+	table, column string
+	keys          []string
+	args          []any
+	stamps        []string // 插入新行时需要填写的时间字段
+}
+
+func legacyFieldOf(a Account) (legacyField, bool) {
+	switch a.Type {
+	case AccountUser:
+		return legacyField{TableUser, "Credit", []string{"Id"}, []any{a.UserID}, []string{"InsertDate"}}, true
+	case AccountGroupMember:
+		return legacyField{TableGroupMember, "Credit", []string{"UserId", "GroupId"}, []any{a.UserID, a.GroupID}, []string{"InsertDate", "UpdateDate"}}, true
+	case AccountLocal:
+		return legacyField{TableFriend, "Credit", []string{"BotUin", "UserId"}, []any{a.BotUin, a.UserID}, []string{"UpdateDate"}}, true
+	case AccountFrozen:
+		if a.GroupID > 0 {
+			return legacyField{TableGroupMember, "FreezeCredit", []string{"UserId", "GroupId"}, []any{a.UserID, a.GroupID}, []string{"InsertDate", "UpdateDate"}}, true
+		}
+		return legacyField{TableUser, "FreezeCredit", []string{"Id"}, []any{a.UserID}, []string{"InsertDate"}}, true
+	case AccountSavings:
+		return legacyField{TableUser, "SaveCredit", []string{"Id"}, []any{a.UserID}, []string{"InsertDate"}}, true
+	}
+	return legacyField{}, false
+}
+
+func (f legacyField) where(offset int) string {
+	conds := make([]string, len(f.keys))
+	for i, k := range f.keys {
+		conds[i] = fmt.Sprintf(`"%s" = $%d`, k, i+offset)
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// legacyBalanceTx 读取原字段余额，记录不存在时为 0
+func legacyBalanceTx(q interface {
+	QueryRow(string, ...any) *sql.Row
+}, a Account) (int64, error) {
+	f, ok := legacyFieldOf(a)
+	if !ok {
+		return 0, nil
+	}
+	var balance sql.NullInt64
+	err := q.QueryRow(fmt.Sprintf(`SELECT "%s" FROM %s WHERE %s`, f.column, f.table, f.where(1)), f.args...).Scan(&balance)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("查询%s失败: %w", accountLabel(a.Type), err)
+	}
+	return balance.Int64, nil
+}
+
+// syncLegacyBalanceTx 将余额快照写回原字段，记录不存在时插入
+func syncLegacyBalanceTx(tx *sql.Tx, a Account, balance int64) error {
+	f, ok := legacyFieldOf(a)
+	if !ok {
+		return nil
+	}
+	res, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET "%s" = $1 WHERE %s`, f.table, f.column, f.where(2)), append([]any{balance}, f.args...)...)
+	if err != nil {
+		return fmt.Errorf("更新%s失败: %w", accountLabel(a.Type), err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 || balance == 0 {
+		return nil
+	}
+
+	cols := make([]string, 0, len(f.keys)+1+len(f.stamps))
+	vals := make([]string, 0, cap(cols))
+	for i, k := range f.keys {
+		cols = append(cols, `"`+k+`"`)
+		vals = append(vals, fmt.Sprintf("$%d", i+1))
+	}
+	cols = append(cols, `"`+f.column+`"`)
+	vals = append(vals, fmt.Sprintf("$%d", len(f.keys)+1))
+	for _, s := range f.stamps {
+		cols = append(cols, `"`+s+`"`)
+		vals = append(vals, "CURRENT_TIMESTAMP")
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, f.table, strings.Join(cols, ", "), strings.Join(vals, ", "))
+	if _, err := tx.Exec(query, append(append([]any{}, f.args...), balance)...); err != nil {
+		return fmt.Errorf("初始化%s失败: %w", accountLabel(a.Type), err)
+	}
+	return nil
+}
+
+// writesCreditLog 可用积分账户的变动继续写入 Credit 表，供原有的积分明细查询使用
+func writesCreditLog(t AccountType) bool {
+	return t == AccountUser || t == AccountGroupMember || t == AccountLocal
+}
+
+func insertCreditLogTx(tx *sql.Tx, a Account, j Journal, amount, balance int64) error {
+	botUin := j.BotUin
+	if a.Type == AccountLocal {
+		botUin = a.BotUin
+	}
+	info := j.Reason
+	if j.Category != "" {
+		info = fmt.Sprintf("%s [%s]", j.Reason, j.Category)
+	}
+	_, err := tx.Exec(fmt.Sprintf(`
+	INSERT INTO %s ("UserId", "GroupId", "BotUin", "CreditAdd", "CreditValue", "CreditInfo", "InsertDate", "Category")
+	VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, $7)
+	`, TableCredit), a.UserID, a.GroupID, botUin, amount, balance, info, j.Category)
+	if err != nil {
+		return fmt.Errorf("记录积分日志到 Credit 表失败: %w", err)
+	}
+	return nil
+}
+
+// GetLedgerBalance 返回账户的余额快照，账户尚未接入账本时返回原字段余额
+func GetLedgerBalance(db *sql.DB, a Account) (int64, error) {
+	var balance int64
+	err := db.QueryRow(fmt.Sprintf(`SELECT "Balance" FROM %s WHERE "Type" = $1 AND "UserId" = $2 AND "GroupId" = $3 AND "BotUin" = $4`, TableLedgerAccount),
+		a.Type, a.UserID, a.GroupID, a.BotUin).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return legacyBalanceTx(db, a)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询账户余额失败: %w", err)
+	}
+	return balance, nil
+}
+
+// ------------------- 对账 -------------------
+
+// BalanceMismatch 账户余额快照、分录合计与原字段三者不一致
+type BalanceMismatch struct {
+	Account  Account
+	Snapshot int64 // ledger_accounts."Balance"
+	Journal  int64 // 分录金额合计
+	Legacy   int64 // 原有表中的余额字段
+}
+
+// ReconcileReport 对账结果
+type ReconcileReport struct {
+	Accounts           int
+	Journals           int
+	UnbalancedJournals []int64
+	Mismatches         []BalanceMismatch
+	// SystemNet 铸币与销毁账户分录合计，应与全部用户账户余额之和互为相反数
+	SystemNet int64
+}
+
+// OK 全部日志平衡且所有账户余额一致
+func (r ReconcileReport) OK() bool {
+	return len(r.UnbalancedJournals) == 0 && len(r.Mismatches) == 0
+}
+
+// ReconcileLedger 核对每一笔日志是否平衡，以及每个账户的余额快照、原字段是否等于其分录合计
+func ReconcileLedger(db *sql.DB) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+	if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, TableLedgerJournal)).Scan(&report.Journals); err != nil {
+		return nil, fmt.Errorf("统计积分日志失败: %w", err)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`SELECT "JournalId" FROM %s GROUP BY "JournalId" HAVING SUM("Amount") <> 0 ORDER BY "JournalId"`, TableLedgerEntry))
+	if err != nil {
+		return nil, fmt.Errorf("核对积分日志失败: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.UnbalancedJournals = append(report.UnbalancedJournals, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(fmt.Sprintf(`
+	SELECT a."Type", a."UserId", a."GroupId", a."BotUin", a."Balance", COALESCE(SUM(e."Amount"), 0)
+	FROM %s a LEFT JOIN %s e ON e."AccountId" = a."Id"
+	GROUP BY a."Id", a."Type", a."UserId", a."GroupId", a."BotUin", a."Balance"
+	ORDER BY a."Id"
+	`, TableLedgerAccount, TableLedgerEntry))
+	if err != nil {
+		return nil, fmt.Errorf("核对账户余额失败: %w", err)
+	}
+	var candidates []BalanceMismatch
+	for rows.Next() {
+		var m BalanceMismatch
+		if err := rows.Scan(&m.Account.Type, &m.Account.UserID, &m.Account.GroupID, &m.Account.BotUin, &m.Snapshot, &m.Journal); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Accounts++
+		if m.Account.IsSystem() {
+			report.SystemNet += m.Journal
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range candidates {
+		if m.Legacy, err = legacyBalanceTx(db, m.Account); err != nil {
+			return nil, err
+		}
+		if m.Snapshot != m.Journal || m.Legacy != m.Journal {
+			report.Mismatches = append(report.Mismatches, m)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,388 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+)
+
+// ledgerTestSchema 与 LedgerSchema 及原有表结构对应的 SQLite 版本，仅包含账本读写涉及的字段
+const ledgerTestSchema = `
+CREATE TABLE users ("Id" INTEGER PRIMARY KEY, "UserOpenid" TEXT NOT NULL DEFAULT '', "Name" TEXT NOT NULL DEFAULT '',
+	"IsSuper" BOOLEAN NOT NULL DEFAULT false, "Credit" INTEGER NOT NULL DEFAULT 0, "SaveCredit" INTEGER NOT NULL DEFAULT 0,
+	"FreezeCredit" INTEGER NOT NULL DEFAULT 0, "InsertDate" TIMESTAMP NOT NULL);
+CREATE TABLE groups ("Oid" INTEGER PRIMARY KEY, "IsCreditSystem" BOOLEAN NOT NULL DEFAULT false);
+CREATE TABLE group_members ("UserId" INTEGER NOT NULL, "GroupId" INTEGER NOT NULL, "Credit" INTEGER NOT NULL DEFAULT 0,
+	"FreezeCredit" INTEGER NOT NULL DEFAULT 0, "InsertDate" TIMESTAMP NOT NULL, "UpdateDate" TIMESTAMP NOT NULL, UNIQUE ("UserId", "GroupId"));
+CREATE TABLE friends ("BotUin" INTEGER NOT NULL, "UserId" INTEGER NOT NULL, "Credit" INTEGER NOT NULL DEFAULT 0, "UpdateDate" TIMESTAMP NOT NULL);
+CREATE TABLE credits ("UserId" INTEGER, "GroupId" INTEGER, "BotUin" INTEGER, "CreditAdd" INTEGER, "CreditValue" INTEGER,
+	"CreditInfo" TEXT, "InsertDate" TIMESTAMP, "Category" TEXT);
+CREATE TABLE user_savings_metadata ("UserId" INTEGER PRIMARY KEY, "LastInterestAt" TIMESTAMP, "UpdateDate" TIMESTAMP);
+CREATE TABLE ledger_accounts ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "Type" TEXT NOT NULL, "UserId" INTEGER NOT NULL DEFAULT 0,
+	"GroupId" INTEGER NOT NULL DEFAULT 0, "BotUin" INTEGER NOT NULL DEFAULT 0, "Balance" INTEGER NOT NULL DEFAULT 0,
+	"UpdateDate" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, UNIQUE ("Type", "UserId", "GroupId", "BotUin"));
+CREATE TABLE ledger_journals ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "IdempotencyKey" TEXT NOT NULL UNIQUE, "Operation" TEXT NOT NULL,
+	"Reason" TEXT NOT NULL DEFAULT '', "Category" TEXT NOT NULL DEFAULT '', "BotUin" INTEGER NOT NULL DEFAULT 0,
+	"InsertDate" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+CREATE TABLE ledger_entries ("Id" INTEGER PRIMARY KEY AUTOINCREMENT, "JournalId" INTEGER NOT NULL, "AccountId" INTEGER NOT NULL,
+	"Amount" INTEGER NOT NULL, "BalanceAfter" INTEGER);
+`
+
+// ledgerTestDriver 包装 SQLite 驱动：SQLite 没有行锁，去掉查询中的 FOR UPDATE [SKIP LOCKED] (单连接下事务本身已串行)
+type ledgerTestDriver struct{ driver.Driver }
+
+func (d ledgerTestDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return ledgerTestConn{c}, nil
+}
+
+type ledgerTestConn struct{ driver.Conn }
+
+var rowLockClause = regexp.MustCompile(`\s+FOR UPDATE( SKIP LOCKED)?`)
+
+func (c ledgerTestConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rowLockClause.ReplaceAllString(query, ""))
+}
+
+var registerLedgerDriver sync.Once
+
+// newLedgerTestDB 创建带账本与原有积分表的内存数据库，extra 为附加的建表语句
+func newLedgerTestDB(t *testing.T, extra ...string) *sql.DB {
+	t.Helper()
+	registerLedgerDriver.Do(func() {
+		base, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			panic(err)
+		}
+		sql.Register("ledger_sqlite", ledgerTestDriver{base.Driver()})
+		base.Close()
+	})
+
+	db, err := sql.Open("ledger_sqlite", fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	for _, schema := range append([]string{ledgerTestSchema}, extra...) {
+		if _, err := db.Exec(schema); err != nil {
+			t.Fatalf("create schema: %v", err)
+		}
+	}
+	return db
+}
+
+func mustBalance(t *testing.T, db *sql.DB, a Account, want int64) {
+	t.Helper()
+	got, err := GetLedgerBalance(db, a)
+	if err != nil {
+		t.Fatalf("balance of %s: %v", a, err)
+	}
+	if got != want {
+		t.Fatalf("balance of %s = %d, want %d", a, got, want)
+	}
+	legacy, err := legacyBalanceTx(db, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy != want {
+		t.Fatalf("legacy balance of %s = %d, want %d", a, legacy, want)
+	}
+}
+
+func mustReconcile(t *testing.T, db *sql.DB) *ReconcileReport {
+	t.Helper()
+	report, err := ReconcileLedger(db)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("ledger out of balance: %+v", report)
+	}
+	return report
+}
+
+func TestJournalValidate(t *testing.T) {
+	user := UserAccount(1)
+	tests := []struct {
+		name    string
+		journal Journal
+		wantErr error
+	}{
+		{name: "balanced", journal: Journal{Key: "k", Postings: []Posting{{user, 10}, {MintAccount, -10}}}},
+		{name: "missing key", journal: Journal{Key: " ", Postings: []Posting{{user, 10}, {MintAccount, -10}}}, wantErr: ErrIdempotencyKeyRequired},
+		{name: "single posting", journal: Journal{Key: "k", Postings: []Posting{{user, 0}}}, wantErr: ErrUnbalancedJournal},
+		{name: "unbalanced", journal: Journal{Key: "k", Postings: []Posting{{user, 10}, {MintAccount, -9}}}, wantErr: ErrUnbalancedJournal},
+		{name: "three way balanced", journal: Journal{Key: "k", Postings: []Posting{{user, -10}, {UserAccount(2), 8}, {BurnAccount, 2}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.journal.Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPostJournal(t *testing.T) {
+	tests := []struct {
+		name     string
+		journals []Journal
+		wantErr  bool
+		want     map[Account]int64
+		applied  []bool
+		logs     int // 写入 credits 表的明细条数
+	}{
+		{
+			name:     "mint",
+			journals: []Journal{mintOrBurn("a", OpAdd, 0, UserAccount(1), 100, "签到", "sign")},
+			want:     map[Account]int64{UserAccount(1): 100},
+			applied:  []bool{true},
+			logs:     1,
+		},
+		{
+			name: "idempotency key replay",
+			journals: []Journal{
+				mintOrBurn("same", OpAdd, 0, UserAccount(1), 100, "签到", "sign"),
+				mintOrBurn("same", OpAdd, 0, UserAccount(1), 100, "签到", "sign"),
+			},
+			want:    map[Account]int64{UserAccount(1): 100},
+			applied: []bool{true, false},
+			logs:    1,
+		},
+		{
+			name: "transfer",
+			journals: []Journal{
+				mintOrBurn("m", OpAdd, 0, UserAccount(1), 100, "签到", "sign"),
+				{Key: "t", Operation: OpTransfer, Postings: []Posting{{UserAccount(1), -30}, {UserAccount(2), 30}}},
+			},
+			want:    map[Account]int64{UserAccount(1): 70, UserAccount(2): 30},
+			applied: []bool{true, true},
+			logs:    3,
+		},
+		{
+			name: "insufficient funds",
+			journals: []Journal{
+				mintOrBurn("m", OpAdd, 0, UserAccount(1), 10, "签到", "sign"),
+				mintOrBurn("b", OpAdd, 0, UserAccount(1), -11, "扣除", "spend"),
+			},
+			wantErr: true,
+			want:    map[Account]int64{UserAccount(1): 10},
+			applied: []bool{true, false},
+			logs:    1,
+		},
+		{
+			name:     "freeze logs only the available account",
+			journals: []Journal{mintOrBurn("m", OpAdd, 0, UserAccount(1), 50, "签到", "sign"), {Key: "f", Operation: OpFreeze, Postings: []Posting{{UserAccount(1), -20}, {FrozenAccount(1, 0), 20}}}},
+			want:     map[Account]int64{UserAccount(1): 30, FrozenAccount(1, 0): 20},
+			applied:  []bool{true, true},
+			logs:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newLedgerTestDB(t)
+			var lastErr error
+			for i, j := range tt.journals {
+				applied, err := PostJournal(db, j)
+				lastErr = err
+				if applied != tt.applied[i] {
+					t.Fatalf("journal %d applied = %v, want %v (err %v)", i, applied, tt.applied[i], err)
+				}
+			}
+			if tt.wantErr {
+				var insufficient *InsufficientPointsError
+				if !errors.As(lastErr, &insufficient) || insufficient.Account != UserAccount(1) || insufficient.Balance != 10 {
+					t.Fatalf("err = %v, want InsufficientPointsError", lastErr)
+				}
+			} else if lastErr != nil {
+				t.Fatal(lastErr)
+			}
+			for a, want := range tt.want {
+				mustBalance(t, db, a, want)
+			}
+			var logs int
+			db.QueryRow(`SELECT COUNT(*) FROM credits`).Scan(&logs)
+			if logs != tt.logs {
+				t.Fatalf("credit logs = %d, want %d", logs, tt.logs)
+			}
+			mustReconcile(t, db)
+		})
+	}
+}
+
+func TestOpeningBalanceFromLegacyField(t *testing.T) {
+	db := newLedgerTestDB(t)
+	db.Exec(`INSERT INTO users ("Id", "Credit", "InsertDate") VALUES (1, 50, CURRENT_TIMESTAMP)`)
+
+	if err := AddPoints(db, "k", 0, 1, 0, 10, "签到", "sign"); err != nil {
+		t.Fatal(err)
+	}
+	mustBalance(t, db, UserAccount(1), 60)
+
+	var openings int
+	db.QueryRow(`SELECT COUNT(*) FROM ledger_journals WHERE "Operation" = $1`, OpOpening).Scan(&openings)
+	if openings != 1 {
+		t.Fatalf("opening journals = %d, want 1", openings)
+	}
+	if report := mustReconcile(t, db); report.SystemNet != -60 {
+		t.Fatalf("system net = %d, want -60", report.SystemNet)
+	}
+}
+
+func TestSavingsTransfer(t *testing.T) {
+	db := newLedgerTestDB(t)
+	if err := AddPoints(db, "m", 0, 1, 0, 100, "签到", "sign"); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name          string
+		deposit       bool
+		key           string
+		amount        int
+		wantErr       bool
+		user, savings int64
+	}{
+		{name: "deposit", deposit: true, key: "d1", amount: 40, user: 60, savings: 40},
+		{name: "deposit replay", deposit: true, key: "d1", amount: 40, user: 60, savings: 40},
+		{name: "deposit more than balance", deposit: true, key: "d2", amount: 61, wantErr: true, user: 60, savings: 40},
+		{name: "withdraw more than savings", key: "w1", amount: 41, wantErr: true, user: 60, savings: 40},
+		{name: "withdraw", key: "w2", amount: 15, user: 75, savings: 25},
+	}
+	for _, s := range steps {
+		var err error
+		if s.deposit {
+			err = DepositPointsToSavings(db, s.key, 0, 1, s.amount)
+		} else {
+			err = WithdrawPointsFromSavings(db, s.key, 0, 1, s.amount)
+		}
+		var insufficient *InsufficientPointsError
+		if s.wantErr != errors.As(err, &insufficient) {
+			t.Fatalf("%s: err = %v", s.name, err)
+		}
+		if !s.wantErr && err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		mustBalance(t, db, UserAccount(1), s.user)
+		mustBalance(t, db, SavingsAccount(1), s.savings)
+	}
+	mustReconcile(t, db)
+}
+
+func TestReconcileDetectsDrift(t *testing.T) {
+	db := newLedgerTestDB(t)
+	if err := TransferPoints(db, "t", 0, 1, 2, 0, 1, "转账", "transfer"); err == nil {
+		t.Fatal("transfer from an empty account should fail")
+	}
+	if err := AddPoints(db, "m", 0, 1, 0, 100, "签到", "sign"); err != nil {
+		t.Fatal(err)
+	}
+	if err := TransferPoints(db, "t", 0, 1, 2, 0, 30, "转账", "transfer"); err != nil {
+		t.Fatal(err)
+	}
+	report := mustReconcile(t, db)
+	if report.Journals != 2 || report.SystemNet != -100 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// 绕过账本直接改写原字段
+	db.Exec(`UPDATE users SET "Credit" = 999 WHERE "Id" = 2`)
+	report, err := ReconcileLedger(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Mismatches) != 1 || report.Mismatches[0].Account != UserAccount(2) || report.Mismatches[0].Legacy != 999 || report.Mismatches[0].Journal != 30 {
+		t.Fatalf("mismatches = %+v", report.Mismatches)
+	}
+
+	// 篡改分录金额导致日志不平衡
+	db.Exec(`UPDATE ledger_entries SET "Amount" = "Amount" + 1 WHERE "Id" = (SELECT MAX("Id") FROM ledger_entries)`)
+	if report, _ = ReconcileLedger(db); len(report.UnbalancedJournals) != 1 {
+		t.Fatalf("unbalanced journals = %v", report.UnbalancedJournals)
+	}
+}
+
+func TestUserWritesGoThroughLedger(t *testing.T) {
+	db := newLedgerTestDB(t)
+	user := &User{UserID: 1, Nickname: "alice", Points: 30, SavingsPoints: 5}
+	if err := CreateUser(db, user); err != nil {
+		t.Fatal(err)
+	}
+	mustBalance(t, db, UserAccount(1), 30)
+	mustBalance(t, db, SavingsAccount(1), 5)
+	if err := AddPoints(db, "m", 0, 1, 0, 5, "签到", "sign"); err != nil {
+		t.Fatal(err)
+	}
+	mustBalance(t, db, UserAccount(1), 35)
+
+	user.Nickname, user.Points, user.SavingsPoints = "alice2", 12, 0
+	if err := UpdateUser(db, user); err != nil {
+		t.Fatal(err)
+	}
+	mustBalance(t, db, UserAccount(1), 12)
+	mustBalance(t, db, SavingsAccount(1), 0)
+
+	var name string
+	db.QueryRow(`SELECT "Name" FROM users WHERE "Id" = 1`).Scan(&name)
+	if name != "alice2" {
+		t.Fatalf("name = %q", name)
+	}
+	if err := UpdateUser(db, &User{UserID: 404}); err == nil {
+		t.Fatal("updating a missing user should fail")
+	}
+	mustReconcile(t, db)
+}
+
+func TestCheckLedgerSchema(t *testing.T) {
+	db := newLedgerTestDB(t)
+	if err := CheckLedgerSchema(db); err != nil {
+		t.Fatalf("complete schema: %v", err)
+	}
+
+	db.Exec(`DROP TABLE ledger_entries`)
+	err := CheckLedgerSchema(db)
+	if !errors.Is(err, ErrLedgerSchemaMissing) || !strings.Contains(err.Error(), TableLedgerEntry) {
+		t.Fatalf("err = %v, want ErrLedgerSchemaMissing naming %s", err, TableLedgerEntry)
+	}
+}
+
+func TestPluginPointsAreIdempotentPerRequest(t *testing.T) {
+	db := newLedgerTestDB(t)
+	db.Exec(`INSERT INTO friends ("BotUin", "UserId", "Credit", "UpdateDate") VALUES (9, 1, 0, CURRENT_TIMESTAMP)`)
+
+	// 同一请求重试只入账一次，新的请求正常入账
+	for _, key := range []string{"echo-1:local", "echo-1:local", "echo-2:local"} {
+		if err := UpdateLocalPoints(db, key, 9, 1, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustBalance(t, db, LocalAccount(9, 1), 20)
+
+	for _, key := range []string{"echo-3:group", "echo-3:group"} {
+		if err := UpdateGroupPoints(db, key, 1, 100, 5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustBalance(t, db, GroupMemberAccount(1, 100), 5)
+
+	// 未提供请求标识时每次都入账
+	UpdateGroupPoints(db, "", 1, 100, 5)
+	UpdateGroupPoints(db, "", 1, 100, 5)
+	mustBalance(t, db, GroupMemberAccount(1, 100), 15)
+	mustReconcile(t, db)
+}
@@ -0,0 +1,203 @@
+package fission
+
+import (
+	"fmt"
+	"time"
+
+	"botworker/internal/db"
+)
+
+// RiskPolicy 邀请关系图风险评估参数
+type RiskPolicy struct {
+	// Depth 向上追溯与向下展开邀请树的层数
+	Depth int
+	// ChainWindow 邀请链上相邻两次绑定间隔小于该值时，视为脚本批量注册形成的链
+	ChainWindow time.Duration
+	// BurstWindow 与 BurstCount：邀请者在窗口内直接邀请的人数达到该值视为突发
+	BurstWindow time.Duration
+	BurstCount  int
+	// HoldScore 与 ReviewScore 风险分达到对应值时奖励延迟发放或转人工审核
+	HoldScore   int
+	ReviewScore int
+}
+
+// DefaultRiskPolicy 默认风险评估参数
+func DefaultRiskPolicy() RiskPolicy {
+	return RiskPolicy{
+		Depth:       3,
+		ChainWindow: 30 * time.Second,
+		BurstWindow: 10 * time.Minute,
+		BurstCount:  5,
+		HoldScore:   40,
+		ReviewScore: 70,
+	}
+}
+
+// RiskSignal 单项风险信号
+type RiskSignal struct {
+	Code   string `json:"code"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// RiskAssessment 一次绑定的风险评估结果，Score 为 0-100
+type RiskAssessment struct {
+	Score   int          `json:"score"`
+	Signals []RiskSignal `json:"signals"`
+}
+
+func (a *RiskAssessment) add(code string, score int, format string, args ...any) {
+	a.Signals = append(a.Signals, RiskSignal{Code: code, Score: score, Detail: fmt.Sprintf(format, args...)})
+	a.Score += score
+	if a.Score > 100 {
+		a.Score = 100
+	}
+}
+
+// Status 按风险分返回邀请状态
+func (a RiskAssessment) Status(p RiskPolicy) string {
+	switch {
+	case p.ReviewScore > 0 && a.Score >= p.ReviewScore:
+		return db.InvitationReview
+	case p.HoldScore > 0 && a.Score >= p.HoldScore:
+		return db.InvitationHeld
+	default:
+		return db.InvitationPending
+	}
+}
+
+// InviteGraph 待评估绑定周边的邀请关系
+type InviteGraph struct {
+	// Ancestors 邀请者的上级邀请链，由近及远
+	Ancestors []db.InvitationEdge
+	// Tree 邀请链顶端用户的邀请子树
+	Tree []db.InvitationEdge
+}
+
+// Score 评估新绑定 candidate 的风险：
+//   - 邀请环：被邀请者出现在邀请者的上级链中
+//   - 指纹复用：候选的 IP 或设备在同一邀请树的任意层级出现过
+//   - 快速邀请链：上级链中相邻绑定间隔均在 ChainWindow 内
+//   - 突发邀请：邀请者短时间内直接邀请大量用户
+//   - 密集簇：邀请树中大部分绑定通过共享的 IP 或设备连成一片
+func Score(g InviteGraph, candidate db.InvitationEdge, p RiskPolicy) RiskAssessment {
+	var a RiskAssessment
+
+	// 1. 邀请环
+	for _, e := range g.Ancestors {
+		if e.InviterID == candidate.InviteeID {
+			a.add("cycle", 100, "被邀请者 %d 是邀请者的上级", candidate.InviteeID)
+			return a
+		}
+	}
+
+	// 2. 指纹复用 (跨层级)
+	var sharedIP, sharedDevice *db.InvitationEdge
+	for _, list := range [][]db.InvitationEdge{g.Ancestors, g.Tree} {
+		for i := range list {
+			e := &list[i]
+			if sharedDevice == nil && candidate.DeviceID != "" && e.DeviceID == candidate.DeviceID {
+				sharedDevice = e
+			}
+			if sharedIP == nil && candidate.IPAddress != "" && e.IPAddress == candidate.IPAddress {
+				sharedIP = e
+			}
+		}
+	}
+	if sharedDevice != nil {
+		a.add("shared_device", 40, "设备与邀请树中的用户 %d 相同", sharedDevice.InviteeID)
+	}
+	if sharedIP != nil {
+		a.add("shared_ip", 25, "IP 与邀请树中的用户 %d 相同", sharedIP.InviteeID)
+	}
+
+	// 3. 快速邀请链
+	links := 0
+	prev := candidate.CreatedAt
+	for _, e := range g.Ancestors {
+		if prev.Sub(e.CreatedAt) > p.ChainWindow {
+			break
+		}
+		links++
+		prev = e.CreatedAt
+	}
+	if links > 0 {
+		score := 15 * links
+		if score > 45 {
+			score = 45
+		}
+		a.add("rapid_chain", score, "上级邀请链中连续 %d 次绑定间隔小于 %s", links, p.ChainWindow)
+	}
+
+	// 4. 突发邀请
+	if p.BurstCount > 0 {
+		recent := 1
+		for _, e := range g.Tree {
+			if e.InviterID == candidate.InviterID && candidate.CreatedAt.Sub(e.CreatedAt) <= p.BurstWindow {
+				recent++
+			}
+		}
+		if recent >= p.BurstCount {
+			a.add("burst", 20, "邀请者 %s 内邀请了 %d 人", p.BurstWindow, recent)
+		}
+	}
+
+	// 5. 密集簇
+	edges := make([]db.InvitationEdge, 0, len(g.Ancestors)+len(g.Tree)+1)
+	edges = append(edges, g.Ancestors...)
+	edges = append(edges, g.Tree...)
+	edges = append(edges, candidate)
+	if size := largestFingerprintCluster(edges); size >= 4 && size*2 >= len(edges) {
+		a.add("dense_cluster", 30, "邀请树 %d 个绑定中有 %d 个通过共享 IP 或设备相连", len(edges), size)
+	}
+
+	return a
+}
+
+// largestFingerprintCluster 将共享 IP 或设备的绑定合并为簇，返回最大簇中至少与他人共享指纹的绑定数
+func largestFingerprintCluster(edges []db.InvitationEdge) int {
+	parent := make([]int, len(edges))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	firstByKey := make(map[string]int)
+	linked := make([]bool, len(edges))
+	for i, e := range edges {
+		for _, key := range []string{"ip:" + e.IPAddress, "dev:" + e.DeviceID} {
+			if key == "ip:" || key == "dev:" {
+				continue
+			}
+			if j, ok := firstByKey[key]; ok {
+				union(i, j)
+				linked[i], linked[j] = true, true
+			} else {
+				firstByKey[key] = i
+			}
+		}
+	}
+
+	sizes := make(map[int]int)
+	largest := 0
+	for i := range edges {
+		if !linked[i] {
+			continue
+		}
+		root := find(i)
+		sizes[root]++
+		if sizes[root] > largest {
+			largest = sizes[root]
+		}
+	}
+	return largest
+}
@@ -0,0 +1,134 @@
+package fission
+
+import (
+	"testing"
+	"time"
+
+	"botworker/internal/db"
+)
+
+func signalCodes(a RiskAssessment) map[string]bool {
+	codes := make(map[string]bool, len(a.Signals))
+	for _, s := range a.Signals {
+		codes[s.Code] = true
+	}
+	return codes
+}
+
+func TestScoreSignals(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	edge := func(inviter, invitee int64, ip, device string, minutes int) db.InvitationEdge {
+		return db.InvitationEdge{InviterID: inviter, InviteeID: invitee, IPAddress: ip, DeviceID: device, CreatedAt: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+	policy := DefaultRiskPolicy()
+
+	tests := []struct {
+		name      string
+		graph     InviteGraph
+		candidate db.InvitationEdge
+		want      []string
+		absent    []string
+		score     int
+	}{
+		{
+			name:      "cycle",
+			graph:     InviteGraph{Ancestors: []db.InvitationEdge{edge(2, 3, "", "", 0), edge(1, 2, "", "", -60)}},
+			candidate: edge(3, 1, "", "", 60),
+			want:      []string{"cycle"},
+			score:     100,
+		},
+		{
+			name:      "shared ip across levels",
+			graph:     InviteGraph{Ancestors: []db.InvitationEdge{edge(1, 2, "10.0.0.1", "d1", -600)}, Tree: []db.InvitationEdge{edge(2, 4, "10.0.0.9", "d4", -300)}},
+			candidate: edge(2, 5, "10.0.0.9", "d5", 0),
+			want:      []string{"shared_ip"},
+			absent:    []string{"shared_device", "cycle", "dense_cluster"},
+			score:     25,
+		},
+		{
+			name:      "shared device",
+			graph:     InviteGraph{Ancestors: []db.InvitationEdge{edge(1, 2, "10.0.0.1", "d1", -600)}},
+			candidate: edge(2, 5, "10.0.0.5", "d1", 0),
+			want:      []string{"shared_device"},
+			absent:    []string{"shared_ip"},
+			score:     40,
+		},
+		{
+			name: "dense cluster",
+			graph: InviteGraph{Tree: []db.InvitationEdge{
+				edge(1, 2, "10.0.0.1", "", -600),
+				edge(1, 3, "10.0.0.1", "d3", -500),
+				edge(2, 4, "10.0.0.4", "d3", -400),
+				edge(3, 6, "10.0.0.6", "d6", -300),
+			}},
+			candidate: edge(4, 5, "10.0.0.4", "", 0),
+			want:      []string{"dense_cluster", "shared_ip"},
+			absent:    []string{"shared_device"},
+			score:     55,
+		},
+		{
+			name: "shared fingerprints below cluster size",
+			graph: InviteGraph{Tree: []db.InvitationEdge{
+				edge(1, 2, "10.0.0.1", "d2", -600),
+				edge(1, 3, "10.0.0.3", "d3", -500),
+				edge(1, 4, "10.0.0.4", "d4", -400),
+			}},
+			candidate: edge(1, 5, "10.0.0.1", "d5", 0),
+			want:      []string{"shared_ip"},
+			absent:    []string{"dense_cluster"},
+			score:     25,
+		},
+		{
+			name:      "rapid chain",
+			graph:     InviteGraph{Ancestors: []db.InvitationEdge{{InviterID: 2, InviteeID: 3, CreatedAt: base.Add(-10 * time.Second)}, {InviterID: 1, InviteeID: 2, CreatedAt: base.Add(-20 * time.Second)}}},
+			candidate: db.InvitationEdge{InviterID: 3, InviteeID: 4, CreatedAt: base},
+			want:      []string{"rapid_chain"},
+			score:     30,
+		},
+		{
+			name:      "clean",
+			graph:     InviteGraph{Ancestors: []db.InvitationEdge{edge(1, 2, "10.0.0.1", "d1", -600)}, Tree: []db.InvitationEdge{edge(2, 3, "10.0.0.3", "d3", -300)}},
+			candidate: edge(2, 4, "10.0.0.4", "d4", 0),
+			absent:    []string{"cycle", "shared_ip", "shared_device", "dense_cluster", "rapid_chain", "burst"},
+			score:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Score(tt.graph, tt.candidate, policy)
+			codes := signalCodes(a)
+			for _, c := range tt.want {
+				if !codes[c] {
+					t.Errorf("missing signal %q in %+v", c, a.Signals)
+				}
+			}
+			for _, c := range tt.absent {
+				if codes[c] {
+					t.Errorf("unexpected signal %q in %+v", c, a.Signals)
+				}
+			}
+			if a.Score != tt.score {
+				t.Errorf("score = %d, want %d (%+v)", a.Score, tt.score, a.Signals)
+			}
+		})
+	}
+}
+
+func TestAssessmentStatus(t *testing.T) {
+	p := DefaultRiskPolicy()
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{0, db.InvitationPending},
+		{p.HoldScore - 1, db.InvitationPending},
+		{p.HoldScore, db.InvitationHeld},
+		{p.ReviewScore, db.InvitationReview},
+		{100, db.InvitationReview},
+	}
+	for _, tt := range tests {
+		if got := (RiskAssessment{Score: tt.score}).Status(p); got != tt.want {
+			t.Errorf("Status(%d) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package fission
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
+
+	"BotMatrix/common/log"
 	"botworker/internal/db"
+
+	"go.uber.org/zap"
 )
 
 // Service 裂变核心服务
@@ -15,11 +22,12 @@ func NewService(sqlDB *sql.DB) *Service {
 	return &Service{db: sqlDB}
 }
 
-// ProcessBind 处理绑定逻辑 (包含防刷和奖励)
+// ProcessBind 处理绑定逻辑 (包含防刷和奖励)。邀请记录与全部奖励在同一事务内写入，奖励经发件箱入账：
+// 风险分正常时立即发放，偏高时延迟 RewardHoldHours 小时发放，过高时等待后台审核
 func (s *Service) ProcessBind(inviterID, inviteeID int64, platform, code, ip, deviceID string) (string, error) {
 	// 1. 获取配置
 	config, err := db.GetFissionConfig(s.db)
-	if err != nil || !config.Enabled {
+	if err != nil || config == nil || !config.Enabled {
 		return "裂变系统暂未开启", fmt.Errorf("fission disabled")
 	}
 
@@ -30,49 +38,178 @@ func (s *Service) ProcessBind(inviterID, inviteeID int64, platform, code, ip, de
 
 	// 3. 防刷：检查当日上限
 	if config.MaxDailyInvites > 0 {
-		count, _ := db.GetDailyInviteCount(s.db, inviterID)
+		count, err := db.GetDailyInviteCount(s.db, inviterID)
+		if err != nil {
+			return "系统繁忙，请稍后再试", err
+		}
 		if count >= config.MaxDailyInvites {
 			return "该邀请者今日名额已满", fmt.Errorf("daily limit reached")
 		}
 	}
 
-	// 4. 防刷：IP/设备校验
+	// 4. 防刷：IP/设备校验与邀请关系图评估
+	now := time.Now()
+	var assessment RiskAssessment
 	if config.AntiFraudEnabled {
-		isFraud, reason, _ := db.CheckInvitationFraud(s.db, ip, deviceID)
+		isFraud, reason, err := db.CheckInvitationFraud(s.db, ip, deviceID)
+		if err != nil {
+			return "系统繁忙，请稍后再试", err
+		}
 		if isFraud {
 			return fmt.Sprintf("异常操作：%s", reason), fmt.Errorf("anti-fraud: %s", reason)
 		}
+
+		assessment, err = s.Assess(db.InvitationEdge{InviterID: inviterID, InviteeID: inviteeID, IPAddress: ip, DeviceID: deviceID, CreatedAt: now})
+		if err != nil {
+			return "系统繁忙，请稍后再试", err
+		}
+	}
+	status := assessment.Status(s.policy(config))
+	availableAt := now
+	if status == db.InvitationHeld {
+		availableAt = now.Add(time.Duration(config.RewardHoldHours) * time.Hour)
 	}
+	signals, _ := json.Marshal(assessment.Signals)
 
-	// 5. 执行绑定
-	err = db.CreateInvitation(s.db, inviterID, inviteeID, code, ip, deviceID)
+	// 5. 执行绑定并写入奖励
+	rewards, err := s.bindRewards(config, inviterID, inviteeID, code)
 	if err != nil {
-		return "绑定失败，您可能已经绑定过或邀请码无效", err
+		return "系统繁忙，请稍后再试", err
+	}
+	bound, err := db.BindInvitation(s.db, db.InvitationBind{
+		InviterID:   inviterID,
+		InviteeID:   inviteeID,
+		Platform:    platform,
+		InviteCode:  code,
+		IPAddress:   ip,
+		DeviceID:    deviceID,
+		Status:      status,
+		RiskScore:   assessment.Score,
+		RiskSignals: string(signals),
+		AvailableAt: availableAt,
+		Rewards:     rewards,
+	})
+	if err != nil {
+		return "绑定失败，请稍后再试", err
+	}
+	if !bound {
+		return "绑定失败，您可能已经绑定过或邀请码无效", fmt.Errorf("invitee already bound")
 	}
 
-	// 6. 发放基础奖励 (邀请者)
-	if config.InviteRewardPoints > 0 {
-		reason := fmt.Sprintf("成功邀请新用户: %d", inviteeID)
-		_ = db.AddPoints(s.db, 0, inviterID, 0, int64(config.InviteRewardPoints), reason, "fission_invite")
-		_ = db.CreateFissionRewardLog(s.db, inviterID, "points", config.InviteRewardPoints, reason)
+	switch status {
+	case db.InvitationReview:
+		return "绑定成功！奖励将在审核通过后发放。", nil
+	case db.InvitationHeld:
+		return fmt.Sprintf("绑定成功！奖励将在 %d 小时后发放。", config.RewardHoldHours), nil
+	}
+
+	// 6. 立即发放；失败的奖励留在发件箱中由 RunOutbox 重试
+	if _, err := db.DispatchFissionRewards(s.db, outboxBatchSize); err != nil {
+		log.Warn("发放裂变奖励失败，稍后重试", zap.Int64("invitee", inviteeID), zap.Error(err))
+		return "绑定成功！奖励将稍后发放。", nil
 	}
+	return "绑定成功！奖励已发放。", nil
+}
 
-	// 7. 发放基础奖励 (被邀请者)
+// bindRewards 绑定时写入发件箱的奖励：邀请者奖励、被邀请者奖励与注册任务奖励
+func (s *Service) bindRewards(config *db.FissionConfig, inviterID, inviteeID int64, code string) ([]db.FissionReward, error) {
+	var rewards []db.FissionReward
+	if config.InviteRewardPoints > 0 {
+		rewards = append(rewards, db.FissionReward{
+			UserID:   inviterID,
+			Amount:   config.InviteRewardPoints,
+			Reason:   fmt.Sprintf("成功邀请新用户: %d", inviteeID),
+			Category: "fission_invite",
+			Key:      fmt.Sprintf("fission_invite:%d", inviteeID),
+		})
+	}
 	if config.NewUserRewardPoints > 0 {
-		reason := fmt.Sprintf("填写邀请码奖励: %s", code)
-		_ = db.AddPoints(s.db, 0, inviteeID, 0, int64(config.NewUserRewardPoints), reason, "fission_bind")
-		_ = db.CreateFissionRewardLog(s.db, inviteeID, "points", config.NewUserRewardPoints, reason)
+		rewards = append(rewards, db.FissionReward{
+			UserID:   inviteeID,
+			Amount:   config.NewUserRewardPoints,
+			Reason:   fmt.Sprintf("填写邀请码奖励: %s", code),
+			Category: "fission_bind",
+			Key:      fmt.Sprintf("fission_bind:%d", inviteeID),
+		})
+	}
+
+	tasks, err := db.GetActiveFissionTasks(s.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if t.TaskType != "register" || t.RewardPoints <= 0 {
+			continue
+		}
+		rewards = append(rewards, db.FissionReward{
+			UserID:   inviteeID,
+			Amount:   t.RewardPoints,
+			Reason:   fmt.Sprintf("完成裂变任务: %s", t.Name),
+			Category: "fission_task",
+			Key:      db.FissionTaskRewardKey(inviteeID, t.ID),
+		})
+	}
+	return rewards, nil
+}
+
+// Assess 按邀请关系图评估一次绑定的风险
+func (s *Service) Assess(candidate db.InvitationEdge) (RiskAssessment, error) {
+	p := DefaultRiskPolicy()
+	ancestors, err := db.GetInvitationAncestors(s.db, candidate.InviterID, p.Depth)
+	if err != nil {
+		return RiskAssessment{}, err
+	}
+	root := candidate.InviterID
+	if len(ancestors) > 0 {
+		root = ancestors[len(ancestors)-1].InviterID
+	}
+	tree, err := db.GetInvitationSubtree(s.db, root, p.Depth+len(ancestors))
+	if err != nil {
+		return RiskAssessment{}, err
 	}
+	return Score(InviteGraph{Ancestors: ancestors, Tree: tree}, candidate, p), nil
+}
 
-	// 8. 触发注册任务完成
-	_ = db.CompleteFissionTask(s.db, inviteeID, "register")
+// policy 用后台配置的阈值覆盖默认评估参数
+func (s *Service) policy(config *db.FissionConfig) RiskPolicy {
+	p := DefaultRiskPolicy()
+	if config.RiskHoldScore > 0 {
+		p.HoldScore = config.RiskHoldScore
+	}
+	if config.RiskReviewScore > 0 {
+		p.ReviewScore = config.RiskReviewScore
+	}
+	return p
+}
 
-	return "绑定成功！奖励已发放。", nil
+// outboxBatchSize 每轮最多发放的奖励条数
+const outboxBatchSize = 100
+
+// RunOutbox 定期发放到期的奖励 (延迟期满、审核通过或之前失败待重试的)，直到 ctx 结束
+func (s *Service) RunOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := db.DispatchFissionRewards(s.db, outboxBatchSize); err != nil {
+			log.Warn("发放裂变奖励失败", zap.Error(err))
+		} else if n > 0 {
+			log.Info("已发放裂变奖励", zap.Int("count", n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 // TriggerTask 触发任务进度
-func (s *Service) TriggerTask(userID int64, taskType string) {
-	_ = db.CompleteFissionTask(s.db, userID, taskType)
+func (s *Service) TriggerTask(userID int64, taskType string) error {
+	if err := db.CompleteFissionTask(s.db, userID, taskType); err != nil {
+		return err
+	}
+	_, err := db.DispatchFissionRewards(s.db, outboxBatchSize)
+	return err
 }
 
 // GetUserStats 获取用户裂变数据
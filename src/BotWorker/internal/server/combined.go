@@ -5,11 +5,13 @@ import (
 	"BotMatrix/common/ai/employee"
 	"BotMatrix/common/bot"
 	"BotMatrix/common/log"
+	"BotMatrix/common/metrics"
 	"BotMatrix/common/models"
 	commononebot "BotMatrix/common/onebot"
 	"BotMatrix/common/plugin/core"
 	"BotMatrix/common/session"
 	"BotMatrix/common/tasks"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"botworker/internal/config"
 	"botworker/internal/db"
@@ -27,6 +29,8 @@ import (
 	"time"
 
 	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type CombinedServer struct {
@@ -64,7 +68,8 @@ func (s *CombinedServer) SetAIService(aiSvc ai.AIService) {
 
 			// 注册任务系统消息处理器
 			s.OnMessage(func(e *onebot.Event) error {
-				if s.taskManager == nil {
+				// 人工接管的会话由坐席回复，不做 AI 任务解析
+				if s.taskManager == nil || e.Handoff {
 					return nil
 				}
 				// 转换 OneBot 事件为任务系统需要的格式
@@ -303,7 +308,7 @@ func (s *CombinedServer) registerStorageHandlers() {
 				points = int64(v)
 			}
 			if database := plugins.GlobalDB; database != nil {
-				_ = db.UpdateLocalPoints(database, botId, userId, points)
+				_ = db.UpdateLocalPoints(database, storageOperationKey(req, params, key), botId, userId, points)
 			}
 		}
 
@@ -320,7 +325,7 @@ func (s *CombinedServer) registerStorageHandlers() {
 				points = int64(v)
 			}
 			if database := plugins.GlobalDB; database != nil {
-				_ = db.UpdateGroupPoints(database, userId, groupId, points)
+				_ = db.UpdateGroupPoints(database, storageOperationKey(req, params, key), userId, groupId, points)
 			}
 		}
 
@@ -430,6 +435,18 @@ func (s *CombinedServer) HandleAPI(action string, fn any) {
 	}
 }
 
+// storageOperationKey 由请求的 echo (或插件传入的 message_id) 与存储键派生积分幂等键，插件重试同一请求时不会重复入账
+func storageOperationKey(req *onebot.Request, params map[string]any, key string) string {
+	id := req.Echo
+	if id == nil || id == "" {
+		id = params["message_id"]
+	}
+	if id == nil || id == "" {
+		return ""
+	}
+	return fmt.Sprint(id) + ":" + key
+}
+
 func (s *CombinedServer) SendMessage(params *onebot.SendMessageParams) (*onebot.Response, error) {
 	log.Printf("[Worker] Sending message: %v", params.Message)
 	// 优先使用WebSocket发送消息
@@ -649,7 +666,7 @@ func (s *CombinedServer) publishActionToNexus(action string, params any) error {
 	}
 
 	// 提取 platform 和 self_id
-	var platform, selfID, groupID, userID, message string
+	var platform, selfID, groupID, userID, message, traceParent string
 
 	// 尝试从 params 中提取 (如果 params 是 struct 指针)
 	if params != nil {
@@ -683,6 +700,11 @@ func (s *CombinedServer) publishActionToNexus(action string, params any) error {
 			if mf.IsValid() {
 				message = fmt.Sprintf("%v", mf.Interface())
 			}
+			// 尝试获取 TraceParent 字段
+			tf := v.FieldByName("TraceParent")
+			if tf.IsValid() && tf.Kind() == reflect.String {
+				traceParent = tf.String()
+			}
 		} else if v.Kind() == reflect.Map {
 			// 如果是 map，尝试直接提取
 			if val, ok := params.(map[string]any); ok {
@@ -701,6 +723,9 @@ func (s *CombinedServer) publishActionToNexus(action string, params any) error {
 				if v, ok := val["message"]; ok {
 					message = fmt.Sprintf("%v", v)
 				}
+				if v, ok := val[tracing.KeyTraceParent].(string); ok {
+					traceParent = v
+				}
 			}
 		}
 	}
@@ -728,6 +753,9 @@ func (s *CombinedServer) publishActionToNexus(action string, params any) error {
 	if message != "" {
 		msg["reply"] = message // BotNexus 期望的字段是 reply
 	}
+	if traceParent != "" {
+		msg[tracing.KeyTraceParent] = traceParent
+	}
 
 	// 打印详细的 Payload 内容
 	if payloadJson, err := json.Marshal(msg); err == nil {
@@ -739,6 +767,16 @@ func (s *CombinedServer) publishActionToNexus(action string, params any) error {
 	return nil
 }
 
+// NotifyToolApproval 将待审批通知发给 BotNexus，由其推送给管理后台的审批人
+func (s *CombinedServer) NotifyToolApproval(event types.ToolApprovalEvent) {
+	log.Printf("[Nexus] Sending tool approval request to Nexus: run=%s tools=%d", event.RunID, len(event.Tools))
+	s.botService.SendToNexus(map[string]any{
+		"type":      "tool_approval",
+		"worker_id": s.config.WorkerID,
+		"approval":  event,
+	})
+}
+
 // Session & State Management 实现
 func (s *CombinedServer) GetSessionContext(platform, userID string) (*types.SessionContext, error) {
 	if s.redisClient == nil {
@@ -823,7 +861,13 @@ func (s *CombinedServer) CreateBaseContext(botUin int64, groupID int64, userID i
 }
 
 // routeMessageToSkill 尝试将消息路由到特定插件技能
-func (s *CombinedServer) routeMessageToSkill(e *onebot.Event) (bool, error) {
+func (s *CombinedServer) routeMessageToSkill(e *onebot.Event) (handled bool, err error) {
+	_, span := tracing.Start(tracing.ContextWithTraceParent(context.Background(), e.TraceParent, e.TraceState), "worker.route_skill")
+	defer func() {
+		span.SetAttributes(attribute.Bool("botmatrix.handled", handled))
+		tracing.End(span, err)
+	}()
+
 	s.skillsMu.RLock()
 	defer s.skillsMu.RUnlock()
 
@@ -1033,6 +1077,7 @@ func (s *CombinedServer) startRedisQueueListener() {
 				}
 
 				log.Printf("[RedisStreams] Received message from %s (ID: %s)", streamName, xmsg.ID)
+				metrics.ObserveQueueLag(streamName, xmsg.ID, time.Now())
 
 				var msg map[string]any
 				if err := json.Unmarshal([]byte(payload), &msg); err != nil {
@@ -1048,6 +1093,12 @@ func (s *CombinedServer) startRedisQueueListener() {
 							log.Printf("[RedisStreams] Panic in message processor: %v", r)
 						}
 					}()
+					// 接续 Nexus 写入队列时的追踪上下文，并让后续的事件、AI 请求与回复动作挂在本 span 下
+					_, span := tracing.Start(tracing.ExtractMap(context.Background(), m), "worker.process_message",
+						trace.WithSpanKind(trace.SpanKindConsumer),
+						trace.WithAttributes(attribute.String("messaging.destination.name", stream), attribute.String("messaging.message.id", msgID)))
+					defer span.End()
+					tracing.InjectMap(trace.ContextWithSpan(context.Background(), span), m)
 					s.processQueueMessage(m)
 					// 处理成功后发送 ACK
 					s.redisClient.XAck(ctx, stream, group, msgID)
@@ -1243,8 +1294,8 @@ func (s *CombinedServer) HandleQueueEvent(msg map[string]any) {
 	}
 
 	// --- 智能体 (Digital Employee) 处理逻辑 ---
-	// 如果该 Bot 被定义为“数字员工”，则在 Worker 端直接进行 AI 响应
-	if s.employeeService != nil && s.aiService != nil && event.PostType == "message" && event.UserID.String() != fmt.Sprintf("%v", event.SelfID) {
+	// 如果该 Bot 被定义为“数字员工”，则在 Worker 端直接进行 AI 响应；人工接管的会话 (handoff) 跳过
+	if s.employeeService != nil && s.aiService != nil && event.PostType == "message" && !event.Handoff && event.UserID.String() != fmt.Sprintf("%v", event.SelfID) {
 		employee, err := s.employeeService.GetEmployeeByBotID(fmt.Sprintf("%v", event.SelfID))
 		if err == nil && employee != nil {
 			log.Printf("[Agent] Bot %v is a Digital Employee: %s (%s)", event.SelfID, employee.Name, employee.Title)
@@ -1262,6 +1313,17 @@ func (s *CombinedServer) HandleQueueEvent(msg map[string]any) {
 					GroupID:     event.GroupID.String(),
 					MessageType: event.MessageType,
 					RawMessage:  event.RawMessage,
+
+					TraceParent: event.TraceParent,
+					TraceState:  event.TraceState,
+				}
+
+				// 网页访客支持流式展示，边生成边推送，避免工具执行期间长时间无响应
+				if streamer, ok := s.aiService.(types.EmployeeChatStreamer); ok && strings.EqualFold(event.Platform, "web") {
+					if _, err := s.streamEmployeeReply(streamer, employee, internalMsg); err != nil {
+						log.Printf("[Agent] AI Chat failed: %v", err)
+					}
+					return
 				}
 
 				response, err := s.aiService.ChatWithEmployee(employee, internalMsg, employee.EnterpriseID)
@@ -1273,6 +1335,7 @@ func (s *CombinedServer) HandleQueueEvent(msg map[string]any) {
 						UserID:      event.UserID,
 						GroupID:     event.GroupID,
 						Message:     response,
+						TraceParent: event.TraceParent,
 					})
 
 					// 数字员工回复后，通常不需要再分发给插件处理通用逻辑
@@ -1289,6 +1352,37 @@ func (s *CombinedServer) HandleQueueEvent(msg map[string]any) {
 	s.dispatchInternalEvent(&event)
 }
 
+// streamEmployeeReply 通过 send_stream 将数字员工的回复增量推送给网页访客，工具执行时推送进度提示
+func (s *CombinedServer) streamEmployeeReply(streamer types.EmployeeChatStreamer, employee *models.DigitalEmployeeGORM, msg types.InternalMessage) (string, error) {
+	streamID := fmt.Sprintf("ai_%d", time.Now().UnixNano())
+	push := func(params map[string]any) {
+		params["platform"] = msg.Platform
+		params["self_id"] = msg.SelfID
+		params["user_id"] = msg.UserID
+		params["stream_id"] = streamID
+		if err := s.SendBotAction(msg.SelfID, "send_stream", params); err != nil {
+			log.Printf("[Agent] Failed to push stream delta: %v", err)
+		}
+	}
+
+	response, err := streamer.ChatWithEmployeeStream(employee, msg, employee.EnterpriseID, func(e types.AgentEvent) {
+		switch e.Type {
+		case types.AgentEventDelta:
+			push(map[string]any{"delta": e.Content, "done": false})
+		case types.AgentEventToolStart:
+			push(map[string]any{"delta": "", "status": "正在执行 " + e.ToolCall.Function.Name + "...", "done": false})
+		}
+	})
+
+	// 以最终回复结束流 (替换掉调用工具前的中间文本；预算不足等提前返回的提示也由此送达)
+	final := response
+	if err != nil {
+		final = "抱歉，处理您的消息时出现错误，请稍后再试。"
+	}
+	push(map[string]any{"delta": "", "content": final, "done": true})
+	return response, err
+}
+
 func (s *CombinedServer) dispatchInternalEvent(event *onebot.Event) {
 	// 这里的逻辑应该与 WebSocketServer.handleEvent 保持同步
 	// 或者直接让 CombinedServer 拥有自己的 handler 列表
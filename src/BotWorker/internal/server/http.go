@@ -2,6 +2,7 @@ package server
 
 import (
 	"BotMatrix/common/log"
+	"BotMatrix/common/metrics"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -166,6 +167,7 @@ func (s *HTTPServer) Run() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/event", s.handleEvent)
 	mux.HandleFunc("/api", s.handleAPIRequest)
+	mux.Handle("/metrics", metrics.Handler(s.config.MetricsToken))
 
 	server := &http.Server{
 		Addr:         s.config.Addr,
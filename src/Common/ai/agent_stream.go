@@ -0,0 +1,232 @@
+package ai
+
+import (
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/metrics"
+	"BotMatrix/common/models"
+	"BotMatrix/common/tracing"
+	"BotMatrix/common/types"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// agentStreamBuffer 事件通道缓冲，避免并发工具事件阻塞执行
+const agentStreamBuffer = 64
+
+// toolCallAssembler 将流式返回的工具调用分片按 Index 拼接为完整调用
+type toolCallAssembler struct {
+	calls []ToolCall
+	slots map[int]int // 分片 Index -> calls 下标
+}
+
+func newToolCallAssembler() *toolCallAssembler {
+	return &toolCallAssembler{slots: make(map[int]int)}
+}
+
+// Add 合并一个分片。ID、名称只在首个分片出现，参数需逐段追加
+func (a *toolCallAssembler) Add(d types.ToolCallDelta) {
+	pos, ok := a.slots[d.Index]
+	// 部分兼容接口不返回 index，每个分片都是一次完整调用，以新的 ID 区分
+	if ok && d.ID != "" && a.calls[pos].ID != "" && a.calls[pos].ID != d.ID {
+		ok = false
+	}
+	if !ok {
+		a.calls = append(a.calls, ToolCall{Type: "function"})
+		pos = len(a.calls) - 1
+		a.slots[d.Index] = pos
+	}
+
+	call := &a.calls[pos]
+	if d.ID != "" {
+		call.ID = d.ID
+	}
+	if d.Type != "" {
+		call.Type = d.Type
+	}
+	if d.Function.Name != "" {
+		call.Function.Name = d.Function.Name
+	}
+	call.Function.Arguments += d.Function.Arguments
+}
+
+// Calls 返回拼接完成的工具调用，缺失 ID 的补齐为本地生成的 ID
+func (a *toolCallAssembler) Calls() []ToolCall {
+	for i := range a.calls {
+		if a.calls[i].ID == "" {
+			a.calls[i].ID = fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), i)
+		}
+		if a.calls[i].Function.Arguments == "" {
+			a.calls[i].Function.Arguments = "{}"
+		}
+	}
+	return a.calls
+}
+
+// chatStreamStep 以流式方式执行一轮对话：实时推送文本增量，并将分片拼装为与 Chat 相同的完整响应
+func (s *AIServiceImpl) chatStreamStep(ctx context.Context, modelID uint, messages []Message, tools []Tool, emit func(types.AgentEvent)) (*ChatResponse, error) {
+	maskedMessages, finalTools, maskCtx := s.prepareChat(ctx, messages, tools)
+	step, _ := ctx.Value("step").(int)
+
+	var model models.AIModelGORM
+	if err := s.db.First(&model, modelID).Error; err != nil {
+		return nil, err
+	}
+
+	provider, err := s.GetProvider(model.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.getClient(provider, &model)
+	if err != nil {
+		return nil, err
+	}
+
+	req := ChatRequest{
+		Model:         model.ModelID,
+		Messages:      maskedMessages,
+		Tools:         finalTools,
+		Stream:        true,
+		StreamOptions: &types.StreamOptions{IncludeUsage: true},
+	}
+	if temperature, ok := ctx.Value("temperature").(float32); ok {
+		req.Temperature = temperature
+	}
+
+	spanCtx, span := tracing.StartLLM(ctx, provider.Type, model.ModelID, true)
+	defer span.End()
+
+	// 流式输出较长，超时放宽到 120s；调用方取消 ctx 时立即中断
+	chatCtx, cancel := context.WithTimeout(spanCtx, 120*time.Second)
+	defer cancel()
+
+	startTime := time.Now()
+	stream, err := client.ChatStream(chatCtx, req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		metrics.ObserveAICall(provider.Type, model.ModelName, time.Since(startTime), 0, 0, err)
+		clog.Error("[AI] ChatStream failed", zap.Error(err), zap.Uint("model_id", modelID))
+		return nil, err
+	}
+
+	resp := &ChatResponse{}
+	var content strings.Builder
+	var finishReason string
+	assembler := newToolCallAssembler()
+
+	for chunk := range stream {
+		if chunk.Error != nil {
+			tracing.RecordError(span, chunk.Error)
+			metrics.ObserveAICall(provider.Type, model.ModelName, time.Since(startTime), 0, 0, chunk.Error)
+			return nil, chunk.Error
+		}
+		if chunk.ID != "" {
+			resp.ID = chunk.ID
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.ReasoningContent != "" {
+				emit(types.AgentEvent{Type: types.AgentEventReasoning, Step: step, Content: choice.Delta.ReasoningContent})
+			}
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				delta, _ := s.unmaskContent(choice.Delta.Content, maskCtx).(string)
+				emit(types.AgentEvent{Type: types.AgentEventDelta, Step: step, Content: delta})
+			}
+			for _, d := range choice.Delta.ToolCalls {
+				assembler.Add(d)
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if err := chatCtx.Err(); err != nil {
+		tracing.RecordError(span, err)
+		// 调用方主动取消不计为模型错误，仅统计超时
+		if errors.Is(err, context.DeadlineExceeded) {
+			metrics.ObserveAICall(provider.Type, model.ModelName, time.Since(startTime), 0, 0, err)
+		}
+		return nil, err
+	}
+
+	message := Message{
+		Role:      RoleAssistant,
+		Content:   s.unmaskContent(content.String(), maskCtx),
+		ToolCalls: assembler.Calls(),
+	}
+	if len(message.ToolCalls) > 0 && finishReason == "" {
+		finishReason = "tool_calls"
+	}
+	resp.Choices = []types.Choice{{Message: message, FinishReason: finishReason}}
+
+	usage := resp.Usage
+	duration := time.Since(startTime)
+	tracing.RecordUsage(span, usage.PromptTokens, usage.CompletionTokens, finishReason)
+	metrics.ObserveAICall(provider.Type, model.ModelName, duration, usage.PromptTokens, usage.CompletionTokens, nil)
+	go func() {
+		s.db.Create(&models.AIUsageLogGORM{
+			ModelName:    model.ModelName,
+			ProviderType: provider.Type,
+			InputTokens:  usage.PromptTokens,
+			OutputTokens: usage.CompletionTokens,
+			DurationMS:   int(duration.Milliseconds()),
+			Status:       "success",
+			CreatedAt:    time.Now(),
+		})
+	}()
+
+	return resp, nil
+}
+
+// ChatAgentStream 流式版本的智能体循环。
+// 通道依次推送文本增量、中间推理、工具开始/结束事件，最后以 done (含累计用量) 或 error 结束并关闭；
+// 取消 ctx 会中断模型输出与后续轮次
+func (s *AIServiceImpl) ChatAgentStream(ctx context.Context, modelID uint, messages []Message, tools []Tool) (<-chan types.AgentEvent, error) {
+	if sessionID, _ := ctx.Value("sessionID").(string); sessionID == "" {
+		ctx = context.WithValue(ctx, "sessionID", fmt.Sprintf("agent_%d", time.Now().UnixNano()))
+	}
+
+	ch := make(chan types.AgentEvent, agentStreamBuffer)
+	emit := func(event types.AgentEvent) {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(ch)
+
+		resp, err := s.runAgentLoop(ctx, modelID, messages, tools, 0, emit)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				clog.Info("[Agent] Stream cancelled by caller")
+				return
+			}
+			emit(types.AgentEvent{Type: types.AgentEventError, Error: err.Error()})
+			return
+		}
+
+		done := types.AgentEvent{Type: types.AgentEventDone, Usage: &resp.Usage}
+		if len(resp.Choices) > 0 {
+			done.Content, _ = resp.Choices[0].Message.Content.(string)
+			done.FinishReason = resp.Choices[0].FinishReason
+		}
+		if done.FinishReason == FinishReasonPendingApproval {
+			// 审批提示并非模型输出，补发一次增量让前端直接展示
+			done.RunID = resp.ID
+			emit(types.AgentEvent{Type: types.AgentEventDelta, Content: done.Content})
+		}
+		emit(done)
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"BotMatrix/common/types"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamTestClient 以分片形式返回：第一轮输出推理文本并调用 weather，参数拆成多段；第二轮输出最终回复
+type streamTestClient struct {
+	approvalTestClient
+	mu       sync.Mutex
+	requests []ChatRequest
+	block    bool // 为 true 时输出首个分片后一直等待 ctx 取消
+}
+
+func (c *streamTestClient) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatStreamResponse, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+
+	var chunks []ChatStreamResponse
+	delta := func(d types.MessageDelta, finish string) ChatStreamResponse {
+		return ChatStreamResponse{ID: "s1", Choices: []types.StreamChoice{{Delta: d, FinishReason: finish}}}
+	}
+	if req.Messages[len(req.Messages)-1].Role != RoleTool {
+		chunks = []ChatStreamResponse{
+			delta(types.MessageDelta{Role: RoleAssistant, Content: "Let me check."}, ""),
+			delta(types.MessageDelta{ToolCalls: []types.ToolCallDelta{{Index: 0, ID: "call_1", Type: "function", Function: types.FunctionCall{Name: "weather"}}}}, ""),
+			delta(types.MessageDelta{ToolCalls: []types.ToolCallDelta{{Index: 0, Function: types.FunctionCall{Arguments: `{"city":`}}}}, ""),
+			delta(types.MessageDelta{ToolCalls: []types.ToolCallDelta{{Index: 0, Function: types.FunctionCall{Arguments: `"sz"}`}}}}, "tool_calls"),
+			{ID: "s1", Usage: &UsageInfo{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+		}
+	} else {
+		chunks = []ChatStreamResponse{
+			delta(types.MessageDelta{Content: "Sunny"}, ""),
+			delta(types.MessageDelta{Content: " today"}, "stop"),
+			{ID: "s2", Usage: &UsageInfo{PromptTokens: 20, CompletionTokens: 2, TotalTokens: 22}},
+		}
+	}
+
+	ch := make(chan ChatStreamResponse)
+	go func() {
+		defer close(ch)
+		for i, chunk := range chunks {
+			select {
+			case ch <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			if c.block && i == 0 {
+				<-ctx.Done()
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func newStreamTestService(t *testing.T, client *streamTestClient) (*AIServiceImpl, *approvalTestProvider, uint) {
+	s, provider, _, modelID := newApprovalTestService(t)
+	s.clientsByConfig["https://llm.test|key"] = client
+	return s, provider, modelID
+}
+
+func TestChatAgentStreamEmitsDeltasAndToolEvents(t *testing.T) {
+	client := &streamTestClient{}
+	s, provider, modelID := newStreamTestService(t, client)
+
+	events, err := s.ChatAgentStream(agentTestContext(), modelID, []Message{{Role: RoleUser, Content: "weather?"}}, nil)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+
+	var seq []types.AgentEventType
+	var text string
+	var done types.AgentEvent
+	for e := range events {
+		seq = append(seq, e.Type)
+		switch e.Type {
+		case types.AgentEventDelta:
+			text += e.Content
+		case types.AgentEventToolEnd:
+			if e.ToolCall == nil || e.ToolCall.Function.Name != "weather" || e.Result != `"ok"` {
+				t.Errorf("unexpected tool_end event: %+v", e)
+			}
+		case types.AgentEventDone:
+			done = e
+		case types.AgentEventError:
+			t.Fatalf("unexpected error event: %s", e.Error)
+		}
+	}
+
+	want := []types.AgentEventType{
+		types.AgentEventDelta, types.AgentEventTrace, types.AgentEventToolStart, types.AgentEventToolEnd,
+		types.AgentEventDelta, types.AgentEventDelta, types.AgentEventDone,
+	}
+	if len(seq) != len(want) {
+		t.Fatalf("unexpected event sequence %v", seq)
+	}
+	for i := range want {
+		if seq[i] != want[i] {
+			t.Fatalf("unexpected event sequence %v", seq)
+		}
+	}
+	if text != "Let me check.Sunny today" || done.Content != "Sunny today" {
+		t.Errorf("unexpected streamed text %q / final %q", text, done.Content)
+	}
+	if done.Usage == nil || done.Usage.TotalTokens != 37 {
+		t.Errorf("expected usage summed over both rounds, got %+v", done.Usage)
+	}
+
+	if calls := provider.calls(); len(calls) != 1 || calls[0] != "weather" {
+		t.Errorf("expected weather to run once, got %v", calls)
+	}
+	// 拼装后的工具调用原样回传给模型
+	second := client.requests[1].Messages
+	var assistant Message
+	for _, m := range second {
+		if m.Role == RoleAssistant {
+			assistant = m
+		}
+	}
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "call_1" || assistant.ToolCalls[0].Function.Arguments != `{"city":"sz"}` {
+		t.Errorf("tool call not assembled from deltas: %+v", assistant.ToolCalls)
+	}
+	if !client.requests[0].Stream || client.requests[0].StreamOptions == nil || !client.requests[0].StreamOptions.IncludeUsage {
+		t.Errorf("stream request should ask for usage: %+v", client.requests[0])
+	}
+}
+
+func TestChatAgentStreamStopsOnCancel(t *testing.T) {
+	client := &streamTestClient{block: true}
+	s, provider, modelID := newStreamTestService(t, client)
+
+	ctx, cancel := context.WithCancel(agentTestContext())
+	events, err := s.ChatAgentStream(ctx, modelID, []Message{{Role: RoleUser, Content: "weather?"}}, nil)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+
+	first := <-events
+	if first.Type != types.AgentEventDelta {
+		t.Fatalf("expected first delta, got %+v", first)
+	}
+	cancel()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				if calls := provider.calls(); len(calls) != 0 {
+					t.Errorf("no tool may run after cancel, got %v", calls)
+				}
+				return
+			}
+			if e.Type == types.AgentEventDone || e.Type == types.AgentEventToolStart {
+				t.Fatalf("run continued after cancel: %+v", e)
+			}
+		case <-timeout:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
+
+func TestToolCallAssemblerWithoutIndex(t *testing.T) {
+	a := newToolCallAssembler()
+	a.Add(types.ToolCallDelta{ID: "a", Function: types.FunctionCall{Name: "x", Arguments: "{}"}})
+	a.Add(types.ToolCallDelta{ID: "b", Function: types.FunctionCall{Name: "y"}})
+	calls := a.Calls()
+	if len(calls) != 2 || calls[0].Function.Name != "x" || calls[1].Function.Name != "y" || calls[1].Function.Arguments != "{}" {
+		t.Errorf("complete tool calls without index must not be merged: %+v", calls)
+	}
+}
@@ -1,186 +1,106 @@
 package ai
 
 import (
-	"BotMatrix/common/log"
-	"BotMatrix/common/types"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"sync"
 	"testing"
-	"time"
 )
 
-func TestDistributedAIChat(t *testing.T) {
-	// 0. Init Logger
-	_ = log.InitLogger(log.Config{
-		Level:       "info",
-		Format:      "console",
-		Development: true,
-	})
-
-	// 1. Setup Nexus Manager
-	mgr := NewManager()
+// skillCallProvider 模拟 Nexus 的 SyncSkillCall：按技能名返回预置的 Worker 结果，并记录请求参数
+type skillCallProvider struct {
+	approvalTestProvider
+	results map[string]any
+	mu      sync.Mutex
+	params  map[string]map[string]any
+}
 
-	// Set up OnCommandSent hook to capture the command and prevent actual network calls
-	mgr.OnCommandSent = func(workerID string, msg types.WorkerCommand) {
-		fmt.Printf("Mocked: Command %s sent to worker %s\n", msg.Type, workerID)
+func (p *skillCallProvider) SyncSkillCall(ctx context.Context, skillName string, params map[string]any) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.params == nil {
+		p.params = make(map[string]map[string]any)
 	}
+	p.params[skillName] = params
+	res, ok := p.results[skillName]
+	if !ok {
+		return nil, errors.New("no worker provides " + skillName)
+	}
+	return res, nil
+}
 
-	// 2. Mock a Worker with ai_chat capability
-	workerID := "test_ai_worker"
-
-	// Register the worker in manager
-	mgr.Mutex.Lock()
-	mgr.Workers = append(mgr.Workers, &types.WorkerClient{
-		ID: workerID,
-		Capabilities: []types.WorkerCapability{
+func TestDistributedAIChat(t *testing.T) {
+	resp := ChatResponse{
+		Choices: []Choice{
 			{
-				Name:        "ai_chat",
-				Description: "Distributed Chat",
+				Message: Message{
+					Role:    RoleAssistant,
+					Content: "Hello from distributed worker!",
+				},
 			},
 		},
-	})
-	mgr.Mutex.Unlock()
-
-	// 3. Create WorkerAIClient
-	client := NewWorkerAIClient(mgr)
-
-	// 4. Run Chat in a goroutine
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	go func() {
-		// Simulate Nexus receiving the result from Redis/WS
-		// We need to wait for the command to be "sent" first
-		// In this mock, we'll just wait a bit and then push to pendingSkillRes
-		time.Sleep(100 * time.Millisecond)
-
-		// Find the correlation ID from pendingSkillRes
-		var correlationID string
-		mgr.pendingSkillRes.Range(func(key, value any) bool {
-			correlationID = key.(string)
-			return false
-		})
-
-		if correlationID != "" {
-			resp := ChatResponse{
-				Choices: []Choice{
-					{
-						Message: Message{
-							Role:    RoleAssistant,
-							Content: "Hello from distributed worker!",
-						},
-					},
-				},
-			}
-			respJSON, _ := json.Marshal(resp)
-
-			// Trigger HandleSkillResult
-			mgr.HandleSkillResult(types.SkillResult{
-				CorrelationID: correlationID,
-				Result:        string(respJSON),
-				WorkerID:      workerID,
-				Status:        "success",
-			})
-		}
-	}()
+	}
+	respJSON, _ := json.Marshal(resp)
+	provider := &skillCallProvider{results: map[string]any{"ai_chat": string(respJSON)}}
+	client := NewWorkerAIClient(provider)
 
-	// 5. Execute Chat
-	resp, err := client.Chat(ctx, ChatRequest{
+	got, err := client.Chat(context.Background(), ChatRequest{
 		Messages: []Message{{Role: RoleUser, Content: "Hi"}},
 	})
-
 	if err != nil {
 		t.Fatalf("Distributed Chat failed: %v", err)
 	}
-
-	if resp.Choices[0].Message.Content != "Hello from distributed worker!" {
-		t.Errorf("Unexpected response: %v", resp.Choices[0].Message.Content)
+	if got.Choices[0].Message.Content != "Hello from distributed worker!" {
+		t.Errorf("Unexpected response: %v", got.Choices[0].Message.Content)
 	}
 
-	fmt.Println("Distributed AI Chat test passed!")
+	// 请求以 JSON 字符串的形式转发给 Worker
+	var forwarded ChatRequest
+	if err := json.Unmarshal([]byte(provider.params["ai_chat"]["request"].(string)), &forwarded); err != nil {
+		t.Fatalf("forwarded request is not JSON: %v", err)
+	}
+	if len(forwarded.Messages) != 1 || forwarded.Messages[0].Content != "Hi" {
+		t.Errorf("forwarded request = %+v", forwarded)
+	}
 }
 
-func TestDistributedAIEmbedding(t *testing.T) {
-	// 0. Init Logger
-	_ = log.InitLogger(log.Config{
-		Level:       "info",
-		Format:      "console",
-		Development: true,
-	})
+func TestDistributedAIChatWorkerError(t *testing.T) {
+	client := NewWorkerAIClient(&skillCallProvider{})
 
-	// 1. Setup Nexus Manager
-	mgr := NewManager()
-
-	// Set up OnCommandSent hook
-	mgr.OnCommandSent = func(workerID string, msg types.WorkerCommand) {
-		fmt.Printf("Mocked: Command %s sent to worker %s\n", msg.Type, workerID)
+	if _, err := client.Chat(context.Background(), ChatRequest{}); err == nil {
+		t.Fatal("expected an error when no worker provides ai_chat")
 	}
 
-	// 2. Mock a Worker with ai_embedding capability
-	workerID := "test_ai_worker"
+	client = NewWorkerAIClient(&skillCallProvider{results: map[string]any{"ai_chat": 42}})
+	if _, err := client.Chat(context.Background(), ChatRequest{}); err == nil {
+		t.Fatal("expected an error for a non-string worker result")
+	}
+}
 
-	mgr.Mutex.Lock()
-	mgr.Workers = append(mgr.Workers, &types.WorkerClient{
-		ID: workerID,
-		Capabilities: []types.WorkerCapability{
+func TestDistributedAIEmbedding(t *testing.T) {
+	resp := EmbeddingResponse{
+		Data: []EmbeddingData{
 			{
-				Name:        "ai_embedding",
-				Description: "Distributed Embedding",
+				Embedding: []float32{0.1, 0.2, 0.3},
+				Index:     0,
 			},
 		},
-	})
-	mgr.Mutex.Unlock()
-
-	// 3. Create WorkerAIClient
-	client := NewWorkerAIClient(mgr)
-
-	// 4. Run CreateEmbedding in a goroutine to simulate result
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-
-		var correlationID string
-		mgr.pendingSkillRes.Range(func(key, value any) bool {
-			correlationID = key.(string)
-			return false
-		})
-
-		if correlationID != "" {
-			resp := EmbeddingResponse{
-				Data: []EmbeddingData{
-					{
-						Embedding: []float32{0.1, 0.2, 0.3},
-						Index:     0,
-					},
-				},
-				Usage: UsageInfo{
-					PromptTokens: 10,
-					TotalTokens:  10,
-				},
-			}
-			respJSON, _ := json.Marshal(resp)
-
-			mgr.HandleSkillResult(types.SkillResult{
-				CorrelationID: correlationID,
-				Result:        string(respJSON),
-				WorkerID:      workerID,
-				Status:        "success",
-			})
-		}
-	}()
+		Usage: UsageInfo{
+			PromptTokens: 10,
+			TotalTokens:  10,
+		},
+	}
+	respJSON, _ := json.Marshal(resp)
+	client := NewWorkerAIClient(&skillCallProvider{results: map[string]any{"ai_embedding": string(respJSON)}})
 
-	// 5. Execute Embedding
-	resp, err := client.CreateEmbedding(context.Background(), EmbeddingRequest{
+	got, err := client.CreateEmbedding(context.Background(), EmbeddingRequest{
 		Input: "Hello world",
 	})
-
 	if err != nil {
 		t.Fatalf("Distributed Embedding failed: %v", err)
 	}
-
-	if len(resp.Data) == 0 || resp.Data[0].Embedding[0] != 0.1 {
-		t.Errorf("Unexpected response: %v", resp)
+	if len(got.Data) == 0 || got.Data[0].Embedding[0] != 0.1 {
+		t.Errorf("Unexpected response: %v", got)
 	}
-
-	fmt.Println("Distributed AI Embedding test passed!")
 }
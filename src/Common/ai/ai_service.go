@@ -5,7 +5,10 @@ import (
 	"BotMatrix/common/ai/employee"
 	"BotMatrix/common/ai/rag"
 	clog "BotMatrix/common/log"
+	"BotMatrix/common/metrics"
 	"BotMatrix/common/models"
+	"BotMatrix/common/secrets"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"context"
 	"encoding/json"
@@ -15,6 +18,8 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -30,7 +35,9 @@ type AIServiceImpl struct {
 	skillManager    *SkillManager
 	memoryService   employee.CognitiveMemoryService
 	employeeService employee.DigitalEmployeeService
+	experiments     *employee.ExperimentService
 	b2bService      b2b.B2BService
+	approvalHooks   ApprovalHooks
 }
 
 func NewAIService(db *gorm.DB, provider AIServiceProvider, mcp MCPManagerInterface) *AIServiceImpl {
@@ -44,9 +51,14 @@ func NewAIService(db *gorm.DB, provider AIServiceProvider, mcp MCPManagerInterfa
 		skillManager:    NewSkillManager(db, provider, mcp),
 		memoryService:   employee.NewCognitiveMemoryService(db),
 		employeeService: employee.NewEmployeeService(db),
+		experiments:     employee.NewExperimentService(db),
 	}
 }
 
+func (s *AIServiceImpl) GetExperimentService() *employee.ExperimentService {
+	return s.experiments
+}
+
 func (s *AIServiceImpl) SetB2BService(b2bSvc b2b.B2BService) {
 	s.b2bService = b2bSvc
 	if s.skillManager != nil {
@@ -90,6 +102,12 @@ func (s *AIServiceImpl) getClient(provider *models.AIProviderGORM, model *models
 		}
 	}
 
+	// API Key 可以是 secret:// 引用，使用时才解密
+	apiKey, err := secrets.Resolve(context.Background(), apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolve api key of provider %d: %w", provider.ID, err)
+	}
+
 	// 使用 URL + Key 的哈希作为缓存键
 	cacheKey := fmt.Sprintf("%s|%s", baseURL, apiKey)
 
@@ -168,13 +186,9 @@ func (s *AIServiceImpl) prepareChat(ctx context.Context, messages []Message, too
 			kb := s.provider.GetKnowledgeBase()
 			chunks, err := kb.Search(ctx, query, 3, nil)
 			if err == nil && len(chunks) > 0 {
-				kbContext := "参考知识库内容：\n"
-				for _, chunk := range chunks {
-					kbContext += fmt.Sprintf("- %s\n", chunk.Content)
-				}
 				messages = append([]Message{{
 					Role:    "system",
-					Content: kbContext,
+					Content: types.KnowledgeContext(chunks),
 				}}, messages...)
 
 				// 记录追踪
@@ -219,6 +233,20 @@ func (s *AIServiceImpl) prepareChat(ctx context.Context, messages []Message, too
 		}
 	}
 
+	// A/B 实验变体可限制可用工具
+	if allowed, ok := ctx.Value("allowedTools").([]string); ok && len(allowed) > 0 {
+		filtered := tools[:0:0]
+		for _, t := range tools {
+			for _, name := range allowed {
+				if t.Function.Name == name {
+					filtered = append(filtered, t)
+					break
+				}
+			}
+		}
+		tools = filtered
+	}
+
 	// 5. 上下文管理 (Context Pruning)
 	if s.contextManager != nil {
 		messages = s.contextManager.PruneMessages(messages)
@@ -265,9 +293,15 @@ func (s *AIServiceImpl) Chat(ctx context.Context, modelID uint, messages []Messa
 		Messages: maskedMessages,
 		Tools:    finalTools,
 	}
+	if temperature, ok := ctx.Value("temperature").(float32); ok {
+		req.Temperature = temperature
+	}
+
+	spanCtx, span := tracing.StartLLM(ctx, provider.Type, model.ModelID, false)
+	defer span.End()
 
 	// 设置超时 (默认 60s)
-	chatCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	chatCtx, cancel := context.WithTimeout(spanCtx, 60*time.Second)
 	defer cancel()
 
 	startTime := time.Now()
@@ -275,11 +309,16 @@ func (s *AIServiceImpl) Chat(ctx context.Context, modelID uint, messages []Messa
 	duration := time.Since(startTime)
 
 	if err != nil {
+		tracing.RecordError(span, err)
+		metrics.ObserveAICall(provider.Type, model.ModelName, duration, 0, 0, err)
 		clog.Error("[AI] Chat failed", zap.Error(err), zap.Uint("model_id", modelID))
 		return nil, err
 	}
 
 	if resp != nil && len(resp.Choices) > 0 {
+		tracing.RecordUsage(span, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Choices[0].FinishReason)
+		metrics.ObserveAICall(provider.Type, model.ModelName, duration, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, nil)
+
 		for i := range resp.Choices {
 			resp.Choices[i].Message.Content = s.unmaskContent(resp.Choices[i].Message.Content, maskCtx)
 		}
@@ -305,26 +344,46 @@ func (s *AIServiceImpl) Chat(ctx context.Context, modelID uint, messages []Messa
 
 // ChatAgent 提供自主 Agent 循环能力，自动处理工具调用并返回最终结果
 func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []Message, tools []Tool) (*ChatResponse, error) {
+	if sessionID, _ := ctx.Value("sessionID").(string); sessionID == "" {
+		ctx = context.WithValue(ctx, "sessionID", fmt.Sprintf("agent_%d", time.Now().UnixNano()))
+	}
+	return s.runAgentLoop(ctx, modelID, messages, tools, 0, nil)
+}
+
+// runAgentLoop 从第 startStep 轮开始执行智能体循环，工具审批挂起的运行恢复时也由此继续。
+// emit 不为空时以流式方式调用模型，并通过 emit 推送文本增量与工具执行事件
+func (s *AIServiceImpl) runAgentLoop(ctx context.Context, modelID uint, currentMessages []Message, tools []Tool, startStep int, emit func(types.AgentEvent)) (*ChatResponse, error) {
+	// 整个运行记为一个 span，各轮模型请求与工具执行都是它的子 span
+	sessionID, _ := ctx.Value("sessionID").(string)
+	ctx, span := tracing.Start(ctx, "invoke_agent", trace.WithAttributes(
+		attribute.String("gen_ai.operation.name", "invoke_agent"),
+		attribute.String("gen_ai.conversation.id", sessionID),
+		attribute.Int("botmatrix.model_id", int(modelID)),
+		attribute.Int("botmatrix.start_step", startStep),
+	))
+	resp, err := s.agentLoop(ctx, modelID, currentMessages, tools, startStep, emit)
+	tracing.End(span, err)
+	return resp, err
+}
+
+func (s *AIServiceImpl) agentLoop(ctx context.Context, modelID uint, currentMessages []Message, tools []Tool, startStep int, emit func(types.AgentEvent)) (*ChatResponse, error) {
 	const maxIterations = 10
-	currentMessages := messages
 
-	// 提取元数据用于工具调用
 	botID, _ := ctx.Value("botID").(string)
 	userIDNum, _ := ctx.Value("userIDNum").(uint)
 	orgIDNum, _ := ctx.Value("orgIDNum").(uint)
 	sessionID, _ := ctx.Value("sessionID").(string)
-
 	if botID == "" {
 		botID = "default_bot"
 	}
 
-	if sessionID == "" {
-		sessionID = fmt.Sprintf("agent_%d", time.Now().UnixNano())
-	}
-
 	var finalResp *ChatResponse
+	var totalUsage UsageInfo
 
-	for i := 0; i < maxIterations; i++ {
+	for i := startStep; i < maxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		clog.Info("[Agent] Iteration", zap.Int("step", i+1), zap.String("session", sessionID))
 
 		// --- 极致优化：在循环内进行上下文修剪，防止多轮工具调用导致 Token 超限 ---
@@ -336,13 +395,22 @@ func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []
 		agentCtx := context.WithValue(ctx, "sessionID", sessionID)
 		agentCtx = context.WithValue(agentCtx, "step", i)
 
-		// 调用基础 Chat
-		resp, err := s.Chat(agentCtx, modelID, currentMessages, tools)
+		// 调用基础 Chat (流式模式下边接收边推送增量)
+		var resp *ChatResponse
+		var err error
+		if emit != nil {
+			resp, err = s.chatStreamStep(agentCtx, modelID, currentMessages, tools, emit)
+		} else {
+			resp, err = s.Chat(agentCtx, modelID, currentMessages, tools)
+		}
 		if err != nil {
 			s.SaveTrace(sessionID, botID, i, "error", err.Error(), "")
 			return nil, err
 		}
 		finalResp = resp
+		totalUsage.PromptTokens += resp.Usage.PromptTokens
+		totalUsage.CompletionTokens += resp.Usage.CompletionTokens
+		totalUsage.TotalTokens += resp.Usage.TotalTokens
 
 		if len(resp.Choices) == 0 {
 			break
@@ -361,9 +429,17 @@ func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []
 			break
 		}
 
+		// 调用工具前输出的文本属于中间推理，而非最终回复
+		if emit != nil && contentStr != "" {
+			emit(types.AgentEvent{Type: types.AgentEventTrace, Step: i, Content: contentStr})
+		}
+
 		// 将 Assistant 的消息添加到历史记录中
 		currentMessages = append(currentMessages, choice.Message)
 
+		// 高风险工具需人工审批，其余工具照常执行
+		runnable, pending := s.splitToolCalls(agentCtx, choice.Message.ToolCalls)
+
 		// --- 极致优化：并发执行独立工具调用 ---
 		type toolResult struct {
 			tc     ToolCall
@@ -371,16 +447,19 @@ func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []
 			err    error
 		}
 
-		toolCount := len(choice.Message.ToolCalls)
+		toolCount := len(runnable)
 		resultChan := make(chan toolResult, toolCount)
 		var wg sync.WaitGroup
 
-		for _, tc := range choice.Message.ToolCalls {
+		for _, tc := range runnable {
 			wg.Add(1)
 			go func(t ToolCall) {
 				defer wg.Done()
 				clog.Info("[Agent] Executing tool", zap.String("name", t.Function.Name))
 				s.SaveTrace(sessionID, botID, i, "tool_call", t.Function.Name, t.Function.Arguments)
+				if emit != nil {
+					emit(types.AgentEvent{Type: types.AgentEventToolStart, Step: i, ToolCall: &t})
+				}
 
 				res, err := s.ExecuteTool(agentCtx, botID, userIDNum, orgIDNum, t)
 				resultChan <- toolResult{tc: t, result: res, err: err}
@@ -406,6 +485,13 @@ func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []
 
 			// 记录工具结果
 			s.SaveTrace(sessionID, botID, i, "tool_result", res.tc.Function.Name, resultStr)
+			if emit != nil {
+				event := types.AgentEvent{Type: types.AgentEventToolEnd, Step: i, ToolCall: &res.tc, Result: resultStr}
+				if res.err != nil {
+					event.Error = res.err.Error()
+				}
+				emit(event)
+			}
 
 			// 检查是否为推理工具，如果是则优化迭代
 			if res.tc.Function.Name == "sequential_thinking" {
@@ -421,6 +507,15 @@ func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []
 			})
 		}
 
+		// 挂起运行，等待审批后由 ResumeAgentRun 继续
+		if len(pending) > 0 {
+			resp, err := s.suspendAgentRun(agentCtx, modelID, i, currentMessages, tools, pending)
+			if emit != nil && resp != nil {
+				resp.Usage = totalUsage
+			}
+			return resp, err
+		}
+
 		// 如果发生了严重错误，且重试次数过多，停止循环
 		if hasError && i > 3 {
 			break
@@ -435,6 +530,10 @@ func (s *AIServiceImpl) ChatAgent(ctx context.Context, modelID uint, messages []
 		}
 	}
 
+	// 流式调用方需要整个运行的累计用量
+	if emit != nil && finalResp != nil {
+		finalResp.Usage = totalUsage
+	}
 	return finalResp, nil
 }
 
@@ -501,7 +600,10 @@ func (s *AIServiceImpl) extractInsightsAndSave(ctx context.Context, modelID uint
 
 // ExecuteTool 执行工具调用，支持普通 Skill 和 MCP Tool
 // ExecuteTool 执行工具调用，支持普通 Skill 和 MCP Tool
-func (s *AIServiceImpl) ExecuteTool(ctx context.Context, botID string, userID uint, orgID uint, toolCall ToolCall) (any, error) {
+func (s *AIServiceImpl) ExecuteTool(ctx context.Context, botID string, userID uint, orgID uint, toolCall ToolCall) (result any, err error) {
+	ctx, span := tracing.StartTool(ctx, toolCall.Function.Name, toolCall.ID)
+	defer func() { tracing.End(span, err) }()
+
 	if s.skillManager != nil {
 		return s.skillManager.ExecuteSkill(ctx, botID, userID, orgID, toolCall)
 	}
@@ -756,6 +858,15 @@ func (s *AIServiceImpl) DispatchIntent(msg types.InternalMessage) (string, error
 }
 
 func (s *AIServiceImpl) ChatWithEmployee(employee *models.DigitalEmployeeGORM, msg types.InternalMessage, targetOrgID uint) (string, error) {
+	return s.chatWithEmployee(employee, msg, targetOrgID, nil)
+}
+
+// ChatWithEmployeeStream 与 ChatWithEmployee 相同，但通过 onEvent 实时推送回复增量与工具执行事件
+func (s *AIServiceImpl) ChatWithEmployeeStream(employee *models.DigitalEmployeeGORM, msg types.InternalMessage, targetOrgID uint, onEvent func(types.AgentEvent)) (string, error) {
+	return s.chatWithEmployee(employee, msg, targetOrgID, onEvent)
+}
+
+func (s *AIServiceImpl) chatWithEmployee(employee *models.DigitalEmployeeGORM, msg types.InternalMessage, targetOrgID uint, onEvent func(types.AgentEvent)) (string, error) {
 	// 0. 检查功能开关
 	if s.provider != nil && !s.provider.IsDigitalEmployeeEnabled() {
 		return "对不起，数字员工服务当前已禁用。请联系管理员开启 EnableDigitalEmployee。", nil
@@ -824,6 +935,18 @@ func (s *AIServiceImpl) ChatWithEmployee(employee *models.DigitalEmployeeGORM, m
 		}
 	}
 
+	// A/B 实验分流：岗位模板与变体配置覆盖 Prompt、模型、温度与工具
+	abConfig, abAssignment := s.resolveExperiment(employee, msg)
+	if abConfig.PromptBase != "" {
+		messages[0].Content = abConfig.PromptBase
+	}
+	if abConfig.PromptSuffix != "" {
+		messages[0].Content = messages[0].Content.(string) + "\n" + abConfig.PromptSuffix
+	}
+	if abConfig.ModelID > 0 {
+		modelID = abConfig.ModelID
+	}
+
 	// 如果还是没模型，用默认的
 	if modelID == 0 {
 		var model models.AIModelGORM
@@ -847,11 +970,16 @@ func (s *AIServiceImpl) ChatWithEmployee(employee *models.DigitalEmployeeGORM, m
 
 	// 6. 调用 AI
 	newSessionID := fmt.Sprintf("chat:%s:user:%s:%d", employee.BotID, msg.UserID, time.Now().Unix())
-	chatCtx := context.WithValue(context.Background(), "botID", employee.BotID)
+	// 延续消息携带的追踪上下文，使模型请求与工具调用挂在同一条 trace 下
+	chatCtx := context.WithValue(tracing.Extract(context.Background(), msg), "botID", employee.BotID)
 	chatCtx = context.WithValue(chatCtx, "userID", fmt.Sprintf("%v", msg.UserID))
 	chatCtx = context.WithValue(chatCtx, "orgIDNum", targetOrgID) // 传入目标企业 ID
 	chatCtx = context.WithValue(chatCtx, "sessionID", newSessionID)
 	chatCtx = context.WithValue(chatCtx, "step", 0)
+	// 回复目标：工具审批挂起后恢复时据此回复原会话
+	chatCtx = context.WithValue(chatCtx, "groupID", msg.GroupID)
+	chatCtx = context.WithValue(chatCtx, "platform", msg.Platform)
+	chatCtx = context.WithValue(chatCtx, "messageType", msg.MessageType)
 
 	// 链路追踪：关联父 SessionID
 	parentSessionID, _ := msg.Extras["parentSessionID"].(string)
@@ -871,13 +999,29 @@ func (s *AIServiceImpl) ChatWithEmployee(employee *models.DigitalEmployeeGORM, m
 		messages[0].Content = messages[0].Content.(string) + taskInfo
 	}
 
+	if abConfig.Temperature != nil {
+		chatCtx = context.WithValue(chatCtx, "temperature", float32(*abConfig.Temperature))
+	}
+	if len(abConfig.Tools) > 0 {
+		chatCtx = context.WithValue(chatCtx, "allowedTools", abConfig.Tools)
+	}
+	if abAssignment != nil {
+		s.SaveTrace(newSessionID, employee.BotID, 0, "ab_assignment", fmt.Sprintf("Experiment %d, Variant %s", abAssignment.ExperimentID, abAssignment.VariantName), "")
+	}
+
 	if employee.EnterpriseID != targetOrgID {
 		chatCtx = context.WithValue(chatCtx, "isDispatched", true)
 		chatCtx = context.WithValue(chatCtx, "sourceOrgID", employee.EnterpriseID)
 	}
 
-	// 使用 ChatAgent 以便支持工具调用
-	resp, err := s.ChatAgent(chatCtx, modelID, messages, nil)
+	// 使用智能体循环以便支持工具调用 (需要流式输出时边生成边推送)
+	var resp *ChatResponse
+	var err error
+	if onEvent != nil {
+		resp, err = s.runAgentLoop(chatCtx, modelID, messages, nil, 0, onEvent)
+	} else {
+		resp, err = s.ChatAgent(chatCtx, modelID, messages, nil)
+	}
 	if err != nil {
 		s.SaveTrace(sessionID, employee.BotID, 0, "error", err.Error(), "")
 		return "", err
@@ -885,6 +1029,10 @@ func (s *AIServiceImpl) ChatWithEmployee(employee *models.DigitalEmployeeGORM, m
 
 	if len(resp.Choices) > 0 {
 		content, _ := resp.Choices[0].Message.Content.(string)
+		// 等待工具审批：直接提示用户，审批后的最终回复由 ApprovalHooks.OnResumed 发送
+		if resp.Choices[0].FinishReason == FinishReasonPendingApproval {
+			return content, nil
+		}
 		// ChatAgent 内部已经记录了最终响应的 trace，这里不需要重复记录
 
 		// 6. 异步保存消息到历史并更新薪资消耗
@@ -909,22 +1057,41 @@ func (s *AIServiceImpl) ChatWithEmployee(employee *models.DigitalEmployeeGORM, m
 			if s.employeeService != nil {
 				s.employeeService.ConsumeSalary(employee.BotID, int64(resp.Usage.TotalTokens))
 			}
+			s.recordExperimentMetric(abAssignment, metricTokenCost, float64(resp.Usage.TotalTokens))
 
 			// 7. 认知记忆自动提取 (异步执行，不影响主流程)
 			userIDStr := fmt.Sprintf("%v", msg.UserID)
 			sideCtx := context.WithValue(context.Background(), "sessionID", newSessionID)
 			sideCtx = context.WithValue(sideCtx, "botID", employee.BotID)
 			sideCtx = context.WithValue(sideCtx, "orgIDNum", targetOrgID)
-			// 为后台任务设置独立超时
-			sideCtx, _ = context.WithTimeout(sideCtx, 30*time.Second)
+			if abAssignment != nil {
+				sideCtx = context.WithValue(sideCtx, "abAssignment", abAssignment)
+			}
+			// 为后台任务设置独立超时，三个任务结束后释放
+			sideCtx, cancelSide := context.WithTimeout(sideCtx, 30*time.Second)
+			var sideWG sync.WaitGroup
+			sideWG.Add(3)
 
-			go s.ExtractAndSaveMemories(sideCtx, userIDStr, employee.BotID, messages[len(messages)-2:])
+			go func() {
+				defer sideWG.Done()
+				s.ExtractAndSaveMemories(sideCtx, userIDStr, employee.BotID, messages[len(messages)-2:])
+			}()
 
 			// 8. AI 自动 KPI 评分
-			go s.EvaluateAndRecordKpi(sideCtx, employee, msg.RawMessage, content)
+			go func() {
+				defer sideWG.Done()
+				s.EvaluateAndRecordKpi(sideCtx, employee, msg.RawMessage, content)
+			}()
 
 			// 9. 数字员工自动学习 (异步执行)
-			go s.AutoLearnFromConversation(sideCtx, employee, messages[len(messages)-2:])
+			go func() {
+				defer sideWG.Done()
+				s.AutoLearnFromConversation(sideCtx, employee, messages[len(messages)-2:])
+			}()
+			go func() {
+				sideWG.Wait()
+				cancelSide()
+			}()
 
 			// 10. 定期固化记忆 (每 20 条消息触发一次，或者根据记忆数量触发)
 			// 这里简单演示：如果记忆数量超过一定阈值就触发
@@ -1170,10 +1337,10 @@ func (s *AIServiceImpl) AutoLearnFromConversation(ctx context.Context, employee
 
 		if err == nil && len(existingChunks) > 0 {
 			topMatch := existingChunks[0]
-			if topMatch.Score > 0.95 {
+			if topMatch.Similarity > 0.95 {
 				// 极高相似度：跳过，避免重复
 				continue
-			} else if topMatch.Score > 0.6 {
+			} else if topMatch.Similarity > 0.6 {
 				// 中等相似度：尝试合并知识
 				mergePrompt := fmt.Sprintf("请合并以下两条相关的知识点，生成一个更全面、准确的版本。\n知识点1：%s\n知识点2：%s\n请直接输出合并后的内容，不要有其他解释。", topMatch.Content, fact)
 				mergeResp, err := client.Chat(ctx, ChatRequest{
@@ -1205,6 +1372,35 @@ func (s *AIServiceImpl) AutoLearnFromConversation(ctx context.Context, employee
 	}
 }
 
+const (
+	metricKPIScore  = employee.MetricKPIScore
+	metricTokenCost = employee.MetricTokenCost
+)
+
+// resolveExperiment 获取数字员工本次对话的 A/B 实验分流结果与生效配置
+func (s *AIServiceImpl) resolveExperiment(emp *models.DigitalEmployeeGORM, msg types.InternalMessage) (employee.VariantConfig, *employee.Assignment) {
+	if s.experiments == nil {
+		return employee.VariantConfig{}, nil
+	}
+	return s.experiments.Resolve(context.Background(), emp, fmt.Sprintf("%v", msg.UserID), msg.GroupID)
+}
+
+func (s *AIServiceImpl) recordExperimentMetric(a *employee.Assignment, metric string, value float64) {
+	if a == nil || s.experiments == nil {
+		return
+	}
+	if err := s.experiments.Record(a, metric, value); err != nil {
+		clog.Warn("[Experiment] Failed to record observation", zap.String("metric", metric), zap.Error(err))
+	}
+}
+
+// recordExperimentMetricFromContext 记录异步任务中的实验指标 (分流结果通过 ctx 的 abAssignment 传递)
+func (s *AIServiceImpl) recordExperimentMetricFromContext(ctx context.Context, metric string, value float64) {
+	if a, ok := ctx.Value("abAssignment").(*employee.Assignment); ok {
+		s.recordExperimentMetric(a, metric, value)
+	}
+}
+
 // EvaluateAndRecordKpi AI 自动对回复质量进行打分
 func (s *AIServiceImpl) EvaluateAndRecordKpi(ctx context.Context, employee *models.DigitalEmployeeGORM, userMsg, aiResp string) {
 	if s.employeeService == nil {
@@ -1265,6 +1461,7 @@ func (s *AIServiceImpl) EvaluateAndRecordKpi(ctx context.Context, employee *mode
 			score = 100
 		}
 		s.employeeService.RecordKpi(employee.ID, "ai_auto_eval", score)
+		s.recordExperimentMetricFromContext(ctx, metricKPIScore, score)
 		clog.Info("[KPI] AI Auto Scored", zap.Uint("employeeID", employee.ID), zap.Float64("score", score))
 
 		// 记录追踪
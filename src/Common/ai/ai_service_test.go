@@ -1,9 +1,9 @@
 package ai
 
 import (
-	"BotMatrix/common/config"
 	clog "BotMatrix/common/log"
 	"BotMatrix/common/models"
+	"BotMatrix/common/types"
 	"context"
 	"fmt"
 	"testing"
@@ -16,19 +16,19 @@ import (
 // MockClient 模拟 AI 客户端
 type MockClient struct{}
 
-func (m *MockClient) Chat(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
-	return &ai.ChatResponse{
+func (m *MockClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return &ChatResponse{
 		ID: "test-id",
-		Choices: []ai.Choice{
+		Choices: []Choice{
 			{
-				Message: ai.Message{
-					Role:    ai.RoleAssistant,
+				Message: Message{
+					Role:    RoleAssistant,
 					Content: "这是模拟的 AI 回复内容。",
 				},
 				FinishReason: "stop",
 			},
 		},
-		Usage: ai.UsageInfo{
+		Usage: UsageInfo{
 			PromptTokens:     10,
 			CompletionTokens: 20,
 			TotalTokens:      30,
@@ -36,23 +36,23 @@ func (m *MockClient) Chat(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 	}, nil
 }
 
-func (m *MockClient) ChatStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.ChatStreamResponse, error) {
-	ch := make(chan ai.ChatStreamResponse)
+func (m *MockClient) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatStreamResponse, error) {
+	ch := make(chan ChatStreamResponse)
 	go func() {
 		defer close(ch)
-		ch <- ai.ChatStreamResponse{
+		ch <- ChatStreamResponse{
 			ID: "test-id",
-			Choices: []ai.StreamChoice{
+			Choices: []types.StreamChoice{
 				{
-					Delta: ai.MessageDelta{Content: "这是模拟的"},
+					Delta: types.MessageDelta{Content: "这是模拟的"},
 				},
 			},
 		}
-		ch <- ai.ChatStreamResponse{
+		ch <- ChatStreamResponse{
 			ID: "test-id",
-			Choices: []ai.StreamChoice{
+			Choices: []types.StreamChoice{
 				{
-					Delta: ai.MessageDelta{Content: "流式回复"},
+					Delta: types.MessageDelta{Content: "流式回复"},
 				},
 			},
 		}
@@ -60,7 +60,7 @@ func (m *MockClient) ChatStream(ctx context.Context, req ai.ChatRequest) (<-chan
 	return ch, nil
 }
 
-func (m *MockClient) CreateEmbedding(ctx context.Context, req ai.EmbeddingRequest) (*ai.EmbeddingResponse, error) {
+func (m *MockClient) CreateEmbedding(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
 	var inputLen int
 	if ss, ok := req.Input.([]string); ok {
 		inputLen = len(ss)
@@ -70,25 +70,33 @@ func (m *MockClient) CreateEmbedding(ctx context.Context, req ai.EmbeddingReques
 		inputLen = 1
 	}
 
-	embeddings := make([]ai.EmbeddingData, inputLen)
+	embeddings := make([]EmbeddingData, inputLen)
 	for i := 0; i < inputLen; i++ {
 		// 生成一个伪随机向量 (2048 维)
 		vec := make([]float32, 2048)
 		for j := 0; j < 2048; j++ {
 			vec[j] = float32(i+j) / 1000.0
 		}
-		embeddings[i] = ai.EmbeddingData{
+		embeddings[i] = EmbeddingData{
 			Embedding: vec,
 			Index:     i,
 		}
 	}
-	return &ai.EmbeddingResponse{
+	return &EmbeddingResponse{
 		Data:  embeddings,
 		Model: req.Model,
-		Usage: ai.UsageInfo{TotalTokens: 100},
+		Usage: UsageInfo{TotalTokens: 100},
 	}, nil
 }
 
+func (m *MockClient) GetEmployeeByBotID(botID string) (*models.DigitalEmployeeGORM, error) {
+	return nil, fmt.Errorf("no employee bound to %s", botID)
+}
+
+func (m *MockClient) PlanTask(ctx context.Context, executionID string) error {
+	return nil
+}
+
 func TestAIServiceLogging(t *testing.T) {
 	// 0. 初始化日志
 	clog.InitDefaultLogger()
@@ -116,25 +124,21 @@ func TestAIServiceLogging(t *testing.T) {
 	db.Create(&model)
 
 	// 3. 创建 AI 服务并手动注入模拟客户端
-	mgr := NewManager()
-	mgr.Config = &config.AppConfig{
-		EnableDigitalEmployee: true,
-	}
-	s := NewAIService(db, mgr)
+	s := NewAIService(db, &approvalTestProvider{db: db}, nil)
 	// 使用 clientsByConfig 注入，Key 需要匹配 s.getClient 生成的规则
 	cacheKey := "https://api.test.com|test-key"
 	s.clientsByConfig[cacheKey] = &MockClient{}
 
 	// 4. 执行 Chat 调用，触发打印
 	fmt.Println("\n=== 开始测试 Chat 日志打印 ===")
-	messages := []ai.Message{
-		{Role: ai.RoleSystem, Content: "你是一个助手"},
-		{Role: ai.RoleUser, Content: "你好，请自我介绍"},
+	messages := []Message{
+		{Role: RoleSystem, Content: "你是一个助手"},
+		{Role: RoleUser, Content: "你好，请自我介绍"},
 	}
-	tools := []ai.Tool{
+	tools := []Tool{
 		{
 			Type: "function",
-			Function: ai.FunctionDefinition{
+			Function: FunctionDefinition{
 				Name:        "test_tool",
 				Description: "测试工具",
 			},
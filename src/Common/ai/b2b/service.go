@@ -3,6 +3,7 @@ package b2b
 import (
 	clog "BotMatrix/common/log"
 	"BotMatrix/common/models"
+	"BotMatrix/common/secrets"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"bytes"
@@ -324,6 +325,10 @@ func (s *B2BServiceImpl) CheckDispatchPermission(employeeID, targetOrgID uint, a
 }
 
 func (s *B2BServiceImpl) signData(privateKeyStr, data string) (string, error) {
+	privateKeyStr, err := secrets.Resolve(context.Background(), privateKeyStr)
+	if err != nil {
+		return "", fmt.Errorf("resolve b2b private key: %w", err)
+	}
 	claims := jwt.MapClaims{
 		"data": data,
 		"exp":  time.Now().Add(time.Minute * 5).Unix(),
@@ -510,8 +515,12 @@ func (s *B2BServiceImpl) generateB2BToken(sourceEntID, targetEntID uint) (string
 		"exp": time.Now().Add(time.Hour).Unix(),
 	}
 
+	privateKey, err := secrets.Resolve(context.Background(), sourceEnt.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("resolve b2b private key: %w", err)
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(sourceEnt.PrivateKey))
+	return token.SignedString([]byte(privateKey))
 }
 
 // VerifyIdentity 验证企业身份
@@ -1,7 +1,7 @@
 package ai
 
 import (
-	"BotNexus/tasks"
+	"BotMatrix/common/tasks"
 	"fmt"
 	"testing"
 )
@@ -58,20 +58,24 @@ func TestBotIdentitySelfAwareness(t *testing.T) {
 			// 验证 Prompt 中是否包含回答该问题所需的关键字
 			switch tc.name {
 			case "身份确认":
-				if !contains(prompt, "BotMatrix") || !contains(prompt, "全能型群组自动化专家") {
+				if !contains(prompt, "BotMatrix Core") || !contains(prompt, "分布式机器人矩阵枢纽") {
 					t.Errorf("Prompt 缺少身份信息")
 				}
 			case "功能概览":
-				if !contains(prompt, "核心能力") || !contains(prompt, "系统功能清单") {
+				if !contains(prompt, "你可以处理以下意图") || !contains(prompt, "skill_call") {
 					t.Errorf("Prompt 缺少功能描述")
 				}
 			case "具体操作指南-任务":
-				if !contains(prompt, "创建任务") || !contains(prompt, "每天/每小时") {
+				if !contains(prompt, "create_task") || !contains(prompt, "创建新的异步执行任务") {
 					t.Errorf("Prompt 缺少任务创建指南")
 				}
 			case "知识库能力测试":
-				if !contains(prompt, "深度知识检索") || !contains(prompt, "RAG") {
-					t.Errorf("Prompt 缺少 RAG 引导信息")
+				if !contains(prompt, "system_query") || !contains(prompt, "知识库") {
+					t.Errorf("Prompt 缺少知识库引导信息")
+				}
+			case "边界测试":
+				if !contains(prompt, "全局规则") || !contains(prompt, "严禁泄露用户 PII 信息") {
+					t.Errorf("Prompt 缺少全局规则")
 				}
 			}
 		})
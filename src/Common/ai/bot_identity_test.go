@@ -1,7 +1,8 @@
 package ai
 
 import (
-	"BotNexus/tasks"
+	"BotMatrix/common/tasks"
+	"BotMatrix/common/types"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -27,6 +28,7 @@ func (m *MockKnowledgeBase) Search(ctx context.Context, query string, limit int,
 
 // MockAIServiceForIdentity 模拟 AI 服务用于身份识别测试
 type MockAIServiceForIdentity struct {
+	types.AIService  // 未覆盖的方法不会被调用
 	LastSystemPrompt string
 }
 
@@ -112,11 +114,11 @@ func TestBotIdentityInjection(t *testing.T) {
 	}
 
 	expectedParts := []string{
-		"你现在的身份是：BotMatrix (全能型群组自动化专家)",
-		"你的性格特征是：专业、高效、偶尔幽默",
-		"### 操作指南 (How-to Guide):",
-		"- **创建任务**:",
-		"- **群管理**:",
+		"你是 BotMatrix Core (分布式机器人矩阵枢纽)。",
+		"专业、高效、可靠、具备极强的多智能体协作能力",
+		"全局规则:",
+		"- create_task: 创建新的异步执行任务",
+		"- system_query: 查询系统运行状态或知识库",
 		"当前运行环境信息：",
 		"- 目标群组 ID: group_456",
 		"- 当前用户角色: admin",
@@ -130,8 +132,8 @@ func TestBotIdentityInjection(t *testing.T) {
 	}
 
 	// 2. 验证解析结果
-	if result.Intent != tasks.AIActionSystemQuery {
-		t.Errorf("Expected intent %s, got %s", tasks.AIActionSystemQuery, result.Intent)
+	if result.Intent != types.AIActionSystemQuery {
+		t.Errorf("Expected intent %s, got %s", types.AIActionSystemQuery, result.Intent)
 	}
 
 	if !contains(result.Analysis, "BotMatrix") {
@@ -141,10 +143,6 @@ func TestBotIdentityInjection(t *testing.T) {
 	fmt.Printf("Parsed Result: %+v\n", result)
 }
 
-func contains(s, substr string) bool {
-	return (s != "" && substr != "" && (len(s) >= len(substr)) && (s == substr || (s[:len(substr)] == substr) || (s[len(s)-len(substr):] == substr) || (len(s) > len(substr) && (s[1:len(substr)+1] == substr || contains(s[1:], substr)))))
-}
-
 func TestBotIdentityJson(t *testing.T) {
 	manifest := tasks.GetDefaultManifest()
 	data, _ := json.MarshalIndent(manifest, "", "  ")
@@ -157,7 +155,7 @@ func TestBotIdentityJson(t *testing.T) {
 		t.Fatal("Identity field missing in JSON manifest")
 	}
 
-	if identity["name"] != "BotMatrix" {
-		t.Errorf("Expected name BotMatrix, got %v", identity["name"])
+	if identity["name"] != "BotMatrix Core" {
+		t.Errorf("Expected name BotMatrix Core, got %v", identity["name"])
 	}
 }
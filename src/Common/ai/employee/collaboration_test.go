@@ -0,0 +1,84 @@
+package employee
+
+import (
+	"BotMatrix/common/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCrossDepartmentCollaboration(t *testing.T) {
+	// 1. 初始化内存数据库
+	db := newTaskTestDB(t)
+	taskSvc := NewDigitalEmployeeTaskService(db, nil)
+
+	ctx := context.Background()
+
+	// 2. 创建两个不同部门的数字员工
+	emp1 := models.DigitalEmployee{
+		Name:       "张三",
+		Department: "技术部",
+		Title:      "架构师",
+		BotID:      "bot-zhangsan",
+		EmployeeID: "EMP_ZS_001",
+	}
+	db.Create(&emp1)
+
+	emp2 := models.DigitalEmployee{
+		Name:       "李四",
+		Department: "财务部",
+		Title:      "财务专员",
+		BotID:      "bot-lisi",
+		EmployeeID: "EMP_LS_002",
+	}
+	db.Create(&emp2)
+
+	// 3. 张三创建一个任务并指派给李四 (跨部门协作)
+	task := &models.DigitalEmployeeTask{
+		ExecutionID: "collaboration-001",
+		Title:       "技术部差旅费核销",
+		Description: "请核销 2025 年 12 月技术部北京出差费用。",
+		AssigneeID:  emp2.ID, // 指派给李四
+		Status:      "pending",
+	}
+	if err := taskSvc.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	// 4. 验证任务指派情况
+	pending, err := taskSvc.GetPendingTasks(ctx, emp2.ID)
+	if err != nil || len(pending) != 1 || pending[0].ExecutionID != "collaboration-001" {
+		t.Fatalf("expected the task in 李四's pending list, got %v (%v)", pending, err)
+	}
+	if others, _ := taskSvc.GetPendingTasks(ctx, emp1.ID); len(others) != 0 {
+		t.Errorf("张三 should have no pending tasks, got %d", len(others))
+	}
+
+	// 5. 李四处理任务并创建一个子任务 (例如让行政部帮忙查行程单)
+	subTask := &models.DigitalEmployeeTask{
+		Title:       "查询北京行程单",
+		Description: "查询张三 12 月 10 日北京往返行程单。",
+		Status:      "pending",
+	}
+	if err := taskSvc.CreateSubTask(ctx, "collaboration-001", subTask); err != nil {
+		t.Fatalf("CreateSubTask failed: %v", err)
+	}
+
+	// 6. 验证子任务关联关系
+	saved, _ := taskSvc.GetTaskByExecutionID(ctx, "collaboration-001")
+	savedSub, err := taskSvc.GetTaskByExecutionID(ctx, subTask.ExecutionID)
+	if err != nil {
+		t.Fatalf("sub task not saved: %v", err)
+	}
+	if savedSub.ParentTaskID != saved.ID {
+		t.Errorf("ParentTaskID = %d, want %d", savedSub.ParentTaskID, saved.ID)
+	}
+	if !strings.HasPrefix(savedSub.ExecutionID, "sub-collaboration-001") {
+		t.Errorf("unexpected sub task execution id %q", savedSub.ExecutionID)
+	}
+
+	// 7. 父任务不存在时不会创建子任务
+	if err := taskSvc.CreateSubTask(ctx, "missing", &models.DigitalEmployeeTask{Title: "orphan"}); err == nil {
+		t.Error("expected an error for a missing parent task")
+	}
+}
@@ -0,0 +1,508 @@
+package employee
+
+import (
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 实验指标名称
+const (
+	MetricKPIScore  = "kpi_score"
+	MetricTokenCost = "token_cost"
+	MetricFeedback  = "feedback"
+)
+
+const (
+	defaultMinSamples    = 30
+	defaultConfidence    = 0.95
+	guardrailCheckPeriod = 30 * time.Second
+
+	experimentStatusRun  = "running"
+	experimentStatusHalt = "halted"
+	experimentStatusDone = "completed"
+	unitConversation     = "conversation"
+)
+
+// VariantConfig 数字员工运行配置，对应 DigitalJob.ConfigTemplate 与 ABVariant.ConfigOverride 的 JSON 结构
+type VariantConfig struct {
+	PromptBase       string   `json:"prompt_base,omitempty"`   // 替换基础人设 Prompt
+	PromptSuffix     string   `json:"prompt_suffix,omitempty"` // 追加到 Prompt 末尾的指令
+	Temperature      *float64 `json:"temperature,omitempty"`
+	ModelID          uint     `json:"model_id,omitempty"`
+	Tools            []string `json:"tools,omitempty"` // 允许使用的工具名，为空表示不限制
+	MemorySnapshotID uint     `json:"memory_snapshot_id,omitempty"`
+}
+
+// mergeConfigJSON 以 override 的键覆盖 base，返回合并后的 JSON 对象
+func mergeConfigJSON(base, override string) map[string]any {
+	merged := make(map[string]any)
+	json.Unmarshal([]byte(base), &merged)
+	var delta map[string]any
+	json.Unmarshal([]byte(override), &delta)
+	for k, v := range delta {
+		merged[k] = v
+	}
+	return merged
+}
+
+func parseVariantConfig(raw map[string]any) VariantConfig {
+	var cfg VariantConfig
+	data, _ := json.Marshal(raw)
+	json.Unmarshal(data, &cfg)
+	return cfg
+}
+
+// Assignment 一次对话命中的实验变体
+type Assignment struct {
+	ExperimentID uint   `json:"experiment_id"`
+	VariantID    uint   `json:"variant_id"`
+	VariantName  string `json:"variant_name"`
+	UnitKey      string `json:"unit_key"`
+}
+
+// ExperimentService A/B 实验运行时：流量分配、指标采集、显著性分析、护栏与胜出变体发布
+type ExperimentService struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastCheck map[uint]time.Time
+}
+
+func NewExperimentService(db *gorm.DB) *ExperimentService {
+	return &ExperimentService{db: db, lastCheck: make(map[uint]time.Time)}
+}
+
+// employeeJob 查找员工当前岗位：优先在职的主岗位关系，其次按职位名称匹配 (Recruit 以职位名作为 Title)
+func (s *ExperimentService) employeeJob(ctx context.Context, emp *models.DigitalEmployee) *models.DigitalJob {
+	var job models.DigitalJob
+	var rel models.EmployeeJobRelation
+	if err := s.db.WithContext(ctx).Where("employee_id = ? AND status = ?", emp.ID, "active").
+		Order("is_primary DESC, id DESC").First(&rel).Error; err == nil {
+		if err := s.db.WithContext(ctx).First(&job, rel.JobID).Error; err == nil {
+			return &job
+		}
+	}
+	if emp.Title != "" {
+		if err := s.db.WithContext(ctx).Where("name = ?", emp.Title).First(&job).Error; err == nil {
+			return &job
+		}
+	}
+	return nil
+}
+
+func experimentActive(exp *models.ABExperiment, now time.Time) bool {
+	if exp.Status != experimentStatusRun {
+		return false
+	}
+	if exp.StartDate != nil && now.Before(*exp.StartDate) {
+		return false
+	}
+	return exp.EndDate == nil || now.Before(*exp.EndDate)
+}
+
+// unitKey 按实验的分流单位生成稳定的分流键
+func unitKey(exp *models.ABExperiment, botID, userID, groupID string) string {
+	if exp.Unit == unitConversation {
+		if groupID != "" {
+			return fmt.Sprintf("bot:%s:group:%s", botID, groupID)
+		}
+		return fmt.Sprintf("bot:%s:user:%s", botID, userID)
+	}
+	return "user:" + userID
+}
+
+// pickVariant 按权重对分流键做确定性哈希分配
+func pickVariant(experimentID uint, key string, variants []models.ABVariant) *models.ABVariant {
+	total := 0
+	for _, v := range variants {
+		if v.Allocation > 0 {
+			total += v.Allocation
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%s", experimentID, key)
+	slot := int(h.Sum32() % uint32(total))
+	for i := range variants {
+		if variants[i].Allocation <= 0 {
+			continue
+		}
+		if slot < variants[i].Allocation {
+			return &variants[i]
+		}
+		slot -= variants[i].Allocation
+	}
+	return nil
+}
+
+// Resolve 计算员工本次对话的生效配置 (岗位模板 + 变体覆盖)，未参与实验时 Assignment 为 nil
+func (s *ExperimentService) Resolve(ctx context.Context, emp *models.DigitalEmployee, userID, groupID string) (VariantConfig, *Assignment) {
+	job := s.employeeJob(ctx, emp)
+	if job == nil {
+		return VariantConfig{}, nil
+	}
+
+	exp, variant, key := s.assign(ctx, emp, job.ID, userID, groupID)
+	if exp == nil {
+		return parseVariantConfig(mergeConfigJSON(job.ConfigTemplate, "")), nil
+	}
+	return parseVariantConfig(mergeConfigJSON(job.ConfigTemplate, variant.ConfigOverride)), &Assignment{
+		ExperimentID: exp.ID,
+		VariantID:    variant.ID,
+		VariantName:  variant.Name,
+		UnitKey:      key,
+	}
+}
+
+func (s *ExperimentService) assign(ctx context.Context, emp *models.DigitalEmployee, jobID uint, userID, groupID string) (*models.ABExperiment, *models.ABVariant, string) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+
+	// 以变体身份招聘的员工固定使用该变体 (实例级实验)
+	var rel models.EmployeeExperimentRelation
+	if err := db.Where(&models.EmployeeExperimentRelation{EmployeeID: emp.ID}).Last(&rel).Error; err == nil {
+		var exp models.ABExperiment
+		var variant models.ABVariant
+		if db.First(&exp, rel.ExperimentID).Error == nil && experimentActive(&exp, now) &&
+			db.First(&variant, rel.VariantID).Error == nil {
+			return &exp, &variant, unitKey(&exp, emp.BotID, userID, groupID)
+		}
+	}
+
+	// 同一岗位同时只运行一个流量实验，取最早创建的
+	var exps []models.ABExperiment
+	if err := db.Where("target_job_id = ? AND status = ?", jobID, experimentStatusRun).Order("id ASC").Find(&exps).Error; err != nil {
+		return nil, nil, ""
+	}
+	for i := range exps {
+		exp := &exps[i]
+		if !experimentActive(exp, now) {
+			continue
+		}
+		var variants []models.ABVariant
+		if err := db.Where("experiment_id = ?", exp.ID).Order("id ASC").Find(&variants).Error; err != nil || len(variants) == 0 {
+			continue
+		}
+		key := unitKey(exp, emp.BotID, userID, groupID)
+
+		var existing models.ABAssignment
+		if err := db.Where("experiment_id = ? AND unit_key = ?", exp.ID, key).First(&existing).Error; err == nil {
+			for j := range variants {
+				if variants[j].ID == existing.VariantID {
+					return exp, &variants[j], key
+				}
+			}
+		}
+
+		variant := pickVariant(exp.ID, key, variants)
+		if variant == nil {
+			continue
+		}
+		if err := db.Create(&models.ABAssignment{ExperimentID: exp.ID, UnitKey: key, VariantID: variant.ID}).Error; err != nil {
+			// 并发分配时以先写入的记录为准
+			if db.Where("experiment_id = ? AND unit_key = ?", exp.ID, key).First(&existing).Error == nil {
+				for j := range variants {
+					if variants[j].ID == existing.VariantID {
+						return exp, &variants[j], key
+					}
+				}
+			}
+		}
+		return exp, variant, key
+	}
+	return nil, nil, ""
+}
+
+// Record 记录一次指标观测，并按周期检查护栏
+func (s *ExperimentService) Record(a *Assignment, metric string, value float64) error {
+	if a == nil {
+		return nil
+	}
+	err := s.db.Create(&models.ABObservation{
+		ExperimentID: a.ExperimentID,
+		VariantID:    a.VariantID,
+		Metric:       metric,
+		UnitKey:      a.UnitKey,
+		Value:        value,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	due := time.Since(s.lastCheck[a.ExperimentID]) >= guardrailCheckPeriod
+	if due {
+		s.lastCheck[a.ExperimentID] = time.Now()
+	}
+	s.mu.Unlock()
+	if due {
+		if halted, reason, err := s.CheckGuardrails(a.ExperimentID); err != nil {
+			clog.Warn("[Experiment] Guardrail check failed", zap.Uint("experiment", a.ExperimentID), zap.Error(err))
+		} else if halted {
+			clog.Warn("[Experiment] Experiment halted by guardrail", zap.Uint("experiment", a.ExperimentID), zap.String("reason", reason))
+		}
+	}
+	return nil
+}
+
+// RecordFeedback 记录用户对某个数字员工的反馈评分，归属到该用户当前所在的变体
+func (s *ExperimentService) RecordFeedback(ctx context.Context, botID, userID, groupID string, score float64) (*Assignment, error) {
+	var emp models.DigitalEmployee
+	if err := s.db.WithContext(ctx).Where("bot_id = ?", botID).First(&emp).Error; err != nil {
+		return nil, fmt.Errorf("employee not found: %w", err)
+	}
+	_, a := s.Resolve(ctx, &emp, userID, groupID)
+	if a == nil {
+		return nil, errors.New("no running experiment for this employee")
+	}
+	return a, s.Record(a, MetricFeedback, score)
+}
+
+// VariantReport 单个变体的统计结果，差值均相对对照组
+type VariantReport struct {
+	Variant     models.ABVariant `json:"variant"`
+	IsControl   bool             `json:"is_control"`
+	Primary     SampleStats      `json:"primary"`
+	TokenCost   SampleStats      `json:"token_cost"`
+	Feedback    SampleStats      `json:"feedback"`
+	Diff        float64          `json:"diff"`
+	DiffCILow   *float64         `json:"diff_ci_low"` // 样本不足 2 个时为 null
+	DiffCIHigh  *float64         `json:"diff_ci_high"`
+	Lift        float64          `json:"lift"` // 相对提升比例
+	PValue      float64          `json:"p_value"`
+	Significant bool             `json:"significant"`
+	Better      bool             `json:"better"` // 显著优于对照组
+}
+
+// ExperimentReport 实验分析报告
+type ExperimentReport struct {
+	Experiment    models.ABExperiment `json:"experiment"`
+	Metric        string              `json:"metric"`
+	LowerIsBetter bool                `json:"lower_is_better"`
+	Confidence    float64             `json:"confidence"`
+	MinSamples    int                 `json:"min_samples"`
+	Variants      []VariantReport     `json:"variants"`
+	WinnerID      *uint               `json:"winner_id"`
+	Conclusion    string              `json:"conclusion"`
+}
+
+func experimentParams(exp *models.ABExperiment) (metric string, confidence float64, minSamples int) {
+	metric = exp.Metric
+	if metric == "" {
+		metric = MetricKPIScore
+	}
+	confidence = exp.Confidence
+	if confidence <= 0 || confidence >= 1 {
+		confidence = defaultConfidence
+	}
+	minSamples = exp.MinSamples
+	if minSamples <= 0 {
+		minSamples = defaultMinSamples
+	}
+	return
+}
+
+func (s *ExperimentService) samples(experimentID, variantID uint, metric string) ([]float64, error) {
+	var values []float64
+	err := s.db.Model(&models.ABObservation{}).
+		Where("experiment_id = ? AND variant_id = ? AND metric = ?", experimentID, variantID, metric).
+		Pluck("value", &values).Error
+	return values, err
+}
+
+// Analyze 计算各变体的指标均值、置信区间，并对核心指标做相对对照组的 Welch t 检验
+func (s *ExperimentService) Analyze(experimentID uint) (*ExperimentReport, error) {
+	var exp models.ABExperiment
+	if err := s.db.First(&exp, experimentID).Error; err != nil {
+		return nil, err
+	}
+	var variants []models.ABVariant
+	if err := s.db.Where("experiment_id = ?", experimentID).Order("id ASC").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	if len(variants) == 0 {
+		return nil, errors.New("experiment has no variants")
+	}
+
+	metric, confidence, minSamples := experimentParams(&exp)
+	report := &ExperimentReport{
+		Experiment:    exp,
+		Metric:        metric,
+		LowerIsBetter: metric == MetricTokenCost,
+		Confidence:    confidence,
+		MinSamples:    minSamples,
+	}
+
+	controlIdx := 0
+	for i, v := range variants {
+		if v.IsControl {
+			controlIdx = i
+			break
+		}
+	}
+
+	for i, v := range variants {
+		vr := VariantReport{Variant: v, IsControl: i == controlIdx}
+		for _, m := range []struct {
+			name string
+			dst  *SampleStats
+		}{{metric, &vr.Primary}, {MetricTokenCost, &vr.TokenCost}, {MetricFeedback, &vr.Feedback}} {
+			values, err := s.samples(exp.ID, v.ID, m.name)
+			if err != nil {
+				return nil, err
+			}
+			*m.dst = describe(values, confidence)
+		}
+		report.Variants = append(report.Variants, vr)
+	}
+
+	control := report.Variants[controlIdx].Primary
+	var best *VariantReport
+	pending := false
+	for i := range report.Variants {
+		vr := &report.Variants[i]
+		if vr.IsControl {
+			continue
+		}
+		vr.Diff, vr.DiffCILow, vr.DiffCIHigh, vr.PValue = welchTest(control, vr.Primary, confidence)
+		if control.Mean != 0 {
+			vr.Lift = vr.Diff / math.Abs(control.Mean)
+		}
+		if control.N < minSamples || vr.Primary.N < minSamples {
+			pending = true
+			continue
+		}
+		vr.Significant = vr.PValue < 1-confidence
+		improved := vr.Diff > 0
+		if report.LowerIsBetter {
+			improved = vr.Diff < 0
+		}
+		vr.Better = vr.Significant && improved
+		if vr.Better && (best == nil || math.Abs(vr.Diff) > math.Abs(best.Diff)) {
+			best = vr
+		}
+	}
+
+	switch {
+	case best != nil:
+		id := best.Variant.ID
+		report.WinnerID = &id
+		report.Conclusion = fmt.Sprintf("变体 %s 在 %s 上显著优于对照组 (p=%.4f, lift=%.1f%%)", best.Variant.Name, metric, best.PValue, best.Lift*100)
+	case pending:
+		report.Conclusion = fmt.Sprintf("样本不足，每个变体至少需要 %d 个 %s 样本", minSamples, metric)
+	default:
+		report.Conclusion = "没有变体显著优于对照组"
+	}
+	return report, nil
+}
+
+// CheckGuardrails 检查护栏：变体核心指标显著劣于对照组超过阈值，或 Token 成本超出倍数时停止实验
+func (s *ExperimentService) CheckGuardrails(experimentID uint) (bool, string, error) {
+	report, err := s.Analyze(experimentID)
+	if err != nil {
+		return false, "", err
+	}
+	exp := report.Experiment
+	if exp.Status != experimentStatusRun {
+		return false, "", nil
+	}
+
+	var control *VariantReport
+	for i := range report.Variants {
+		if report.Variants[i].IsControl {
+			control = &report.Variants[i]
+		}
+	}
+
+	var reason string
+	for _, vr := range report.Variants {
+		if vr.IsControl {
+			continue
+		}
+		if exp.GuardrailMaxDrop > 0 && vr.Significant && !vr.Better {
+			drop := -vr.Lift
+			if report.LowerIsBetter {
+				drop = vr.Lift
+			}
+			if drop > exp.GuardrailMaxDrop {
+				reason = fmt.Sprintf("变体 %s 的 %s 相对对照组恶化 %.1f%% (p=%.4f)", vr.Variant.Name, report.Metric, drop*100, vr.PValue)
+				break
+			}
+		}
+		if exp.GuardrailMaxCostRatio > 0 && control.TokenCost.N >= report.MinSamples && vr.TokenCost.N >= report.MinSamples &&
+			control.TokenCost.Mean > 0 && vr.TokenCost.Mean/control.TokenCost.Mean > exp.GuardrailMaxCostRatio {
+			reason = fmt.Sprintf("变体 %s 的 Token 成本为对照组的 %.2f 倍", vr.Variant.Name, vr.TokenCost.Mean/control.TokenCost.Mean)
+			break
+		}
+	}
+	if reason == "" {
+		return false, "", nil
+	}
+
+	now := time.Now()
+	err = s.db.Model(&models.ABExperiment{}).Where("id = ? AND status = ?", exp.ID, experimentStatusRun).
+		Updates(map[string]any{"status": experimentStatusHalt, "halt_reason": reason, "end_date": &now}).Error
+	return err == nil, reason, err
+}
+
+// Promote 将变体配置合并到目标岗位的配置模板并结束实验；variantID 为 0 时使用统计判定的胜出变体
+func (s *ExperimentService) Promote(experimentID, variantID uint) (*models.DigitalJob, error) {
+	report, err := s.Analyze(experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if variantID == 0 {
+		if report.WinnerID == nil {
+			return nil, fmt.Errorf("no winning variant: %s", report.Conclusion)
+		}
+		variantID = *report.WinnerID
+	}
+
+	var variant *models.ABVariant
+	for i := range report.Variants {
+		if report.Variants[i].Variant.ID == variantID {
+			variant = &report.Variants[i].Variant
+		}
+	}
+	if variant == nil {
+		return nil, errors.New("variant does not belong to this experiment")
+	}
+
+	var job models.DigitalJob
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&job, report.Experiment.TargetJobID).Error; err != nil {
+			return fmt.Errorf("target job not found: %w", err)
+		}
+		data, _ := json.Marshal(mergeConfigJSON(job.ConfigTemplate, variant.ConfigOverride))
+		job.ConfigTemplate = string(data)
+		if err := tx.Model(&job).Update("config_template", job.ConfigTemplate).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&models.ABExperiment{}).Where("id = ?", experimentID).Updates(map[string]any{
+			"status":            experimentStatusDone,
+			"winner_variant_id": variantID,
+			"end_date":          &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	clog.Info("[Experiment] Variant promoted", zap.Uint("experiment", experimentID), zap.Uint("variant", variantID), zap.Uint("job", job.ID))
+	return &job, nil
+}
@@ -0,0 +1,202 @@
+package employee
+
+import (
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newExperimentDB(t *testing.T) *gorm.DB {
+	clog.InitDefaultLogger()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.DigitalEmployee{}, &models.DigitalJob{}, &models.EmployeeJobRelation{},
+		&models.ABExperiment{}, &models.ABVariant{}, &models.EmployeeExperimentRelation{},
+		&models.ABAssignment{}, &models.ABObservation{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+// seedExperiment 创建岗位、员工和一个两变体 (对照组 + 实验组) 的运行中实验
+func seedExperiment(t *testing.T, db *gorm.DB, exp models.ABExperiment) (*models.DigitalEmployee, []models.ABVariant) {
+	job := models.DigitalJob{Name: "Support", ConfigTemplate: `{"prompt_suffix": "Be polite.", "temperature": 0.7}`}
+	db.Create(&job)
+	emp := models.DigitalEmployee{BotID: "bot1", EmployeeID: "E001", Name: "Alice", Title: "Support"}
+	db.Create(&emp)
+
+	exp.Name = "tone"
+	exp.TargetJobID = job.ID
+	exp.Status = experimentStatusRun
+	if err := db.Create(&exp).Error; err != nil {
+		t.Fatalf("failed to create experiment: %v", err)
+	}
+	variants := []models.ABVariant{
+		{ExperimentID: exp.ID, Name: "control", Allocation: 50, IsControl: true},
+		{ExperimentID: exp.ID, Name: "concise", Allocation: 50, ConfigOverride: `{"prompt_suffix": "Be concise.", "model_id": 7}`},
+	}
+	db.Create(&variants)
+	return &emp, variants
+}
+
+func TestWelchTest(t *testing.T) {
+	// 与 scipy.stats.ttest_ind(equal_var=False) 对照
+	a := describe([]float64{19.7, 20.4, 19.9, 20.1, 20.3, 19.8}, 0.95)
+	b := describe([]float64{21.0, 20.6, 21.4, 20.9, 21.3, 20.8}, 0.95)
+	diff, low, high, p := welchTest(a, b, 0.95)
+	if math.Abs(diff-0.9667) > 1e-3 {
+		t.Errorf("unexpected diff %f", diff)
+	}
+	if p <= 0 || p > 1e-3 {
+		t.Errorf("expected a significant p-value, got %g", p)
+	}
+	if low == nil || high == nil || *low <= 0 || *high <= *low {
+		t.Errorf("confidence interval should exclude zero: [%v, %v]", low, high)
+	}
+
+	same := describe([]float64{1, 2, 3, 4, 5}, 0.95)
+	if _, _, _, p := welchTest(same, same, 0.95); math.Abs(p-1) > 1e-9 {
+		t.Errorf("identical samples must give p=1, got %g", p)
+	}
+	if q := tQuantile(0.975, 10); math.Abs(q-2.228) > 1e-3 {
+		t.Errorf("t quantile: want 2.228, got %f", q)
+	}
+}
+
+func TestResolveAssignmentIsSticky(t *testing.T) {
+	db := newExperimentDB(t)
+	emp, variants := seedExperiment(t, db, models.ABExperiment{})
+	svc := NewExperimentService(db)
+	ctx := context.Background()
+
+	seen := map[uint]int{}
+	for i := 0; i < 40; i++ {
+		user := fmt.Sprintf("u%d", i)
+		cfg, a := svc.Resolve(ctx, emp, user, "")
+		if a == nil {
+			t.Fatalf("user %s should be enrolled", user)
+		}
+		_, again := svc.Resolve(ctx, emp, user, "")
+		if again.VariantID != a.VariantID {
+			t.Fatalf("assignment for %s changed from %d to %d", user, a.VariantID, again.VariantID)
+		}
+		seen[a.VariantID]++
+
+		if a.VariantID == variants[1].ID {
+			if cfg.PromptSuffix != "Be concise." || cfg.ModelID != 7 || cfg.Temperature == nil || *cfg.Temperature != 0.7 {
+				t.Errorf("variant override not merged over job template: %+v", cfg)
+			}
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected traffic on both variants, got %v", seen)
+	}
+
+	// 调整权重后，已分配的用户保持原变体
+	db.Model(&models.ABVariant{}).Where("id = ?", variants[1].ID).Update("allocation", 0)
+	var assigned models.ABAssignment
+	db.Where("variant_id = ?", variants[1].ID).First(&assigned)
+	_, a := svc.Resolve(ctx, emp, assigned.UnitKey[len("user:"):], "")
+	if a == nil || a.VariantID != variants[1].ID {
+		t.Errorf("existing assignment must survive reallocation, got %+v", a)
+	}
+}
+
+func TestGuardrailHaltsExperiment(t *testing.T) {
+	db := newExperimentDB(t)
+	_, variants := seedExperiment(t, db, models.ABExperiment{MinSamples: 5, GuardrailMaxDrop: 0.2})
+	svc := NewExperimentService(db)
+	expID := variants[0].ExperimentID
+
+	for i := 0; i < 6; i++ {
+		svc.Record(&Assignment{ExperimentID: expID, VariantID: variants[0].ID}, MetricKPIScore, 80+float64(i%3))
+		svc.Record(&Assignment{ExperimentID: expID, VariantID: variants[1].ID}, MetricKPIScore, 50+float64(i%3))
+	}
+
+	halted, reason, err := svc.CheckGuardrails(expID)
+	if err != nil || !halted || reason == "" {
+		t.Fatalf("expected experiment to be halted, got halted=%v reason=%q err=%v", halted, reason, err)
+	}
+	var exp models.ABExperiment
+	db.First(&exp, expID)
+	if exp.Status != experimentStatusHalt || exp.HaltReason == "" || exp.EndDate == nil {
+		t.Errorf("halt not persisted: %+v", exp)
+	}
+}
+
+func TestPromoteWinner(t *testing.T) {
+	db := newExperimentDB(t)
+	_, variants := seedExperiment(t, db, models.ABExperiment{MinSamples: 5})
+	svc := NewExperimentService(db)
+	expID := variants[0].ExperimentID
+
+	if _, err := svc.Promote(expID, 0); err == nil {
+		t.Fatal("promote without enough samples must fail")
+	}
+
+	for i := 0; i < 8; i++ {
+		svc.Record(&Assignment{ExperimentID: expID, VariantID: variants[0].ID}, MetricKPIScore, 70+float64(i%4))
+		svc.Record(&Assignment{ExperimentID: expID, VariantID: variants[1].ID}, MetricKPIScore, 85+float64(i%4))
+	}
+	report, err := svc.Analyze(expID)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if report.WinnerID == nil || *report.WinnerID != variants[1].ID {
+		t.Fatalf("expected variant %d to win: %s", variants[1].ID, report.Conclusion)
+	}
+
+	job, err := svc.Promote(expID, 0)
+	if err != nil {
+		t.Fatalf("promote failed: %v", err)
+	}
+	var cfg map[string]any
+	json.Unmarshal([]byte(job.ConfigTemplate), &cfg)
+	if cfg["prompt_suffix"] != "Be concise." || cfg["temperature"] != 0.7 || cfg["model_id"] != float64(7) {
+		t.Errorf("unexpected promoted template: %s", job.ConfigTemplate)
+	}
+
+	var exp models.ABExperiment
+	db.First(&exp, expID)
+	if exp.Status != experimentStatusDone || exp.WinnerVariantID == nil || *exp.WinnerVariantID != variants[1].ID {
+		t.Errorf("experiment not completed with winner: %+v", exp)
+	}
+}
+
+func TestReportWithFewSamplesEncodes(t *testing.T) {
+	db := newExperimentDB(t)
+	_, variants := seedExperiment(t, db, models.ABExperiment{MinSamples: 5})
+	svc := NewExperimentService(db)
+	expID := variants[0].ExperimentID
+
+	// 新实验：对照组 1 个样本，实验组没有样本
+	svc.Record(&Assignment{ExperimentID: expID, VariantID: variants[0].ID}, MetricKPIScore, 80)
+	report, err := svc.Analyze(expID)
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("report must be JSON encodable: %v", err)
+	}
+	var decoded struct {
+		Variants []map[string]any `json:"variants"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Variants) != 2 {
+		t.Fatalf("unexpected report %s: %v", data, err)
+	}
+	if v := decoded.Variants[1]; v["diff_ci_low"] != nil || v["diff_ci_high"] != nil {
+		t.Errorf("confidence interval must be null without enough samples, got %v / %v", v["diff_ci_low"], v["diff_ci_high"])
+	}
+}
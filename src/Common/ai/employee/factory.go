@@ -40,15 +40,15 @@ func (f *DigitalEmployeeFactory) Recruit(ctx context.Context, params RecruitPara
 		return nil, fmt.Errorf("job not found: %w", err)
 	}
 
-	// 2. 检查是否有 Variant (基因突变/特定配置)
-	var variantConfig map[string]interface{}
+	// 2. 检查是否有 Variant (基因突变/特定配置)，变体配置覆盖岗位默认配置
+	var variantOverride string
 	if params.VariantID != nil {
 		var variant models.ABVariant
 		if err := f.db.WithContext(ctx).First(&variant, *params.VariantID).Error; err == nil {
-			// 解析配置覆盖
-			json.Unmarshal([]byte(variant.ConfigOverride), &variantConfig)
+			variantOverride = variant.ConfigOverride
 		}
 	}
+	variantConfig := mergeConfigJSON(job.ConfigTemplate, variantOverride)
 
 	// 3. 自动生成姓名 (如果未提供)
 	name := params.Name
@@ -0,0 +1,115 @@
+package employee
+
+import (
+	"BotMatrix/common/models"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmployeeKPIAndOptimization(t *testing.T) {
+	// 1. 初始化内存数据库
+	db := newTaskTestDB(t)
+
+	// 2. 模拟 AI 服务
+	mockAI := &scriptedAIService{replies: []string{"优化后的简介：我是一名专业的资深助理，擅长处理各种复杂任务。"}}
+	kpiSvc := NewDigitalEmployeeKPIService(db, mockAI)
+
+	ctx := context.Background()
+
+	// 3. 创建测试员工与默认模型
+	emp := models.DigitalEmployee{
+		Name:       "王五",
+		Bio:        "原始简介：我是一个普通的助理。",
+		BotID:      "bot-wangwu",
+		EmployeeID: "EMP001",
+	}
+	db.Create(&emp)
+
+	db.Create(&models.AIModel{
+		ModelID:   "gpt-4",
+		IsDefault: true,
+	})
+
+	// 4. 创建一些失败的任务记录
+	for i := 0; i < 3; i++ {
+		db.Create(&models.DigitalEmployeeTask{
+			ExecutionID: fmt.Sprintf("exec-fail-%d", i),
+			AssigneeID:  emp.ID,
+			Status:      "failed",
+			ResultRaw:   "Task execution timed out",
+			CreatedAt:   time.Now().AddDate(0, 0, -i),
+		})
+	}
+
+	// 5. 运行 KPI 计算
+	score, err := kpiSvc.CalculateKPI(ctx, emp.ID)
+	if err != nil {
+		t.Fatalf("CalculateKPI failed: %v", err)
+	}
+	if score != 0 { // 3 个任务，0 个完成，KPI 应为 0
+		t.Errorf("score = %v, want 0", score)
+	}
+
+	// 6. 运行优化
+	if err := kpiSvc.OptimizeEmployee(ctx, emp.ID); err != nil {
+		t.Fatalf("OptimizeEmployee failed: %v", err)
+	}
+
+	// 7. 验证优化后的 Bio
+	var updatedEmp models.DigitalEmployee
+	db.First(&updatedEmp, emp.ID)
+	if !strings.Contains(updatedEmp.Bio, "优化后的简介") || updatedEmp.KpiScore != 0 {
+		t.Errorf("unexpected employee after optimization: bio=%q kpi=%v", updatedEmp.Bio, updatedEmp.KpiScore)
+	}
+
+	// 8. 测试生成绩效报告
+	report, err := kpiSvc.GetPerformanceReport(ctx, emp.ID, 30)
+	if err != nil {
+		t.Fatalf("GetPerformanceReport failed: %v", err)
+	}
+	for _, want := range []string{"数字员工绩效报告", "王五", "失败 3", "表现欠佳"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+
+	// 9. 测试高绩效场景
+	emp2 := models.DigitalEmployee{
+		Name:       "赵六",
+		Title:      "高级架构师",
+		BotID:      "bot-zhaoliu",
+		EmployeeID: "EMP002",
+	}
+	db.Create(&emp2)
+	for i := 0; i < 5; i++ {
+		db.Create(&models.DigitalEmployeeTask{
+			ExecutionID: fmt.Sprintf("exec-success-%d", i),
+			AssigneeID:  emp2.ID,
+			Status:      "completed",
+			PlanRaw:     `{"steps": [{"index": 1, "title": "检索", "tool": "knowledge_search"}]}`,
+			CreatedAt:   time.Now().AddDate(0, 0, -i),
+		})
+	}
+
+	report2, err := kpiSvc.GetPerformanceReport(ctx, emp2.ID, 30)
+	if err != nil {
+		t.Fatalf("GetPerformanceReport failed: %v", err)
+	}
+	for _, want := range []string{"表现优异", "成功 5", "`knowledge_search`: 使用 5 次"} {
+		if !strings.Contains(report2, want) {
+			t.Errorf("report missing %q:\n%s", want, report2)
+		}
+	}
+
+	// 10. 没有失败任务时不做优化，也不调用 AI
+	calls := mockAI.calls
+	if err := kpiSvc.OptimizeEmployee(ctx, emp2.ID); err != nil {
+		t.Fatalf("OptimizeEmployee failed: %v", err)
+	}
+	if mockAI.calls != calls {
+		t.Error("employees without failed tasks should not be optimized")
+	}
+}
@@ -3,6 +3,7 @@ package employee
 import (
 	"BotMatrix/common/ai/b2b"
 	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/identity"
 	clog "BotMatrix/common/log"
 	"BotMatrix/common/models"
 	"BotMatrix/common/types"
@@ -18,6 +19,7 @@ import (
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // --- DigitalEmployeeService Implementation ---
@@ -315,6 +317,9 @@ func (s *CognitiveMemoryServiceImpl) SetEmbeddingService(svc any) {
 }
 
 func (s *CognitiveMemoryServiceImpl) GetRelevantMemories(ctx context.Context, userID string, botID string, query string) ([]models.CognitiveMemory, error) {
+	// 跨平台绑定后记忆归属于 NexusUID
+	userID = identity.Resolve(s.db, userID)
+
 	var userMemories []models.CognitiveMemory
 	var roleMemories []models.CognitiveMemory
 
@@ -390,6 +395,7 @@ func (s *CognitiveMemoryServiceImpl) SaveMemory(ctx context.Context, memory *mod
 		memory.CreatedAt = time.Now()
 	}
 	memory.LastSeen = time.Now()
+	memory.UserID = identity.Resolve(s.db, memory.UserID)
 
 	if s.embeddingSvc != nil && memory.Content != "" {
 		vec, err := s.embeddingSvc.GenerateEmbedding(ctx, memory.Content)
@@ -413,6 +419,8 @@ func (s *CognitiveMemoryServiceImpl) ConsolidateMemories(ctx context.Context, us
 		return fmt.Errorf("AI service is required for consolidation")
 	}
 
+	userID = identity.Resolve(s.db, userID)
+
 	var memories []models.CognitiveMemory
 	query := s.db.WithContext(ctx).Where("bot_id = ?", botID)
 	if userID != "" {
@@ -636,27 +644,27 @@ func (s *DigitalEmployeeTaskServiceImpl) CreateTask(ctx context.Context, task *m
 
 func (s *DigitalEmployeeTaskServiceImpl) UpdateTaskStatus(ctx context.Context, executionID string, status string, progress int) error {
 	updates := map[string]any{
-		"status":   status,
-		"progress": progress,
+		"Status":   status,
+		"Progress": progress,
 	}
 	if status == "completed" || status == "failed" {
 		now := time.Now()
-		updates["end_time"] = &now
+		updates["EndTime"] = &now
 	}
 	return s.db.WithContext(ctx).Model(&models.DigitalEmployeeTask{}).
-		Where("execution_id = ?", executionID).
+		Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
 		Updates(updates).Error
 }
 
 func (s *DigitalEmployeeTaskServiceImpl) GetPendingTasks(ctx context.Context, employeeID uint) ([]*models.DigitalEmployeeTask, error) {
 	var tasks []*models.DigitalEmployeeTask
-	err := s.db.Where("assignee_id = ? AND status = ?", employeeID, "pending").Find(&tasks).Error
+	err := s.db.Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "pending"}).Find(&tasks).Error
 	return tasks, err
 }
 
 func (s *DigitalEmployeeTaskServiceImpl) GetTaskByExecutionID(ctx context.Context, executionID string) (*models.DigitalEmployeeTask, error) {
 	var task models.DigitalEmployeeTask
-	if err := s.db.WithContext(ctx).Where("execution_id = ?", executionID).First(&task).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).First(&task).Error; err != nil {
 		return nil, err
 	}
 	return &task, nil
@@ -664,8 +672,8 @@ func (s *DigitalEmployeeTaskServiceImpl) GetTaskByExecutionID(ctx context.Contex
 
 func (s *DigitalEmployeeTaskServiceImpl) AssignTask(ctx context.Context, executionID string, assigneeID uint) error {
 	return s.db.WithContext(ctx).Model(&models.DigitalEmployeeTask{}).
-		Where("execution_id = ?", executionID).
-		Update("assignee_id", assigneeID).Error
+		Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
+		Update("AssigneeID", assigneeID).Error
 }
 
 func (s *DigitalEmployeeTaskServiceImpl) PlanTask(ctx context.Context, executionID string) error {
@@ -728,11 +736,11 @@ func (s *DigitalEmployeeTaskServiceImpl) PlanTask(ctx context.Context, execution
 	}
 
 	return s.db.WithContext(ctx).Model(&models.DigitalEmployeeTask{}).
-		Where("execution_id = ?", executionID).
+		Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
 		Updates(map[string]any{
-			"plan_raw": content,
-			"status":   "executing",
-			"progress": 30,
+			"PlanRaw":  content,
+			"Status":   "executing",
+			"Progress": 30,
 		}).Error
 }
 
@@ -779,11 +787,11 @@ func (s *DigitalEmployeeTaskServiceImpl) ExecuteTask(ctx context.Context, execut
 
 		if step.RequiresApproval && task.Status != "approved" {
 			s.db.Model(&models.DigitalEmployeeTask{}).
-				Where("execution_id = ?", executionID).
+				Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
 				Updates(map[string]any{
-					"status":             "pending_approval",
-					"current_step_index": i,
-					"result_raw":         strings.Join(results, "\n\n"),
+					"Status":           "pending_approval",
+					"CurrentStepIndex": i,
+					"ResultRaw":        strings.Join(results, "\n\n"),
 				})
 			fmt.Printf("[Task] Step %d requires approval, pausing execution\n", i+1)
 			return nil
@@ -827,11 +835,11 @@ func (s *DigitalEmployeeTaskServiceImpl) ExecuteTask(ctx context.Context, execut
 		results = append(results, fmt.Sprintf("### %s\n%s", step.Title, stepResult))
 
 		s.db.Model(&models.DigitalEmployeeTask{}).
-			Where("execution_id = ?", executionID).
+			Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
 			Updates(map[string]any{
-				"progress":           progress,
-				"current_step_index": i + 1,
-				"result_raw":         strings.Join(results, "\n\n"),
+				"Progress":         progress,
+				"CurrentStepIndex": i + 1,
+				"ResultRaw":        strings.Join(results, "\n\n"),
 			})
 
 		if task.Status == "approved" {
@@ -849,8 +857,8 @@ func (s *DigitalEmployeeTaskServiceImpl) ExecuteStep(ctx context.Context, execut
 
 func (s *DigitalEmployeeTaskServiceImpl) ApproveTask(ctx context.Context, executionID string) error {
 	return s.db.WithContext(ctx).Model(&models.DigitalEmployeeTask{}).
-		Where("execution_id = ?", executionID).
-		Update("status", "approved").Error
+		Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
+		Update("Status", "approved").Error
 }
 
 func (s *DigitalEmployeeTaskServiceImpl) CreateSubTask(ctx context.Context, parentExecutionID string, subTask *models.DigitalEmployeeTask) error {
@@ -877,12 +885,12 @@ func (s *DigitalEmployeeTaskServiceImpl) RecordTaskResult(ctx context.Context, e
 	}
 	now := time.Now()
 	return s.db.WithContext(ctx).Model(&models.DigitalEmployeeTask{}).
-		Where("execution_id = ?", executionID).
+		Where(&models.DigitalEmployeeTask{ExecutionID: executionID}).
 		Updates(map[string]any{
-			"result_raw": result,
-			"status":     status,
-			"end_time":   &now,
-			"progress":   100,
+			"ResultRaw": result,
+			"Status":    status,
+			"EndTime":   &now,
+			"Progress":  100,
 		}).Error
 }
 
@@ -903,6 +911,11 @@ func NewDigitalEmployeeKPIService(db *gorm.DB, aiSvc types.AIService) DigitalEmp
 	}
 }
 
+// createdAfter 按 CreatedAt 列过滤 (列名为大驼峰，需要带引号的列引用)
+func createdAfter(t time.Time) clause.Expression {
+	return clause.Gt{Column: clause.Column{Name: "CreatedAt"}, Value: t}
+}
+
 func (s *DigitalEmployeeKPIServiceImpl) SetB2BService(b2b b2b.B2BService) {
 	s.b2bSvc = b2b
 }
@@ -918,7 +931,7 @@ func (s *DigitalEmployeeKPIServiceImpl) CalculateKPI(ctx context.Context, employ
 
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
 	s.db.Model(&models.DigitalEmployeeTask{}).
-		Where("assignee_id = ? AND created_at > ?", employeeID, thirtyDaysAgo).
+		Where(&models.DigitalEmployeeTask{AssigneeID: employeeID}).Where(createdAfter(thirtyDaysAgo)).
 		Count(&stats.TotalTasks)
 
 	if stats.TotalTasks == 0 {
@@ -926,7 +939,7 @@ func (s *DigitalEmployeeKPIServiceImpl) CalculateKPI(ctx context.Context, employ
 	}
 
 	s.db.Model(&models.DigitalEmployeeTask{}).
-		Where("assignee_id = ? AND status = ? AND created_at > ?", employeeID, "completed", thirtyDaysAgo).
+		Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "completed"}).Where(createdAfter(thirtyDaysAgo)).
 		Count(&stats.CompletedTasks)
 
 	var avgStats struct {
@@ -934,7 +947,7 @@ func (s *DigitalEmployeeKPIServiceImpl) CalculateKPI(ctx context.Context, employ
 		AvgDuration   float64 `gorm:"column:avg_duration"`
 	}
 	s.db.Model(&models.DigitalEmployeeTask{}).
-		Where("assignee_id = ? AND status = ? AND created_at > ?", employeeID, "completed", thirtyDaysAgo).
+		Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "completed"}).Where(createdAfter(thirtyDaysAgo)).
 		Select("COALESCE(AVG(token_usage), 0) as avg_token_usage, COALESCE(AVG(duration), 0) as avg_duration").
 		Scan(&avgStats)
 
@@ -975,7 +988,7 @@ func (s *DigitalEmployeeKPIServiceImpl) CalculateKPI(ctx context.Context, employ
 
 	finalScore := (scoreCompletion * 0.6) + (scoreEfficiency * 0.2) + (scoreCost * 0.2)
 
-	if err := s.db.Model(&models.DigitalEmployee{}).Where("id = ?", employeeID).Update("kpi_score", finalScore).Error; err != nil {
+	if err := s.db.Model(&models.DigitalEmployee{}).Where(&models.DigitalEmployee{ID: employeeID}).Update("KpiScore", finalScore).Error; err != nil {
 		return finalScore, err
 	}
 
@@ -990,7 +1003,7 @@ func (s *DigitalEmployeeKPIServiceImpl) OptimizeEmployee(ctx context.Context, em
 
 	if s.b2bSvc != nil {
 		var dispatch models.DigitalEmployeeDispatch
-		err := s.db.Where("employee_id = ? AND status = ?", employeeID, "approved").First(&dispatch).Error
+		err := s.db.Where(&models.DigitalEmployeeDispatch{EmployeeID: employeeID, Status: "approved"}).First(&dispatch).Error
 		if err == nil {
 			hasPerm, err := s.b2bSvc.CheckDispatchPermission(employeeID, dispatch.TargetEntID, "optimize")
 			if err != nil {
@@ -1003,8 +1016,8 @@ func (s *DigitalEmployeeKPIServiceImpl) OptimizeEmployee(ctx context.Context, em
 	}
 
 	var failedTasks []models.DigitalEmployeeTask
-	s.db.Where("assignee_id = ? AND status = ?", employeeID, "failed").
-		Order("created_at DESC").
+	s.db.Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "failed"}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "CreatedAt"}, Desc: true}).
 		Limit(5).
 		Find(&failedTasks)
 
@@ -1044,7 +1057,7 @@ func (s *DigitalEmployeeKPIServiceImpl) OptimizeEmployee(ctx context.Context, em
 		zap.String("name", emp.Name),
 		zap.Uint("enterprise_id", emp.EnterpriseID))
 
-	return s.db.Model(&models.DigitalEmployee{}).Where("id = ?", employeeID).Update("bio", optimizedBio).Error
+	return s.db.Model(&models.DigitalEmployee{}).Where(&models.DigitalEmployee{ID: employeeID}).Update("Bio", optimizedBio).Error
 }
 
 func (s *DigitalEmployeeKPIServiceImpl) GetPerformanceReport(ctx context.Context, employeeID uint, days int) (string, error) {
@@ -1063,16 +1076,16 @@ func (s *DigitalEmployeeKPIServiceImpl) GetPerformanceReport(ctx context.Context
 		AvgDuration   float64
 	}
 	startTime := time.Now().AddDate(0, 0, -days)
-	s.db.Model(&models.DigitalEmployeeTask{}).Where("assignee_id = ? AND created_at > ?", employeeID, startTime).Count(&stats.Total)
-	s.db.Model(&models.DigitalEmployeeTask{}).Where("assignee_id = ? AND status = ? AND created_at > ?", employeeID, "completed", startTime).Count(&stats.Completed)
-	s.db.Model(&models.DigitalEmployeeTask{}).Where("assignee_id = ? AND status = ? AND created_at > ?", employeeID, "failed", startTime).Count(&stats.Failed)
+	s.db.Model(&models.DigitalEmployeeTask{}).Where(&models.DigitalEmployeeTask{AssigneeID: employeeID}).Where(createdAfter(startTime)).Count(&stats.Total)
+	s.db.Model(&models.DigitalEmployeeTask{}).Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "completed"}).Where(createdAfter(startTime)).Count(&stats.Completed)
+	s.db.Model(&models.DigitalEmployeeTask{}).Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "failed"}).Where(createdAfter(startTime)).Count(&stats.Failed)
 
 	var avgStats struct {
 		AvgTokenUsage float64 `gorm:"column:avg_token_usage"`
 		AvgDuration   float64 `gorm:"column:avg_duration"`
 	}
 	s.db.Model(&models.DigitalEmployeeTask{}).
-		Where("assignee_id = ? AND status = ? AND created_at > ?", employeeID, "completed", startTime).
+		Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "completed"}).Where(createdAfter(startTime)).
 		Select("COALESCE(AVG(token_usage), 0) as avg_token_usage, COALESCE(AVG(duration), 0) as avg_duration").
 		Scan(&avgStats)
 
@@ -1088,7 +1101,7 @@ func (s *DigitalEmployeeKPIServiceImpl) GetPerformanceReport(ctx context.Context
 	report += fmt.Sprintf("- **累计消耗 Token**: %d / %d (预算)\n", emp.SalaryToken, emp.SalaryLimit)
 
 	var tasks []models.DigitalEmployeeTask
-	s.db.Where("assignee_id = ? AND status = ? AND plan_raw != ''", employeeID, "completed").Limit(20).Find(&tasks)
+	s.db.Where(&models.DigitalEmployeeTask{AssigneeID: employeeID, Status: "completed"}).Where(clause.Neq{Column: clause.Column{Name: "PlanRaw"}, Value: ""}).Limit(20).Find(&tasks)
 	toolCounts := make(map[string]int)
 	for _, t := range tasks {
 		var plan struct {
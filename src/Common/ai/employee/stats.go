@@ -0,0 +1,154 @@
+package employee
+
+import "math"
+
+// SampleStats 单组样本的描述统计
+type SampleStats struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	CILow  float64 `json:"ci_low"`  // 均值置信区间下限
+	CIHigh float64 `json:"ci_high"` // 均值置信区间上限
+}
+
+// describe 计算样本均值、标准差及均值的置信区间 (t 分布)
+func describe(values []float64, confidence float64) SampleStats {
+	st := SampleStats{N: len(values)}
+	if st.N == 0 {
+		return st
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	st.Mean = sum / float64(st.N)
+	st.CILow, st.CIHigh = st.Mean, st.Mean
+	if st.N < 2 {
+		return st
+	}
+
+	var ss float64
+	for _, v := range values {
+		ss += (v - st.Mean) * (v - st.Mean)
+	}
+	st.StdDev = math.Sqrt(ss / float64(st.N-1))
+	margin := tQuantile(1-(1-confidence)/2, float64(st.N-1)) * st.StdDev / math.Sqrt(float64(st.N))
+	st.CILow, st.CIHigh = st.Mean-margin, st.Mean+margin
+	return st
+}
+
+// welchTest 对两组样本做 Welch t 检验，返回均值差 (b-a)、差值置信区间和双侧 p 值。
+// 任一组样本少于 2 个时置信区间无法计算，low/high 为 nil
+func welchTest(a, b SampleStats, confidence float64) (diff float64, low, high *float64, p float64) {
+	diff = b.Mean - a.Mean
+	if a.N < 2 || b.N < 2 {
+		return diff, nil, nil, 1
+	}
+	va := a.StdDev * a.StdDev / float64(a.N)
+	vb := b.StdDev * b.StdDev / float64(b.N)
+	se := math.Sqrt(va + vb)
+	if se == 0 {
+		if diff == 0 {
+			return 0, ptr(0), ptr(0), 1
+		}
+		return diff, ptr(diff), ptr(diff), 0
+	}
+
+	df := (va + vb) * (va + vb) / (va*va/float64(a.N-1) + vb*vb/float64(b.N-1))
+	t := diff / se
+	p = 2 * (1 - studentTCDF(math.Abs(t), df))
+	margin := tQuantile(1-(1-confidence)/2, df) * se
+	return diff, ptr(diff - margin), ptr(diff + margin), p
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+// studentTCDF Student t 分布的累积分布函数
+func studentTCDF(t, df float64) float64 {
+	x := df / (df + t*t)
+	tail := 0.5 * regIncBeta(df/2, 0.5, x)
+	if t >= 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// tQuantile t 分布分位数，二分求解
+func tQuantile(p, df float64) float64 {
+	lo, hi := -1000.0, 1000.0
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if studentTCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regIncBeta 正则化不完全 Beta 函数 I_x(a, b)，使用连分式展开 (Numerical Recipes betacf)
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaCF(a, b, x) / a
+	}
+	return 1 - front*betaCF(b, a, 1-x)/b
+}
+
+func betaCF(a, b, x float64) float64 {
+	const (
+		maxIter = 200
+		eps     = 3e-14
+		tiny    = 1e-300
+	)
+	qab, qap, qam := a+b, a+1, a-1
+	c, d := 1.0, 1-qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,148 @@
+package employee
+
+import (
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"BotMatrix/common/types"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// scriptedAIService 按顺序返回预置的 Chat 回复，其余 AIService 方法不会被调用
+type scriptedAIService struct {
+	types.AIService
+	mu      sync.Mutex
+	replies []string
+	calls   int
+}
+
+func (m *scriptedAIService) Chat(ctx context.Context, modelID uint, messages []types.Message, tools []types.Tool) (*types.ChatResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if len(m.replies) == 0 {
+		return nil, errors.New("unexpected Chat call")
+	}
+	reply := m.replies[0]
+	if len(m.replies) > 1 {
+		m.replies = m.replies[1:]
+	}
+	return &types.ChatResponse{
+		Choices: []types.Choice{{Message: types.Message{Role: types.RoleAssistant, Content: reply}}},
+	}, nil
+}
+
+func (m *scriptedAIService) push(replies ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replies = append(m.replies, replies...)
+}
+
+func newTaskTestDB(t *testing.T) *gorm.DB {
+	clog.InitDefaultLogger()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.DigitalEmployee{}, &models.DigitalEmployeeTask{}, &models.AIModel{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestTaskEngineFlow(t *testing.T) {
+	db := newTaskTestDB(t)
+
+	// 模拟 AI 服务，依次返回计划与各步骤的执行结果
+	mockAI := &scriptedAIService{}
+	taskSvc := NewDigitalEmployeeTaskService(db, nil)
+	taskSvc.SetAIService(mockAI)
+
+	ctx := context.Background()
+	executionID := "test-exec-001"
+
+	// --- 阶段 1: 创建任务 ---
+	task := &models.DigitalEmployeeTask{
+		ExecutionID: executionID,
+		Title:       "分析财务报表",
+		Description: "分析 2025 年 Q4 财报，找出支出异常项。",
+	}
+	if err := taskSvc.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	// --- 阶段 2: 任务规划 ---
+	mockAI.push(`{"steps": [
+		{"index": 1, "title": "读取数据", "description": "从数据库读取 Q4 支出数据"},
+		{"index": 2, "title": "对比分析", "description": "对比 Q3 数据，标记增长超过 20% 的项", "requires_approval": true},
+		{"index": 3, "title": "生成报告", "description": "汇总异常项并给出建议"}
+	]}`)
+	if err := taskSvc.PlanTask(ctx, executionID); err != nil {
+		t.Fatalf("PlanTask failed: %v", err)
+	}
+
+	updated, _ := taskSvc.GetTaskByExecutionID(ctx, executionID)
+	if updated.Status != "executing" || !strings.Contains(updated.PlanRaw, "读取数据") {
+		t.Fatalf("unexpected task after planning: status=%s plan=%s", updated.Status, updated.PlanRaw)
+	}
+
+	// --- 阶段 3: 执行到需要审批的步骤 2 时暂停 ---
+	mockAI.replies = nil
+	mockAI.push("已读取数据，共 100 条支出记录。")
+	if err := taskSvc.ExecuteTask(ctx, executionID); err != nil {
+		t.Fatalf("ExecuteTask failed: %v", err)
+	}
+
+	updated, _ = taskSvc.GetTaskByExecutionID(ctx, executionID)
+	if updated.Status != "pending_approval" || updated.CurrentStepIndex != 1 {
+		t.Fatalf("expected pause before step 2, got status=%s step=%d", updated.Status, updated.CurrentStepIndex)
+	}
+	if !strings.Contains(updated.ResultRaw, "已读取数据") {
+		t.Errorf("step 1 result not recorded: %s", updated.ResultRaw)
+	}
+
+	// --- 阶段 4: 审批并继续 ---
+	if err := taskSvc.ApproveTask(ctx, executionID); err != nil {
+		t.Fatalf("ApproveTask failed: %v", err)
+	}
+	mockAI.replies = nil
+	mockAI.push("发现差旅费增长 35%，属于异常。", "报告已生成：建议加强差旅合规检查。")
+	if err := taskSvc.ExecuteTask(ctx, executionID); err != nil {
+		t.Fatalf("ExecuteTask after approval failed: %v", err)
+	}
+
+	updated, _ = taskSvc.GetTaskByExecutionID(ctx, executionID)
+	if updated.Status != "completed" || updated.Progress != 100 {
+		t.Fatalf("expected completed task, got status=%s progress=%d", updated.Status, updated.Progress)
+	}
+	for _, want := range []string{"已读取数据", "差旅费增长 35%", "建议加强差旅合规检查"} {
+		if !strings.Contains(updated.ResultRaw, want) {
+			t.Errorf("result missing %q: %s", want, updated.ResultRaw)
+		}
+	}
+	if mockAI.calls != 4 {
+		t.Errorf("expected 4 AI calls (plan + 3 steps), got %d", mockAI.calls)
+	}
+}
+
+func TestPlanTaskRequiresAIService(t *testing.T) {
+	db := newTaskTestDB(t)
+	taskSvc := NewDigitalEmployeeTaskService(db, nil)
+	ctx := context.Background()
+
+	taskSvc.CreateTask(ctx, &models.DigitalEmployeeTask{ExecutionID: "exec-no-ai", Title: "t"})
+	if err := taskSvc.PlanTask(ctx, "exec-no-ai"); err == nil {
+		t.Fatal("expected an error without an AI service")
+	}
+	if err := taskSvc.ExecuteTask(ctx, "exec-no-ai"); err == nil {
+		t.Fatal("expected an error for a task without a plan")
+	}
+}
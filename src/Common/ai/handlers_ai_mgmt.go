@@ -2,6 +2,7 @@ package ai
 
 import (
 	"BotMatrix/common/models"
+	"BotMatrix/common/secrets"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"context"
@@ -35,11 +36,9 @@ func HandleListAIProviders(m Manager) http.HandlerFunc {
 			utils.SendJSONResponse(w, false, "获取提供商失败: "+err.Error(), nil)
 			return
 		}
-		// 隐藏 API Key
+		// 隐藏 API Key，密钥引用原样返回
 		for i := range providers {
-			if providers[i].APIKey != "" {
-				providers[i].APIKey = "********"
-			}
+			providers[i].APIKey = secrets.RedactValue(providers[i].APIKey)
 		}
 		utils.SendJSONResponse(w, true, "", providers)
 	}
@@ -47,7 +46,7 @@ func HandleListAIProviders(m Manager) http.HandlerFunc {
 
 // HandleSaveAIProvider 保存提供商 (新增或修改)
 // @Summary 保存 AI 提供商
-// @Description 新增或更新 AI 提供商配置。如果 API Key 为 "********" 则保持原值。
+// @Description 新增或更新 AI 提供商配置。如果 API Key 为 "********" 则保持原值；配置了主密钥时新的 API Key 加密保存到密钥存储，记录中只保存 secret:// 引用。
 // @Tags AI Management
 // @Accept json
 // @Produce json
@@ -67,7 +66,7 @@ func HandleSaveAIProvider(m Manager) http.HandlerFunc {
 		var err error
 		if provider.ID > 0 {
 			// 如果是更新，且 APIKey 是 "********"，说明用户没有修改 Key，需要保留原有的 Key
-			if provider.APIKey == "********" {
+			if provider.APIKey == secrets.Mask {
 				var oldProvider models.AIProviderGORM
 				if err := db.First(&oldProvider, provider.ID).Error; err == nil {
 					provider.APIKey = oldProvider.APIKey
@@ -79,15 +78,36 @@ func HandleSaveAIProvider(m Manager) http.HandlerFunc {
 			fmt.Printf("[DEBUG] Creating AI Provider (%s), Key length: %d\n", provider.Name, len(provider.APIKey))
 			err = db.Create(&provider).Error
 		}
+		if err == nil {
+			err = sealAPIKey(r, db, &provider, fmt.Sprintf("ai/provider-%d", provider.ID), &provider.APIKey)
+		}
 
 		if err != nil {
 			utils.SendJSONResponse(w, false, "保存提供商失败: "+err.Error(), nil)
 			return
 		}
+		provider.APIKey = secrets.RedactValue(provider.APIKey)
 		utils.SendJSONResponse(w, true, "保存成功", provider)
 	}
 }
 
+// sealAPIKey 将记录中的明文 API Key 写入密钥存储，并把列改为 secret:// 引用；未配置主密钥时保持明文
+func sealAPIKey(r *http.Request, db *gorm.DB, model any, name string, key *string) error {
+	by := ""
+	if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+		by = claims.Username
+	}
+	ref, err := secrets.Seal(r.Context(), name, *key, by)
+	if err != nil || ref == *key {
+		return err
+	}
+	if err := db.Model(model).Update("ApiKey", ref).Error; err != nil {
+		return err
+	}
+	*key = ref
+	return nil
+}
+
 // HandleDeleteAIProvider 删除提供商
 // @Summary 删除 AI 提供商
 // @Description 根据 ID 删除指定的 AI 提供商配置
@@ -133,13 +153,17 @@ func HandleListAIModels(m Manager) http.HandlerFunc {
 			utils.SendJSONResponse(w, false, "获取模型失败: "+err.Error(), nil)
 			return
 		}
+		for i := range modelsList {
+			modelsList[i].APIKey = secrets.RedactValue(modelsList[i].APIKey)
+			modelsList[i].Provider.APIKey = secrets.RedactValue(modelsList[i].Provider.APIKey)
+		}
 		utils.SendJSONResponse(w, true, "", modelsList)
 	}
 }
 
 // HandleSaveAIModel 保存模型
 // @Summary 保存 AI 模型
-// @Description 新增或更新 AI 模型配置
+// @Description 新增或更新 AI 模型配置。模型级 API Key 为 "********" 时保持原值，新的 API Key 加密保存到密钥存储
 // @Tags AI Management
 // @Accept json
 // @Produce json
@@ -158,15 +182,26 @@ func HandleSaveAIModel(m Manager) http.HandlerFunc {
 		db := m.GetGORMDB()
 		var err error
 		if model.ID > 0 {
+			if model.APIKey == secrets.Mask {
+				var oldModel models.AIModelGORM
+				if err := db.First(&oldModel, model.ID).Error; err == nil {
+					model.APIKey = oldModel.APIKey
+				}
+			}
 			err = db.Save(&model).Error
 		} else {
 			err = db.Create(&model).Error
 		}
+		if err == nil {
+			err = sealAPIKey(r, db, &model, fmt.Sprintf("ai/model-%d", model.ID), &model.APIKey)
+		}
 
 		if err != nil {
 			utils.SendJSONResponse(w, false, "保存模型失败: "+err.Error(), nil)
 			return
 		}
+		model.APIKey = secrets.RedactValue(model.APIKey)
+		model.Provider.APIKey = secrets.RedactValue(model.Provider.APIKey)
 		utils.SendJSONResponse(w, true, "保存成功", model)
 	}
 }
@@ -306,9 +341,8 @@ func HandleGetAIAgent(m Manager) http.HandlerFunc {
 		}
 
 		// 安全处理：隐藏 API Key
-		if agent.Model.Provider.APIKey != "" {
-			agent.Model.Provider.APIKey = "********"
-		}
+		agent.Model.APIKey = secrets.RedactValue(agent.Model.APIKey)
+		agent.Model.Provider.APIKey = secrets.RedactValue(agent.Model.Provider.APIKey)
 
 		utils.SendJSONResponse(w, true, "", agent)
 	}
@@ -420,6 +454,36 @@ func HandleListAIUsageLogs(m Manager) http.HandlerFunc {
 
 // --- AI Trial (SSE) ---
 
+// openChatEvents 打开流式对话事件通道。AI 服务支持流式智能体循环时使用 ChatAgentStream，
+// 否则退化为普通流式对话，仅输出文本增量
+func openChatEvents(ctx context.Context, aiSvc types.AIService, modelID uint, messages []Message) (<-chan types.AgentEvent, error) {
+	if streamer, ok := aiSvc.(types.AgentStreamer); ok {
+		return streamer.ChatAgentStream(ctx, modelID, messages, nil)
+	}
+
+	stream, err := aiSvc.ChatStream(ctx, modelID, messages, nil)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan types.AgentEvent)
+	go func() {
+		defer close(events)
+		var content strings.Builder
+		for resp := range stream {
+			if resp.Error != nil {
+				events <- types.AgentEvent{Type: types.AgentEventError, Error: resp.Error.Error()}
+				return
+			}
+			if len(resp.Choices) > 0 && resp.Choices[0].Delta.Content != "" {
+				content.WriteString(resp.Choices[0].Delta.Content)
+				events <- types.AgentEvent{Type: types.AgentEventDelta, Content: resp.Choices[0].Delta.Content}
+			}
+		}
+		events <- types.AgentEvent{Type: types.AgentEventDone, Content: content.String()}
+	}()
+	return events, nil
+}
+
 // HandleAIChatStream 处理网页端流式试用
 // @Summary AI 试用对话 (流式)
 // @Description 在管理后台直接与指定的 AI 智能体对话，支持 SSE 流式返回
@@ -650,8 +714,10 @@ func HandleAIChatStream(m Manager) http.HandlerFunc {
 			return
 		}
 
-		// 启动流式对话
-		stream, err := aiSvc.ChatStream(r.Context(), modelID, fullMessages, nil)
+		// 启动流式对话 (支持智能体循环时实时推送工具调用过程)
+		streamCtx := context.WithValue(r.Context(), "sessionID", sessionID)
+		streamCtx = context.WithValue(streamCtx, "userIDNum", userID)
+		events, err := openChatEvents(streamCtx, aiSvc, modelID, fullMessages)
 		if err != nil {
 			fmt.Printf("[DEBUG] ChatStream error: %v\n", err)
 			utils.SendJSONResponse(w, false, "启动流式对话失败: "+err.Error(), nil)
@@ -673,6 +739,7 @@ func HandleAIChatStream(m Manager) http.HandlerFunc {
 
 		fmt.Println("[DEBUG] Starting stream loop")
 		var assistantContent string
+		var usage *UsageInfo
 
 		// 立即发送 session_id 给前端，确保即使 AI 响应延迟，前端也能锁定会话
 		metaData, _ := json.Marshal(map[string]string{
@@ -681,33 +748,31 @@ func HandleAIChatStream(m Manager) http.HandlerFunc {
 		fmt.Fprintf(w, "data: %s\n\n", string(metaData))
 		flusher.Flush()
 
-		// 循环发送流式数据
-		for resp := range stream {
-			if resp.Error != nil {
-				errStr := resp.Error.Error()
+		// 循环发送流式事件：文本增量保持 {"content": ...} 格式，工具/推理等事件原样下发
+		for event := range events {
+			switch event.Type {
+			case types.AgentEventDelta:
+				if event.Content == "" {
+					continue
+				}
+				assistantContent += event.Content
+			case types.AgentEventTrace:
+				// 工具调用前的中间推理不计入最终回复
+				assistantContent = strings.TrimSuffix(assistantContent, event.Content)
+			case types.AgentEventDone:
+				usage = event.Usage
+			case types.AgentEventError:
 				// 如果错误信息包含 HTML 标签，说明可能是被防火墙拦截或配置了错误的 URL
-				if strings.Contains(errStr, "<html") || strings.Contains(errStr, "<!DOCTYPE") {
+				if strings.Contains(event.Error, "<html") || strings.Contains(event.Error, "<!DOCTYPE") {
 					fmt.Printf("[DEBUG] Stream error: Received HTML response instead of JSON. Check your Provider BaseURL.\n")
-					fmt.Fprintf(w, "data: %s\n\n", "Error: 接口返回了 HTML 页面而非 JSON，请检查 AI 提供商的 BaseURL 配置是否正确（应为 API 地址而非网页地址）。")
+					event.Error = "接口返回了 HTML 页面而非 JSON，请检查 AI 提供商的 BaseURL 配置是否正确（应为 API 地址而非网页地址）。"
 				} else {
-					fmt.Printf("[DEBUG] Stream error: %v\n", resp.Error)
-					fmt.Fprintf(w, "data: %s\n\n", "Error: "+errStr)
-				}
-				flusher.Flush()
-				break
-			}
-
-			if len(resp.Choices) > 0 {
-				choice := resp.Choices[0]
-				if choice.Delta.Content != "" {
-					assistantContent += choice.Delta.Content
-					data, _ := json.Marshal(map[string]string{
-						"content": choice.Delta.Content,
-					})
-					fmt.Fprintf(w, "data: %s\n\n", string(data))
-					flusher.Flush()
+					fmt.Printf("[DEBUG] Stream error: %v\n", event.Error)
 				}
 			}
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "data: %s\n\n", string(data))
+			flusher.Flush()
 		}
 
 		fmt.Println("[DEBUG] Stream loop finished")
@@ -731,9 +796,13 @@ func HandleAIChatStream(m Manager) http.HandlerFunc {
 				"last_msg": assistantContent,
 			})
 
-			// 记录使用日志和计算收益扣除
+			// 记录使用日志和计算收益扣除 (模型未返回用量时粗略估算)
 			inputTokens := totalTokens
-			outputTokens := int(float64(len(assistantContent)) * 1.2) // 粗略估算
+			outputTokens := int(float64(len(assistantContent)) * 1.2)
+			if usage != nil && usage.TotalTokens > 0 {
+				inputTokens = usage.PromptTokens
+				outputTokens = usage.CompletionTokens
+			}
 
 			revenueDeducted := 0
 			if agent.RevenueRate > 0 && userID > 0 && userID != agent.OwnerID {
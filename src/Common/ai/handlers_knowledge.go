@@ -2,6 +2,7 @@ package ai
 
 import (
 	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/ai/rag/ingest"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"context"
@@ -99,6 +100,38 @@ func HandleKnowledgeUpload(m Manager) http.HandlerFunc {
 		}
 
 		filename := header.Filename
+		// source 使用 uuid 确保唯一性，title 使用原始文件名
+		source := "upload://" + uuid.New().String() + "/" + filename
+
+		// 同时提供了多个 ID 时都进行绑定
+		var access []rag.AccessGrant
+		if botID != "" && (targetType != "bot" || targetID != botID) {
+			access = append(access, rag.AccessGrant{OwnerType: "bot", OwnerID: botID})
+		}
+		if groupID != "" && (targetType != "group" || targetID != groupID) {
+			access = append(access, rag.AccessGrant{OwnerType: "group", OwnerID: groupID})
+		}
+
+		// 配置了入库队列时持久化任务，可查询进度并在失败后重试
+		if ingestor := kb.Ingestor(); ingestor != nil {
+			jobID, err := ingestor.Enqueue(r.Context(), rag.IngestRequest{
+				Title:      filename,
+				Source:     source,
+				Content:    content,
+				DocType:    docType,
+				UploaderID: uploaderID,
+				TargetType: targetType,
+				TargetID:   targetID,
+				Access:     access,
+			})
+			if err != nil {
+				utils.SendJSONResponse(w, false, "创建入库任务失败: "+err.Error(), nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "文件已接收，后台正在进行解析与向量化处理", map[string]any{"job_id": jobID})
+			return
+		}
+
 		indexer := rag.NewIndexer(kb, m.GetAIService(), 0)
 
 		// 异步执行索引任务，避免前端超时
@@ -106,25 +139,13 @@ func HandleKnowledgeUpload(m Manager) http.HandlerFunc {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 			defer cancel()
 
-			// source 使用 uuid 确保唯一性，title 使用原始文件名
-			source := "upload://" + uuid.New().String() + "/" + filename
-			err := indexer.IndexContent(ctx, filename, source, content, docType, uploaderID, targetType, targetID)
+			docID, err := indexer.IndexContentWithProgress(ctx, filename, source, content, docType, uploaderID, targetType, targetID, nil)
 			if err != nil {
 				fmt.Printf("[Knowledge] Index failed: %v\n", err)
 				return
 			}
-
-			// --- 优化：如果同时提供了多个 ID，则都进行绑定 ---
-			// 先找到刚刚创建的文档 ID (IndexContent 内部没有返回，我们需要查询)
-			var doc rag.KnowledgeDoc
-			db := m.GetGORMDB()
-			if err := db.Where("source = ?", source).First(&doc).Error; err == nil {
-				if botID != "" && (targetType != "bot" || targetID != botID) {
-					kb.AddDocAccess(ctx, doc.ID, "bot", botID)
-				}
-				if groupID != "" && (targetType != "group" || targetID != groupID) {
-					kb.AddDocAccess(ctx, doc.ID, "group", groupID)
-				}
+			for _, g := range access {
+				kb.AddDocAccess(ctx, docID, g.OwnerType, g.OwnerID)
 			}
 
 			fmt.Printf("[Knowledge] Indexed file: %s\n", filename)
@@ -223,6 +244,9 @@ func HandleKnowledgeDelete(m Manager) http.HandlerFunc {
 			return
 		}
 
+		var chunkIDs []uint
+		db.Model(&rag.KnowledgeChunk{}).Where("doc_id = ?", id).Pluck("id", &chunkIDs)
+
 		// 开启事务删除
 		err := db.Transaction(func(tx *gorm.DB) error {
 			// 1. 删除切片
@@ -247,6 +271,11 @@ func HandleKnowledgeDelete(m Manager) http.HandlerFunc {
 			return
 		}
 
+		// 同步移除向量索引中的切片
+		if kb, ok := m.GetKnowledgeBase().(*rag.PostgresKnowledgeBase); ok {
+			kb.DeleteVectors(r.Context(), chunkIDs)
+		}
+
 		utils.SendJSONResponse(w, true, "删除成功", nil)
 	}
 }
@@ -288,3 +317,101 @@ func HandleKnowledgeDetail(m Manager) http.HandlerFunc {
 		})
 	}
 }
+
+// ingestQueue 返回知识库的入库队列，未启用时为 nil
+func ingestQueue(m Manager) *ingest.Queue {
+	kb, ok := m.GetKnowledgeBase().(*rag.PostgresKnowledgeBase)
+	if !ok {
+		return nil
+	}
+	q, _ := kb.Ingestor().(*ingest.Queue)
+	return q
+}
+
+// HandleKnowledgeJobs 查询入库任务
+// @Summary 查询入库任务
+// @Description 查询知识入库任务的状态与进度，普通用户只能看到自己上传的任务；指定 id 时返回单个任务
+// @Tags Knowledge
+// @Produce json
+// @Security BearerAuth
+// @Param id query int false "任务 ID"
+// @Param status query string false "任务状态 (pending, running, succeeded, failed)"
+// @Param limit query int false "返回数量，默认 50"
+// @Success 200 {object} utils.JSONResponse "任务列表"
+// @Router /api/knowledge/jobs [get]
+func HandleKnowledgeJobs(m Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := ingestQueue(m)
+		if q == nil {
+			utils.SendJSONResponse(w, false, "入库队列未启用", nil)
+			return
+		}
+
+		claims, _ := r.Context().Value(types.UserClaimsKey).(*types.UserClaims)
+		userID := ""
+		isAdmin := false
+		if claims != nil {
+			userID = fmt.Sprintf("%d", claims.UserID)
+			isAdmin = claims.IsAdmin
+		}
+
+		if id, _ := strconv.Atoi(r.URL.Query().Get("id")); id > 0 {
+			job, err := q.Get(r.Context(), uint(id))
+			if err != nil || (!isAdmin && job.UploaderID != userID) {
+				utils.SendJSONResponse(w, false, "未找到任务", nil)
+				return
+			}
+			utils.SendJSONResponse(w, true, "", job)
+			return
+		}
+
+		filter := ingest.ListFilter{Status: r.URL.Query().Get("status")}
+		filter.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+		if !isAdmin {
+			filter.UploaderID = userID
+		}
+		jobs, err := q.List(r.Context(), filter)
+		if err != nil {
+			utils.SendJSONResponse(w, false, "获取任务失败: "+err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", jobs)
+	}
+}
+
+// HandleKnowledgeJobRetry 重试失败的入库任务
+// @Summary 重试入库任务
+// @Description 将失败的入库任务重新排队，只有上传者或管理员可以重试
+// @Tags Knowledge
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务 ID"
+// @Success 200 {object} utils.JSONResponse "已重新排队"
+// @Router /api/knowledge/jobs/retry/{id} [post]
+func HandleKnowledgeJobRetry(m Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := ingestQueue(m)
+		if q == nil {
+			utils.SendJSONResponse(w, false, "入库队列未启用", nil)
+			return
+		}
+		idStr := strings.TrimPrefix(r.URL.Path, "/api/knowledge/jobs/retry/")
+		id, _ := strconv.Atoi(idStr)
+		if id == 0 {
+			utils.SendJSONResponse(w, false, "无效的 ID", nil)
+			return
+		}
+
+		claims, _ := r.Context().Value(types.UserClaimsKey).(*types.UserClaims)
+		job, err := q.Get(r.Context(), uint(id))
+		if err != nil || claims == nil || (!claims.IsAdmin && job.UploaderID != fmt.Sprintf("%d", claims.UserID)) {
+			utils.SendJSONResponse(w, false, "未找到任务", nil)
+			return
+		}
+		if err := q.Retry(r.Context(), job.ID); err != nil {
+			utils.SendJSONResponse(w, false, "重试失败: "+err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "任务已重新排队", nil)
+	}
+}
@@ -509,11 +509,20 @@ func (h *KnowledgeMCPHost) CallTool(ctx context.Context, serverID string, toolNa
 
 	var results []string
 	for _, c := range chunks {
-		results = append(results, fmt.Sprintf("Source: %s\nContent: %s", c.Source, c.Content))
+		if c.Citation != nil {
+			results = append(results, fmt.Sprintf("[%d] Source: %s\nContent: %s", c.Citation.Index, c.Source, c.Content))
+		} else {
+			results = append(results, fmt.Sprintf("Source: %s\nContent: %s", c.Source, c.Content))
+		}
+	}
+
+	text := "Found relevant information:\n\n" + strings.Join(results, "\n\n---\n\n")
+	if footnotes := types.CitationFootnotes(chunks, ""); footnotes != "" {
+		text += "\n\nCite sources with their [n] markers. References:\n" + footnotes
 	}
 
 	return types.MCPCallToolResponse{
-		Content: []types.MCPContent{{Type: "text", Text: "Found relevant information:\n\n" + strings.Join(results, "\n\n---\n\n")}},
+		Content: []types.MCPContent{{Type: "text", Text: text}},
 	}, nil
 }
 
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"BotMatrix/common/models"
+	"BotMatrix/common/types"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeManager 只实现测试用到的 types.Manager 方法
+type fakeManager struct {
+	types.Manager
+	db    *gorm.DB
+	aiSvc types.AIService
+	mcp   types.MCPManagerInterface
+}
+
+func (m *fakeManager) GetGORMDB() *gorm.DB                      { return m.db }
+func (m *fakeManager) GetAIService() types.AIService            { return m.aiSvc }
+func (m *fakeManager) GetKnowledgeBase() types.KnowledgeBase    { return nil }
+func (m *fakeManager) GetMCPManager() types.MCPManagerInterface { return m.mcp }
+func (m *fakeManager) GetCognitiveMemoryService() types.CognitiveMemoryService {
+	return nil
+}
+
+// fakeEmployeeChat 记录转交给数字员工的消息
+type fakeEmployeeChat struct {
+	types.AIService
+	LastMsg types.InternalMessage
+}
+
+func (m *fakeEmployeeChat) ChatWithEmployee(employee *models.DigitalEmployeeGORM, msg types.InternalMessage, targetOrgID uint) (string, error) {
+	m.LastMsg = msg
+	return fmt.Sprintf("Hello from %s", employee.Name), nil
+}
+
+func TestAgentCollaborationMCPHost(t *testing.T) {
+	// 1. 设置内存数据库
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	db.AutoMigrate(&models.DigitalEmployeeGORM{}, &models.Execution{})
+
+	// 2. 插入测试数据 (另一家企业的员工不可见)
+	emp := models.DigitalEmployeeGORM{
+		EmployeeID:   "EMP001",
+		Name:         "Collaborator",
+		EnterpriseID: 1,
+		BotID:        "bot123",
+	}
+	db.Create(&emp)
+	db.Create(&models.DigitalEmployeeGORM{EmployeeID: "EMP999", Name: "Outsider", EnterpriseID: 2, BotID: "bot999"})
+
+	// 3. 模拟 Manager 和 AI Service
+	mockAI := &fakeEmployeeChat{}
+	host := NewAgentCollaborationMCPHost(NewCollaborationProviderImpl(&fakeManager{db: db, aiSvc: mockAI}))
+
+	text := func(t *testing.T, resp any) string {
+		t.Helper()
+		mcpResp, ok := resp.(types.MCPCallToolResponse)
+		if !ok || len(mcpResp.Content) == 0 {
+			t.Fatalf("expected content in response, got %#v", resp)
+		}
+		return mcpResp.Content[0].Text
+	}
+
+	t.Run("colleague_list", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "orgIDNum", uint(1))
+		resp, err := host.CallTool(ctx, "collaboration", "colleague_list", nil)
+		if err != nil {
+			t.Fatalf("colleague_list failed: %v", err)
+		}
+		got := text(t, resp)
+		if !strings.Contains(got, "Collaborator") || strings.Contains(got, "Outsider") {
+			t.Errorf("expected only colleagues of the same enterprise, got: %s", got)
+		}
+	})
+
+	t.Run("colleague_consult", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "orgIDNum", uint(1))
+		ctx = context.WithValue(ctx, "sessionID", "parent-session")
+		args := map[string]any{
+			"target_employee_id": "EMP001",
+			"question":           "How are you?",
+		}
+		resp, err := host.CallTool(ctx, "collaboration", "colleague_consult", args)
+		if err != nil {
+			t.Fatalf("colleague_consult failed: %v", err)
+		}
+		if got := text(t, resp); !strings.Contains(got, "Hello from Collaborator") {
+			t.Errorf("unexpected response: %s", got)
+		}
+
+		// 检查问题内容与父 session ID 传递
+		if mockAI.LastMsg.RawMessage != "How are you?" {
+			t.Errorf("unexpected question: %q", mockAI.LastMsg.RawMessage)
+		}
+		if mockAI.LastMsg.Extras["parentSessionID"] != "parent-session" {
+			t.Errorf("expected parentSessionID to be 'parent-session', got: %v", mockAI.LastMsg.Extras["parentSessionID"])
+		}
+	})
+
+	t.Run("colleague_consult_other_enterprise", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "orgIDNum", uint(1))
+		args := map[string]any{"target_employee_id": "EMP999", "question": "hi"}
+		if _, err := host.CallTool(ctx, "collaboration", "colleague_consult", args); err == nil {
+			t.Error("expected employees of another enterprise to be unreachable")
+		}
+	})
+
+	t.Run("task_report_and_status", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "orgIDNum", uint(1))
+		db.Create(&models.Execution{ExecutionID: "exec_1", Status: models.ExecRunning})
+
+		if _, err := host.CallTool(ctx, "collaboration", "task_report", map[string]any{
+			"execution_id": "exec_1", "status": "success", "result": "done",
+		}); err != nil {
+			t.Fatalf("task_report failed: %v", err)
+		}
+		resp, err := host.CallTool(ctx, "collaboration", "task_status", map[string]any{"execution_id": "exec_1"})
+		if err != nil {
+			t.Fatalf("task_status failed: %v", err)
+		}
+		if got := text(t, resp); !strings.Contains(got, "success") || !strings.Contains(got, "done") {
+			t.Errorf("unexpected status: %s", got)
+		}
+	})
+
+	t.Run("missing_org", func(t *testing.T) {
+		if _, err := host.CallTool(context.Background(), "collaboration", "colleague_list", nil); err == nil {
+			t.Error("expected an error without an organization in context")
+		}
+	})
+}
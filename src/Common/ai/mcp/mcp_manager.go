@@ -1,13 +1,21 @@
 package mcp
 
 import (
+	clog "BotMatrix/common/log"
 	"BotMatrix/common/models"
+	"BotMatrix/common/secrets"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // InternalSkillProviderImpl 实现了 InternalSkillProvider 接口
@@ -60,7 +68,7 @@ func NewCollaborationProviderImpl(m types.Manager) *CollaborationProviderImpl {
 
 func (p *CollaborationProviderImpl) GetEmployeesByOrg(ctx context.Context, orgID uint) ([]models.DigitalEmployeeGORM, error) {
 	var employees []models.DigitalEmployeeGORM
-	if err := p.m.GetGORMDB().WithContext(ctx).Where("enterprise_id = ?", orgID).Find(&employees).Error; err != nil {
+	if err := p.m.GetGORMDB().WithContext(ctx).Where(clause.Eq{Column: clause.Column{Name: "EnterpriseId"}, Value: orgID}).Find(&employees).Error; err != nil {
 		return nil, err
 	}
 	return employees, nil
@@ -68,15 +76,20 @@ func (p *CollaborationProviderImpl) GetEmployeesByOrg(ctx context.Context, orgID
 
 func (p *CollaborationProviderImpl) GetEmployeeByID(ctx context.Context, orgID uint, employeeID string) (*models.DigitalEmployeeGORM, error) {
 	var targetEmp models.DigitalEmployeeGORM
-	if err := p.m.GetGORMDB().WithContext(ctx).Where("enterprise_id = ? AND employee_id = ?", orgID, employeeID).First(&targetEmp).Error; err != nil {
+	if err := p.m.GetGORMDB().WithContext(ctx).
+		Where(clause.Eq{Column: clause.Column{Name: "EnterpriseId"}, Value: orgID}).
+		Where(clause.Eq{Column: clause.Column{Name: "EmployeeId"}, Value: employeeID}).
+		First(&targetEmp).Error; err != nil {
 		return nil, fmt.Errorf("未找到工号为 %s 的同事", employeeID)
 	}
 	return &targetEmp, nil
 }
 
 func (p *CollaborationProviderImpl) ChatWithEmployee(ctx context.Context, employee *models.DigitalEmployeeGORM, message *types.Message, orgID uint) (string, error) {
+	// Extras 携带 parentSessionID / executionID，用于关联协作链路与委派任务
 	return p.m.GetAIService().ChatWithEmployee(employee, types.InternalMessage{
 		RawMessage: fmt.Sprintf("%v", message.Content),
+		Extras:     message.Extras,
 	}, orgID)
 }
 
@@ -118,6 +131,7 @@ func (p *CollaborationProviderImpl) NewInternalMessage(msgType, category, botID,
 	return &types.Message{
 		Role:    types.Role(role),
 		Content: content,
+		Extras:  map[string]any{},
 	}
 }
 
@@ -227,7 +241,12 @@ func (m *MCPManager) LoadFromDB() error {
 		switch cfg.Type {
 		case "webhook":
 			// Webhook 类型直接使用 Endpoint
-			host = types.NewGenericWebhookMCPHost(cfg.Endpoint, cfg.APIKey, nil)
+			apiKey, err := secrets.Resolve(context.Background(), cfg.APIKey)
+			if err != nil {
+				clog.Warn("[MCP] 解析服务 API Key 失败", zap.String("server", cfg.Name), zap.Error(err))
+				continue
+			}
+			host = types.NewGenericWebhookMCPHost(cfg.Endpoint, apiKey, nil)
 		case "internal":
 			host = NewInternalSkillMCPHost(NewInternalSkillProviderImpl(m.manager))
 		}
@@ -244,9 +263,18 @@ func (m *MCPManager) LoadFromDB() error {
 	return nil
 }
 
-// CallTool 包装了 types.MCPManager.CallTool，可以根据需要增加逻辑
+// CallTool 包装了 types.MCPManager.CallTool，并为每次调用记录追踪 span (名称为 服务__工具)
 func (m *MCPManager) CallTool(ctx context.Context, fullName string, args map[string]any) (any, error) {
-	return m.MCPManager.CallTool(ctx, fullName, args)
+	server, tool, _ := strings.Cut(fullName, "__")
+	ctx, span := tracing.Start(ctx, "mcp.call_tool "+fullName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("mcp.server", server),
+			attribute.String("mcp.tool", tool),
+		))
+	res, err := m.MCPManager.CallTool(ctx, fullName, args)
+	tracing.End(span, err)
+	return res, err
 }
 
 // GetToolsForContext 包装了 types.MCPManager.GetToolsForContext
@@ -3,6 +3,7 @@ package mcp
 import (
 	"BotMatrix/common/ai"
 	"context"
+	"strings"
 	"testing"
 )
 
@@ -48,7 +49,7 @@ func (m *MockMCPHost) GetPrompt(ctx context.Context, serverID string, promptName
 }
 
 func TestMCPManager(t *testing.T) {
-	m := NewMCPManager(nil, &Manager{})
+	m := NewMCPManager(&fakeManager{})
 	m.RegisterServer(ai.MCPServerInfo{
 		ID:    "weather_service",
 		Name:  "Weather Service",
@@ -56,10 +57,12 @@ func TestMCPManager(t *testing.T) {
 	}, &MockMCPHost{})
 
 	ctx := context.Background()
-	tools, err := m.GetToolsForContext(ctx, 0, 0)
+	all, err := m.GetToolsForContext(ctx, 0, 0)
 	if err != nil {
 		t.Fatalf("Failed to get tools: %v", err)
 	}
+	// 内置服务 (推理、知识库、协作等) 的工具不在本测试范围内
+	tools := registeredTools(all)
 
 	if len(tools) != 1 {
 		t.Errorf("Expected 1 tool, got %d", len(tools))
@@ -88,14 +91,25 @@ func TestMCPManager(t *testing.T) {
 	}, &MockMCPHost{})
 
 	// 匿名上下文应找不到私有工具
-	tools, _ = m.GetToolsForContext(ctx, 0, 0)
-	if len(tools) != 1 {
+	all, _ = m.GetToolsForContext(ctx, 0, 0)
+	if tools = registeredTools(all); len(tools) != 1 {
 		t.Errorf("Anonymous user should only see 1 tool, got %d", len(tools))
 	}
 
 	// 指定用户 ID 应看到私有工具
-	tools, _ = m.GetToolsForContext(ctx, 123, 0)
-	if len(tools) != 2 {
+	all, _ = m.GetToolsForContext(ctx, 123, 0)
+	if tools = registeredTools(all); len(tools) != 2 {
 		t.Errorf("User 123 should see 2 tools, got %d", len(tools))
 	}
 }
+
+// registeredTools 只保留本测试注册的 weather_service 与 private_service 的工具
+func registeredTools(tools []ai.Tool) []ai.Tool {
+	var out []ai.Tool
+	for _, tool := range tools {
+		if strings.HasPrefix(tool.Function.Name, "weather_service__") || strings.HasPrefix(tool.Function.Name, "private_service__") {
+			out = append(out, tool)
+		}
+	}
+	return out
+}
@@ -11,7 +11,7 @@ import (
 
 func TestMCPHandlers(t *testing.T) {
 	// 模拟 Manager
-	m := &Manager{}
+	m := &fakeManager{}
 
 	t.Run("HandleMCPListTools", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/api/mcp/v1/tools", nil)
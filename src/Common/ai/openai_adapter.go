@@ -120,6 +120,16 @@ func (a *OpenAIAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan
 		defer resp.Body.Close()
 		defer close(ch)
 
+		// 消费方放弃读取 (ctx 取消) 时立即退出，避免 goroutine 阻塞在发送上
+		send := func(r ChatStreamResponse) bool {
+			select {
+			case ch <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
 		scanner := bufio.NewScanner(resp.Body)
 		// 增加缓冲区大小以防单行数据过长 (默认 64K 可能不够)
 		buf := make([]byte, 0, 64*1024)
@@ -139,13 +149,15 @@ func (a *OpenAIAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan
 
 			var streamResp ChatStreamResponse
 			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
-				ch <- ChatStreamResponse{Error: err}
+				send(ChatStreamResponse{Error: err})
+				return
+			}
+			if !send(streamResp) {
 				return
 			}
-			ch <- streamResp
 		}
 		if err := scanner.Err(); err != nil {
-			ch <- ChatStreamResponse{Error: err}
+			send(ChatStreamResponse{Error: err})
 		}
 	}()
 
@@ -2,21 +2,19 @@ package ai
 
 import (
 	"BotMatrix/common/models"
-	"BotNexus/tasks"
+	"BotMatrix/common/types"
 	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
-
-	"github.com/glebarez/sqlite"
-	"gorm.io/gorm"
+	"time"
 )
 
 // MockAIServiceForPrivateChat 模拟私聊场景下的 AI 服务
 type MockAIServiceForPrivateChat struct {
+	types.AIService
 	LastSystemPrompt string
-	ReturnCrossGroup bool
 }
 
 func (m *MockAIServiceForPrivateChat) Chat(ctx context.Context, modelID uint, messages []Message, tools []Tool) (*ChatResponse, error) {
@@ -24,176 +22,164 @@ func (m *MockAIServiceForPrivateChat) Chat(ctx context.Context, modelID uint, me
 
 	// 如果用户输入包含“取消”，返回取消意图
 	userInput, _ := messages[len(messages)-1].Content.(string)
-	if strings.Contains(userInput, "取消") {
-		return &ChatResponse{
-			Choices: []Choice{
-				{
-					Message: Message{
-						Role: RoleAssistant,
-						ToolCalls: []ToolCall{
-							{
-								ID:   "call_cancel",
-								Type: "function",
-								Function: FunctionCall{
-									Name:      "cancel_task",
-									Arguments: "{\"task_id\":\"123\"}",
-								},
-							},
-						},
-					},
-				},
-			},
-		}, nil
-	}
-
-	groupID := ""
-	if m.ReturnCrossGroup {
-		groupID = "999"
-	}
-
-	// 模拟 AI 解析：用户说“报时”，AI 自动补全了 group_id
-	taskData := map[string]any{
-		"name":           "报时任务",
-		"type":           "cron",
-		"action_type":    "send_message",
-		"action_params":  fmt.Sprintf("{\"group_id\":\"%s\",\"message\":\"报时啦\"}", groupID),
-		"trigger_config": "{\"cron\":\"0 * * * *\"}",
-	}
-	if groupID == "" {
-		taskData["action_params"] = "{\"message\":\"报时啦\"}"
+	call := FunctionCall{Name: "cancel_task", Arguments: "{\"task_id\":\"123\"}"}
+	if !strings.Contains(userInput, "取消") {
+		// 模拟 AI 解析：用户说“报时”，AI 按运行环境补全 group_id
+		groupID := ""
+		if idx := strings.Index(m.LastSystemPrompt, "目标群组 ID: "); idx >= 0 {
+			groupID = strings.Fields(m.LastSystemPrompt[idx+len("目标群组 ID: "):])[0]
+		}
+		taskData := map[string]any{
+			"name":           "报时任务",
+			"type":           "cron",
+			"action_type":    "send_message",
+			"action_params":  fmt.Sprintf("{\"group_id\":\"%s\",\"message\":\"报时啦\"}", groupID),
+			"trigger_config": "{\"cron\":\"0 * * * *\"}",
+		}
+		taskJSON, _ := json.Marshal(taskData)
+		call = FunctionCall{Name: "create_task", Arguments: string(taskJSON)}
 	}
-	taskJSON, _ := json.Marshal(taskData)
 
 	return &ChatResponse{
 		Choices: []Choice{
 			{
 				Message: Message{
-					Role: RoleAssistant,
-					ToolCalls: []ToolCall{
-						{
-							ID:   "call_private",
-							Type: "function",
-							Function: FunctionCall{
-								Name:      "create_task",
-								Arguments: string(taskJSON),
-							},
-						},
-					},
+					Role:      RoleAssistant,
+					ToolCalls: []ToolCall{{ID: "call_private", Type: "function", Function: call}},
 				},
 			},
 		},
 	}, nil
 }
 
-func (m *MockAIServiceForPrivateChat) CreateEmbedding(ctx context.Context, modelID uint, input any) (*EmbeddingResponse, error) {
-	return &EmbeddingResponse{}, nil
-}
-
-func (m *MockAIServiceForPrivateChat) ChatStream(ctx context.Context, modelID uint, messages []Message, tools []Tool) (<-chan ChatStreamResponse, error) {
-	return nil, nil
-}
-
-func (m *MockAIServiceForPrivateChat) ChatAgent(ctx context.Context, modelID uint, messages []Message, tools []Tool) (*ChatResponse, error) {
-	return m.Chat(ctx, modelID, messages, tools)
-}
-
 // MockExecutor 模拟执行器
 type MockExecutor struct {
-	LastExecutedDraft *tasks.AIDraft
+	LastExecutedDraft *models.AIDraft
 }
 
-func (m *MockExecutor) ExecuteAIDraft(draft *tasks.AIDraft) error {
+func (m *MockExecutor) ExecuteAIDraft(draft *models.AIDraft) error {
 	m.LastExecutedDraft = draft
 	return nil
 }
 
 func TestPrivateChatAndCrossGroup(t *testing.T) {
-	// 1. 初始化内存数据库
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("failed to connect database: %v", err)
-	}
-
-	// 自动迁移
-	db.AutoMigrate(&tasks.Task{}, &tasks.AIDraft{}, &tasks.UserIdentity{}, &models.AIModelGORM{}, &models.AIProviderGORM{})
+	// 1. 初始化内存数据库与任务管理器
+	db, tm, botMgr := newTaskTestManager(t)
 
-	// 预先创建用户身份，否则 setUserDefaultGroup 会失败
-	db.Create(&tasks.UserIdentity{
+	// 预先创建用户身份，否则 setUserDefaultGroup 无处记录
+	db.Create(&models.UserIdentity{
 		NexusUID:    "nexus_123",
 		Platform:    "qq",
 		PlatformUID: "user_123",
 		Nickname:    "test_user",
 	})
 
-	// 2. 初始化 Mock 服务
 	aiSvc := &MockAIServiceForPrivateChat{}
-	botMgr := &MockBotManager{}
 	executor := &MockExecutor{}
-	tm := tasks.NewTaskManager(db, nil, botMgr)
 	tm.SetExecutor(executor)
 	tm.AI.SetAIService(aiSvc)
-	tm.AI.Manifest = tasks.GetDefaultManifest() // 确保有 Manifest，否则 Parse 会失败
 
-	// 3. 场景 A: 用户在群 101 中发过消息，记录默认群组
-	fmt.Println("\n--- [场景 A: 用户在群 101 发起任务] ---")
-	tm.ProcessChatMessage(context.Background(), "bot1", "101", "user_123", "AI 帮我报时")
+	ctx := context.Background()
+
+	// 2. 场景 A: 用户在群 101 中发过消息，记录默认群组
+	tm.ProcessChatMessage(ctx, "bot1", "101", "user_123", "AI 帮我报时")
 
-	// 验证 UserIdentity 是否记录了默认群
-	var identity tasks.UserIdentity
+	var identity models.UserIdentity
 	db.Where("platform_uid = ?", "user_123").First(&identity)
-	fmt.Printf("用户默认群组记录: %s\n", identity.Metadata)
+	var metadata map[string]any
+	json.Unmarshal([]byte(identity.Metadata), &metadata)
+	defaultGroup, _ := metadata["default_group"].(string)
+	if defaultGroup != "101" {
+		t.Fatalf("default group = %q, want 101 (metadata %s)", defaultGroup, identity.Metadata)
+	}
 
-	// 4. 场景 B: 用户在私聊中发起任务
-	fmt.Println("\n--- [场景 B: 用户在私聊发起任务] ---")
-	aiSvc.LastSystemPrompt = ""
-	tm.ProcessChatMessage(context.Background(), "bot1", "", "user_123", "AI 报时")
+	// 私聊消息不会覆盖默认群组
+	tm.ProcessChatMessage(ctx, "bot1", "", "user_123", "AI 报时")
+	db.Where("platform_uid = ?", "user_123").First(&identity)
+	if !strings.Contains(identity.Metadata, "101") {
+		t.Errorf("private messages should keep the default group, got %s", identity.Metadata)
+	}
 
-	// 验证 System Prompt 是否包含了目标群组 ID
-	if !strings.Contains(aiSvc.LastSystemPrompt, "目标群组 ID: 101") {
+	// 3. 场景 B: 私聊中发起任务，默认群组作为目标群组交给 AI
+	result, err := tm.AI.MatchSkillByLLM(ctx, "AI 报时", 0, map[string]any{
+		"effective_group_id": defaultGroup,
+		"is_private":         true,
+		"bot_id":             "bot1",
+	})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !strings.Contains(aiSvc.LastSystemPrompt, "目标群组 ID: 101") || !strings.Contains(aiSvc.LastSystemPrompt, "私聊") {
 		t.Errorf("System Prompt should contain target group ID 101, got: %s", aiSvc.LastSystemPrompt)
 	}
-	fmt.Println("System Prompt 验证通过: 包含了默认群组 ID 101")
-
-	// 验证草稿是否自动补全了 group_id
-	var draft tasks.AIDraft
-	db.Order("id desc").First(&draft)
-	fmt.Printf("生成的草稿数据: %s\n", draft.Data)
-	// 因为是在 JSON 字符串中，group_id 可能会带转义反斜杠，或者不带（取决于序列化深度）
-	// 我们直接检查是否包含 group_id 和 101
-	if !strings.Contains(draft.Data, "group_id") || !strings.Contains(draft.Data, "101") {
-		t.Errorf("Draft should automatically include group_id: 101, got: %s", draft.Data)
-	}
-	fmt.Println("草稿验证通过: 自动补全了 group_id 101")
-
-	// 5. 场景 C: 跨群操作拦截 (普通用户尝试操作群 999)
-	fmt.Println("\n--- [场景 C: 跨群操作拦截] ---")
-	aiSvc.ReturnCrossGroup = true
-	botMgr.Actions = nil // 清空记录
-	tm.ProcessChatMessage(context.Background(), "bot1", "101", "user_123", "给群 999 设置报时")
-
-	// 验证是否收到了拦截消息
-	intercepted := false
-	for _, action := range botMgr.Actions {
-		if strings.Contains(action, "权限拦截") || strings.Contains(action, "没有权限") {
-			intercepted = true
-			break
-		}
+	if result.Intent != types.AIActionCreateTask {
+		t.Fatalf("Intent = %s, want %s", result.Intent, types.AIActionCreateTask)
+	}
+	data, _ := json.Marshal(result.Data)
+	if !strings.Contains(string(data), `\"group_id\":\"101\"`) {
+		t.Errorf("Draft should automatically include group_id: 101, got: %s", data)
 	}
-	if !intercepted {
-		t.Errorf("Should have intercepted cross-group operation")
+
+	// 4. 场景 C: 取消意图
+	cancel, err := tm.AI.MatchSkillByLLM(ctx, "取消刚才的任务", 0, map[string]any{"is_private": false})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if cancel.Intent != types.AIActionCancelTask {
+		t.Errorf("Intent = %s, want %s", cancel.Intent, types.AIActionCancelTask)
+	}
+
+	// 5. 场景 D: 确认草稿后交给执行器
+	draft := models.AIDraft{
+		DraftID:    "draftprivate0001",
+		UserID:     123,
+		GroupID:    "101",
+		Intent:     string(result.Intent),
+		Data:       string(data),
+		Status:     "pending",
+		ExpireTime: time.Now().Add(10 * time.Minute),
 	}
-	fmt.Println("跨群拦截验证通过: 成功拦截了非法跨群请求")
+	db.Create(&draft)
 
-	// 6. 场景 D: 即时执行验证 (取消任务)
-	fmt.Println("\n--- [场景 D: 即时执行验证 (取消任务)] ---")
 	botMgr.Actions = nil
-	executor.LastExecutedDraft = nil
-	tm.ProcessChatMessage(context.Background(), "bot1", "101", "user_admin", "取消刚才的任务")
+	if err := tm.ProcessChatMessage(ctx, "bot1", "", "user_123", "#确认 "+draft.DraftID); err != nil {
+		t.Fatalf("confirm failed: %v", err)
+	}
+	if executor.LastExecutedDraft == nil || executor.LastExecutedDraft.DraftID != draft.DraftID {
+		t.Fatalf("confirmed draft should be executed, got %+v", executor.LastExecutedDraft)
+	}
+	db.First(&draft, draft.ID)
+	if draft.Status != "confirmed" {
+		t.Errorf("draft status = %s, want confirmed", draft.Status)
+	}
+	if msg := botMgr.lastMessage(); !strings.Contains(msg, "send_private_msg") {
+		t.Errorf("private confirmations should be answered privately, got %s", msg)
+	}
+
+	// 6. 场景 E: 只有创建者可以取消任务
+	task := &models.Task{
+		Name:          "报时任务",
+		Type:          "cron",
+		ActionType:    "send_message",
+		TriggerConfig: "{\"cron\":\"0 * * * *\"}",
+		CreatorID:     123,
+	}
+	if err := tm.CreateTask(task, false); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
 
-	if executor.LastExecutedDraft == nil {
-		t.Errorf("Cancel task should be executed immediately, but no draft executed")
-	} else if executor.LastExecutedDraft.Intent != string(tasks.AIActionCancelTask) {
-		t.Errorf("Executed draft should be cancel_task, got: %s", executor.LastExecutedDraft.Intent)
+	tm.ProcessChatMessage(ctx, "bot1", "101", "456", fmt.Sprintf("#取消 %d", task.ID))
+	if msg := botMgr.lastMessage(); !strings.Contains(msg, "权限不足") {
+		t.Errorf("non-creators should not cancel the task, got %s", msg)
+	}
+
+	tm.ProcessChatMessage(ctx, "bot1", "101", "123", fmt.Sprintf("#取消 %d", task.ID))
+	if msg := botMgr.lastMessage(); !strings.Contains(msg, "已成功取消") {
+		t.Errorf("creator should cancel the task, got %s", msg)
+	}
+	var cancelled models.Task
+	db.First(&cancelled, task.ID)
+	if cancelled.Status != models.TaskDisabled || cancelled.NextRunTime != nil {
+		t.Errorf("cancelled task should be disabled, got status=%s next=%v", cancelled.Status, cancelled.NextRunTime)
 	}
-	fmt.Println("即时执行验证通过: 取消任务指令已直接执行")
 }
@@ -46,6 +46,40 @@ func (s *TaskAIEmbeddingService) GenerateEmbedding(ctx context.Context, text str
 	return resp.Data[0].Embedding, nil
 }
 
+// GenerateEmbeddings 一次请求生成多条向量。多模态 (vision) 模型的输入格式不支持批量，逐条生成
+func (s *TaskAIEmbeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if strings.Contains(strings.ToLower(s.modelName), "vision") {
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			v, err := s.GenerateEmbedding(ctx, text)
+			if err != nil {
+				return nil, err
+			}
+			vectors[i] = v
+		}
+		return vectors, nil
+	}
+
+	resp, err := s.svc.CreateEmbedding(ctx, s.modelID, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, d := range resp.Data {
+		// 按 index 归位，兼容不保证顺序的接口
+		pos := d.Index
+		if pos < 0 || pos >= len(texts) || vectors[pos] != nil {
+			pos = i
+		}
+		vectors[pos] = d.Embedding
+	}
+	return vectors, nil
+}
+
 // GenerateQueryEmbedding 为检索生成向量，会自动添加豆包建议的前缀
 func (s *TaskAIEmbeddingService) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
 	// 针对豆包模型 (含 doubao 关键字)，添加官方建议的检索前缀
@@ -0,0 +1,88 @@
+package eval
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// HashEmbedding 基于特征哈希的本地向量 (英文单词 + 中文字符二元组)，不依赖模型服务。
+// 语义能力有限，用于离线冒烟评测与对比切分参数，不代表线上向量模型的效果
+type HashEmbedding struct {
+	Dim int // 默认 256
+}
+
+func (h HashEmbedding) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	dim := h.Dim
+	if dim <= 0 {
+		dim = 256
+	}
+	v := make([]float32, dim)
+	for _, tok := range hashTokens(text) {
+		f := fnv.New32a()
+		f.Write([]byte(tok))
+		sum := f.Sum32()
+		sign := float32(1)
+		if sum&1 == 1 {
+			sign = -1
+		}
+		v[int(sum>>1)%dim] += sign
+	}
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		v[0] = 1
+		return v, nil
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v, nil
+}
+
+func (h HashEmbedding) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	return h.GenerateEmbedding(ctx, query)
+}
+
+// hashTokens 英文与数字按单词切分，中日韩文字取相邻二元组
+func hashTokens(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			tokens = append(tokens, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
@@ -0,0 +1,138 @@
+package eval
+
+import (
+	"BotMatrix/common/types"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeKB 按问题返回预设的检索结果
+type fakeKB map[string][]types.DocChunk
+
+func (f fakeKB) Search(ctx context.Context, query string, limit int, filter *types.SearchFilter) ([]types.DocChunk, error) {
+	return f[query], nil
+}
+
+func chunk(docID uint, source, content string) types.DocChunk {
+	return types.DocChunk{DocID: docID, Source: source, Title: source, Content: content}
+}
+
+// echoModel 复述系统消息中的第一条参考内容
+type echoModel struct{}
+
+func (echoModel) Complete(ctx context.Context, messages []types.Message) (string, error) {
+	if messages[0].Role != types.RoleSystem {
+		return "", nil
+	}
+	ctxText, _ := messages[0].Content.(string)
+	lines := strings.Split(ctxText, "\n")
+	return strings.TrimPrefix(lines[1], "[1] "), nil
+}
+
+var testSet = &GoldenSet{Questions: []Question{
+	{ID: "install", Query: "如何安装", ExpectedSources: []string{"docs/install.md"}, ExpectedAnswer: "运行安装脚本"},
+	{ID: "config", Query: "配置文件在哪", ExpectedSources: []string{"config.md", "faq.md"}},
+	{ID: "missing", Query: "不存在的问题", ExpectedSources: []string{"other.md"}},
+}}
+
+func TestMetrics(t *testing.T) {
+	docs := rankedDocs([]types.DocChunk{
+		chunk(1, "/repo/docs/a.md", "x"),
+		chunk(1, "/repo/docs/a.md", "y"),
+		{ID: "graph_context", Content: "graph"},
+		chunk(2, "/repo/docs/config.md", "z"),
+	})
+	if len(docs) != 2 {
+		t.Fatalf("rankedDocs = %d, want 2", len(docs))
+	}
+	if got := RecallAtK(docs, []string{"config.md", "faq.md"}, 5); got != 0.5 {
+		t.Fatalf("recall = %v", got)
+	}
+	if got := RecallAtK(docs, []string{"config.md"}, 1); got != 0 {
+		t.Fatalf("recall@1 = %v", got)
+	}
+	if got := ReciprocalRank(docs, []string{"config.md"}); got != 0.5 {
+		t.Fatalf("rr = %v", got)
+	}
+	if matches(docs[1], "fig.md") {
+		t.Fatalf("suffix match must respect path boundaries")
+	}
+}
+
+func TestRunnerAndCompare(t *testing.T) {
+	base := fakeKB{
+		"如何安装":   {chunk(1, "docs/readme.md", "项目简介"), chunk(2, "docs/install.md", "运行安装脚本即可完成安装")},
+		"配置文件在哪": {chunk(3, "config.md", "配置文件位于 config.json")},
+	}
+	candidate := fakeKB{
+		"如何安装":   {chunk(2, "docs/install.md", "运行安装脚本即可完成安装")},
+		"配置文件在哪": {chunk(4, "faq.md", "常见问题")},
+	}
+
+	run := func(kb types.KnowledgeBase, name string) *Report {
+		r := &Runner{KB: kb, K: 3, Answerer: ChatAnswerer{Model: echoModel{}}, Grader: OverlapGrader{}}
+		report, err := r.Run(context.Background(), name, testSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	a, b := run(base, "base"), run(candidate, "candidate")
+
+	if a.Summary.Questions != 3 || a.Results[0].FirstHit != 2 || a.Results[1].Recall != 0.5 || a.Results[2].FirstHit != 0 {
+		t.Fatalf("unexpected base results: %+v", a.Results)
+	}
+	if got, want := a.Summary.MRR, (0.5+1)/3; got < want-1e-9 || got > want+1e-9 {
+		t.Fatalf("mrr = %v, want %v", got, want)
+	}
+	// 候选配置只召回了答案所在文档，回答完全来自上下文
+	if b.Results[0].Faithfulness != 1 || b.Results[0].Correctness <= 0 {
+		t.Fatalf("grade = %+v", b.Results[0])
+	}
+
+	diff := Compare(a, b)
+	if len(diff.Improvements) != 1 || diff.Improvements[0].ID != "install" {
+		t.Fatalf("improvements = %+v", diff.Improvements)
+	}
+	if len(diff.Regressions) != 0 {
+		t.Fatalf("regressions = %+v", diff.Regressions)
+	}
+
+	var buf bytes.Buffer
+	diff.WriteMarkdown(&buf)
+	out := buf.String()
+	for _, want := range []string{"base → candidate", "| MRR |", "+0.167", "## 改进 (1)", "| install | 如何安装 | 2 → 1 |"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestOverlapGrader(t *testing.T) {
+	g, _ := OverlapGrader{}.Grade(context.Background(), Question{ExpectedAnswer: "运行安装脚本"}, "运行安装脚本", []string{"请运行安装脚本"})
+	if g.Faithfulness != 1 || g.Correctness != 1 {
+		t.Fatalf("grade = %+v", g)
+	}
+	g, _ = OverlapGrader{}.Grade(context.Background(), Question{}, "完全无关的编造内容", []string{"请运行安装脚本"})
+	if g.Faithfulness != 0 || g.Correctness != -1 {
+		t.Fatalf("grade = %+v", g)
+	}
+}
+
+func TestHashEmbeddingSimilarity(t *testing.T) {
+	ctx := context.Background()
+	q, _ := HashEmbedding{}.GenerateQueryEmbedding(ctx, "如何安装 BotMatrix")
+	near, _ := HashEmbedding{}.GenerateEmbedding(ctx, "BotMatrix 安装步骤")
+	far, _ := HashEmbedding{}.GenerateEmbedding(ctx, "天气预报插件")
+	dot := func(a, b []float32) (s float32) {
+		for i := range a {
+			s += a[i] * b[i]
+		}
+		return
+	}
+	if dot(q, near) <= dot(q, far) {
+		t.Fatalf("expected related text to be closer")
+	}
+}
@@ -0,0 +1,47 @@
+// Package eval 知识库检索与问答质量评测：
+// 以黄金问题集运行检索与回答链路，计算 recall@k、MRR 与回答忠实度，并对比两种配置生成回归报告。
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GoldenSet 黄金问题集
+type GoldenSet struct {
+	Name      string     `json:"name"`
+	Questions []Question `json:"questions"`
+}
+
+// Question 一条评测问题。ExpectedSources 为应被召回的文档 (来源路径或标题，来源按路径后缀匹配)，
+// ExpectedAnswer 为参考答案，可为空
+type Question struct {
+	ID              string   `json:"id"`
+	Query           string   `json:"query"`
+	ExpectedSources []string `json:"expected_sources"`
+	ExpectedAnswer  string   `json:"expected_answer,omitempty"`
+}
+
+// LoadGoldenSet 读取 JSON 格式的黄金问题集
+func LoadGoldenSet(path string) (*GoldenSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set GoldenSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid golden set %s: %v", path, err)
+	}
+	for i := range set.Questions {
+		q := &set.Questions[i]
+		if strings.TrimSpace(q.Query) == "" {
+			return nil, fmt.Errorf("question %d has no query", i+1)
+		}
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("q%d", i+1)
+		}
+	}
+	return &set, nil
+}
@@ -0,0 +1,155 @@
+package eval
+
+import (
+	"BotMatrix/common/ai/rag/rank"
+	"BotMatrix/common/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ChatModel 评测使用的对话模型
+type ChatModel interface {
+	Complete(ctx context.Context, messages []types.Message) (string, error)
+}
+
+// AIServiceModel 以 types.AIService 的指定模型作为 ChatModel
+type AIServiceModel struct {
+	Svc     types.AIService
+	ModelID uint
+}
+
+func (m AIServiceModel) Complete(ctx context.Context, messages []types.Message) (string, error) {
+	resp, err := m.Svc.Chat(ctx, m.ModelID, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty chat response")
+	}
+	content, _ := resp.Choices[0].Message.Content.(string)
+	return content, nil
+}
+
+// Answerer 根据检索结果生成回答
+type Answerer interface {
+	Answer(ctx context.Context, query string, chunks []types.DocChunk) (string, error)
+}
+
+// ChatAnswerer 与机器人回答链路一致：将检索结果以 types.KnowledgeContext 注入系统消息后提问
+type ChatAnswerer struct {
+	Model ChatModel
+}
+
+func (a ChatAnswerer) Answer(ctx context.Context, query string, chunks []types.DocChunk) (string, error) {
+	var messages []types.Message
+	if len(chunks) > 0 {
+		messages = append(messages, types.Message{Role: types.RoleSystem, Content: types.KnowledgeContext(chunks)})
+	}
+	messages = append(messages, types.Message{Role: types.RoleUser, Content: query})
+	return a.Model.Complete(ctx, messages)
+}
+
+// Grade 回答评分，取值 [0,1]；Correctness 在没有参考答案时为 -1
+type Grade struct {
+	Faithfulness float64 `json:"faithfulness"` // 回答内容有多少能被检索到的上下文支持
+	Correctness  float64 `json:"correctness"`  // 回答与参考答案的一致程度
+	Reason       string  `json:"reason,omitempty"`
+}
+
+// Grader 回答评分器
+type Grader interface {
+	Grade(ctx context.Context, q Question, answer string, contexts []string) (Grade, error)
+}
+
+// LLMGrader 由对话模型评分
+type LLMGrader struct {
+	Model ChatModel
+}
+
+var gradeObjectPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+func (g LLMGrader) Grade(ctx context.Context, q Question, answer string, contexts []string) (Grade, error) {
+	var prompt strings.Builder
+	prompt.WriteString("你是一个严格的问答评测专家。请根据参考上下文评估回答：\n")
+	prompt.WriteString("- faithfulness: 回答中的陈述有多少能被参考上下文直接支持 (0-10)，编造或与上下文矛盾的内容扣分。\n")
+	if q.ExpectedAnswer != "" {
+		prompt.WriteString("- correctness: 回答与标准答案在事实上的一致程度 (0-10)。\n")
+	}
+	prompt.WriteString("只输出 JSON，如 {\"faithfulness\": 8, \"correctness\": 7, \"reason\": \"简要说明\"}。\n\n")
+	prompt.WriteString("问题：" + q.Query + "\n\n参考上下文：\n")
+	for i, c := range contexts {
+		prompt.WriteString(fmt.Sprintf("[%d] %s\n", i+1, c))
+	}
+	if q.ExpectedAnswer != "" {
+		prompt.WriteString("\n标准答案：" + q.ExpectedAnswer + "\n")
+	}
+	prompt.WriteString("\n待评估回答：" + answer + "\n")
+
+	content, err := g.Model.Complete(ctx, []types.Message{{Role: types.RoleUser, Content: prompt.String()}})
+	if err != nil {
+		return Grade{}, err
+	}
+	var raw struct {
+		Faithfulness float64  `json:"faithfulness"`
+		Correctness  *float64 `json:"correctness"`
+		Reason       string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(gradeObjectPattern.FindString(content)), &raw); err != nil {
+		return Grade{}, fmt.Errorf("invalid grader response %q: %v", content, err)
+	}
+	grade := Grade{Faithfulness: clamp(raw.Faithfulness / 10), Correctness: -1, Reason: raw.Reason}
+	if q.ExpectedAnswer != "" && raw.Correctness != nil {
+		grade.Correctness = clamp(*raw.Correctness / 10)
+	}
+	return grade, nil
+}
+
+// OverlapGrader 本地评分器，不调用模型：
+// 忠实度取回答中能在上下文找到的字符二元组比例，正确性取参考答案二元组被回答覆盖的比例。
+// 只适合冒烟测试与离线对比，分数绝对值不代表真实质量
+type OverlapGrader struct{}
+
+func (OverlapGrader) Grade(ctx context.Context, q Question, answer string, contexts []string) (Grade, error) {
+	grade := Grade{Faithfulness: coverage(answer, strings.Join(contexts, "\n")), Correctness: -1}
+	if q.ExpectedAnswer != "" {
+		grade.Correctness = coverage(q.ExpectedAnswer, answer)
+	}
+	return grade, nil
+}
+
+// coverage part 的字符二元组有多少出现在 whole 中
+func coverage(part, whole string) float64 {
+	pg, wg := bigramSet(part), bigramSet(whole)
+	if len(pg) == 0 {
+		return rank.TextJaccard(part, whole)
+	}
+	hit := 0
+	for g := range pg {
+		if wg[g] {
+			hit++
+		}
+	}
+	return float64(hit) / float64(len(pg))
+}
+
+func bigramSet(s string) map[string]bool {
+	var runes []rune
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	set := make(map[string]bool, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = true
+	}
+	return set
+}
+
+func clamp(v float64) float64 {
+	return min(max(v, 0), 1)
+}
@@ -0,0 +1,80 @@
+package eval
+
+import (
+	"BotMatrix/common/types"
+	"strings"
+)
+
+// rankedDocs 将检索结果按首次出现的顺序去重为文档列表，忽略知识图谱等非文档结果
+func rankedDocs(chunks []types.DocChunk) []types.DocChunk {
+	seen := make(map[string]bool, len(chunks))
+	var docs []types.DocChunk
+	for _, c := range chunks {
+		if c.DocID == 0 && c.Source == "" {
+			continue
+		}
+		key := c.Source
+		if key == "" {
+			key = c.Title
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		docs = append(docs, c)
+	}
+	return docs
+}
+
+// matches 判断检索到的文档是否为期望文档：来源或标题相同，或来源以 "/"+expected 结尾
+func matches(doc types.DocChunk, expected string) bool {
+	expected = strings.TrimSpace(expected)
+	if expected == "" {
+		return false
+	}
+	if doc.Source == expected || doc.Title == expected {
+		return true
+	}
+	source := strings.ReplaceAll(doc.Source, "\\", "/")
+	return strings.HasSuffix(source, "/"+strings.TrimPrefix(expected, "/"))
+}
+
+// RecallAtK 前 k 个文档中命中的期望文档比例；没有期望文档时返回 1
+func RecallAtK(docs []types.DocChunk, expected []string, k int) float64 {
+	if len(expected) == 0 {
+		return 1
+	}
+	if k > 0 && len(docs) > k {
+		docs = docs[:k]
+	}
+	hit := 0
+	for _, exp := range expected {
+		for _, d := range docs {
+			if matches(d, exp) {
+				hit++
+				break
+			}
+		}
+	}
+	return float64(hit) / float64(len(expected))
+}
+
+// FirstHit 第一个期望文档的排名 (从 1 开始)，未命中时返回 0
+func FirstHit(docs []types.DocChunk, expected []string) int {
+	for i, d := range docs {
+		for _, exp := range expected {
+			if matches(d, exp) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// ReciprocalRank 第一个期望文档排名的倒数，未命中时为 0
+func ReciprocalRank(docs []types.DocChunk, expected []string) float64 {
+	if r := FirstHit(docs, expected); r > 0 {
+		return 1 / float64(r)
+	}
+	return 0
+}
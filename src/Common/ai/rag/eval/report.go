@@ -0,0 +1,184 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// regressionEpsilon 小于此值的分数变化视为持平
+const regressionEpsilon = 1e-9
+
+// Change 单个问题在两次评测间的变化
+type Change struct {
+	ID         string  `json:"id"`
+	Query      string  `json:"query"`
+	BaseRecall float64 `json:"base_recall"`
+	Recall     float64 `json:"recall"`
+	BaseHit    int     `json:"base_hit"`
+	Hit        int     `json:"hit"`
+	BaseFaith  float64 `json:"base_faithfulness"`
+	Faith      float64 `json:"faithfulness"`
+}
+
+// Diff 两次评测的对比
+type Diff struct {
+	Base         *Report  `json:"base"`
+	Candidate    *Report  `json:"candidate"`
+	Regressions  []Change `json:"regressions"`
+	Improvements []Change `json:"improvements"`
+}
+
+// Compare 对比基线与候选配置，按问题 ID 配对；
+// 召回率下降、命中排名后移或忠实度下降的问题记为回归，反之记为改进
+func Compare(base, candidate *Report) *Diff {
+	diff := &Diff{Base: base, Candidate: candidate}
+	byID := make(map[string]QuestionResult, len(base.Results))
+	for _, r := range base.Results {
+		byID[r.ID] = r
+	}
+	for _, c := range candidate.Results {
+		b, ok := byID[c.ID]
+		if !ok {
+			continue
+		}
+		change := Change{
+			ID: c.ID, Query: c.Query,
+			BaseRecall: b.Recall, Recall: c.Recall,
+			BaseHit: b.FirstHit, Hit: c.FirstHit,
+			BaseFaith: b.Faithfulness, Faith: c.Faithfulness,
+		}
+		switch score := compareResult(b, c); {
+		case score < 0:
+			diff.Regressions = append(diff.Regressions, change)
+		case score > 0:
+			diff.Improvements = append(diff.Improvements, change)
+		}
+	}
+	return diff
+}
+
+// compareResult 依次比较召回率、首个命中的倒数排名与忠实度，返回候选相对基线的优劣 (-1/0/1)
+func compareResult(b, c QuestionResult) int {
+	for _, pair := range [][2]float64{{b.Recall, c.Recall}, {b.RR, c.RR}, {b.Faithfulness, c.Faithfulness}} {
+		if pair[0] < 0 || pair[1] < 0 {
+			continue
+		}
+		if d := pair[1] - pair[0]; math.Abs(d) > regressionEpsilon {
+			if d > 0 {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// WriteJSON 以 JSON 输出报告或对比结果
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteMarkdown 输出单次评测报告
+func (r *Report) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# RAG 评测报告: %s\n\n", r.Name)
+	writeSummaryTable(w, []string{r.Name}, []Summary{r.Summary}, r.K)
+
+	fmt.Fprintf(w, "\n## 问题明细\n\n| ID | 问题 | 命中排名 | Recall@%d | 忠实度 | 检索结果 |\n|---|---|---|---|---|---|\n", r.K)
+	for _, q := range r.Results {
+		retrieved := strings.Join(q.Retrieved, "<br>")
+		if q.Error != "" {
+			retrieved = "错误: " + q.Error
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %.2f | %s | %s |\n",
+			q.ID, escapeCell(q.Query), hitString(q.FirstHit), q.Recall, score(q.Faithfulness), escapeCell(retrieved))
+	}
+}
+
+// WriteMarkdown 输出两种配置的对比报告
+func (d *Diff) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# RAG 配置对比: %s → %s\n\n", d.Base.Name, d.Candidate.Name)
+	writeSummaryTable(w, []string{d.Base.Name, d.Candidate.Name}, []Summary{d.Base.Summary, d.Candidate.Summary}, d.Candidate.K)
+
+	writeChanges := func(title string, changes []Change) {
+		fmt.Fprintf(w, "\n## %s (%d)\n\n", title, len(changes))
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "| ID | 问题 | 命中排名 | Recall | 忠实度 |\n|---|---|---|---|---|\n")
+		for _, c := range changes {
+			fmt.Fprintf(w, "| %s | %s | %s → %s | %.2f → %.2f | %s → %s |\n",
+				c.ID, escapeCell(c.Query), hitString(c.BaseHit), hitString(c.Hit), c.BaseRecall, c.Recall, score(c.BaseFaith), score(c.Faith))
+		}
+	}
+	writeChanges("回归", d.Regressions)
+	writeChanges("改进", d.Improvements)
+}
+
+func writeSummaryTable(w io.Writer, names []string, sums []Summary, k int) {
+	fmt.Fprintf(w, "| 指标 | %s |", strings.Join(names, " | "))
+	if len(sums) == 2 {
+		fmt.Fprint(w, " 变化 |")
+	}
+	fmt.Fprintf(w, "\n|---|%s", strings.Repeat("---|", len(names)))
+	if len(sums) == 2 {
+		fmt.Fprint(w, "---|")
+	}
+	fmt.Fprintln(w)
+
+	rows := []struct {
+		label string
+		value func(Summary) float64
+	}{
+		{fmt.Sprintf("Recall@%d", k), func(s Summary) float64 { return s.RecallAtK }},
+		{"MRR", func(s Summary) float64 { return s.MRR }},
+		{fmt.Sprintf("Hit@%d", k), func(s Summary) float64 { return s.HitRate }},
+		{"忠实度", func(s Summary) float64 { return s.Faithfulness }},
+		{"正确性", func(s Summary) float64 { return s.Correctness }},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %s |", row.label)
+		for _, s := range sums {
+			fmt.Fprintf(w, " %s |", score(row.value(s)))
+		}
+		if len(sums) == 2 {
+			a, b := row.value(sums[0]), row.value(sums[1])
+			if a >= 0 && b >= 0 {
+				fmt.Fprintf(w, " %+.3f |", b-a)
+			} else {
+				fmt.Fprint(w, " - |")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "| 平均检索耗时 (ms) |")
+	for _, s := range sums {
+		fmt.Fprintf(w, " %.0f |", s.AvgLatencyMs)
+	}
+	if len(sums) == 2 {
+		fmt.Fprintf(w, " %+.0f |", sums[1].AvgLatencyMs-sums[0].AvgLatencyMs)
+	}
+	fmt.Fprintln(w)
+}
+
+func score(v float64) string {
+	if v < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", v)
+}
+
+func hitString(rank int) string {
+	if rank == 0 {
+		return "未命中"
+	}
+	return fmt.Sprintf("%d", rank)
+}
+
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
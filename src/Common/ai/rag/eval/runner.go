@@ -0,0 +1,159 @@
+package eval
+
+import (
+	"BotMatrix/common/types"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Runner 评测运行器。KB 为被测知识库，Answerer 与 Grader 为空时只评测检索
+type Runner struct {
+	KB       types.KnowledgeBase
+	K        int // 检索条数，默认 5
+	Filter   *types.SearchFilter
+	Answerer Answerer
+	Grader   Grader
+}
+
+// QuestionResult 单个问题的评测结果
+type QuestionResult struct {
+	ID           string   `json:"id"`
+	Query        string   `json:"query"`
+	Retrieved    []string `json:"retrieved"` // 去重后的文档来源，按排名
+	FirstHit     int      `json:"first_hit"` // 第一个期望文档的排名，0 表示未命中
+	Recall       float64  `json:"recall"`
+	RR           float64  `json:"rr"`
+	Answer       string   `json:"answer,omitempty"`
+	Faithfulness float64  `json:"faithfulness"` // 未评分时为 -1
+	Correctness  float64  `json:"correctness"`  // 未评分或没有参考答案时为 -1
+	LatencyMs    int64    `json:"latency_ms"`   // 检索耗时
+	Error        string   `json:"error,omitempty"`
+}
+
+// Summary 汇总指标；Faithfulness 与 Correctness 为已评分问题的均值，没有评分时为 -1
+type Summary struct {
+	Questions    int     `json:"questions"`
+	RecallAtK    float64 `json:"recall_at_k"`
+	MRR          float64 `json:"mrr"`
+	HitRate      float64 `json:"hit_rate"`
+	Faithfulness float64 `json:"faithfulness"`
+	Correctness  float64 `json:"correctness"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	Errors       int     `json:"errors"`
+}
+
+// Report 一次评测的结果
+type Report struct {
+	Name      string           `json:"name"`
+	K         int              `json:"k"`
+	Config    any              `json:"config,omitempty"` // 被测配置，原样写入报告
+	StartedAt time.Time        `json:"started_at"`
+	Summary   Summary          `json:"summary"`
+	Results   []QuestionResult `json:"results"`
+}
+
+// Run 依次评测问题集中的每个问题
+func (r *Runner) Run(ctx context.Context, name string, set *GoldenSet) (*Report, error) {
+	k := r.K
+	if k <= 0 {
+		k = 5
+	}
+	report := &Report{Name: name, K: k, StartedAt: time.Now()}
+
+	for _, q := range set.Questions {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		report.Results = append(report.Results, r.evaluate(ctx, q, k))
+	}
+	report.Summary = summarize(report.Results)
+	return report, nil
+}
+
+func (r *Runner) evaluate(ctx context.Context, q Question, k int) QuestionResult {
+	res := QuestionResult{ID: q.ID, Query: q.Query, Faithfulness: -1, Correctness: -1}
+
+	start := time.Now()
+	chunks, err := r.KB.Search(ctx, q.Query, k, r.Filter)
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = fmt.Sprintf("search: %v", err)
+		return res
+	}
+
+	docs := rankedDocs(chunks)
+	for _, d := range docs {
+		res.Retrieved = append(res.Retrieved, d.Source)
+	}
+	res.FirstHit = FirstHit(docs, q.ExpectedSources)
+	res.Recall = RecallAtK(docs, q.ExpectedSources, k)
+	res.RR = ReciprocalRank(docs, q.ExpectedSources)
+
+	if r.Answerer == nil {
+		return res
+	}
+	answer, err := r.Answerer.Answer(ctx, q.Query, chunks)
+	if err != nil {
+		res.Error = fmt.Sprintf("answer: %v", err)
+		return res
+	}
+	res.Answer = answer
+
+	if r.Grader == nil {
+		return res
+	}
+	contexts := make([]string, len(chunks))
+	for i, c := range chunks {
+		contexts[i] = c.Content
+	}
+	grade, err := r.Grader.Grade(ctx, q, answer, contexts)
+	if err != nil {
+		res.Error = fmt.Sprintf("grade: %v", err)
+		return res
+	}
+	res.Faithfulness = grade.Faithfulness
+	res.Correctness = grade.Correctness
+	return res
+}
+
+func summarize(results []QuestionResult) Summary {
+	s := Summary{Questions: len(results), Faithfulness: -1, Correctness: -1}
+	if len(results) == 0 {
+		return s
+	}
+	var faith, correct float64
+	var faithN, correctN int
+	var latency int64
+	for _, r := range results {
+		if r.Error != "" {
+			s.Errors++
+		}
+		s.RecallAtK += r.Recall
+		s.MRR += r.RR
+		if r.FirstHit > 0 {
+			s.HitRate++
+		}
+		latency += r.LatencyMs
+		if r.Faithfulness >= 0 {
+			faith += r.Faithfulness
+			faithN++
+		}
+		if r.Correctness >= 0 {
+			correct += r.Correctness
+			correctN++
+		}
+	}
+	n := float64(len(results))
+	s.RecallAtK /= n
+	s.MRR /= n
+	s.HitRate /= n
+	s.AvgLatencyMs = float64(latency) / n
+	if faithN > 0 {
+		s.Faithfulness = faith / float64(faithN)
+	}
+	if correctN > 0 {
+		s.Correctness = correct / float64(correctN)
+	}
+	return s
+}
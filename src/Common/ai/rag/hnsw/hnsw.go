@@ -0,0 +1,486 @@
+// Package hnsw 实现进程内的 HNSW (Hierarchical Navigable Small World) 近似最近邻索引，
+// 使用余弦距离，支持删除 (墓碑标记) 与持久化到磁盘，供无 pgvector 的部署使用。
+package hnsw
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	DefaultM              = 16
+	DefaultEfConstruction = 200
+	DefaultEfSearch       = 64
+)
+
+// ErrDimensionMismatch 向量维度与索引不一致 (通常是更换了向量模型，需要重建索引)
+var ErrDimensionMismatch = errors.New("hnsw: vector dimension mismatch")
+
+// Result 搜索结果，Distance 为余弦距离 (0 表示方向完全一致)
+type Result struct {
+	ID       uint64
+	Distance float32
+}
+
+type node struct {
+	ID      uint64
+	Vector  []float32
+	Friends [][]uint32 // 每一层的邻居 (内部下标)
+	Deleted bool
+}
+
+// Index HNSW 索引，并发安全
+type Index struct {
+	mu             sync.RWMutex
+	m              int
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+	dim            int
+	nodes          []*node
+	ids            map[uint64]uint32 // 外部 ID -> 当前有效节点下标
+	entry          int
+	maxLevel       int
+	deleted        int
+	rng            *rand.Rand
+}
+
+// New 创建空索引，参数 <= 0 时使用默认值
+func New(m, efConstruction, efSearch int) *Index {
+	if m <= 0 {
+		m = DefaultM
+	}
+	if efConstruction <= 0 {
+		efConstruction = DefaultEfConstruction
+	}
+	if efSearch <= 0 {
+		efSearch = DefaultEfSearch
+	}
+	return &Index{
+		m:              m,
+		efConstruction: efConstruction,
+		efSearch:       efSearch,
+		levelMult:      1 / math.Log(float64(m)),
+		ids:            make(map[uint64]uint32),
+		entry:          -1,
+		rng:            rand.New(rand.NewSource(1)),
+	}
+}
+
+// Len 返回有效 (未删除) 向量数量
+func (h *Index) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.ids)
+}
+
+// Dim 返回索引的向量维度，空索引为 0
+func (h *Index) Dim() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.dim
+}
+
+// Deleted 返回墓碑节点数量，过多时应调用 Compact
+func (h *Index) Deleted() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.deleted
+}
+
+// Add 插入或更新向量。更新时旧节点标记为删除，再插入新节点
+func (h *Index) Add(id uint64, vector []float32) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dim == 0 {
+		h.dim = len(vector)
+	}
+	if len(vector) != h.dim || h.dim == 0 {
+		return fmt.Errorf("%w: got %d, index has %d", ErrDimensionMismatch, len(vector), h.dim)
+	}
+	if old, ok := h.ids[id]; ok {
+		h.nodes[old].Deleted = true
+		h.deleted++
+	}
+
+	level := int(math.Floor(-math.Log(h.rng.Float64()+1e-12) * h.levelMult))
+	n := &node{ID: id, Vector: normalize(vector), Friends: make([][]uint32, level+1)}
+	idx := uint32(len(h.nodes))
+	h.nodes = append(h.nodes, n)
+	h.ids[id] = idx
+
+	if h.entry < 0 {
+		h.entry = int(idx)
+		h.maxLevel = level
+		return nil
+	}
+
+	ep := []candidate{{idx: uint32(h.entry), dist: h.distance(n.Vector, h.entry)}}
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(n.Vector, ep, 1, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(n.Vector, ep, h.efConstruction, l)
+		neighbors := h.selectNeighbors(found, h.m)
+		for _, c := range neighbors {
+			n.Friends[l] = append(n.Friends[l], c.idx)
+			h.link(c.idx, idx, l)
+		}
+		ep = found
+	}
+
+	if level > h.maxLevel {
+		h.maxLevel = level
+		h.entry = int(idx)
+	}
+	return nil
+}
+
+// Delete 删除向量，返回是否存在
+func (h *Index) Delete(id uint64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	idx, ok := h.ids[id]
+	if !ok {
+		return false
+	}
+	h.nodes[idx].Deleted = true
+	h.deleted++
+	delete(h.ids, id)
+	return true
+}
+
+// Search 返回与 query 最相近的 k 个向量 (按距离升序)，ef <= 0 时使用默认搜索宽度
+func (h *Index) Search(query []float32, k int, ef int) ([]Result, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.entry < 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != h.dim {
+		return nil, fmt.Errorf("%w: got %d, index has %d", ErrDimensionMismatch, len(query), h.dim)
+	}
+	if ef < h.efSearch {
+		ef = h.efSearch
+	}
+	if ef < k {
+		ef = k
+	}
+
+	q := normalize(query)
+	ep := []candidate{{idx: uint32(h.entry), dist: h.distance(q, h.entry)}}
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(q, ep, 1, l)
+	}
+	found := h.searchLayer(q, ep, ef, 0)
+
+	results := make([]Result, 0, k)
+	for _, c := range found {
+		n := h.nodes[c.idx]
+		if n.Deleted {
+			continue
+		}
+		results = append(results, Result{ID: n.ID, Distance: c.dist})
+		if len(results) == k {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Each 遍历所有有效向量 (已归一化)，fn 返回错误时停止
+func (h *Index) Each(fn func(id uint64, vector []float32) error) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, n := range h.nodes {
+		if n.Deleted {
+			continue
+		}
+		if err := fn(n.ID, n.Vector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact 以有效向量重建索引，清理墓碑节点
+func (h *Index) Compact() *Index {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	fresh := New(h.m, h.efConstruction, h.efSearch)
+	for _, n := range h.nodes {
+		if !n.Deleted {
+			fresh.Add(n.ID, n.Vector)
+		}
+	}
+	return fresh
+}
+
+// --- 图操作 ---
+
+type candidate struct {
+	idx  uint32
+	dist float32
+}
+
+// minHeap 候选集 (距离最近的先出)
+type minHeap []candidate
+
+func (q minHeap) Len() int           { return len(q) }
+func (q minHeap) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q minHeap) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *minHeap) Push(x any)        { *q = append(*q, x.(candidate)) }
+func (q *minHeap) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// maxHeap 结果集 (距离最远的先出，便于淘汰)
+type maxHeap []candidate
+
+func (q maxHeap) Len() int           { return len(q) }
+func (q maxHeap) Less(i, j int) bool { return q[i].dist > q[j].dist }
+func (q maxHeap) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *maxHeap) Push(x any)        { *q = append(*q, x.(candidate)) }
+func (q *maxHeap) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+func (q maxHeap) peek() candidate { return q[0] }
+
+// searchLayer 在第 level 层从入口点出发做贪心扩展，返回最近的 ef 个节点 (升序)
+func (h *Index) searchLayer(q []float32, entries []candidate, ef int, level int) []candidate {
+	visited := make(map[uint32]struct{}, ef*4)
+	candidates := &minHeap{}
+	results := &maxHeap{}
+	for _, e := range entries {
+		visited[e.idx] = struct{}{}
+		heap.Push(candidates, e)
+		heap.Push(results, e)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && c.dist > results.peek().dist {
+			break
+		}
+		friends := h.nodes[c.idx].Friends
+		if level >= len(friends) {
+			continue
+		}
+		for _, f := range friends[level] {
+			if _, ok := visited[f]; ok {
+				continue
+			}
+			visited[f] = struct{}{}
+			d := h.distance(q, int(f))
+			if results.Len() < ef || d < results.peek().dist {
+				heap.Push(candidates, candidate{idx: f, dist: d})
+				heap.Push(results, candidate{idx: f, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, results.Len())
+	copy(out, *results)
+	sort.Slice(out, func(i, j int) bool { return out[i].dist < out[j].dist })
+	return out
+}
+
+// selectNeighbors 启发式选择邻居：优先保留与已选邻居不太相似的候选，使图覆盖更多方向
+func (h *Index) selectNeighbors(sorted []candidate, m int) []candidate {
+	if len(sorted) <= m {
+		return sorted
+	}
+	selected := make([]candidate, 0, m)
+	var skipped []candidate
+	for _, c := range sorted {
+		if len(selected) == m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if cosineDistance(h.nodes[c.idx].Vector, h.nodes[s.idx].Vector) < c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+	// 不足 m 个时用被跳过的最近候选补齐
+	for _, c := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// link 为节点 from 在第 level 层添加邻居 to，超出上限时重新选择邻居
+func (h *Index) link(from, to uint32, level int) {
+	n := h.nodes[from]
+	if level >= len(n.Friends) {
+		return
+	}
+	n.Friends[level] = append(n.Friends[level], to)
+
+	limit := h.m
+	if level == 0 {
+		limit = h.m * 2
+	}
+	if len(n.Friends[level]) <= limit {
+		return
+	}
+	cands := make([]candidate, 0, len(n.Friends[level]))
+	for _, f := range n.Friends[level] {
+		cands = append(cands, candidate{idx: f, dist: cosineDistance(n.Vector, h.nodes[f].Vector)})
+	}
+	sort.Slice(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+	kept := h.selectNeighbors(cands, limit)
+	n.Friends[level] = n.Friends[level][:0]
+	for _, c := range kept {
+		n.Friends[level] = append(n.Friends[level], c.idx)
+	}
+}
+
+func (h *Index) distance(q []float32, idx int) float32 {
+	return cosineDistance(q, h.nodes[idx].Vector)
+}
+
+// cosineDistance 两个已归一化向量的余弦距离
+func cosineDistance(a, b []float32) float32 {
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1 - dot
+}
+
+// normalize 返回单位化后的副本，零向量原样返回
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	out := make([]float32, len(v))
+	if sum == 0 {
+		return out
+	}
+	norm := float32(math.Sqrt(sum))
+	for i, f := range v {
+		out[i] = f / norm
+	}
+	return out
+}
+
+// --- 持久化 ---
+
+type snapshot struct {
+	M              int
+	EfConstruction int
+	EfSearch       int
+	Dim            int
+	Entry          int
+	MaxLevel       int
+	Nodes          []*node
+}
+
+// Save 将索引写入 w
+func (h *Index) Save(w io.Writer) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(snapshot{
+		M:              h.m,
+		EfConstruction: h.efConstruction,
+		EfSearch:       h.efSearch,
+		Dim:            h.dim,
+		Entry:          h.entry,
+		MaxLevel:       h.maxLevel,
+		Nodes:          h.nodes,
+	})
+}
+
+// Load 从 r 读取索引
+func Load(r io.Reader) (*Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("hnsw: failed to decode index: %w", err)
+	}
+	h := New(s.M, s.EfConstruction, s.EfSearch)
+	h.dim = s.Dim
+	h.entry = s.Entry
+	h.maxLevel = s.MaxLevel
+	h.nodes = s.Nodes
+	for i, n := range h.nodes {
+		if n.Deleted {
+			h.deleted++
+			continue
+		}
+		h.ids[n.ID] = uint32(i)
+	}
+	if len(h.nodes) == 0 {
+		h.entry = -1
+	}
+	return h, nil
+}
+
+// SaveFile 原子地将索引写入文件 (先写临时文件再重命名)
+func (h *Index) SaveFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := h.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile 从文件读取索引
+func LoadFile(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
@@ -0,0 +1,173 @@
+package hnsw
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func randomVectors(n, dim int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([][]float32, n)
+	for i := range out {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = rng.Float32()*2 - 1
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// bruteForce 精确计算最近的 k 个向量，作为召回率基准
+func bruteForce(vectors [][]float32, q []float32, k int) []uint64 {
+	nq := normalize(q)
+	type scored struct {
+		id   uint64
+		dist float32
+	}
+	all := make([]scored, len(vectors))
+	for i, v := range vectors {
+		all[i] = scored{id: uint64(i + 1), dist: cosineDistance(nq, normalize(v))}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].dist < all[j].dist })
+	ids := make([]uint64, k)
+	for i := range ids {
+		ids[i] = all[i].id
+	}
+	return ids
+}
+
+func buildIndex(t *testing.T, vectors [][]float32) *Index {
+	h := New(0, 0, 0)
+	for i, v := range vectors {
+		if err := h.Add(uint64(i+1), v); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+	return h
+}
+
+func TestSearchRecall(t *testing.T) {
+	vectors := randomVectors(2000, 32, 1)
+	h := buildIndex(t, vectors)
+	queries := randomVectors(50, 32, 2)
+
+	const k = 10
+	hit := 0
+	for _, q := range queries {
+		want := map[uint64]bool{}
+		for _, id := range bruteForce(vectors, q, k) {
+			want[id] = true
+		}
+		got, err := h.Search(q, k, 0)
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		for i, r := range got {
+			if want[r.ID] {
+				hit++
+			}
+			if i > 0 && r.Distance < got[i-1].Distance {
+				t.Fatalf("results not sorted by distance: %+v", got)
+			}
+		}
+	}
+	if recall := float64(hit) / float64(len(queries)*k); recall < 0.9 {
+		t.Errorf("recall@%d too low: %.3f", k, recall)
+	}
+}
+
+func TestDeleteAndUpdate(t *testing.T) {
+	vectors := randomVectors(300, 16, 3)
+	h := buildIndex(t, vectors)
+
+	target := vectors[41] // ID 42
+	got, _ := h.Search(target, 1, 0)
+	if len(got) != 1 || got[0].ID != 42 {
+		t.Fatalf("expected exact match for id 42, got %+v", got)
+	}
+
+	if !h.Delete(42) || h.Delete(42) {
+		t.Fatal("delete should report existence exactly once")
+	}
+	got, _ = h.Search(target, 5, 0)
+	for _, r := range got {
+		if r.ID == 42 {
+			t.Fatal("deleted vector returned by search")
+		}
+	}
+
+	// 更新向量：旧位置不再命中，新位置命中
+	h.Add(7, target)
+	got, _ = h.Search(target, 1, 0)
+	if len(got) != 1 || got[0].ID != 7 {
+		t.Errorf("updated vector not found at new position: %+v", got)
+	}
+	if h.Len() != 299 || h.Deleted() != 2 {
+		t.Errorf("unexpected counts len=%d deleted=%d", h.Len(), h.Deleted())
+	}
+
+	compact := h.Compact()
+	if compact.Len() != 299 || compact.Deleted() != 0 {
+		t.Errorf("compact should drop tombstones, len=%d deleted=%d", compact.Len(), compact.Deleted())
+	}
+	got, _ = compact.Search(target, 1, 0)
+	if len(got) != 1 || got[0].ID != 7 {
+		t.Errorf("compacted index lost vector: %+v", got)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	vectors := randomVectors(500, 24, 4)
+	h := buildIndex(t, vectors)
+	h.Delete(3)
+
+	path := filepath.Join(t.TempDir(), "vectors.hnsw")
+	if err := h.SaveFile(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded.Len() != h.Len() || loaded.Dim() != 24 {
+		t.Fatalf("loaded index differs: len=%d dim=%d", loaded.Len(), loaded.Dim())
+	}
+
+	for _, q := range randomVectors(10, 24, 5) {
+		a, _ := h.Search(q, 5, 0)
+		b, _ := loaded.Search(q, 5, 0)
+		if len(a) != len(b) {
+			t.Fatalf("result length differs after reload")
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("results differ after reload: %+v vs %+v", a, b)
+			}
+		}
+	}
+
+	// 加载后仍可继续写入
+	if err := loaded.Add(10000, vectors[0]); err != nil {
+		t.Errorf("add after load failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := loaded.Save(&buf); err != nil || buf.Len() == 0 {
+		t.Errorf("save to writer failed: %v", err)
+	}
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	h := New(0, 0, 0)
+	h.Add(1, []float32{1, 0, 0})
+	if err := h.Add(2, []float32{1, 0}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch on add, got %v", err)
+	}
+	if _, err := h.Search([]float32{1}, 1, 0); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch on search, got %v", err)
+	}
+}
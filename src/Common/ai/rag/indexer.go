@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Indexer 负责将文档转换为切片并入库
@@ -54,6 +55,49 @@ func NewIndexer(kb *PostgresKnowledgeBase, svc AIService, modelID uint) *Indexer
 	return idx
 }
 
+// KnowledgeBase 返回索引写入的知识库
+func (idx *Indexer) KnowledgeBase() *PostgresKnowledgeBase {
+	return idx.kb
+}
+
+// ChunkOptions 文本类文档 (.md/.txt) 的切分参数，零值字段使用默认值
+type ChunkOptions struct {
+	Splitter  string // hierarchical (默认): 按结构切分后再切出子切片; flat: 只按结构切分; recursive: 忽略结构按长度切分
+	ChunkSize int    // 切片 (hierarchical 下为父切片) 的最大字符数，默认 1000
+	ChildSize int    // hierarchical 子切片大小，默认 400
+	Overlap   int    // recursive 切片重叠，默认 ChunkSize/10
+}
+
+// SetChunkOptions 按参数重新注册 .md 与 .txt 解析器，用于调优与评测不同的切分策略
+func (idx *Indexer) SetChunkOptions(opts ChunkOptions) error {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 1000
+	}
+	if opts.ChildSize <= 0 {
+		opts.ChildSize = 400
+	}
+	if opts.Overlap <= 0 {
+		opts.Overlap = opts.ChunkSize / 10
+	}
+
+	md := ContentParser(&MarkdownParser{MinSize: 50, MaxSize: opts.ChunkSize})
+	txt := ContentParser(&TxtParser{MinSize: 20, MaxSize: opts.ChunkSize})
+	switch opts.Splitter {
+	case "", "hierarchical":
+		md = &HierarchicalParser{BaseParser: md, ChildSize: opts.ChildSize}
+		txt = &HierarchicalParser{BaseParser: txt, ChildSize: opts.ChildSize}
+	case "flat":
+	case "recursive":
+		md = &RecursiveParser{ChunkSize: opts.ChunkSize, Overlap: opts.Overlap}
+		txt = md
+	default:
+		return fmt.Errorf("unknown splitter %q", opts.Splitter)
+	}
+	idx.RegisterParser(".md", md)
+	idx.RegisterParser(".txt", txt)
+	return nil
+}
+
 func (idx *Indexer) RegisterParser(ext string, parser ContentParser) {
 	idx.parsers[strings.ToLower(ext)] = parser
 }
@@ -71,6 +115,13 @@ func (idx *Indexer) IndexFile(ctx context.Context, path string, docType string)
 
 // IndexContent 索引通用内容
 func (idx *Indexer) IndexContent(ctx context.Context, title, source string, content []byte, docType, uploaderID, targetType, targetID string) error {
+	_, err := idx.IndexContentWithProgress(ctx, title, source, content, docType, uploaderID, targetType, targetID, nil)
+	return err
+}
+
+// IndexContentWithProgress 索引通用内容并返回文档 ID，progress 在每批向量生成后回调 (已完成切片数, 切片总数)。
+// 文档哈希在全部切片入库后才写入，中途失败时重试会重新索引
+func (idx *Indexer) IndexContentWithProgress(ctx context.Context, title, source string, content []byte, docType, uploaderID, targetType, targetID string, progress func(done, total int)) (uint, error) {
 	hash := fmt.Sprintf("%x", sha256.Sum256(content))
 
 	// 1. 检查文档是否已存在且哈希一致
@@ -82,26 +133,26 @@ func (idx *Indexer) IndexContent(ctx context.Context, title, source string, cont
 				idx.kb.AddDocAccess(ctx, existingDoc.ID, targetType, targetID)
 			}
 			log.Printf("[Indexer] Skip unchanged content: %s", source)
-			return nil
+			return existingDoc.ID, nil
 		}
 		// 如果哈希不一致，删除旧的切片，准备重新索引
-		idx.kb.db.Where("doc_id = ?", existingDoc.ID).Delete(&KnowledgeChunk{})
-		existingDoc.Hash = hash
+		if err := idx.kb.DeleteDocChunks(ctx, existingDoc.ID); err != nil {
+			return existingDoc.ID, err
+		}
+		existingDoc.Title = title
 		existingDoc.UploaderID = uploaderID
-		existingDoc.UpdatedAt = time.Now()
-		// 注意：Content 字段会在解析后更新
+		// 注意：Content 与 Hash 字段会在解析、入库后更新
 	} else {
 		// 不存在则创建
 		existingDoc = KnowledgeDoc{
 			Title:      title,
 			Source:     source,
 			Type:       docType,
-			Hash:       hash,
 			UploaderID: uploaderID,
 			Status:     "active",
 		}
 		if err := idx.kb.db.Create(&existingDoc).Error; err != nil {
-			return err
+			return 0, err
 		}
 		// 创建初始授权关系
 		if targetType != "" && targetID != "" {
@@ -132,37 +183,143 @@ func (idx *Indexer) IndexContent(ctx context.Context, title, source string, cont
 		fullText.WriteString("\n")
 	}
 	existingDoc.Content = fullText.String()
+	existingDoc.UpdatedAt = time.Now()
 	idx.kb.db.Save(&existingDoc)
 
-	// 3. 处理切片并生成向量 (递归保存多级索引)
+	// 3. 批量生成向量并递归保存多级索引
+	nodes := flattenChunks(chunks)
+	if err := idx.embedNodes(ctx, nodes, progress); err != nil {
+		return existingDoc.ID, err
+	}
+	for i := range nodes {
+		if err := idx.saveNode(ctx, existingDoc.ID, nodes, i, source); err != nil {
+			return existingDoc.ID, err
+		}
+	}
+
+	// 4. 全部入库后写入哈希，标记索引完成
+	if err := idx.kb.db.Model(&existingDoc).Update("hash", hash).Error; err != nil {
+		return existingDoc.ID, err
+	}
+
+	log.Printf("[Indexer] Indexed %s (%d top-level chunks, %d total)", source, len(chunks), len(nodes))
+	return existingDoc.ID, nil
+}
+
+// chunkNode 展开后的切片，parent 为父节点在列表中的下标 (-1 表示顶层)
+type chunkNode struct {
+	chunk     Chunk
+	parent    int
+	start     int
+	embedding []float32
+	id        uint
+}
+
+// flattenChunks 按先序展开多级切片，保证父节点先于子节点入库
+func flattenChunks(chunks []Chunk) []chunkNode {
+	var nodes []chunkNode
+	var walk func(c Chunk, parent, start int)
+	walk = func(c Chunk, parent, start int) {
+		nodes = append(nodes, chunkNode{chunk: c, parent: parent, start: start})
+		self := len(nodes) - 1
+		for _, child := range c.Children {
+			walk(child, self, childOffset(c.Content, child.Content, start))
+		}
+	}
+	offset := 0
 	for _, c := range chunks {
-		idx.saveChunkRecursive(ctx, existingDoc.ID, 0, c, source)
+		walk(c, -1, offset)
+		offset += utf8.RuneCountInString(c.Content) + 1 // 与 Content 拼接时的换行对齐
 	}
+	return nodes
+}
 
-	log.Printf("[Indexer] Indexed %s (%d top-level chunks)", source, len(chunks))
+const (
+	embedBatchSize = 16
+	embedRetries   = 3
+)
+
+// embedNodes 分批生成向量，单批失败时退避重试
+func (idx *Indexer) embedNodes(ctx context.Context, nodes []chunkNode, progress func(done, total int)) error {
+	batcher, _ := idx.kb.embeddingService.(BatchEmbeddingService)
+	for start := 0; start < len(nodes); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(nodes))
+		texts := make([]string, 0, end-start)
+		for _, n := range nodes[start:end] {
+			texts = append(texts, n.chunk.Content)
+		}
+
+		var vectors [][]float32
+		var err error
+		for attempt := 0; attempt < embedRetries; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Duration(attempt) * time.Second):
+				}
+			}
+			vectors, err = idx.embedBatch(ctx, batcher, texts)
+			if err == nil {
+				break
+			}
+			log.Printf("[Indexer] Embedding batch %d-%d failed (attempt %d): %v", start, end, attempt+1, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		for i, v := range vectors {
+			nodes[start+i].embedding = v
+		}
+		if progress != nil {
+			progress(end, len(nodes))
+		}
+	}
 	return nil
 }
 
-// saveChunkRecursive 递归保存切片及其子切片
-func (idx *Indexer) saveChunkRecursive(ctx context.Context, docID uint, parentID uint, chunk Chunk, source string) error {
-	// 1. 生成向量
-	// 极致优化：父节点和子节点都生成向量。检索时通常搜索子节点，但返回父节点上下文。
-	embedding, err := idx.kb.embeddingService.GenerateEmbedding(ctx, chunk.Content)
-	if err != nil {
-		log.Printf("[Indexer] Failed to generate embedding for chunk: %v", err)
-		return err
+func (idx *Indexer) embedBatch(ctx context.Context, batcher BatchEmbeddingService, texts []string) ([][]float32, error) {
+	if batcher != nil {
+		vectors, err := batcher.GenerateEmbeddings(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("embedding service returned %d vectors for %d texts", len(vectors), len(texts))
+		}
+		return vectors, nil
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, err := idx.kb.embeddingService.GenerateEmbedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// saveNode 保存单个切片，父节点 ID 取自已入库的父节点
+func (idx *Indexer) saveNode(ctx context.Context, docID uint, nodes []chunkNode, i int, source string) error {
+	node := &nodes[i]
+	var parentID uint
+	if node.parent >= 0 {
+		parentID = nodes[node.parent].id
 	}
 
-	// 2. 保存到数据库
 	dbChunk := KnowledgeChunk{
 		DocID:     docID,
 		ParentID:  parentID,
-		Content:   chunk.Content,
-		Embedding: embedding,
-		Metadata:  fmt.Sprintf(`{"title": "%s", "source": "%s"}`, chunk.Title, source),
+		Content:   node.chunk.Content,
+		Embedding: node.embedding,
+		Metadata:  fmt.Sprintf(`{"title": "%s", "source": "%s"}`, node.chunk.Title, source),
+
+		StartOffset: node.start,
+		EndOffset:   node.start + utf8.RuneCountInString(node.chunk.Content),
 	}
 
-	if len(chunk.Children) > 0 {
+	if len(node.chunk.Children) > 0 {
 		dbChunk.Type = "parent"
 	} else if parentID != 0 {
 		dbChunk.Type = "small"
@@ -173,20 +330,26 @@ func (idx *Indexer) saveChunkRecursive(ctx context.Context, docID uint, parentID
 	if err := idx.kb.db.Create(&dbChunk).Error; err != nil {
 		return err
 	}
-
-	// 3. 递归保存子节点
-	for _, child := range chunk.Children {
-		idx.saveChunkRecursive(ctx, docID, dbChunk.ID, child, source)
+	node.id = dbChunk.ID
+	if err := idx.kb.IndexVector(ctx, dbChunk.ID, node.embedding); err != nil {
+		log.Printf("[Indexer] Failed to index vector for chunk %d: %v", dbChunk.ID, err)
 	}
 
 	// --- RAG 2.0: GraphRAG Entity Extraction (仅对较大片段执行，避免碎片化提取) ---
 	if dbChunk.Type == "parent" || dbChunk.Type == "chunk" {
-		go idx.ExtractAndIndexGraph(ctx, chunk.Content, docID)
+		go idx.ExtractAndIndexGraph(ctx, node.chunk.Content, docID)
 	}
-
 	return nil
 }
 
+// childOffset 定位子切片在父切片中的字符偏移；子切片经过改写找不到时退化为父切片起点
+func childOffset(parent, child string, parentStart int) int {
+	if i := strings.Index(parent, child); i >= 0 {
+		return parentStart + utf8.RuneCountInString(parent[:i])
+	}
+	return parentStart
+}
+
 // ExtractAndIndexGraph 从文本中提取实体和关系并入库
 func (idx *Indexer) ExtractAndIndexGraph(ctx context.Context, content string, docID uint) {
 	if idx.svc == nil {
@@ -0,0 +1,125 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DirectoryConnector 同步本地目录中的文档，支持文件监听
+type DirectoryConnector struct {
+	Dir        string
+	Extensions []string // 为空时使用 DefaultExtensions
+}
+
+// DefaultExtensions 默认同步的文本文件扩展名
+var DefaultExtensions = []string{".md", ".txt"}
+
+func NewDirectoryConnector(dir string, extensions ...string) *DirectoryConnector {
+	return &DirectoryConnector{Dir: dir, Extensions: extensions}
+}
+
+func (c *DirectoryConnector) Name() string {
+	return "dir:" + c.Dir
+}
+
+func (c *DirectoryConnector) List(ctx context.Context) ([]SourceItem, error) {
+	var items []SourceItem
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !matchExtension(path, c.Extensions) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		items = append(items, SourceItem{
+			Source:  path,
+			Title:   filepath.Base(path),
+			Version: fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()),
+		})
+		return nil
+	})
+	return items, err
+}
+
+func (c *DirectoryConnector) Fetch(ctx context.Context, item SourceItem) (Document, error) {
+	content, err := os.ReadFile(item.Source)
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Content: content}, nil
+}
+
+// Watch 监听目录 (含新建的子目录) 的文件变更
+func (c *DirectoryConnector) Watch(ctx context.Context, notify func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	addTree := func(root string) {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				watcher.Add(path)
+			}
+			return nil
+		})
+	}
+	addTree(c.Dir)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					addTree(event.Name)
+					notify()
+					continue
+				}
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			// 删除或重命名的目录无法判断扩展名，一律触发同步
+			if matchExtension(event.Name, c.Extensions) || filepath.Ext(event.Name) == "" {
+				notify()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func matchExtension(path string, extensions []string) bool {
+	if len(extensions) == 0 {
+		extensions = DefaultExtensions
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitConnector 同步 Git 仓库中的文档，依赖系统安装的 git 命令。
+// 仓库浅克隆到 CacheDir，每次 List 前拉取最新提交，以 blob 哈希作为版本
+type GitConnector struct {
+	URL        string
+	Branch     string   // 为空时使用远端默认分支
+	CacheDir   string   // 本地克隆目录的父目录，默认 data/ingest/git
+	Extensions []string // 为空时使用 DefaultExtensions
+}
+
+func NewGitConnector(repoURL, branch string, extensions ...string) *GitConnector {
+	return &GitConnector{URL: repoURL, Branch: branch, Extensions: extensions}
+}
+
+func (c *GitConnector) Name() string {
+	name := "git:" + redactURL(c.URL)
+	if c.Branch != "" {
+		name += "@" + c.Branch
+	}
+	return name
+}
+
+// workDir 每个仓库与分支对应一个独立的克隆目录
+func (c *GitConnector) workDir() string {
+	base := c.CacheDir
+	if base == "" {
+		base = filepath.Join("data", "ingest", "git")
+	}
+	return filepath.Join(base, fmt.Sprintf("%x", sha256.Sum256([]byte(c.URL+"@"+c.Branch)))[:16])
+}
+
+func (c *GitConnector) git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// update 克隆或拉取仓库到最新提交
+func (c *GitConnector) update(ctx context.Context) (string, error) {
+	dir := c.workDir()
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		os.RemoveAll(dir)
+		args := []string{"clone", "--depth", "1"}
+		if c.Branch != "" {
+			args = append(args, "--branch", c.Branch)
+		}
+		if _, err := c.git(ctx, "", append(args, c.URL, dir)...); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}
+
+	ref := c.Branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := c.git(ctx, dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return "", err
+	}
+	if _, err := c.git(ctx, dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func (c *GitConnector) List(ctx context.Context) ([]SourceItem, error) {
+	dir, err := c.update(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.git(ctx, dir, "ls-tree", "-r", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := redactURL(c.URL) + "#"
+	var items []SourceItem
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// <mode> SP <type> SP <object> TAB <file>
+		meta, file, ok := strings.Cut(scanner.Text(), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[1] != "blob" || !matchExtension(file, c.Extensions) {
+			continue
+		}
+		items = append(items, SourceItem{
+			Source:  prefix + file,
+			Title:   path.Base(file),
+			Version: fields[2],
+		})
+	}
+	return items, scanner.Err()
+}
+
+func (c *GitConnector) Fetch(ctx context.Context, item SourceItem) (Document, error) {
+	file := strings.TrimPrefix(item.Source, redactURL(c.URL)+"#")
+	content, err := os.ReadFile(filepath.Join(c.workDir(), filepath.FromSlash(file)))
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Content: content}, nil
+}
+
+// redactURL 去掉地址中的用户名与密码，避免凭据写入知识库
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,334 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAPConnector 同步 IMAP 邮箱中的邮件正文。
+// 以 UIDVALIDITY 与 UID 标识邮件，邮件内容不可变，因此已入库的邮件不会重复拉取
+type IMAPConnector struct {
+	Addr        string // host:port，默认端口 993
+	Username    string
+	Password    string
+	Mailbox     string // 默认 INBOX
+	MaxMessages int    // 只同步最新的若干封，默认 500
+	PlainText   bool   // 不使用 TLS，仅用于测试或内网
+	Timeout     time.Duration
+
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+	// 当前会话选中邮箱的 UIDVALIDITY
+	uidValidity string
+}
+
+func NewIMAPConnector(addr, username, password, mailbox string) *IMAPConnector {
+	return &IMAPConnector{Addr: addr, Username: username, Password: password, Mailbox: mailbox}
+}
+
+func (c *IMAPConnector) mailbox() string {
+	if c.Mailbox == "" {
+		return "INBOX"
+	}
+	return c.Mailbox
+}
+
+func (c *IMAPConnector) Name() string {
+	return fmt.Sprintf("imap:%s@%s/%s", c.Username, c.Addr, c.mailbox())
+}
+
+func (c *IMAPConnector) sourcePrefix() string {
+	return fmt.Sprintf("imap://%s@%s/%s;UIDVALIDITY=%s/;UID=", c.Username, c.Addr, c.mailbox(), c.uidValidity)
+}
+
+func (c *IMAPConnector) List(ctx context.Context) ([]SourceItem, error) {
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+
+	lines, err := c.command("UID SEARCH ALL")
+	if err != nil {
+		return nil, err
+	}
+	var uids []string
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "* SEARCH"); ok {
+			uids = append(uids, strings.Fields(rest)...)
+		}
+	}
+
+	limit := c.MaxMessages
+	if limit <= 0 {
+		limit = 500
+	}
+	if len(uids) > limit {
+		uids = uids[len(uids)-limit:]
+	}
+
+	items := make([]SourceItem, 0, len(uids))
+	for _, uid := range uids {
+		items = append(items, SourceItem{Source: c.sourcePrefix() + uid, Title: "mail " + uid, Version: uid})
+	}
+	return items, nil
+}
+
+func (c *IMAPConnector) Fetch(ctx context.Context, item SourceItem) (Document, error) {
+	if c.conn == nil {
+		return Document{}, fmt.Errorf("imap: not connected")
+	}
+	uid := strings.TrimPrefix(item.Source, c.sourcePrefix())
+	if _, err := strconv.ParseUint(uid, 10, 32); err != nil {
+		return Document{}, fmt.Errorf("imap: invalid source %s", item.Source)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+
+	tag := c.nextTag()
+	if err := c.write(fmt.Sprintf("%s UID FETCH %s BODY.PEEK[]", tag, uid)); err != nil {
+		return Document{}, err
+	}
+	var raw []byte
+	for {
+		line, literal, err := c.readResponse()
+		if err != nil {
+			return Document{}, err
+		}
+		if literal != nil && raw == nil {
+			raw = literal
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			if !strings.HasPrefix(line, tag+" OK") {
+				return Document{}, fmt.Errorf("imap: %s", line)
+			}
+			break
+		}
+	}
+	if raw == nil {
+		return Document{}, fmt.Errorf("imap: message %s not found", uid)
+	}
+	subject, body, err := parseMail(raw)
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Title: subject, Content: []byte(body)}, nil
+}
+
+// Close 登出并关闭连接，由 Syncer 在每次同步结束时调用
+func (c *IMAPConnector) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	c.command("LOGOUT")
+	err := c.conn.Close()
+	c.conn, c.reader = nil, nil
+	return err
+}
+
+var uidValidityPattern = regexp.MustCompile(`UIDVALIDITY (\d+)`)
+
+func (c *IMAPConnector) connect(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+	addr := c.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "993")
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if c.PlainText {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(10 * timeout))
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	// 服务器问候
+	if greeting, _, err := c.readResponse(); err != nil || !strings.HasPrefix(greeting, "* OK") {
+		c.conn.Close()
+		c.conn = nil
+		return fmt.Errorf("imap: unexpected greeting %q: %v", greeting, err)
+	}
+	if _, err := c.command(fmt.Sprintf("LOGIN %s %s", quoteIMAP(c.Username), quoteIMAP(c.Password))); err != nil {
+		c.Close()
+		return err
+	}
+	lines, err := c.command("EXAMINE " + quoteIMAP(c.mailbox()))
+	if err != nil {
+		c.Close()
+		return err
+	}
+	for _, line := range lines {
+		if m := uidValidityPattern.FindStringSubmatch(line); m != nil {
+			c.uidValidity = m[1]
+		}
+	}
+	return nil
+}
+
+func (c *IMAPConnector) nextTag() string {
+	c.tag++
+	return fmt.Sprintf("A%03d", c.tag)
+}
+
+func (c *IMAPConnector) write(line string) error {
+	_, err := io.WriteString(c.conn, line+"\r\n")
+	return err
+}
+
+// command 发送命令并收集未标记的响应行，直到收到带标签的完成响应
+func (c *IMAPConnector) command(cmd string) ([]string, error) {
+	tag := c.nextTag()
+	if err := c.write(tag + " " + cmd); err != nil {
+		return nil, err
+	}
+	var lines []string
+	for {
+		line, _, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			if !strings.HasPrefix(line, tag+" OK") {
+				verb, _, _ := strings.Cut(cmd, " ")
+				return nil, fmt.Errorf("imap %s: %s", verb, strings.TrimPrefix(line, tag+" "))
+			}
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+var literalPattern = regexp.MustCompile(`\{(\d+)\}$`)
+
+// readResponse 读取一条响应，行尾为 {n} 时读取随后的 n 字节字面量，并继续读取该响应的剩余部分
+func (c *IMAPConnector) readResponse() (string, []byte, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	m := literalPattern.FindStringSubmatch(line)
+	if m == nil {
+		return line, nil, nil
+	}
+	n, _ := strconv.Atoi(m[1])
+	literal := make([]byte, n)
+	if _, err := io.ReadFull(c.reader, literal); err != nil {
+		return "", nil, err
+	}
+	rest, _, err := c.readResponse()
+	return line + rest, literal, err
+}
+
+func quoteIMAP(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// parseMail 解析邮件，返回解码后的主题与正文 (优先 text/plain，其次 text/html 转文本)
+func parseMail(raw []byte) (string, string, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return "", "", err
+	}
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	plain, htmlBody := extractParts(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	body := plain
+	if strings.TrimSpace(body) == "" && htmlBody != "" {
+		_, body = htmlToText([]byte(htmlBody))
+	}
+
+	var sb strings.Builder
+	if from := msg.Header.Get("From"); from != "" {
+		if decoded, err := dec.DecodeHeader(from); err == nil {
+			from = decoded
+		}
+		sb.WriteString("From: " + from + "\n")
+	}
+	if date := msg.Header.Get("Date"); date != "" {
+		sb.WriteString("Date: " + date + "\n")
+	}
+	if subject != "" {
+		sb.WriteString("Subject: " + subject + "\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(strings.TrimSpace(body))
+	return subject, sb.String(), nil
+}
+
+// extractParts 递归遍历 MIME 结构，返回第一个 text/plain 与 text/html 部分
+func extractParts(contentType, encoding string, body io.Reader) (string, string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var plain, htmlBody string
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			p, h := extractParts(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if plain == "" {
+				plain = p
+			}
+			if htmlBody == "" {
+				htmlBody = h
+			}
+		}
+		return plain, htmlBody
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, sitemapMaxBody))
+
+	switch mediaType {
+	case "text/plain":
+		return string(data), ""
+	case "text/html":
+		return "", string(data)
+	}
+	return "", ""
+}
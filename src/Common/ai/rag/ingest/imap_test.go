@@ -0,0 +1,104 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+const testMail = "From: =?UTF-8?B?5byg5LiJ?= <zhang@example.com>\r\n" +
+	"Subject: =?UTF-8?B?5ZGo5oql?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"=E6=9C=AC=E5=91=A8=E8=BF=9B=E5=B1=95=E9=A1=BA=E5=88=A9\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>html version</p>\r\n" +
+	"--b1--\r\n"
+
+// serveIMAP 最小化的 IMAP 服务端，只响应连接器用到的命令
+func serveIMAP(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch {
+		case strings.HasPrefix(cmd, "LOGIN"):
+			if cmd != `LOGIN "bot" "p\"w"` {
+				fmt.Fprintf(conn, "%s NO bad credentials\r\n", tag)
+				continue
+			}
+			fmt.Fprintf(conn, "%s OK logged in\r\n", tag)
+		case strings.HasPrefix(cmd, "EXAMINE"):
+			fmt.Fprintf(conn, "* 2 EXISTS\r\n* OK [UIDVALIDITY 42] ok\r\n%s OK [READ-ONLY] done\r\n", tag)
+		case cmd == "UID SEARCH ALL":
+			fmt.Fprintf(conn, "* SEARCH 7 9\r\n%s OK done\r\n", tag)
+		case strings.HasPrefix(cmd, "UID FETCH 9 "):
+			fmt.Fprintf(conn, "* 2 FETCH (UID 9 BODY[] {%d}\r\n%s)\r\n%s OK done\r\n", len(testMail), testMail, tag)
+		case cmd == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK bye\r\n", tag)
+			return
+		default:
+			fmt.Fprintf(conn, "%s BAD unknown\r\n", tag)
+		}
+	}
+}
+
+func TestIMAPConnector(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveIMAP(conn)
+		}
+	}()
+
+	conn := NewIMAPConnector(ln.Addr().String(), "bot", `p"w`, "")
+	conn.PlainText = true
+	defer conn.Close()
+
+	items, err := conn.List(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(items) != 2 || !strings.HasSuffix(items[1].Source, "/INBOX;UIDVALIDITY=42/;UID=9") {
+		t.Fatalf("items = %+v", items)
+	}
+
+	doc, err := conn.Fetch(context.Background(), items[1])
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if doc.Title != "周报" {
+		t.Fatalf("title = %q", doc.Title)
+	}
+	content := string(doc.Content)
+	if !strings.Contains(content, "From: 张三 <zhang@example.com>") || !strings.HasSuffix(content, "本周进展顺利") {
+		t.Fatalf("content = %q", content)
+	}
+
+	if _, err := conn.Fetch(context.Background(), items[0]); err == nil {
+		t.Fatalf("fetching a missing message should fail")
+	}
+}
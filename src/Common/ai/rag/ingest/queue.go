@@ -0,0 +1,425 @@
+// Package ingest 知识库异步入库：持久化的任务队列 (进度、失败重试) 与增量同步的数据源连接器。
+package ingest
+
+import (
+	"BotMatrix/common/ai/rag"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ErrJobNotFound 任务不存在
+var ErrJobNotFound = errors.New("ingest job not found")
+
+// Job 入库任务。上传内容暂存在 Payload 表 (Nexus 与 Worker 可能从同一张表领取任务，不能依赖本地文件)，
+// 成功后删除；最终失败时保留以便手动重试
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	DocID       uint       `gorm:"index" json:"doc_id"`
+	Title       string     `gorm:"size:255" json:"title"`
+	Source      string     `gorm:"size:512;index" json:"source"`
+	DocType     string     `gorm:"size:50" json:"doc_type"`
+	UploaderID  string     `gorm:"size:64;index" json:"uploader_id"`
+	TargetType  string     `gorm:"size:20" json:"target_type"`
+	TargetID    string     `gorm:"size:64" json:"target_id"`
+	Access      string     `gorm:"type:text" json:"-"`                        // 额外授权 ([]rag.AccessGrant 的 JSON)
+	Connector   string     `gorm:"size:255;index" json:"connector,omitempty"` // 来源连接器，为空表示手动上传
+	Size        int64      `json:"size"`
+	Status      string     `gorm:"size:20;index" json:"status"`
+	Progress    int        `json:"progress"` // 0-100
+	ChunksDone  int        `json:"chunks_done"`
+	ChunksTotal int        `json:"chunks_total"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	NextRunAt   time.Time  `gorm:"index" json:"next_run_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	HeartbeatAt *time.Time `gorm:"index" json:"heartbeat_at,omitempty"` // 执行中定期刷新，超过 LeaseTimeout 未刷新视为执行进程已退出
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "knowledge_ingest_jobs"
+}
+
+// Payload 任务待入库的原始内容，与任务分表存放，列表查询不会加载大字段
+type Payload struct {
+	JobID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Content []byte `gorm:"not null"`
+}
+
+func (Payload) TableName() string {
+	return "knowledge_ingest_payloads"
+}
+
+// Options 队列参数，零值字段使用默认值
+type Options struct {
+	Workers      int           // 并发任务数，默认 2
+	MaxAttempts  int           // 单个任务最多执行次数，默认 3
+	PollInterval time.Duration // 轮询待执行任务的间隔，默认 5s
+	JobTimeout   time.Duration // 单次执行超时，默认 30m
+	RetryBackoff time.Duration // 首次重试等待时间，之后逐次翻倍，默认 30s
+	LeaseTimeout time.Duration // 执行中任务的心跳超时，超时后由任意进程重新排队，默认 2m
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+	}
+	if o.JobTimeout <= 0 {
+		o.JobTimeout = 30 * time.Minute
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 30 * time.Second
+	}
+	if o.LeaseTimeout <= 0 {
+		o.LeaseTimeout = 2 * time.Minute
+	}
+	return o
+}
+
+// Queue 基于数据库的入库任务队列，实现 rag.Ingestor
+type Queue struct {
+	db      *gorm.DB
+	indexer *rag.Indexer
+	opts    Options
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewQueue(db *gorm.DB, indexer *rag.Indexer, opts Options) *Queue {
+	return &Queue{
+		db:      db,
+		indexer: indexer,
+		opts:    opts.withDefaults(),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Migrate 创建任务与同步状态表
+func (q *Queue) Migrate() error {
+	return q.db.AutoMigrate(&Job{}, &Payload{}, &SourceState{})
+}
+
+// Start 建表并启动工作协程
+func (q *Queue) Start(ctx context.Context) error {
+	if err := q.Migrate(); err != nil {
+		return err
+	}
+	q.requeueExpired()
+
+	ctx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+	return nil
+}
+
+// Stop 停止工作协程并等待执行中的任务结束
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+}
+
+// Enqueue 暂存内容并创建入库任务，返回任务 ID
+func (q *Queue) Enqueue(ctx context.Context, req rag.IngestRequest) (uint, error) {
+	return q.enqueue(ctx, req, "")
+}
+
+func (q *Queue) enqueue(ctx context.Context, req rag.IngestRequest, connector string) (uint, error) {
+	access, _ := json.Marshal(req.Access)
+	job := Job{
+		Title:       req.Title,
+		Source:      req.Source,
+		DocType:     req.DocType,
+		UploaderID:  req.UploaderID,
+		TargetType:  req.TargetType,
+		TargetID:    req.TargetID,
+		Access:      string(access),
+		Connector:   connector,
+		Size:        int64(len(req.Content)),
+		Status:      StatusPending,
+		MaxAttempts: q.opts.MaxAttempts,
+		NextRunAt:   time.Now(),
+	}
+	// 任务与内容在同一事务中写入，避免工作协程领取到尚无内容的任务
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		return tx.Create(&Payload{JobID: job.ID, Content: req.Content}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	q.notify()
+	return job.ID, nil
+}
+
+// requeueExpired 将心跳超时的执行中任务重新排队 (执行进程已退出)；其他进程仍在执行的任务不受影响
+func (q *Queue) requeueExpired() {
+	deadline := time.Now().Add(-q.opts.LeaseTimeout)
+	res := q.db.Model(&Job{}).
+		Where("status = ? AND (heartbeat_at < ? OR (heartbeat_at IS NULL AND started_at < ?))", StatusRunning, deadline, deadline).
+		Updates(map[string]any{"status": StatusPending, "next_run_at": time.Now()})
+	if res.RowsAffected > 0 {
+		log.Printf("[Ingest] Requeued %d job(s) with expired lease", res.RowsAffected)
+	}
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Get 查询任务
+func (q *Queue) Get(ctx context.Context, id uint) (*Job, error) {
+	var job Job
+	if err := q.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListFilter 任务列表过滤条件
+type ListFilter struct {
+	Status     string
+	UploaderID string
+	Connector  string
+	Limit      int
+}
+
+// List 按创建时间倒序列出任务
+func (q *Queue) List(ctx context.Context, filter ListFilter) ([]Job, error) {
+	query := q.db.WithContext(ctx).Model(&Job{}).Order("id DESC")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UploaderID != "" {
+		query = query.Where("uploader_id = ?", filter.UploaderID)
+	}
+	if filter.Connector != "" {
+		query = query.Where("connector = ?", filter.Connector)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var jobs []Job
+	err := query.Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// Retry 将失败的任务重新排队
+func (q *Queue) Retry(ctx context.Context, id uint) error {
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Status != StatusFailed {
+		return fmt.Errorf("only failed jobs can be retried, job %d is %s", id, job.Status)
+	}
+	var payloads int64
+	if err := q.db.WithContext(ctx).Model(&Payload{}).Where("job_id = ?", id).Count(&payloads).Error; err != nil {
+		return err
+	}
+	if payloads == 0 {
+		return fmt.Errorf("job payload is no longer available")
+	}
+	err = q.db.WithContext(ctx).Model(job).Updates(map[string]any{
+		"status":      StatusPending,
+		"attempts":    0,
+		"error":       "",
+		"next_run_at": time.Now(),
+	}).Error
+	if err == nil {
+		q.notify()
+	}
+	return err
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			job, err := q.claim(ctx)
+			if err != nil || job == nil {
+				break
+			}
+			q.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+			q.requeueExpired()
+		}
+	}
+}
+
+// claim 领取一个到期的待执行任务；以条件更新保证多个工作协程 (或多个进程) 不会重复领取
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var jobs []Job
+		err := q.db.WithContext(ctx).
+			Where("status = ? AND next_run_at <= ?", StatusPending, time.Now()).
+			Order("id").Limit(1).Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return nil, err
+		}
+		job := jobs[0]
+
+		now := time.Now()
+		res := q.db.WithContext(ctx).Model(&Job{}).
+			Where("id = ? AND status = ?", job.ID, StatusPending).
+			Updates(map[string]any{"status": StatusRunning, "started_at": now, "heartbeat_at": now, "attempts": job.Attempts + 1})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			job.Status = StatusRunning
+			job.StartedAt = &now
+			job.HeartbeatAt = &now
+			job.Attempts++
+			return &job, nil
+		}
+		// 被其他工作协程抢先领取，继续找下一个
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) {
+	jobCtx, cancel := context.WithTimeout(ctx, q.opts.JobTimeout)
+	defer cancel()
+	go q.heartbeat(jobCtx, job.ID)
+
+	docID, err := q.index(jobCtx, job)
+	if err != nil {
+		if ctx.Err() != nil {
+			// 进程退出导致的中断不计入失败次数，下次启动时重新执行
+			q.db.Model(job).Updates(map[string]any{"status": StatusPending, "attempts": job.Attempts - 1})
+			return
+		}
+		q.fail(job, err)
+		return
+	}
+
+	now := time.Now()
+	q.db.Model(job).Updates(map[string]any{
+		"status":      StatusSucceeded,
+		"doc_id":      docID,
+		"progress":    100,
+		"error":       "",
+		"finished_at": now,
+	})
+	q.db.Where("job_id = ?", job.ID).Delete(&Payload{})
+	log.Printf("[Ingest] Job %d indexed %s (doc %d)", job.ID, job.Source, docID)
+}
+
+// heartbeat 在任务执行期间定期刷新心跳，直到 ctx 结束
+func (q *Queue) heartbeat(ctx context.Context, id uint) {
+	ticker := time.NewTicker(q.opts.LeaseTimeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.db.Model(&Job{}).Where("id = ? AND status = ?", id, StatusRunning).Update("heartbeat_at", time.Now())
+		}
+	}
+}
+
+func (q *Queue) index(ctx context.Context, job *Job) (uint, error) {
+	var payload Payload
+	if err := q.db.WithContext(ctx).Where("job_id = ?", job.ID).First(&payload).Error; err != nil {
+		return 0, fmt.Errorf("failed to read payload: %v", err)
+	}
+	content := payload.Content
+
+	progress := func(done, total int) {
+		pct := 0
+		if total > 0 {
+			pct = done * 99 / total // 入库完成前不显示 100%
+		}
+		q.db.Model(job).Updates(map[string]any{"progress": pct, "chunks_done": done, "chunks_total": total})
+	}
+
+	docID, err := q.indexer.IndexContentWithProgress(ctx, job.Title, job.Source, content,
+		job.DocType, job.UploaderID, job.TargetType, job.TargetID, progress)
+	if err != nil {
+		return docID, err
+	}
+
+	var grants []rag.AccessGrant
+	if job.Access != "" {
+		json.Unmarshal([]byte(job.Access), &grants)
+	}
+	kb := q.indexer.KnowledgeBase()
+	for _, g := range grants {
+		if g.OwnerType == job.TargetType && g.OwnerID == job.TargetID {
+			continue
+		}
+		kb.AddDocAccess(ctx, docID, g.OwnerType, g.OwnerID)
+	}
+	return docID, nil
+}
+
+// fail 记录失败；未达到最大次数时按指数退避重新排队
+func (q *Queue) fail(job *Job, err error) {
+	updates := map[string]any{"error": err.Error()}
+	if job.Attempts < job.MaxAttempts {
+		delay := q.opts.RetryBackoff << (job.Attempts - 1)
+		updates["status"] = StatusPending
+		updates["next_run_at"] = time.Now().Add(delay)
+		log.Printf("[Ingest] Job %d failed (attempt %d/%d), retrying in %s: %v", job.ID, job.Attempts, job.MaxAttempts, delay, err)
+	} else {
+		updates["status"] = StatusFailed
+		updates["finished_at"] = time.Now()
+		log.Printf("[Ingest] Job %d failed permanently: %v", job.ID, err)
+		if job.Connector != "" {
+			// 清除同步状态，下次同步时重新入库
+			q.db.Where("connector = ? AND source = ?", job.Connector, job.Source).Delete(&SourceState{})
+		}
+	}
+	q.db.Model(job).Updates(updates)
+}
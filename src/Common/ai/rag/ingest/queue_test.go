@@ -0,0 +1,230 @@
+package ingest
+
+import (
+	"BotMatrix/common/ai/rag"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeEmbedding 按字符生成向量，failures 次调用前返回错误
+type fakeEmbedding struct {
+	mu       sync.Mutex
+	calls    int
+	failures int
+}
+
+func (f *fakeEmbedding) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("embedding service unavailable")
+	}
+	v := make([]float32, 8)
+	for i, r := range text {
+		v[i%8] += float32(r % 17)
+	}
+	v[0]++
+	return v, nil
+}
+
+func (f *fakeEmbedding) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	return f.GenerateEmbedding(ctx, query)
+}
+
+func newTestQueue(t *testing.T, es rag.EmbeddingService, opts Options) (*Queue, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	kb := rag.NewPostgresKnowledgeBase(db, es, nil, 0)
+	kb.SetVectorStoreConfig("embedded", filepath.Join(t.TempDir(), "vectors.idx"))
+	if err := kb.Setup(); err != nil {
+		t.Fatalf("setup kb: %v", err)
+	}
+
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 20 * time.Millisecond
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = 10 * time.Millisecond
+	}
+	q := NewQueue(db, rag.NewIndexer(kb, nil, 0), opts)
+	if err := q.Start(context.Background()); err != nil {
+		t.Fatalf("start queue: %v", err)
+	}
+	t.Cleanup(q.Stop)
+	return q, db
+}
+
+// waitJob 等待任务进入终态
+func waitJob(t *testing.T, q *Queue, id uint) *Job {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if job.Status == StatusSucceeded || job.Status == StatusFailed {
+			return job
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job %d did not finish", id)
+	return nil
+}
+
+func testContent() []byte {
+	var sb strings.Builder
+	for i := 0; i < 40; i++ {
+		sb.WriteString("BotMatrix 知识库异步入库测试段落，包含足够长的文本用于切分。\n\n")
+	}
+	return []byte(sb.String())
+}
+
+func TestQueueIndexesWithProgress(t *testing.T) {
+	q, db := newTestQueue(t, &fakeEmbedding{}, Options{})
+
+	id, err := q.Enqueue(context.Background(), rag.IngestRequest{
+		Title: "guide.txt", Source: "upload/guide.txt", Content: testContent(),
+		DocType: "doc", UploaderID: "u1", TargetType: "bot", TargetID: "b1",
+		Access: []rag.AccessGrant{{OwnerType: "group", OwnerID: "g1"}},
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	job := waitJob(t, q, id)
+	if job.Status != StatusSucceeded {
+		t.Fatalf("status = %s, error = %s", job.Status, job.Error)
+	}
+	if job.Progress != 100 || job.ChunksTotal == 0 || job.ChunksDone != job.ChunksTotal {
+		t.Fatalf("progress = %d (%d/%d)", job.Progress, job.ChunksDone, job.ChunksTotal)
+	}
+	if job.DocID == 0 || job.Attempts != 1 {
+		t.Fatalf("doc = %d, attempts = %d", job.DocID, job.Attempts)
+	}
+
+	var chunks int64
+	db.Model(&rag.KnowledgeChunk{}).Where("doc_id = ?", job.DocID).Count(&chunks)
+	if int(chunks) != job.ChunksTotal {
+		t.Fatalf("chunks = %d, want %d", chunks, job.ChunksTotal)
+	}
+	var grants int64
+	db.Model(&rag.KnowledgeDocAccess{}).Where("doc_id = ? AND owner_type = ? AND owner_id = ?", job.DocID, "group", "g1").Count(&grants)
+	if grants != 1 {
+		t.Fatalf("extra access grant not applied")
+	}
+	var doc rag.KnowledgeDoc
+	db.First(&doc, job.DocID)
+	if doc.Hash == "" {
+		t.Fatalf("doc hash not written after indexing")
+	}
+	var payloads int64
+	db.Model(&Payload{}).Where("job_id = ?", id).Count(&payloads)
+	if payloads != 0 {
+		t.Fatalf("payload should be removed after indexing")
+	}
+}
+
+func TestQueueRequeuesOnlyExpiredLeases(t *testing.T) {
+	q, db := newTestQueue(t, &fakeEmbedding{}, Options{LeaseTimeout: time.Minute})
+	q.Stop() // 只验证重新排队，不执行任务
+
+	stale := time.Now().Add(-2 * time.Minute)
+	fresh := time.Now()
+	tests := []struct {
+		name      string
+		started   time.Time
+		heartbeat *time.Time
+		want      string
+	}{
+		{name: "heartbeat expired", started: stale, heartbeat: &stale, want: StatusPending},
+		{name: "heartbeat alive", started: stale, heartbeat: &fresh, want: StatusRunning},
+		{name: "no heartbeat, started long ago", started: stale, want: StatusPending},
+		{name: "no heartbeat, just started", started: fresh, want: StatusRunning},
+	}
+	ids := make([]uint, len(tests))
+	for i, tt := range tests {
+		started := tt.started
+		job := Job{Title: tt.name, Status: StatusRunning, StartedAt: &started, HeartbeatAt: tt.heartbeat, NextRunAt: stale}
+		if err := db.Create(&job).Error; err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		ids[i] = job.ID
+	}
+
+	q.requeueExpired()
+	for i, tt := range tests {
+		job, err := q.Get(context.Background(), ids[i])
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if job.Status != tt.want {
+			t.Errorf("%s: status = %s, want %s", tt.name, job.Status, tt.want)
+		}
+	}
+}
+
+func TestQueueRetriesTransientFailure(t *testing.T) {
+	// 前 3 次调用失败，耗尽索引器内部的重试，任务第二次执行时成功
+	q, _ := newTestQueue(t, &fakeEmbedding{failures: 3}, Options{MaxAttempts: 3})
+
+	id, err := q.Enqueue(context.Background(), rag.IngestRequest{
+		Title: "a.txt", Source: "upload/a.txt", Content: testContent(), UploaderID: "u1",
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job := waitJob(t, q, id)
+	if job.Status != StatusSucceeded || job.Attempts != 2 {
+		t.Fatalf("status = %s, attempts = %d, error = %s", job.Status, job.Attempts, job.Error)
+	}
+}
+
+func TestQueueFailsPermanentlyAndRetries(t *testing.T) {
+	es := &fakeEmbedding{failures: 3}
+	q, _ := newTestQueue(t, es, Options{MaxAttempts: 1})
+
+	id, err := q.Enqueue(context.Background(), rag.IngestRequest{
+		Title: "b.txt", Source: "upload/b.txt", Content: testContent(), UploaderID: "u1",
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job := waitJob(t, q, id)
+	if job.Status != StatusFailed || !strings.Contains(job.Error, "unavailable") {
+		t.Fatalf("status = %s, error = %s", job.Status, job.Error)
+	}
+
+	jobs, err := q.List(context.Background(), ListFilter{Status: StatusFailed})
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("list failed jobs = %d, %v", len(jobs), err)
+	}
+
+	// 服务恢复后手动重试
+	if err := q.Retry(context.Background(), id); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	job = waitJob(t, q, id)
+	if job.Status != StatusSucceeded {
+		t.Fatalf("status after retry = %s, error = %s", job.Status, job.Error)
+	}
+	if err := q.Retry(context.Background(), id); err == nil {
+		t.Fatalf("retrying a succeeded job should fail")
+	}
+}
@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// SitemapConnector 按 sitemap.xml 抓取网站页面，支持 sitemapindex 嵌套，以 lastmod 作为版本
+type SitemapConnector struct {
+	URL      string
+	MaxPages int    // 单次同步最多抓取的页面数，默认 1000
+	Prefix   string // 只同步以此前缀开头的页面，为空时不限制
+	client   *http.Client
+}
+
+// sitemapMaxBody 单个 sitemap 或页面的最大字节数
+const sitemapMaxBody = 10 << 20
+
+func NewSitemapConnector(sitemapURL string) *SitemapConnector {
+	return &SitemapConnector{URL: sitemapURL, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (c *SitemapConnector) Name() string {
+	return "sitemap:" + c.URL
+}
+
+type sitemapDoc struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func (c *SitemapConnector) List(ctx context.Context) ([]SourceItem, error) {
+	maxPages := c.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1000
+	}
+
+	var items []SourceItem
+	seen := map[string]bool{}
+	queue := []string{c.URL}
+	for len(queue) > 0 && len(items) < maxPages {
+		loc := queue[0]
+		queue = queue[1:]
+		if seen[loc] {
+			continue
+		}
+		seen[loc] = true
+
+		body, _, err := c.get(ctx, loc)
+		if err != nil {
+			// 嵌套的 sitemap 失败时跳过，根 sitemap 失败时整体失败以免误删已入库页面
+			if loc == c.URL {
+				return nil, err
+			}
+			continue
+		}
+		var doc sitemapDoc
+		if err := xml.Unmarshal(body, &doc); err != nil {
+			if loc == c.URL {
+				return nil, fmt.Errorf("invalid sitemap: %v", err)
+			}
+			continue
+		}
+		for _, s := range doc.Sitemaps {
+			queue = append(queue, strings.TrimSpace(s.Loc))
+		}
+		for _, u := range doc.URLs {
+			page := strings.TrimSpace(u.Loc)
+			if page == "" || seen[page] || (c.Prefix != "" && !strings.HasPrefix(page, c.Prefix)) {
+				continue
+			}
+			seen[page] = true
+			items = append(items, SourceItem{Source: page, Title: page, Version: strings.TrimSpace(u.LastMod)})
+			if len(items) >= maxPages {
+				break
+			}
+		}
+	}
+	return items, nil
+}
+
+func (c *SitemapConnector) Fetch(ctx context.Context, item SourceItem) (Document, error) {
+	body, contentType, err := c.get(ctx, item.Source)
+	if err != nil {
+		return Document{}, err
+	}
+	if !strings.Contains(contentType, "html") {
+		return Document{Content: body}, nil
+	}
+	title, text := htmlToText(body)
+	return Document{Title: title, Content: []byte(text)}, nil
+}
+
+func (c *SitemapConnector) get(ctx context.Context, target string) ([]byte, string, error) {
+	if _, err := url.ParseRequestURI(target); err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", "BotMatrix-Ingest/1.0")
+	client := c.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, sitemapMaxBody))
+	return body, resp.Header.Get("Content-Type"), err
+}
+
+// htmlToText 提取页面标题与正文文本，忽略脚本、样式与导航等非正文元素
+func htmlToText(body []byte) (string, string) {
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", string(body)
+	}
+
+	var title string
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "nav", "header", "footer", "svg":
+				return
+			case "title":
+				if n.FirstChild != nil && title == "" {
+					title = strings.TrimSpace(n.FirstChild.Data)
+				}
+				return
+			}
+		}
+		if n.Type == html.TextNode {
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				sb.WriteString(text)
+				sb.WriteByte(' ')
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "div", "br", "li", "tr", "section", "article", "pre", "blockquote",
+				"h1", "h2", "h3", "h4", "h5", "h6":
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	walk(root)
+
+	lines := strings.Split(sb.String(), "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return title, strings.Join(out, "\n")
+}
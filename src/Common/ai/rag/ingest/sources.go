@@ -0,0 +1,60 @@
+package ingest
+
+import (
+	"BotMatrix/common/config"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// NewConnector 按配置创建数据源连接器
+func NewConnector(cfg config.KnowledgeSourceConfig) (Connector, error) {
+	switch cfg.Type {
+	case "directory", "dir":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("directory source requires path")
+		}
+		return NewDirectoryConnector(cfg.Path, cfg.Extensions...), nil
+	case "git":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("git source requires url")
+		}
+		return NewGitConnector(cfg.URL, cfg.Branch, cfg.Extensions...), nil
+	case "sitemap":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("sitemap source requires url")
+		}
+		conn := NewSitemapConnector(cfg.URL)
+		conn.Prefix = cfg.Prefix
+		return conn, nil
+	case "imap":
+		if cfg.Host == "" || cfg.Username == "" {
+			return nil, fmt.Errorf("imap source requires host and username")
+		}
+		return NewIMAPConnector(cfg.Host, cfg.Username, cfg.Password, cfg.Mailbox), nil
+	}
+	return nil, fmt.Errorf("unknown knowledge source type %q", cfg.Type)
+}
+
+// StartSources 为每个配置的数据源启动后台同步，配置无效的数据源记录日志后跳过
+func StartSources(ctx context.Context, queue *Queue, sources []config.KnowledgeSourceConfig) {
+	for _, src := range sources {
+		conn, err := NewConnector(src)
+		if err != nil {
+			log.Printf("[Ingest] Skip knowledge source: %v", err)
+			continue
+		}
+		interval := time.Duration(src.Interval) * time.Second
+		if interval <= 0 {
+			interval = time.Hour
+		}
+		syncer := NewSyncer(queue, conn, SyncOptions{
+			DocType:    src.DocType,
+			TargetType: src.TargetType,
+			TargetID:   src.TargetID,
+		})
+		go syncer.Run(ctx, interval, 2*time.Second)
+		log.Printf("[Ingest] Syncing %s every %s", conn.Name(), interval)
+	}
+}
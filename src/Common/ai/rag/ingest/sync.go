@@ -0,0 +1,250 @@
+package ingest
+
+import (
+	"BotMatrix/common/ai/rag"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SourceItem 数据源中的一篇文档
+type SourceItem struct {
+	Source  string // 唯一来源标识，即入库后的 KnowledgeDoc.Source
+	Title   string
+	Version string // 廉价的变更标识 (修改时间、lastmod、git blob 等)；为空时每次同步都拉取内容比较哈希
+}
+
+// Document 拉取到的文档内容，Title 非空时覆盖 SourceItem.Title
+type Document struct {
+	Title   string
+	Content []byte
+}
+
+// Connector 数据源连接器
+type Connector interface {
+	// Name 连接器的唯一名称，用于关联同步状态与入库任务
+	Name() string
+	// List 列出数据源当前的全部文档
+	List(ctx context.Context) ([]SourceItem, error)
+	// Fetch 拉取单篇文档内容
+	Fetch(ctx context.Context, item SourceItem) (Document, error)
+}
+
+// Watcher 可以主动通知变更的连接器 (如本地目录)，Watch 在 ctx 结束前阻塞
+type Watcher interface {
+	Watch(ctx context.Context, notify func()) error
+}
+
+// SourceState 数据源中每篇文档最近一次入库时的版本与内容哈希
+type SourceState struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Connector string    `gorm:"size:255;uniqueIndex:idx_source_state" json:"connector"`
+	Source    string    `gorm:"size:512;uniqueIndex:idx_source_state" json:"source"`
+	Version   string    `gorm:"size:128" json:"version"`
+	Hash      string    `gorm:"size:64" json:"hash"`
+	SyncedAt  time.Time `json:"synced_at"`
+}
+
+func (SourceState) TableName() string {
+	return "knowledge_source_states"
+}
+
+// SyncOptions 同步入库时使用的文档类型与授权对象
+type SyncOptions struct {
+	DocType    string
+	UploaderID string
+	TargetType string
+	TargetID   string
+}
+
+// SyncResult 一次同步的统计
+type SyncResult struct {
+	Queued    int `json:"queued"`
+	Unchanged int `json:"unchanged"`
+	Deleted   int `json:"deleted"`
+	Failed    int `json:"failed"`
+}
+
+// Syncer 将连接器中的文档按哈希增量同步到知识库：新增或变更的文档进入入库队列，数据源中已删除的文档从知识库移除
+type Syncer struct {
+	queue *Queue
+	conn  Connector
+	opts  SyncOptions
+}
+
+func NewSyncer(queue *Queue, conn Connector, opts SyncOptions) *Syncer {
+	if opts.DocType == "" {
+		opts.DocType = "doc"
+	}
+	if opts.UploaderID == "" {
+		opts.UploaderID = "system"
+	}
+	if opts.TargetType == "" {
+		opts.TargetType, opts.TargetID = "system", "system"
+	}
+	return &Syncer{queue: queue, conn: conn, opts: opts}
+}
+
+// SyncOnce 执行一次同步
+func (s *Syncer) SyncOnce(ctx context.Context) (SyncResult, error) {
+	var result SyncResult
+	if closer, ok := s.conn.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	items, err := s.conn.List(ctx)
+	if err != nil {
+		return result, fmt.Errorf("%s: list failed: %w", s.conn.Name(), err)
+	}
+
+	db := s.queue.db.WithContext(ctx)
+	var states []SourceState
+	if err := db.Where("connector = ?", s.conn.Name()).Find(&states).Error; err != nil {
+		return result, err
+	}
+	known := make(map[string]*SourceState, len(states))
+	for i := range states {
+		known[states[i].Source] = &states[i]
+	}
+
+	listed := make(map[string]bool, len(items))
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		listed[item.Source] = true
+
+		state := known[item.Source]
+		if state != nil && item.Version != "" && state.Version == item.Version {
+			result.Unchanged++
+			continue
+		}
+
+		doc, err := s.conn.Fetch(ctx, item)
+		if err != nil {
+			log.Printf("[Ingest] %s: fetch %s failed: %v", s.conn.Name(), item.Source, err)
+			result.Failed++
+			continue
+		}
+		hash := fmt.Sprintf("%x", sha256.Sum256(doc.Content))
+		if state != nil && state.Hash == hash {
+			db.Model(state).Updates(map[string]any{"version": item.Version, "synced_at": time.Now()})
+			result.Unchanged++
+			continue
+		}
+
+		title := item.Title
+		if doc.Title != "" {
+			title = doc.Title
+		}
+		_, err = s.queue.enqueue(ctx, rag.IngestRequest{
+			Title:      title,
+			Source:     item.Source,
+			Content:    doc.Content,
+			DocType:    s.opts.DocType,
+			UploaderID: s.opts.UploaderID,
+			TargetType: s.opts.TargetType,
+			TargetID:   s.opts.TargetID,
+		}, s.conn.Name())
+		if err != nil {
+			log.Printf("[Ingest] %s: enqueue %s failed: %v", s.conn.Name(), item.Source, err)
+			result.Failed++
+			continue
+		}
+
+		next := SourceState{Connector: s.conn.Name(), Source: item.Source, Version: item.Version, Hash: hash, SyncedAt: time.Now()}
+		if state != nil {
+			next.ID = state.ID
+		}
+		db.Save(&next)
+		result.Queued++
+	}
+
+	// 数据源中已不存在的文档从知识库删除
+	kb := s.queue.indexer.KnowledgeBase()
+	for source, state := range known {
+		if listed[source] {
+			continue
+		}
+		var doc rag.KnowledgeDoc
+		err := db.Where("source = ?", source).First(&doc).Error
+		if err == nil {
+			err = kb.DeleteDoc(ctx, doc.ID)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		if err != nil {
+			log.Printf("[Ingest] %s: delete %s failed: %v", s.conn.Name(), source, err)
+			result.Failed++
+			continue
+		}
+		db.Delete(state)
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// Run 立即同步一次，之后按 interval 周期同步；连接器实现 Watcher 时在变更通知后 (合并 debounce 内的多次通知) 立即同步
+func (s *Syncer) Run(ctx context.Context, interval, debounce time.Duration) {
+	changes := make(chan struct{}, 1)
+	if w, ok := s.conn.(Watcher); ok {
+		go func() {
+			err := w.Watch(ctx, func() {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Printf("[Ingest] %s: watch stopped: %v", s.conn.Name(), err)
+			}
+		}()
+	}
+
+	syncNow := func() {
+		result, err := s.SyncOnce(ctx)
+		if err != nil {
+			log.Printf("[Ingest] %s: sync failed: %v", s.conn.Name(), err)
+			return
+		}
+		if result.Queued+result.Deleted+result.Failed > 0 {
+			log.Printf("[Ingest] %s: synced (queued %d, deleted %d, unchanged %d, failed %d)",
+				s.conn.Name(), result.Queued, result.Deleted, result.Unchanged, result.Failed)
+		}
+	}
+
+	syncNow()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			syncNow()
+		case <-changes:
+			// 等待连续的文件变更结束后再同步
+			timer := time.NewTimer(debounce)
+		drain:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-changes:
+					timer.Reset(debounce)
+				case <-timer.C:
+					break drain
+				}
+			}
+			syncNow()
+		}
+	}
+}
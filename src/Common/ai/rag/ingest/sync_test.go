@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"BotMatrix/common/ai/rag"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitIdle 等待队列中的任务全部结束
+func waitIdle(t *testing.T, q *Queue) {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		var n int64
+		q.db.Model(&Job{}).Where("status IN ?", []string{StatusPending, StatusRunning}).Count(&n)
+		if n == 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("queue did not become idle")
+}
+
+func TestDirectorySyncDetectsChanges(t *testing.T) {
+	q, db := newTestQueue(t, &fakeEmbedding{}, Options{})
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.md", "# A\n\n"+string(testContent()))
+	write("b.txt", string(testContent()))
+	write("ignored.bin", "binary")
+
+	syncer := NewSyncer(q, NewDirectoryConnector(dir), SyncOptions{})
+	ctx := context.Background()
+
+	result, err := syncer.SyncOnce(ctx)
+	if err != nil || result.Queued != 2 {
+		t.Fatalf("first sync = %+v, %v", result, err)
+	}
+	waitIdle(t, q)
+
+	// 未变更的文件不会重复入库
+	result, err = syncer.SyncOnce(ctx)
+	if err != nil || result.Queued != 0 || result.Unchanged != 2 {
+		t.Fatalf("second sync = %+v, %v", result, err)
+	}
+
+	// 只修改时间不修改内容时按哈希判定为未变更
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "b.txt"), later, later)
+	write("a.md", "# A\n\n更新后的内容。"+string(testContent()))
+	os.Remove(filepath.Join(dir, "b.txt"))
+	write("c.txt", "新文件 "+string(testContent()))
+
+	result, err = syncer.SyncOnce(ctx)
+	if err != nil || result.Queued != 2 || result.Deleted != 1 {
+		t.Fatalf("third sync = %+v, %v", result, err)
+	}
+	waitIdle(t, q)
+
+	var docs []rag.KnowledgeDoc
+	db.Order("source").Find(&docs)
+	if len(docs) != 2 {
+		t.Fatalf("docs = %d, want 2", len(docs))
+	}
+	if !strings.HasSuffix(docs[0].Source, "a.md") || !strings.Contains(docs[0].Content, "更新后的内容") {
+		t.Fatalf("a.md not reindexed: %q", docs[0].Source)
+	}
+	if !strings.HasSuffix(docs[1].Source, "c.txt") {
+		t.Fatalf("unexpected doc %q", docs[1].Source)
+	}
+	var orphan int64
+	db.Model(&rag.KnowledgeChunk{}).Where("doc_id NOT IN (?)", db.Model(&rag.KnowledgeDoc{}).Select("id")).Count(&orphan)
+	if orphan != 0 {
+		t.Fatalf("%d chunks left behind by deleted/updated docs", orphan)
+	}
+}
+
+func TestDirectoryUnchangedContentSkipsQueue(t *testing.T) {
+	q, _ := newTestQueue(t, &fakeEmbedding{}, Options{})
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, testContent(), 0o644)
+
+	syncer := NewSyncer(q, NewDirectoryConnector(dir), SyncOptions{})
+	if _, err := syncer.SyncOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	result, err := syncer.SyncOnce(context.Background())
+	if err != nil || result.Queued != 0 || result.Unchanged != 1 {
+		t.Fatalf("touch-only sync = %+v, %v", result, err)
+	}
+}
+
+func TestSitemapConnector(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<?xml version="1.0"?><sitemapindex><sitemap><loc>` + srv.URL + `/pages.xml</loc></sitemap></sitemapindex>`))
+		case "/pages.xml":
+			w.Write([]byte(`<urlset>
+				<url><loc>` + srv.URL + `/docs/intro</loc><lastmod>2024-01-01</lastmod></url>
+				<url><loc>` + srv.URL + `/blog/post</loc></url>
+			</urlset>`))
+		case "/docs/intro":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head><title>入门</title><script>var x=1;</script></head>
+				<body><nav>菜单</nav><h1>快速开始</h1><p>安装   BotMatrix</p></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conn := NewSitemapConnector(srv.URL + "/sitemap.xml")
+	conn.Prefix = srv.URL + "/docs/"
+	items, err := conn.List(context.Background())
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(items) != 1 || items[0].Version != "2024-01-01" {
+		t.Fatalf("items = %+v", items)
+	}
+
+	doc, err := conn.Fetch(context.Background(), items[0])
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if doc.Title != "入门" || string(doc.Content) != "快速开始\n安装 BotMatrix" {
+		t.Fatalf("doc = %q / %q", doc.Title, doc.Content)
+	}
+}
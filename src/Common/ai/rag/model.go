@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // Vector 向量类型，支持 GORM 序列化
@@ -28,6 +29,18 @@ func (v Vector) Value() (driver.Value, error) {
 	return res, nil
 }
 
+// GormDBDataType 非 PostgreSQL 数据库没有 vector 类型，以文本保存 (供内置向量索引重建使用)
+func (Vector) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return ""
+	case "mysql":
+		return "longtext"
+	default:
+		return "text"
+	}
+}
+
 func (v *Vector) Scan(value interface{}) error {
 	if value == nil {
 		return nil
@@ -85,7 +98,10 @@ type KnowledgeChunk struct {
 	Embedding Vector        `gorm:"type:vector(2048)" json:"-"` // Doubao-embedding-vision (Default 2048)
 	Metadata  string        `gorm:"type:jsonb" json:"metadata"`
 	Type      string        `gorm:"size:20;index;default:'chunk'" json:"type"` // parent, chunk, small
-	CreatedAt time.Time     `json:"created_at"`
+	// 切片在 KnowledgeDoc.Content 中的起止字符偏移，用于引用定位
+	StartOffset int       `json:"start_offset"`
+	EndOffset   int       `json:"end_offset"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // TableName 指定表名
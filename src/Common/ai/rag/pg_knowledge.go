@@ -1,14 +1,15 @@
 package rag
 
 import (
+	"BotMatrix/common/ai/rag/rank"
 	"BotMatrix/common/types"
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // KnowledgeBase 知识库核心接口
@@ -23,12 +24,51 @@ type EmbeddingService interface {
 	GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error)
 }
 
-// PostgresKnowledgeBase 基于 PostgreSQL + pgvector 的知识库实现
+// BatchEmbeddingService 支持一次请求生成多条向量的服务，索引时优先使用
+type BatchEmbeddingService interface {
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Ingestor 异步入库队列，由 rag/ingest 实现
+type Ingestor interface {
+	Enqueue(ctx context.Context, req IngestRequest) (uint, error)
+}
+
+// IngestRequest 一次入库请求
+type IngestRequest struct {
+	Title      string
+	Source     string
+	Content    []byte
+	DocType    string
+	UploaderID string
+	TargetType string
+	TargetID   string
+	// Access 索引完成后额外授予的访问权限
+	Access []AccessGrant
+}
+
+// AccessGrant 文档授权对象
+type AccessGrant struct {
+	OwnerType string `json:"owner_type"`
+	OwnerID   string `json:"owner_id"`
+}
+
+// PostgresKnowledgeBase 基于关系数据库的知识库实现，向量检索由可插拔的 VectorStore 提供
+// (PostgreSQL + pgvector，或 SQLite/MySQL 下的内置 HNSW 索引)
 type PostgresKnowledgeBase struct {
 	db               *gorm.DB
 	embeddingService EmbeddingService
 	aiSvc            types.AIService // 用于 Query Refinement 等高级功能
 	aiModelID        uint            // 用于 AI 服务的模型 ID
+
+	vectors    VectorStore
+	vectorOnce sync.Once
+	vectorKind string // auto, pgvector, embedded
+	vectorPath string // 内置索引文件路径
+
+	reranker   Reranker
+	searchOpts SearchOptions
+	ingestor   Ingestor
 }
 
 func NewPostgresKnowledgeBase(db *gorm.DB, es EmbeddingService, aiSvc types.AIService, aiModelID uint) *PostgresKnowledgeBase {
@@ -40,6 +80,79 @@ func NewPostgresKnowledgeBase(db *gorm.DB, es EmbeddingService, aiSvc types.AISe
 	}
 }
 
+// SetVectorStoreConfig 指定向量存储类型与内置索引路径，需在 Setup 之前调用
+func (p *PostgresKnowledgeBase) SetVectorStoreConfig(kind, path string) {
+	p.vectorKind = kind
+	p.vectorPath = path
+}
+
+// SetVectorStore 直接指定向量存储
+func (p *PostgresKnowledgeBase) SetVectorStore(vs VectorStore) {
+	p.vectors = vs
+}
+
+// VectorStore 返回当前使用的向量存储，首次调用时按配置创建；不可用时为 nil
+func (p *PostgresKnowledgeBase) VectorStore() VectorStore {
+	p.vectorOnce.Do(func() {
+		if p.vectors != nil {
+			return
+		}
+		vs, err := NewVectorStore(p.db, p.vectorKind, p.vectorPath)
+		if err != nil {
+			// 向量存储不可用时仍可使用关键词检索
+			fmt.Printf("Warning: vector store unavailable: %v. Only keyword search is enabled.\n", err)
+			return
+		}
+		p.vectors = vs
+		fmt.Printf("[RAG] Vector store: %s\n", vs.Name())
+	})
+	return p.vectors
+}
+
+// SetIngestor 设置异步入库队列
+func (p *PostgresKnowledgeBase) SetIngestor(i Ingestor) {
+	p.ingestor = i
+}
+
+// Ingestor 返回异步入库队列，未配置时为 nil (调用方应同步索引)
+func (p *PostgresKnowledgeBase) Ingestor() Ingestor {
+	return p.ingestor
+}
+
+// IndexVector 将切片向量写入向量存储
+func (p *PostgresKnowledgeBase) IndexVector(ctx context.Context, chunkID uint, vector []float32) error {
+	vs := p.VectorStore()
+	if vs == nil || len(vector) == 0 {
+		return nil
+	}
+	return vs.Upsert(ctx, chunkID, vector)
+}
+
+// DeleteVectors 从向量存储中移除切片 (切片行需由调用方删除)
+func (p *PostgresKnowledgeBase) DeleteVectors(ctx context.Context, chunkIDs []uint) error {
+	vs := p.VectorStore()
+	if vs == nil || len(chunkIDs) == 0 {
+		return nil
+	}
+	return vs.Delete(ctx, chunkIDs)
+}
+
+// ChunkIDs 返回文档的全部切片 ID
+func (p *PostgresKnowledgeBase) ChunkIDs(ctx context.Context, docID uint) []uint {
+	var ids []uint
+	p.db.WithContext(ctx).Model(&KnowledgeChunk{}).Where("doc_id = ?", docID).Pluck("id", &ids)
+	return ids
+}
+
+// DeleteDocChunks 删除文档的全部切片及其向量 (重新索引前调用)
+func (p *PostgresKnowledgeBase) DeleteDocChunks(ctx context.Context, docID uint) error {
+	ids := p.ChunkIDs(ctx, docID)
+	if err := p.db.WithContext(ctx).Where("doc_id = ?", docID).Delete(&KnowledgeChunk{}).Error; err != nil {
+		return err
+	}
+	return p.DeleteVectors(ctx, ids)
+}
+
 // Search 执行语义搜索
 func (p *PostgresKnowledgeBase) Search(ctx context.Context, query string, limit int, filter *types.SearchFilter) ([]types.DocChunk, error) {
 	return p.SearchHybrid(ctx, query, limit, filter)
@@ -68,28 +181,19 @@ func (p *PostgresKnowledgeBase) SearchHybrid(ctx context.Context, query string,
 		}
 	}
 
-	var err error
-
-	// 1. 检查是否支持向量搜索 (pgvector 扩展是否存在)
-	hasVector := false
-	if p.db.Dialector.Name() == "postgres" {
-		p.db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector')").Scan(&hasVector)
-	}
-
-	// 2. 构造基础查询 (使用 DISTINCT 避免多所有者共享导致的切片重复)
-	baseQuery := p.db.WithContext(ctx).
+	// 1. 构造权限范围 (文档状态 + knowledge_doc_access)，向量检索与关键词检索共用
+	scope := p.db.WithContext(ctx).
 		Table("knowledge_chunks").
-		Select("DISTINCT knowledge_chunks.*").
 		Joins("JOIN knowledge_docs ON knowledge_chunks.doc_id = knowledge_docs.id").
 		Joins("JOIN knowledge_doc_access ON knowledge_docs.id = knowledge_doc_access.doc_id").
 		Where("knowledge_docs.deleted_at IS NULL")
 
-	// 3. 应用过滤条件
+	// 2. 应用过滤条件
 	if filter != nil {
 		if filter.Status != "" {
-			baseQuery = baseQuery.Where("knowledge_docs.status = ?", filter.Status)
+			scope = scope.Where("knowledge_docs.status = ?", filter.Status)
 		} else {
-			baseQuery = baseQuery.Where("knowledge_docs.status = ?", "active")
+			scope = scope.Where("knowledge_docs.status = ?", "active")
 		}
 
 		// 权限过滤逻辑：系统全局知识 + 目标对象知识 (用户或群组) + 机器人自有知识
@@ -111,116 +215,84 @@ func (p *PostgresKnowledgeBase) SearchHybrid(ctx context.Context, query string,
 			ownerCond = ownerCond.Or("knowledge_doc_access.owner_type = 'bot_user' AND knowledge_doc_access.owner_id = ?", botUserID)
 		}
 
-		baseQuery = baseQuery.Where(ownerCond)
+		scope = scope.Where(ownerCond)
 	} else {
 		// 默认只搜索 active 的系统知识
-		baseQuery = baseQuery.Where("knowledge_docs.status = ? AND knowledge_doc_access.owner_type = ?", "active", "system")
+		scope = scope.Where("knowledge_docs.status = ? AND knowledge_doc_access.owner_type = ?", "active", "system")
 	}
+	scope = scope.Session(&gorm.Session{})
 
-	// 4. 执行搜索
-	// --- 向量搜索 (极致优化：优先搜索子切片但返回父上下文) ---
-	var vectorChunks []KnowledgeChunk
-	if hasVector {
-		var vector []float32
-		vector, err = p.embeddingService.GenerateQueryEmbedding(ctx, refinedQuery)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate embedding: %v", err)
-		}
-		vectorStr, _ := json.Marshal(vector)
+	opts := p.searchOptions()
+	candidates := max(limit*opts.CandidateFactor, minSearchCandidates)
 
-		// 搜索时优先搜索 small 类型切片 (通常更精准)，如果是 parent 类型则作为背景
-		err = baseQuery.Session(&gorm.Session{}).
-			Preload("Doc").
-			Order(fmt.Sprintf("embedding <=> '%s'", string(vectorStr))).
-			Limit(limit).
-			Find(&vectorChunks).Error
-		if err != nil {
-			fmt.Printf("Vector search failed, falling back to keyword: %v\n", err)
-		}
+	// 3. 多路召回：向量与关键词各取 candidates 个候选
+	pool := make(map[uint]*scoredChunk)
+	vectorIDs, err := p.vectorRecall(ctx, refinedQuery, candidates, scope, opts.MinSimilarity, pool)
+	if err != nil {
+		return nil, err
 	}
-
-	// --- 关键词搜索 (极致优化：使用 PostgreSQL 全文检索提高精度) ---
-	var keywordChunks []KnowledgeChunk
-	cleanQuery := refinedQuery
-	for _, punc := range []string{"？", "?", "！", "!", "。", ".", "，", ","} {
-		cleanQuery = strings.ReplaceAll(cleanQuery, punc, " ")
+	keywordIDs := p.keywordRecall(ctx, refinedQuery, candidates, scope, pool)
+	fmt.Printf("Search Results - Vector: %d, Keyword: %d\n", len(vectorIDs), len(keywordIDs))
+
+	// 4. 倒数排名融合 (RRF)
+	var ranked []*scoredChunk
+	for _, f := range rank.Fuse(opts.RRFK, vectorIDs, keywordIDs) {
+		c := pool[f.ID]
+		c.score = f.Score
+		ranked = append(ranked, c)
 	}
-	words := strings.Fields(cleanQuery)
-
-	if len(words) > 0 {
-		kwQuery := baseQuery.Session(&gorm.Session{}).Preload("Doc")
-		if p.db.Dialector.Name() == "postgres" {
-			// 使用 to_tsquery 进行全文检索
-			tsQuery := strings.Join(words, " & ")
-			err = kwQuery.Where("to_tsvector('simple', knowledge_chunks.content) @@ to_tsquery('simple', ?)", tsQuery).
-				Limit(limit).
-				Find(&keywordChunks).Error
-		}
 
-		if err != nil || len(keywordChunks) == 0 {
-			// 降级到 LIKE 搜索
-			queryBuilder := ""
-			args := make([]interface{}, 0)
-			for i, word := range words {
-				if i > 0 {
-					queryBuilder += " OR "
-				}
-				queryBuilder += "knowledge_chunks.content LIKE ?"
-				args = append(args, "%"+word+"%")
-			}
-			err = kwQuery.Where(queryBuilder, args...).Limit(limit).Find(&keywordChunks).Error
-		}
-	}
-
-	fmt.Printf("Search Results - Vector: %d, Keyword: %d\n", len(vectorChunks), len(keywordChunks))
-
-	// 2. 合并结果并去重，同时处理多级索引逻辑 (Parent-Child)
-	seen := make(map[uint]bool)
-	var intermediateChunks []KnowledgeChunk
-
-	// 先添加关键词匹配的
-	for _, c := range keywordChunks {
-		if !seen[c.ID] {
-			intermediateChunks = append(intermediateChunks, c)
-			seen[c.ID] = true
-		}
-	}
+	// 5. 可选的重排阶段与分数阈值
+	ranked = p.rerank(ctx, query, ranked, limit, opts)
 
-	// 再添加向量匹配的
-	for _, c := range vectorChunks {
-		if !seen[c.ID] {
-			intermediateChunks = append(intermediateChunks, c)
-			seen[c.ID] = true
-		}
+	// 6. MMR 去除近似重复的切片
+	items := make([]rank.Item, len(ranked))
+	byID := make(map[uint]*scoredChunk, len(ranked))
+	for i, c := range ranked {
+		items[i] = rank.Item{ID: c.chunk.ID, Score: c.score, Text: c.chunk.Content}
+		byID[c.chunk.ID] = c
 	}
+	selected := rank.MMR(items, limit, opts.MMRLambda, opts.DupThreshold)
 
 	// --- 极致优化：多级索引回溯 (如果搜到的是子切片，获取其父切片作为补充上下文) ---
-	var finalResults []types.DocChunk
-	for _, c := range intermediateChunks {
+	var results []types.DocChunk
+	expandedParents := make(map[uint]bool)
+	for _, item := range selected {
+		sc := byID[item.ID]
+		c := sc.chunk
 		content := c.Content
-		if c.Type == "small" && c.ParentID != 0 {
+		if c.Type == "small" && c.ParentID != 0 && !expandedParents[c.ParentID] {
 			var parent KnowledgeChunk
 			if err := p.db.First(&parent, c.ParentID).Error; err == nil {
 				// 将父切片内容作为 Context 补充，或者直接替换为父切片内容
 				// 这里采用“子切片内容在前，父切片背景在后”的策略
 				content = fmt.Sprintf("%s\n\n[背景补充]\n%s", c.Content, parent.Content)
+				expandedParents[c.ParentID] = true
 			}
 		}
 
-		finalResults = append(finalResults, types.DocChunk{
-			ID:      fmt.Sprintf("chunk_%d", c.ID),
-			Content: content,
-			Source:  c.Doc.Source,
-		})
-	}
-
-	// 截断到 limit
-	if len(finalResults) > limit {
-		finalResults = finalResults[:limit]
+		chunk := types.DocChunk{
+			ID:         fmt.Sprintf("chunk_%d", c.ID),
+			Content:    content,
+			Score:      sc.score,
+			Similarity: sc.similarity,
+			DocID:      c.DocID,
+		}
+		if c.Doc != nil {
+			chunk.Source = c.Doc.Source
+			chunk.Title = c.Doc.Title
+			chunk.Citation = &types.Citation{
+				DocID:   c.DocID,
+				ChunkID: c.ID,
+				Title:   c.Doc.Title,
+				Source:  c.Doc.Source,
+				Start:   c.StartOffset,
+				End:     c.EndOffset,
+			}
+		}
+		results = append(results, chunk)
 	}
 
-	results := finalResults
-
 	// --- RAG 2.0: GraphRAG Retrieval ---
 	entities, relations, _ := p.SearchGraph(ctx, refinedQuery, 3)
 	if len(entities) > 0 {
@@ -245,7 +317,8 @@ func (p *PostgresKnowledgeBase) SearchHybrid(ctx context.Context, query string,
 
 	// --- RAG 2.0: Self-Reflection (检索相关性自检) ---
 	// 使用并发处理提高自省效率，确保检索质量的同时不牺牲响应速度
-	if p.aiSvc != nil && len(results) > 0 {
+	// 配置了 Reranker 时相关性已由重排阶段把关，不再逐条自检
+	if p.reranker == nil && p.aiSvc != nil && len(results) > 0 {
 		fmt.Printf("[RAG 2.0] Starting Parallel Self-Reflection for %d chunks...\n", len(results))
 
 		type reflectionResult struct {
@@ -300,10 +373,22 @@ func (p *PostgresKnowledgeBase) SearchHybrid(ctx context.Context, query string,
 				filteredResults = append(filteredResults, res.chunk)
 			}
 		}
-		return filteredResults, nil
+		return numberCitations(filteredResults), nil
 	}
 
-	return results, nil
+	return numberCitations(results), nil
+}
+
+// numberCitations 按最终顺序为引用分配脚注编号
+func numberCitations(results []types.DocChunk) []types.DocChunk {
+	n := 0
+	for i := range results {
+		if results[i].Citation != nil {
+			n++
+			results[i].Citation.Index = n
+		}
+	}
+	return results
 }
 
 // SetDocStatus 设置文档状态 (启用/暂停)
@@ -311,9 +396,10 @@ func (p *PostgresKnowledgeBase) SetDocStatus(ctx context.Context, docID uint, st
 	return p.db.WithContext(ctx).Model(&KnowledgeDoc{}).Where("id = ?", docID).Update("status", status).Error
 }
 
-// DeleteDoc 删除文档及其所有切片和权限记录
+// DeleteDoc 删除文档及其所有切片、向量和权限记录
 func (p *PostgresKnowledgeBase) DeleteDoc(ctx context.Context, docID uint) error {
-	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	chunkIDs := p.ChunkIDs(ctx, docID)
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 删除权限记录
 		if err := tx.Where("doc_id = ?", docID).Delete(&KnowledgeDocAccess{}).Error; err != nil {
 			return err
@@ -328,6 +414,10 @@ func (p *PostgresKnowledgeBase) DeleteDoc(ctx context.Context, docID uint) error
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	return p.DeleteVectors(ctx, chunkIDs)
 }
 
 // GetUserDocs 获取用户或群组可见的文档列表 (基于授权关系)
@@ -427,10 +517,10 @@ func (p *PostgresKnowledgeBase) SearchGraph(ctx context.Context, query string, l
 		if hasVector {
 			vector, err := p.embeddingService.GenerateQueryEmbedding(ctx, query)
 			if err == nil {
-				vectorStr, _ := json.Marshal(vector)
 				p.db.WithContext(ctx).
 					Model(&KnowledgeEntity{}).
-					Order(fmt.Sprintf("embedding <=> '%s'", string(vectorStr))).
+					Where("embedding IS NOT NULL").
+					Order(clause.OrderBy{Expression: clause.Expr{SQL: "embedding <=> ?::vector", Vars: []interface{}{Vector(vector)}, WithoutParentheses: true}}).
 					Limit(limit).
 					Find(&entities)
 			}
@@ -464,14 +554,27 @@ func (p *PostgresKnowledgeBase) SearchGraph(ctx context.Context, query string, l
 	return entities, relations, nil
 }
 
-// Setup 初始化数据库表和扩展
+// Setup 初始化数据库表、扩展与向量存储
 func (p *PostgresKnowledgeBase) Setup() error {
 	// 仅在 PostgreSQL 下尝试创建扩展
 	if p.db.Dialector.Name() == "postgres" {
 		if err := p.db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
-			fmt.Printf("Warning: failed to create vector extension: %v. Vector search will be disabled.\n", err)
-			// 不返回错误，允许继续初始化，后续搜索会自动退化到关键词
+			fmt.Printf("Warning: failed to create vector extension: %v. Falling back to the embedded vector index.\n", err)
+			// 不返回错误，允许继续初始化，向量检索改用内置索引
 		}
 	}
-	return p.db.AutoMigrate(&KnowledgeDoc{}, &KnowledgeDocAccess{}, &KnowledgeChunk{}, &KnowledgeEntity{}, &KnowledgeRelation{})
+	if err := p.db.AutoMigrate(&KnowledgeDoc{}, &KnowledgeDocAccess{}, &KnowledgeChunk{}, &KnowledgeEntity{}, &KnowledgeRelation{}); err != nil {
+		return err
+	}
+
+	// 关键词检索使用的全文索引
+	if p.db.Dialector.Name() == "postgres" {
+		if err := p.db.Exec("CREATE INDEX IF NOT EXISTS idx_knowledge_chunks_content_tsv ON knowledge_chunks USING GIN (to_tsvector('simple', content))").Error; err != nil {
+			fmt.Printf("Warning: failed to create full-text index: %v\n", err)
+		}
+	}
+
+	// 表结构就绪后再打开向量存储 (内置索引可能需要从 embedding 列重建)
+	p.VectorStore()
+	return nil
 }
@@ -0,0 +1,176 @@
+// Package rank 提供混合检索的结果融合与多样性重排：
+// 倒数排名融合 (RRF) 与最大边际相关 (MMR) 去重。
+package rank
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultRRFK RRF 平滑常数，取论文推荐值 60
+const DefaultRRFK = 60
+
+// Scored 带分数的候选
+type Scored struct {
+	ID    uint
+	Score float64
+}
+
+// Fuse 对多路排序结果做倒数排名融合：score(d) = Σ 1/(k + rank_i(d))，rank 从 1 开始。
+// 分数相同时保持首次出现的顺序
+func Fuse(k float64, lists ...[]uint) []Scored {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	scores := make(map[uint]float64)
+	var order []uint
+	for _, list := range lists {
+		seen := make(map[uint]bool, len(list))
+		for i, id := range list {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if _, ok := scores[id]; !ok {
+				order = append(order, id)
+			}
+			scores[id] += 1 / (k + float64(i+1))
+		}
+	}
+
+	out := make([]Scored, len(order))
+	for i, id := range order {
+		out[i] = Scored{ID: id, Score: scores[id]}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+// Item MMR 的候选项。Vector 为空时以文本的字符二元组重合度衡量相似度
+type Item struct {
+	ID     uint
+	Score  float64 // 与查询的相关度，越大越相关
+	Vector []float32
+	Text   string
+}
+
+// MMR 按最大边际相关从 items 中选出至多 limit 项：
+// 每次选择 lambda*相关度 - (1-lambda)*与已选项的最大相似度 最高的候选；
+// 与已选项相似度不低于 dupThreshold 的候选视为重复直接丢弃 (dupThreshold <= 0 时不去重)
+func MMR(items []Item, limit int, lambda, dupThreshold float64) []Item {
+	if limit <= 0 || len(items) == 0 {
+		return nil
+	}
+
+	// 相关度归一化到 [0,1]，与相似度处于同一量纲
+	maxScore := 0.0
+	for _, it := range items {
+		maxScore = math.Max(maxScore, it.Score)
+	}
+	relevance := make([]float64, len(items))
+	for i, it := range items {
+		if maxScore > 0 {
+			relevance[i] = it.Score / maxScore
+		}
+	}
+
+	selected := make([]Item, 0, limit)
+	used := make([]bool, len(items))
+	maxSim := make([]float64, len(items)) // 各候选与已选项的最大相似度
+
+	for len(selected) < limit {
+		best, bestValue := -1, math.Inf(-1)
+		for i := range items {
+			if used[i] {
+				continue
+			}
+			value := lambda*relevance[i] - (1-lambda)*maxSim[i]
+			if value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		used[best] = true
+		chosen := items[best]
+		selected = append(selected, chosen)
+		for i := range items {
+			if used[i] {
+				continue
+			}
+			sim := Similarity(chosen, items[i])
+			if dupThreshold > 0 && sim >= dupThreshold {
+				used[i] = true
+				continue
+			}
+			maxSim[i] = math.Max(maxSim[i], sim)
+		}
+	}
+	return selected
+}
+
+// Similarity 两个候选的相似度：都有向量时取余弦相似度，否则取文本的字符二元组 Jaccard 系数
+func Similarity(a, b Item) float64 {
+	if len(a.Vector) > 0 && len(a.Vector) == len(b.Vector) {
+		return Cosine(a.Vector, b.Vector)
+	}
+	return TextJaccard(a.Text, b.Text)
+}
+
+// Cosine 余弦相似度，任一向量为零向量时返回 0
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// TextJaccard 以字符二元组计算 Jaccard 系数，对中英文都适用，忽略大小写、空白与标点
+func TextJaccard(a, b string) float64 {
+	sa, sb := bigrams(a), bigrams(b)
+	if len(sa) == 0 || len(sb) == 0 {
+		if string(normalizeText(a)) == string(normalizeText(b)) {
+			return 1
+		}
+		return 0
+	}
+	inter := 0
+	for g := range sa {
+		if sb[g] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(sa)+len(sb)-inter)
+}
+
+func normalizeText(s string) []rune {
+	var out []rune
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func bigrams(s string) map[string]bool {
+	runes := normalizeText(s)
+	set := make(map[string]bool, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = true
+	}
+	return set
+}
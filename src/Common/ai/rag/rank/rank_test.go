@@ -0,0 +1,70 @@
+package rank
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFuse(t *testing.T) {
+	// 2 在两路中都排第二，应超过只在一路中排第一的 1 和 3
+	got := Fuse(60, []uint{1, 2, 4}, []uint{3, 2})
+	if len(got) != 4 || got[0].ID != 2 {
+		t.Fatalf("expected id 2 first, got %+v", got)
+	}
+	want := 2.0 / 62
+	if math.Abs(got[0].Score-want) > 1e-12 {
+		t.Errorf("score = %v, want %v", got[0].Score, want)
+	}
+	// 1 与 3 同分，保持首次出现顺序
+	if got[1].ID != 1 || got[2].ID != 3 || got[3].ID != 4 {
+		t.Errorf("unexpected order %+v", got)
+	}
+
+	// 同一路中的重复只计一次
+	dup := Fuse(0, []uint{5, 5, 6})
+	if len(dup) != 2 || dup[0].Score != 1.0/61 {
+		t.Errorf("duplicates must be ignored: %+v", dup)
+	}
+}
+
+func TestMMRDropsNearDuplicates(t *testing.T) {
+	items := []Item{
+		{ID: 1, Score: 1.0, Text: "重置密码请进入设置页面点击安全选项"},
+		{ID: 2, Score: 0.9, Text: "重置密码请进入设置页面，点击安全选项。"},
+		{ID: 3, Score: 0.5, Text: "Billing questions go to the finance team"},
+	}
+	got := MMR(items, 3, 0.7, 0.9)
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Fatalf("near-identical chunk should be dropped: %+v", got)
+	}
+}
+
+func TestMMRPrefersDiversity(t *testing.T) {
+	items := []Item{
+		{ID: 1, Score: 1.0, Vector: []float32{1, 0, 0}},
+		{ID: 2, Score: 0.95, Vector: []float32{0.98, 0.2, 0}},
+		{ID: 3, Score: 0.8, Vector: []float32{0, 1, 0}},
+	}
+	got := MMR(items, 2, 0.5, 0)
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Fatalf("expected diverse pick [1 3], got %+v", got)
+	}
+
+	// lambda=1 退化为按相关度排序
+	got = MMR(items, 2, 1, 0)
+	if got[1].ID != 2 {
+		t.Errorf("lambda=1 should rank purely by relevance, got %+v", got)
+	}
+}
+
+func TestTextJaccard(t *testing.T) {
+	if s := TextJaccard("Hello, World", "hello world!"); s != 1 {
+		t.Errorf("punctuation and case should be ignored, got %v", s)
+	}
+	if s := TextJaccard("abc", "xyz"); s != 0 {
+		t.Errorf("disjoint texts should score 0, got %v", s)
+	}
+	if s := TextJaccard("a", "a"); s != 1 {
+		t.Errorf("identical single characters should score 1, got %v", s)
+	}
+}
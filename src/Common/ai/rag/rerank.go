@@ -0,0 +1,141 @@
+package rag
+
+import (
+	"BotMatrix/common/types"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reranker 对召回的候选切片重新打分，返回与 docs 一一对应、范围 [0,1] 的相关度
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []string) ([]float64, error)
+}
+
+// CrossEncoderReranker 调用 Cohere/Jina 兼容的 /rerank 接口 (bge-reranker 等 cross-encoder 模型)
+type CrossEncoderReranker struct {
+	Endpoint string // 完整的 rerank 接口地址
+	APIKey   string
+	Model    string
+	client   *http.Client
+}
+
+func NewCrossEncoderReranker(endpoint, apiKey, model string) *CrossEncoderReranker {
+	return &CrossEncoderReranker{
+		Endpoint: endpoint,
+		APIKey:   apiKey,
+		Model:    model,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	body, _ := json.Marshal(map[string]any{
+		"model":     r.Model,
+		"query":     query,
+		"documents": docs,
+		"top_n":     len(docs),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank request failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid rerank response: %v", err)
+	}
+
+	scores := make([]float64, len(docs))
+	for _, item := range result.Results {
+		if item.Index >= 0 && item.Index < len(scores) {
+			scores[item.Index] = item.RelevanceScore
+		}
+	}
+	return scores, nil
+}
+
+// LLMReranker 让对话模型一次性为所有候选打 0-10 分，适合没有部署 cross-encoder 的环境
+type LLMReranker struct {
+	svc     types.AIService
+	modelID uint
+}
+
+func NewLLMReranker(svc types.AIService, modelID uint) *LLMReranker {
+	return &LLMReranker{svc: svc, modelID: modelID}
+}
+
+// llmRerankSnippet 每个候选送入模型的最大字符数
+const llmRerankSnippet = 500
+
+var scoreArrayPattern = regexp.MustCompile(`\[[\d\s.,]*\]`)
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	var prompt strings.Builder
+	prompt.WriteString("你是一个检索结果评估专家。请评估下列每个文档片段对回答用户问题的帮助程度，给出 0-10 的整数分数。\n")
+	prompt.WriteString("只输出一个 JSON 数字数组，顺序与片段编号一致，不要有任何解释。\n")
+	prompt.WriteString(fmt.Sprintf("问题：%s\n\n", query))
+	for i, doc := range docs {
+		if runes := []rune(doc); len(runes) > llmRerankSnippet {
+			doc = string(runes[:llmRerankSnippet])
+		}
+		prompt.WriteString(fmt.Sprintf("片段 %d：\n%s\n\n", i+1, doc))
+	}
+
+	resp, err := r.svc.Chat(ctx, r.modelID, []types.Message{
+		{Role: types.RoleUser, Content: prompt.String()},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty rerank response")
+	}
+	content, _ := resp.Choices[0].Message.Content.(string)
+	return parseLLMScores(content, len(docs))
+}
+
+// parseLLMScores 从模型输出中提取分数数组并归一化到 [0,1]
+func parseLLMScores(content string, n int) ([]float64, error) {
+	match := scoreArrayPattern.FindString(content)
+	if match == "" {
+		return nil, fmt.Errorf("no score array in rerank response: %q", content)
+	}
+	fields := strings.FieldsFunc(strings.Trim(match, "[]"), func(r rune) bool { return r == ',' || r == ' ' || r == '\n' })
+	if len(fields) != n {
+		return nil, fmt.Errorf("rerank returned %d scores for %d documents", len(fields), n)
+	}
+	scores := make([]float64, n)
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rerank score %q", f)
+		}
+		scores[i] = min(max(v/10, 0), 1)
+	}
+	return scores, nil
+}
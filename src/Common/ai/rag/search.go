@@ -0,0 +1,221 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchOptions 混合检索的调优参数，零值字段使用默认值
+type SearchOptions struct {
+	CandidateFactor int     // 每路召回 limit*CandidateFactor 个候选，默认 4
+	RRFK            float64 // RRF 平滑常数，默认 60
+	MinSimilarity   float64 // 向量召回的最低余弦相似度，0 表示不限制
+	MinScore        float64 // 重排后的最低相关度 [0,1]，仅在配置了 Reranker 时生效
+	MMRLambda       float64 // MMR 中相关度的权重，默认 0.7
+	DupThreshold    float64 // 文本相似度不低于此值的切片视为重复，默认 0.8
+}
+
+const (
+	minSearchCandidates = 20
+	minRerankCandidates = 10
+)
+
+// scoredChunk 检索过程中的候选切片
+type scoredChunk struct {
+	chunk      KnowledgeChunk
+	score      float64 // RRF 融合分或重排分
+	similarity float64 // 向量余弦相似度，仅向量召回时有值
+}
+
+// SetSearchOptions 设置检索调优参数
+func (p *PostgresKnowledgeBase) SetSearchOptions(opts SearchOptions) {
+	p.searchOpts = opts
+}
+
+// SetReranker 设置重排器，nil 表示不重排
+func (p *PostgresKnowledgeBase) SetReranker(r Reranker) {
+	p.reranker = r
+}
+
+func (p *PostgresKnowledgeBase) searchOptions() SearchOptions {
+	opts := p.searchOpts
+	if opts.CandidateFactor <= 0 {
+		opts.CandidateFactor = 4
+	}
+	if opts.MMRLambda <= 0 {
+		opts.MMRLambda = 0.7
+	}
+	if opts.DupThreshold <= 0 {
+		opts.DupThreshold = 0.8
+	}
+	return opts
+}
+
+// vectorRecall 向量召回，返回按距离排序的切片 ID，切片本身写入 pool
+func (p *PostgresKnowledgeBase) vectorRecall(ctx context.Context, query string, k int, scope *gorm.DB, minSimilarity float64, pool map[uint]*scoredChunk) ([]uint, error) {
+	vs := p.VectorStore()
+	if vs == nil {
+		return nil, nil
+	}
+	vector, err := p.embeddingService.GenerateQueryEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %v", err)
+	}
+
+	hits, err := vs.Search(ctx, vector, k, scope)
+	if err != nil {
+		fmt.Printf("Vector search failed, falling back to keyword: %v\n", err)
+		return nil, nil
+	}
+
+	var ids []uint
+	similarity := make(map[uint]float64, len(hits))
+	for _, h := range hits {
+		sim := 1 - float64(h.Distance)
+		if minSimilarity > 0 && sim < minSimilarity {
+			continue
+		}
+		ids = append(ids, h.ID)
+		similarity[h.ID] = sim
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var found []KnowledgeChunk
+	if err := p.db.WithContext(ctx).Preload("Doc").Where("id IN ?", ids).Find(&found).Error; err != nil {
+		fmt.Printf("Vector search failed, falling back to keyword: %v\n", err)
+		return nil, nil
+	}
+	byID := make(map[uint]KnowledgeChunk, len(found))
+	for _, c := range found {
+		byID[c.ID] = c
+	}
+
+	// 保持向量距离顺序
+	var ordered []uint
+	for _, id := range ids {
+		if c, ok := byID[id]; ok {
+			pool[id] = &scoredChunk{chunk: c, similarity: similarity[id]}
+			ordered = append(ordered, id)
+		}
+	}
+	return ordered, nil
+}
+
+// keywordRecall 关键词召回：PostgreSQL 使用全文检索并按 ts_rank_cd 排序，
+// 其他数据库或全文检索无结果时退化为 LIKE，按命中词数排序
+func (p *PostgresKnowledgeBase) keywordRecall(ctx context.Context, query string, k int, scope *gorm.DB, pool map[uint]*scoredChunk) []uint {
+	terms := keywordTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// 以子查询限定权限范围，避免 DISTINCT 与排序表达式冲突
+	base := p.db.WithContext(ctx).Model(&KnowledgeChunk{}).Preload("Doc").
+		Where("knowledge_chunks.id IN (?)", scope.Select("knowledge_chunks.id")).
+		Session(&gorm.Session{})
+
+	var chunks []KnowledgeChunk
+	var err error
+	if p.db.Dialector.Name() == "postgres" {
+		tsQuery := strings.Join(terms, " | ")
+		err = base.Where("to_tsvector('simple', knowledge_chunks.content) @@ to_tsquery('simple', ?)", tsQuery).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "ts_rank_cd(to_tsvector('simple', knowledge_chunks.content), to_tsquery('simple', ?)) DESC",
+				Vars:               []interface{}{tsQuery},
+				WithoutParentheses: true,
+			}}).
+			Limit(k).
+			Find(&chunks).Error
+	}
+
+	if err != nil || len(chunks) == 0 {
+		// 降级到 LIKE 搜索
+		conds := make([]string, len(terms))
+		args := make([]interface{}, len(terms))
+		for i, term := range terms {
+			conds[i] = "LOWER(knowledge_chunks.content) LIKE ?"
+			args[i] = "%" + term + "%"
+		}
+		chunks = nil
+		if err := base.Where(strings.Join(conds, " OR "), args...).Limit(k * 2).Find(&chunks).Error; err != nil {
+			fmt.Printf("Keyword search failed: %v\n", err)
+			return nil
+		}
+		matched := func(c KnowledgeChunk) int {
+			content := strings.ToLower(c.Content)
+			n := 0
+			for _, term := range terms {
+				if strings.Contains(content, term) {
+					n++
+				}
+			}
+			return n
+		}
+		sort.SliceStable(chunks, func(i, j int) bool { return matched(chunks[i]) > matched(chunks[j]) })
+		if len(chunks) > k {
+			chunks = chunks[:k]
+		}
+	}
+
+	ids := make([]uint, 0, len(chunks))
+	for _, c := range chunks {
+		if _, ok := pool[c.ID]; !ok {
+			pool[c.ID] = &scoredChunk{chunk: c}
+		}
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// keywordTerms 将查询拆分为检索词，只保留字母与数字，避免 tsquery 语法错误
+func keywordTerms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool, len(fields))
+	var terms []string
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// rerank 用 Reranker 为融合后的前若干候选重新打分，过滤低于 MinScore 的结果；失败时保持融合顺序
+func (p *PostgresKnowledgeBase) rerank(ctx context.Context, query string, ranked []*scoredChunk, limit int, opts SearchOptions) []*scoredChunk {
+	if p.reranker == nil || len(ranked) == 0 {
+		return ranked
+	}
+
+	head := ranked[:min(len(ranked), max(limit*3, minRerankCandidates))]
+	docs := make([]string, len(head))
+	for i, c := range head {
+		docs[i] = c.chunk.Content
+	}
+	scores, err := p.reranker.Rerank(ctx, query, docs)
+	if err != nil || len(scores) != len(head) {
+		fmt.Printf("[RAG] Rerank failed, keeping fused order: %v\n", err)
+		return ranked
+	}
+
+	out := make([]*scoredChunk, 0, len(head))
+	for i, c := range head {
+		if scores[i] < opts.MinScore {
+			continue
+		}
+		c.score = scores[i]
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].score > out[j].score })
+	return out
+}
@@ -237,16 +237,21 @@ func (p *HierarchicalParser) Parse(ctx context.Context, content []byte) []Chunk
 // MarkdownParser Markdown 解析器实现 (增强版)
 type MarkdownParser struct {
 	MinSize int
+	MaxSize int // 超过 2*MaxSize 的章节按 MaxSize 递归切分，默认 1000
 }
 
 func (p *MarkdownParser) Parse(ctx context.Context, content []byte) []Chunk {
+	maxSize := p.MaxSize
+	if maxSize <= 0 {
+		maxSize = 1000
+	}
 	// 先按标题切分
 	chunks := SimpleMarkdownChunker(string(content), p.MinSize)
 	// 如果某个片段还是太大，递归切分
 	var finalChunks []Chunk
 	for _, c := range chunks {
-		if len(c.Content) > 2000 {
-			subTexts := RecursiveCharacterTextSplitter(c.Content, 1000, 100, nil)
+		if len(c.Content) > 2*maxSize {
+			subTexts := RecursiveCharacterTextSplitter(c.Content, maxSize, maxSize/10, nil)
 			for _, st := range subTexts {
 				finalChunks = append(finalChunks, Chunk{Content: st, Title: c.Title})
 			}
@@ -257,6 +262,22 @@ func (p *MarkdownParser) Parse(ctx context.Context, content []byte) []Chunk {
 	return finalChunks
 }
 
+// RecursiveParser 不考虑文档结构，按固定长度递归切分全文
+type RecursiveParser struct {
+	ChunkSize int
+	Overlap   int
+}
+
+func (p *RecursiveParser) Parse(ctx context.Context, content []byte) []Chunk {
+	var chunks []Chunk
+	for _, text := range RecursiveCharacterTextSplitter(string(content), p.ChunkSize, p.Overlap, nil) {
+		if strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{Content: text})
+		}
+	}
+	return chunks
+}
+
 // CodeParser 代码解析器实现
 type CodeParser struct{}
 
@@ -274,9 +295,14 @@ func (p *DefaultParser) Parse(ctx context.Context, content []byte) []Chunk {
 // TxtParser 纯文本解析器实现
 type TxtParser struct {
 	MinSize int
+	MaxSize int // 段落合并后的最大字符数，默认 1000
 }
 
 func (p *TxtParser) Parse(ctx context.Context, content []byte) []Chunk {
+	maxSize := p.MaxSize
+	if maxSize <= 0 {
+		maxSize = 1000
+	}
 	text := string(content)
 	// 按双换行符切分段落
 	paragraphs := strings.Split(text, "\n\n")
@@ -289,7 +315,7 @@ func (p *TxtParser) Parse(ctx context.Context, content []byte) []Chunk {
 			continue
 		}
 
-		if currentChunk.Len()+len(pText) > 1000 { // 超过 1000 字符强制切分
+		if currentChunk.Len()+len(pText) > maxSize { // 超过最大长度强制切分
 			if currentChunk.Len() >= p.MinSize {
 				chunks = append(chunks, Chunk{Content: currentChunk.String()})
 			}
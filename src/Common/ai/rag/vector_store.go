@@ -0,0 +1,378 @@
+package rag
+
+import (
+	"BotMatrix/common/ai/rag/hnsw"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	VectorStorePgVector = "pgvector"
+	VectorStoreEmbedded = "embedded"
+
+	// DefaultVectorStorePath 内置向量索引的默认持久化路径
+	DefaultVectorStorePath = "data/knowledge_vectors.hnsw"
+)
+
+// VectorHit 向量检索命中的切片
+type VectorHit struct {
+	ID       uint    `json:"id"`
+	Distance float32 `json:"distance"` // 余弦距离，越小越相似
+}
+
+// VectorStore 知识切片的向量存储。
+// Search 的 scope 是已应用文档状态与 knowledge_doc_access 权限过滤的 knowledge_chunks 查询，
+// 实现必须只返回 scope 范围内的切片
+type VectorStore interface {
+	Name() string
+	Upsert(ctx context.Context, id uint, vector []float32) error
+	Delete(ctx context.Context, ids []uint) error
+	Search(ctx context.Context, query []float32, k int, scope *gorm.DB) ([]VectorHit, error)
+	// Each 遍历存储中的全部向量，用于在不同实现间迁移
+	Each(ctx context.Context, fn func(id uint, vector []float32) error) error
+	Close() error
+}
+
+// NewVectorStore 按类型创建向量存储。kind 为空或 auto 时，PostgreSQL 且安装了 vector 扩展则使用 pgvector，否则使用内置索引
+func NewVectorStore(db *gorm.DB, kind, path string) (VectorStore, error) {
+	if kind == "" || kind == "auto" {
+		kind = VectorStoreEmbedded
+		if hasPgVector(db) {
+			kind = VectorStorePgVector
+		}
+	}
+
+	switch kind {
+	case VectorStorePgVector:
+		if !hasPgVector(db) {
+			return nil, fmt.Errorf("pgvector is not available on %s", db.Dialector.Name())
+		}
+		return NewPgVectorStore(db), nil
+	case VectorStoreEmbedded:
+		if path == "" {
+			path = DefaultVectorStorePath
+		}
+		return OpenEmbeddedVectorStore(db, path)
+	default:
+		return nil, fmt.Errorf("unknown vector store: %s", kind)
+	}
+}
+
+// MigrateVectors 将 src 中的全部向量写入 dst，返回迁移数量
+func MigrateVectors(ctx context.Context, src, dst VectorStore) (int, error) {
+	count := 0
+	err := src.Each(ctx, func(id uint, vector []float32) error {
+		if err := dst.Upsert(ctx, id, vector); err != nil {
+			return fmt.Errorf("chunk %d: %w", id, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func hasPgVector(db *gorm.DB) bool {
+	if db.Dialector.Name() != "postgres" {
+		return false
+	}
+	hasVector := false
+	db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector')").Scan(&hasVector)
+	return hasVector
+}
+
+// eachChunkEmbedding 分批读取 knowledge_chunks.embedding 列 (任何数据库均以文本形式保存向量)
+func eachChunkEmbedding(ctx context.Context, db *gorm.DB, fn func(id uint, vector []float32) error) error {
+	var batch []KnowledgeChunk
+	var fnErr error
+	err := db.WithContext(ctx).Model(&KnowledgeChunk{}).
+		Select("id", "embedding").
+		Where("embedding IS NOT NULL").
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, c := range batch {
+				if len(c.Embedding) == 0 {
+					continue
+				}
+				if fnErr = fn(c.ID, c.Embedding); fnErr != nil {
+					return fnErr
+				}
+			}
+			return nil
+		}).Error
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// --- pgvector ---
+
+// PgVectorStore 基于 PostgreSQL pgvector 扩展，向量保存在 knowledge_chunks.embedding 列
+type PgVectorStore struct {
+	db *gorm.DB
+}
+
+func NewPgVectorStore(db *gorm.DB) *PgVectorStore {
+	return &PgVectorStore{db: db}
+}
+
+func (s *PgVectorStore) Name() string { return VectorStorePgVector }
+
+func (s *PgVectorStore) Upsert(ctx context.Context, id uint, vector []float32) error {
+	return s.db.WithContext(ctx).Model(&KnowledgeChunk{}).Where("id = ?", id).Update("embedding", Vector(vector)).Error
+}
+
+// Delete 向量随切片行一起删除，无需单独处理
+func (s *PgVectorStore) Delete(ctx context.Context, ids []uint) error { return nil }
+
+func (s *PgVectorStore) Search(ctx context.Context, query []float32, k int, scope *gorm.DB) ([]VectorHit, error) {
+	var hits []VectorHit
+	err := scope.Session(&gorm.Session{}).WithContext(ctx).
+		Select("knowledge_chunks.id AS id, MIN(knowledge_chunks.embedding <=> ?::vector) AS distance", Vector(query)).
+		Where("knowledge_chunks.embedding IS NOT NULL").
+		Group("knowledge_chunks.id").
+		Order("distance").
+		Limit(k).
+		Scan(&hits).Error
+	return hits, err
+}
+
+func (s *PgVectorStore) Each(ctx context.Context, fn func(id uint, vector []float32) error) error {
+	return eachChunkEmbedding(ctx, s.db, fn)
+}
+
+func (s *PgVectorStore) Close() error { return nil }
+
+// --- 内置 HNSW 索引 ---
+
+// EmbeddedVectorStore 进程内 HNSW 索引，持久化到本地文件，适用于 SQLite/MySQL 等无 pgvector 的部署。
+// 写入后延迟保存，Close 时立即落盘
+type EmbeddedVectorStore struct {
+	db    *gorm.DB
+	path  string
+	mu    sync.Mutex // 保护 index 替换 (压缩) 与写入
+	index *hnsw.Index
+	dirty bool
+	timer *time.Timer
+}
+
+// embeddedSaveDelay 写入后延迟落盘，合并批量索引时的多次写入
+const embeddedSaveDelay = 2 * time.Second
+
+// OpenEmbeddedVectorStore 打开内置索引；索引文件不存在或与 knowledge_chunks.embedding 列数量不一致
+// (例如进程在延迟落盘前退出) 时从数据库重建
+func OpenEmbeddedVectorStore(db *gorm.DB, path string) (*EmbeddedVectorStore, error) {
+	s := &EmbeddedVectorStore{db: db, path: path}
+
+	index, err := hnsw.LoadFile(path)
+	switch {
+	case err == nil:
+		s.index = index
+		if db == nil {
+			return s, nil
+		}
+		var count int64
+		db.Model(&KnowledgeChunk{}).Where("embedding IS NOT NULL").Count(&count)
+		if int(count) == index.Len() {
+			return s, nil
+		}
+		log.Printf("[RAG] Embedded vector index is stale (%d indexed, %d in database), rebuilding", index.Len(), count)
+	case errors.Is(err, fs.ErrNotExist):
+		if db == nil {
+			s.index = hnsw.New(0, 0, 0)
+			return s, nil
+		}
+	default:
+		return nil, err
+	}
+
+	if err := s.rebuild(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// rebuild 从 knowledge_chunks.embedding 列重建索引并落盘
+func (s *EmbeddedVectorStore) rebuild() error {
+	s.index = hnsw.New(0, 0, 0)
+	n := 0
+	err := eachChunkEmbedding(context.Background(), s.db, func(id uint, vector []float32) error {
+		n++
+		return s.index.Add(uint64(id), vector)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rebuild vector index: %w", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	log.Printf("[RAG] Rebuilt embedded vector index from database (%d vectors)", n)
+	return s.Flush()
+}
+
+func (s *EmbeddedVectorStore) Name() string { return VectorStoreEmbedded }
+
+// Len 返回索引中的向量数量
+func (s *EmbeddedVectorStore) Len() int { return s.current().Len() }
+
+func (s *EmbeddedVectorStore) current() *hnsw.Index {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index
+}
+
+func (s *EmbeddedVectorStore) Upsert(ctx context.Context, id uint, vector []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.index.Add(uint64(id), vector); err != nil {
+		return err
+	}
+	s.scheduleSaveLocked()
+	return nil
+}
+
+func (s *EmbeddedVectorStore) Delete(ctx context.Context, ids []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for _, id := range ids {
+		if s.index.Delete(uint64(id)) {
+			changed = true
+		}
+	}
+	if changed {
+		s.scheduleSaveLocked()
+	}
+	return nil
+}
+
+// embeddedMaxCandidates 单次检索最多从索引取出的候选数量；scope 过滤掉的切片过多时宁可少返回结果，也不扫描整个索引
+const embeddedMaxCandidates = 4096
+
+// embeddedScopeBatch 每次 IN 查询携带的 ID 数量，远低于 SQLite 等数据库的绑定参数上限
+const embeddedScopeBatch = 500
+
+// Search 先在索引中多取候选，再用 scope 过滤出有权限的切片；过滤后不足 k 个时扩大候选范围重试，
+// 候选数量以 embeddedMaxCandidates 为上限，每轮只查询新出现的候选
+func (s *EmbeddedVectorStore) Search(ctx context.Context, query []float32, k int, scope *gorm.DB) ([]VectorHit, error) {
+	index := s.current()
+	total := index.Len()
+	if total == 0 || k <= 0 {
+		return nil, nil
+	}
+	limit := total
+	if limit > embeddedMaxCandidates {
+		limit = embeddedMaxCandidates
+	}
+
+	allowed := make(map[uint]bool)
+	checked := make(map[uint]bool)
+	fetch := k * 4
+	for {
+		if fetch > limit {
+			fetch = limit
+		}
+		results, err := index.Search(query, fetch, fetch)
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []uint
+		for _, r := range results {
+			if id := uint(r.ID); !checked[id] {
+				checked[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if err := s.filterScope(ctx, scope, ids, allowed); err != nil {
+			return nil, err
+		}
+
+		hits := make([]VectorHit, 0, k)
+		for _, r := range results {
+			if allowed[uint(r.ID)] {
+				hits = append(hits, VectorHit{ID: uint(r.ID), Distance: r.Distance})
+				if len(hits) == k {
+					break
+				}
+			}
+		}
+		if len(hits) == k || fetch >= limit {
+			return hits, nil
+		}
+		fetch *= 4
+	}
+}
+
+// filterScope 分批查询 ids 中属于 scope 的切片，结果写入 allowed
+func (s *EmbeddedVectorStore) filterScope(ctx context.Context, scope *gorm.DB, ids []uint, allowed map[uint]bool) error {
+	for start := 0; start < len(ids); start += embeddedScopeBatch {
+		end := start + embeddedScopeBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var allowedIDs []uint
+		if err := scope.Session(&gorm.Session{}).WithContext(ctx).
+			Where("knowledge_chunks.id IN ?", ids[start:end]).
+			Pluck("knowledge_chunks.id", &allowedIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range allowedIDs {
+			allowed[id] = true
+		}
+	}
+	return nil
+}
+
+func (s *EmbeddedVectorStore) Each(ctx context.Context, fn func(id uint, vector []float32) error) error {
+	return s.current().Each(func(id uint64, vector []float32) error {
+		return fn(uint(id), vector)
+	})
+}
+
+// Flush 立即将索引写入磁盘，墓碑过多时先压缩
+func (s *EmbeddedVectorStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.index.Deleted() > s.index.Len()/4 {
+		s.index = s.index.Compact()
+	}
+	if err := s.index.SaveFile(s.path); err != nil {
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+func (s *EmbeddedVectorStore) Close() error {
+	s.mu.Lock()
+	dirty := s.dirty
+	s.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	return s.Flush()
+}
+
+// scheduleSaveLocked 标记索引已修改并安排延迟落盘，调用方需持有 s.mu
+func (s *EmbeddedVectorStore) scheduleSaveLocked() {
+	s.dirty = true
+	if s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(embeddedSaveDelay, func() {
+		if err := s.Flush(); err != nil {
+			log.Printf("[RAG] %v", err)
+		}
+	})
+}
@@ -1,14 +1,17 @@
 package ai
 
 import (
-	"BotMatrix/common/bot"
 	"BotMatrix/common/models"
+	"BotMatrix/common/tasks"
 	"BotMatrix/common/types"
-	"BotNexus/tasks"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -22,7 +25,6 @@ type MockBotManager struct {
 func (m *MockBotManager) SendBotAction(botID string, action string, params any) error {
 	p, _ := json.Marshal(params)
 	msg := fmt.Sprintf("[BOT %s] Action: %s, Params: %s", botID, action, string(p))
-	fmt.Printf(">>> %s\n", msg)
 	m.Actions = append(m.Actions, msg)
 	return nil
 }
@@ -40,18 +42,67 @@ func (m *MockBotManager) GetGroupMembers(botID string, groupID string) ([]types.
 	}, nil
 }
 
-// MockClientForTimeReport 模拟专门用于整点报时的 AI 客户端
-type MockClientForTimeReport struct{}
+func (m *MockBotManager) lastMessage() string {
+	if len(m.Actions) == 0 {
+		return ""
+	}
+	return m.Actions[len(m.Actions)-1]
+}
 
-func (m *MockClientForTimeReport) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatStreamResponse, error) {
-	return nil, nil
+// draftExecutor 模拟 Nexus 的草稿执行器：create_task 草稿落地为任务
+type draftExecutor struct {
+	tm       *tasks.TaskManager
+	executed []*models.AIDraft
 }
 
-func (m *MockClientForTimeReport) CreateEmbedding(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
-	return nil, nil
+func (e *draftExecutor) ExecuteAIDraft(draft *models.AIDraft) error {
+	e.executed = append(e.executed, draft)
+	if draft.Intent != string(types.AIActionCreateTask) {
+		return nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(draft.Data), &data); err != nil {
+		return err
+	}
+	name, _ := data["name"].(string)
+	if name == "" {
+		return errors.New("草稿缺少任务名称")
+	}
+	task := &models.Task{
+		Name:          name,
+		Type:          fmt.Sprint(data["type"]),
+		ActionType:    fmt.Sprint(data["action_type"]),
+		ActionParams:  fmt.Sprint(data["action_params"]),
+		TriggerConfig: fmt.Sprint(data["trigger_config"]),
+		CreatorID:     draft.UserID,
+	}
+	return e.tm.CreateTask(task, false)
+}
+
+// newTaskTestManager 创建使用内存数据库的任务管理器 (无 Redis)
+func newTaskTestManager(t *testing.T) (*gorm.DB, *tasks.TaskManager, *MockBotManager) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.UserIdentity{}, &models.AIProviderGORM{}, &models.AIModelGORM{}, &models.AIUsageLogGORM{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	bm := &MockBotManager{}
+	return db, tasks.NewTaskManager(db, nil, bm, "test"), bm
+}
+
+// MockClientForTimeReport 模拟专门用于整点报时的 AI 客户端
+type MockClientForTimeReport struct {
+	approvalTestClient
+	lastRequest ChatRequest
 }
 
 func (m *MockClientForTimeReport) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	m.lastRequest = req
 	// 模拟 AI 识别意图并调用 create_task
 	// 假设用户说：帮我设置一个整点报时，每天 8 点到 22 点在群 100 报时
 
@@ -86,89 +137,77 @@ func (m *MockClientForTimeReport) Chat(ctx context.Context, req ChatRequest) (*C
 }
 
 func TestTimeReportTask(t *testing.T) {
-	fmt.Println("\n===== [整点报时任务演示测试] =====")
-
 	// 1. 初始化环境
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("Failed to open database: %v", err)
-	}
-	err = db.AutoMigrate(&tasks.Task{}, &tasks.AIDraft{}, &models.AIProviderGORM{}, &models.AIModelGORM{}, &models.AIUsageLogGORM{})
-	if err != nil {
-		t.Fatalf("Failed to migrate database: %v", err)
-	}
+	db, tm, bm := newTaskTestManager(t)
 
-	bm := &MockBotManager{}
-	tm := tasks.NewTaskManager(db, nil, bm)
+	// 设置 AI 解析器：AIServiceImpl 按 BaseURL+APIKey 缓存客户端，注入模拟客户端
+	provider := models.AIProviderGORM{Name: "Test", BaseURL: "https://api.test.com", APIKey: "test-key"}
+	db.Create(&provider)
+	model := models.AIModelGORM{ProviderID: provider.ID, ModelID: "test-model", ModelName: "Test Model", IsDefault: true}
+	db.Create(&model)
 
-	// 设置 AI 解析器
-	tm.AI.Manifest = tasks.GetDefaultManifest()
-	aiSvc := NewAIService(db, nil)
-	// 注入模拟的客户端逻辑 (这里需要注意，AIServiceImpl 内部现在使用 getClient 来获取客户端)
-	// 为了让 Mock 工作，我们需要模拟一个 Client 接口
+	aiSvc := NewAIService(db, &approvalTestProvider{db: db}, nil)
 	mockClient := &MockClientForTimeReport{}
-	// 由于 AIServiceImpl 现在使用 BaseURL+APIKey 的哈希作为缓存，
-	// 我们需要确保 Provider 存在并匹配
-	provider := models.AIProviderGORM{ID: 1, Name: "Test", BaseURL: "https://api.test.com", APIKey: "test-key"}
-	db.Create(&provider)
-	cacheKey := "https://api.test.com|test-key"
-	aiSvc.clientsByConfig[cacheKey] = mockClient
+	aiSvc.clientsByConfig["https://api.test.com|test-key"] = mockClient
 	tm.AI.SetAIService(aiSvc)
-	db.Create(&models.AIModelGORM{
-		ID:         1,
-		ProviderID: 1,
-		ModelID:    "test-model",
-		ModelName:  "Test Model",
-		IsDefault:  true,
-	})
 
-	// 2. 模拟用户在群里说话
-	fmt.Println("\n[用户输入]: 帮我设置一个整点报时，每天 8 点到 22 点在群 100 报时")
-
-	// 由于 ProcessChatMessage 内部会调用 tm.AI.Parse，而 tm.AI.Parse 内部又会调用 aiSvc.Chat
-	// 我们已经在 ai_service.go 中加了打印，这里会触发打印
-	err = tm.ProcessChatMessage(context.Background(), "bot123", "100", "admin_1", "帮我设置一个整点报时，每天 8 点到 22 点在群 100 报时")
+	// 2. 用户在群里说话，AI 解析出 create_task 意图
+	input := "帮我设置一个整点报时，每天 8 点到 22 点在群 100 报时"
+	result, err := tm.AI.MatchSkillByLLM(context.Background(), input, model.ID, map[string]any{
+		"effective_group_id": "100",
+		"is_private":         false,
+		"bot_id":             "bot123",
+	})
 	if err != nil {
-		t.Fatalf("Process failed: %v", err)
+		t.Fatalf("parse failed: %v", err)
+	}
+	if result.Intent != types.AIActionCreateTask {
+		t.Fatalf("Intent = %s, want %s", result.Intent, types.AIActionCreateTask)
+	}
+	if prompt, _ := mockClient.lastRequest.Messages[0].Content.(string); !strings.Contains(prompt, "目标群组 ID: 100") || !strings.Contains(prompt, "群聊") {
+		t.Errorf("system prompt should carry the group context, got: %s", prompt)
 	}
 
-	// 3. 检查是否生成了草稿
-	var draft tasks.AIDraft
-	db.First(&draft)
-	fmt.Printf("\n[系统生成草稿]: ID=%s, Intent=%s\n", draft.DraftID, draft.Intent)
-
-	// 4. 模拟用户确认
-	confirmCmd := "#确认 " + draft.DraftID
-	fmt.Printf("\n[用户确认]: %s\n", confirmCmd)
-
-	// 设置执行器 (Manager 实现了 TaskExecutor)
-	mgr := &Manager{
-		Manager: &bot.Manager{
-			GORMDB: db,
-		},
-		TaskManager: tm,
+	// 3. 解析结果保存为待确认草稿
+	data, _ := json.Marshal(result.Data)
+	draft := models.AIDraft{
+		DraftID:    "tr" + strconv.FormatInt(time.Now().UnixNano(), 10)[:14],
+		UserID:     1,
+		GroupID:    "100",
+		Intent:     string(result.Intent),
+		Data:       string(data),
+		Status:     "pending",
+		ExpireTime: time.Now().Add(10 * time.Minute),
 	}
-	tm.Executor = mgr
+	db.Create(&draft)
 
-	err = tm.ProcessChatMessage(context.Background(), "bot123", "100", "admin_1", confirmCmd)
-	if err != nil {
+	// 4. 用户确认后由执行器落地任务
+	executor := &draftExecutor{tm: tm}
+	tm.SetExecutor(executor)
+	if err := tm.ProcessChatMessage(context.Background(), "bot123", "100", "admin_1", "#确认 "+draft.DraftID); err != nil {
 		t.Fatalf("Confirm failed: %v", err)
 	}
+	if len(executor.executed) != 1 || executor.executed[0].DraftID != draft.DraftID {
+		t.Fatalf("draft should be executed once, got %d", len(executor.executed))
+	}
 
 	// 5. 验证任务是否创建成功
-	var task tasks.Task
-	err = db.Order("id desc").First(&task).Error
-	if err != nil {
-		fmt.Printf("\n[验证失败]: 未找到创建的任务: %v\n", err)
-	} else {
-		fmt.Printf("\n[任务创建成功]:\n")
-		fmt.Printf("  ID: %d\n", task.ID)
-		fmt.Printf("  名称: %s\n", task.Name)
-		fmt.Printf("  类型: %s\n", task.Type)
-		fmt.Printf("  Cron: %s\n", task.TriggerConfig)
-		fmt.Printf("  动作: %s\n", task.ActionType)
-		fmt.Printf("  参数: %s\n", task.ActionParams)
-	}
-
-	fmt.Println("\n===== [演示结束] =====")
+	var task models.Task
+	if err := db.Order("id desc").First(&task).Error; err != nil {
+		t.Fatalf("未找到创建的任务: %v", err)
+	}
+	if task.Name != "整点报时任务" || task.Type != "cron" || !strings.Contains(task.TriggerConfig, "0 8-22 * * *") {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	if task.NextRunTime == nil {
+		t.Error("cron task should have a next run time")
+	}
+
+	db.First(&draft, draft.ID)
+	if draft.Status != "confirmed" {
+		t.Errorf("draft status = %s, want confirmed", draft.Status)
+	}
+	if msg := bm.lastMessage(); !strings.Contains(msg, "send_group_msg") || !strings.Contains(msg, fmt.Sprintf("#%d", task.ID)) {
+		t.Errorf("confirmation reply should reference the new task, got %s", msg)
+	}
 }
@@ -0,0 +1,458 @@
+package ai
+
+import (
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"BotMatrix/common/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 工具审批状态 (ToolAuditLog.Status)
+const (
+	ToolApprovalPending  = "PendingApproval"
+	ToolApprovalApproved = "Approved"
+	ToolApprovalRejected = "Rejected"
+	ToolApprovalExpired  = "Expired"
+)
+
+// 挂起运行状态 (AgentRun.Status)
+const (
+	AgentRunSuspended = "suspended"
+	AgentRunResuming  = "resuming"
+	AgentRunCompleted = "completed"
+	AgentRunFailed    = "failed"
+)
+
+const (
+	// FinishReasonPendingApproval ChatAgent 因等待工具审批而提前返回
+	FinishReasonPendingApproval = "pending_approval"
+	// DefaultToolApprovalTimeout 审批超时时间，超时后按拒绝处理
+	DefaultToolApprovalTimeout = 30 * time.Minute
+
+	riskHigh = "high"
+)
+
+var (
+	ErrAgentRunNotSuspended = errors.New("agent run is not suspended")
+	ErrApprovalPending      = errors.New("tool approval still pending")
+)
+
+// ApprovalHooks 工具审批回调，由宿主 (Worker/Nexus) 注入消息发送能力
+type ApprovalHooks struct {
+	// OnPending 运行挂起时通知审批人
+	OnPending func(run *models.AgentRun, logs []models.ToolAuditLog)
+	// OnResumed 审批完成、运行恢复后，将最终回复发回原会话
+	OnResumed func(run *models.AgentRun, content string)
+	// Timeout 审批超时时间，0 为 DefaultToolApprovalTimeout
+	Timeout time.Duration
+}
+
+// SetApprovalHooks 设置工具审批回调
+func (s *AIServiceImpl) SetApprovalHooks(hooks ApprovalHooks) {
+	s.approvalHooks = hooks
+}
+
+// NewToolApprovalEvent 构造发给审批人的待审批通知，审批人按 AuditID 审批或拒绝
+func NewToolApprovalEvent(run *models.AgentRun, logs []models.ToolAuditLog) types.ToolApprovalEvent {
+	event := types.ToolApprovalEvent{
+		Type:        "tool_approval",
+		RunID:       run.RunID,
+		Platform:    run.Platform,
+		BotID:       run.BotID,
+		UserID:      run.UserID,
+		GroupID:     run.GroupID,
+		MessageType: run.MessageType,
+		Tools:       make([]types.ToolApprovalItem, 0, len(logs)),
+		ExpireTime:  run.ExpireTime,
+	}
+	for _, l := range logs {
+		event.Tools = append(event.Tools, types.ToolApprovalItem{
+			AuditID:   l.Id,
+			ToolName:  l.ToolName,
+			Arguments: l.Arguments,
+			RiskLevel: l.RiskLevel,
+		})
+	}
+	return event
+}
+
+// agentContextKeys 挂起时需要持久化、恢复时重新注入 context 的键
+var agentContextKeys = []string{
+	"botID", "userID", "userIDNum", "orgIDNum", "sessionID", "groupID", "platform", "messageType",
+	"isDispatched", "sourceOrgID", "temperature", "allowedTools", "parentSessionID", "executionID",
+}
+
+func snapshotAgentContext(ctx context.Context) map[string]any {
+	snapshot := make(map[string]any)
+	for _, key := range agentContextKeys {
+		if v := ctx.Value(key); v != nil {
+			snapshot[key] = v
+		}
+	}
+	return snapshot
+}
+
+// restoreAgentContext 按原始类型还原 JSON 反序列化后的上下文值
+func restoreAgentContext(ctx context.Context, raw string) context.Context {
+	var snapshot map[string]any
+	json.Unmarshal([]byte(raw), &snapshot)
+	for key, v := range snapshot {
+		switch key {
+		case "userIDNum", "orgIDNum", "sourceOrgID":
+			if f, ok := v.(float64); ok {
+				v = uint(f)
+			}
+		case "temperature":
+			if f, ok := v.(float64); ok {
+				v = float32(f)
+			}
+		case "allowedTools":
+			if items, ok := v.([]any); ok {
+				names := make([]string, 0, len(items))
+				for _, item := range items {
+					if name, ok := item.(string); ok {
+						names = append(names, name)
+					}
+				}
+				v = names
+			}
+		}
+		ctx = context.WithValue(ctx, key, v)
+	}
+	return ctx
+}
+
+// toolRiskLevel 获取工具风险等级：系统能力取 Capability.RiskLevel，MCP 工具取 MCPTool.RiskLevel，B2B 跨企业调用视为高风险
+func (s *AIServiceImpl) toolRiskLevel(ctx context.Context, name string) string {
+	if s.provider != nil {
+		if manifest := s.provider.GetManifest(); manifest != nil {
+			if cap, ok := manifest.Actions[name]; ok && cap.RiskLevel != "" {
+				return cap.RiskLevel
+			}
+			if cap, ok := manifest.Triggers[name]; ok && cap.RiskLevel != "" {
+				return cap.RiskLevel
+			}
+			for _, cap := range manifest.Skills {
+				if cap.Name == name && cap.RiskLevel != "" {
+					return cap.RiskLevel
+				}
+			}
+		}
+	}
+
+	if strings.HasPrefix(name, "b2b__") {
+		return riskHigh
+	}
+
+	if s.db != nil && strings.Contains(name, "__") {
+		parts := strings.SplitN(name, "__", 2)
+		var server models.MCPServerGORM
+		if err := s.db.WithContext(ctx).Where(&models.MCPServerGORM{Name: parts[0]}).First(&server).Error; err == nil {
+			var tool models.MCPToolGORM
+			err := s.db.WithContext(ctx).Where(&models.MCPToolGORM{ServerID: server.ID, Name: parts[1]}).First(&tool).Error
+			if err == nil && tool.RiskLevel != "" {
+				return tool.RiskLevel
+			}
+		}
+	}
+	return "low"
+}
+
+// requiresApproval 高风险工具需人工审批后才能执行
+func requiresApproval(riskLevel string) bool {
+	return riskLevel == riskHigh
+}
+
+type pendingToolCall struct {
+	call ToolCall
+	risk string
+}
+
+// splitToolCalls 将工具调用分为可直接执行与需要审批两类
+func (s *AIServiceImpl) splitToolCalls(ctx context.Context, calls []ToolCall) (runnable []ToolCall, pending []pendingToolCall) {
+	if s.db == nil {
+		return calls, nil
+	}
+	for _, tc := range calls {
+		if risk := s.toolRiskLevel(ctx, tc.Function.Name); requiresApproval(risk) {
+			pending = append(pending, pendingToolCall{call: tc, risk: risk})
+		} else {
+			runnable = append(runnable, tc)
+		}
+	}
+	return runnable, pending
+}
+
+func (s *AIServiceImpl) approvalTimeout() time.Duration {
+	if s.approvalHooks.Timeout > 0 {
+		return s.approvalHooks.Timeout
+	}
+	return DefaultToolApprovalTimeout
+}
+
+// suspendAgentRun 持久化当前消息状态并为待审批的工具调用创建审计记录，返回提示用户等待审批的响应
+func (s *AIServiceImpl) suspendAgentRun(ctx context.Context, modelID uint, step int, messages []Message, tools []Tool, pending []pendingToolCall) (*ChatResponse, error) {
+	sessionID, _ := ctx.Value("sessionID").(string)
+	botID, _ := ctx.Value("botID").(string)
+	userID, _ := ctx.Value("userID").(string)
+	groupID, _ := ctx.Value("groupID").(string)
+	platform, _ := ctx.Value("platform").(string)
+	messageType, _ := ctx.Value("messageType").(string)
+
+	messagesJSON, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+	toolsJSON, _ := json.Marshal(tools)
+	contextJSON, _ := json.Marshal(snapshotAgentContext(ctx))
+
+	expire := time.Now().Add(s.approvalTimeout())
+	run := models.AgentRun{
+		RunID:       "run_" + uuid.New().String(),
+		SessionID:   sessionID,
+		BotID:       botID,
+		UserID:      userID,
+		GroupID:     groupID,
+		Platform:    platform,
+		MessageType: messageType,
+		ModelID:     modelID,
+		Step:        step,
+		Messages:    string(messagesJSON),
+		Tools:       string(toolsJSON),
+		Context:     string(contextJSON),
+		Status:      AgentRunSuspended,
+		ExpireTime:  expire,
+	}
+
+	logs := make([]models.ToolAuditLog, 0, len(pending))
+	names := make([]string, 0, len(pending))
+	for _, p := range pending {
+		logs = append(logs, models.ToolAuditLog{
+			RunID:      run.RunID,
+			SessionID:  sessionID,
+			ToolCallID: p.call.ID,
+			BotID:      botID,
+			UserID:     userID,
+			GroupID:    groupID,
+			ToolName:   p.call.Function.Name,
+			Arguments:  p.call.Function.Arguments,
+			RiskLevel:  p.risk,
+			Status:     ToolApprovalPending,
+			CreateTime: time.Now(),
+			ExpireTime: &expire,
+		})
+		names = append(names, p.call.Function.Name)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+		return tx.Create(&logs).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suspend agent run: %w", err)
+	}
+
+	clog.Info("[Agent] Run suspended for tool approval", zap.String("run", run.RunID), zap.Strings("tools", names))
+	s.SaveTrace(sessionID, botID, step, "approval_pending", strings.Join(names, ","), run.RunID)
+	if s.approvalHooks.OnPending != nil {
+		go s.approvalHooks.OnPending(&run, logs)
+	}
+
+	ids := make([]string, 0, len(logs))
+	for _, l := range logs {
+		ids = append(ids, fmt.Sprintf("#%d", l.Id))
+	}
+	content := fmt.Sprintf("以下操作需要管理员审批后才能执行：%s（审批单 %s，%d 分钟内有效）。审批完成后我会继续处理并回复您。",
+		strings.Join(names, "、"), strings.Join(ids, " "), int(s.approvalTimeout().Minutes()))
+	return &ChatResponse{
+		ID: run.RunID,
+		Choices: []types.Choice{{
+			Message:      Message{Role: RoleAssistant, Content: content},
+			FinishReason: FinishReasonPendingApproval,
+		}},
+	}, nil
+}
+
+// ResumeAgentRun 在所有待审批工具调用均已处理 (通过/拒绝/超时) 后恢复挂起的运行
+func (s *AIServiceImpl) ResumeAgentRun(ctx context.Context, runID string) (*ChatResponse, *models.AgentRun, error) {
+	now := time.Now()
+	var run models.AgentRun
+	if err := s.db.Where(&models.AgentRun{RunID: runID}).First(&run).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var logs []models.ToolAuditLog
+	if err := s.db.Where(&models.ToolAuditLog{RunID: runID}).Order(clause.OrderByColumn{Column: clause.Column{Name: "Id"}}).Find(&logs).Error; err != nil {
+		return nil, &run, err
+	}
+	for _, l := range logs {
+		if l.Status == ToolApprovalPending && now.Before(run.ExpireTime) {
+			return nil, &run, ErrApprovalPending
+		}
+	}
+
+	// 抢占运行，防止多个实例重复恢复
+	res := s.db.Model(&models.AgentRun{}).Where(&models.AgentRun{RunID: runID, Status: AgentRunSuspended}).Update("Status", AgentRunResuming)
+	if res.Error != nil {
+		return nil, &run, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, &run, ErrAgentRunNotSuspended
+	}
+	run.Status = AgentRunResuming
+
+	var messages []Message
+	if err := json.Unmarshal([]byte(run.Messages), &messages); err != nil {
+		s.finishAgentRun(&run, AgentRunFailed, err.Error())
+		return nil, &run, fmt.Errorf("corrupted run state: %w", err)
+	}
+	var tools []Tool
+	json.Unmarshal([]byte(run.Tools), &tools)
+
+	runCtx := restoreAgentContext(ctx, run.Context)
+	userIDNum, _ := runCtx.Value("userIDNum").(uint)
+	orgIDNum, _ := runCtx.Value("orgIDNum").(uint)
+
+	for _, l := range logs {
+		var result string
+		updates := map[string]any{}
+		switch l.Status {
+		case ToolApprovalApproved:
+			tc := ToolCall{ID: l.ToolCallID, Type: "function", Function: types.FunctionCall{Name: l.ToolName, Arguments: l.Arguments}}
+			res, err := s.ExecuteTool(runCtx, run.BotID, userIDNum, orgIDNum, tc)
+			if err != nil {
+				result = fmt.Sprintf("Error: %v", err)
+			} else {
+				b, _ := json.Marshal(res)
+				result = string(b)
+			}
+			updates["Result"] = result
+			updates["ExecutedAt"] = time.Now()
+		case ToolApprovalRejected:
+			result = "管理员拒绝执行该操作"
+			if l.RejectionReason != "" {
+				result += "，原因：" + l.RejectionReason
+			}
+		default:
+			result = "审批超时，操作未执行"
+			updates["Status"] = ToolApprovalExpired
+		}
+		if len(updates) > 0 {
+			s.db.Model(&models.ToolAuditLog{Id: l.Id}).Updates(updates)
+		}
+		s.SaveTrace(run.SessionID, run.BotID, run.Step, "tool_result", l.ToolName, result)
+		messages = append(messages, Message{
+			Role:       RoleTool,
+			Content:    result,
+			ToolCallID: l.ToolCallID,
+			Name:       l.ToolName,
+		})
+	}
+
+	resp, err := s.runAgentLoop(runCtx, run.ModelID, messages, tools, run.Step+1, nil)
+	if err != nil {
+		s.finishAgentRun(&run, AgentRunFailed, err.Error())
+		return nil, &run, err
+	}
+	content := ""
+	if len(resp.Choices) > 0 {
+		content, _ = resp.Choices[0].Message.Content.(string)
+	}
+	s.finishAgentRun(&run, AgentRunCompleted, content)
+	return resp, &run, nil
+}
+
+func (s *AIServiceImpl) finishAgentRun(run *models.AgentRun, status, result string) {
+	run.Status = status
+	run.Result = result
+	s.db.Model(&models.AgentRun{ID: run.ID}).Updates(map[string]any{"Status": status, "Result": result})
+}
+
+// approvalBatchSize 每批加载的待恢复运行数量
+const approvalBatchSize = 50
+
+// readyAgentRuns 按 Id 分页加载审批已全部完成或已超时的挂起运行，仍在等待审批的运行在 SQL 中即被过滤
+func (s *AIServiceImpl) readyAgentRuns(ctx context.Context, afterID uint, now time.Time) ([]models.AgentRun, error) {
+	pending := s.db.Model(&models.ToolAuditLog{}).Select("1").
+		Where(clause.Eq{Column: clause.Column{Table: "ToolAuditLog", Name: "RunId"}, Value: clause.Column{Table: "AgentRun", Name: "RunId"}}).
+		Where(&models.ToolAuditLog{Status: ToolApprovalPending})
+
+	var runs []models.AgentRun
+	err := s.db.WithContext(ctx).Where(&models.AgentRun{Status: AgentRunSuspended}).
+		Where(clause.Gt{Column: clause.Column{Name: "Id"}, Value: afterID}).
+		Where("? <= ? OR NOT EXISTS (?)", clause.Column{Name: "ExpireTime"}, now, pending).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "Id"}}).Limit(approvalBatchSize).Find(&runs).Error
+	return runs, err
+}
+
+// ProcessToolApprovals 恢复所有审批已完成或已超时的挂起运行，返回恢复的数量
+func (s *AIServiceImpl) ProcessToolApprovals(ctx context.Context) (int, error) {
+	now := time.Now()
+	resumed := 0
+	var afterID uint
+	for {
+		runs, err := s.readyAgentRuns(ctx, afterID, now)
+		if err != nil {
+			return resumed, err
+		}
+		for _, run := range runs {
+			afterID = run.ID
+			if s.resumeReadyRun(ctx, run) {
+				resumed++
+			}
+		}
+		if len(runs) < approvalBatchSize {
+			return resumed, nil
+		}
+	}
+}
+
+// resumeReadyRun 恢复单个运行，返回是否确实由本实例处理
+func (s *AIServiceImpl) resumeReadyRun(ctx context.Context, run models.AgentRun) bool {
+	resp, r, err := s.ResumeAgentRun(ctx, run.RunID)
+	if errors.Is(err, ErrApprovalPending) || errors.Is(err, ErrAgentRunNotSuspended) {
+		return false
+	}
+	if err != nil {
+		clog.Error("[Agent] Failed to resume run", zap.String("run", run.RunID), zap.Error(err))
+		return true
+	}
+
+	if s.employeeService != nil && resp.Usage.TotalTokens > 0 {
+		s.employeeService.ConsumeSalary(r.BotID, int64(resp.Usage.TotalTokens))
+	}
+	if s.approvalHooks.OnResumed != nil && r.Result != "" {
+		s.approvalHooks.OnResumed(r, r.Result)
+	}
+	return true
+}
+
+// StartApprovalWatcher 定期检查审批结果并恢复挂起的运行 (审批接口可能位于其他进程，因此以数据库状态为准)
+func (s *AIServiceImpl) StartApprovalWatcher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ProcessToolApprovals(ctx); err != nil {
+					clog.Warn("[Agent] Failed to process tool approvals", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,300 @@
+package ai
+
+import (
+	clog "BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"BotMatrix/common/types"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// approvalTestClient 第一轮请求调用工具，收到工具结果后给出最终回复
+type approvalTestClient struct {
+	mu       sync.Mutex
+	requests []ChatRequest
+}
+
+func (c *approvalTestClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != RoleTool {
+		return &ChatResponse{Choices: []types.Choice{{
+			Message: Message{Role: RoleAssistant, ToolCalls: []ToolCall{
+				{ID: "call_kick", Type: "function", Function: types.FunctionCall{Name: "kick_member", Arguments: `{"user_id":"42"}`}},
+				{ID: "call_weather", Type: "function", Function: types.FunctionCall{Name: "weather", Arguments: `{"city":"sz"}`}},
+			}},
+			FinishReason: "tool_calls",
+		}}}, nil
+	}
+
+	var results []string
+	for _, m := range req.Messages {
+		if m.Role == RoleTool {
+			results = append(results, m.Name+"="+m.Content.(string))
+		}
+	}
+	return &ChatResponse{
+		Choices: []types.Choice{{Message: Message{Role: RoleAssistant, Content: strings.Join(results, ";")}, FinishReason: "stop"}},
+		Usage:   UsageInfo{TotalTokens: 10},
+	}, nil
+}
+
+func (c *approvalTestClient) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatStreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *approvalTestClient) CreateEmbedding(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *approvalTestClient) GetEmployeeByBotID(botID string) (*models.DigitalEmployeeGORM, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *approvalTestClient) PlanTask(ctx context.Context, executionID string) error {
+	return nil
+}
+
+// approvalTestProvider 记录实际执行的技能调用
+type approvalTestProvider struct {
+	db       *gorm.DB
+	mu       sync.Mutex
+	executed []string
+}
+
+func (p *approvalTestProvider) SyncSkillCall(ctx context.Context, skillName string, params map[string]any) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.executed = append(p.executed, skillName)
+	return "ok", nil
+}
+
+func (p *approvalTestProvider) GetWorkers() []types.WorkerInfo { return nil }
+func (p *approvalTestProvider) CheckPermission(ctx context.Context, botID string, userID uint, orgID uint, skillName string) (bool, error) {
+	return true, nil
+}
+func (p *approvalTestProvider) GetGORMDB() *gorm.DB             { return p.db }
+func (p *approvalTestProvider) GetKnowledgeBase() KnowledgeBase { return nil }
+func (p *approvalTestProvider) GetManifest() *SystemManifest {
+	return &SystemManifest{Actions: map[string]Capability{
+		"kick_member": {Name: "kick_member", RiskLevel: "high"},
+		"weather":     {Name: "weather", RiskLevel: "low"},
+	}}
+}
+func (p *approvalTestProvider) IsDigitalEmployeeEnabled() bool { return false }
+
+func (p *approvalTestProvider) calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.executed...)
+}
+
+func newApprovalTestService(t *testing.T) (*AIServiceImpl, *approvalTestProvider, *approvalTestClient, uint) {
+	clog.InitDefaultLogger()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.AIProviderGORM{}, &models.AIModelGORM{}, &models.AIUsageLogGORM{}, &models.AIAgentTraceGORM{},
+		&models.BotSkillPermissionGORM{}, &models.MCPServerGORM{}, &models.MCPToolGORM{},
+		&models.ToolAuditLog{}, &models.AgentRun{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	providerRow := models.AIProviderGORM{Name: "test", BaseURL: "https://llm.test", APIKey: "key"}
+	db.Create(&providerRow)
+	model := models.AIModelGORM{ProviderID: providerRow.ID, ModelName: "test", ModelID: "test-model"}
+	db.Create(&model)
+
+	provider := &approvalTestProvider{db: db}
+	client := &approvalTestClient{}
+	s := NewAIService(db, provider, nil)
+	s.memoryService = nil
+	s.skillManager = nil // 直接经由 provider 执行技能
+	s.clientsByConfig["https://llm.test|key"] = client
+	return s, provider, client, model.ID
+}
+
+func agentTestContext() context.Context {
+	ctx := context.WithValue(context.Background(), "botID", "bot1")
+	ctx = context.WithValue(ctx, "groupID", "g1")
+	return context.WithValue(ctx, "orgIDNum", uint(7))
+}
+
+func TestHighRiskToolSuspendsAndResumesOnApproval(t *testing.T) {
+	s, provider, _, modelID := newApprovalTestService(t)
+
+	var resumed string
+	s.SetApprovalHooks(ApprovalHooks{OnResumed: func(run *models.AgentRun, content string) { resumed = content }})
+
+	resp, err := s.ChatAgent(agentTestContext(), modelID, []Message{{Role: RoleUser, Content: "kick 42"}}, nil)
+	if err != nil {
+		t.Fatalf("chat agent failed: %v", err)
+	}
+	if resp.Choices[0].FinishReason != FinishReasonPendingApproval {
+		t.Fatalf("expected run to be suspended, got %q", resp.Choices[0].FinishReason)
+	}
+	if calls := provider.calls(); len(calls) != 1 || calls[0] != "weather" {
+		t.Fatalf("only the low risk tool may run before approval, got %v", calls)
+	}
+
+	var entry models.ToolAuditLog
+	if err := s.db.Where(&models.ToolAuditLog{ToolName: "kick_member"}).First(&entry).Error; err != nil {
+		t.Fatalf("pending audit log not created: %v", err)
+	}
+	if entry.Status != ToolApprovalPending || entry.RunID == "" || entry.GroupID != "g1" {
+		t.Fatalf("unexpected audit log: %+v", entry)
+	}
+
+	// 审批前不会恢复
+	if n, _ := s.ProcessToolApprovals(context.Background()); n != 0 {
+		t.Fatalf("run resumed before approval")
+	}
+
+	s.db.Model(&entry).Updates(map[string]any{"Status": ToolApprovalApproved, "ApprovedBy": "admin"})
+	if n, err := s.ProcessToolApprovals(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected one resumed run, got %d (%v)", n, err)
+	}
+
+	if calls := provider.calls(); len(calls) != 2 || calls[1] != "kick_member" {
+		t.Errorf("approved tool should be executed on resume, got %v", calls)
+	}
+	if !strings.Contains(resumed, "kick_member=") || !strings.Contains(resumed, "weather=") {
+		t.Errorf("final answer should see both tool results, got %q", resumed)
+	}
+
+	var run models.AgentRun
+	s.db.Where(&models.AgentRun{RunID: entry.RunID}).First(&run)
+	if run.Status != AgentRunCompleted {
+		t.Errorf("run should be completed, got %s", run.Status)
+	}
+	s.db.First(&entry, entry.Id)
+	if entry.ExecutedAt == nil || entry.Result == "" {
+		t.Errorf("execution result not recorded: %+v", entry)
+	}
+
+	// 已完成的运行不会被重复恢复
+	if _, _, err := s.ResumeAgentRun(context.Background(), run.RunID); !errors.Is(err, ErrAgentRunNotSuspended) {
+		t.Errorf("expected ErrAgentRunNotSuspended, got %v", err)
+	}
+}
+
+func TestPendingHookNotifiesApprovers(t *testing.T) {
+	s, _, _, modelID := newApprovalTestService(t)
+
+	notified := make(chan types.ToolApprovalEvent, 1)
+	s.SetApprovalHooks(ApprovalHooks{OnPending: func(run *models.AgentRun, logs []models.ToolAuditLog) {
+		notified <- NewToolApprovalEvent(run, logs)
+	}})
+
+	if _, err := s.ChatAgent(agentTestContext(), modelID, []Message{{Role: RoleUser, Content: "kick 42"}}, nil); err != nil {
+		t.Fatalf("chat agent failed: %v", err)
+	}
+
+	var event types.ToolApprovalEvent
+	select {
+	case event = <-notified:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnPending was not called for the suspended run")
+	}
+
+	var entry models.ToolAuditLog
+	s.db.Where(&models.ToolAuditLog{ToolName: "kick_member"}).First(&entry)
+	if event.Type != "tool_approval" || event.RunID != entry.RunID || event.BotID != "bot1" || event.GroupID != "g1" || event.ExpireTime.IsZero() {
+		t.Fatalf("unexpected approval event: %+v", event)
+	}
+	if len(event.Tools) != 1 || event.Tools[0].AuditID != entry.Id || event.Tools[0].ToolName != "kick_member" || event.Tools[0].RiskLevel != "high" {
+		t.Errorf("approvers should get the pending audit log, got %+v", event.Tools)
+	}
+}
+
+func TestRejectedAndExpiredToolsAreRefused(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		expire bool
+		want   string
+	}{
+		{name: "rejected", status: ToolApprovalRejected, want: "管理员拒绝执行该操作，原因：no"},
+		{name: "expired", status: ToolApprovalPending, expire: true, want: "审批超时"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, provider, _, modelID := newApprovalTestService(t)
+			var resumed string
+			s.SetApprovalHooks(ApprovalHooks{OnResumed: func(run *models.AgentRun, content string) { resumed = content }})
+
+			if _, err := s.ChatAgent(agentTestContext(), modelID, []Message{{Role: RoleUser, Content: "kick 42"}}, nil); err != nil {
+				t.Fatalf("chat agent failed: %v", err)
+			}
+			var entry models.ToolAuditLog
+			s.db.Where(&models.ToolAuditLog{ToolName: "kick_member"}).First(&entry)
+			s.db.Model(&entry).Updates(map[string]any{"Status": tt.status, "RejectionReason": "no"})
+			if tt.expire {
+				s.db.Model(&models.AgentRun{}).Where(&models.AgentRun{RunID: entry.RunID}).Update("ExpireTime", time.Now().Add(-time.Minute))
+			}
+
+			if n, _ := s.ProcessToolApprovals(context.Background()); n != 1 {
+				t.Fatalf("expected run to resume")
+			}
+			for _, c := range provider.calls() {
+				if c == "kick_member" {
+					t.Fatal("refused tool must not be executed")
+				}
+			}
+			if !strings.Contains(resumed, tt.want) {
+				t.Errorf("expected refusal %q in final answer, got %q", tt.want, resumed)
+			}
+			if tt.expire {
+				s.db.First(&entry, entry.Id)
+				if entry.Status != ToolApprovalExpired {
+					t.Errorf("expected audit log to expire, got %s", entry.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestApprovedRunBehindPendingBacklogIsResumed(t *testing.T) {
+	s, provider, _, modelID := newApprovalTestService(t)
+
+	// 超过一批的较早运行仍在等待审批，不应挡住后面已审批的运行
+	for i := 0; i < approvalBatchSize+10; i++ {
+		runID := fmt.Sprintf("waiting-%d", i)
+		s.db.Create(&models.AgentRun{RunID: runID, Status: AgentRunSuspended, ExpireTime: time.Now().Add(time.Hour)})
+		s.db.Create(&models.ToolAuditLog{RunID: runID, ToolName: "kick_member", Status: ToolApprovalPending})
+	}
+
+	if _, err := s.ChatAgent(agentTestContext(), modelID, []Message{{Role: RoleUser, Content: "kick 42"}}, nil); err != nil {
+		t.Fatalf("chat agent failed: %v", err)
+	}
+	var entry models.ToolAuditLog
+	s.db.Where(&models.ToolAuditLog{ToolName: "kick_member", GroupID: "g1"}).First(&entry)
+	s.db.Model(&entry).Update("Status", ToolApprovalApproved)
+
+	if n, err := s.ProcessToolApprovals(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected the approved run to resume, got %d (%v)", n, err)
+	}
+	if calls := provider.calls(); len(calls) != 2 || calls[1] != "kick_member" {
+		t.Errorf("approved tool should be executed on resume, got %v", calls)
+	}
+
+	var waiting int64
+	s.db.Model(&models.AgentRun{}).Where(&models.AgentRun{Status: AgentRunSuspended}).Count(&waiting)
+	if waiting != approvalBatchSize+10 {
+		t.Errorf("pending runs must stay suspended, got %d", waiting)
+	}
+}
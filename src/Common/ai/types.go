@@ -21,9 +21,11 @@ const (
 
 type Message = types.Message
 type ToolCall = types.ToolCall
+type FunctionCall = types.FunctionCall
 type Tool = types.Tool
 type ChatRequest = types.ChatRequest
 type ChatResponse = types.ChatResponse
+type Choice = types.Choice
 type ChatStreamResponse = types.ChatStreamResponse
 type EmbeddingRequest = types.EmbeddingRequest
 type EmbeddingResponse = types.EmbeddingResponse
@@ -0,0 +1,139 @@
+package apikey
+
+import (
+	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.APIKeyAuditLog{}, &models.RBACRoleBinding{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(db), db
+}
+
+func TestIssueAndAuthenticate(t *testing.T) {
+	svc, db := newTestService(t)
+	account, err := svc.CreateServiceAccount("ci-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, key, err := svc.Issue(account.ID, IssueOptions{
+		Name:       "ci",
+		Scopes:     []rbac.Permission{rbac.MessagesSend},
+		AllowedIPs: []string{"10.0.0.0/8", "192.168.1.5"},
+	}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(plaintext, KeyPrefix+key.Prefix+"_") {
+		t.Fatalf("key %q does not carry prefix %q", plaintext, key.Prefix)
+	}
+	var stored models.APIKey
+	db.First(&stored, key.ID)
+	if strings.Contains(stored.Hash, plaintext) || stored.Hash == "" {
+		t.Fatal("key must be stored hashed")
+	}
+
+	claims, err := svc.Authenticate(plaintext, "10.1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != int64(account.ID) || claims.APIKeyID != key.ID || claims.IsAdmin || len(claims.Scopes) != 1 || claims.Scopes[0] != "messages:send" {
+		t.Fatalf("claims = %+v", claims)
+	}
+	db.First(&stored, key.ID)
+	if stored.LastUsedAt == nil || stored.LastUsedIP != "10.1.2.3" {
+		t.Fatalf("last used not tracked: %+v", stored)
+	}
+
+	if _, err := svc.Authenticate(plaintext, "172.16.0.1"); err == nil {
+		t.Fatal("IP outside the allowlist must be rejected")
+	}
+	if _, err := svc.Authenticate(plaintext[:len(plaintext)-1]+"x", "10.1.2.3"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("tampered key: %v", err)
+	}
+	if _, _, err := svc.Issue(account.ID, IssueOptions{Scopes: []rbac.Permission{"bots:fly"}}, "admin"); err == nil {
+		t.Fatal("unknown scope must be rejected")
+	}
+
+	if err := svc.Revoke(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Authenticate(plaintext, "10.1.2.3"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("revoked key: %v", err)
+	}
+}
+
+func TestRotateKeepsOldKeyDuringOverlap(t *testing.T) {
+	svc, _ := newTestService(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	account, _ := svc.CreateServiceAccount("crm-sync")
+	oldKey, old, err := svc.Issue(account.ID, IssueOptions{Name: "crm", Scopes: []rbac.Permission{"bots:*"}}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, rotated, err := svc.Rotate(old.ID, time.Hour, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Scopes != old.Scopes || rotated.Name != "crm" {
+		t.Fatalf("rotated key lost its settings: %+v", rotated)
+	}
+
+	for _, k := range []string{oldKey, newKey} {
+		if _, err := svc.Authenticate(k, "127.0.0.1"); err != nil {
+			t.Fatalf("key should work during overlap: %v", err)
+		}
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := svc.Authenticate(oldKey, "127.0.0.1"); err == nil {
+		t.Fatal("old key must expire after the overlap")
+	}
+	if _, err := svc.Authenticate(newKey, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.DeleteServiceAccount(account.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Authenticate(newKey, "127.0.0.1"); err == nil {
+		t.Fatal("keys of a deleted account must be rejected")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:4000"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 203.0.113.9, 10.0.0.3")
+
+	if ip := ClientIP(r, nil); ip != "10.0.0.2" {
+		t.Fatalf("untrusted peer: %s", ip)
+	}
+	if ip := ClientIP(r, []string{"10.0.0.0/24"}); ip != "203.0.113.9" {
+		t.Fatalf("trusted proxy: %s", ip)
+	}
+	if _, err := ParseAllowlist([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("invalid CIDR must be rejected")
+	}
+}
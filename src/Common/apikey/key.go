@@ -0,0 +1,135 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// KeyPrefix 所有 API Key 的固定前缀，便于在日志与代码仓库中识别泄露的 Key
+const KeyPrefix = "bmk_"
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generate 生成新 Key，返回完整 Key、可公开的标识前缀与哈希
+func generate() (plaintext, prefix, hash string, err error) {
+	buf := make([]byte, 25)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	raw := strings.ToLower(encoding.EncodeToString(buf))
+	prefix, secret := raw[:8], raw[8:]
+	plaintext = KeyPrefix + prefix + "_" + secret
+	return plaintext, prefix, hashKey(plaintext), nil
+}
+
+func hashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// parse 从完整 Key 中取出标识前缀
+func parse(plaintext string) (string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, KeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 8 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// IsKey 判断凭证是否为 API Key (而不是 JWT)
+func IsKey(credential string) bool {
+	return strings.HasPrefix(credential, KeyPrefix)
+}
+
+// FromRequest 从 X-API-Key 或 Authorization: Bearer 头中读取 API Key。
+// 不接受查询参数中的 Key，避免其出现在访问日志中
+func FromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && IsKey(token) {
+		return token
+	}
+	return ""
+}
+
+// ParseAllowlist 校验并规范化 IP 白名单 (IP 或 CIDR)
+func ParseAllowlist(entries []string) ([]string, error) {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if strings.Contains(e, "/") {
+			p, err := netip.ParsePrefix(e)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", e)
+			}
+			out = append(out, p.Masked().String())
+			continue
+		}
+		addr, err := netip.ParseAddr(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %q", e)
+		}
+		out = append(out, addr.Unmap().String())
+	}
+	return out, nil
+}
+
+// ipAllowed 判断 IP 是否在白名单内，白名单为空时不限制
+func ipAllowed(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, e := range allowlist {
+		if p, err := netip.ParsePrefix(e); err == nil {
+			if p.Contains(addr) {
+				return true
+			}
+		} else if a, err := netip.ParseAddr(e); err == nil && a.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP 返回请求来源 IP。仅当直连地址属于可信代理时，才采信 X-Forwarded-For 中最右侧的非代理地址
+func ClientIP(r *http.Request, trustedProxies []string) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if len(trustedProxies) == 0 || !ipAllowed(trustedProxies, host) {
+		return host
+	}
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !ipAllowed(trustedProxies, hop) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
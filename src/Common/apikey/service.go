@@ -0,0 +1,273 @@
+package apikey
+
+import (
+	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/types"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// touchInterval 最近使用时间的最小更新间隔，避免每个请求都写库
+const touchInterval = time.Minute
+
+// DefaultRotationOverlap 轮换时旧 Key 的默认保留时长，供调用方在此期间切换到新 Key
+const DefaultRotationOverlap = 24 * time.Hour
+
+var accountNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_\-]{2,63}$`)
+
+// ErrInvalidKey 统一的认证失败错误，不向调用方区分 Key 不存在、已撤销或已过期
+var ErrInvalidKey = errors.New("invalid or expired API key")
+
+// IssueOptions 签发 Key 的参数
+type IssueOptions struct {
+	Name       string            `json:"name"`
+	Scopes     []rbac.Permission `json:"scopes"`      // 为空时不额外限制
+	AllowedIPs []string          `json:"allowed_ips"` // 为空时不限制来源
+	ExpiresAt  *time.Time        `json:"expires_at"`
+}
+
+// Service 服务账号与 API Key 管理
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// CreateServiceAccount 创建服务账号。服务账号的密码不可用，只能通过 API Key 访问，权限通过 RBAC 授予
+func (s *Service) CreateServiceAccount(name string) (*models.User, error) {
+	if !accountNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid service account name %q", name)
+	}
+	account := &models.User{
+		Username:       "svc:" + name,
+		PasswordHash:   "!",
+		IsService:      true,
+		Active:         true,
+		SessionVersion: 1,
+	}
+	if err := s.db.Create(account).Error; err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ServiceAccounts 列出全部服务账号
+func (s *Service) ServiceAccounts() ([]models.User, error) {
+	var accounts []models.User
+	err := s.db.Where("is_service = ?", true).Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+func (s *Service) account(id uint) (*models.User, error) {
+	var account models.User
+	if err := s.db.Where("id = ? AND is_service = ?", id, true).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("service account %d not found", id)
+	}
+	return &account, nil
+}
+
+// DeleteServiceAccount 删除服务账号并撤销其全部 Key
+func (s *Service) DeleteServiceAccount(id uint) error {
+	account, err := s.account(id)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.APIKey{}).Where("account_id = ? AND revoked_at IS NULL", id).Update("revoked_at", s.now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.RBACRoleBinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(account).Error
+	})
+}
+
+// Issue 为服务账号签发新 Key，返回的完整 Key 只在此时可见
+func (s *Service) Issue(accountID uint, opts IssueOptions, createdBy string) (string, *models.APIKey, error) {
+	if _, err := s.account(accountID); err != nil {
+		return "", nil, err
+	}
+	for _, p := range opts.Scopes {
+		if !p.Valid() {
+			return "", nil, fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	allowlist, err := ParseAllowlist(opts.AllowedIPs)
+	if err != nil {
+		return "", nil, err
+	}
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(s.now()) {
+		return "", nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	plaintext, prefix, hash, err := generate()
+	if err != nil {
+		return "", nil, err
+	}
+	key := &models.APIKey{
+		AccountID: accountID,
+		Name:      opts.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		ExpiresAt: opts.ExpiresAt,
+		CreatedBy: createdBy,
+	}
+	if len(opts.Scopes) > 0 {
+		data, _ := json.Marshal(opts.Scopes)
+		key.Scopes = string(data)
+	}
+	if len(allowlist) > 0 {
+		data, _ := json.Marshal(allowlist)
+		key.AllowedIPs = string(data)
+	}
+	if err := s.db.Create(key).Error; err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// Keys 列出服务账号的 Key，accountID 为 0 时列出全部
+func (s *Service) Keys(accountID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	q := s.db.Order("id")
+	if accountID != 0 {
+		q = q.Where("account_id = ?", accountID)
+	}
+	err := q.Find(&keys).Error
+	return keys, err
+}
+
+func (s *Service) activeKey(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.db.First(&key, id).Error; err != nil {
+		return nil, fmt.Errorf("API key %d not found", id)
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("API key %d is revoked", id)
+	}
+	return &key, nil
+}
+
+// Rotate 签发一个权限、白名单与有效期相同的新 Key，旧 Key 在 overlap 之后失效，
+// 以便调用方在不中断服务的情况下切换。overlap 为 0 时使用 DefaultRotationOverlap
+func (s *Service) Rotate(id uint, overlap time.Duration, createdBy string) (string, *models.APIKey, error) {
+	old, err := s.activeKey(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if overlap <= 0 {
+		overlap = DefaultRotationOverlap
+	}
+
+	opts := IssueOptions{Name: old.Name, ExpiresAt: old.ExpiresAt}
+	json.Unmarshal([]byte(old.Scopes), &opts.Scopes)
+	json.Unmarshal([]byte(old.AllowedIPs), &opts.AllowedIPs)
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(s.now()) {
+		opts.ExpiresAt = nil
+	}
+	plaintext, key, err := s.Issue(old.AccountID, opts, createdBy)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cutoff := s.now().Add(overlap)
+	updates := map[string]any{"rotated_to": key.ID}
+	if old.ExpiresAt == nil || old.ExpiresAt.After(cutoff) {
+		updates["expires_at"] = cutoff
+	}
+	if err := s.db.Model(old).Updates(updates).Error; err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// Revoke 立即撤销 Key
+func (s *Service) Revoke(id uint) error {
+	key, err := s.activeKey(id)
+	if err != nil {
+		return err
+	}
+	return s.db.Model(key).Update("revoked_at", s.now()).Error
+}
+
+// Authenticate 校验 Key 与来源 IP，返回对应服务账号的身份
+func (s *Service) Authenticate(plaintext, ip string) (*types.UserClaims, error) {
+	prefix, ok := parse(plaintext)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	var key models.APIKey
+	if err := s.db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, ErrInvalidKey
+	}
+	if subtle.ConstantTimeCompare([]byte(hashKey(plaintext)), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidKey
+	}
+	now := s.now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, ErrInvalidKey
+	}
+	var allowlist []string
+	json.Unmarshal([]byte(key.AllowedIPs), &allowlist)
+	if !ipAllowed(allowlist, ip) {
+		return nil, fmt.Errorf("API key %s is not allowed from %s", key.Prefix, ip)
+	}
+
+	var account models.User
+	if err := s.db.Where("id = ? AND is_service = ?", key.AccountID, true).First(&account).Error; err != nil || !account.Active {
+		return nil, ErrInvalidKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval || key.LastUsedIP != ip {
+		s.db.Model(&key).Updates(map[string]any{"last_used_at": now, "last_used_ip": ip})
+	}
+
+	claims := &types.UserClaims{
+		UserID:         int64(account.ID),
+		Username:       account.Username,
+		SessionVersion: account.SessionVersion,
+		APIKeyID:       key.ID,
+		APIKeyPrefix:   key.Prefix,
+	}
+	json.Unmarshal([]byte(key.Scopes), &claims.Scopes)
+	return claims, nil
+}
+
+// Audit 记录一次通过 Key 发起的请求
+func (s *Service) Audit(claims *types.UserClaims, method, path string, status int, ip string) {
+	s.db.Create(&models.APIKeyAuditLog{
+		KeyID:     claims.APIKeyID,
+		KeyPrefix: claims.APIKeyPrefix,
+		AccountID: uint(claims.UserID),
+		Method:    method,
+		Path:      path,
+		Status:    status,
+		IP:        ip,
+		CreatedAt: s.now(),
+	})
+}
+
+// AuditLogs 查询审计记录，keyID 为 0 时不过滤
+func (s *Service) AuditLogs(keyID uint, limit int) ([]models.APIKeyAuditLog, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	var logs []models.APIKeyAuditLog
+	q := s.db.Order("id DESC").Limit(limit)
+	if keyID != 0 {
+		q = q.Where("key_id = ?", keyID)
+	}
+	err := q.Find(&logs).Error
+	return logs, err
+}
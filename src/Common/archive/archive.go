@@ -0,0 +1,378 @@
+// Package archive 消息归档：记录机器人收发的消息，提供按条件过滤、全文检索、游标分页、
+// 按机器人配置的保留策略，以及会话导出 (JSON / NDJSON)。
+package archive
+
+import (
+	"BotMatrix/common/types"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SearchAuto Postgres 使用 tsvector，其他数据库使用内置倒排索引
+	SearchAuto = "auto"
+	// SearchTSVector 强制使用 Postgres tsvector
+	SearchTSVector = "tsvector"
+	// SearchIndex 强制使用内置倒排索引
+	SearchIndex = "index"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+var (
+	// ErrInvalidCursor 分页游标无法解析
+	ErrInvalidCursor = errors.New("invalid archive cursor")
+	// ErrThreadRequired 导出会话时必须指定机器人以及群或用户
+	ErrThreadRequired = errors.New("thread export requires bot_id and group_id or user_id")
+)
+
+// Message 归档的一条消息。群消息以 (BotID, GroupID) 归为一个会话，私聊以 (BotID, UserID) 归为一个会话
+type Message struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	MessageID   string    `gorm:"size:64" json:"message_id"`
+	BotID       string    `gorm:"size:64;index:idx_message_archive_bot_time,priority:1" json:"bot_id"`
+	Platform    string    `gorm:"size:32;index" json:"platform"`
+	PostType    string    `gorm:"size:32" json:"post_type"`
+	MessageType string    `gorm:"size:32" json:"message_type"`
+	UserID      string    `gorm:"size:64;index" json:"user_id"`
+	UserName    string    `gorm:"size:255" json:"user_name"`
+	GroupID     string    `gorm:"size:64;index" json:"group_id"`
+	GroupName   string    `gorm:"size:255" json:"group_name"`
+	Content     string    `gorm:"type:text" json:"content"`
+	SearchText  string    `gorm:"type:text" json:"-"` // Tokenize 结果，以空格分隔
+	CreatedAt   time.Time `gorm:"index;index:idx_message_archive_bot_time,priority:2" json:"created_at"`
+}
+
+func (Message) TableName() string {
+	return "message_archive"
+}
+
+// RetentionPolicy 单个机器人的消息保留天数，0 表示永久保留
+type RetentionPolicy struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BotID     string    `gorm:"size:64;uniqueIndex" json:"bot_id"`
+	Days      int       `json:"days"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (RetentionPolicy) TableName() string {
+	return "message_retention_policies"
+}
+
+// FromInternal 将收发的消息事件转换为归档记录，非消息事件返回 false
+func FromInternal(msg types.InternalMessage) (Message, bool) {
+	if msg.PostType != "message" && msg.PostType != "message_sent" {
+		return Message{}, false
+	}
+	created := time.Now()
+	if msg.Time > 0 {
+		created = time.Unix(msg.Time, 0)
+	}
+	name := msg.SenderCard
+	if name == "" {
+		name = msg.SenderName
+	}
+	return Message{
+		MessageID:   msg.ID,
+		BotID:       msg.SelfID,
+		Platform:    msg.Platform,
+		PostType:    msg.PostType,
+		MessageType: msg.MessageType,
+		UserID:      msg.UserID,
+		UserName:    name,
+		GroupID:     msg.GroupID,
+		GroupName:   msg.GroupName,
+		Content:     msg.RawMessage,
+		CreatedAt:   created,
+	}, true
+}
+
+// Options 归档参数，零值字段使用默认值
+type Options struct {
+	Search               string        // 全文检索实现，默认 SearchAuto
+	DefaultRetentionDays int           // 未单独配置保留策略的机器人的保留天数，0 表示永久保留
+	BufferSize           int           // 异步写入缓冲的消息数，默认 1000，满时丢弃新消息
+	BatchSize            int           // 单次批量写入的消息数，默认 200
+	FlushInterval        time.Duration // 批量写入间隔，默认 1s
+	PurgeInterval        time.Duration // 执行保留策略的间隔，默认 1h
+	ShouldPurge          func() bool   // 返回 false 时跳过本轮清理，集群模式下只由 leader 清理；nil 表示始终清理
+}
+
+func (o Options) withDefaults() Options {
+	if o.Search == "" {
+		o.Search = SearchAuto
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 1000
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 200
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.PurgeInterval <= 0 {
+		o.PurgeInterval = time.Hour
+	}
+	return o
+}
+
+// Service 消息归档服务
+type Service struct {
+	db     *gorm.DB
+	opts   Options
+	search searcher
+
+	queue   chan Message
+	dropped int64
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func New(db *gorm.DB, opts Options) *Service {
+	opts = opts.withDefaults()
+	var s searcher = termIndex{}
+	switch opts.Search {
+	case SearchTSVector:
+		s = tsvectorSearch{}
+	case SearchAuto:
+		if db.Dialector.Name() == "postgres" {
+			s = tsvectorSearch{}
+		}
+	}
+	return &Service{
+		db:     db,
+		opts:   opts,
+		search: s,
+		queue:  make(chan Message, opts.BufferSize),
+	}
+}
+
+// Migrate 创建归档、保留策略与检索索引
+func (s *Service) Migrate() error {
+	if err := s.db.AutoMigrate(&Message{}, &RetentionPolicy{}); err != nil {
+		return err
+	}
+	return s.search.migrate(s.db)
+}
+
+// Start 建表并启动批量写入与定期清理协程
+func (s *Service) Start(ctx context.Context) error {
+	if err := s.Migrate(); err != nil {
+		return err
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(2)
+	go s.writeLoop(ctx)
+	go s.purgeLoop(ctx)
+	return nil
+}
+
+// Stop 停止后台协程，缓冲中的消息会在退出前写入
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Enqueue 异步归档一条消息事件，非消息事件或缓冲已满时返回 false
+func (s *Service) Enqueue(msg types.InternalMessage) bool {
+	m, ok := FromInternal(msg)
+	if !ok {
+		return false
+	}
+	select {
+	case s.queue <- m:
+		return true
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+		return false
+	}
+}
+
+// Dropped 返回因缓冲已满被丢弃的消息数
+func (s *Service) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Service) writeLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Message, 0, s.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.Store(context.Background(), batch...); err != nil {
+			log.Printf("[Archive] 写入 %d 条消息失败: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case m := <-s.queue:
+			batch = append(batch, m)
+			if len(batch) >= s.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case m := <-s.queue:
+					batch = append(batch, m)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Store 同步写入消息并建立检索索引
+func (s *Service) Store(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	for i := range msgs {
+		msgs[i].ID = 0
+		msgs[i].CreatedAt = msgs[i].CreatedAt.UTC()
+		msgs[i].SearchText = strings.Join(Tokenize(msgs[i].UserName+" "+msgs[i].Content), " ")
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(msgs, 200).Error; err != nil {
+			return err
+		}
+		return s.search.index(tx, msgs)
+	})
+}
+
+// Query 归档查询条件，字符串字段为空表示不过滤
+type Query struct {
+	BotID       string
+	Platform    string
+	UserID      string
+	GroupID     string
+	MessageType string
+	Since       time.Time // 包含
+	Until       time.Time // 不包含
+	Text        string    // 全文检索，要求包含全部检索词
+	Cursor      string    // 上一页返回的 NextCursor
+	Limit       int       // 默认 50，最大 500
+	Ascending   bool      // 默认按时间倒序
+}
+
+// Page 一页查询结果，NextCursor 为空表示没有更多数据
+type Page struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// Search 按条件查询归档消息，使用 (created_at, id) 游标分页
+func (s *Service) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	tx := s.db.WithContext(ctx).Model(&Message{})
+	if q.BotID != "" {
+		tx = tx.Where("bot_id = ?", q.BotID)
+	}
+	if q.Platform != "" {
+		tx = tx.Where("platform = ?", q.Platform)
+	}
+	if q.UserID != "" {
+		tx = tx.Where("user_id = ?", q.UserID)
+	}
+	if q.GroupID != "" {
+		tx = tx.Where("group_id = ?", q.GroupID)
+	}
+	if q.MessageType != "" {
+		tx = tx.Where("message_type = ?", q.MessageType)
+	}
+	if !q.Since.IsZero() {
+		tx = tx.Where("created_at >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		tx = tx.Where("created_at < ?", q.Until.UTC())
+	}
+	if q.Text != "" {
+		terms := Tokenize(q.Text)
+		if len(terms) == 0 {
+			return &Page{Messages: []Message{}}, nil
+		}
+		tx = s.search.match(tx, terms)
+	}
+
+	op, order := "<", "created_at DESC, id DESC"
+	if q.Ascending {
+		op, order = ">", "created_at ASC, id ASC"
+	}
+	if q.Cursor != "" {
+		at, id, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(fmt.Sprintf("(created_at %s ?) OR (created_at = ? AND id %s ?)", op, op), at, at, id)
+	}
+
+	var msgs []Message
+	if err := tx.Order(order).Limit(limit + 1).Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+	page := &Page{Messages: msgs}
+	if len(msgs) > limit {
+		page.Messages = msgs[:limit]
+		last := page.Messages[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func encodeCursor(at time.Time, id uint) string {
+	raw := strconv.FormatInt(at.UnixNano(), 10) + ":" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(c string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	nanos, err1 := strconv.ParseInt(ts, 10, 64)
+	n, err2 := strconv.ParseUint(id, 10, 64)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.Unix(0, nanos).UTC(), uint(n), nil
+}
@@ -0,0 +1,243 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"BotMatrix/common/types"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T, opts Options) *Service {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	// 内存库每个连接相互独立，后台写入协程需与查询共用同一连接
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	s := New(db, opts)
+	if err := s.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+var base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func seed(t *testing.T, s *Service) {
+	t.Helper()
+	msgs := []Message{
+		{BotID: "b1", Platform: "qq", MessageType: "group", GroupID: "g1", UserID: "u1", UserName: "Alice", Content: "我要申请退款", CreatedAt: base},
+		{BotID: "b1", Platform: "qq", MessageType: "group", GroupID: "g1", UserID: "u2", UserName: "Bob", Content: "Refund processed", CreatedAt: base.Add(time.Minute)},
+		{BotID: "b1", Platform: "qq", MessageType: "private", UserID: "u1", UserName: "Alice", Content: "hello bot", CreatedAt: base.Add(2 * time.Minute)},
+		{BotID: "b2", Platform: "wechat", MessageType: "group", GroupID: "g2", UserID: "u3", UserName: "Carol", Content: "退款进度如何", CreatedAt: base.Add(3 * time.Minute)},
+		{BotID: "b1", Platform: "qq", MessageType: "group", GroupID: "g1", UserID: "u1", UserName: "Alice", Content: "谢谢", CreatedAt: base.Add(4 * time.Minute)},
+	}
+	if err := s.Store(context.Background(), msgs...); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+}
+
+func contents(p *Page) []string {
+	out := make([]string, 0, len(p.Messages))
+	for _, m := range p.Messages {
+		out = append(out, m.Content)
+	}
+	return out
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("ＨＥＬＬＯ World, 申请退款! hello 好")
+	want := []string{"hello", "world", "申请", "请退", "退款", "好"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tokenize = %v, want %v", got, want)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	s := newTestService(t, Options{})
+	seed(t, s)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"bot", Query{BotID: "b2"}, []string{"退款进度如何"}},
+		{"platform", Query{Platform: "qq", MessageType: "private"}, []string{"hello bot"}},
+		{"group and user", Query{GroupID: "g1", UserID: "u1"}, []string{"谢谢", "我要申请退款"}},
+		{"time range", Query{Since: base.Add(time.Minute), Until: base.Add(3 * time.Minute)}, []string{"hello bot", "Refund processed"}},
+		{"cjk text", Query{Text: "退款"}, []string{"退款进度如何", "我要申请退款"}},
+		{"latin text case-insensitive", Query{Text: "REFUND"}, []string{"Refund processed"}},
+		{"sender name", Query{Text: "alice hello"}, []string{"hello bot"}},
+		{"text with filter", Query{Text: "退款", BotID: "b1"}, []string{"我要申请退款"}},
+		{"no match", Query{Text: "不存在"}, []string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := s.Search(ctx, tc.q)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if got := contents(page); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSearchKeysetPagination(t *testing.T) {
+	s := newTestService(t, Options{})
+	ctx := context.Background()
+	// 相同时间戳的消息依靠 id 排序，不应在翻页时重复或遗漏
+	var msgs []Message
+	for i := 0; i < 7; i++ {
+		msgs = append(msgs, Message{BotID: "b1", Content: string(rune('a' + i)), CreatedAt: base.Add(time.Duration(i/2) * time.Second)})
+	}
+	if err := s.Store(ctx, msgs...); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	q := Query{Limit: 3}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := s.Search(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, contents(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if want := []string{"g", "f", "e", "d", "c", "b", "a"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("pages = %v, want %v", seen, want)
+	}
+
+	if _, err := s.Search(ctx, Query{Cursor: "not-a-cursor"}); err != ErrInvalidCursor {
+		t.Fatalf("bad cursor err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestRetentionPurge(t *testing.T) {
+	s := newTestService(t, Options{DefaultRetentionDays: 30})
+	ctx := context.Background()
+	now := base
+	old := now.AddDate(0, 0, -10)
+	ancient := now.AddDate(0, 0, -60)
+	if err := s.Store(ctx,
+		Message{BotID: "b1", Content: "b1 old", CreatedAt: old},
+		Message{BotID: "b1", Content: "b1 ancient", CreatedAt: ancient},
+		Message{BotID: "b2", Content: "b2 old", CreatedAt: old},
+		Message{BotID: "b2", Content: "b2 ancient", CreatedAt: ancient},
+		Message{BotID: "b3", Content: "b3 ancient", CreatedAt: ancient},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetRetention(ctx, "b1", 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetRetention(ctx, "b3", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.Purge(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("purged %d, want 3", n)
+	}
+	page, _ := s.Search(ctx, Query{Ascending: true})
+	if got, want := contents(page), []string{"b3 ancient", "b2 old"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("remaining = %v, want %v", got, want)
+	}
+	var terms int64
+	s.db.Model(&Term{}).Where("term = ?", "ancient").Count(&terms)
+	if terms != 1 {
+		t.Fatalf("index still holds %d postings for purged messages", terms)
+	}
+
+	if err := s.SetRetention(ctx, "b3", -1); err != nil {
+		t.Fatal(err)
+	}
+	policies, _ := s.RetentionPolicies(ctx)
+	if len(policies) != 1 || policies[0].BotID != "b1" {
+		t.Fatalf("policies = %+v", policies)
+	}
+}
+
+func TestExportThread(t *testing.T) {
+	s := newTestService(t, Options{})
+	seed(t, s)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	n, err := s.ExportThread(ctx, &buf, Query{BotID: "b1", GroupID: "g1"}, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var arr []Message
+	if err := json.Unmarshal(buf.Bytes(), &arr); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if n != 3 || len(arr) != 3 || arr[0].Content != "我要申请退款" || arr[2].Content != "谢谢" {
+		t.Fatalf("json export = %d %+v", n, arr)
+	}
+
+	buf.Reset()
+	n, err = s.ExportThread(ctx, &buf, Query{BotID: "b1", UserID: "u1"}, FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if n != 1 || len(lines) != 1 || !strings.Contains(lines[0], "hello bot") {
+		t.Fatalf("ndjson export = %d %v", n, lines)
+	}
+
+	if _, err := s.ExportThread(ctx, &buf, Query{BotID: "b1"}, FormatJSON); err != ErrThreadRequired {
+		t.Fatalf("err = %v, want ErrThreadRequired", err)
+	}
+}
+
+func TestEnqueueFlushesOnStop(t *testing.T) {
+	s := newTestService(t, Options{FlushInterval: time.Hour})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.Enqueue(types.InternalMessage{PostType: "notice", SelfID: "b1"}) {
+		t.Fatal("notice events must not be archived")
+	}
+	if !s.Enqueue(types.InternalMessage{PostType: "message", SelfID: "b1", MessageType: "group", GroupID: "g1", UserID: "u1", SenderName: "Alice", RawMessage: "queued", Time: base.Unix()}) {
+		t.Fatal("enqueue failed")
+	}
+	s.Stop()
+
+	page, err := s.Search(context.Background(), Query{Text: "queued"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].UserName != "Alice" {
+		t.Fatalf("got %+v", page.Messages)
+	}
+}
@@ -0,0 +1,71 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// exportPage 导出时每次读取的消息数
+const exportPage = 500
+
+// ExportThread 按时间正序导出一个会话的全部消息：群会话需指定 BotID 与 GroupID，私聊需指定 BotID 与 UserID
+// (未指定 MessageType 时只导出私聊消息)。
+// q 中的时间范围、类型与全文检索条件仍然生效，Cursor、Limit 与排序方向被忽略。返回导出的消息数
+func (s *Service) ExportThread(ctx context.Context, w io.Writer, q Query, format string) (int, error) {
+	if q.BotID == "" || (q.GroupID == "" && q.UserID == "") {
+		return 0, ErrThreadRequired
+	}
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatNDJSON {
+		return 0, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	if q.GroupID == "" && q.MessageType == "" {
+		q.MessageType = "private"
+	}
+	q.Cursor, q.Limit, q.Ascending = "", exportPage, true
+	enc := json.NewEncoder(w)
+	count := 0
+	if format == FormatJSON {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return 0, err
+		}
+	}
+	for {
+		page, err := s.Search(ctx, q)
+		if err != nil {
+			return count, err
+		}
+		for i := range page.Messages {
+			if format == FormatJSON && count > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return count, err
+				}
+			}
+			// Encoder 在每条记录后追加换行，JSON 数组中同样合法
+			if err := enc.Encode(&page.Messages[i]); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if format == FormatJSON {
+		if _, err := io.WriteString(w, "]\n"); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
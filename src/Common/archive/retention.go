@@ -0,0 +1,138 @@
+package archive
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// purgeBatch 单次删除的消息数，避免长事务
+const purgeBatch = 1000
+
+// SetRetention 设置机器人的消息保留天数：days 为 0 表示永久保留，小于 0 表示删除该机器人的策略并回到默认值
+func (s *Service) SetRetention(ctx context.Context, botID string, days int) error {
+	db := s.db.WithContext(ctx)
+	if days < 0 {
+		return db.Where("bot_id = ?", botID).Delete(&RetentionPolicy{}).Error
+	}
+	var p RetentionPolicy
+	err := db.Where("bot_id = ?", botID).First(&p).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		return db.Create(&RetentionPolicy{BotID: botID, Days: days}).Error
+	case err != nil:
+		return err
+	}
+	return db.Model(&p).Update("days", days).Error
+}
+
+// RetentionPolicies 返回所有单独配置的保留策略
+func (s *Service) RetentionPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	var policies []RetentionPolicy
+	err := s.db.WithContext(ctx).Order("bot_id").Find(&policies).Error
+	return policies, err
+}
+
+// DefaultRetentionDays 返回未单独配置的机器人的保留天数
+func (s *Service) DefaultRetentionDays() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opts.DefaultRetentionDays
+}
+
+// SetDefaultRetentionDays 修改默认保留天数，下一次清理时生效
+func (s *Service) SetDefaultRetentionDays(days int) {
+	s.mu.Lock()
+	s.opts.DefaultRetentionDays = days
+	s.mu.Unlock()
+}
+
+// Purge 按保留策略删除 now 之前过期的消息，返回删除的条数
+func (s *Service) Purge(ctx context.Context, now time.Time) (int64, error) {
+	policies, err := s.RetentionPolicies(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	configured := make([]string, 0, len(policies))
+	for _, p := range policies {
+		configured = append(configured, p.BotID)
+		if p.Days <= 0 {
+			continue
+		}
+		cutoff := now.AddDate(0, 0, -p.Days).UTC()
+		n, err := s.purgeWhere(ctx, func(q *gorm.DB) *gorm.DB {
+			return q.Where("bot_id = ? AND created_at < ?", p.BotID, cutoff)
+		})
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	if days := s.DefaultRetentionDays(); days > 0 {
+		cutoff := now.AddDate(0, 0, -days).UTC()
+		n, err := s.purgeWhere(ctx, func(q *gorm.DB) *gorm.DB {
+			q = q.Where("created_at < ?", cutoff)
+			if len(configured) > 0 {
+				q = q.Where("bot_id NOT IN ?", configured)
+			}
+			return q
+		})
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (s *Service) purgeWhere(ctx context.Context, scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		q := scope(s.db.WithContext(ctx).Model(&Message{}))
+		if err := q.Limit(purgeBatch).Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := s.search.remove(tx, ids); err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", ids).Delete(&Message{}).Error
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(ids))
+		if len(ids) < purgeBatch {
+			return total, nil
+		}
+	}
+}
+
+func (s *Service) purgeLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if s.opts.ShouldPurge != nil && !s.opts.ShouldPurge() {
+				continue
+			}
+			n, err := s.Purge(ctx, now)
+			if err != nil {
+				log.Printf("[Archive] 清理过期消息失败: %v", err)
+			} else if n > 0 {
+				log.Printf("[Archive] 已清理 %d 条过期消息", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// maxTermLen 单个检索词的最大字节数，超出部分截断
+const maxTermLen = 64
+
+// Tokenize 将文本切分为去重后的检索词：全角字符转半角并转小写，拉丁字母与数字按单词切分，
+// 中日韩文字按相邻两字 (bigram) 切分，连续只有一个字时保留单字。入库与查询使用同一切分方式
+func Tokenize(text string) []string {
+	text = strings.ToLower(norm.NFKC.String(text))
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if len(t) > maxTermLen {
+			t = t[:maxTermLen]
+			for !utf8Valid(t) {
+				t = t[:len(t)-1]
+			}
+		}
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			add(string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch len(cjk) {
+		case 0:
+		case 1:
+			add(string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				add(string(cjk[i : i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func utf8Valid(s string) bool {
+	return strings.ToValidUTF8(s, "�") == s
+}
+
+// searcher 全文检索实现
+type searcher interface {
+	// migrate 创建检索所需的索引或表
+	migrate(db *gorm.DB) error
+	// index 为新写入的消息建立索引
+	index(tx *gorm.DB, msgs []Message) error
+	// remove 删除消息的索引
+	remove(tx *gorm.DB, ids []uint) error
+	// match 为查询附加全文检索条件，terms 为 Tokenize 的结果
+	match(q *gorm.DB, terms []string) *gorm.DB
+}
+
+// tsvectorSearch 使用 Postgres tsvector：对 search_text 建立 GIN 表达式索引，查询时要求包含全部检索词
+type tsvectorSearch struct{}
+
+func (tsvectorSearch) migrate(db *gorm.DB) error {
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_message_archive_search ON message_archive USING GIN (to_tsvector('simple', search_text))`).Error
+}
+
+func (tsvectorSearch) index(*gorm.DB, []Message) error { return nil }
+
+func (tsvectorSearch) remove(*gorm.DB, []uint) error { return nil }
+
+func (tsvectorSearch) match(q *gorm.DB, terms []string) *gorm.DB {
+	return q.Where("to_tsvector('simple', search_text) @@ plainto_tsquery('simple', ?)", strings.Join(terms, " "))
+}
+
+// Term 内置倒排索引中的一条记录：检索词 → 消息
+type Term struct {
+	Term      string `gorm:"primaryKey;size:64"`
+	MessageID uint   `gorm:"primaryKey;index"`
+}
+
+func (Term) TableName() string {
+	return "message_archive_terms"
+}
+
+// termIndex 内置倒排索引，用于 SQLite 等不支持 tsvector 的数据库
+type termIndex struct{}
+
+func (termIndex) migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Term{})
+}
+
+func (termIndex) index(tx *gorm.DB, msgs []Message) error {
+	var terms []Term
+	for _, m := range msgs {
+		for _, t := range strings.Fields(m.SearchText) {
+			terms = append(terms, Term{Term: t, MessageID: m.ID})
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(terms, 500).Error
+}
+
+func (termIndex) remove(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Where("message_id IN ?", ids).Delete(&Term{}).Error
+}
+
+func (termIndex) match(q *gorm.DB, terms []string) *gorm.DB {
+	for _, t := range terms {
+		q = q.Where("id IN (SELECT message_id FROM message_archive_terms WHERE term = ?)", t)
+	}
+	return q
+}
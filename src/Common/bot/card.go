@@ -0,0 +1,508 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"BotMatrix/common/types"
+)
+
+// CardCallbackMaxSkew is the maximum accepted age of a signed card callback
+const CardCallbackMaxSkew = time.Hour
+
+// MaxCardLinkTTL caps the validity a card may request for its signed callback links (see types.Card.LinkTTL)
+const MaxCardLinkTTL = 30 * 24 * time.Hour
+
+// CardLinkTTL returns how long the signed callback links of card stay valid
+func CardLinkTTL(card *types.Card) time.Duration {
+	ttl := time.Duration(card.LinkTTL) * time.Second
+	switch {
+	case ttl <= 0:
+		return CardCallbackMaxSkew
+	case ttl > MaxCardLinkTTL:
+		return MaxCardLinkTTL
+	}
+	return ttl
+}
+
+// CardRef records where a rendered card lives on the platform, so it can be updated in place
+type CardRef struct {
+	CardID    string    `json:"card_id"`
+	PluginID  string    `json:"plugin_id"`
+	MessageID string    `json:"message_id"` // Feishu message_id / DingTalk outTrackId
+	Target    string    `json:"target"`     // chat/user the card was sent to
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CardStore keeps the card ID -> platform message mapping for a single adapter
+type CardStore struct {
+	mu   sync.RWMutex
+	refs map[string]CardRef
+	ttl  time.Duration
+}
+
+// NewCardStore creates a card store. Entries older than ttl are dropped by Prune.
+func NewCardStore(ttl time.Duration) *CardStore {
+	return &CardStore{
+		refs: make(map[string]CardRef),
+		ttl:  ttl,
+	}
+}
+
+// Put stores or refreshes a card reference
+func (s *CardStore) Put(ref CardRef) {
+	ref.UpdatedAt = time.Now()
+	s.mu.Lock()
+	s.refs[ref.CardID] = ref
+	s.mu.Unlock()
+}
+
+// Get returns the reference of a previously sent card
+func (s *CardStore) Get(cardID string) (CardRef, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ref, ok := s.refs[cardID]
+	return ref, ok
+}
+
+// Prune removes expired card references
+func (s *CardStore) Prune() {
+	if s.ttl <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-s.ttl)
+	s.mu.Lock()
+	for id, ref := range s.refs {
+		if ref.UpdatedAt.Before(deadline) {
+			delete(s.refs, id)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// StartPruner periodically prunes expired references until ctx is done
+func (s *CardStore) StartPruner(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Prune()
+			}
+		}
+	}()
+}
+
+// ParseCard decodes a card from action params. It accepts a map, a JSON string or a *types.Card.
+// A missing card ID is generated, the plugin ID is filled from pluginID if the card has none.
+func ParseCard(v any, pluginID string) (*types.Card, error) {
+	var card types.Card
+	switch c := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("card is required")
+	case *types.Card:
+		card = *c
+	case types.Card:
+		card = c
+	case string:
+		if err := json.Unmarshal([]byte(c), &card); err != nil {
+			return nil, fmt.Errorf("invalid card json: %v", err)
+		}
+	default:
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid card: %v", err)
+		}
+		if err := json.Unmarshal(data, &card); err != nil {
+			return nil, fmt.Errorf("invalid card: %v", err)
+		}
+	}
+
+	if card.Title == "" && len(card.Elements) == 0 {
+		return nil, fmt.Errorf("card must have a title or at least one element")
+	}
+	if card.ID == "" {
+		card.ID = fmt.Sprintf("card_%d", time.Now().UnixNano())
+	}
+	if card.PluginID == "" {
+		card.PluginID = pluginID
+	}
+	return &card, nil
+}
+
+// CardButtonValue builds the callback payload carried by an interactive button
+func CardButtonValue(card *types.Card, btn types.CardButton) map[string]any {
+	value := make(map[string]any, len(btn.Value)+3)
+	for k, v := range btn.Value {
+		value[k] = v
+	}
+	value["card_id"] = card.ID
+	value["action"] = btn.Action
+	if card.PluginID != "" {
+		value["plugin_id"] = card.PluginID
+	}
+	return value
+}
+
+// ParseCardButtonValue splits a button callback payload into the routing fields and the custom value
+func ParseCardButtonValue(value map[string]any) (cardID, pluginID, action string, extra map[string]any) {
+	extra = make(map[string]any)
+	for k, v := range value {
+		switch k {
+		case "card_id":
+			cardID = fmt.Sprint(v)
+		case "plugin_id":
+			pluginID = fmt.Sprint(v)
+		case "action":
+			action = fmt.Sprint(v)
+		default:
+			extra[k] = v
+		}
+	}
+	return
+}
+
+// NewCardActionEvent wraps a card callback into a OneBot notice for BotNexus
+func NewCardActionEvent(selfID string, action types.CardAction) map[string]any {
+	return map[string]any{
+		"post_type":   "notice",
+		"notice_type": "card_action",
+		"sub_type":    action.Action,
+		"time":        time.Now().Unix(),
+		"self_id":     selfID,
+		"user_id":     action.UserID,
+		"group_id":    action.GroupID,
+		"message_id":  action.MessageID,
+		"card_action": action,
+	}
+}
+
+// RenderFeishuCard renders a card as a Feishu message card (interactive message content)
+func RenderFeishuCard(card *types.Card) map[string]any {
+	theme := card.Theme
+	if theme == "" {
+		theme = "blue"
+	}
+
+	elements := make([]any, 0, len(card.Elements)+1)
+	for _, el := range card.Elements {
+		switch el.Type {
+		case "divider":
+			elements = append(elements, map[string]any{"tag": "hr"})
+		case "image":
+			elements = append(elements, map[string]any{
+				"tag":     "img",
+				"img_key": el.ImageURL,
+				"alt":     map[string]any{"tag": "plain_text", "content": el.Content},
+			})
+		case "progress":
+			elements = append(elements, map[string]any{
+				"tag":  "div",
+				"text": map[string]any{"tag": "lark_md", "content": progressText(el)},
+			})
+		case "fields":
+			fields := make([]any, 0, len(el.Fields))
+			for _, f := range el.Fields {
+				fields = append(fields, map[string]any{
+					"is_short": true,
+					"text":     map[string]any{"tag": "lark_md", "content": fmt.Sprintf("**%s**\n%s", f.Label, f.Value)},
+				})
+			}
+			elements = append(elements, map[string]any{"tag": "div", "fields": fields})
+		case "text":
+			elements = append(elements, map[string]any{
+				"tag":  "div",
+				"text": map[string]any{"tag": "plain_text", "content": el.Content},
+			})
+		default:
+			elements = append(elements, map[string]any{
+				"tag":  "div",
+				"text": map[string]any{"tag": "lark_md", "content": el.Content},
+			})
+		}
+	}
+
+	if len(card.Buttons) > 0 {
+		actions := make([]any, 0, len(card.Buttons))
+		for _, btn := range card.Buttons {
+			style := btn.Style
+			if style == "" {
+				style = "default"
+			}
+			b := map[string]any{
+				"tag":  "button",
+				"text": map[string]any{"tag": "plain_text", "content": btn.Text},
+				"type": style,
+			}
+			if btn.Action != "" {
+				b["value"] = CardButtonValue(card, btn)
+			}
+			if btn.URL != "" {
+				b["url"] = btn.URL
+			}
+			actions = append(actions, b)
+		}
+		elements = append(elements, map[string]any{"tag": "action", "actions": actions})
+	}
+
+	return map[string]any{
+		"config": map[string]any{
+			"wide_screen_mode": true,
+			"update_multi":     true,
+		},
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": card.Title},
+			"template": theme,
+		},
+		"elements": elements,
+	}
+}
+
+// RenderDingTalkActionCard renders a card as a webhook ActionCard message.
+// ActionCard buttons can only open URLs, so callback buttons point at callbackURL with
+// a signed query string (see SignCardCallback) carrying the expiry and the bound operator.
+// Buttons are dropped if callbackURL is empty.
+func RenderDingTalkActionCard(card *types.Card, callbackURL, secret string) map[string]any {
+	text := renderMarkdown(card)
+	exp := strconv.FormatInt(time.Now().Add(CardLinkTTL(card)).Unix(), 10)
+
+	btns := make([]map[string]string, 0, len(card.Buttons))
+	for _, btn := range card.Buttons {
+		link := btn.URL
+		if btn.Action != "" {
+			if callbackURL == "" {
+				continue
+			}
+			values := url.Values{}
+			for k, v := range CardButtonValue(card, btn) {
+				values.Set(k, fmt.Sprint(v))
+			}
+			values.Set("exp", exp)
+			if card.Operator != "" {
+				values.Set("operator", card.Operator)
+			}
+			values.Set("sign", SignCardCallback(secret, values))
+			link = callbackURL + "?" + values.Encode()
+		}
+		if link == "" {
+			continue
+		}
+		btns = append(btns, map[string]string{"title": btn.Text, "actionURL": link})
+	}
+
+	actionCard := map[string]any{
+		"title":          card.Title,
+		"text":           text,
+		"btnOrientation": "0",
+	}
+	if len(btns) > 0 {
+		actionCard["btns"] = btns
+	}
+	return map[string]any{
+		"msgtype":    "actionCard",
+		"actionCard": actionCard,
+	}
+}
+
+// RenderDingTalkStandardCard renders a card as a DingTalk interactive card (StandardCard template)
+// for enterprise robots. Callback buttons are delivered through the stream card callback topic.
+func RenderDingTalkStandardCard(card *types.Card) map[string]any {
+	contents := make([]any, 0, len(card.Elements)+1)
+	for i, el := range card.Elements {
+		id := fmt.Sprintf("el_%d", i)
+		switch el.Type {
+		case "divider":
+			contents = append(contents, map[string]any{"type": "divider", "id": id})
+		case "image":
+			contents = append(contents, map[string]any{"type": "image", "image": el.ImageURL, "id": id})
+		case "progress":
+			contents = append(contents, map[string]any{"type": "markdown", "text": progressText(el), "id": id})
+		case "fields":
+			contents = append(contents, map[string]any{"type": "markdown", "text": fieldsMarkdown(el.Fields), "id": id})
+		default:
+			contents = append(contents, map[string]any{"type": "markdown", "text": el.Content, "id": id})
+		}
+	}
+
+	if len(card.Buttons) > 0 {
+		actions := make([]any, 0, len(card.Buttons))
+		for i, btn := range card.Buttons {
+			status := btn.Style
+			if status == "" || status == "default" {
+				status = "normal"
+			}
+			b := map[string]any{
+				"type":   "button",
+				"label":  map[string]any{"type": "text", "text": btn.Text},
+				"status": status,
+				"id":     fmt.Sprintf("btn_%d", i),
+			}
+			if btn.Action != "" {
+				b["actionType"] = "request"
+				b["value"] = CardButtonValue(card, btn)
+			} else {
+				b["actionType"] = "openLink"
+				b["url"] = map[string]any{"all": btn.URL}
+			}
+			actions = append(actions, b)
+		}
+		contents = append(contents, map[string]any{"type": "action", "actions": actions, "id": "actions"})
+	}
+
+	return map[string]any{
+		"config": map[string]any{"autoLayout": true, "enableForward": true},
+		"header": map[string]any{
+			"title": map[string]any{"type": "text", "text": card.Title},
+		},
+		"contents": contents,
+	}
+}
+
+// VerifyFeishuSignature checks X-Lark-Signature of an HTTP callback:
+// sha256(timestamp + nonce + encryptKey + body) in hex.
+func VerifyFeishuSignature(timestamp, nonce, encryptKey string, body []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	h := sha256.New()
+	h.Write([]byte(timestamp + nonce + encryptKey))
+	h.Write(body)
+	expected := hex.EncodeToString(h.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// VerifyDingTalkSignature checks the timestamp/sign headers of a DingTalk HTTP callback:
+// base64(HmacSHA256(timestamp + "\n" + secret)) with a one hour validity window.
+func VerifyDingTalkSignature(timestamp, sign, secret string, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("callback secret is not configured")
+	}
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %s", timestamp)
+	}
+	if d := now.Sub(time.UnixMilli(ms)); d > CardCallbackMaxSkew || d < -CardCallbackMaxSkew {
+		return fmt.Errorf("timestamp expired")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(sign)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// SignCardCallback signs the query of a card callback URL. The "sign" key itself is excluded.
+func SignCardCallback(secret string, values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(values.Get(k))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(sb.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCardCallback validates a signed card callback query produced by RenderDingTalkActionCard.
+// Links carry their expiry in "exp"; links signed with only "ts" are valid for CardCallbackMaxSkew.
+func VerifyCardCallback(secret string, values url.Values, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("card callback secret is not configured")
+	}
+	if values.Has("exp") {
+		exp, err := strconv.ParseInt(values.Get("exp"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid expiry")
+		}
+		if expiry := time.Unix(exp, 0); now.After(expiry) || expiry.Sub(now) > MaxCardLinkTTL {
+			return fmt.Errorf("callback link expired")
+		}
+	} else {
+		ts, err := strconv.ParseInt(values.Get("ts"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp")
+		}
+		if d := now.Sub(time.Unix(ts, 0)); d > CardCallbackMaxSkew || d < -CardCallbackMaxSkew {
+			return fmt.Errorf("callback link expired")
+		}
+	}
+	if !hmac.Equal([]byte(SignCardCallback(secret, values)), []byte(values.Get("sign"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func renderMarkdown(card *types.Card) string {
+	var sb strings.Builder
+	if card.Title != "" {
+		sb.WriteString("### " + card.Title + "\n\n")
+	}
+	for _, el := range card.Elements {
+		switch el.Type {
+		case "divider":
+			sb.WriteString("---\n\n")
+		case "image":
+			sb.WriteString(fmt.Sprintf("![%s](%s)\n\n", el.Content, el.ImageURL))
+		case "progress":
+			sb.WriteString(progressText(el) + "\n\n")
+		case "fields":
+			sb.WriteString(fieldsMarkdown(el.Fields) + "\n\n")
+		default:
+			sb.WriteString(el.Content + "\n\n")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func fieldsMarkdown(fields []types.CardField) string {
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		lines = append(lines, fmt.Sprintf("**%s**: %s", f.Label, f.Value))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func progressText(el types.CardElement) string {
+	p := el.Progress
+	if p < 0 {
+		p = 0
+	}
+	if p > 100 {
+		p = 100
+	}
+	filled := p / 10
+	bar := strings.Repeat("▓", filled) + strings.Repeat("░", 10-filled)
+	if el.Content != "" {
+		return fmt.Sprintf("%s\n%s %d%%", el.Content, bar, p)
+	}
+	return fmt.Sprintf("%s %d%%", bar, p)
+}
@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"BotMatrix/common/types"
+)
+
+func testCard() *types.Card {
+	return &types.Card{
+		ID:       "card_1",
+		PluginID: "approval",
+		Title:    "请假审批",
+		Elements: []types.CardElement{
+			{Type: "markdown", Content: "**张三** 申请请假 2 天"},
+			{Type: "progress", Content: "处理中", Progress: 40},
+		},
+		Buttons: []types.CardButton{
+			{Text: "同意", Action: "approve", Style: "primary", Value: map[string]any{"request_id": "42"}},
+			{Text: "详情", URL: "https://example.com/42"},
+		},
+	}
+}
+
+func TestParseCard(t *testing.T) {
+	card, err := ParseCard(map[string]any{"title": "hello"}, "plugin_a")
+	if err != nil {
+		t.Fatalf("ParseCard failed: %v", err)
+	}
+	if card.ID == "" {
+		t.Error("expected a generated card ID")
+	}
+	if card.PluginID != "plugin_a" {
+		t.Errorf("expected plugin_a, got %s", card.PluginID)
+	}
+
+	if _, err := ParseCard(map[string]any{}, ""); err == nil {
+		t.Error("expected error for empty card")
+	}
+	if _, err := ParseCard(nil, ""); err == nil {
+		t.Error("expected error for nil card")
+	}
+}
+
+func TestRenderFeishuCardButtonValue(t *testing.T) {
+	rendered := RenderFeishuCard(testCard())
+	elements := rendered["elements"].([]any)
+	action := elements[len(elements)-1].(map[string]any)
+	if action["tag"] != "action" {
+		t.Fatalf("expected last element to be action, got %v", action["tag"])
+	}
+	btn := action["actions"].([]any)[0].(map[string]any)
+	value := btn["value"].(map[string]any)
+
+	cardID, pluginID, act, extra := ParseCardButtonValue(value)
+	if cardID != "card_1" || pluginID != "approval" || act != "approve" {
+		t.Errorf("unexpected routing fields: %s %s %s", cardID, pluginID, act)
+	}
+	if extra["request_id"] != "42" {
+		t.Errorf("expected custom value to survive, got %v", extra)
+	}
+
+	link := action["actions"].([]any)[1].(map[string]any)
+	if _, ok := link["value"]; ok {
+		t.Error("url-only button should not carry a callback value")
+	}
+}
+
+func TestDingTalkActionCardSignedLink(t *testing.T) {
+	msg := RenderDingTalkActionCard(testCard(), "https://bot.example.com/dingtalk/card", "s3cret")
+	ac := msg["actionCard"].(map[string]any)
+	btns := ac["btns"].([]map[string]string)
+	if len(btns) != 2 {
+		t.Fatalf("expected 2 buttons, got %d", len(btns))
+	}
+	if !strings.Contains(ac["text"].(string), "40%") {
+		t.Errorf("expected progress in markdown, got %s", ac["text"])
+	}
+
+	u, err := url.Parse(btns[0]["actionURL"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if err := VerifyCardCallback("s3cret", query, time.Now()); err != nil {
+		t.Errorf("expected valid link: %v", err)
+	}
+	if err := VerifyCardCallback("other", query, time.Now()); err == nil {
+		t.Error("expected signature mismatch with wrong secret")
+	}
+	if err := VerifyCardCallback("s3cret", query, time.Now().Add(2*time.Hour)); err == nil {
+		t.Error("expected expired link")
+	}
+
+	query.Set("action", "reject")
+	if err := VerifyCardCallback("s3cret", query, time.Now()); err == nil {
+		t.Error("expected tampered link to be rejected")
+	}
+
+	// Without a callback URL, only link buttons survive
+	msg = RenderDingTalkActionCard(testCard(), "", "s3cret")
+	if btns := msg["actionCard"].(map[string]any)["btns"].([]map[string]string); len(btns) != 1 {
+		t.Errorf("expected only the url button, got %d", len(btns))
+	}
+}
+
+func TestVerifyFeishuSignature(t *testing.T) {
+	body := []byte(`{"schema":"2.0"}`)
+	h := sha256.New()
+	h.Write([]byte("1700000000" + "nonce" + "key"))
+	h.Write(body)
+	sig := hex.EncodeToString(h.Sum(nil))
+
+	if !VerifyFeishuSignature("1700000000", "nonce", "key", body, sig) {
+		t.Error("expected valid signature")
+	}
+	if VerifyFeishuSignature("1700000000", "nonce", "key", []byte(`{}`), sig) {
+		t.Error("expected invalid signature for modified body")
+	}
+	if VerifyFeishuSignature("1700000000", "nonce", "key", body, "") {
+		t.Error("expected empty signature to be rejected")
+	}
+}
+
+func TestVerifyDingTalkSignature(t *testing.T) {
+	now := time.Now()
+	ts := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(ts + "\n" + "secret"))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name    string
+		ts      string
+		sign    string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", ts, sign, now, false},
+		{"wrong sign", ts, "AAAA", now, true},
+		{"expired", ts, sign, now.Add(2 * time.Hour), true},
+		{"bad timestamp", "abc", sign, now, true},
+	}
+	for _, tt := range tests {
+		err := VerifyDingTalkSignature(tt.ts, tt.sign, "secret", tt.now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: wantErr=%v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	// 未配置密钥时任何人都能算出签名，必须拒绝
+	forged := hmac.New(sha256.New, nil)
+	forged.Write([]byte(ts + "\n"))
+	if err := VerifyDingTalkSignature(ts, base64.StdEncoding.EncodeToString(forged.Sum(nil)), "", now); err == nil {
+		t.Error("expected callbacks to be rejected without a secret")
+	}
+}
+
+func TestDingTalkActionCardOperatorAndTTL(t *testing.T) {
+	card := testCard()
+	card.Operator = "manager_1"
+	card.LinkTTL = int((72 * time.Hour).Seconds())
+	btns := RenderDingTalkActionCard(card, "https://bot.example.com/dingtalk/card", "s3cret")["actionCard"].(map[string]any)["btns"].([]map[string]string)
+	u, _ := url.Parse(btns[0]["actionURL"])
+	query := u.Query()
+
+	if query.Get("operator") != "manager_1" {
+		t.Fatalf("operator missing from link: %s", u.RawQuery)
+	}
+	if err := VerifyCardCallback("s3cret", query, time.Now().Add(48*time.Hour)); err != nil {
+		t.Errorf("link should honor the card TTL: %v", err)
+	}
+	if err := VerifyCardCallback("s3cret", query, time.Now().Add(73*time.Hour)); err == nil {
+		t.Error("expected link to expire after the card TTL")
+	}
+
+	query.Set("operator", "someone_else")
+	if err := VerifyCardCallback("s3cret", query, time.Now()); err == nil {
+		t.Error("expected a rebound operator to be rejected")
+	}
+
+	card.LinkTTL = int((365 * 24 * time.Hour).Seconds())
+	if got := CardLinkTTL(card); got != MaxCardLinkTTL {
+		t.Errorf("ttl = %v, want capped at %v", got, MaxCardLinkTTL)
+	}
+}
+
+func TestCardStorePrune(t *testing.T) {
+	s := NewCardStore(time.Minute)
+	s.Put(CardRef{CardID: "a", MessageID: "m1"})
+	if ref, ok := s.Get("a"); !ok || ref.MessageID != "m1" {
+		t.Fatalf("expected stored ref, got %v %v", ref, ok)
+	}
+
+	s.mu.Lock()
+	ref := s.refs["a"]
+	ref.UpdatedAt = time.Now().Add(-2 * time.Minute)
+	s.refs["a"] = ref
+	s.mu.Unlock()
+
+	s.Prune()
+	if _, ok := s.Get("a"); ok {
+		t.Error("expected expired ref to be pruned")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -12,10 +13,15 @@ import (
 	"syscall"
 	"time"
 
+	"BotMatrix/common/metrics"
+	"BotMatrix/common/secrets"
+	"BotMatrix/common/tracing"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BotConfig defines basic configuration for any bot
@@ -26,6 +32,8 @@ type BotConfig struct {
 	UseTLS    bool   `json:"use_tls"`   // Whether to use TLS (HTTPS/WSS)
 	CertFile  string `json:"cert_file"` // Certificate file path
 	KeyFile   string `json:"key_file"`  // Private key file path
+
+	MetricsToken string `json:"metrics_token"` // Bearer token required by /metrics, overridden by METRICS_TOKEN
 }
 
 // LogManager handles log rotation and retrieval
@@ -131,6 +139,13 @@ type BaseBot struct {
 	ConfigPtr   any
 	RestartFunc func()
 	Sections    []ConfigSection
+	Platform    string
+
+	tracingOnce     sync.Once
+	tracingShutdown func(context.Context) error
+
+	secretFields map[string]*string
+	secretRefs   map[string]secretField
 }
 
 func NewBaseBot(defaultLogPort int) *BaseBot {
@@ -187,18 +202,38 @@ func (b *BaseBot) HandleConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
 		b.Mu.RLock()
 		defer b.Mu.RUnlock()
+		data, err := json.Marshal(b.ConfigPtr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(b.ConfigPtr)
+		w.Write(secrets.RedactJSON(data))
 		return
 	}
 
 	if r.Method == http.MethodPost {
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		b.Mu.Lock()
-		if err := json.NewDecoder(r.Body).Decode(b.ConfigPtr); err != nil {
+		// Masked values posted back by the UI keep the current secret
+		current, _ := json.Marshal(b.ConfigPtr)
+		patch, err = secrets.RestoreMasked(patch, current)
+		if err == nil {
+			err = json.Unmarshal(patch, b.ConfigPtr)
+		}
+		if err != nil {
 			b.Mu.Unlock()
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if err := b.resolveSecrets(); err != nil {
+			log.Printf("Failed to resolve secrets: %v", err)
+		}
 
 		// Save to file while still holding the lock
 		b.SaveConfig("config.json")
@@ -230,11 +265,15 @@ func (b *BaseBot) HandleConfigUI(w http.ResponseWriter, r *http.Request) {
 			if inputType == "" {
 				inputType = "text"
 			}
+			value := field.Value
+			if s, ok := value.(string); ok && secrets.IsSensitiveKey(field.ID) {
+				value = secrets.RedactValue(s)
+			}
 			fieldsHTML += fmt.Sprintf(`
                 <div class="form-group">
                     <label>%s</label>
                     <input type="%s" id="%s" value="%v">
-                </div>`, field.Label, inputType, field.ID, field.Value)
+                </div>`, field.Label, inputType, field.ID, value)
 		}
 		sectionsHTML += fmt.Sprintf(`
             <div class="card">
@@ -419,6 +458,9 @@ func (b *BaseBot) SaveConfig(path string) error {
 		data, err = json.MarshalIndent(b.Config, "", "  ")
 	}
 
+	if err == nil {
+		data, err = b.restoreSecretRefs(data)
+	}
 	if err != nil {
 		return err
 	}
@@ -442,6 +484,8 @@ func (b *BaseBot) StartHTTPServer() {
 		w.Write([]byte("OK"))
 	})
 
+	b.Mux.Handle("/metrics", metrics.Handler(b.metricsToken()))
+
 	addr := fmt.Sprintf(":%d", b.Config.LogPort)
 	server := &http.Server{
 		Addr:    addr,
@@ -476,16 +520,50 @@ func (b *BaseBot) StartHTTPServer() {
 	}()
 }
 
+func (b *BaseBot) metricsToken() string {
+	if token := os.Getenv("METRICS_TOKEN"); token != "" {
+		return token
+	}
+	b.Mu.RLock()
+	defer b.Mu.RUnlock()
+	return b.Config.MetricsToken
+}
+
 func (b *BaseBot) WaitExitSignal() {
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
 	log.Println("Shutting down...")
 	b.Cancel()
+	if b.tracingShutdown != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		b.tracingShutdown(ctx)
+	}
+}
+
+// InitTracing sets up OpenTelemetry once per process; spans are flushed by WaitExitSignal.
+// Adapters get it implicitly from StartNexusConnection using the TRACING_* / OTEL_* environment
+// (see tracing.FromEnv); processes with a config.json call it first with tracing.FromConfig.
+func (b *BaseBot) InitTracing(opts tracing.Options) {
+	b.tracingOnce.Do(func() {
+		shutdown, err := tracing.Init(context.Background(), opts)
+		if err != nil {
+			log.Printf("Failed to initialize tracing: %v", err)
+			return
+		}
+		b.tracingShutdown = shutdown
+	})
 }
 
 // StartNexusConnection connects to BotNexus and handles reconnection
 func (b *BaseBot) StartNexusConnection(ctx context.Context, addr, platform, selfID string, commandHandler func([]byte)) {
+	b.Platform = platform
+	service := b.BotName
+	if service == "" {
+		service = platform + "-adapter"
+	}
+	b.InitTracing(tracing.FromEnv(service))
 	go func() {
 		for {
 			select {
@@ -534,6 +612,7 @@ func (b *BaseBot) StartNexusConnection(ctx context.Context, addr, platform, self
 						b.ConnMu.Unlock()
 						break
 					}
+					metrics.AdapterActionsReceived.WithLabelValues(metrics.Platform(platform)).Inc()
 					commandHandler(message)
 				}
 				time.Sleep(1 * time.Second)
@@ -542,13 +621,42 @@ func (b *BaseBot) StartNexusConnection(ctx context.Context, addr, platform, self
 	}()
 }
 
+// SendToNexus writes an event or action response to BotNexus. Events start the trace of
+// a message: the adapter span's context is injected into the payload as traceparent.
 func (b *BaseBot) SendToNexus(msg any) {
+	span := trace.SpanFromContext(context.Background()) // no-op unless the message is a traced event
+	// platform events are counted, action responses and meta events are not
+	counted := false
+	if event, ok := msg.(map[string]any); ok {
+		postType, _ := event["post_type"].(string)
+		counted = postType != "" && postType != "meta_event"
+		if _, traced := event[tracing.KeyTraceParent]; postType != "" && postType != "meta_event" && !traced {
+			var ctx context.Context
+			ctx, span = tracing.Start(b.Ctx, "adapter.send_to_nexus",
+				trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(
+					attribute.String("botmatrix.platform", b.Platform),
+					attribute.String("botmatrix.post_type", postType),
+				))
+			tracing.InjectMap(ctx, event)
+		}
+	}
+	defer span.End()
+
 	b.ConnMu.Lock()
 	defer b.ConnMu.Unlock()
 	if b.NexusConn == nil {
+		if counted {
+			metrics.AdapterEventsForwarded.WithLabelValues(metrics.Platform(b.Platform), metrics.StatusError).Inc()
+		}
 		return
 	}
-	if err := b.NexusConn.WriteJSON(msg); err != nil {
+	err := b.NexusConn.WriteJSON(msg)
+	if counted {
+		metrics.AdapterEventsForwarded.WithLabelValues(metrics.Platform(b.Platform), metrics.Status(err)).Inc()
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
 		log.Printf("Failed to send to Nexus: %v", err)
 		b.NexusConn.Close()
 		b.NexusConn = nil
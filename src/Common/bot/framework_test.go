@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"BotMatrix/common/metrics"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAdapterMetrics(t *testing.T) {
+	// 模拟 Nexus：连接后下发一个动作，并接收适配器上报的事件
+	events := make(chan []byte, 4)
+	upgrader := websocket.Upgrader{}
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"action": "send_msg"})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			events <- data
+		}
+	}))
+	defer nexus.Close()
+
+	b := NewBaseBot(0)
+	defer b.Cancel()
+	b.Config.MetricsToken = "scrape"
+
+	forwarded := metrics.AdapterEventsForwarded.WithLabelValues("metrics_test", metrics.StatusSuccess)
+	dropped := metrics.AdapterEventsForwarded.WithLabelValues("metrics_test", metrics.StatusError)
+	received := metrics.AdapterActionsReceived.WithLabelValues("metrics_test")
+	// 计数器为进程级全局变量，按增量断言
+	base := map[prometheus.Counter]float64{}
+	for _, c := range []prometheus.Counter{forwarded, dropped, received} {
+		base[c] = testutil.ToFloat64(c)
+	}
+	delta := func(c prometheus.Counter) float64 { return testutil.ToFloat64(c) - base[c] }
+
+	b.Platform = "metrics_test"
+	b.SendToNexus(map[string]any{"post_type": "message"}) // 尚未连接
+	if delta(dropped) != 1 {
+		t.Fatalf("events dropped while disconnected = %v, want 1", delta(dropped))
+	}
+
+	commands := make(chan []byte, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b.StartNexusConnection(ctx, "ws"+strings.TrimPrefix(nexus.URL, "http"), "metrics_test", "10001", func(msg []byte) { commands <- msg })
+	select {
+	case <-commands:
+	case <-time.After(2 * time.Second):
+		t.Fatal("action from Nexus was not delivered")
+	}
+	if delta(received) != 1 {
+		t.Fatalf("actions received = %v, want 1", delta(received))
+	}
+
+	<-events // lifecycle 元事件不计数
+	b.SendToNexus(map[string]any{"post_type": "message"})
+	<-events
+	if delta(forwarded) != 1 {
+		t.Fatalf("events forwarded = %v, want 1", delta(forwarded))
+	}
+
+	// /metrics 按令牌保护并输出适配器计数
+	b.StartHTTPServer()
+	rec := httptest.NewRecorder()
+	b.Mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("/metrics without token = %d, want 401", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape")
+	rec = httptest.NewRecorder()
+	b.Mux.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `botmatrix_adapter_actions_received_total{platform="metrics_test"}`) {
+		t.Fatalf("/metrics is missing adapter counters:\n%s", rec.Body.String())
+	}
+}
@@ -10,9 +10,12 @@ import (
 	"sync"
 	"time"
 
+	"BotMatrix/common/apikey"
 	"BotMatrix/common/config"
 	"BotMatrix/common/database"
 	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/sso"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 
@@ -90,6 +93,12 @@ type Manager struct {
 	GORMDB      *gorm.DB
 	GORMManager *database.GORMManager
 
+	rbacService   *rbac.Service
+	apiKeyService *apikey.Service
+	ssoProvider   *sso.Provider
+	ssoService    *sso.Service
+	authMu        sync.Mutex
+
 	MessageCache []types.InternalMessage
 	CacheMutex   sync.RWMutex
 
@@ -117,6 +126,11 @@ type Manager struct {
 	TaskManager                any // Will be cast to *tasks.TaskManager
 	MCPManager                 types.MCPManagerInterface
 	KnowledgeBase              types.KnowledgeBase
+
+	// IsLeader reports whether this node runs the named singleton job in cluster mode; nil means a single node
+	IsLeader func(job string) bool
+	// RoutingRulesChanged is called after a routing rule is saved or deleted, e.g. to notify other cluster nodes
+	RoutingRulesChanged func()
 }
 
 // GetGORMDB returns the GORM database instance
@@ -293,14 +307,23 @@ func (m *Manager) GetBot(platform, selfID string) (*types.BotClient, bool) {
 	return nil, false
 }
 
+// JWTSecret returns the signing secret in effect. Managers sharing the
+// startup GlobalConfig follow hot reloads through config.Current.
+func (m *Manager) JWTSecret() string {
+	if m.Config == config.GlobalConfig {
+		return config.Current().JWTSecret
+	}
+	return m.Config.JWTSecret
+}
+
 // ValidateToken validates a JWT token
 func (m *Manager) ValidateToken(tokenString string) (*types.UserClaims, error) {
-	return utils.ValidateToken(tokenString, m.Config.JWTSecret)
+	return utils.ValidateToken(tokenString, m.JWTSecret())
 }
 
 // GenerateToken generates a JWT token for a user
 func (m *Manager) GenerateToken(user *types.User) (string, error) {
-	return utils.GenerateToken(user, m.Config.JWTSecret)
+	return utils.GenerateToken(user, m.JWTSecret())
 }
 
 // LoadUsersFromDB 从数据库加载所有用户到内存
@@ -599,6 +622,10 @@ func (m *Manager) SaveUserToDB(u any) error {
 			CreatedAt:      user.CreatedAt,
 			UpdatedAt:      user.UpdatedAt,
 		}
+		if userGORM.ID != 0 {
+			// 只更新 types.User 携带的字段，避免覆盖平台绑定、服务账号与 TOTP 等其他列
+			return m.GORMDB.Model(userGORM).Select("username", "password_hash", "is_admin", "active", "qq", "session_version", "updated_at").Updates(userGORM).Error
+		}
 	default:
 		return fmt.Errorf("unsupported user type: %T", u)
 	}
@@ -620,16 +647,26 @@ func (m *Manager) SaveRoutingRuleToDB(pattern, targetWorkerID string) error {
 	// Use Upsert logic
 	var existing models.RoutingRuleGORM
 	result := m.GORMDB.Where("pattern = ?", pattern).First(&existing)
+	var err error
 	if result.Error == nil {
 		existing.TargetWorkerID = targetWorkerID
-		return m.GORMDB.Save(&existing).Error
+		err = m.GORMDB.Save(&existing).Error
+	} else {
+		err = m.GORMDB.Create(rule).Error
+	}
+	if err == nil && m.RoutingRulesChanged != nil {
+		m.RoutingRulesChanged()
 	}
-	return m.GORMDB.Create(rule).Error
+	return err
 }
 
 // DeleteRoutingRuleFromDB deletes routing rule from database
 func (m *Manager) DeleteRoutingRuleFromDB(pattern string) error {
-	return m.GORMDB.Where("pattern = ?", pattern).Delete(&models.RoutingRuleGORM{}).Error
+	err := m.GORMDB.Where("pattern = ?", pattern).Delete(&models.RoutingRuleGORM{}).Error
+	if err == nil && m.RoutingRulesChanged != nil {
+		m.RoutingRulesChanged()
+	}
+	return err
 }
 
 // LoadRoutingRulesFromDB loads all routing rules from database
@@ -2,17 +2,29 @@ package bot
 
 import (
 	"net/http"
+	"time"
 
+	"BotMatrix/common/apikey"
 	"BotMatrix/common/middleware"
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/sso"
+	"BotMatrix/common/types"
 )
 
-// JWTMiddleware validates the JWT token in the Authorization header
+// JWTMiddleware validates the JWT token in the Authorization header. API keys are rejected:
+// routes open to service accounts must declare a permission via RequirePermission and friends or RequireKeyPermission
 func (m *Manager) JWTMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	mw := middleware.JWTMiddleware(
-		m.ValidateToken,
-		m.GetOrLoadUser,
-	)
-	return mw(next)
+	return middleware.RejectAPIKeyMiddleware(m.sessionMiddleware)(next)
+}
+
+func (m *Manager) sessionMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return middleware.JWTMiddleware(m.ValidateToken, m.GetOrLoadUser)(next)
+}
+
+// keyOrSessionMiddleware authenticates either a user session or the API key of a service account.
+// It must only wrap handlers that check a permission, since key scopes are enforced through rbac.Authorization.Limit
+func (m *Manager) keyOrSessionMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return middleware.APIKeyMiddleware(m, m.sessionMiddleware)(next)
 }
 
 // AdminMiddleware ensures the user has admin privileges
@@ -20,3 +32,126 @@ func (m *Manager) AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	mw := middleware.AdminMiddleware(m.JWTMiddleware)
 	return mw(next)
 }
+
+// RequirePermission ensures the user holds perm within the scope of the request (bot_id, group_id, enterprise_id)
+func (m *Manager) RequirePermission(perm rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return middleware.PermissionMiddleware(m.keyOrSessionMiddleware, m.Authorize, perm, perm, false)(next)
+}
+
+// RequireReadWrite requires read for GET requests and write for all other methods
+func (m *Manager) RequireReadWrite(read, write rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return middleware.PermissionMiddleware(m.keyOrSessionMiddleware, m.Authorize, read, write, false)(next)
+}
+
+// RequireScopedPermission lets users holding perm in any scope through; the handler filters results via rbac.FromContext
+func (m *Manager) RequireScopedPermission(perm rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return middleware.PermissionMiddleware(m.keyOrSessionMiddleware, m.Authorize, perm, perm, true)(next)
+}
+
+// RequireKeyPermission applies perm only to API key requests, leaving user sessions unchanged
+func (m *Manager) RequireKeyPermission(perm rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
+	checked := middleware.PermissionMiddleware(func(h http.HandlerFunc) http.HandlerFunc { return h }, m.Authorize, perm, perm, false)(next)
+	return m.keyOrSessionMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims.APIKeyID != 0 {
+			checked(w, r)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// RBAC returns the role service, or nil before the database is initialized
+func (m *Manager) RBAC() *rbac.Service {
+	m.authMu.Lock()
+	defer m.authMu.Unlock()
+	if m.rbacService == nil && m.GORMDB != nil {
+		m.rbacService = rbac.NewService(m.GORMDB)
+	}
+	return m.rbacService
+}
+
+// Authorize computes the effective permissions of the user; without a database only admins are authorized
+func (m *Manager) Authorize(claims *types.UserClaims) (*rbac.Authorization, error) {
+	svc := m.RBAC()
+	if svc == nil {
+		return &rbac.Authorization{UserID: uint(claims.UserID), Admin: claims.IsAdmin}, nil
+	}
+	authz, err := svc.Authorize(uint(claims.UserID), claims.IsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range claims.Scopes {
+		authz.Limit = append(authz.Limit, rbac.Permission(s))
+	}
+	return authz, nil
+}
+
+// APIKeys returns the service account and API key store, or nil before the database is initialized
+func (m *Manager) APIKeys() *apikey.Service {
+	m.authMu.Lock()
+	defer m.authMu.Unlock()
+	if m.apiKeyService == nil && m.GORMDB != nil {
+		m.apiKeyService = apikey.NewService(m.GORMDB)
+	}
+	return m.apiKeyService
+}
+
+// AuthenticateAPIKey implements middleware.APIKeyAuthenticator
+func (m *Manager) AuthenticateAPIKey(r *http.Request, key string) (*types.UserClaims, error) {
+	svc := m.APIKeys()
+	if svc == nil {
+		return nil, apikey.ErrInvalidKey
+	}
+	return svc.Authenticate(key, m.clientIP(r))
+}
+
+// AuditAPIKey implements middleware.APIKeyAuthenticator
+func (m *Manager) AuditAPIKey(r *http.Request, claims *types.UserClaims, status int) {
+	if svc := m.APIKeys(); svc != nil {
+		svc.Audit(claims, r.Method, r.URL.Path, status, m.clientIP(r))
+	}
+}
+
+func (m *Manager) clientIP(r *http.Request) string {
+	var trusted []string
+	if m.Config != nil {
+		trusted = m.Config.TrustedProxies
+	}
+	return apikey.ClientIP(r, trusted)
+}
+
+// SSO returns the OIDC provider and provisioning service, or nil when SSO is disabled or the database is not initialized
+func (m *Manager) SSO() (*sso.Provider, *sso.Service) {
+	if m.Config == nil || !m.Config.OIDC.Enabled || m.Config.OIDC.Issuer == "" {
+		return nil, nil
+	}
+	rbacSvc := m.RBAC()
+	m.authMu.Lock()
+	defer m.authMu.Unlock()
+	if m.ssoProvider == nil && m.GORMDB != nil {
+		m.ssoProvider = sso.NewProvider(m.Config.OIDC)
+		m.ssoService = sso.NewService(m.GORMDB, m.ssoProvider.Config(), rbacSvc)
+	}
+	return m.ssoProvider, m.ssoService
+}
+
+// ReloadUser drops the cached user and reloads it from the database
+func (m *Manager) ReloadUser(username string) (*types.User, bool) {
+	m.UsersMutex.Lock()
+	delete(m.Users, username)
+	m.UsersMutex.Unlock()
+	return m.GetOrLoadUser(username)
+}
+
+// RevokeSessions bumps the session version so that every token issued to the user is rejected
+func (m *Manager) RevokeSessions(username string) error {
+	user, ok := m.GetOrLoadUser(username)
+	if !ok {
+		return nil
+	}
+	m.UsersMutex.Lock()
+	defer m.UsersMutex.Unlock()
+	user.SessionVersion++
+	user.UpdatedAt = time.Now()
+	return m.SaveUserToDB(user)
+}
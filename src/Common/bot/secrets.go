@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"BotMatrix/common/config"
+	"BotMatrix/common/database"
+	"BotMatrix/common/secrets"
+)
+
+// secretField is a config field that was loaded as a secret:// reference.
+// The resolved value is used at runtime, the reference is what gets saved.
+type secretField struct {
+	ptr   *string
+	ref   string
+	plain string
+}
+
+var (
+	secretStoreOnce sync.Once
+	secretStoreErr  error
+)
+
+// openSecretStore sets up the default secret store from the master key and the
+// pg_* settings in config.json (or PG_* environment variables)
+var openSecretStore = func() error {
+	if secrets.Default() != nil {
+		return nil
+	}
+	config.InitConfig(config.CONFIG_FILE)
+	keys, err := secrets.LoadKeyring(config.GlobalConfig.SecretsMasterKeyFile)
+	if err != nil {
+		return err
+	}
+	db, err := database.InitGORM(config.GlobalConfig)
+	if err != nil {
+		return err
+	}
+	secrets.SetDefault(secrets.New(db, keys, secrets.Options{}))
+	return nil
+}
+
+// ResolveSecrets replaces secret:// references in the given config fields
+// (keyed by their JSON name) with the stored values. The fields are resolved
+// again whenever the config is updated through /config, and SaveConfig writes
+// the references back instead of the resolved values.
+func (b *BaseBot) ResolveSecrets(fields map[string]*string) error {
+	b.Mu.Lock()
+	defer b.Mu.Unlock()
+	b.secretFields = fields
+	return b.resolveSecrets()
+}
+
+// resolveSecrets must be called with b.Mu held
+func (b *BaseBot) resolveSecrets() error {
+	if b.secretRefs == nil {
+		b.secretRefs = make(map[string]secretField)
+	}
+	for name, ptr := range b.secretFields {
+		if ref, ok := b.secretRefs[name]; ok && *ptr == ref.plain {
+			continue // unchanged since it was resolved
+		}
+		delete(b.secretRefs, name)
+		if !secrets.IsRef(*ptr) {
+			continue
+		}
+		secretStoreOnce.Do(func() { secretStoreErr = openSecretStore() })
+		if secretStoreErr != nil {
+			return fmt.Errorf("resolve %s: %w", name, secretStoreErr)
+		}
+		plain, err := secrets.Resolve(context.Background(), *ptr)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", name, err)
+		}
+		b.secretRefs[name] = secretField{ptr: ptr, ref: *ptr, plain: plain}
+		*ptr = plain
+	}
+	return nil
+}
+
+// restoreSecretRefs puts the references back into the marshalled config for
+// fields that still hold the value they were resolved to
+func (b *BaseBot) restoreSecretRefs(data []byte) ([]byte, error) {
+	if len(b.secretRefs) == 0 {
+		return data, nil
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	for name, f := range b.secretRefs {
+		if *f.ptr == f.plain {
+			cfg[name] = f.ref
+		}
+	}
+	return json.MarshalIndent(cfg, "", "  ")
+}
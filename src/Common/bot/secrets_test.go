@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"BotMatrix/common/secrets"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type secretTestConfig struct {
+	BotConfig
+	AppID     string `json:"app_id"`
+	AppSecret string `json:"app_secret"`
+}
+
+func TestResolveSecretsKeepsReferencesInConfig(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := secrets.NewKeyring(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := secrets.New(db, ring, secrets.Options{})
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	secrets.SetDefault(store)
+	defer secrets.SetDefault(nil)
+	ref, err := secrets.Seal(context.Background(), "platform/feishu/app_secret", "s3cret", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(t.TempDir())
+	cfg := &secretTestConfig{AppID: "cli_1", AppSecret: ref}
+	b := NewBaseBot(0)
+	defer b.Cancel()
+	b.SetupStandardHandlers("TestBot", cfg, nil, []ConfigSection{{
+		Title:  "App",
+		Fields: []ConfigField{{Label: "App Secret", ID: "app_secret", Type: "password", Value: "s3cret"}},
+	}})
+
+	if err := b.ResolveSecrets(map[string]*string{"app_secret": &cfg.AppSecret}); err != nil {
+		t.Fatalf("ResolveSecrets: %v", err)
+	}
+	if cfg.AppSecret != "s3cret" {
+		t.Fatalf("AppSecret = %q, want the resolved value", cfg.AppSecret)
+	}
+
+	// 读取配置与配置页面时脱敏
+	rec := httptest.NewRecorder()
+	b.HandleConfig(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if strings.Contains(rec.Body.String(), "s3cret") || !strings.Contains(rec.Body.String(), secrets.Mask) {
+		t.Errorf("GET /config should mask secrets, got %s", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	b.HandleConfigUI(rec, httptest.NewRequest(http.MethodGet, "/config-ui", nil))
+	if strings.Contains(rec.Body.String(), "s3cret") {
+		t.Error("config UI should mask secrets")
+	}
+
+	// 回传脱敏值保持原密钥，保存的文件中仍是引用
+	rec = httptest.NewRecorder()
+	b.HandleConfig(rec, httptest.NewRequest(http.MethodPost, "/config", strings.NewReader(`{"app_id":"cli_2","app_secret":"********"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /config = %d %s", rec.Code, rec.Body.String())
+	}
+	if cfg.AppID != "cli_2" || cfg.AppSecret != "s3cret" {
+		t.Fatalf("unexpected config after update: %+v", cfg)
+	}
+	saved, _ := os.ReadFile("config.json")
+	if strings.Contains(string(saved), "s3cret") || !strings.Contains(string(saved), ref) {
+		t.Errorf("config.json should keep the reference, got %s", saved)
+	}
+
+	// 提交新的值后按新值保存
+	rec = httptest.NewRecorder()
+	b.HandleConfig(rec, httptest.NewRequest(http.MethodPost, "/config", strings.NewReader(`{"app_secret":"rotated"}`)))
+	saved, _ = os.ReadFile("config.json")
+	if cfg.AppSecret != "rotated" || strings.Contains(string(saved), ref) {
+		t.Errorf("a new value should replace the reference, got %+v / %s", cfg, saved)
+	}
+}
@@ -105,7 +105,7 @@ func (m *Manager) StartPeriodicStatsSave() {
 				return
 			case t := <-ticker.C:
 				// 如果是凌晨 1 点左右，执行昨天的统计固化
-				if t.Hour() == 1 {
+				if t.Hour() == 1 && (m.IsLeader == nil || m.IsLeader("stats_save")) {
 					m.SaveAllStatsToDB()
 				}
 			}
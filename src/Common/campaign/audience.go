@@ -0,0 +1,209 @@
+package campaign
+
+import (
+	"BotMatrix/common/tasks"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// 目标类型
+const (
+	TargetGroup   = "group"
+	TargetPrivate = "private"
+	TargetGuild   = "guild"
+)
+
+// Contact 机器人可触达的联系人，Attrs 为可用于圈选与模板渲染的属性
+type Contact struct {
+	BotID    string
+	Platform string
+	Type     string // group, private
+	ID       string
+	Name     string
+	Attrs    map[string]string
+}
+
+// Directory 提供机器人的联系人，用于按属性圈选以及为目标选择发送机器人
+type Directory interface {
+	Contacts(ctx context.Context) ([]Contact, error)
+}
+
+// Segment 受众圈选条件：Targets、GroupIDs、UserIDs 与 Tags 的结果取并集，再按 Attributes 过滤并排除 ExcludeTags。
+// 只设置 Attributes 时从 Directory 的全部联系人中筛选
+type Segment struct {
+	Targets     []Target          `json:"targets"`      // 明确指定机器人的目标
+	GroupIDs    []string          `json:"group_ids"`    // 群号
+	UserIDs     []string          `json:"user_ids"`     // 私聊用户
+	Tags        []string          `json:"tags"`         // tasks 标签，群标签对应群聊，好友标签对应私聊
+	TagLogic    string            `json:"tag_logic"`    // OR (默认) 或 AND
+	Attributes  map[string]string `json:"attributes"`   // 联系人属性，全部相等才保留
+	ExcludeTags []string          `json:"exclude_tags"` // 命中任一标签的目标被排除
+	BotIDs      []string          `json:"bot_ids"`      // 限定发送机器人，为空表示不限
+}
+
+// tagTypes 标签类型与目标类型的对应关系
+var tagTypes = map[string]string{
+	TargetGroup:   "group",
+	TargetPrivate: "friend",
+}
+
+type targetKey struct {
+	typ, id string
+}
+
+// Resolve 按圈选条件生成接收者 (未保存)。无法确定发送机器人的目标以 skipped 状态保留，便于在进度中看到
+func (s *Service) Resolve(ctx context.Context, seg Segment) ([]Recipient, error) {
+	var contacts []Contact
+	if s.dir != nil {
+		var err error
+		if contacts, err = s.dir.Contacts(ctx); err != nil {
+			return nil, err
+		}
+	}
+	allowedBot := func(botID string) bool {
+		if len(seg.BotIDs) == 0 {
+			return true
+		}
+		for _, b := range seg.BotIDs {
+			if b == botID {
+				return true
+			}
+		}
+		return false
+	}
+	// 每个目标可由哪些联系人 (机器人) 触达
+	reach := make(map[targetKey][]Contact)
+	for _, c := range contacts {
+		if allowedBot(c.BotID) {
+			k := targetKey{c.Type, c.ID}
+			reach[k] = append(reach[k], c)
+		}
+	}
+
+	// 1. 并集
+	var order []targetKey
+	explicit := make(map[targetKey]Target)
+	add := func(k targetKey) {
+		if _, ok := explicit[k]; ok {
+			return
+		}
+		explicit[k] = Target{Type: k.typ, ID: k.id}
+		order = append(order, k)
+	}
+	for _, t := range seg.Targets {
+		k := targetKey{t.Type, t.ID}
+		if _, ok := explicit[k]; !ok {
+			order = append(order, k)
+		}
+		explicit[k] = t
+	}
+	for _, id := range seg.GroupIDs {
+		add(targetKey{TargetGroup, id})
+	}
+	for _, id := range seg.UserIDs {
+		add(targetKey{TargetPrivate, id})
+	}
+	if len(seg.Tags) > 0 {
+		tagging := tasks.NewTaggingManager(s.db.WithContext(ctx))
+		logic := seg.TagLogic
+		if logic == "" {
+			logic = "OR"
+		}
+		for _, typ := range []string{TargetGroup, TargetPrivate} {
+			ids, err := tagging.GetTargetsByTags(tagTypes[typ], seg.Tags, logic)
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				add(targetKey{typ, id})
+			}
+		}
+	}
+	onlyAttributes := len(order) == 0 && len(seg.Attributes) > 0
+	if onlyAttributes {
+		if s.dir == nil {
+			return nil, errors.New("attribute segments require a contact directory")
+		}
+		for _, c := range contacts {
+			if allowedBot(c.BotID) {
+				add(targetKey{c.Type, c.ID})
+			}
+		}
+	}
+
+	// 2. 排除标签
+	excluded := make(map[targetKey]bool)
+	if len(seg.ExcludeTags) > 0 {
+		tagging := tasks.NewTaggingManager(s.db.WithContext(ctx))
+		for _, typ := range []string{TargetGroup, TargetPrivate} {
+			ids, err := tagging.GetTargetsByTags(tagTypes[typ], seg.ExcludeTags, "OR")
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				excluded[targetKey{typ, id}] = true
+			}
+		}
+	}
+
+	// 3. 属性过滤与机器人分配
+	var recipients []Recipient
+	for _, k := range order {
+		if excluded[k] {
+			continue
+		}
+		t := explicit[k]
+		var contact *Contact
+		for i, c := range reach[k] {
+			if t.BotID == "" || c.BotID == t.BotID {
+				contact = &reach[k][i]
+				if matchAttrs(c.Attrs, seg.Attributes) {
+					break
+				}
+			}
+		}
+		if len(seg.Attributes) > 0 && (contact == nil || !matchAttrs(contact.Attrs, seg.Attributes)) {
+			continue
+		}
+
+		r := Recipient{TargetType: t.Type, TargetID: t.ID, GuildID: t.GuildID, BotID: t.BotID, Platform: t.Platform, Status: DeliveryQueued}
+		vars := map[string]string{"id": t.ID, "type": t.Type, "name": t.ID}
+		if contact != nil {
+			if r.BotID == "" {
+				r.BotID = contact.BotID
+			}
+			if r.Platform == "" {
+				r.Platform = contact.Platform
+			}
+			if contact.Name != "" {
+				vars["name"] = contact.Name
+			}
+			for a, v := range contact.Attrs {
+				vars[a] = v
+			}
+		}
+		if r.BotID == "" && len(seg.BotIDs) > 0 {
+			r.BotID = seg.BotIDs[0]
+		}
+		if r.BotID == "" {
+			r.Status, r.Error = DeliverySkipped, "no bot can reach this target"
+		}
+		vars["bot_id"] = r.BotID
+		raw, _ := json.Marshal(vars)
+		r.Vars = string(raw)
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+func matchAttrs(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
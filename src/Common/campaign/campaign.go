@@ -0,0 +1,409 @@
+// Package campaign 群发活动：按标签、群列表与联系人属性圈选受众，支持定时发送、按时区的免打扰时段、
+// 按接收者渲染的模板内容、按机器人限速投递，以及暂停、恢复与取消。每个接收者单独记录投递状态。
+package campaign
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 活动状态
+const (
+	StatusScheduled = "scheduled" // 等待发送时间
+	StatusRunning   = "running"   // 投递中
+	StatusPaused    = "paused"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// 接收者投递状态
+const (
+	DeliveryQueued  = "queued"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliverySkipped = "skipped"
+)
+
+var (
+	// ErrNotFound 活动不存在
+	ErrNotFound = errors.New("campaign not found")
+	// ErrInvalidState 当前状态不允许该操作
+	ErrInvalidState = errors.New("campaign state does not allow this operation")
+	// ErrEmptyAudience 圈选结果为空
+	ErrEmptyAudience = errors.New("campaign audience is empty")
+)
+
+// Campaign 群发活动
+type Campaign struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Name          string     `gorm:"size:255" json:"name"`
+	Template      string     `gorm:"type:text" json:"template"`
+	Vars          string     `gorm:"type:text" json:"-"` // 活动级模板变量 (map[string]string 的 JSON)
+	Audience      string     `gorm:"type:text" json:"-"` // 圈选条件 (Segment 的 JSON)
+	SendAt        time.Time  `gorm:"index" json:"send_at"`
+	TimeZone      string     `gorm:"size:64" json:"time_zone"`
+	QuietStart    string     `gorm:"size:5" json:"quiet_start"` // HH:MM，与 QuietEnd 相同表示不设免打扰
+	QuietEnd      string     `gorm:"size:5" json:"quiet_end"`
+	RatePerMinute int        `json:"rate_per_minute"` // 每个机器人每分钟发送数，0 表示使用平台默认值
+	Status        string     `gorm:"size:20;index" json:"status"`
+	CreatedBy     string     `gorm:"size:64" json:"created_by"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (Campaign) TableName() string {
+	return "broadcast_campaigns"
+}
+
+// Recipient 活动的一个接收者
+type Recipient struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CampaignID    uint       `gorm:"uniqueIndex:idx_campaign_recipient,priority:1;index:idx_campaign_delivery,priority:1" json:"campaign_id"`
+	BotID         string     `gorm:"size:64;uniqueIndex:idx_campaign_recipient,priority:2" json:"bot_id"`
+	Platform      string     `gorm:"size:32" json:"platform"`
+	TargetType    string     `gorm:"size:20;uniqueIndex:idx_campaign_recipient,priority:3" json:"target_type"`
+	TargetID      string     `gorm:"size:64;uniqueIndex:idx_campaign_recipient,priority:4" json:"target_id"`
+	GuildID       string     `gorm:"size:64" json:"guild_id,omitempty"`
+	Vars          string     `gorm:"type:text" json:"-"` // 接收者模板变量 (map[string]string 的 JSON)
+	Status        string     `gorm:"size:20;index:idx_campaign_delivery,priority:2" json:"status"`
+	Attempts      int        `json:"attempts"`
+	Error         string     `gorm:"type:text" json:"error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (Recipient) TableName() string {
+	return "broadcast_recipients"
+}
+
+// Target 投递目标
+type Target struct {
+	BotID    string `json:"bot_id"`
+	Platform string `json:"platform,omitempty"`
+	Type     string `json:"type"` // group, private, guild
+	ID       string `json:"id"`
+	GuildID  string `json:"guild_id,omitempty"`
+}
+
+// Sender 将消息发送给目标，返回错误表示本次投递失败
+type Sender interface {
+	Send(ctx context.Context, t Target, message string) error
+}
+
+// Options 活动参数，零值字段使用默认值
+type Options struct {
+	DefaultRate   int            // 每个机器人每分钟默认发送数，默认 20
+	PlatformRates map[string]int // 按平台覆盖默认发送速率
+	MaxAttempts   int            // 单个接收者最多尝试次数，默认 3
+	RetryBackoff  time.Duration  // 首次重试等待时间，之后逐次翻倍，默认 30s
+	SendTimeout   time.Duration  // 单次发送超时，默认 15s
+	PollInterval  time.Duration  // 投递循环间隔，默认 1s
+	ShouldRun     func() bool    // 返回 false 时跳过本轮投递，集群模式下只由 leader 投递；nil 表示始终投递
+}
+
+func (o Options) withDefaults() Options {
+	if o.DefaultRate <= 0 {
+		o.DefaultRate = 20
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 30 * time.Second
+	}
+	if o.SendTimeout <= 0 {
+		o.SendTimeout = 15 * time.Second
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	return o
+}
+
+// Service 群发活动服务
+type Service struct {
+	db     *gorm.DB
+	dir    Directory
+	sender Sender
+	opts   Options
+	now    func() time.Time
+
+	mu       sync.Mutex
+	nextSend map[string]time.Time // 机器人 → 下次允许发送的时间
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// New 创建活动服务，dir 为空时属性圈选不可用
+func New(db *gorm.DB, dir Directory, sender Sender, opts Options) *Service {
+	return &Service{
+		db:       db,
+		dir:      dir,
+		sender:   sender,
+		opts:     opts.withDefaults(),
+		now:      time.Now,
+		nextSend: make(map[string]time.Time),
+	}
+}
+
+// Migrate 创建活动与接收者表
+func (s *Service) Migrate() error {
+	return s.db.AutoMigrate(&Campaign{}, &Recipient{})
+}
+
+// Start 建表并启动投递循环
+func (s *Service) Start(ctx context.Context) error {
+	if err := s.Migrate(); err != nil {
+		return err
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.run(ctx)
+	return nil
+}
+
+// Stop 停止投递循环
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Spec 创建活动的参数
+type Spec struct {
+	Name          string            `json:"name"`
+	Template      string            `json:"template"` // text/template 语法，如 "{{.name}} 你好"
+	Vars          map[string]string `json:"vars"`     // 活动级变量，接收者变量同名时优先
+	Audience      Segment           `json:"audience"`
+	SendAt        time.Time         `json:"send_at"` // 零值表示立即发送
+	TimeZone      string            `json:"time_zone"`
+	QuietStart    string            `json:"quiet_start"`
+	QuietEnd      string            `json:"quiet_end"`
+	RatePerMinute int               `json:"rate_per_minute"`
+	CreatedBy     string            `json:"-"`
+}
+
+// Create 校验参数、圈选受众并创建活动，接收者在创建时确定
+func (s *Service) Create(ctx context.Context, spec Spec) (*Campaign, error) {
+	if strings.TrimSpace(spec.Template) == "" {
+		return nil, errors.New("campaign template is empty")
+	}
+	if _, err := parseTemplate(spec.Template); err != nil {
+		return nil, err
+	}
+	sched := Schedule{TimeZone: spec.TimeZone, QuietStart: spec.QuietStart, QuietEnd: spec.QuietEnd}
+	if err := sched.Validate(); err != nil {
+		return nil, err
+	}
+
+	recipients, err := s.Resolve(ctx, spec.Audience)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, ErrEmptyAudience
+	}
+
+	vars, _ := json.Marshal(spec.Vars)
+	audience, _ := json.Marshal(spec.Audience)
+	sendAt := spec.SendAt
+	if sendAt.IsZero() {
+		sendAt = s.now()
+	}
+	c := &Campaign{
+		Name:          spec.Name,
+		Template:      spec.Template,
+		Vars:          string(vars),
+		Audience:      string(audience),
+		SendAt:        sendAt,
+		TimeZone:      spec.TimeZone,
+		QuietStart:    spec.QuietStart,
+		QuietEnd:      spec.QuietEnd,
+		RatePerMinute: spec.RatePerMinute,
+		Status:        StatusScheduled,
+		CreatedBy:     spec.CreatedBy,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		for i := range recipients {
+			recipients[i].CampaignID = c.ID
+			recipients[i].NextAttemptAt = sendAt
+		}
+		return tx.CreateInBatches(recipients, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get 查询活动
+func (s *Service) Get(ctx context.Context, id uint) (*Campaign, error) {
+	var c Campaign
+	err := s.db.WithContext(ctx).First(&c, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return &c, err
+}
+
+// List 按创建时间倒序列出活动，status 为空表示全部
+func (s *Service) List(ctx context.Context, status string, limit int) ([]Campaign, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	q := s.db.WithContext(ctx).Order("id DESC").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var list []Campaign
+	err := q.Find(&list).Error
+	return list, err
+}
+
+// Progress 活动各投递状态的接收者数量
+type Progress struct {
+	Total   int64 `json:"total"`
+	Queued  int64 `json:"queued"`
+	Sent    int64 `json:"sent"`
+	Failed  int64 `json:"failed"`
+	Skipped int64 `json:"skipped"`
+}
+
+// Progress 统计活动的投递进度
+func (s *Service) Progress(ctx context.Context, id uint) (Progress, error) {
+	var rows []struct {
+		Status string
+		N      int64
+	}
+	var p Progress
+	err := s.db.WithContext(ctx).Model(&Recipient{}).Select("status, COUNT(*) AS n").
+		Where("campaign_id = ?", id).Group("status").Scan(&rows).Error
+	if err != nil {
+		return p, err
+	}
+	for _, r := range rows {
+		p.Total += r.N
+		switch r.Status {
+		case DeliveryQueued:
+			p.Queued = r.N
+		case DeliverySent:
+			p.Sent = r.N
+		case DeliveryFailed:
+			p.Failed = r.N
+		case DeliverySkipped:
+			p.Skipped = r.N
+		}
+	}
+	return p, nil
+}
+
+// Recipients 分页列出活动接收者，status 为空表示全部
+func (s *Service) Recipients(ctx context.Context, id uint, status string, offset, limit int) ([]Recipient, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	q := s.db.WithContext(ctx).Where("campaign_id = ?", id).Order("id").Offset(offset).Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var list []Recipient
+	err := q.Find(&list).Error
+	return list, err
+}
+
+// Pause 暂停未结束的活动，正在发送的一条消息不受影响
+func (s *Service) Pause(ctx context.Context, id uint) error {
+	return s.transition(ctx, id, StatusPaused, StatusScheduled, StatusRunning)
+}
+
+// Resume 恢复已暂停的活动
+func (s *Service) Resume(ctx context.Context, id uint) error {
+	c, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	next := StatusScheduled
+	if c.StartedAt != nil {
+		next = StatusRunning
+	}
+	return s.transition(ctx, id, next, StatusPaused)
+}
+
+// Cancel 取消活动，尚未发送的接收者标记为 skipped
+func (s *Service) Cancel(ctx context.Context, id uint) error {
+	if err := s.transition(ctx, id, StatusCancelled, StatusScheduled, StatusRunning, StatusPaused); err != nil {
+		return err
+	}
+	now := s.now()
+	return s.db.WithContext(ctx).Model(&Recipient{}).
+		Where("campaign_id = ? AND status = ?", id, DeliveryQueued).
+		Updates(map[string]any{"status": DeliverySkipped, "error": "campaign cancelled", "updated_at": now}).Error
+}
+
+// transition 仅当活动处于 from 之一时将其改为 to
+func (s *Service) transition(ctx context.Context, id uint, to string, from ...string) error {
+	updates := map[string]any{"status": to, "updated_at": s.now()}
+	if to == StatusCancelled {
+		updates["finished_at"] = s.now()
+	}
+	res := s.db.WithContext(ctx).Model(&Campaign{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		if _, err := s.Get(ctx, id); err != nil {
+			return err
+		}
+		return ErrInvalidState
+	}
+	return nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("campaign").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid campaign template: %w", err)
+	}
+	return t, nil
+}
+
+// Literal 将普通文本转换为按原文输出的模板，用于不含变量的消息
+func Literal(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// Render 用活动变量与接收者变量渲染模板，接收者变量同名时优先
+func Render(text string, campaignVars, recipientVars map[string]string) (string, error) {
+	t, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	data := make(map[string]string, len(campaignVars)+len(recipientVars))
+	for k, v := range campaignVars {
+		data[k] = v
+	}
+	for k, v := range recipientVars {
+		data[k] = v
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
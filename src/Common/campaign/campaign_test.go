@@ -0,0 +1,302 @@
+package campaign
+
+import (
+	"BotMatrix/common/models"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// consoleSender 模拟 ConsoleBot：记录收到的发送动作并回复成功，failFor 中的目标返回失败
+type consoleSender struct {
+	mu      sync.Mutex
+	sent    []string
+	failFor map[string]bool
+}
+
+func (c *consoleSender) Send(_ context.Context, t Target, message string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failFor[t.ID] {
+		return errors.New("retcode 100")
+	}
+	c.sent = append(c.sent, fmt.Sprintf("%s>%s:%s %s", t.BotID, t.Type, t.ID, message))
+	return nil
+}
+
+func (c *consoleSender) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.sent...)
+}
+
+type staticDirectory []Contact
+
+func (d staticDirectory) Contacts(context.Context) ([]Contact, error) { return d, nil }
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestService(t *testing.T, dir Directory, sender Sender, opts Options) (*Service, *clock) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := db.AutoMigrate(&models.Tag{}); err != nil {
+		t.Fatal(err)
+	}
+	s := New(db, dir, sender, opts)
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	clk := &clock{t: time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC)} // 北京时间 12:00
+	s.now = clk.now
+	return s, clk
+}
+
+var directory = staticDirectory{
+	{BotID: "bot1", Platform: "qq", Type: TargetGroup, ID: "g1", Name: "一群", Attrs: map[string]string{"region": "east"}},
+	{BotID: "bot1", Platform: "qq", Type: TargetGroup, ID: "g2", Name: "二群", Attrs: map[string]string{"region": "west"}},
+	{BotID: "bot2", Platform: "qq", Type: TargetPrivate, ID: "u1", Name: "Alice", Attrs: map[string]string{"region": "east", "vip": "yes"}},
+	{BotID: "bot2", Platform: "qq", Type: TargetPrivate, ID: "u2", Name: "Bob", Attrs: map[string]string{"region": "east"}},
+}
+
+func targetsOf(rs []Recipient) []string {
+	var out []string
+	for _, r := range rs {
+		out = append(out, fmt.Sprintf("%s:%s@%s/%s", r.TargetType, r.TargetID, r.BotID, r.Status))
+	}
+	return out
+}
+
+func TestResolveSegments(t *testing.T) {
+	s, _ := newTestService(t, directory, &consoleSender{}, Options{})
+	ctx := context.Background()
+	for _, tag := range []models.Tag{
+		{Name: "vip", Type: "group", TargetID: "g2"},
+		{Name: "vip", Type: "friend", TargetID: "u1"},
+		{Name: "vip", Type: "friend", TargetID: "u9"},
+		{Name: "blocked", Type: "friend", TargetID: "u1"},
+	} {
+		if err := s.db.Create(&tag).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		seg  Segment
+		want []string
+	}{
+		{"tags", Segment{Tags: []string{"vip"}}, []string{"group:g2@bot1/queued", "private:u1@bot2/queued", "private:u9@/skipped"}},
+		{"exclude tags", Segment{Tags: []string{"vip"}, ExcludeTags: []string{"blocked"}, BotIDs: []string{"bot9"}}, []string{"group:g2@bot9/queued", "private:u9@bot9/queued"}},
+		{"groups and users deduplicated", Segment{GroupIDs: []string{"g1", "g1"}, UserIDs: []string{"u2"}}, []string{"group:g1@bot1/queued", "private:u2@bot2/queued"}},
+		{"attributes only", Segment{Attributes: map[string]string{"region": "east"}}, []string{"group:g1@bot1/queued", "private:u1@bot2/queued", "private:u2@bot2/queued"}},
+		{"attributes filter tags", Segment{Tags: []string{"vip"}, Attributes: map[string]string{"region": "east"}}, []string{"private:u1@bot2/queued"}},
+		{"explicit target keeps bot", Segment{Targets: []Target{{BotID: "bot3", Type: TargetGuild, ID: "c1", GuildID: "guild1"}}}, []string{"guild:c1@bot3/queued"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, err := s.Resolve(ctx, tc.seg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := targetsOf(rs); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScheduleQuietHours(t *testing.T) {
+	sched := Schedule{TimeZone: "Asia/Shanghai", QuietStart: "22:00", QuietEnd: "08:00"}
+	if err := sched.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	cases := []struct{ at, want time.Time }{
+		{time.Date(2026, 3, 2, 12, 0, 0, 0, loc), time.Date(2026, 3, 2, 12, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 2, 23, 30, 0, 0, loc), time.Date(2026, 3, 3, 8, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 3, 6, 0, 0, 0, loc), time.Date(2026, 3, 3, 8, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 3, 8, 0, 0, 0, loc), time.Date(2026, 3, 3, 8, 0, 0, 0, loc)},
+	}
+	for _, tc := range cases {
+		if got := sched.NextAllowed(tc.at.UTC()); !got.Equal(tc.want) {
+			t.Errorf("NextAllowed(%s) = %s, want %s", tc.at, got, tc.want)
+		}
+	}
+	if err := (Schedule{QuietStart: "25:00", QuietEnd: "08:00"}).Validate(); err == nil {
+		t.Fatal("invalid quiet hour accepted")
+	}
+	if err := (Schedule{TimeZone: "Mars/Olympus"}).Validate(); err == nil {
+		t.Fatal("invalid time zone accepted")
+	}
+}
+
+func TestDeliveryThrottleTemplateAndRetry(t *testing.T) {
+	sender := &consoleSender{failFor: map[string]bool{"u2": true}}
+	s, clk := newTestService(t, directory, sender, Options{MaxAttempts: 2, RetryBackoff: time.Minute})
+	ctx := context.Background()
+
+	c, err := s.Create(ctx, Spec{
+		Name:          "launch",
+		Template:      "{{.name}}，{{.product}} 上线了",
+		Vars:          map[string]string{"product": "BotMatrix"},
+		Audience:      Segment{GroupIDs: []string{"g1", "g2"}, UserIDs: []string{"u1", "u2"}},
+		RatePerMinute: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一轮每个机器人各发一条
+	if n := s.Tick(ctx); n != 2 {
+		t.Fatalf("first tick handled %d, want 2", n)
+	}
+	// 每分钟 2 条：30 秒内同一机器人不再发送
+	clk.advance(10 * time.Second)
+	if n := s.Tick(ctx); n != 0 {
+		t.Fatalf("throttled tick handled %d, want 0", n)
+	}
+	clk.advance(20 * time.Second)
+	if n := s.Tick(ctx); n != 2 {
+		t.Fatalf("second tick handled %d, want 2", n)
+	}
+	want := []string{
+		"bot1>group:g1 一群，BotMatrix 上线了",
+		"bot2>private:u1 Alice，BotMatrix 上线了",
+		"bot1>group:g2 二群，BotMatrix 上线了",
+	}
+	if got := sender.messages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %v, want %v", got, want)
+	}
+
+	// u2 首次失败后按退避重试，达到上限后标记为 failed，活动结束
+	clk.advance(time.Minute)
+	s.Tick(ctx)
+	clk.advance(time.Minute)
+	s.Tick(ctx)
+	p, err := s.Progress(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != (Progress{Total: 4, Sent: 3, Failed: 1}) {
+		t.Fatalf("progress = %+v", p)
+	}
+	failed, _ := s.Recipients(ctx, c.ID, DeliveryFailed, 0, 10)
+	if len(failed) != 1 || failed[0].Attempts != 2 || failed[0].Error == "" {
+		t.Fatalf("failed recipients = %+v", failed)
+	}
+	s.Tick(ctx)
+	if got, _ := s.Get(ctx, c.ID); got.Status != StatusCompleted || got.FinishedAt == nil {
+		t.Fatalf("campaign = %+v, want completed", got)
+	}
+}
+
+func TestScheduleAndQuietHoursDeferDelivery(t *testing.T) {
+	sender := &consoleSender{}
+	s, clk := newTestService(t, directory, sender, Options{})
+	ctx := context.Background()
+
+	// 北京时间 12:00 创建，计划 13:00 发送，免打扰 12:30-14:00
+	c, err := s.Create(ctx, Spec{
+		Template:   "hi {{.name}}",
+		Audience:   Segment{GroupIDs: []string{"g1"}},
+		SendAt:     clk.t.Add(time.Hour),
+		TimeZone:   "Asia/Shanghai",
+		QuietStart: "12:30",
+		QuietEnd:   "14:00",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Tick(ctx); n != 0 {
+		t.Fatal("delivered before send_at")
+	}
+	clk.advance(time.Hour)
+	if n := s.Tick(ctx); n != 0 {
+		t.Fatal("delivered during quiet hours")
+	}
+	if got, _ := s.Get(ctx, c.ID); got.Status != StatusScheduled {
+		t.Fatalf("status = %s, want scheduled", got.Status)
+	}
+	clk.advance(time.Hour)
+	if n := s.Tick(ctx); n != 1 {
+		t.Fatal("not delivered after quiet hours")
+	}
+	if got := sender.messages(); len(got) != 1 || got[0] != "bot1>group:g1 hi 一群" {
+		t.Fatalf("sent %v", got)
+	}
+}
+
+func TestPauseResumeCancel(t *testing.T) {
+	sender := &consoleSender{}
+	s, clk := newTestService(t, directory, sender, Options{DefaultRate: 60})
+	ctx := context.Background()
+
+	c, err := s.Create(ctx, Spec{Template: "{{.id}}", Audience: Segment{GroupIDs: []string{"g1", "g2"}, UserIDs: []string{"u1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Tick(ctx)
+	if err := s.Pause(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(time.Minute)
+	if n := s.Tick(ctx); n != 0 {
+		t.Fatal("paused campaign delivered")
+	}
+	if err := s.Pause(ctx, c.ID); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("double pause err = %v", err)
+	}
+	if err := s.Resume(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get(ctx, c.ID); got.Status != StatusRunning {
+		t.Fatalf("resumed status = %s, want running", got.Status)
+	}
+	if err := s.Cancel(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(time.Minute)
+	if n := s.Tick(ctx); n != 0 {
+		t.Fatal("cancelled campaign delivered")
+	}
+	p, _ := s.Progress(ctx, c.ID)
+	if p != (Progress{Total: 3, Sent: 2, Skipped: 1}) {
+		t.Fatalf("progress = %+v", p)
+	}
+	if err := s.Resume(ctx, c.ID); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("resume cancelled err = %v", err)
+	}
+	if err := s.Cancel(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cancel missing err = %v", err)
+	}
+}
+
+func TestCreateValidation(t *testing.T) {
+	s, _ := newTestService(t, directory, &consoleSender{}, Options{})
+	ctx := context.Background()
+	if _, err := s.Create(ctx, Spec{Template: "{{.name", Audience: Segment{GroupIDs: []string{"g1"}}}); err == nil {
+		t.Fatal("invalid template accepted")
+	}
+	if _, err := s.Create(ctx, Spec{Template: "hi", Audience: Segment{Attributes: map[string]string{"region": "north"}}}); !errors.Is(err, ErrEmptyAudience) {
+		t.Fatalf("empty audience err = %v", err)
+	}
+	if _, err := Render("{{.missing}}", nil, map[string]string{"name": "x"}); err == nil {
+		t.Fatal("missing variable rendered")
+	}
+}
@@ -0,0 +1,165 @@
+package campaign
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+func (s *Service) run(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.opts.ShouldRun != nil && !s.opts.ShouldRun() {
+				continue
+			}
+			s.Tick(ctx)
+		}
+	}
+}
+
+// Tick 执行一轮投递：每个到期且不在免打扰时段的活动，为每个未被限速的机器人发送一条消息。返回本轮处理的接收者数
+func (s *Service) Tick(ctx context.Context) int {
+	now := s.now()
+	var campaigns []Campaign
+	err := s.db.WithContext(ctx).Where("status IN ? AND send_at <= ?", []string{StatusScheduled, StatusRunning}, now).
+		Order("id").Find(&campaigns).Error
+	if err != nil {
+		log.Printf("[Campaign] 查询待投递活动失败: %v", err)
+		return 0
+	}
+	handled := 0
+	for i := range campaigns {
+		handled += s.deliver(ctx, &campaigns[i], now)
+	}
+	return handled
+}
+
+func (s *Service) deliver(ctx context.Context, c *Campaign, now time.Time) int {
+	db := s.db.WithContext(ctx)
+
+	var queued int64
+	if err := db.Model(&Recipient{}).Where("campaign_id = ? AND status = ?", c.ID, DeliveryQueued).Count(&queued).Error; err != nil {
+		return 0
+	}
+	if queued == 0 {
+		db.Model(&Campaign{}).Where("id = ? AND status IN ?", c.ID, []string{StatusScheduled, StatusRunning}).
+			Updates(map[string]any{"status": StatusCompleted, "finished_at": now, "updated_at": now})
+		return 0
+	}
+
+	sched := Schedule{TimeZone: c.TimeZone, QuietStart: c.QuietStart, QuietEnd: c.QuietEnd}
+	if sched.NextAllowed(now).After(now) {
+		return 0
+	}
+	if c.Status == StatusScheduled {
+		res := db.Model(&Campaign{}).Where("id = ? AND status = ?", c.ID, StatusScheduled).
+			Updates(map[string]any{"status": StatusRunning, "started_at": now, "updated_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return 0
+		}
+	}
+
+	var bots []string
+	if err := db.Model(&Recipient{}).Distinct("bot_id").
+		Where("campaign_id = ? AND status = ? AND next_attempt_at <= ?", c.ID, DeliveryQueued, now).
+		Order("bot_id").Pluck("bot_id", &bots).Error; err != nil {
+		return 0
+	}
+
+	var campaignVars map[string]string
+	json.Unmarshal([]byte(c.Vars), &campaignVars)
+
+	handled := 0
+	for _, botID := range bots {
+		var r Recipient
+		err := db.Where("campaign_id = ? AND bot_id = ? AND status = ? AND next_attempt_at <= ?", c.ID, botID, DeliveryQueued, now).
+			Order("id").First(&r).Error
+		if err != nil {
+			continue
+		}
+		if !s.acquire(botID, s.rateFor(c, r.Platform), now) {
+			continue
+		}
+		s.deliverOne(ctx, c, campaignVars, &r, now)
+		handled++
+	}
+	return handled
+}
+
+// rateFor 返回活动在该平台上每个机器人每分钟的发送数
+func (s *Service) rateFor(c *Campaign, platform string) int {
+	if c.RatePerMinute > 0 {
+		return c.RatePerMinute
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.opts.PlatformRates[platform]; r > 0 {
+		return r
+	}
+	return s.opts.DefaultRate
+}
+
+// SetRates 修改默认与按平台的发送速率，对之后的投递立即生效；defaultRate 为 0 时使用默认值
+func (s *Service) SetRates(defaultRate int, platformRates map[string]int) {
+	opts := Options{DefaultRate: defaultRate}.withDefaults()
+	rates := make(map[string]int, len(platformRates))
+	for k, v := range platformRates {
+		rates[k] = v
+	}
+	s.mu.Lock()
+	s.opts.DefaultRate = opts.DefaultRate
+	s.opts.PlatformRates = rates
+	s.mu.Unlock()
+}
+
+// acquire 按机器人限速，多个活动共用同一机器人时共享配额
+func (s *Service) acquire(botID string, perMinute int, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Before(s.nextSend[botID]) {
+		return false
+	}
+	s.nextSend[botID] = now.Add(time.Minute / time.Duration(perMinute))
+	return true
+}
+
+func (s *Service) deliverOne(ctx context.Context, c *Campaign, campaignVars map[string]string, r *Recipient, now time.Time) {
+	db := s.db.WithContext(ctx)
+
+	var vars map[string]string
+	json.Unmarshal([]byte(r.Vars), &vars)
+	text, err := Render(c.Template, campaignVars, vars)
+	if err != nil {
+		db.Model(&Recipient{}).Where("id = ? AND status = ?", r.ID, DeliveryQueued).
+			Updates(map[string]any{"status": DeliverySkipped, "error": err.Error(), "updated_at": now})
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, s.opts.SendTimeout)
+	err = s.sender.Send(sendCtx, Target{BotID: r.BotID, Platform: r.Platform, Type: r.TargetType, ID: r.TargetID, GuildID: r.GuildID}, text)
+	cancel()
+
+	attempts := r.Attempts + 1
+	if err == nil {
+		// 已经发出的消息即使活动在发送期间被取消也记为 sent
+		db.Model(&Recipient{}).Where("id = ?", r.ID).
+			Updates(map[string]any{"status": DeliverySent, "attempts": attempts, "error": "", "sent_at": s.now(), "updated_at": s.now()})
+		return
+	}
+
+	updates := map[string]any{"attempts": attempts, "error": err.Error(), "updated_at": now}
+	if attempts >= s.opts.MaxAttempts {
+		updates["status"] = DeliveryFailed
+	} else {
+		updates["next_attempt_at"] = now.Add(s.opts.RetryBackoff << (attempts - 1))
+	}
+	db.Model(&Recipient{}).Where("id = ? AND status = ?", r.ID, DeliveryQueued).Updates(updates)
+	log.Printf("[Campaign] 活动 %d 发送给 %s:%s 失败 (第 %d 次): %v", c.ID, r.TargetType, r.TargetID, attempts, err)
+}
@@ -0,0 +1,76 @@
+package campaign
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule 免打扰时段，按 TimeZone (IANA 时区名，默认 UTC) 的当地时间计算，QuietStart 晚于 QuietEnd 表示跨零点
+type Schedule struct {
+	TimeZone   string
+	QuietStart string
+	QuietEnd   string
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.TimeZone)
+}
+
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quiet hour %q, expected HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate 校验时区与免打扰时段格式
+func (s Schedule) Validate() error {
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", s.TimeZone, err)
+	}
+	if s.QuietStart == "" && s.QuietEnd == "" {
+		return nil
+	}
+	if _, err := parseClock(s.QuietStart); err != nil {
+		return err
+	}
+	_, err := parseClock(s.QuietEnd)
+	return err
+}
+
+// NextAllowed 返回不早于 t 且不在免打扰时段内的最早时间
+func (s Schedule) NextAllowed(t time.Time) time.Time {
+	if s.QuietStart == "" || s.QuietStart == s.QuietEnd {
+		return t
+	}
+	loc, err := s.location()
+	if err != nil {
+		return t
+	}
+	start, err1 := parseClock(s.QuietStart)
+	end, err2 := parseClock(s.QuietEnd)
+	if err1 != nil || err2 != nil {
+		return t
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	var quiet bool
+	if start < end {
+		quiet = minute >= start && minute < end
+	} else {
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return t
+	}
+	day := local
+	if start > end && minute >= start {
+		day = local.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), end/60, end%60, 0, 0, loc)
+}
@@ -0,0 +1,346 @@
+// Package cluster 多个 BotNexus 节点共享 Redis 组成的集群 (active-active)。
+// 每个节点以带租期的键注册自己并定期续租；节点登记自己持有的机器人与 Worker 连接，
+// 其他节点据此把动作经 Redis pub/sub 转发给持有连接的节点；调度扫描等单例任务通过按任务的租约选出 leader。
+// 节点失联后其租约、连接登记与 leader 身份在 LeaseTTL 内自动过期
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 连接登记的类型
+const (
+	KindBot    = "bot"
+	KindWorker = "worker"
+)
+
+var (
+	// ErrNodeUnavailable 目标节点不在线 (没有订阅者)
+	ErrNodeUnavailable = errors.New("cluster: node unavailable")
+	// ErrNoHandler 目标节点没有注册对应类型的处理器
+	ErrNoHandler = errors.New("cluster: no handler")
+)
+
+// Options 集群参数，零值字段使用默认值
+type Options struct {
+	NodeID         string        // 节点 ID，默认 主机名-随机串，重启后变化
+	Addr           string        // 对外地址，仅用于展示
+	Prefix         string        // Redis 键前缀，默认 botmatrix:cluster
+	LeaseTTL       time.Duration // 节点、连接登记与 leader 租期，默认 15s
+	RenewInterval  time.Duration // 续租间隔，默认 LeaseTTL/3
+	RequestTimeout time.Duration // Call 的默认超时，默认 30s
+}
+
+func (o Options) withDefaults() Options {
+	if o.NodeID == "" {
+		host, _ := os.Hostname()
+		if host == "" {
+			host = "nexus"
+		}
+		o.NodeID = host + "-" + uuid.NewString()[:8]
+	}
+	if o.Prefix == "" {
+		o.Prefix = "botmatrix:cluster"
+	}
+	if o.LeaseTTL <= 0 {
+		o.LeaseTTL = 15 * time.Second
+	}
+	if o.RenewInterval <= 0 || o.RenewInterval >= o.LeaseTTL {
+		o.RenewInterval = o.LeaseTTL / 3
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = 30 * time.Second
+	}
+	return o
+}
+
+// NodeInfo 节点注册信息，随续租刷新
+type NodeInfo struct {
+	ID        string    `json:"id"`
+	Addr      string    `json:"addr,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	RenewedAt time.Time `json:"renewed_at"`
+	Bots      []string  `json:"bots"`
+	Workers   []string  `json:"workers"`
+	Leader    []string  `json:"leader"` // 本节点担任 leader 的单例任务
+}
+
+// Node 集群中的一个节点
+type Node struct {
+	rdb       redis.UniversalClient
+	opts      Options
+	startedAt time.Time
+
+	mu       sync.Mutex
+	owned    map[string]map[string]bool // kind → id
+	jobs     map[string]time.Time       // 单例任务 → 本节点 leader 身份的有效期，零值表示不是 leader
+	handlers map[string]Handler
+	pending  map[string]chan envelope
+
+	pubsub *redis.PubSub
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建节点，调用 Start 后加入集群
+func New(rdb redis.UniversalClient, opts Options) *Node {
+	return &Node{
+		rdb:      rdb,
+		opts:     opts.withDefaults(),
+		owned:    map[string]map[string]bool{KindBot: {}, KindWorker: {}},
+		jobs:     make(map[string]time.Time),
+		handlers: make(map[string]Handler),
+		pending:  make(map[string]chan envelope),
+	}
+}
+
+// ID 返回节点 ID
+func (n *Node) ID() string {
+	return n.opts.NodeID
+}
+
+// LeaseTTL 返回租期
+func (n *Node) LeaseTTL() time.Duration {
+	return n.opts.LeaseTTL
+}
+
+func (n *Node) key(parts ...string) string {
+	return n.opts.Prefix + ":" + strings.Join(parts, ":")
+}
+
+func (n *Node) channel(nodeID string) string {
+	return n.key("inbox", nodeID)
+}
+
+// Start 订阅本节点的消息通道、注册节点并启动续租循环。订阅在返回前已生效
+func (n *Node) Start(ctx context.Context) error {
+	n.startedAt = time.Now()
+	pubsub := n.rdb.Subscribe(ctx, n.channel(n.opts.NodeID), n.key("broadcast"))
+	for i := 0; i < 2; i++ {
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			return fmt.Errorf("cluster: subscribe: %w", err)
+		}
+	}
+	if err := n.Heartbeat(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.mu.Lock()
+	n.cancel, n.pubsub = cancel, pubsub
+	n.mu.Unlock()
+	n.wg.Add(2)
+	go n.receiveLoop(ctx, pubsub)
+	go n.renewLoop(ctx)
+	log.Printf("[Cluster] Node %s joined (lease %s)", n.opts.NodeID, n.opts.LeaseTTL)
+	return nil
+}
+
+// Stop 退出集群：释放 leader 身份与连接登记并注销节点，其他节点无需等待租约过期即可接管
+func (n *Node) Stop() {
+	n.mu.Lock()
+	stop, pubsub := n.cancel, n.pubsub
+	n.cancel, n.pubsub = nil, nil
+	n.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop()
+	pubsub.Close()
+	n.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n.mu.Lock()
+	var keys []string
+	for kind, ids := range n.owned {
+		for id := range ids {
+			keys = append(keys, n.key(kind, id))
+		}
+	}
+	for job := range n.jobs {
+		keys = append(keys, n.key("leader", job))
+		n.jobs[job] = time.Time{}
+	}
+	n.mu.Unlock()
+	for _, k := range keys {
+		releaseScript.Run(ctx, n.rdb, []string{k}, n.opts.NodeID)
+	}
+	n.rdb.Del(ctx, n.key("nodes", n.opts.NodeID))
+	log.Printf("[Cluster] Node %s left", n.opts.NodeID)
+}
+
+func (n *Node) renewLoop(ctx context.Context) {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.opts.RenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.Heartbeat(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("[Cluster] Heartbeat failed: %v", err)
+			}
+		}
+	}
+}
+
+// Heartbeat 续租一次：刷新节点注册、连接登记与 leader 租约，并竞选空缺的单例任务。
+// 由 Start 启动的循环按 RenewInterval 调用
+func (n *Node) Heartbeat(ctx context.Context) error {
+	now := time.Now()
+	ttl := n.opts.LeaseTTL
+
+	// 连接登记：仍归本节点的续期；过期后无人接手的重新登记；已被其他节点接手的不再持有
+	n.mu.Lock()
+	owned := make(map[string][]string, len(n.owned))
+	for kind, ids := range n.owned {
+		for id := range ids {
+			owned[kind] = append(owned[kind], id)
+		}
+	}
+	n.mu.Unlock()
+	for kind, ids := range owned {
+		for _, id := range ids {
+			ok, err := renewScript.Run(ctx, n.rdb, []string{n.key(kind, id)}, n.opts.NodeID, ttl.Milliseconds()).Bool()
+			if err != nil {
+				return fmt.Errorf("cluster: renew %s %s: %w", kind, id, err)
+			}
+			if !ok {
+				n.mu.Lock()
+				delete(n.owned[kind], id)
+				n.mu.Unlock()
+				log.Printf("[Cluster] %s %s is now owned by another node", kind, id)
+			}
+		}
+	}
+
+	// 单例任务：续期或竞选
+	n.mu.Lock()
+	jobs := make([]string, 0, len(n.jobs))
+	for job := range n.jobs {
+		jobs = append(jobs, job)
+	}
+	n.mu.Unlock()
+	for _, job := range jobs {
+		ok, err := campaignScript.Run(ctx, n.rdb, []string{n.key("leader", job)}, n.opts.NodeID, ttl.Milliseconds()).Bool()
+		if err != nil {
+			return fmt.Errorf("cluster: elect %s: %w", job, err)
+		}
+		n.mu.Lock()
+		was := now.Before(n.jobs[job])
+		if ok {
+			n.jobs[job] = now.Add(ttl)
+		} else {
+			n.jobs[job] = time.Time{}
+		}
+		n.mu.Unlock()
+		if ok != was {
+			log.Printf("[Cluster] Node %s leader of %s: %v", n.opts.NodeID, job, ok)
+		}
+	}
+
+	info := n.info(now)
+	data, _ := json.Marshal(info)
+	return n.rdb.Set(ctx, n.key("nodes", n.opts.NodeID), data, ttl).Err()
+}
+
+func (n *Node) info(now time.Time) NodeInfo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	info := NodeInfo{
+		ID:        n.opts.NodeID,
+		Addr:      n.opts.Addr,
+		StartedAt: n.startedAt,
+		RenewedAt: now,
+		Bots:      sortedKeys(n.owned[KindBot]),
+		Workers:   sortedKeys(n.owned[KindWorker]),
+		Leader:    []string{},
+	}
+	for job, until := range n.jobs {
+		if now.Before(until) {
+			info.Leader = append(info.Leader, job)
+		}
+	}
+	sort.Strings(info.Leader)
+	return info
+}
+
+// Nodes 返回租约仍有效的节点，按 ID 排序
+func (n *Node) Nodes(ctx context.Context) ([]NodeInfo, error) {
+	var nodes []NodeInfo
+	iter := n.rdb.Scan(ctx, 0, n.key("nodes", "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := n.rdb.Get(ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var info NodeInfo
+		if json.Unmarshal(data, &info) == nil {
+			nodes = append(nodes, info)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// 仅当键仍属于本节点时续期；键已过期则重新占有
+var renewScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if v == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if not v then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0`)
+
+// 竞选 leader：空缺时占有，已是 leader 时续期
+var campaignScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if not v then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if v == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// 仅当键仍属于本节点时删除
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
@@ -0,0 +1,152 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func startNode(t *testing.T, mr *miniredis.Miniredis, id string) *Node {
+	t.Helper()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	n := New(rdb, Options{NodeID: id, LeaseTTL: 3 * time.Second, RenewInterval: time.Hour})
+	n.Elect("scheduler")
+	if err := n.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(n.Stop)
+	return n
+}
+
+func TestRegistry(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	a, b := startNode(t, mr, "a"), startNode(t, mr, "b")
+
+	if err := a.Claim(ctx, KindBot, "qq:1"); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := b.Owner(ctx, KindBot, "qq:1"); owner != "a" {
+		t.Fatalf("owner = %q", owner)
+	}
+
+	// 机器人重连到 b 后，a 的断线注销不能覆盖 b 的登记
+	b.Claim(ctx, KindBot, "qq:1")
+	a.Release(ctx, KindBot, "qq:1")
+	if owner, _ := a.Owner(ctx, KindBot, "qq:1"); owner != "b" {
+		t.Fatalf("owner after handover = %q", owner)
+	}
+	b.Release(ctx, KindBot, "qq:1")
+	if owner, _ := a.Owner(ctx, KindBot, "qq:1"); owner != "" {
+		t.Fatalf("owner after release = %q", owner)
+	}
+
+	// 续租后节点信息列出持有的连接
+	a.Claim(ctx, KindWorker, "w1")
+	a.Heartbeat(ctx)
+	nodes, err := b.Nodes(ctx)
+	if err != nil || len(nodes) != 2 {
+		t.Fatalf("nodes = %+v, %v", nodes, err)
+	}
+	if nodes[0].ID != "a" || len(nodes[0].Workers) != 1 || nodes[0].Workers[0] != "w1" {
+		t.Fatalf("node a = %+v", nodes[0])
+	}
+
+	// 节点失联后登记随租约过期
+	mr.FastForward(4 * time.Second)
+	if owner, _ := b.Owner(ctx, KindWorker, "w1"); owner != "" {
+		t.Fatalf("expired owner = %q", owner)
+	}
+}
+
+func TestCall(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	a, b := startNode(t, mr, "a"), startNode(t, mr, "b")
+
+	b.Handle("echo", func(_ context.Context, from string, payload json.RawMessage) (any, error) {
+		var in string
+		json.Unmarshal(payload, &in)
+		return from + ":" + in, nil
+	})
+	b.Handle("fail", func(context.Context, string, json.RawMessage) (any, error) {
+		return nil, errors.New("boom")
+	})
+
+	var out string
+	if err := a.Call(ctx, "b", "echo", "hi", &out); err != nil || out != "a:hi" {
+		t.Fatalf("call = %q, %v", out, err)
+	}
+	if err := a.Call(ctx, "b", "fail", nil, nil); err == nil || err.Error() != "cluster: node b: boom" {
+		t.Fatalf("remote error = %v", err)
+	}
+	if err := a.Call(ctx, "b", "missing", nil, nil); !errors.Is(err, ErrNoHandler) {
+		t.Fatalf("missing handler = %v", err)
+	}
+	if err := a.Send(ctx, "c", "echo", "hi"); !errors.Is(err, ErrNodeUnavailable) {
+		t.Fatalf("offline node = %v", err)
+	}
+
+	got := make(chan string, 2)
+	a.Handle("reload", func(_ context.Context, from string, _ json.RawMessage) (any, error) {
+		got <- from
+		return nil, nil
+	})
+	b.Handle("reload", func(_ context.Context, from string, _ json.RawMessage) (any, error) {
+		got <- "b received from " + from
+		return nil, nil
+	})
+	if err := a.Broadcast(ctx, "reload", nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-got:
+		if v != "b received from a" {
+			t.Fatalf("broadcast delivered to %q", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("broadcast not delivered")
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	a := startNode(t, mr, "a")
+	b := startNode(t, mr, "b")
+
+	if !a.IsLeader("scheduler") || b.IsLeader("scheduler") {
+		t.Fatalf("leader a=%v b=%v", a.IsLeader("scheduler"), b.IsLeader("scheduler"))
+	}
+	if leader, _ := b.Leader(ctx, "scheduler"); leader != "a" {
+		t.Fatalf("leader = %q", leader)
+	}
+
+	// 主动退出后立即交接
+	a.Stop()
+	b.Heartbeat(ctx)
+	if !b.IsLeader("scheduler") {
+		t.Fatal("b did not take over after a left")
+	}
+
+	// leader 失联 (不再续租) 时租约过期后由其他节点接管
+	c := startNode(t, mr, "c")
+	if c.IsLeader("scheduler") {
+		t.Fatal("c should not be leader while b holds the lease")
+	}
+	mr.FastForward(4 * time.Second)
+	c.Heartbeat(ctx)
+	if !c.IsLeader("scheduler") {
+		t.Fatal("c did not take over expired lease")
+	}
+	b.Heartbeat(ctx)
+	if b.IsLeader("scheduler") {
+		t.Fatal("b still leader after losing the lease")
+	}
+}
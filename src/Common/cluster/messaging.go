@@ -0,0 +1,171 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Handler 处理其他节点发来的消息，返回值作为 Call 的结果回传 (Send 与 Broadcast 忽略返回值)
+type Handler func(ctx context.Context, from string, payload json.RawMessage) (any, error)
+
+// envelope 节点间消息，经 Redis pub/sub 投递到目标节点的 inbox 通道或广播通道
+type envelope struct {
+	Type    string          `json:"type"`
+	From    string          `json:"from"`
+	ID      string          `json:"id,omitempty"`    // Call 的请求 ID，回复时原样带回
+	Reply   bool            `json:"reply,omitempty"` // 是否为 Call 的回复
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Handle 注册消息处理器，同一类型重复注册时覆盖
+func (n *Node) Handle(typ string, h Handler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[typ] = h
+}
+
+// Send 向指定节点发送消息，不等待处理结果
+func (n *Node) Send(ctx context.Context, nodeID, typ string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return n.publish(ctx, n.channel(nodeID), envelope{Type: typ, From: n.opts.NodeID, Payload: data})
+}
+
+// Broadcast 向其他所有节点发送消息，本节点不会收到
+func (n *Node) Broadcast(ctx context.Context, typ string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	err = n.publish(ctx, n.key("broadcast"), envelope{Type: typ, From: n.opts.NodeID, Payload: data})
+	if err == ErrNodeUnavailable {
+		return nil
+	}
+	return err
+}
+
+// Call 向指定节点发送请求并等待处理器的返回值解码到 out (可为 nil)。ctx 没有截止时间时使用 RequestTimeout
+func (n *Node) Call(ctx context.Context, nodeID, typ string, payload, out any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.opts.RequestTimeout)
+		defer cancel()
+	}
+
+	id := uuid.NewString()
+	ch := make(chan envelope, 1)
+	n.mu.Lock()
+	n.pending[id] = ch
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.pending, id)
+		n.mu.Unlock()
+	}()
+
+	if err := n.publish(ctx, n.channel(nodeID), envelope{Type: typ, From: n.opts.NodeID, ID: id, Payload: data}); err != nil {
+		return err
+	}
+	select {
+	case reply := <-ch:
+		if reply.Error == ErrNoHandler.Error() {
+			return ErrNoHandler
+		}
+		if reply.Error != "" {
+			return fmt.Errorf("cluster: node %s: %s", nodeID, reply.Error)
+		}
+		if out != nil && len(reply.Payload) > 0 {
+			return json.Unmarshal(reply.Payload, out)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cluster: call %s on node %s: %w", typ, nodeID, ctx.Err())
+	}
+}
+
+func (n *Node) publish(ctx context.Context, channel string, env envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	receivers, err := n.rdb.Publish(ctx, channel, data).Result()
+	if err != nil {
+		return err
+	}
+	if receivers == 0 {
+		return ErrNodeUnavailable
+	}
+	return nil
+}
+
+func (n *Node) receiveLoop(ctx context.Context, pubsub *redis.PubSub) {
+	defer n.wg.Done()
+	for msg := range pubsub.Channel() {
+		var env envelope
+		if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+			log.Printf("[Cluster] Invalid message on %s: %v", msg.Channel, err)
+			continue
+		}
+		if env.From == n.opts.NodeID {
+			continue
+		}
+		if env.Reply {
+			n.mu.Lock()
+			ch := n.pending[env.ID]
+			n.mu.Unlock()
+			if ch != nil {
+				select {
+				case ch <- env:
+				default:
+				}
+			}
+			continue
+		}
+		// 处理器可能等待机器人响应，逐条异步处理
+		go n.dispatch(ctx, env)
+	}
+}
+
+func (n *Node) dispatch(ctx context.Context, env envelope) {
+	n.mu.Lock()
+	h := n.handlers[env.Type]
+	n.mu.Unlock()
+
+	var result any
+	var err error
+	if h == nil {
+		err = ErrNoHandler
+	} else {
+		result, err = h(ctx, env.From, env.Payload)
+	}
+	if env.ID == "" {
+		if err != nil && err != ErrNoHandler {
+			log.Printf("[Cluster] Handling %s from %s failed: %v", env.Type, env.From, err)
+		}
+		return
+	}
+
+	reply := envelope{Type: env.Type, From: n.opts.NodeID, ID: env.ID, Reply: true}
+	if err != nil {
+		reply.Error = err.Error()
+	} else if result != nil {
+		if reply.Payload, err = json.Marshal(result); err != nil {
+			reply.Error = err.Error()
+		}
+	}
+	if err := n.publish(context.Background(), n.channel(env.From), reply); err != nil {
+		log.Printf("[Cluster] Replying %s to %s failed: %v", env.Type, env.From, err)
+	}
+}
@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Claim 登记本节点持有某个连接。同一连接重连到其他节点时以最后登记的节点为准
+func (n *Node) Claim(ctx context.Context, kind, id string) error {
+	if err := n.rdb.Set(ctx, n.key(kind, id), n.opts.NodeID, n.opts.LeaseTTL).Err(); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	if n.owned[kind] == nil {
+		n.owned[kind] = make(map[string]bool)
+	}
+	n.owned[kind][id] = true
+	n.mu.Unlock()
+	return nil
+}
+
+// Release 注销连接登记，连接已被其他节点接手时保留对方的登记
+func (n *Node) Release(ctx context.Context, kind, id string) error {
+	n.mu.Lock()
+	delete(n.owned[kind], id)
+	n.mu.Unlock()
+	return releaseScript.Run(ctx, n.rdb, []string{n.key(kind, id)}, n.opts.NodeID).Err()
+}
+
+// Owner 返回持有连接的节点 ID，没有节点持有时返回空串
+func (n *Node) Owner(ctx context.Context, kind, id string) (string, error) {
+	owner, err := n.rdb.Get(ctx, n.key(kind, id)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return owner, err
+}
+
+// Owns 报告本节点是否持有连接
+func (n *Node) Owns(kind, id string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.owned[kind][id]
+}
+
+// Elect 参与单例任务的 leader 竞选，结果在下一次 Heartbeat 生效 (Start 时立即竞选)
+func (n *Node) Elect(jobs ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, job := range jobs {
+		if _, ok := n.jobs[job]; !ok {
+			n.jobs[job] = time.Time{}
+		}
+	}
+}
+
+// IsLeader 报告本节点当前是否为单例任务的 leader。续租失败超过 LeaseTTL 后视为失去 leader 身份，
+// 避免与新 leader 同时执行
+func (n *Node) IsLeader(job string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return time.Now().Before(n.jobs[job])
+}
+
+// Leader 返回单例任务当前的 leader 节点 ID，空缺时返回空串
+func (n *Node) Leader(ctx context.Context, job string) (string, error) {
+	leader, err := n.rdb.Get(ctx, n.key("leader", job)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return leader, err
+}
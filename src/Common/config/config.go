@@ -10,6 +10,12 @@ type AppConfig struct {
 	DefaultAdminPassword string `json:"default_admin_password"`
 	StatsFile            string `json:"stats_file"`
 
+	TrustedProxies []string `json:"trusted_proxies"` // 可信反向代理 (IP 或 CIDR)，仅来自这些地址的请求才采信 X-Forwarded-For
+	MetricsToken   string   `json:"metrics_token"`   // /metrics 抓取令牌 (Bearer)，为空时不校验
+
+	// OIDC 单点登录
+	OIDC OIDCConfig `json:"oidc"`
+
 	// Database Configuration
 	PGHost     string `json:"pg_host"`
 	PGPort     int    `json:"pg_port"`
@@ -27,6 +33,40 @@ type AppConfig struct {
 
 	// AI Configuration
 	AIEmbeddingModel string `json:"ai_embedding_model"`
+	RAGVectorStore   string `json:"rag_vector_store"` // auto (默认), pgvector, embedded
+	RAGVectorPath    string `json:"rag_vector_path"`  // 内置 HNSW 索引文件路径
+
+	// RAG 检索调优
+	RAGReranker       string  `json:"rag_reranker"`        // 空 (不重排), llm, cross-encoder
+	RAGRerankEndpoint string  `json:"rag_rerank_endpoint"` // cross-encoder 的 /rerank 接口地址
+	RAGRerankModel    string  `json:"rag_rerank_model"`
+	RAGRerankAPIKey   string  `json:"rag_rerank_api_key"`
+	RAGMinSimilarity  float64 `json:"rag_min_similarity"` // 向量召回的最低余弦相似度
+	RAGMinScore       float64 `json:"rag_min_score"`      // 重排后的最低相关度
+
+	// 知识库异步入库
+	IngestWorkers    int                     `json:"ingest_workers"`    // 并发入库任务数，默认 2
+	KnowledgeSources []KnowledgeSourceConfig `json:"knowledge_sources"` // 增量同步的数据源
+
+	// 消息归档
+	MessageArchiveSearch        string `json:"message_archive_search"`         // auto (默认), tsvector, index
+	MessageArchiveRetentionDays int    `json:"message_archive_retention_days"` // 未单独配置的机器人的保留天数，0 表示永久保留
+
+	// 消息频率限制，Redis 中的动态配置 (botmatrix:config:ratelimit) 优先
+	RateLimit RateLimitConfig `json:"rate_limit"`
+
+	// 群发活动
+	CampaignRatePerMinute int            `json:"campaign_rate_per_minute"` // 每个机器人每分钟发送数，默认 20
+	CampaignPlatformRates map[string]int `json:"campaign_platform_rates"`  // 按平台覆盖发送速率，如 {"wechat": 10}
+
+	// 密钥存储主密钥文件，环境变量 BOTMATRIX_MASTER_KEY / BOTMATRIX_MASTER_KEY_FILE 优先
+	SecretsMasterKeyFile string `json:"secrets_master_key_file"`
+
+	// 分布式追踪 (OpenTelemetry)
+	Tracing TracingConfig `json:"tracing"`
+
+	// 多节点集群 (共享 Redis)
+	Cluster ClusterConfig `json:"cluster"`
 
 	// Feature Flags
 	EnableSkill           bool   `json:"enable_skill"`
@@ -40,6 +80,74 @@ type AppConfig struct {
 	AzureTranslateRegion   string `json:"azure_translate_region"`
 }
 
+// RateLimitConfig 每分钟消息上限，0 表示使用默认值
+type RateLimitConfig struct {
+	UserPerMinute  int `json:"user_per_minute"`  // 单个用户，默认 20
+	GroupPerMinute int `json:"group_per_minute"` // 单个群，默认 100
+}
+
+// TracingConfig OpenTelemetry 追踪导出配置
+type TracingConfig struct {
+	Exporter    string            `json:"exporter"`     // none (默认), stdout, file, otlp
+	Endpoint    string            `json:"endpoint"`     // OTLP/HTTP 地址，如 localhost:4318 或 https://otel.example.com/v1/traces
+	Insecure    bool              `json:"insecure"`     // OTLP 使用 HTTP 而非 HTTPS
+	Headers     map[string]string `json:"headers"`      // OTLP 请求头，如鉴权令牌
+	File        string            `json:"file"`         // file 导出器写入的文件 (每行一个 JSON span)
+	SampleRatio float64           `json:"sample_ratio"` // 采样比例 (0, 1]，0 表示全部采样；上游已采样的请求始终跟随
+}
+
+// ClusterConfig BotNexus 集群配置，启用后多个节点共享 Redis 中的连接登记并选举单例任务的 leader
+type ClusterConfig struct {
+	Enabled      bool   `json:"enabled"`
+	NodeID       string `json:"node_id"`       // 节点 ID，默认 主机名-随机串
+	Addr         string `json:"addr"`          // 节点对外地址，仅用于展示
+	LeaseSeconds int    `json:"lease_seconds"` // 节点租期，默认 15 秒，节点失联后经过该时长由其他节点接管
+}
+
+// OIDCConfig OIDC/OAuth2 单点登录配置 (授权码 + PKCE)
+type OIDCConfig struct {
+	Enabled               bool              `json:"enabled"`
+	Issuer                string            `json:"issuer"` // IdP 地址，通过 /.well-known/openid-configuration 发现端点
+	ClientID              string            `json:"client_id"`
+	ClientSecret          string            `json:"client_secret"`  // 公共客户端可留空，仅依赖 PKCE
+	RedirectURL           string            `json:"redirect_url"`   // 回调地址，如 https://nexus.example.com/api/auth/oidc/callback
+	Scopes                []string          `json:"scopes"`         // 默认 openid profile email
+	UsernameClaim         string            `json:"username_claim"` // 默认 preferred_username，缺失时使用 email
+	GroupsClaim           string            `json:"groups_claim"`   // 默认 groups
+	AdminGroups           []string          `json:"admin_groups"`   // 这些组的成员获得管理员标记；为空时不同步管理员标记
+	RoleMappings          []OIDCRoleMapping `json:"role_mappings"`
+	EnterpriseClaim       string            `json:"enterprise_claim"` // 该声明的值作为企业 ID，在企业范围内授予 EnterpriseRole
+	EnterpriseRole        string            `json:"enterprise_role"`  // 默认 operator
+	Enforce               bool              `json:"enforce"`          // 强制 SSO：禁用本地密码登录与注册，默认 admin 账号保留作为应急入口
+	PostLogoutRedirectURL string            `json:"post_logout_redirect_url"`
+}
+
+// OIDCRoleMapping 将 IdP 组映射为 BotMatrix 角色授权
+type OIDCRoleMapping struct {
+	Group     string `json:"group"`
+	Role      string `json:"role"`
+	ScopeType string `json:"scope_type"` // 默认 global
+	ScopeID   string `json:"scope_id"`
+}
+
+// KnowledgeSourceConfig 知识库数据源
+type KnowledgeSourceConfig struct {
+	Type       string   `json:"type"`       // directory, git, sitemap, imap
+	Path       string   `json:"path"`       // directory: 本地目录
+	URL        string   `json:"url"`        // git: 仓库地址; sitemap: sitemap.xml 地址
+	Branch     string   `json:"branch"`     // git: 分支，默认远端默认分支
+	Prefix     string   `json:"prefix"`     // sitemap: 只同步以此前缀开头的页面
+	Extensions []string `json:"extensions"` // directory/git: 同步的文件扩展名，默认 .md .txt
+	Host       string   `json:"host"`       // imap: host:port
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Mailbox    string   `json:"mailbox"` // imap: 默认 INBOX
+	DocType    string   `json:"doc_type"`
+	TargetType string   `json:"target_type"` // 授权对象，默认 system
+	TargetID   string   `json:"target_id"`
+	Interval   int      `json:"interval"` // 同步间隔 (秒)，默认 3600
+}
+
 // ConnectionConfig represents a connection configuration
 type ConnectionConfig struct {
 	Name     string `json:"name"`
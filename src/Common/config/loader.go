@@ -2,11 +2,12 @@ package config
 
 import (
 	"encoding/json"
-	"log"
 	"os"
+	"strings"
 )
 
-// GlobalConfig is the global configuration instance
+// GlobalConfig is the configuration loaded at startup. Hot reloads do not
+// update it; read reloadable settings through Current instead.
 var GlobalConfig = &AppConfig{}
 
 const CONFIG_FILE = "config.json"
@@ -24,6 +25,7 @@ var (
 const (
 	REDIS_KEY_QUEUE_DEFAULT    = "botmatrix:queue:default"
 	REDIS_KEY_QUEUE_WORKER     = "botmatrix:queue:worker:%s"
+	REDIS_KEY_QUEUE_GROUP      = "botmatrix:group:workers"
 	REDIS_KEY_RATELIMIT_USER   = "botmatrix:ratelimit:user:%s"
 	REDIS_KEY_RATELIMIT_GROUP  = "botmatrix:ratelimit:group:%s"
 	REDIS_KEY_IDEMPOTENCY      = "botmatrix:msg:idempotency:%s"
@@ -34,6 +36,15 @@ const (
 	REDIS_KEY_CONFIG_TTL       = "botmatrix:config:ttl"
 )
 
+// std 为 InitConfig 使用的默认重载器，启动时加载的配置同步到 GlobalConfig
+var std = newStd(CONFIG_FILE)
+
+func newStd(path string) *Reloader {
+	r := NewReloader(path, ReloadOptions{})
+	r.mirror = GlobalConfig
+	return r
+}
+
 // InitConfig initializes the global configuration
 func InitConfig(path string) error {
 	resolvedPath := CONFIG_FILE
@@ -41,8 +52,8 @@ func InitConfig(path string) error {
 		resolvedPath = path
 	}
 
-	loadConfigFromFile(resolvedPath)
-	loadConfigFromEnv()
+	std = newStd(resolvedPath)
+	std.load(GlobalConfig)
 
 	// Sync backward compatibility constants
 	if GlobalConfig.WSPort != "" {
@@ -62,48 +73,109 @@ func InitConfig(path string) error {
 	return nil
 }
 
-func loadConfigFromFile(path string) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		log.Printf("Warning: Config file %s not found, using default/env values", path)
-		return
-	}
+// Default 返回 InitConfig 加载配置所用的重载器
+func Default() *Reloader {
+	return std
+}
 
-	if err := json.Unmarshal(data, GlobalConfig); err != nil {
-		log.Printf("Error: Failed to parse config file: %v", err)
-	} else {
-		log.Printf("Successfully loaded config from %s", path)
-	}
+// Current 返回当前生效的配置快照，调用方不得修改
+func Current() *AppConfig {
+	return std.Current()
+}
+
+// OnChange 订阅默认重载器上顶层字段 key 的变更，如 OnChange("rate_limit", fn)
+func OnChange(key string, fn func(ChangeEvent) error) func() {
+	return std.OnChange(key, fn)
 }
 
 func loadConfigFromEnv() {
+	applyEnv(GlobalConfig)
+}
+
+// applyEnv 用环境变量覆盖 cfg 中的对应字段
+func applyEnv(cfg *AppConfig) {
 	if val := os.Getenv("WS_PORT"); val != "" {
-		GlobalConfig.WSPort = val
+		cfg.WSPort = val
 	}
 	if val := os.Getenv("WEBUI_PORT"); val != "" {
-		GlobalConfig.WebUIPort = val
+		cfg.WebUIPort = val
 	}
 	if val := os.Getenv("REDIS_ADDR"); val != "" {
-		GlobalConfig.RedisAddr = val
+		cfg.RedisAddr = val
 	}
 	if val := os.Getenv("REDIS_PWD"); val != "" {
-		GlobalConfig.RedisPwd = val
+		cfg.RedisPwd = val
 	}
 	if val := os.Getenv("JWT_SECRET"); val != "" {
-		GlobalConfig.JWTSecret = val
+		cfg.JWTSecret = val
+	}
+	if val := os.Getenv("TRUSTED_PROXIES"); val != "" {
+		cfg.TrustedProxies = strings.Split(val, ",")
+	}
+	if val := os.Getenv("METRICS_TOKEN"); val != "" {
+		cfg.MetricsToken = val
+	}
+	if val := os.Getenv("TRACING_EXPORTER"); val != "" {
+		cfg.Tracing.Exporter = val
+	}
+	if val := os.Getenv("TRACING_FILE"); val != "" {
+		cfg.Tracing.File = val
+	}
+	if val := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); val != "" {
+		cfg.Tracing.Endpoint = val
+		if cfg.Tracing.Exporter == "" {
+			cfg.Tracing.Exporter = "otlp"
+		}
+	}
+	if val := os.Getenv("CLUSTER_ENABLED"); val != "" {
+		cfg.Cluster.Enabled = val == "true" || val == "1"
+	}
+	if val := os.Getenv("CLUSTER_NODE_ID"); val != "" {
+		cfg.Cluster.NodeID = val
+	}
+	if val := os.Getenv("OIDC_ISSUER"); val != "" {
+		cfg.OIDC.Enabled = true
+		cfg.OIDC.Issuer = val
+	}
+	if val := os.Getenv("OIDC_CLIENT_ID"); val != "" {
+		cfg.OIDC.ClientID = val
+	}
+	if val := os.Getenv("OIDC_CLIENT_SECRET"); val != "" {
+		cfg.OIDC.ClientSecret = val
+	}
+	if val := os.Getenv("OIDC_REDIRECT_URL"); val != "" {
+		cfg.OIDC.RedirectURL = val
+	}
+	if val := os.Getenv("OIDC_ENFORCE"); val != "" {
+		cfg.OIDC.Enforce = val == "true" || val == "1"
 	}
 	if val := os.Getenv("PG_HOST"); val != "" {
-		GlobalConfig.PGHost = val
+		cfg.PGHost = val
 	}
 	if val := os.Getenv("AI_EMBEDDING_MODEL"); val != "" {
-		GlobalConfig.AIEmbeddingModel = val
+		cfg.AIEmbeddingModel = val
+	}
+	if val := os.Getenv("RAG_VECTOR_STORE"); val != "" {
+		cfg.RAGVectorStore = val
+	}
+	if val := os.Getenv("RAG_VECTOR_PATH"); val != "" {
+		cfg.RAGVectorPath = val
+	}
+	if val := os.Getenv("RAG_RERANKER"); val != "" {
+		cfg.RAGReranker = val
+	}
+	if val := os.Getenv("RAG_RERANK_ENDPOINT"); val != "" {
+		cfg.RAGRerankEndpoint = val
+	}
+	if val := os.Getenv("RAG_RERANK_API_KEY"); val != "" {
+		cfg.RAGRerankAPIKey = val
 	}
 	// ... add other env vars as needed
 }
 
 // GetResolvedConfigPath returns the absolute path to the config file
 func GetResolvedConfigPath() string {
-	return CONFIG_FILE
+	return std.Path()
 }
 
 // SaveConfig persists configuration to disk
@@ -112,5 +184,5 @@ func SaveConfig(cfg *AppConfig) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(CONFIG_FILE, data, 0644)
+	return os.WriteFile(GetResolvedConfigPath(), data, 0644)
 }
@@ -0,0 +1,427 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 重载结果
+const (
+	ReloadApplied    = "applied"
+	ReloadRejected   = "rejected"    // 解析或校验失败，原配置继续生效
+	ReloadRolledBack = "rolled_back" // 订阅者拒绝变更，已恢复原配置
+)
+
+// restartKeys 只在启动时读取的字段，修改后需要重启才能生效
+var restartKeys = map[string]bool{
+	"ws_port": true, "webui_port": true, "redis_addr": true, "redis_pwd": true, "stats_file": true,
+	"metrics_token": true, "oidc": true,
+	"pg_host": true, "pg_port": true, "pg_user": true, "pg_password": true, "pg_dbname": true, "pg_sslmode": true,
+	"mssql_host": true, "mssql_port": true, "mssql_user": true, "mssql_password": true, "mssql_dbname": true,
+	"ai_embedding_model": true, "rag_vector_store": true, "rag_vector_path": true,
+	"rag_reranker": true, "rag_rerank_endpoint": true, "rag_rerank_model": true, "rag_rerank_api_key": true,
+	"rag_min_similarity": true, "rag_min_score": true,
+	"ingest_workers": true, "knowledge_sources": true,
+	"message_archive_search": true, "enable_skill": true, "enable_digital_employee": true,
+	"secrets_master_key_file": true, "tracing": true, "cluster": true,
+}
+
+// ChangeEvent 配置变更事件，Old 与 New 为只读快照
+type ChangeEvent struct {
+	Version int64
+	Source  string   // startup、file 或 api:<用户名>
+	Keys    []string // 发生变化的顶层字段 (JSON 名)，如 rate_limit
+	Old     *AppConfig
+	New     *AppConfig
+}
+
+// Has 判断事件是否包含某个顶层字段
+func (e ChangeEvent) Has(key string) bool {
+	for _, k := range e.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadRecord 一次重载的结果
+type ReloadRecord struct {
+	Version         int64        `json:"version"` // 重载后生效的版本
+	Time            time.Time    `json:"time"`
+	Source          string       `json:"source"`
+	Status          string       `json:"status"`
+	Keys            []string     `json:"keys,omitempty"`
+	RestartRequired []string     `json:"restart_required,omitempty"`
+	Error           string       `json:"error,omitempty"`
+	Fields          []FieldError `json:"fields,omitempty"` // 校验失败的字段
+}
+
+// ReloadOptions 重载参数，零值字段使用默认值
+type ReloadOptions struct {
+	Debounce    time.Duration // 合并文件连续变更的等待时间，默认 500ms
+	HistorySize int           // 保留的重载记录数，默认 50
+}
+
+func (o ReloadOptions) withDefaults() ReloadOptions {
+	if o.Debounce <= 0 {
+		o.Debounce = 500 * time.Millisecond
+	}
+	if o.HistorySize <= 0 {
+		o.HistorySize = 50
+	}
+	return o
+}
+
+type subscription struct {
+	key string
+	fn  func(ChangeEvent) error
+}
+
+// Reloader 管理配置文件的加载、校验与热更新。
+// 变更以整份快照原子替换；订阅者按注册顺序收到通知，任一订阅者返回错误时整体回滚
+type Reloader struct {
+	path string
+	opts ReloadOptions
+
+	current atomic.Pointer[AppConfig]
+	mirror  *AppConfig // 兼容直接读取 GlobalConfig 的代码，仅在启动加载时写入；之后的变更需通过 Current 读取
+
+	mu      sync.Mutex // 串行化重载
+	version int64
+	lastSum [sha256.Size]byte
+
+	subMu   sync.Mutex // 保护 subs 与 history
+	subs    []*subscription
+	history []ReloadRecord
+}
+
+// NewReloader 创建配置重载器，初始配置为空
+func NewReloader(path string, opts ReloadOptions) *Reloader {
+	r := &Reloader{path: path, opts: opts.withDefaults()}
+	r.current.Store(&AppConfig{})
+	return r
+}
+
+// Path 返回配置文件路径
+func (r *Reloader) Path() string {
+	return r.path
+}
+
+// Current 返回当前生效的配置快照，调用方不得修改
+func (r *Reloader) Current() *AppConfig {
+	return r.current.Load()
+}
+
+// Version 返回当前配置版本，每次生效的变更加一
+func (r *Reloader) Version() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.version
+}
+
+// OnChange 订阅顶层字段 key (JSON 名，"*" 表示任意字段) 的变更，返回取消订阅函数。
+// fn 在重载过程中同步调用，不能再调用 Reload 或 Update
+func (r *Reloader) OnChange(key string, fn func(ChangeEvent) error) func() {
+	s := &subscription{key: key, fn: fn}
+	r.subMu.Lock()
+	r.subs = append(r.subs, s)
+	r.subMu.Unlock()
+	return func() {
+		r.subMu.Lock()
+		defer r.subMu.Unlock()
+		for i, sub := range r.subs {
+			if sub == s {
+				r.subs = append(r.subs[:i], r.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// History 返回最近的重载记录，最新的在前
+func (r *Reloader) History() []ReloadRecord {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	out := make([]ReloadRecord, len(r.history))
+	for i, rec := range r.history {
+		out[len(r.history)-1-i] = rec
+	}
+	return out
+}
+
+// load 启动时加载：在 base 的副本上叠加配置文件与环境变量。
+// 为兼容已有部署，文件缺失、解析或校验失败只记录日志
+func (r *Reloader) load(base *AppConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := clone(base)
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		log.Printf("Warning: Config file %s not found, using default/env values", r.path)
+	} else if err := json.Unmarshal(data, next); err != nil {
+		log.Printf("Error: Failed to parse config file: %v", err)
+	} else {
+		log.Printf("Successfully loaded config from %s", r.path)
+	}
+	r.lastSum = sha256.Sum256(data)
+	applyEnv(next)
+
+	rec := ReloadRecord{Time: time.Now(), Source: "startup", Status: ReloadApplied}
+	if err := Validate(next); err != nil {
+		log.Printf("Warning: %v", err)
+		rec.Error = err.Error()
+		rec.Fields = err.(*ValidationError).Fields
+	}
+	r.version++
+	rec.Version = r.version
+	r.swap(next)
+	if r.mirror != nil {
+		// 启动阶段尚无并发读者，可以直接覆盖；热更新后不再写入，避免与读取 GlobalConfig 的代码竞争
+		*r.mirror = *clone(next)
+	}
+	r.record(rec)
+}
+
+// Reload 重新读取配置文件并叠加环境变量，文件内容未变化时不做任何事
+func (r *Reloader) Reload(source string) error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sum := sha256.Sum256(data)
+	if sum == r.lastSum {
+		return nil
+	}
+	r.lastSum = sum
+
+	next := &AppConfig{}
+	if err := json.Unmarshal(data, next); err != nil {
+		err = fmt.Errorf("parse %s: %w", r.path, err)
+		r.reject(source, err)
+		return err
+	}
+	applyEnv(next)
+	_, err = r.commit(next, source)
+	return err
+}
+
+// Update 以当前配置为基础合并 patch (JSON 对象，只需包含要修改的字段)，
+// 校验通过后写回配置文件并生效
+func (r *Reloader) Update(patch []byte, source string) (ReloadRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.Current()
+	next := clone(old)
+	if err := json.Unmarshal(patch, next); err != nil {
+		err = fmt.Errorf("parse config patch: %w", err)
+		return r.reject(source, err), err
+	}
+	if err := Validate(next); err != nil {
+		return r.reject(source, err), err
+	}
+	if len(diffKeys(old, next)) == 0 {
+		return ReloadRecord{Version: r.version, Time: time.Now(), Source: source, Status: ReloadApplied}, nil
+	}
+
+	// 先写文件再生效，订阅者回滚时恢复原文件
+	if err := r.write(next); err != nil {
+		return ReloadRecord{}, err
+	}
+	rec, err := r.commit(next, source)
+	if err != nil && rec.Status == ReloadRolledBack {
+		if werr := r.write(old); werr != nil {
+			log.Printf("[Config] 回滚后恢复配置文件失败: %v", werr)
+		}
+	}
+	return rec, err
+}
+
+// Watch 监听配置文件，变更在 Debounce 时间内没有新的写入后重载；ctx 结束时返回
+func (r *Reloader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// 监听所在目录，编辑器保存时常以重命名替换文件
+	abs, err := filepath.Abs(r.path)
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(abs)); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(r.opts.Debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == abs && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				timer.Reset(r.opts.Debounce)
+			}
+		case <-timer.C:
+			if err := r.Reload("file"); err != nil {
+				log.Printf("[Config] 重载 %s 失败，继续使用原配置: %v", r.path, err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("[Config] 监听配置文件出错: %v", err)
+		}
+	}
+}
+
+// commit 校验并原子替换配置，然后通知订阅者；调用方持有 r.mu
+func (r *Reloader) commit(next *AppConfig, source string) (ReloadRecord, error) {
+	if err := Validate(next); err != nil {
+		return r.reject(source, err), err
+	}
+	old := r.Current()
+	keys := diffKeys(old, next)
+	if len(keys) == 0 {
+		return ReloadRecord{Version: r.version, Time: time.Now(), Source: source, Status: ReloadApplied}, nil
+	}
+
+	ev := ChangeEvent{Version: r.version + 1, Source: source, Keys: keys, Old: old, New: next}
+	r.subMu.Lock()
+	var subs []*subscription
+	for _, s := range r.subs {
+		if s.key == "*" || ev.Has(s.key) {
+			subs = append(subs, s)
+		}
+	}
+	r.subMu.Unlock()
+
+	r.swap(next)
+	for i, s := range subs {
+		if err := s.fn(ev); err != nil {
+			// 恢复原配置，并让已经应用变更的订阅者改回去
+			r.swap(old)
+			revert := ChangeEvent{Version: r.version, Source: source, Keys: keys, Old: next, New: old}
+			for j := i - 1; j >= 0; j-- {
+				if rerr := subs[j].fn(revert); rerr != nil {
+					log.Printf("[Config] 回滚 %s 失败: %v", subs[j].key, rerr)
+				}
+			}
+			err = fmt.Errorf("config change to %s rejected: %w", s.key, err)
+			rec := ReloadRecord{Version: r.version, Time: time.Now(), Source: source, Status: ReloadRolledBack, Keys: keys, Error: err.Error()}
+			log.Printf("[Config] %s 的变更已回滚: %v", source, err)
+			r.record(rec)
+			return rec, err
+		}
+	}
+
+	r.version = ev.Version
+	rec := ReloadRecord{Version: r.version, Time: time.Now(), Source: source, Status: ReloadApplied, Keys: keys}
+	for _, k := range keys {
+		if restartKeys[k] {
+			rec.RestartRequired = append(rec.RestartRequired, k)
+		}
+	}
+	if len(rec.RestartRequired) > 0 {
+		log.Printf("[Config] 配置 v%d 已生效 (%s): %v，其中 %v 需要重启后生效", r.version, source, keys, rec.RestartRequired)
+	} else {
+		log.Printf("[Config] 配置 v%d 已生效 (%s): %v", r.version, source, keys)
+	}
+	r.record(rec)
+	return rec, nil
+}
+
+func (r *Reloader) reject(source string, err error) ReloadRecord {
+	rec := ReloadRecord{Version: r.version, Time: time.Now(), Source: source, Status: ReloadRejected, Error: err.Error()}
+	if verr, ok := err.(*ValidationError); ok {
+		rec.Fields = verr.Fields
+	}
+	log.Printf("[Config] 拒绝来自 %s 的配置: %v", source, err)
+	r.record(rec)
+	return rec
+}
+
+func (r *Reloader) swap(cfg *AppConfig) {
+	r.current.Store(cfg)
+}
+
+func (r *Reloader) record(rec ReloadRecord) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	r.history = append(r.history, rec)
+	if over := len(r.history) - r.opts.HistorySize; over > 0 {
+		r.history = append([]ReloadRecord(nil), r.history[over:]...)
+	}
+}
+
+// write 将配置写回文件；先写临时文件再替换，替换失败 (如容器中单文件挂载) 时直接覆盖
+func (r *Reloader) write(cfg *AppConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		if err = os.Rename(tmp, r.path); err != nil {
+			os.Remove(tmp)
+			err = os.WriteFile(r.path, data, 0644)
+		}
+		if err != nil {
+			return err
+		}
+	} else if err := os.WriteFile(r.path, data, 0644); err != nil {
+		return err
+	}
+	// 自身写入不再触发重载
+	r.lastSum = sha256.Sum256(data)
+	return nil
+}
+
+// clone 通过 JSON 深拷贝配置，避免快照之间共享切片与 map
+func clone(cfg *AppConfig) *AppConfig {
+	out := &AppConfig{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, out)
+	}
+	return out
+}
+
+// diffKeys 返回取值不同的顶层字段名
+func diffKeys(a, b *AppConfig) []string {
+	var ma, mb map[string]json.RawMessage
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	json.Unmarshal(da, &ma)
+	json.Unmarshal(db, &mb)
+	var keys []string
+	for k, v := range mb {
+		if !bytes.Equal(ma[k], v) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
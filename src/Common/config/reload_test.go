@@ -0,0 +1,223 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestReloader(t *testing.T, content string) *Reloader {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReloader(path, ReloadOptions{Debounce: 50 * time.Millisecond})
+	r.load(&AppConfig{})
+	return r
+}
+
+func TestValidateNamesFieldPaths(t *testing.T) {
+	cfg := &AppConfig{
+		WSPort:                "8080",
+		TrustedProxies:        []string{"10.0.0.0/8", "not-an-ip"},
+		RAGReranker:           "cross-encoder",
+		RateLimit:             RateLimitConfig{UserPerMinute: -1},
+		CampaignPlatformRates: map[string]int{"qq": 10, "wechat": -5},
+		KnowledgeSources:      []KnowledgeSourceConfig{{Type: "directory", Path: "docs"}, {Type: "ftp"}},
+		OIDC: OIDCConfig{
+			Enabled:      true,
+			Issuer:       "https://sso.example.com",
+			RoleMappings: []OIDCRoleMapping{{Group: "ops"}},
+		},
+	}
+	err := Validate(cfg)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want *ValidationError", err)
+	}
+	var paths []string
+	for _, f := range verr.Fields {
+		paths = append(paths, f.Path)
+	}
+	want := []string{
+		"ws_port",
+		"trusted_proxies[1]",
+		"rag_rerank_endpoint",
+		"knowledge_sources[1].type",
+		"rate_limit.user_per_minute",
+		"campaign_platform_rates.wechat",
+		"oidc.client_id",
+		"oidc.redirect_url",
+		"oidc.role_mappings[0].role",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths = %v\nwant    %v", paths, want)
+	}
+
+	if err := Validate(&AppConfig{WSPort: ":8080", RedisAddr: "redis:6379", LogLevel: "DEBUG"}); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+}
+
+func TestUpdateNotifiesSubscribersAndPersists(t *testing.T) {
+	r := newTestReloader(t, `{"log_level":"INFO","rate_limit":{"user_per_minute":20}}`)
+
+	var got []ChangeEvent
+	r.OnChange("rate_limit", func(ev ChangeEvent) error {
+		got = append(got, ev)
+		return nil
+	})
+	r.OnChange("log_level", func(ev ChangeEvent) error {
+		t.Errorf("log_level subscriber called for %v", ev.Keys)
+		return nil
+	})
+
+	rec, err := r.Update([]byte(`{"rate_limit":{"user_per_minute":5,"group_per_minute":50},"ws_port":":9090"}`), "api:admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != ReloadApplied || rec.Version != 2 || !reflect.DeepEqual(rec.Keys, []string{"rate_limit", "ws_port"}) {
+		t.Fatalf("record = %+v", rec)
+	}
+	if !reflect.DeepEqual(rec.RestartRequired, []string{"ws_port"}) {
+		t.Fatalf("restart required = %v", rec.RestartRequired)
+	}
+	if len(got) != 1 || got[0].Old.RateLimit.UserPerMinute != 20 || got[0].New.RateLimit.GroupPerMinute != 50 {
+		t.Fatalf("events = %+v", got)
+	}
+	if cur := r.Current(); cur.RateLimit.UserPerMinute != 5 || cur.LogLevel != "INFO" {
+		t.Fatalf("current = %+v", cur.RateLimit)
+	}
+
+	// 写回的文件不会被再次当作变更
+	if err := r.Reload("file"); err != nil || len(got) != 1 {
+		t.Fatalf("reload after own write: err=%v events=%d", err, len(got))
+	}
+	fresh := NewReloader(r.Path(), ReloadOptions{})
+	fresh.load(&AppConfig{})
+	if fresh.Current().RateLimit.GroupPerMinute != 50 {
+		t.Fatalf("update was not persisted: %+v", fresh.Current().RateLimit)
+	}
+}
+
+func TestMirrorIsOnlyWrittenAtStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"log_level":"INFO"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mirror := &AppConfig{}
+	r := NewReloader(path, ReloadOptions{})
+	r.mirror = mirror
+	r.load(&AppConfig{})
+	if mirror.LogLevel != "INFO" {
+		t.Fatalf("mirror not filled at startup: %q", mirror.LogLevel)
+	}
+
+	// 热更新期间并发读取镜像不应产生数据竞争 (go test -race)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = mirror.LogLevel
+		}
+	}()
+	if _, err := r.Update([]byte(`{"log_level":"DEBUG"}`), "api:admin"); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if mirror.LogLevel != "INFO" || r.Current().LogLevel != "DEBUG" {
+		t.Fatalf("mirror=%q current=%q", mirror.LogLevel, r.Current().LogLevel)
+	}
+}
+
+func TestInvalidAndRejectedChangesRollBack(t *testing.T) {
+	r := newTestReloader(t, `{"campaign_rate_per_minute":20}`)
+
+	if _, err := r.Update([]byte(`{"campaign_rate_per_minute":-1}`), "api:admin"); err == nil {
+		t.Fatal("invalid update accepted")
+	}
+
+	applied := 0
+	r.OnChange("campaign_rate_per_minute", func(ev ChangeEvent) error {
+		applied = ev.New.CampaignRatePerMinute
+		return nil
+	})
+	r.OnChange("*", func(ev ChangeEvent) error {
+		if ev.New.CampaignRatePerMinute > 100 {
+			return errors.New("too fast")
+		}
+		return nil
+	})
+	rec, err := r.Update([]byte(`{"campaign_rate_per_minute":500}`), "api:admin")
+	if err == nil || rec.Status != ReloadRolledBack {
+		t.Fatalf("rec = %+v, err = %v", rec, err)
+	}
+	// 先收到变更的订阅者被通知改回原值
+	if applied != 20 || r.Current().CampaignRatePerMinute != 20 || r.Version() != 1 {
+		t.Fatalf("after rollback: applied=%d current=%d version=%d", applied, r.Current().CampaignRatePerMinute, r.Version())
+	}
+	var onDisk AppConfig
+	data, _ := os.ReadFile(r.Path())
+	if err := json.Unmarshal(data, &onDisk); err != nil || onDisk.CampaignRatePerMinute != 20 {
+		t.Fatalf("config file not restored: %s", data)
+	}
+
+	hist := r.History()
+	statuses := make([]string, len(hist))
+	for i, h := range hist {
+		statuses[i] = h.Status
+	}
+	if !reflect.DeepEqual(statuses, []string{ReloadRolledBack, ReloadRejected, ReloadApplied}) {
+		t.Fatalf("history = %v", statuses)
+	}
+	if hist[1].Fields[0].Path != "campaign_rate_per_minute" {
+		t.Fatalf("rejected fields = %+v", hist[1].Fields)
+	}
+}
+
+func TestWatchDebouncesFileEdits(t *testing.T) {
+	r := newTestReloader(t, `{"log_level":"INFO"}`)
+	events := make(chan ChangeEvent, 10)
+	r.OnChange("log_level", func(ev ChangeEvent) error {
+		events <- ev
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	// 连续多次写入只触发一次重载
+	for _, level := range []string{"WARN", "ERROR", "DEBUG"} {
+		os.WriteFile(r.Path(), []byte(`{"log_level":"`+level+`"}`), 0644)
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case ev := <-events:
+		if ev.Source != "file" || ev.New.LogLevel != "DEBUG" {
+			t.Fatalf("event = %+v", ev)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("file change was not applied")
+	}
+
+	// 不合法的编辑被拒绝，原配置继续生效
+	os.WriteFile(r.Path(), []byte(`{"log_level":"loud"}`), 0644)
+	deadline := time.Now().Add(3 * time.Second)
+	for r.History()[0].Status != ReloadRejected {
+		if time.Now().After(deadline) {
+			t.Fatalf("invalid edit not rejected: %+v", r.History()[0])
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(events) != 0 || r.Current().LogLevel != "DEBUG" {
+		t.Fatalf("invalid edit applied: level=%s events=%d", r.Current().LogLevel, len(events))
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError 单个字段的校验错误，Path 为 JSON 字段路径，如 oidc.role_mappings[0].group
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError 配置校验失败时返回，包含全部不合法的字段
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Path+": "+f.Message)
+	}
+	return "invalid config: " + strings.Join(parts, "; ")
+}
+
+// Validate 按字段校验配置，不合法时返回 *ValidationError
+func Validate(cfg *AppConfig) error {
+	v := &validator{}
+
+	v.listenAddr("ws_port", cfg.WSPort)
+	v.listenAddr("webui_port", cfg.WebUIPort)
+	if cfg.RedisAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.RedisAddr); err != nil {
+			v.addf("redis_addr", "must be host:port")
+		}
+	}
+	for i, p := range cfg.TrustedProxies {
+		p = strings.TrimSpace(p)
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				v.addf(fmt.Sprintf("trusted_proxies[%d]", i), "must be an IP address or CIDR, got %q", p)
+			}
+		}
+	}
+
+	v.port("pg_port", cfg.PGPort)
+	v.oneOf("pg_sslmode", cfg.PGSSLMode, "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	v.port("mssql_port", cfg.MSSQLPort)
+
+	v.oneOf("rag_vector_store", cfg.RAGVectorStore, "", "auto", "pgvector", "embedded")
+	v.oneOf("rag_reranker", cfg.RAGReranker, "", "llm", "cross-encoder")
+	if cfg.RAGReranker == "cross-encoder" && cfg.RAGRerankEndpoint == "" {
+		v.addf("rag_rerank_endpoint", "required when rag_reranker is cross-encoder")
+	}
+	v.url("rag_rerank_endpoint", cfg.RAGRerankEndpoint)
+	if cfg.RAGMinSimilarity < 0 || cfg.RAGMinSimilarity > 1 {
+		v.addf("rag_min_similarity", "must be between 0 and 1")
+	}
+	if cfg.RAGMinScore < 0 {
+		v.addf("rag_min_score", "must not be negative")
+	}
+
+	v.nonNegative("ingest_workers", cfg.IngestWorkers)
+	for i, src := range cfg.KnowledgeSources {
+		path := fmt.Sprintf("knowledge_sources[%d]", i)
+		switch src.Type {
+		case "directory":
+			v.required(path+".path", src.Path)
+		case "git", "sitemap":
+			v.required(path+".url", src.URL)
+		case "imap":
+			v.required(path+".host", src.Host)
+		default:
+			v.addf(path+".type", "must be one of directory, git, sitemap, imap, got %q", src.Type)
+		}
+		v.nonNegative(path+".interval", src.Interval)
+	}
+
+	v.oneOf("message_archive_search", cfg.MessageArchiveSearch, "", "auto", "tsvector", "index")
+	v.nonNegative("message_archive_retention_days", cfg.MessageArchiveRetentionDays)
+
+	v.nonNegative("rate_limit.user_per_minute", cfg.RateLimit.UserPerMinute)
+	v.nonNegative("rate_limit.group_per_minute", cfg.RateLimit.GroupPerMinute)
+
+	v.nonNegative("campaign_rate_per_minute", cfg.CampaignRatePerMinute)
+	platforms := make([]string, 0, len(cfg.CampaignPlatformRates))
+	for p := range cfg.CampaignPlatformRates {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	for _, p := range platforms {
+		v.nonNegative("campaign_platform_rates."+p, cfg.CampaignPlatformRates[p])
+	}
+
+	v.oneOf("log_level", strings.ToLower(cfg.LogLevel), "", "debug", "info", "warn", "warning", "error")
+
+	v.oneOf("tracing.exporter", cfg.Tracing.Exporter, "", "none", "stdout", "file", "otlp")
+	switch cfg.Tracing.Exporter {
+	case "file":
+		v.required("tracing.file", cfg.Tracing.File)
+	case "otlp":
+		v.required("tracing.endpoint", cfg.Tracing.Endpoint)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	v.nonNegative("cluster.lease_seconds", cfg.Cluster.LeaseSeconds)
+	if cfg.Cluster.LeaseSeconds > 0 && cfg.Cluster.LeaseSeconds < 3 {
+		v.addf("cluster.lease_seconds", "must be at least 3")
+	}
+
+	if cfg.OIDC.Enabled {
+		v.required("oidc.issuer", cfg.OIDC.Issuer)
+		v.required("oidc.client_id", cfg.OIDC.ClientID)
+		v.required("oidc.redirect_url", cfg.OIDC.RedirectURL)
+	}
+	v.url("oidc.issuer", cfg.OIDC.Issuer)
+	v.url("oidc.redirect_url", cfg.OIDC.RedirectURL)
+	for i, rm := range cfg.OIDC.RoleMappings {
+		path := fmt.Sprintf("oidc.role_mappings[%d]", i)
+		v.required(path+".group", rm.Group)
+		v.required(path+".role", rm.Role)
+	}
+
+	if len(v.fields) > 0 {
+		return &ValidationError{Fields: v.fields}
+	}
+	return nil
+}
+
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.fields = append(v.fields, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf(path, "is required")
+	}
+}
+
+func (v *validator) nonNegative(path string, n int) {
+	if n < 0 {
+		v.addf(path, "must not be negative")
+	}
+}
+
+func (v *validator) port(path string, n int) {
+	if n < 0 || n > 65535 {
+		v.addf(path, "must be between 0 and 65535")
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	names := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+		if a != "" {
+			names = append(names, a)
+		}
+	}
+	v.addf(path, "must be one of %s, got %q", strings.Join(names, ", "), value)
+}
+
+// listenAddr 校验 ":8080" 或 "host:port" 形式的监听地址
+func (v *validator) listenAddr(path, addr string) {
+	if addr == "" {
+		return
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		v.addf(path, "must be :port or host:port, got %q", addr)
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		v.addf(path, "invalid port %q", port)
+	}
+}
+
+func (v *validator) url(path, raw string) {
+	if raw == "" {
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(path, "must be an http(s) URL, got %q", raw)
+	}
+}
@@ -3,16 +3,21 @@ package common
 import (
 	"BotMatrix/common/ai"
 	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/identity"
 	clog "BotMatrix/common/log"
 	"BotMatrix/common/models"
+	"BotMatrix/common/moderation"
 	"BotMatrix/common/types"
+	"BotMatrix/common/urlfilter"
 	"BotMatrix/common/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -63,12 +68,45 @@ type MessageFlowControl struct {
 type SensitiveWords struct {
 	Levels     FilterSet `json:"levels"`
 	MatchModes []string  `json:"match_modes"` // exact, prefix, regex
+	// Rules are scoped words with per-hit actions; Levels entries are treated as unscoped block rules
+	Rules         []moderation.Rule `json:"rules,omitempty"`
+	DefaultAction string            `json:"default_action,omitempty"` // block, escalate, mask, warn
+	DisablePinyin bool              `json:"disable_pinyin,omitempty"`
+}
+
+// engineRules merges the legacy level lists with the scoped rules. Legacy system entries
+// that look like regular expressions are also matched as regex, as they were before.
+func (s SensitiveWords) engineRules() []moderation.Rule {
+	rules := make([]moderation.Rule, 0, len(s.Rules)+len(s.Levels.System)+len(s.Levels.Robot)+len(s.Levels.Group))
+	for _, word := range s.Levels.System {
+		rules = append(rules, moderation.Rule{Word: word, Level: moderation.LevelSystem})
+		if regexp.QuoteMeta(word) != word {
+			if _, err := regexp.Compile(word); err == nil {
+				rules = append(rules, moderation.Rule{Word: word, Level: moderation.LevelSystem, Mode: moderation.ModeRegex})
+			}
+		}
+	}
+	for _, word := range s.Levels.Robot {
+		rules = append(rules, moderation.Rule{Word: word, Level: moderation.LevelRobot})
+	}
+	for _, word := range s.Levels.Group {
+		rules = append(rules, moderation.Rule{Word: word, Level: moderation.LevelGroup})
+	}
+	return append(rules, s.Rules...)
+}
+
+func (s SensitiveWords) engineOptions() moderation.Options {
+	return moderation.Options{Modes: s.MatchModes, DefaultAction: s.DefaultAction, DisablePinyin: s.DisablePinyin}
 }
 
 type URLFilter struct {
 	Whitelist  []string `json:"whitelist"`
 	Blacklist  []string `json:"blacklist"`
 	MatchModes []string `json:"match_modes"` // exact, domain_suffix, regex
+	// ExpandShortLinks resolves links on ShortLinkDomains and checks their targets as well
+	ExpandShortLinks bool     `json:"expand_short_links,omitempty"`
+	ShortLinkDomains []string `json:"short_link_domains,omitempty"` // defaults to urlfilter.DefaultShortLinkDomains
+	ResolveTimeoutMs int      `json:"resolve_timeout_ms,omitempty"` // defaults to 2000
 }
 
 type Statistics struct {
@@ -118,12 +156,18 @@ type CorePlugin struct {
 	Config  CorePluginConfig
 	Mutex   sync.RWMutex
 
-	// Compiled regex patterns for optimization
-	sensitiveRegex []*regexp.Regexp
-	urlRegex       []*regexp.Regexp
+	// Compiled patterns for optimization; the sensitive word engine is rebuilt only when the word list changes
+	wordFilter *moderation.Engine
+	urlFilter  *urlfilter.Filter
+
+	// URLResolver expands short links when URLFilter.ExpandShortLinks is set; nil uses an HTTP resolver
+	URLResolver urlfilter.Resolver
 
 	// Internal state
 	isOpen bool
+
+	identityOnce sync.Once
+	identitySvc  *identity.Service
 }
 
 func NewCorePlugin(m *Manager) *CorePlugin {
@@ -156,6 +200,7 @@ func NewCorePlugin(m *Manager) *CorePlugin {
 					"system_event":  "always_forward",
 					"admin_command": "always_forward",
 					"token_login":   "always_forward",
+					"identity_bind": "always_forward",
 				},
 			},
 			SensitiveWords: SensitiveWords{
@@ -203,6 +248,7 @@ func NewCorePlugin(m *Manager) *CorePlugin {
 		isOpen: true,
 	}
 
+	p.RecompileRegex()
 	// Initial sync from Redis if available
 	p.SyncFromRedis()
 
@@ -260,27 +306,50 @@ func (p *CorePlugin) RecompileRegex() {
 	p.Mutex.Lock()
 	defer p.Mutex.Unlock()
 
-	p.sensitiveRegex = nil
-	for _, pattern := range p.Config.SensitiveWords.Levels.System {
-		if reg, err := regexp.Compile(pattern); err == nil {
-			p.sensitiveRegex = append(p.sensitiveRegex, reg)
+	rules, opts := p.Config.SensitiveWords.engineRules(), p.Config.SensitiveWords.engineOptions()
+	if p.wordFilter == nil || p.wordFilter.Version() != moderation.Version(rules, opts) {
+		engine, err := moderation.New(rules, opts)
+		if err != nil {
+			clog.Warn("[Core] Some sensitive word rules were skipped", zap.Error(err))
 		}
+		p.wordFilter = engine
 	}
 
-	p.urlRegex = nil
-	for _, pattern := range p.Config.URLFilter.Blacklist {
-		if reg, err := regexp.Compile(pattern); err == nil {
-			p.urlRegex = append(p.urlRegex, reg)
+	policy, err := urlfilter.NewPolicy(urlfilter.Options{
+		Whitelist:      p.Config.URLFilter.Whitelist,
+		Blacklist:      p.Config.URLFilter.Blacklist,
+		Modes:          p.Config.URLFilter.MatchModes,
+		WhitelistFirst: p.Config.FlowPriority.WhitelistHighest,
+	})
+	if err != nil {
+		clog.Warn("[Core] Some URL filter entries were skipped", zap.Error(err))
+	}
+	var resolver urlfilter.Resolver
+	if p.Config.URLFilter.ExpandShortLinks {
+		resolver = p.URLResolver
+		if resolver == nil {
+			resolver = urlfilter.NewHTTPResolver(p.urlResolveTimeout())
 		}
 	}
+	p.urlFilter = urlfilter.NewFilter(policy, resolver, p.Config.URLFilter.ShortLinkDomains)
 }
 
-// ProcessMessage checks if a message should be allowed through the system
+func (p *CorePlugin) urlResolveTimeout() time.Duration {
+	if p.Config.URLFilter.ResolveTimeoutMs > 0 {
+		return time.Duration(p.Config.URLFilter.ResolveTimeoutMs) * time.Millisecond
+	}
+	return 2 * time.Second
+}
+
+// ProcessMessage checks if a message should be allowed through the system.
+// Moderation may rewrite the message in place (masking) or annotate msg.Extras (warnings).
 // Returns: allowed (bool), reason (string), error
-func (p *CorePlugin) ProcessMessage(msg types.InternalMessage) (bool, string, error) {
+func (p *CorePlugin) ProcessMessage(m *types.InternalMessage) (bool, string, error) {
 	p.Mutex.RLock()
 	defer p.Mutex.RUnlock()
 
+	msg := *m
+
 	if !p.Config.Enabled {
 		return true, "", nil
 	}
@@ -308,12 +377,13 @@ func (p *CorePlugin) ProcessMessage(msg types.InternalMessage) (bool, string, er
 	}
 
 	// 5. Content filtering (Sensitive words & URLs)
-	if allowed, reason := p.checkContent(msg); !allowed {
+	if allowed, reason := p.checkContent(m); !allowed {
 		if p.Config.Statistics.Enable && p.Config.Statistics.RecordShortCircuitMessages {
 			go p.RecordBlockedMessage(msg, reason)
 		}
 		return false, reason, nil
 	}
+	msg = *m
 
 	// 6. Record success statistics
 	if p.Config.Statistics.Enable {
@@ -423,7 +493,21 @@ func (p *CorePlugin) HandleFileUpload(msg types.InternalMessage) (string, error)
 					return
 				}
 
-				// 2. Index content using rag.Indexer
+				// 2. 启用了入库队列时交给队列处理 (可查询进度、失败重试)
+				if ingestor := kb.Ingestor(); ingestor != nil {
+					jobID, err := ingestor.Enqueue(ctx, rag.IngestRequest{
+						Title: name, Source: url, Content: content, DocType: "upload",
+						UploaderID: uploaderID, TargetType: tType, TargetID: tID,
+					})
+					if err != nil {
+						clog.Error("[RAG] 创建入库任务失败", zap.String("file", name), zap.Error(err))
+					} else {
+						clog.Info("[RAG] 文件已加入入库队列", zap.String("file", name), zap.Uint("job_id", jobID))
+					}
+					return
+				}
+
+				// 3. Index content using rag.Indexer
 				// We use AI service from Manager if available
 				var aiSvc ai.AIService
 				if p.Manager.AIIntegrationService != nil {
@@ -549,6 +633,9 @@ func (p *CorePlugin) IdentifyMessageType(msg types.InternalMessage) string {
 		if p.isTokenLoginCommand(msg) {
 			return "token_login"
 		}
+		if p.isIdentityBindCommand(msg) {
+			return "identity_bind"
+		}
 		return "user_message"
 	}
 	return "system_event"
@@ -650,6 +737,107 @@ func (p *CorePlugin) HandleTokenLoginCommand(msg types.InternalMessage) (string,
 	return fmt.Sprintf("🔑 临时登录令牌已生成（10分钟有效）：\n\n%s\n\n请点击链接直接登录控制台。请勿将此链接泄露给他人。", loginURL), nil
 }
 
+func (p *CorePlugin) isIdentityBindCommand(msg types.InternalMessage) bool {
+	fields := strings.Fields(p.extractTextMessage(msg))
+	return len(fields) > 0 && len(fields) <= 2 && (fields[0] == "/bind" || fields[0] == "绑定")
+}
+
+// identityService 延迟创建身份服务，绑定码兑换的错误计数需在多次调用间保留
+func (p *CorePlugin) identityService() *identity.Service {
+	p.identityOnce.Do(func() {
+		if p.Manager.GORMDB != nil {
+			p.identitySvc = identity.NewService(p.Manager.GORMDB)
+		}
+	})
+	return p.identitySvc
+}
+
+// bindTargetPlatforms 返回当前在线机器人所在的平台，用于提示绑定码的目标平台
+func (p *CorePlugin) bindTargetPlatforms() []string {
+	seen := make(map[string]bool)
+	p.Manager.Mutex.RLock()
+	for _, b := range p.Manager.Bots {
+		if b.Platform != "" {
+			seen[strings.ToLower(b.Platform)] = true
+		}
+	}
+	p.Manager.Mutex.RUnlock()
+	platforms := make([]string, 0, len(seen))
+	for platform := range seen {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// HandleIdentityBindCommand 处理跨平台账号绑定指令：
+// "/bind <平台>" 生成只能在该平台兑换的绑定码 (只有一个其他平台在线时可省略)，
+// "/bind <绑定码>" 在另一平台兑换，"/bind list" 查看已绑定账号
+func (p *CorePlugin) HandleIdentityBindCommand(msg types.InternalMessage) (string, error) {
+	svc := p.identityService()
+	if svc == nil {
+		return "❌ 系统配置错误：数据库未连接", nil
+	}
+
+	fields := strings.Fields(p.extractTextMessage(msg))
+	if len(fields) == 1 || (fields[1] != "list" && !identity.IsBindCode(fields[1])) {
+		if msg.MessageType == "group" {
+			return "🔒 为防止绑定码泄露，请私聊机器人发送 /bind 获取绑定码", nil
+		}
+		var target string
+		if len(fields) == 2 {
+			target = strings.ToLower(fields[1])
+		} else {
+			var others []string
+			for _, platform := range p.bindTargetPlatforms() {
+				if platform != strings.ToLower(msg.Platform) {
+					others = append(others, platform)
+				}
+			}
+			if len(others) != 1 {
+				reply := "🔗 请指定要绑定的平台，例如：/bind telegram"
+				if len(others) > 0 {
+					reply += "\n当前可用平台：" + strings.Join(others, "、")
+				}
+				return reply, nil
+			}
+			target = others[0]
+		}
+		code, err := svc.RequestBindCode(msg.Platform, msg.UserID, msg.SenderName, target)
+		if err != nil {
+			return "❌ 绑定码生成失败，请稍后再试", err
+		}
+		return fmt.Sprintf("🔗 账号绑定码：%s（%d分钟内有效）\n\n请在 %s 平台向机器人发送：/bind %s\n绑定后 AI 记忆和个人知识库将在各平台共享。", code, int(identity.BindCodeTTL.Minutes()), target, code), nil
+	}
+
+	if fields[1] == "list" {
+		id, err := svc.Ensure(msg.Platform, msg.UserID, msg.SenderName)
+		if err != nil {
+			return "❌ 查询失败，请稍后再试", err
+		}
+		accounts, _ := svc.Accounts(id.NexusUID)
+		var sb strings.Builder
+		sb.WriteString("🔗 已绑定的账号：")
+		for _, acc := range accounts {
+			sb.WriteString(fmt.Sprintf("\n- %s: %s", acc.Platform, acc.PlatformUID))
+		}
+		return sb.String(), nil
+	}
+
+	nexusUID, err := svc.RedeemBindCode(msg.Platform, msg.UserID, msg.SenderName, fields[1])
+	switch {
+	case errors.Is(err, identity.ErrAlreadyLinked):
+		return "✅ 该账号已与绑定码对应账号绑定，无需重复操作", nil
+	case errors.Is(err, identity.ErrInvalidCode), errors.Is(err, identity.ErrTooManyTries), errors.Is(err, identity.ErrSameAccount):
+		return "❌ " + err.Error(), nil
+	case err != nil:
+		return "❌ 绑定失败，请稍后再试", err
+	}
+
+	accounts, _ := svc.Accounts(nexusUID)
+	return fmt.Sprintf("✅ 绑定成功，当前身份已关联 %d 个平台账号。发送 /bind list 查看", len(accounts)), nil
+}
+
 func (p *CorePlugin) isInList(target string, list []string) bool {
 	for _, item := range list {
 		if item == target {
@@ -659,7 +847,7 @@ func (p *CorePlugin) isInList(target string, list []string) bool {
 	return false
 }
 
-func (p *CorePlugin) checkContent(msg types.InternalMessage) (bool, string) {
+func (p *CorePlugin) checkContent(msg *types.InternalMessage) (bool, string) {
 	message := msg.RawMessage
 	if message == "" {
 		// If raw message is empty (v12), reconstruct for content check
@@ -678,39 +866,47 @@ func (p *CorePlugin) checkContent(msg types.InternalMessage) (bool, string) {
 		message = sb.String()
 	}
 
-	if message == "" {
-		return true, ""
-	}
-
 	// 1. Sensitive words check
-	if p.Config.FlowPriority.SensitiveWordsCheck {
-		for _, word := range p.Config.SensitiveWords.Levels.System {
-			if strings.Contains(message, word) {
-				return false, "sensitive_word_detected"
+	if message != "" && p.Config.FlowPriority.SensitiveWordsCheck && p.wordFilter != nil && !p.wordFilter.Empty() {
+		target := moderation.Target{BotID: msg.SelfID}
+		if msg.MessageType == "group" {
+			target.GroupID = msg.GroupID
+		}
+		res := p.wordFilter.Check(message, target)
+		switch res.Action {
+		case moderation.ActionBlock:
+			for _, hit := range res.Hits {
+				if hit.Rule.Action == moderation.ActionBlock && hit.Rule.Mode != moderation.ModeRegex {
+					return false, "sensitive_word_detected"
+				}
 			}
+			return false, "sensitive_word_regex_detected"
+		case moderation.ActionEscalate:
+			go p.RecordModerationReview(*msg, res.Words())
+			return false, "sensitive_word_escalated"
+		case moderation.ActionMask:
+			p.maskContent(msg, target)
+			message = msg.RawMessage
 		}
-		// Regex check
-		for _, reg := range p.sensitiveRegex {
-			if reg.MatchString(message) {
-				return false, "sensitive_word_regex_detected"
+		if len(res.Hits) > 0 {
+			if msg.Extras == nil {
+				msg.Extras = make(map[string]any)
 			}
+			msg.Extras["moderation"] = map[string]any{"action": res.Action, "words": res.Words()}
 		}
 	}
 
-	// 2. URL filter check
-	if p.Config.FlowPriority.URLFilterCheck {
-		// Simple URL detection (can be improved)
-		if strings.Contains(message, "http://") || strings.Contains(message, "https://") {
-			for _, blacklistedURL := range p.Config.URLFilter.Blacklist {
-				if strings.Contains(message, blacklistedURL) {
-					return false, "blacklisted_url_detected"
-				}
-			}
-			// Regex check
-			for _, reg := range p.urlRegex {
-				if reg.MatchString(message) {
+	// 2. URL filter check: links in text, CQ codes and share/card segments, including bare domains
+	if p.Config.FlowPriority.URLFilterCheck && p.urlFilter != nil {
+		if links := urlfilter.FromMessage(*msg); len(links) > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), p.urlResolveTimeout())
+			decision, blocked := p.urlFilter.Check(ctx, links)
+			cancel()
+			if blocked {
+				if decision.Mode == urlfilter.ModeRegex {
 					return false, "blacklisted_url_regex_detected"
 				}
+				return false, "blacklisted_url_detected"
 			}
 		}
 	}
@@ -718,6 +914,55 @@ func (p *CorePlugin) checkContent(msg types.InternalMessage) (bool, string) {
 	return true, ""
 }
 
+// maskContent replaces masked sensitive words in the raw message and in every text segment.
+// Segments are checked one by one so that hit offsets stay relative to the text being rewritten.
+func (p *CorePlugin) maskContent(msg *types.InternalMessage, target moderation.Target) {
+	if msg.RawMessage != "" {
+		msg.RawMessage = p.wordFilter.Check(msg.RawMessage, target).Mask(msg.RawMessage)
+	}
+	segments := make([]types.MessageSegment, len(msg.Message))
+	copy(segments, msg.Message)
+	for i, seg := range segments {
+		if seg.Type != "text" {
+			continue
+		}
+		switch data := seg.Data.(type) {
+		case map[string]any:
+			if t, ok := data["text"].(string); ok {
+				masked := make(map[string]any, len(data))
+				for k, v := range data {
+					masked[k] = v
+				}
+				masked["text"] = p.wordFilter.Check(t, target).Mask(t)
+				segments[i].Data = masked
+			}
+		case string:
+			segments[i].Data = p.wordFilter.Check(data, target).Mask(data)
+		}
+	}
+	msg.Message = segments
+}
+
+// RecordModerationReview queues an escalated message for manual review
+func (p *CorePlugin) RecordModerationReview(msg types.InternalMessage, words []string) {
+	if p.Manager.Rdb == nil {
+		return
+	}
+	ctx := context.Background()
+	record := map[string]any{
+		"time":     time.Now().Format(time.RFC3339),
+		"bot_id":   msg.SelfID,
+		"user_id":  msg.UserID,
+		"group_id": msg.GroupID,
+		"content":  p.extractTextMessage(msg),
+		"words":    words,
+		"protocol": msg.Protocol,
+	}
+	data, _ := json.Marshal(record)
+	p.Manager.Rdb.LPush(ctx, "core:moderation:review", data)
+	p.Manager.Rdb.LTrim(ctx, "core:moderation:review", 0, 999) // Keep last 1000
+}
+
 // HandleAdminCommand processes system admin commands
 func (p *CorePlugin) HandleAdminCommand(msg types.InternalMessage) (string, error) {
 	message := msg.RawMessage
@@ -854,12 +1099,96 @@ func (p *CorePlugin) HandleAdminCommand(msg types.InternalMessage) (string, erro
 		} else {
 			return "暂不支持移除操作", nil
 		}
+	case "word":
+		return p.handleWordCommand(args), nil
 	case "reload":
 		p.SyncFromRedis()
 		return "🔄 配置已从 Redis 重新加载", nil
 	default:
-		return "❓ 未知指令。可用指令: open, close, status, whitelist, blacklist, reload", nil
+		return "❓ 未知指令。可用指令: open, close, status, whitelist, blacklist, word, reload", nil
+	}
+}
+
+// handleWordCommand edits the scoped sensitive word rules:
+// /system word <add|remove> <system|robot|group> <word> [block|escalate|mask|warn] [scope]
+func (p *CorePlugin) handleWordCommand(args []string) string {
+	const usage = "用法: /system word <add|remove> <system|robot|group> <词> [block|escalate|mask|warn] [机器人ID/群号]"
+	if len(args) < 3 {
+		return usage
+	}
+	op, level, word := args[0], args[1], args[2]
+	if level != moderation.LevelSystem && level != moderation.LevelRobot && level != moderation.LevelGroup {
+		return usage
+	}
+	rule := moderation.Rule{Word: word, Level: level}
+	if len(args) > 3 {
+		switch args[3] {
+		case moderation.ActionBlock, moderation.ActionEscalate, moderation.ActionMask, moderation.ActionWarn:
+			rule.Action = args[3]
+		default:
+			return usage
+		}
+	}
+	if len(args) > 4 {
+		rule.Scope = args[4]
+	}
+
+	// SaveToRedis and RecompileRegex take the lock themselves, so edit under the lock and release it first
+	var reply string
+	p.Mutex.Lock()
+	rules := p.Config.SensitiveWords.Rules
+	idx := -1
+	for i, r := range rules {
+		if r.Word == rule.Word && r.Level == rule.Level && r.Scope == rule.Scope {
+			idx = i
+			break
+		}
+	}
+	switch op {
+	case "add":
+		if idx >= 0 {
+			rules[idx] = rule
+		} else {
+			rules = append(rules, rule)
+		}
+		reply = fmt.Sprintf("✅ 已添加敏感词 %s (%s)", word, level)
+	case "remove":
+		if idx < 0 {
+			p.Mutex.Unlock()
+			return fmt.Sprintf("未找到敏感词 %s (%s)", word, level)
+		}
+		rules = append(rules[:idx:idx], rules[idx+1:]...)
+		reply = fmt.Sprintf("🗑️ 已移除敏感词 %s (%s)", word, level)
+	default:
+		p.Mutex.Unlock()
+		return usage
+	}
+	p.Config.SensitiveWords.Rules = rules
+	p.Mutex.Unlock()
+
+	p.SaveToRedis()
+	p.RecompileRegex()
+	return reply
+}
+
+// StartRedisSync periodically reloads the shared config from Redis so that word list
+// edits made on other instances take effect; the filter is only rebuilt when it changed
+func (p *CorePlugin) StartRedisSync(ctx context.Context, interval time.Duration) {
+	if p.Manager.Rdb == nil {
+		return
 	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.SyncFromRedis()
+			}
+		}
+	}()
 }
 
 // HandleKBCommand processes knowledge base management commands
@@ -48,6 +48,8 @@ func (m *GORMManager) InitGORM(cfg *config.AppConfig) error {
 		&models.DigitalRoleTemplateGORM{},
 		&models.CognitiveMemoryGORM{},
 		&models.AIAgentTraceGORM{},
+		&models.ToolAuditLog{},
+		&models.AgentRun{},
 		&models.BotSkillPermissionGORM{},
 		&models.MCPServerGORM{},
 		&models.MCPToolGORM{},
@@ -70,6 +72,7 @@ func (m *GORMManager) InitGORM(cfg *config.AppConfig) error {
 		&models.FissionTaskGORM{},
 		&models.UserFissionRecordGORM{},
 		&models.FissionRewardLogGORM{},
+		&models.FissionRewardOutboxGORM{},
 		&models.AIPromptTemplateGORM{},
 		&models.AIKnowledgeBaseGORM{},
 		&models.AISkillGORM{},
@@ -85,14 +88,29 @@ func (m *GORMManager) InitGORM(cfg *config.AppConfig) error {
 		&models.DigitalEmployeeDispatchGORM{},
 		&models.DigitalEmployeeTodoGORM{},
 		&models.DigitalEmployeeTaskGORM{},
+		&models.DigitalJob{},
+		&models.EmployeeJobRelation{},
+		&models.ABExperiment{},
+		&models.ABVariant{},
+		&models.EmployeeExperimentRelation{},
+		&models.ABAssignment{},
+		&models.ABObservation{},
 		&models.Task{},
 		&models.Execution{},
 		&models.Tag{},
 		&models.Strategy{},
 		&models.AIDraft{},
 		&models.UserIdentity{},
+		&models.IdentityBindCode{},
+		&models.IdentityLinkLog{},
 		&models.ShadowRule{},
+		&models.ShadowRecord{},
 		&models.TaskTag{},
+		&models.RBACRole{},
+		&models.RBACRoleBinding{},
+		&models.APIKey{},
+		&models.APIKeyAuditLog{},
+		&models.SSOIdentity{},
 	); err != nil {
 		log.Printf("GORM AutoMigrate failed (remaining models): %v", err)
 	}
@@ -71,6 +71,7 @@ type InvitationGORM = models.InvitationGORM
 type FissionTaskGORM = models.FissionTaskGORM
 type UserFissionRecordGORM = models.UserFissionRecordGORM
 type FissionRewardLogGORM = models.FissionRewardLogGORM
+type FissionRewardOutboxGORM = models.FissionRewardOutboxGORM
 type GroupCacheGORM = models.GroupCacheGORM
 type MemberCacheGORM = models.MemberCacheGORM
 type FriendCacheGORM = models.FriendCacheGORM
@@ -2,14 +2,18 @@ package common
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"BotMatrix/common/bot"
 	"BotMatrix/common/models"
+	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 // HandleGetFissionConfig 获取裂变配置
@@ -274,3 +278,130 @@ func HandleGetFissionLeaderboard(m *bot.Manager) http.HandlerFunc {
 		})
 	}
 }
+
+// FissionReviewItem 审核队列中的一条邀请及其待发奖励
+type FissionReviewItem struct {
+	Invitation models.InvitationGORM            `json:"invitation"`
+	Rewards    []models.FissionRewardOutboxGORM `json:"rewards"`
+}
+
+// HandleGetFissionReviews 获取裂变奖励审核队列
+// @Summary 获取裂变审核队列
+// @Description 获取因风险分偏高而延迟发放 (held) 或等待人工审核 (review) 的邀请及其待发奖励，按风险分从高到低排列
+// @Tags Fission
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object "审核队列"
+// @Router /api/admin/fission/reviews [get]
+func HandleGetFissionReviews(m *bot.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items := []FissionReviewItem{}
+		if m.GORMDB != nil {
+			var invitations []models.InvitationGORM
+			m.GORMDB.Where("status IN ?", []string{"held", "review"}).Order("risk_score desc, created_at asc").Limit(100).Find(&invitations)
+
+			ids := make([]uint, 0, len(invitations))
+			for _, inv := range invitations {
+				ids = append(ids, inv.ID)
+			}
+			var rewards []models.FissionRewardOutboxGORM
+			if len(ids) > 0 {
+				m.GORMDB.Where("invitation_id IN ?", ids).Order("id").Find(&rewards)
+			}
+			byInvitation := make(map[uint][]models.FissionRewardOutboxGORM)
+			for _, rw := range rewards {
+				byInvitation[rw.InvitationID] = append(byInvitation[rw.InvitationID], rw)
+			}
+			for _, inv := range invitations {
+				items = append(items, FissionReviewItem{Invitation: inv, Rewards: byInvitation[inv.ID]})
+			}
+		}
+
+		utils.SendJSONResponse(w, true, "", struct {
+			Reviews []FissionReviewItem `json:"reviews"`
+		}{
+			Reviews: items,
+		})
+	}
+}
+
+// HandleReviewFissionInvitation 审核裂变邀请
+// @Summary 审核裂变邀请
+// @Description approve 放行：待发奖励立即进入发放队列；reject 拒绝：取消全部待发奖励。仅处理 held 或 review 状态的邀请
+// @Tags Fission
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "邀请记录 ID"
+// @Param body body object true "{\"action\": \"approve|reject\"}"
+// @Success 200 {object} utils.JSONResponse "审核成功"
+// @Router /api/admin/fission/reviews/{id} [post]
+func HandleReviewFissionInvitation(m *bot.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := utils.GetLangFromRequest(r)
+
+		pathParts := strings.Split(r.URL.Path, "/")
+		id, err := strconv.Atoi(pathParts[len(pathParts)-1])
+		if err != nil || id <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+			return
+		}
+
+		var req struct {
+			Action string `json:"action"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Action != "approve" && req.Action != "reject") {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, utils.T(lang, "invalid_request_format"), nil)
+			return
+		}
+
+		if m.GORMDB == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, "database not available", nil)
+			return
+		}
+
+		reviewer := ""
+		if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+			reviewer = claims.Username
+		}
+
+		now := time.Now()
+		invStatus, rewardStatus := "pending", "pending"
+		if req.Action == "reject" {
+			invStatus, rewardStatus = "rejected", "cancelled"
+		}
+
+		err = m.GORMDB.Transaction(func(tx *gorm.DB) error {
+			// 以状态为条件更新，避免与发放任务或其他审核人并发处理同一邀请
+			res := tx.Model(&models.InvitationGORM{}).
+				Where("id = ? AND status IN ?", id, []string{"held", "review"}).
+				Updates(map[string]any{"status": invStatus, "reviewed_by": reviewer, "reviewed_at": now, "updated_at": now})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errFissionReviewConflict
+			}
+			return tx.Model(&models.FissionRewardOutboxGORM{}).
+				Where("invitation_id = ? AND status IN ?", id, []string{"pending", "review"}).
+				Updates(map[string]any{"status": rewardStatus, "available_at": now, "updated_at": now}).Error
+		})
+		if err == errFissionReviewConflict {
+			w.WriteHeader(http.StatusConflict)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+
+		utils.SendJSONResponse(w, true, utils.T(lang, "action_success"), nil)
+	}
+}
+
+var errFissionReviewConflict = errors.New("invitation is not awaiting review")
@@ -2,8 +2,10 @@ package common
 
 import (
 	"BotMatrix/common/config"
+	"BotMatrix/common/identity"
 	"BotMatrix/common/log"
 	"BotMatrix/common/models"
+	"BotMatrix/common/secrets"
 	"BotMatrix/common/tasks"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
@@ -434,13 +436,20 @@ func HandleTranslate(m *Manager) http.HandlerFunc {
 			return
 		}
 
-		if config.GlobalConfig.AzureTranslateKey == "" {
+		cfg := config.Current()
+		if cfg.AzureTranslateKey == "" {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			utils.SendJSONResponse(w, false, "Azure Translate API key not configured", nil)
 			return
 		}
+		azureKey, err := secrets.Resolve(r.Context(), cfg.AzureTranslateKey)
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
 
-		endpoint := config.GlobalConfig.AzureTranslateEndpoint
+		endpoint := cfg.AzureTranslateEndpoint
 		if endpoint == "" {
 			endpoint = "https://api.cognitive.microsofttranslator.com"
 		}
@@ -462,9 +471,9 @@ func HandleTranslate(m *Manager) http.HandlerFunc {
 		}
 
 		azureReq.Header.Set("Content-Type", "application/json")
-		azureReq.Header.Set("Ocp-Apim-Subscription-Key", config.GlobalConfig.AzureTranslateKey)
-		if config.GlobalConfig.AzureTranslateRegion != "" {
-			azureReq.Header.Set("Ocp-Apim-Subscription-Region", config.GlobalConfig.AzureTranslateRegion)
+		azureReq.Header.Set("Ocp-Apim-Subscription-Key", azureKey)
+		if cfg.AzureTranslateRegion != "" {
+			azureReq.Header.Set("Ocp-Apim-Subscription-Region", cfg.AzureTranslateRegion)
 		}
 
 		client := &http.Client{Timeout: 10 * time.Second}
@@ -674,6 +683,106 @@ func HandleDeleteIdentity(m *Manager) http.HandlerFunc {
 	}
 }
 
+// identityOperator 返回执行身份管理操作的管理员名称，用于审计
+func identityOperator(r *http.Request) string {
+	if claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims); ok && claims != nil {
+		return "admin:" + claims.Username
+	}
+	return "admin"
+}
+
+// HandleGetIdentityAccounts 查询身份下绑定的全部平台账号及合并后的资料
+func HandleGetIdentityAccounts(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc := identity.NewService(m.GORMDB)
+		nexusUID := r.URL.Query().Get("nexus_uid")
+		if nexusUID == "" {
+			platform, uid := r.URL.Query().Get("platform"), r.URL.Query().Get("platform_uid")
+			var acc models.UserIdentity
+			if err := m.GORMDB.Where("platform = ? AND platform_uid = ?", platform, uid).First(&acc).Error; err != nil {
+				utils.SendJSONResponse(w, false, identity.ErrNotFound.Error(), nil)
+				return
+			}
+			nexusUID = acc.NexusUID
+		}
+
+		accounts, err := svc.Accounts(nexusUID)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		profile, err := svc.Profile(nexusUID)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", map[string]any{
+			"nexus_uid": nexusUID,
+			"accounts":  accounts,
+			"profile":   profile,
+		})
+	}
+}
+
+// HandleMergeIdentities 合并两个身份，保留方由系统按创建时间确定
+func HandleMergeIdentities(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			NexusUIDA string `json:"nexus_uid_a"`
+			NexusUIDB string `json:"nexus_uid_b"`
+			Reason    string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.NexusUIDA == "" || req.NexusUIDB == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "nexus_uid_a and nexus_uid_b are required", nil)
+			return
+		}
+
+		survivor, err := identity.NewService(m.GORMDB).Merge(req.NexusUIDA, req.NexusUIDB, identityOperator(r), req.Reason)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "Merged", map[string]any{"nexus_uid": survivor})
+	}
+}
+
+// HandleSplitIdentity 将平台账号从身份中拆出
+func HandleSplitIdentity(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Platform    string `json:"platform"`
+			PlatformUID string `json:"platform_uid"`
+			Reason      string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Platform == "" || req.PlatformUID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			utils.SendJSONResponse(w, false, "platform and platform_uid are required", nil)
+			return
+		}
+
+		newUID, err := identity.NewService(m.GORMDB).Split(req.Platform, req.PlatformUID, identityOperator(r), req.Reason)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "Split", map[string]any{"nexus_uid": newUID})
+	}
+}
+
+// HandleListIdentityLogs 查询身份绑定/合并/拆分审计日志
+func HandleListIdentityLogs(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		logs, err := identity.NewService(m.GORMDB).Logs(r.URL.Query().Get("nexus_uid"), limit)
+		if err != nil {
+			utils.SendJSONResponse(w, false, err.Error(), nil)
+			return
+		}
+		utils.SendJSONResponse(w, true, "", logs)
+	}
+}
+
 func HandleListShadowRules(m *Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var rules []models.ShadowRule
@@ -690,6 +799,7 @@ func HandleSaveShadowRule(m *Manager) http.HandlerFunc {
 			return
 		}
 		m.GORMDB.Save(&rule)
+		refreshShadowRules(m)
 		utils.SendJSONResponse(w, true, "Saved", rule)
 	}
 }
@@ -698,6 +808,14 @@ func HandleDeleteShadowRule(m *Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.URL.Query().Get("id")
 		m.GORMDB.Delete(&models.ShadowRule{}, id)
+		refreshShadowRules(m)
 		utils.SendJSONResponse(w, true, "Deleted", nil)
 	}
 }
+
+// refreshShadowRules 规则修改后立即刷新影子执行的规则缓存
+func refreshShadowRules(m *Manager) {
+	if tm, ok := m.TaskManager.(*tasks.TaskManager); ok && tm.Interceptors != nil && tm.Interceptors.Shadow != nil {
+		tm.Interceptors.Shadow.LoadRules()
+	}
+}
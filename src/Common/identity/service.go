@@ -0,0 +1,559 @@
+package identity
+
+import (
+	"BotMatrix/common/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// BindCodeTTL 绑定码有效期
+	BindCodeTTL = 10 * time.Minute
+	// BindCodeLength 绑定码位数
+	BindCodeLength = 10
+	// MaxRedeemFailures 单个账号在 BindCodeTTL 内允许的错误次数，防止暴力猜测
+	MaxRedeemFailures = 5
+	// MaxPlatformRedeemFailures 同一平台的全部账号在 BindCodeTTL 内允许的错误次数，
+	// 防止批量创建账号 (如网页访客) 绕过单账号限制
+	MaxPlatformRedeemFailures = 20
+	// MaxGlobalRedeemFailures 全部平台在 BindCodeTTL 内允许的错误次数
+	MaxGlobalRedeemFailures = 100
+)
+
+var (
+	ErrInvalidCode    = errors.New("绑定码无效或已过期")
+	ErrTooManyTries   = errors.New("尝试次数过多，请稍后再试")
+	ErrAlreadyLinked  = errors.New("两个账号已属于同一身份")
+	ErrNotFound       = errors.New("身份不存在")
+	ErrNothingToSplit = errors.New("该身份仅包含一个平台账号，无需拆分")
+	ErrSameAccount    = errors.New("请在另一个平台的账号上发送绑定码")
+)
+
+// OwnershipHook 在身份合并或数据归属变更时迁移业务数据的归属，
+// from 为原持有者 ID 列表，to 为新的 NexusUID，须在 tx 内完成
+type OwnershipHook func(tx *gorm.DB, from []string, to string) error
+
+var (
+	hooksMu sync.RWMutex
+	hooks   = map[string]OwnershipHook{
+		"memories":  moveMemories,
+		"knowledge": moveKnowledgeAccess,
+	}
+)
+
+// RegisterOwnershipHook 注册业务数据归属迁移钩子 (如积分、订阅等)，同名覆盖
+func RegisterOwnershipHook(name string, hook OwnershipHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks[name] = hook
+}
+
+func runHooks(tx *gorm.DB, from []string, to string) error {
+	hooksMu.RLock()
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	hooksMu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		hooksMu.RLock()
+		hook := hooks[name]
+		hooksMu.RUnlock()
+		if err := hook(tx, from, to); err != nil {
+			return fmt.Errorf("ownership hook %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// moveMemories 认知记忆随身份迁移
+func moveMemories(tx *gorm.DB, from []string, to string) error {
+	if !tx.Migrator().HasTable(&models.CognitiveMemory{}) {
+		return nil
+	}
+	return tx.Model(&models.CognitiveMemory{}).Where(map[string]any{"UserId": from}).Update("UserId", to).Error
+}
+
+// moveKnowledgeAccess 个人知识库授权随身份迁移
+func moveKnowledgeAccess(tx *gorm.DB, from []string, to string) error {
+	if !tx.Migrator().HasTable("knowledge_doc_access") {
+		return nil
+	}
+	return tx.Table("knowledge_doc_access").
+		Where("owner_type = ? AND owner_id IN ?", "user", from).
+		Update("owner_id", to).Error
+}
+
+// Service 跨平台身份服务：自动建档、绑定码、合并与拆分
+type Service struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	failures  map[string][]time.Time // 限流键 (账号 platform:uid、平台、全局) -> 最近的错误兑换时间
+	lastPrune time.Time
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, failures: make(map[string][]time.Time)}
+}
+
+// Migrate 迁移身份相关表；旧版本 nexus_uid 上的唯一索引会阻止多个平台账号共享身份，需先删除
+func (s *Service) Migrate() error {
+	if s.db.Migrator().HasIndex(&models.UserIdentity{}, "idx_user_identities_nexus_uid") {
+		if err := s.db.Migrator().DropIndex(&models.UserIdentity{}, "idx_user_identities_nexus_uid"); err != nil {
+			return err
+		}
+	}
+	return s.db.AutoMigrate(&models.UserIdentity{}, &models.IdentityBindCode{}, &models.IdentityLinkLog{})
+}
+
+func newNexusUID() string {
+	return "nx_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// Ensure 获取平台账号对应的身份，不存在时自动创建
+func (s *Service) Ensure(platform, platformUID, nickname string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := s.db.Where("platform = ? AND platform_uid = ?", platform, platformUID).First(&identity).Error
+	if err == nil {
+		return &identity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	identity = models.UserIdentity{
+		NexusUID:    newNexusUID(),
+		Platform:    platform,
+		PlatformUID: platformUID,
+		Nickname:    nickname,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&identity).Error; err != nil {
+			return err
+		}
+		// 建档前以平台 ID 存储的数据归入新身份；平台 ID 在其他平台重复时无法区分归属，保持原样
+		var others int64
+		tx.Model(&models.UserIdentity{}).Where("platform_uid = ? AND id <> ?", platformUID, identity.ID).Count(&others)
+		if others == 0 {
+			if err := runHooks(tx, []string{platformUID}, identity.NexusUID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.IdentityLinkLog{
+			Action:      "create",
+			NexusUID:    identity.NexusUID,
+			Platform:    platform,
+			PlatformUID: platformUID,
+			Operator:    "system",
+		}).Error
+	})
+	if err != nil {
+		// 并发建档时唯一索引冲突，返回已存在的记录
+		if again := s.db.Where("platform = ? AND platform_uid = ?", platform, platformUID).First(&identity).Error; again == nil {
+			return &identity, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Accounts 返回身份下的全部平台账号，按创建时间排序
+func (s *Service) Accounts(nexusUID string) ([]models.UserIdentity, error) {
+	var accounts []models.UserIdentity
+	err := s.db.Where("nexus_uid = ?", nexusUID).Order("created_at ASC, id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// Profile 合并身份下各账号的扩展属性：先创建的账号优先，points 累加
+func (s *Service) Profile(nexusUID string) (map[string]any, error) {
+	accounts, err := s.Accounts(nexusUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, ErrNotFound
+	}
+
+	profile := make(map[string]any)
+	var points float64
+	for _, acc := range accounts {
+		var meta map[string]any
+		if json.Unmarshal([]byte(acc.Metadata), &meta) != nil {
+			continue
+		}
+		for k, v := range meta {
+			if k == "points" {
+				if n, ok := v.(float64); ok {
+					points += n
+				}
+				continue
+			}
+			if _, exists := profile[k]; !exists {
+				profile[k] = v
+			}
+		}
+	}
+	profile["points"] = points
+	return profile, nil
+}
+
+// Resolve 将平台用户 ID 解析为 NexusUID；平台 ID 不唯一或未建档时原样返回
+func Resolve(db *gorm.DB, platformUID string) string {
+	if db == nil || platformUID == "" || strings.HasPrefix(platformUID, "nx_") {
+		return platformUID
+	}
+	var uids []string
+	if err := db.Model(&models.UserIdentity{}).Where("platform_uid = ?", platformUID).Distinct().Pluck("nexus_uid", &uids).Error; err != nil {
+		return platformUID
+	}
+	if len(uids) != 1 {
+		return platformUID
+	}
+	return uids[0]
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestBindCode 为平台账号生成一次性绑定码，只能由 targetPlatform 的账号兑换；旧的未使用绑定码同时失效
+func (s *Service) RequestBindCode(platform, platformUID, nickname, targetPlatform string) (string, error) {
+	targetPlatform = normalizePlatform(targetPlatform)
+	if targetPlatform == "" {
+		return "", errors.New("target platform is required")
+	}
+	identity, err := s.Ensure(platform, platformUID, nickname)
+	if err != nil {
+		return "", err
+	}
+
+	var code string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.IdentityBindCode{}).
+			Where("platform = ? AND platform_uid = ? AND used_at IS NULL", platform, platformUID).
+			Update("expire_time", now).Error; err != nil {
+			return err
+		}
+		tx.Where("expire_time < ?", now.Add(-24*time.Hour)).Delete(&models.IdentityBindCode{})
+
+		// 有效期内的绑定码不能重复
+		limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(BindCodeLength), nil)
+		for i := 0; i < 5; i++ {
+			n, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				return err
+			}
+			code = fmt.Sprintf("%0*d", BindCodeLength, n)
+
+			var active int64
+			if err := tx.Model(&models.IdentityBindCode{}).
+				Where("code_hash = ? AND used_at IS NULL AND expire_time > ?", hashCode(code), now).
+				Count(&active).Error; err != nil {
+				return err
+			}
+			if active > 0 {
+				continue
+			}
+			return tx.Create(&models.IdentityBindCode{
+				CodeHash:       hashCode(code),
+				NexusUID:       identity.NexusUID,
+				Platform:       platform,
+				PlatformUID:    platformUID,
+				TargetPlatform: targetPlatform,
+				ExpireTime:     now.Add(BindCodeTTL),
+			}).Error
+		}
+		return errors.New("failed to allocate bind code")
+	})
+	return code, err
+}
+
+// normalizePlatform 统一平台名大小写：适配器上报 "Telegram"、"DingTalk"，指令中通常输入小写
+func normalizePlatform(platform string) string {
+	return strings.ToLower(strings.TrimSpace(platform))
+}
+
+// IsBindCode 判断指令参数是否为绑定码格式
+func IsBindCode(s string) bool {
+	if len(s) != BindCodeLength {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// redeemLimits 兑换的限流键与上限：账号、兑换方平台、全局
+func redeemLimits(platform, platformUID string) map[string]int {
+	platform = normalizePlatform(platform)
+	return map[string]int{
+		"account:" + platform + ":" + platformUID: MaxRedeemFailures,
+		"platform:" + platform:                    MaxPlatformRedeemFailures,
+		"global":                                  MaxGlobalRedeemFailures,
+	}
+}
+
+// allowRedeem 检查兑换频率，返回 false 表示任一限流键已达到错误上限
+func (s *Service) allowRedeem(limits map[string]int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	cutoff := now.Add(-BindCodeTTL)
+
+	// 定期清理过期的计数，避免大量一次性账号使 failures 无限增长
+	if now.Sub(s.lastPrune) > time.Minute {
+		for key := range s.failures {
+			s.pruneLocked(key, cutoff)
+		}
+		s.lastPrune = now
+	}
+
+	allowed := true
+	for key, max := range limits {
+		if len(s.pruneLocked(key, cutoff)) >= max {
+			allowed = false
+		}
+	}
+	return allowed
+}
+
+// pruneLocked 丢弃 cutoff 之前的错误记录，没有剩余记录时删除键，调用方需持有 s.mu
+func (s *Service) pruneLocked(key string, cutoff time.Time) []time.Time {
+	recent := s.failures[key][:0]
+	for _, t := range s.failures[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(s.failures, key)
+		return nil
+	}
+	s.failures[key] = recent
+	return recent
+}
+
+func (s *Service) recordFailure(limits map[string]int) {
+	s.mu.Lock()
+	now := time.Now()
+	for key := range limits {
+		s.failures[key] = append(s.failures[key], now)
+	}
+	s.mu.Unlock()
+}
+
+// RedeemBindCode 在另一平台账号上兑换绑定码，将两个身份合并，返回合并后的 NexusUID。
+// 绑定码与合并在同一事务中提交，合并失败时绑定码仍可再次使用
+func (s *Service) RedeemBindCode(platform, platformUID, nickname, code string) (string, error) {
+	limits := redeemLimits(platform, platformUID)
+	if !s.allowRedeem(limits) {
+		return "", ErrTooManyTries
+	}
+
+	// 绑定码只对生成时指定的平台有效，其他平台兑换与错误的绑定码同样计入失败次数
+	var record models.IdentityBindCode
+	err := s.db.Where("code_hash = ? AND target_platform = ? AND used_at IS NULL AND expire_time > ?",
+		hashCode(strings.TrimSpace(code)), normalizePlatform(platform), time.Now()).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordFailure(limits)
+			return "", ErrInvalidCode
+		}
+		return "", err
+	}
+	if strings.EqualFold(record.Platform, platform) && record.PlatformUID == platformUID {
+		return "", ErrSameAccount
+	}
+
+	redeemer, err := s.Ensure(platform, platformUID, nickname)
+	if err != nil {
+		return "", err
+	}
+
+	var survivor, absorbed string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 标记已使用 (条件更新保证一次性)
+		now := time.Now()
+		res := tx.Model(&models.IdentityBindCode{}).Where("id = ? AND used_at IS NULL", record.ID).Update("used_at", &now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+
+		// 发起方的身份可能在生成绑定码后被合并，以账号当前归属为准
+		var owner models.UserIdentity
+		if err := tx.Where("platform = ? AND platform_uid = ?", record.Platform, record.PlatformUID).First(&owner).Error; err != nil {
+			return ErrInvalidCode
+		}
+		if owner.NexusUID == redeemer.NexusUID {
+			survivor = owner.NexusUID
+			return nil
+		}
+
+		detail := fmt.Sprintf("bind code from %s:%s", record.Platform, record.PlatformUID)
+		survivor, absorbed, err = mergeTx(tx, "bind", owner.NexusUID, redeemer.NexusUID, platform+":"+platformUID, detail)
+		return err
+	})
+	switch {
+	case err != nil:
+		return "", err
+	case absorbed == "":
+		return survivor, ErrAlreadyLinked
+	}
+	log.Printf("[Identity] bind: %s -> %s (by %s:%s)", absorbed, survivor, platform, platformUID)
+	return survivor, nil
+}
+
+// Merge 管理员合并两个身份，返回保留的 NexusUID
+func (s *Service) Merge(a, b, operator, reason string) (string, error) {
+	if a == b {
+		return a, ErrAlreadyLinked
+	}
+	return s.merge("merge", a, b, operator, reason)
+}
+
+// merge 合并两个身份。保留规则确定且与参数顺序无关：
+// 最早创建账号所在的身份保留，创建时间相同时 NexusUID 字典序较小者保留
+func (s *Service) merge(action, a, b, operator, detail string) (string, error) {
+	var survivor, absorbed string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		survivor, absorbed, err = mergeTx(tx, action, a, b, operator, detail)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[Identity] %s: %s -> %s (by %s)", action, absorbed, survivor, operator)
+	return survivor, nil
+}
+
+// mergeTx 在 tx 内合并两个身份并记录审计日志，返回保留与被合并的 NexusUID
+func mergeTx(tx *gorm.DB, action, a, b, operator, detail string) (survivor, absorbed string, err error) {
+	first := func(uid string) (*models.UserIdentity, error) {
+		var acc models.UserIdentity
+		if err := tx.Where("nexus_uid = ?", uid).Order("created_at ASC, id ASC").First(&acc).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrNotFound, uid)
+			}
+			return nil, err
+		}
+		return &acc, nil
+	}
+	oldestA, err := first(a)
+	if err != nil {
+		return "", "", err
+	}
+	oldestB, err := first(b)
+	if err != nil {
+		return "", "", err
+	}
+
+	survivor, absorbed = a, b
+	switch {
+	case oldestB.CreatedAt.Before(oldestA.CreatedAt):
+		survivor, absorbed = b, a
+	case oldestB.CreatedAt.Equal(oldestA.CreatedAt) && b < a:
+		survivor, absorbed = b, a
+	}
+
+	var moved []models.UserIdentity
+	if err := tx.Where("nexus_uid = ?", absorbed).Find(&moved).Error; err != nil {
+		return "", "", err
+	}
+	if err := tx.Model(&models.UserIdentity{}).Where("nexus_uid = ?", absorbed).Update("nexus_uid", survivor).Error; err != nil {
+		return "", "", err
+	}
+	if err := runHooks(tx, []string{absorbed}, survivor); err != nil {
+		return "", "", err
+	}
+
+	for _, acc := range moved {
+		if err := tx.Create(&models.IdentityLinkLog{
+			Action:      action,
+			NexusUID:    survivor,
+			FromUID:     absorbed,
+			Platform:    acc.Platform,
+			PlatformUID: acc.PlatformUID,
+			Operator:    operator,
+			Detail:      detail,
+		}).Error; err != nil {
+			return "", "", err
+		}
+	}
+	return survivor, absorbed, nil
+}
+
+// Split 将平台账号从身份中拆出并分配新的 NexusUID。
+// 合并期间产生的记忆、授权等数据无法区分来源，保留在原身份下
+func (s *Service) Split(platform, platformUID, operator, reason string) (string, error) {
+	var newUID string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var acc models.UserIdentity
+		if err := tx.Where("platform = ? AND platform_uid = ?", platform, platformUID).First(&acc).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.UserIdentity{}).Where("nexus_uid = ?", acc.NexusUID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count < 2 {
+			return ErrNothingToSplit
+		}
+
+		newUID = newNexusUID()
+		if err := tx.Model(&acc).Update("nexus_uid", newUID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.IdentityLinkLog{
+			Action:      "split",
+			NexusUID:    newUID,
+			FromUID:     acc.NexusUID,
+			Platform:    platform,
+			PlatformUID: platformUID,
+			Operator:    operator,
+			Detail:      reason,
+		}).Error
+	})
+	return newUID, err
+}
+
+// Logs 查询身份审计日志，nexusUID 为空时返回全部
+func (s *Service) Logs(nexusUID string, limit int) ([]models.IdentityLinkLog, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	query := s.db.Order("id DESC").Limit(limit)
+	if nexusUID != "" {
+		query = query.Where("nexus_uid = ? OR from_uid = ?", nexusUID, nexusUID)
+	}
+	var logs []models.IdentityLinkLog
+	err := query.Find(&logs).Error
+	return logs, err
+}
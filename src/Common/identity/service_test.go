@@ -0,0 +1,286 @@
+package identity
+
+import (
+	"BotMatrix/common/models"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	svc := NewService(db)
+	if err := svc.Migrate(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.AutoMigrate(&models.CognitiveMemory{}); err != nil {
+		t.Fatalf("failed to migrate memories: %v", err)
+	}
+	return svc, db
+}
+
+func TestEnsureAdoptsExistingMemories(t *testing.T) {
+	svc, db := newTestService(t)
+	db.Create(&models.CognitiveMemory{UserID: "10001", BotID: "bot", Content: "likes tea"})
+
+	id, err := svc.Ensure("qq", "10001", "alice")
+	if err != nil {
+		t.Fatalf("ensure failed: %v", err)
+	}
+	again, _ := svc.Ensure("qq", "10001", "alice")
+	if again.NexusUID != id.NexusUID {
+		t.Fatalf("ensure must be idempotent: %s vs %s", id.NexusUID, again.NexusUID)
+	}
+
+	var mem models.CognitiveMemory
+	db.First(&mem)
+	if mem.UserID != id.NexusUID {
+		t.Errorf("memory should follow the new identity, got %q", mem.UserID)
+	}
+	if got := Resolve(db, "10001"); got != id.NexusUID {
+		t.Errorf("resolve: want %s, got %s", id.NexusUID, got)
+	}
+}
+
+func TestBindCodeFlow(t *testing.T) {
+	svc, db := newTestService(t)
+
+	qq, _ := svc.Ensure("qq", "10001", "alice")
+	db.Create(&models.CognitiveMemory{UserID: "tg-only", BotID: "bot", Content: "from telegram"})
+	tg, _ := svc.Ensure("telegram", "tg-only", "alice_tg")
+	db.Model(&models.UserIdentity{}).Where("id = ?", qq.ID).Update("metadata", `{"points": 30, "lang": "zh"}`)
+	db.Model(&models.UserIdentity{}).Where("id = ?", tg.ID).Update("metadata", `{"points": 12, "lang": "en"}`)
+
+	self, _ := svc.RequestBindCode("telegram", "tg-only", "alice_tg", "telegram")
+	if _, err := svc.RedeemBindCode("telegram", "tg-only", "", self); !errors.Is(err, ErrSameAccount) {
+		t.Error("redeeming on the same account must fail")
+	}
+
+	code, err := svc.RequestBindCode("telegram", "tg-only", "alice_tg", "qq")
+	if err != nil || len(code) != BindCodeLength || !IsBindCode(code) {
+		t.Fatalf("unexpected code %q: %v", code, err)
+	}
+
+	if _, err := svc.RedeemBindCode("wechat", "w1", "", code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("codes must only be redeemable on the target platform, got %v", err)
+	}
+
+	nexusUID, err := svc.RedeemBindCode("qq", "10001", "alice", code)
+	if err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	// QQ 账号先创建，保留其身份
+	if nexusUID != qq.NexusUID {
+		t.Errorf("expected oldest identity %s to survive, got %s", qq.NexusUID, nexusUID)
+	}
+	if _, err := svc.RedeemBindCode("qq", "10001", "alice", code); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("bind codes are single use, got %v", err)
+	}
+
+	accounts, _ := svc.Accounts(nexusUID)
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 linked accounts, got %d", len(accounts))
+	}
+
+	var mem models.CognitiveMemory
+	db.Where(map[string]any{"Content": "from telegram"}).First(&mem)
+	if mem.UserID != nexusUID {
+		t.Errorf("memories must follow the merged identity, got %q", mem.UserID)
+	}
+
+	profile, _ := svc.Profile(nexusUID)
+	if profile["points"] != float64(42) || profile["lang"] != "zh" {
+		t.Errorf("unexpected merged profile: %v", profile)
+	}
+
+	logs, _ := svc.Logs(nexusUID, 10)
+	found := false
+	for _, l := range logs {
+		if l.Action == "bind" && l.FromUID == tg.NexusUID && l.PlatformUID == "tg-only" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected bind audit log, got %+v", logs)
+	}
+}
+
+func TestBindCodeIgnoresPlatformCase(t *testing.T) {
+	svc, _ := newTestService(t)
+	svc.Ensure("QQ", "10001", "alice")
+
+	// 指令中输入小写平台名，适配器上报的平台名首字母大写
+	code, err := svc.RequestBindCode("QQ", "10001", "alice", "telegram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RedeemBindCode("Telegram", "tg-1", "alice_tg", code); err != nil {
+		t.Fatalf("redeem from adapter platform %q failed: %v", "Telegram", err)
+	}
+
+	code, _ = svc.RequestBindCode("DingTalk", "dt-1", "bob", "Feishu")
+	if _, err := svc.RedeemBindCode("Feishu", "fs-1", "bob", code); err != nil {
+		t.Fatalf("redeem with mixed-case target failed: %v", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.failures) != 0 {
+		t.Errorf("successful redemptions must not count as failures: %v", svc.failures)
+	}
+}
+
+func TestFailedMergeKeepsBindCode(t *testing.T) {
+	svc, _ := newTestService(t)
+	svc.Ensure("qq", "1", "")
+	code, _ := svc.RequestBindCode("qq", "1", "", "telegram")
+
+	RegisterOwnershipHook("test-failing", func(tx *gorm.DB, from []string, to string) error {
+		return errors.New("boom")
+	})
+	_, err := svc.RedeemBindCode("telegram", "2", "", code)
+	hooksMu.Lock()
+	delete(hooks, "test-failing")
+	hooksMu.Unlock()
+	if err == nil {
+		t.Fatal("merge with a failing hook must fail")
+	}
+
+	nexusUID, err := svc.RedeemBindCode("telegram", "2", "", code)
+	if err != nil {
+		t.Fatalf("bind code must survive a failed merge: %v", err)
+	}
+	if accounts, _ := svc.Accounts(nexusUID); len(accounts) != 2 {
+		t.Errorf("expected 2 linked accounts, got %d", len(accounts))
+	}
+}
+
+func TestRedeemBruteForceLimit(t *testing.T) {
+	svc, _ := newTestService(t)
+	code, _ := svc.RequestBindCode("qq", "1", "", "telegram")
+
+	for i := 0; i < MaxRedeemFailures; i++ {
+		if _, err := svc.RedeemBindCode("telegram", "2", "", "bad"); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d: expected invalid code, got %v", i, err)
+		}
+	}
+	if _, err := svc.RedeemBindCode("telegram", "2", "", code); !errors.Is(err, ErrTooManyTries) {
+		t.Errorf("expected lockout after %d failures, got %v", MaxRedeemFailures, err)
+	}
+}
+
+func TestRedeemPlatformLimit(t *testing.T) {
+	svc, _ := newTestService(t)
+	code, _ := svc.RequestBindCode("qq", "1", "", "web")
+
+	// 每次换一个新账号也会触发平台级限制
+	for i := 0; i < MaxPlatformRedeemFailures; i++ {
+		if _, err := svc.RedeemBindCode("web", fmt.Sprintf("visitor-%d", i), "", "0000000000"); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d: expected invalid code, got %v", i, err)
+		}
+	}
+	if _, err := svc.RedeemBindCode("web", "fresh-visitor", "", code); !errors.Is(err, ErrTooManyTries) {
+		t.Errorf("expected platform lockout, got %v", err)
+	}
+	// 其他平台不受影响
+	if _, err := svc.RedeemBindCode("telegram", "2", "", "0000000000"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("other platforms must not be locked out, got %v", err)
+	}
+}
+
+func TestRedeemFailuresArePruned(t *testing.T) {
+	svc, _ := newTestService(t)
+	for i := 0; i < 3; i++ {
+		svc.RedeemBindCode("web", fmt.Sprintf("visitor-%d", i), "", "bad")
+	}
+	svc.mu.Lock()
+	if len(svc.failures) != 5 { // 3 个账号 + 平台 + 全局
+		t.Fatalf("failures = %d keys", len(svc.failures))
+	}
+	old := time.Now().Add(-2 * BindCodeTTL)
+	for key := range svc.failures {
+		svc.failures[key] = []time.Time{old}
+	}
+	svc.lastPrune = time.Time{}
+	svc.mu.Unlock()
+
+	svc.RedeemBindCode("telegram", "2", "", "bad")
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.failures) != 3 { // 仅剩本次的账号、平台与全局
+		t.Fatalf("expired keys not pruned: %v", svc.failures)
+	}
+}
+
+func TestMergeSurvivorIsDeterministic(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name     string
+		a, b     models.UserIdentity
+		survivor string
+	}{
+		{
+			name:     "older identity wins",
+			a:        models.UserIdentity{NexusUID: "nx_b", Platform: "qq", PlatformUID: "1", CreatedAt: created},
+			b:        models.UserIdentity{NexusUID: "nx_a", Platform: "tg", PlatformUID: "2", CreatedAt: created.Add(time.Minute)},
+			survivor: "nx_b",
+		},
+		{
+			name:     "tie broken by smaller uid",
+			a:        models.UserIdentity{NexusUID: "nx_z", Platform: "qq", PlatformUID: "1", CreatedAt: created},
+			b:        models.UserIdentity{NexusUID: "nx_c", Platform: "tg", PlatformUID: "2", CreatedAt: created},
+			survivor: "nx_c",
+		},
+	}
+	for _, tt := range tests {
+		for _, swap := range []bool{false, true} {
+			svc, db := newTestService(t)
+			db.Create(&tt.a)
+			db.Create(&tt.b)
+			x, y := tt.a.NexusUID, tt.b.NexusUID
+			if swap {
+				x, y = y, x
+			}
+			got, err := svc.Merge(x, y, "admin", "test")
+			if err != nil {
+				t.Fatalf("%s: merge failed: %v", tt.name, err)
+			}
+			if got != tt.survivor {
+				t.Errorf("%s (swap=%v): want %s, got %s", tt.name, swap, tt.survivor, got)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	svc, _ := newTestService(t)
+	qq, _ := svc.Ensure("qq", "1", "")
+	if _, err := svc.Split("qq", "1", "admin", ""); !errors.Is(err, ErrNothingToSplit) {
+		t.Fatalf("expected ErrNothingToSplit, got %v", err)
+	}
+
+	tg, _ := svc.Ensure("telegram", "2", "")
+	survivor, _ := svc.Merge(qq.NexusUID, tg.NexusUID, "admin", "")
+
+	newUID, err := svc.Split("telegram", "2", "admin", "wrong person")
+	if err != nil {
+		t.Fatalf("split failed: %v", err)
+	}
+	if newUID == survivor {
+		t.Fatal("split account must get a new identity")
+	}
+	accounts, _ := svc.Accounts(survivor)
+	if len(accounts) != 1 || accounts[0].Platform != "qq" {
+		t.Errorf("unexpected remaining accounts: %+v", accounts)
+	}
+}
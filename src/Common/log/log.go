@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,6 +15,9 @@ import (
 
 var (
 	Logger *zap.Logger
+
+	// level 所有日志实例共用的级别，可通过 SetLevel 在运行时调整
+	level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 )
 
 // Config 日志配置
@@ -37,11 +41,10 @@ func InitLogger(config Config) error {
 	}
 
 	// 设置日志级别
-	level, err := zapcore.ParseLevel(config.Level)
-	if err != nil {
+	if err := SetLevel(config.Level); err != nil {
 		return err
 	}
-	zapConfig.Level.SetLevel(level)
+	zapConfig.Level = level
 
 	// 设置输出格式
 	if config.Format == "console" {
@@ -70,8 +73,9 @@ func InitLogger(config Config) error {
 		zapConfig.ErrorOutputPaths = []string{"stderr"}
 	}
 
-	// 构建日志实例
-	Logger, err = zapConfig.Build(zap.AddCallerSkip(1))
+	// 构建日志实例，写出前对敏感字段脱敏
+	var err error
+	Logger, err = zapConfig.Build(zap.AddCallerSkip(1), zap.WrapCore(newRedactCore))
 	if err != nil {
 		return err
 	}
@@ -82,6 +86,28 @@ func InitLogger(config Config) error {
 	return nil
 }
 
+// SetLevel 调整日志级别，立即对已创建的日志实例生效。空值视为 info，warning 等同 warn
+func SetLevel(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		name = "info"
+	case "warning":
+		name = "warn"
+	}
+	l, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+// GetLevel 返回当前日志级别
+func GetLevel() zapcore.Level {
+	return level.Level()
+}
+
 // InitDefaultLogger 使用默认配置初始化日志
 func InitDefaultLogger() {
 	config := Config{
@@ -245,8 +271,8 @@ func SetOutput(w io.Writer) {
 	core := zapcore.NewCore(
 		zapcore.NewConsoleEncoder(config),
 		zapcore.AddSync(w),
-		zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		level,
 	)
-	Logger = zap.New(core, zap.AddCallerSkip(1))
+	Logger = zap.New(newRedactCore(core), zap.AddCallerSkip(1))
 	zap.ReplaceGlobals(Logger)
 }
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSetLevelAppliesToExistingLogger(t *testing.T) {
+	prev, prevLevel := Logger, GetLevel()
+	defer func() { Logger = prev; level.SetLevel(prevLevel); zap.ReplaceGlobals(prevOrNop(prev)) }()
+
+	var buf strings.Builder
+	SetOutput(&buf)
+	if err := SetLevel("WARNING"); err != nil || GetLevel() != zapcore.WarnLevel {
+		t.Fatalf("SetLevel(WARNING) = %v, level %s", err, GetLevel())
+	}
+	Info("hidden")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	Debug("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Fatalf("output = %s", buf.String())
+	}
+
+	if err := SetLevel("verbose"); err == nil || GetLevel() != zapcore.DebugLevel {
+		t.Fatalf("invalid level should be rejected and keep the current one, got %v / %s", err, GetLevel())
+	}
+	if err := SetLevel(""); err != nil || GetLevel() != zapcore.InfoLevel {
+		t.Fatalf("empty level should mean info, got %v / %s", err, GetLevel())
+	}
+}
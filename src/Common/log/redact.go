@@ -0,0 +1,89 @@
+package log
+
+import (
+	"strings"
+
+	"BotMatrix/common/secrets"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore 在写出前对日志字段脱敏：敏感字段名 (api_key、jwt_secret、AppSecret 等) 的值替换为 secrets.Mask，
+// JSON 文本与 zap.Any 记录的对象按 secrets.Redact 递归处理
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	if _, ok := core.(redactCore); ok {
+		return core
+	}
+	return redactCore{core}
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		r, changed := redactField(f)
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = append([]zapcore.Field(nil), fields...)
+		}
+		out[i] = r
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func redactField(f zapcore.Field) (zapcore.Field, bool) {
+	sensitive := secrets.IsSensitiveKey(f.Key)
+	switch f.Type {
+	case zapcore.StringType:
+		if sensitive {
+			return zap.String(f.Key, secrets.RedactValue(f.String)), f.String != ""
+		}
+		if strings.HasPrefix(strings.TrimSpace(f.String), "{") {
+			return zap.String(f.Key, string(secrets.RedactJSON([]byte(f.String)))), true
+		}
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		b, _ := f.Interface.([]byte)
+		if sensitive {
+			return zap.String(f.Key, secrets.Mask), len(b) > 0
+		}
+		if f.Type == zapcore.ByteStringType && strings.HasPrefix(strings.TrimSpace(string(b)), "{") {
+			return zap.ByteString(f.Key, secrets.RedactJSON(b)), true
+		}
+	case zapcore.StringerType:
+		if sensitive {
+			return zap.String(f.Key, secrets.Mask), true
+		}
+	case zapcore.ReflectType:
+		if sensitive {
+			return zap.String(f.Key, secrets.Mask), f.Interface != nil
+		}
+		if f.Interface != nil {
+			return zap.Any(f.Key, secrets.Redact(f.Interface)), true
+		}
+	}
+	return f, false
+}
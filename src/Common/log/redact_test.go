@@ -0,0 +1,78 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"BotMatrix/common/secrets"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactCoreMasksSensitiveFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newRedactCore(core)).With(zap.String("jwt_secret", "with-secret"))
+
+	logger.Info("config",
+		zap.String("api_key", "sk-plain"),
+		zap.String("AppSecret", "wx"),
+		zap.String("ref_token", secrets.Ref("ai/openai")),
+		zap.String("model", "gpt-4o"),
+		zap.String("body", `{"name":"openai","api_key":"sk-body"}`),
+		zap.ByteString("raw", []byte(`{"client_secret":"cs-plain"}`)),
+		zap.Any("provider", map[string]any{"name": "openai", "oidc": map[string]any{"client_secret": "cs-plain"}}),
+		zap.Int("retries", 3),
+	)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	for _, key := range []string{"jwt_secret", "api_key", "AppSecret"} {
+		if fields[key] != secrets.Mask {
+			t.Errorf("%s = %v, want masked", key, fields[key])
+		}
+	}
+	if fields["ref_token"] != secrets.Ref("ai/openai") || fields["model"] != "gpt-4o" || fields["retries"] != int64(3) {
+		t.Errorf("non-secret fields changed: %v", fields)
+	}
+	for _, key := range []string{"body", "raw", "provider"} {
+		s := fieldString(fields[key])
+		if strings.Contains(s, "sk-body") || strings.Contains(s, "cs-plain") || !strings.Contains(s, secrets.Mask) {
+			t.Errorf("%s = %v, want nested secret masked", key, fields[key])
+		}
+	}
+}
+
+func TestSetOutputRedacts(t *testing.T) {
+	prev := Logger
+	defer func() { Logger = prev; zap.ReplaceGlobals(prevOrNop(prev)) }()
+
+	var buf strings.Builder
+	SetOutput(&buf)
+	Info("login", zap.String("password", "hunter2"))
+	if strings.Contains(buf.String(), "hunter2") || !strings.Contains(buf.String(), secrets.Mask) {
+		t.Fatalf("output = %s", buf.String())
+	}
+}
+
+func fieldString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	}
+	data, _ := zapcore.NewJSONEncoder(zapcore.EncoderConfig{}).EncodeEntry(zapcore.Entry{}, []zapcore.Field{zap.Any("v", v)})
+	return data.String()
+}
+
+func prevOrNop(l *zap.Logger) *zap.Logger {
+	if l == nil {
+		return zap.NewNop()
+	}
+	return l
+}
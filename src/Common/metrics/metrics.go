@@ -0,0 +1,190 @@
+// Package metrics 定义 BotNexus、BotWorker 与各适配器共享的 Prometheus 指标。
+// 指标统一以 botmatrix_ 为前缀，标签名与取值约定见下方常量，新增指标时应复用这些标签，
+// 以便同一套看板与告警规则可以横跨所有组件
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace 所有指标名的前缀
+const Namespace = "botmatrix"
+
+// 标签名约定
+const (
+	LabelPlatform  = "platform"  // 平台，如 qq、wechat、telegram
+	LabelBotID     = "bot_id"    // 机器人 SelfID
+	LabelWorkerID  = "worker_id" // Worker ID
+	LabelDecision  = "decision"  // 路由决策，取值见 Route* 常量
+	LabelStream    = "stream"    // Redis Stream 键名
+	LabelGroup     = "group"     // Redis 消费组
+	LabelProvider  = "provider"  // AI 提供商类型，如 openai、deepseek
+	LabelModel     = "model"     // AI 模型名称
+	LabelStatus    = "status"    // 结果状态，取值见 Status* 常量或任务执行状态
+	LabelTokenType = "type"      // Token 类型：prompt、completion
+	LabelPlugin    = "plugin"    // 插件 ID
+	LabelAction    = "action"    // 任务动作类型
+	LabelScope     = "scope"     // 限流维度：user、group
+)
+
+// 标签取值约定
+const (
+	Unknown = "unknown" // 标签值缺失时的占位值，避免出现空标签
+
+	StatusSuccess = "success"
+	StatusError   = "error"
+
+	RouteRule        = "rule"         // 命中路由规则，发送给规则指定的 Worker
+	RouteDirect      = "direct"       // 直接发送给调用方指定的 Worker
+	RouteLoadBalance = "load_balance" // 负载均衡选择 Worker
+	RouteRetry       = "retry"        // 发送失败，移除 Worker 后重试
+	RouteCached      = "cached"       // 无可用 Worker，消息进入缓存等待
+
+	ScopeUser  = "user"
+	ScopeGroup = "group"
+)
+
+// Registry 进程内的指标注册表，已包含 Go 运行时与进程指标
+var Registry = prometheus.NewRegistry()
+
+// latencyBuckets 消息链路耗时的分桶 (5ms ~ 30s)
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// aiBuckets AI 调用耗时的分桶 (100ms ~ 2min)
+var aiBuckets = []float64{.1, .25, .5, 1, 2, 5, 10, 20, 30, 60, 120}
+
+var (
+	// MessagesReceived 机器人上报给 Nexus 的事件数
+	MessagesReceived = newCounterVec("messages_received_total", "Events received from bots.", LabelPlatform, LabelBotID)
+	// MessagesSent Nexus 转发给机器人的动作数，status 表示是否写入成功
+	MessagesSent = newCounterVec("messages_sent_total", "Actions sent to bots.", LabelPlatform, LabelBotID, LabelStatus)
+	// AdapterEventsForwarded 适配器转发给 Nexus 的平台事件数，status 表示是否写入成功
+	AdapterEventsForwarded = newCounterVec("adapter_events_forwarded_total", "Platform events forwarded by adapters to BotNexus.", LabelPlatform, LabelStatus)
+	// AdapterActionsReceived 适配器从 Nexus 收到、待发往平台的动作数
+	AdapterActionsReceived = newCounterVec("adapter_actions_received_total", "Actions adapters received from BotNexus.", LabelPlatform)
+	// RoutingDecisions 消息转发给 Worker 时的路由决策
+	RoutingDecisions = newCounterVec("routing_decisions_total", "Routing decisions made when forwarding events to workers.", LabelDecision)
+	// RateLimitRejections 因超出频率限制被丢弃的消息
+	RateLimitRejections = newCounterVec("ratelimit_rejections_total", "Events dropped by the rate limiter.", LabelScope)
+
+	// WorkerRTT Worker 发起的机器人 API 调用往返耗时
+	WorkerRTT = newHistogramVec("worker_rtt_seconds", "Round trip time of bot API calls issued by workers.", latencyBuckets, LabelWorkerID)
+	// WorkerProcessDuration Worker 处理一条事件的耗时 (从 Nexus 转发到收到 Worker 回复)
+	WorkerProcessDuration = newHistogramVec("worker_process_seconds", "Time from forwarding an event to a worker until it replies.", latencyBuckets, LabelWorkerID)
+	// QueueLag 消息从写入 Redis Stream 到被 Worker 读取的延迟
+	QueueLag = newHistogramVec("queue_lag_seconds", "Delay between a message entering a Redis stream and a worker reading it.", latencyBuckets, LabelStream)
+
+	// AIRequestDuration AI 模型调用耗时，status 为 success 或 error
+	AIRequestDuration = newHistogramVec("ai_request_duration_seconds", "Latency of AI model calls.", aiBuckets, LabelProvider, LabelModel, LabelStatus)
+	// AITokens AI 模型调用消耗的 Token 数
+	AITokens = newCounterVec("ai_tokens_total", "Tokens consumed by AI model calls.", LabelProvider, LabelModel, LabelTokenType)
+	// AIErrors AI 模型调用失败次数
+	AIErrors = newCounterVec("ai_errors_total", "Failed AI model calls.", LabelProvider, LabelModel)
+
+	// PluginRestarts 插件进程自动重启次数
+	PluginRestarts = newCounterVec("plugin_restarts_total", "Automatic plugin process restarts.", LabelPlugin)
+	// PluginCrashes 插件进程异常退出次数
+	PluginCrashes = newCounterVec("plugin_crashes_total", "Plugin processes that exited unexpectedly.", LabelPlugin)
+
+	// TaskExecutions 定时任务执行结果，status 为执行记录的最终状态
+	TaskExecutions = newCounterVec("task_executions_total", "Task executions by final status.", LabelAction, LabelStatus)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: Namespace, Name: name, Help: help}, labels)
+	Registry.MustRegister(c)
+	return c
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: Namespace, Name: name, Help: help, Buckets: buckets}, labels)
+	Registry.MustRegister(h)
+	return h
+}
+
+// Value 规范化标签值：去除首尾空白，空值记为 unknown
+func Value(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return Unknown
+	}
+	return v
+}
+
+// Platform 规范化平台标签值，统一小写
+func Platform(p string) string {
+	return Value(strings.ToLower(p))
+}
+
+// Status 根据 err 返回 success 或 error
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
+
+// ObserveAICall 记录一次 AI 模型调用的耗时、Token 用量与结果
+func ObserveAICall(provider, model string, d time.Duration, promptTokens, completionTokens int, err error) {
+	provider, model = Value(provider), Value(model)
+	AIRequestDuration.WithLabelValues(provider, model, Status(err)).Observe(d.Seconds())
+	if err != nil {
+		AIErrors.WithLabelValues(provider, model).Inc()
+		return
+	}
+	if promptTokens > 0 {
+		AITokens.WithLabelValues(provider, model, "prompt").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		AITokens.WithLabelValues(provider, model, "completion").Add(float64(completionTokens))
+	}
+}
+
+// ObserveQueueLag 根据 Redis Stream 消息 ID 中的毫秒时间戳记录排队延迟
+func ObserveQueueLag(stream, id string, now time.Time) {
+	ms, _, ok := strings.Cut(id, "-")
+	if !ok {
+		return
+	}
+	ts, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return
+	}
+	lag := now.Sub(time.UnixMilli(ts))
+	if lag < 0 {
+		lag = 0
+	}
+	QueueLag.WithLabelValues(stream).Observe(lag.Seconds())
+}
+
+// Handler 返回 /metrics 的 HTTP 处理器；token 不为空时要求请求携带 Authorization: Bearer <token>
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
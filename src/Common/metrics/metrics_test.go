@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestValue(t *testing.T) {
+	if got := Value("  "); got != Unknown {
+		t.Fatalf("Value(blank) = %q, want %q", got, Unknown)
+	}
+	if got := Platform(" QQ "); got != "qq" {
+		t.Fatalf("Platform(QQ) = %q, want qq", got)
+	}
+	if Status(nil) != StatusSuccess || Status(errors.New("x")) != StatusError {
+		t.Fatal("Status mapping is wrong")
+	}
+}
+
+func TestObserveAICall(t *testing.T) {
+	ObserveAICall("openai", "gpt-test", 200*time.Millisecond, 10, 5, nil)
+	ObserveAICall("openai", "gpt-test", time.Second, 0, 0, errors.New("timeout"))
+
+	if got := testutil.ToFloat64(AITokens.WithLabelValues("openai", "gpt-test", "prompt")); got != 10 {
+		t.Fatalf("prompt tokens = %v, want 10", got)
+	}
+	if got := testutil.ToFloat64(AITokens.WithLabelValues("openai", "gpt-test", "completion")); got != 5 {
+		t.Fatalf("completion tokens = %v, want 5", got)
+	}
+	if got := testutil.ToFloat64(AIErrors.WithLabelValues("openai", "gpt-test")); got != 1 {
+		t.Fatalf("errors = %v, want 1", got)
+	}
+	if n := testutil.CollectAndCount(AIRequestDuration, Namespace+"_ai_request_duration_seconds"); n != 2 {
+		t.Fatalf("duration series = %d, want 2 (success and error)", n)
+	}
+}
+
+func TestObserveQueueLag(t *testing.T) {
+	now := time.UnixMilli(1700000002500)
+	ObserveQueueLag("botmatrix:queue:test", "1700000000000-0", now)
+	ObserveQueueLag("botmatrix:queue:test", "not-an-id", now)
+	ObserveQueueLag("botmatrix:queue:test", "invalid", now)
+
+	var m dto.Metric
+	if err := QueueLag.WithLabelValues("botmatrix:queue:test").(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if h := m.GetHistogram(); h.GetSampleCount() != 1 || h.GetSampleSum() != 2.5 {
+		t.Fatalf("lag count=%d sum=%v, want 1 and 2.5", h.GetSampleCount(), h.GetSampleSum())
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	MessagesReceived.WithLabelValues("qq", "10001").Inc()
+	h := Handler("secret")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token: status = %d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	for _, want := range []string{
+		`botmatrix_messages_received_total{bot_id="10001",platform="qq"}`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("exposition is missing %s", want)
+		}
+	}
+}
+
+func TestStreamCollector(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	const group = "botmatrix:group:workers"
+	for _, stream := range []string{"botmatrix:queue:default", "botmatrix:queue:worker:w1"} {
+		for i := 0; i < 3; i++ {
+			rdb.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]any{"payload": "{}"}})
+		}
+	}
+	if err := rdb.XGroupCreate(ctx, "botmatrix:queue:default", group, "0").Err(); err != nil {
+		t.Fatal(err)
+	}
+	// 读取两条但不确认，留下两条待确认消息
+	rdb.XReadGroup(ctx, &redis.XReadGroupArgs{Group: group, Consumer: "c1", Streams: []string{"botmatrix:queue:default", ">"}, Count: 2})
+	rdb.Set(ctx, "botmatrix:queue:not-a-stream", "x", 0)
+
+	expected := `
+# HELP botmatrix_redis_stream_length Number of entries in a Redis stream.
+# TYPE botmatrix_redis_stream_length gauge
+botmatrix_redis_stream_length{stream="botmatrix:queue:default"} 3
+botmatrix_redis_stream_length{stream="botmatrix:queue:worker:w1"} 3
+# HELP botmatrix_redis_stream_pending Messages delivered to a consumer group but not yet acknowledged.
+# TYPE botmatrix_redis_stream_pending gauge
+botmatrix_redis_stream_pending{group="botmatrix:group:workers",stream="botmatrix:queue:default"} 2
+botmatrix_redis_stream_pending{group="botmatrix:group:workers",stream="botmatrix:queue:worker:w1"} 0
+`
+	c := NewStreamCollector(rdb, group, "botmatrix:queue:*")
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"strings"
 
+	"BotMatrix/common/apikey"
+	"BotMatrix/common/rbac"
 	"BotMatrix/common/types"
 	"BotMatrix/common/utils"
 )
@@ -80,3 +82,138 @@ func AdminMiddleware(jwtMiddleware func(http.HandlerFunc) http.HandlerFunc) func
 		})
 	}
 }
+
+// AuthorizeFunc 计算用户的有效授权
+type AuthorizeFunc func(claims *types.UserClaims) (*rbac.Authorization, error)
+
+// PermissionMiddleware 校验用户在请求涉及的范围 (bot_id、group_id、enterprise_id) 内拥有所需权限：
+// GET/HEAD 请求需要 read，其余需要 write；请求不带范围参数时需要全局授权，范围参数冲突或无法解析时返回 400。
+// scoped 为 true 时 (如列表接口)，不带范围参数的请求只需在任意范围内拥有权限，由处理函数按 rbac.FromContext 过滤结果
+func PermissionMiddleware(jwtMiddleware func(http.HandlerFunc) http.HandlerFunc, authorize AuthorizeFunc, read, write rbac.Permission, scoped bool) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return jwtMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			lang := utils.GetLangFromRequest(r)
+			claims, ok := r.Context().Value(types.UserClaimsKey).(*types.UserClaims)
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				utils.SendJSONResponse(w, false, utils.T(lang, "missing_auth_header|未提供认证信息"), nil)
+				return
+			}
+			authz, err := authorize(claims)
+			if err != nil {
+				log.Printf("[RBAC] Failed to load permissions for user %s: %v", claims.Username, err)
+				w.WriteHeader(http.StatusInternalServerError)
+				utils.SendJSONResponse(w, false, err.Error(), nil)
+				return
+			}
+
+			perm := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				perm = read
+			}
+			scopes, err := rbac.ScopesFromRequest(r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				utils.SendJSONResponse(w, false, utils.T(lang, "invalid_scope|请求范围参数无效: %v", err), nil)
+				return
+			}
+			allowed := authz.AllowedAll(perm, scopes)
+			if !allowed && scoped && len(scopes) == 0 {
+				allowed = authz.AllowedAnywhere(perm)
+			}
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				utils.SendJSONResponse(w, false, utils.T(lang, "permission_denied|缺少权限: %s", perm), nil)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(rbac.WithAuthorization(r.Context(), authz)))
+		})
+	}
+}
+
+// APIKeyAuthenticator 校验 API Key 并记录审计
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(r *http.Request, key string) (*types.UserClaims, error)
+	AuditAPIKey(r *http.Request, claims *types.UserClaims, status int)
+}
+
+// APIKeyMiddleware 请求携带 API Key (X-API-Key 或 Authorization: Bearer bmk_...) 时以服务账号身份认证并记录审计，
+// 否则交给 fallback (JWT) 处理。API Key 不接受 ?token= 查询参数
+func APIKeyMiddleware(auth APIKeyAuthenticator, fallback func(http.HandlerFunc) http.HandlerFunc) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		jwtNext := fallback(next)
+		return func(w http.ResponseWriter, r *http.Request) {
+			lang := utils.GetLangFromRequest(r)
+			if apikey.IsKey(r.URL.Query().Get("token")) {
+				w.WriteHeader(http.StatusUnauthorized)
+				utils.SendJSONResponse(w, false, utils.T(lang, "api_key_in_query|API Key 只能通过请求头传递"), nil)
+				return
+			}
+			key := apikey.FromRequest(r)
+			if key == "" {
+				jwtNext(w, r)
+				return
+			}
+
+			claims, err := auth.AuthenticateAPIKey(r, key)
+			if err != nil {
+				log.Printf("[Auth] API key rejected for %s: %v", r.URL.Path, err)
+				w.WriteHeader(http.StatusUnauthorized)
+				utils.SendJSONResponse(w, false, utils.T(lang, "invalid_api_key|无效或已过期的 API Key"), nil)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			ctx := context.WithValue(r.Context(), types.UserClaimsKey, claims)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			auth.AuditAPIKey(r, claims, rec.status)
+		}
+	}
+}
+
+// RejectAPIKeyMiddleware 拒绝携带 API Key 的请求，其余交给 next (JWT) 处理。
+// 接口只有显式声明 API Key 所需的权限 (见 APIKeyMiddleware) 时才对服务账号开放
+func RejectAPIKeyMiddleware(next func(http.HandlerFunc) http.HandlerFunc) func(http.HandlerFunc) http.HandlerFunc {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		jwtNext := next(h)
+		return func(w http.ResponseWriter, r *http.Request) {
+			if apikey.FromRequest(r) != "" || apikey.IsKey(r.URL.Query().Get("token")) {
+				lang := utils.GetLangFromRequest(r)
+				w.WriteHeader(http.StatusForbidden)
+				utils.SendJSONResponse(w, false, utils.T(lang, "api_key_not_allowed|该接口不接受 API Key"), nil)
+				return
+			}
+			jwtNext(w, r)
+		}
+	}
+}
+
+// statusRecorder 记录响应状态码，保留流式响应所需的 Flush
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
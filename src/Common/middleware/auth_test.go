@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"BotMatrix/common/rbac"
+	"BotMatrix/common/types"
+)
+
+type fakeKeys struct{ audited []int }
+
+func (f *fakeKeys) AuthenticateAPIKey(r *http.Request, key string) (*types.UserClaims, error) {
+	if key != "bmk_test_secret" {
+		return nil, errors.New("invalid key")
+	}
+	return &types.UserClaims{Username: "svc:crm", APIKeyID: 1, Scopes: []string{string(rbac.MessagesSend)}}, nil
+}
+
+func (f *fakeKeys) AuditAPIKey(r *http.Request, claims *types.UserClaims, status int) {
+	f.audited = append(f.audited, status)
+}
+
+// fakeSession 模拟 JWT 校验：Bearer user-token 视为已登录用户
+func fakeSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer user-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func TestAPIKeysAreDeniedUnlessRouteDeclaresPermission(t *testing.T) {
+	keys := &fakeKeys{}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	authorize := func(claims *types.UserClaims) (*rbac.Authorization, error) {
+		authz := &rbac.Authorization{Admin: true} // 服务账号拥有全部 RBAC 权限，只受 Key 的 scopes 限制
+		for _, s := range claims.Scopes {
+			authz.Limit = append(authz.Limit, rbac.Permission(s))
+		}
+		return authz, nil
+	}
+	keyOrSession := APIKeyMiddleware(keys, fakeSession)
+
+	sessionOnly := RejectAPIKeyMiddleware(fakeSession)(ok)
+	sendRoute := PermissionMiddleware(keyOrSession, authorize, rbac.MessagesSend, rbac.MessagesSend, false)(ok)
+	configRoute := PermissionMiddleware(keyOrSession, authorize, rbac.ConfigWrite, rbac.ConfigWrite, false)(ok)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		header  [2]string
+		target  string
+		want    int
+	}{
+		{"session on jwt-only route", sessionOnly, [2]string{"Authorization", "Bearer user-token"}, "/api/tasks", http.StatusNoContent},
+		{"key header on jwt-only route", sessionOnly, [2]string{"X-API-Key", "bmk_test_secret"}, "/api/tasks", http.StatusForbidden},
+		{"bearer key on jwt-only route", sessionOnly, [2]string{"Authorization", "Bearer bmk_test_secret"}, "/api/tasks", http.StatusForbidden},
+		{"query key on jwt-only route", sessionOnly, [2]string{}, "/api/tasks?token=bmk_test_secret", http.StatusForbidden},
+		{"key within scope", sendRoute, [2]string{"X-API-Key", "bmk_test_secret"}, "/api/action", http.StatusNoContent},
+		{"key outside scope", configRoute, [2]string{"X-API-Key", "bmk_test_secret"}, "/api/admin/config", http.StatusForbidden},
+		{"invalid key", sendRoute, [2]string{"X-API-Key", "bmk_bad"}, "/api/action", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.header[0] != "" {
+				req.Header.Set(tt.header[0], tt.header[1])
+			}
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+	if len(keys.audited) != 2 {
+		t.Errorf("audited = %v, want the two authenticated key requests", keys.audited)
+	}
+}
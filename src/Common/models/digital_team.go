@@ -9,16 +9,17 @@ import (
 // DigitalJob 定义具体的职位/工种 (Role/Position)
 // 这是数字员工的社会身份，决定了它的职责范围和KPI标准
 type DigitalJob struct {
-	ID          uint           `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
-	Name        string         `gorm:"size:100;uniqueIndex;not null;column:name" json:"name"` // e.g. "Senior Go Developer", "Marketing Specialist"
-	Description string         `gorm:"type:text;column:description" json:"description"`
-	Level       int            `gorm:"default:1;column:level" json:"level"` // 职级 (1-10)
-	Department  string         `gorm:"size:100;column:department" json:"department"`
-	BaseSalary  int64          `gorm:"default:0;column:base_salary" json:"base_salary"` // 基础薪资 (Token/Day)
-	IsActive    bool           `gorm:"default:true;column:is_active" json:"is_active"`
-	CreatedAt   time.Time      `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt   time.Time      `gorm:"column:updated_at" json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
+	ID             uint           `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Name           string         `gorm:"size:100;uniqueIndex;not null;column:name" json:"name"` // e.g. "Senior Go Developer", "Marketing Specialist"
+	Description    string         `gorm:"type:text;column:description" json:"description"`
+	Level          int            `gorm:"default:1;column:level" json:"level"` // 职级 (1-10)
+	Department     string         `gorm:"size:100;column:department" json:"department"`
+	BaseSalary     int64          `gorm:"default:0;column:base_salary" json:"base_salary"`         // 基础薪资 (Token/Day)
+	ConfigTemplate string         `gorm:"type:text;column:config_template" json:"config_template"` // 岗位默认配置 (JSON，格式同 ABVariant.ConfigOverride)，A/B 实验胜出变体合并至此
+	IsActive       bool           `gorm:"default:true;column:is_active" json:"is_active"`
+	CreatedAt      time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
 }
 
 func (DigitalJob) TableName() string {
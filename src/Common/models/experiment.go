@@ -9,17 +9,26 @@ import (
 // ABExperiment 定义一个 A/B 测试实验
 // 用于对比不同设定（Prompt, Model, Parameters, Memory）对数字员工绩效的影响
 type ABExperiment struct {
-	ID          uint           `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
-	Name        string         `gorm:"size:100;not null;column:name" json:"name"`
-	Description string         `gorm:"type:text;column:description" json:"description"`
-	TargetJobID uint           `gorm:"index;column:target_job_id" json:"target_job_id"`       // 测试针对的目标岗位
-	Status      string         `gorm:"size:20;default:'planned';column:status" json:"status"` // planned, running, completed, halted
-	Metric      string         `gorm:"size:50;column:metric" json:"metric"`                   // 核心考核指标: kpi_score, revenue, task_completion_rate
-	StartDate   *time.Time     `gorm:"column:start_date" json:"start_date"`
-	EndDate     *time.Time     `gorm:"column:end_date" json:"end_date"`
-	CreatedAt   time.Time      `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt   time.Time      `gorm:"column:updated_at" json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
+	ID          uint       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Name        string     `gorm:"size:100;not null;column:name" json:"name"`
+	Description string     `gorm:"type:text;column:description" json:"description"`
+	TargetJobID uint       `gorm:"index;column:target_job_id" json:"target_job_id"`       // 测试针对的目标岗位
+	Status      string     `gorm:"size:20;default:'planned';column:status" json:"status"` // planned, running, completed, halted
+	Metric      string     `gorm:"size:50;column:metric" json:"metric"`                   // 核心考核指标: kpi_score, revenue, task_completion_rate
+	Unit        string     `gorm:"size:20;default:'user';column:unit" json:"unit"`        // 分流单位: user (按用户), conversation (按会话)
+	StartDate   *time.Time `gorm:"column:start_date" json:"start_date"`
+	EndDate     *time.Time `gorm:"column:end_date" json:"end_date"`
+
+	// 统计与护栏配置
+	MinSamples            int            `gorm:"column:min_samples" json:"min_samples"`                           // 每个变体参与判定的最少样本数，0 为默认 30
+	Confidence            float64        `gorm:"column:confidence" json:"confidence"`                             // 置信水平，0 为默认 0.95
+	GuardrailMaxDrop      float64        `gorm:"column:guardrail_max_drop" json:"guardrail_max_drop"`             // 核心指标相对对照组显著下降超过该比例时自动停止 (0.2 = 20%)，0 为不检查
+	GuardrailMaxCostRatio float64        `gorm:"column:guardrail_max_cost_ratio" json:"guardrail_max_cost_ratio"` // Token 成本超过对照组该倍数时自动停止，0 为不检查
+	HaltReason            string         `gorm:"type:text;column:halt_reason" json:"halt_reason"`
+	WinnerVariantID       *uint          `gorm:"column:winner_variant_id" json:"winner_variant_id"`
+	CreatedAt             time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt             time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
 }
 
 func (ABExperiment) TableName() string {
@@ -40,6 +49,7 @@ type ABVariant struct {
 	ConfigOverride string `gorm:"type:text;column:config_override" json:"config_override"`
 
 	Allocation  int       `gorm:"default:0;column:allocation" json:"allocation"`     // 流量/实例分配权重 (0-100)
+	IsControl   bool      `gorm:"column:is_control" json:"is_control"`               // 对照组，未指定时取 ID 最小的变体
 	SampleCount int       `gorm:"default:0;column:sample_count" json:"sample_count"` // 当前已生成的实例数量
 	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
@@ -63,6 +73,34 @@ func (EmployeeExperimentRelation) TableName() string {
 	return "EmployeeExperimentRelation"
 }
 
+// ABAssignment 记录分流单位 (用户/会话) 命中的变体，保证调整权重后已分配的用户不会被重新分流
+type ABAssignment struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	ExperimentID uint      `gorm:"uniqueIndex:idx_ab_assignment_unit,priority:1;column:experiment_id" json:"experiment_id"`
+	UnitKey      string    `gorm:"size:255;uniqueIndex:idx_ab_assignment_unit,priority:2;column:unit_key" json:"unit_key"`
+	VariantID    uint      `gorm:"index;column:variant_id" json:"variant_id"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ABAssignment) TableName() string {
+	return "ABAssignment"
+}
+
+// ABObservation 变体的一次指标观测 (KPI 评分、Token 成本、用户反馈)
+type ABObservation struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	ExperimentID uint      `gorm:"index:idx_ab_observation,priority:1;column:experiment_id" json:"experiment_id"`
+	VariantID    uint      `gorm:"index:idx_ab_observation,priority:2;column:variant_id" json:"variant_id"`
+	Metric       string    `gorm:"size:50;index:idx_ab_observation,priority:3;column:metric" json:"metric"` // kpi_score, token_cost, feedback
+	UnitKey      string    `gorm:"size:255;column:unit_key" json:"unit_key"`
+	Value        float64   `gorm:"column:value" json:"value"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ABObservation) TableName() string {
+	return "ABObservation"
+}
+
 // MemorySnapshot 记忆快照 (用于克隆高绩效员工的记忆)
 type MemorySnapshot struct {
 	ID          uint      `gorm:"primaryKey;autoIncrement;column:Id" json:"id"`
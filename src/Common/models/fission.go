@@ -14,6 +14,9 @@ type FissionConfigGORM struct {
 	MinLevelRequired     int       `gorm:"default:0;column:min_level_required" json:"min_level_required"`
 	MaxDailyInvites      int       `gorm:"default:0;column:max_daily_invites" json:"max_daily_invites"`
 	AntiFraudEnabled     bool      `gorm:"default:true;column:anti_fraud_enabled" json:"anti_fraud_enabled"`
+	RiskHoldScore        int       `gorm:"default:40;column:risk_hold_score" json:"risk_hold_score"`     // 风险分达到该值时奖励延迟发放
+	RiskReviewScore      int       `gorm:"default:70;column:risk_review_score" json:"risk_review_score"` // 风险分达到该值时奖励需人工审核
+	RewardHoldHours      int       `gorm:"default:24;column:reward_hold_hours" json:"reward_hold_hours"` // 延迟发放的小时数
 	WelcomeMessage       string    `gorm:"size:1000;column:welcome_message" json:"welcome_message"`
 	InviteCodeTemplate   string    `gorm:"size:255;default:'INV-{RAND}';column:invite_code_template" json:"invite_code_template"`
 	Rules                string    `gorm:"type:text;column:rules" json:"rules"`
@@ -31,9 +34,13 @@ type InvitationGORM struct {
 	InviteeID   int64      `gorm:"uniqueIndex;column:invitee_id" json:"invitee_id"` // 被邀请人 ID (唯一，一个用户只能被邀请一次)
 	Platform    string     `gorm:"size:50;column:platform" json:"platform"`
 	InviteCode  string     `gorm:"index;size:50;column:invite_code" json:"invite_code"`
-	Status      string     `gorm:"size:20;default:'pending';column:status" json:"status"` // pending, completed, invalid
+	Status      string     `gorm:"size:20;default:'pending';column:status" json:"status"` // pending, held, review, completed, rejected, invalid
 	IPAddress   string     `gorm:"column:ip_address;size:100" json:"ip_address"`
 	DeviceID    string     `gorm:"size:255;column:device_id" json:"device_id"`
+	RiskScore   int        `gorm:"default:0;column:risk_score" json:"risk_score"`
+	RiskSignals string     `gorm:"type:text;column:risk_signals" json:"risk_signals"` // 风险信号 JSON 数组
+	ReviewedBy  string     `gorm:"size:100;column:reviewed_by" json:"reviewed_by"`
+	ReviewedAt  *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
 	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
 	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at"`
 	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
@@ -92,3 +99,25 @@ type FissionRewardLogGORM struct {
 func (FissionRewardLogGORM) TableName() string {
 	return "fission_reward_logs"
 }
+
+// FissionRewardOutboxGORM 待发放的裂变奖励 (发件箱)
+// 绑定邀请与写入奖励在同一事务内完成，奖励由 BotWorker 的发放任务按 AvailableAt 逐条入账，幂等键保证不会重复发放
+type FissionRewardOutboxGORM struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	InvitationID   uint      `gorm:"index;column:invitation_id" json:"invitation_id"` // 0 表示与邀请无关的任务奖励
+	UserID         int64     `gorm:"index;column:user_id" json:"user_id"`
+	Amount         int       `gorm:"column:amount" json:"amount"`
+	Reason         string    `gorm:"size:255;column:reason" json:"reason"`
+	Category       string    `gorm:"size:50;column:category" json:"category"`
+	IdempotencyKey string    `gorm:"uniqueIndex;size:191;column:idempotency_key" json:"idempotency_key"`
+	Status         string    `gorm:"index;size:20;default:'pending';column:status" json:"status"` // pending, review, done, cancelled, failed
+	AvailableAt    time.Time `gorm:"index;column:available_at" json:"available_at"`
+	Attempts       int       `gorm:"default:0;column:attempts" json:"attempts"`
+	LastError      string    `gorm:"type:text;column:last_error" json:"last_error"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (FissionRewardOutboxGORM) TableName() string {
+	return "fission_reward_outbox"
+}
@@ -380,6 +380,7 @@ type MCPTool struct {
 	Name        string    `gorm:"size:100;not null;column:Name" json:"name"`
 	Description string    `gorm:"type:text;column:Description" json:"description"`
 	InputSchema string    `gorm:"type:text;column:InputSchema" json:"input_schema"` // JSON Schema
+	RiskLevel   string    `gorm:"size:20;column:RiskLevel" json:"risk_level"`       // 风险等级 (low, medium, high)，high 需人工审批
 	IsActive    bool      `gorm:"default:true;column:IsActive" json:"is_active"`
 	CreatedAt   time.Time `gorm:"column:CreatedAt" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"column:UpdatedAt" json:"updated_at"`
@@ -697,22 +698,55 @@ func (DigitalEmployeeTodo) TableName() string {
 // ToolAuditLog 工具调用审计日志
 type ToolAuditLog struct {
 	Id              uint       `gorm:"primaryKey;autoIncrement;column:Id" json:"id"`
+	RunID           string     `gorm:"index;size:64;column:RunId" json:"run_id"` // 挂起的智能体运行 (AgentRun.RunID)
+	SessionID       string     `gorm:"index;size:100;column:SessionId" json:"session_id"`
+	ToolCallID      string     `gorm:"size:100;column:ToolCallId" json:"tool_call_id"`
 	BotID           string     `gorm:"size:64;column:BotId" json:"bot_id"`
 	UserID          string     `gorm:"size:64;column:UserId" json:"user_id"`
+	GroupID         string     `gorm:"size:64;column:GroupId" json:"group_id"`
 	ToolName        string     `gorm:"size:100;column:ToolName" json:"tool_name"`
 	Arguments       string     `gorm:"type:text;column:Arguments" json:"arguments"`
 	RiskLevel       string     `gorm:"size:20;column:RiskLevel" json:"risk_level"`
-	Status          string     `gorm:"size:50;column:Status" json:"status"` // PendingApproval, Approved, Rejected
+	Status          string     `gorm:"index;size:50;column:Status" json:"status"` // PendingApproval, Approved, Rejected, Expired
 	RejectionReason string     `gorm:"type:text;column:RejectionReason" json:"rejection_reason"`
+	Result          string     `gorm:"type:text;column:Result" json:"result"` // 审批通过后的实际执行结果
 	CreateTime      time.Time  `gorm:"column:CreateTime" json:"create_time"`
+	ExpireTime      *time.Time `gorm:"column:ExpireTime" json:"expire_time"` // 审批截止时间，超时视为拒绝
 	ApprovedAt      *time.Time `gorm:"column:ApprovedAt" json:"approved_at"`
 	ApprovedBy      string     `gorm:"size:100;column:ApprovedBy" json:"approved_by"`
+	ExecutedAt      *time.Time `gorm:"column:ExecutedAt" json:"executed_at"`
 }
 
 func (ToolAuditLog) TableName() string {
 	return "ToolAuditLog"
 }
 
+// AgentRun 因高风险工具等待审批而挂起的智能体运行，审批完成后据此恢复
+type AgentRun struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement;column:Id" json:"id"`
+	RunID       string    `gorm:"uniqueIndex;size:64;column:RunId" json:"run_id"`
+	SessionID   string    `gorm:"index;size:100;column:SessionId" json:"session_id"`
+	BotID       string    `gorm:"size:64;column:BotId" json:"bot_id"`
+	UserID      string    `gorm:"size:64;column:UserId" json:"user_id"`
+	GroupID     string    `gorm:"size:64;column:GroupId" json:"group_id"`
+	Platform    string    `gorm:"size:50;column:Platform" json:"platform"`
+	MessageType string    `gorm:"size:20;column:MessageType" json:"message_type"` // 恢复后回复原会话用
+	ModelID     uint      `gorm:"column:ModelId" json:"model_id"`
+	Step        int       `gorm:"column:Step" json:"step"`                   // 挂起时的迭代轮次
+	Messages    string    `gorm:"type:text;column:Messages" json:"-"`        // 挂起时的完整消息历史 (JSON)
+	Tools       string    `gorm:"type:text;column:Tools" json:"-"`           // 可用工具定义 (JSON)
+	Context     string    `gorm:"type:text;column:Context" json:"-"`         // 恢复执行所需的上下文值 (JSON)
+	Status      string    `gorm:"index;size:20;column:Status" json:"status"` // suspended, resuming, completed, failed
+	Result      string    `gorm:"type:text;column:Result" json:"result"`     // 恢复后的最终回复或错误
+	ExpireTime  time.Time `gorm:"index;column:ExpireTime" json:"expire_time"`
+	CreatedAt   time.Time `gorm:"column:CreatedAt" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:UpdatedAt" json:"updated_at"`
+}
+
+func (AgentRun) TableName() string {
+	return "AgentRun"
+}
+
 // Aliases for backward compatibility and facade consistency
 type AIProviderGORM = AIProvider
 type AIModelGORM = AIModel
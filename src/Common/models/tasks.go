@@ -132,9 +132,9 @@ type UserIdentity struct {
 	CreatedAt   time.Time      `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"column:updated_at" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index;column:deleted_at" json:"deleted_at"`
-	NexusUID    string         `gorm:"size:100;uniqueIndex;not null;column:nexus_uid" json:"nexus_uid"` // 统一的 Nexus ID
-	Platform    string         `gorm:"size:50;index;not null;column:platform" json:"platform"`          // 平台 (qq, wechat, tg)
-	PlatformUID string         `gorm:"size:255;index;not null;column:platform_uid" json:"platform_uid"` // 平台内部 ID
+	NexusUID    string         `gorm:"size:100;index:idx_identity_nexus_uid;not null;column:nexus_uid" json:"nexus_uid"`                                 // 统一的 Nexus ID (同一用户的多个平台账号共享)
+	Platform    string         `gorm:"size:50;uniqueIndex:idx_identity_platform_uid,priority:1;not null;column:platform" json:"platform"`                // 平台 (qq, wechat, tg)
+	PlatformUID string         `gorm:"size:255;uniqueIndex:idx_identity_platform_uid,priority:2;index;not null;column:platform_uid" json:"platform_uid"` // 平台内部 ID
 	Nickname    string         `gorm:"size:255;column:nickname" json:"nickname"`
 	Metadata    string         `gorm:"type:text;column:metadata" json:"metadata"` // 扩展属性 (积分、等级、偏好等)
 }
@@ -143,6 +143,41 @@ func (UserIdentity) TableName() string {
 	return "user_identities"
 }
 
+// IdentityBindCode 跨平台绑定的一次性验证码
+type IdentityBindCode struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	CodeHash    string     `gorm:"size:64;index;not null;column:code_hash" json:"-"`
+	NexusUID    string     `gorm:"size:100;index;not null;column:nexus_uid" json:"nexus_uid"` // 发起绑定的身份
+	Platform    string     `gorm:"size:50;column:platform" json:"platform"`
+	PlatformUID string     `gorm:"size:255;column:platform_uid" json:"platform_uid"`
+	ExpireTime  time.Time  `gorm:"index;column:expire_time" json:"expire_time"`
+	UsedAt      *time.Time `gorm:"column:used_at" json:"used_at"`
+
+	TargetPlatform string `gorm:"size:50;column:target_platform" json:"target_platform"` // 只能由该平台的账号兑换
+}
+
+func (IdentityBindCode) TableName() string {
+	return "user_identity_bind_codes"
+}
+
+// IdentityLinkLog 身份绑定、合并与拆分的审计日志
+type IdentityLinkLog struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	CreatedAt   time.Time `gorm:"index;column:created_at" json:"created_at"`
+	Action      string    `gorm:"size:20;index;column:action" json:"action"`        // create, bind, merge, split
+	NexusUID    string    `gorm:"size:100;index;column:nexus_uid" json:"nexus_uid"` // 操作后的身份
+	FromUID     string    `gorm:"size:100;index;column:from_uid" json:"from_uid"`   // 被合并或拆分前的身份
+	Platform    string    `gorm:"size:50;column:platform" json:"platform"`          // 涉及的平台账号
+	PlatformUID string    `gorm:"size:255;column:platform_uid" json:"platform_uid"`
+	Operator    string    `gorm:"size:100;column:operator" json:"operator"` // 用户自助为平台账号，管理员为用户名
+	Detail      string    `gorm:"type:text;column:detail" json:"detail"`
+}
+
+func (IdentityLinkLog) TableName() string {
+	return "user_identity_link_logs"
+}
+
 // ShadowRule 影子执行与 A/B 测试规则
 type ShadowRule struct {
 	ID             uint           `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
@@ -154,12 +189,42 @@ type ShadowRule struct {
 	MatchPattern   string         `gorm:"size:255;column:match_pattern" json:"match_pattern"`                // 匹配模式 (bot_*, group_*)
 	IsEnabled      bool           `gorm:"default:false;column:is_enabled" json:"is_enabled"`
 	TrafficPercent int            `gorm:"default:0;column:traffic_percent" json:"traffic_percent"` // 影子流量比例 (0-100)
+	MaxErrors      int            `gorm:"default:5;column:max_errors" json:"max_errors"`           // 连续错误达到该值时自动停用
+	ErrorCount     int            `gorm:"default:0;column:error_count" json:"error_count"`         // 当前连续错误次数
+	DisabledReason string         `gorm:"size:255;column:disabled_reason" json:"disabled_reason"`  // 自动停用原因
 }
 
 func (ShadowRule) TableName() string {
 	return "task_shadow_rules"
 }
 
+// ShadowRecord 影子执行对比记录 (主 Worker 与影子 Worker 对同一条消息的响应)
+type ShadowRecord struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	CreatedAt        time.Time `gorm:"index;column:created_at" json:"created_at"`
+	RuleID           uint      `gorm:"index;column:rule_id" json:"rule_id"`
+	MessageID        string    `gorm:"size:255;column:message_id" json:"message_id"`
+	Platform         string    `gorm:"size:50;column:platform" json:"platform"`
+	SelfID           string    `gorm:"size:100;column:self_id" json:"self_id"`
+	GroupID          string    `gorm:"size:100;column:group_id" json:"group_id"`
+	UserID           string    `gorm:"size:100;column:user_id" json:"user_id"`
+	Content          string    `gorm:"type:text;column:content" json:"content"`
+	PrimaryWorkerID  string    `gorm:"size:100;column:primary_worker_id" json:"primary_worker_id"`
+	ShadowWorkerID   string    `gorm:"size:100;column:shadow_worker_id" json:"shadow_worker_id"`
+	PrimaryLatencyMs int64     `gorm:"column:primary_latency_ms" json:"primary_latency_ms"` // -1 表示无响应
+	ShadowLatencyMs  int64     `gorm:"column:shadow_latency_ms" json:"shadow_latency_ms"`
+	PrimaryReply     string    `gorm:"type:text;column:primary_reply" json:"primary_reply"`
+	ShadowReply      string    `gorm:"type:text;column:shadow_reply" json:"shadow_reply"`
+	PrimaryActions   string    `gorm:"type:text;column:primary_actions" json:"primary_actions"` // JSON 序列化的动作列表
+	ShadowActions    string    `gorm:"type:text;column:shadow_actions" json:"shadow_actions"`
+	ReplyMatch       bool      `gorm:"column:reply_match" json:"reply_match"`
+	ActionsMatch     bool      `gorm:"column:actions_match" json:"actions_match"`
+}
+
+func (ShadowRecord) TableName() string {
+	return "task_shadow_records"
+}
+
 // TaskTag 任务与标签的中间表
 type TaskTag struct {
 	TaskID uint `gorm:"primaryKey;column:task_id" json:"task_id"`
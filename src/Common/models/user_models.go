@@ -14,9 +14,13 @@ type User struct {
 	Platform       string         `gorm:"size:32;column:platform" json:"platform"`
 	PlatformID     string         `gorm:"size:64;column:platform_id" json:"platform_id"`
 	IsAdmin        bool           `gorm:"default:false;column:is_admin" json:"is_admin"`
+	IsService      bool           `gorm:"default:false;column:is_service" json:"is_service"` // 服务账号：不能密码登录，只能通过 API Key 访问
 	QQ             string         `gorm:"size:20;column:qq" json:"qq"`
 	Active         bool           `gorm:"default:true;column:active" json:"active"`
 	SessionVersion int            `gorm:"default:1;column:session_version" json:"session_version"`
+	TOTPSecret     string         `gorm:"size:64;column:totp_secret" json:"-"` // TOTP 密钥 (Base32)，启用前为待验证状态
+	TOTPEnabled    bool           `gorm:"default:false;column:totp_enabled" json:"totp_enabled"`
+	TOTPLastStep   int64          `gorm:"column:totp_last_step" json:"-"` // 最近一次通过校验的时间步，防止验证码重放
 	CreatedAt      time.Time      `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt      time.Time      `gorm:"column:updated_at" json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
@@ -42,3 +46,87 @@ type UserLoginTokenGORM struct {
 func (UserLoginTokenGORM) TableName() string {
 	return "UserLoginToken"
 }
+
+// SSOIdentity 用户与 IdP 身份 (issuer + subject) 的绑定，SSO 登录时即时创建
+type SSOIdentity struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	UserID      uint       `gorm:"index;not null;column:user_id" json:"user_id"`
+	Issuer      string     `gorm:"uniqueIndex:idx_sso_identity;not null;size:255;column:issuer" json:"issuer"`
+	Subject     string     `gorm:"uniqueIndex:idx_sso_identity;not null;size:255;column:subject" json:"subject"`
+	Email       string     `gorm:"size:255;column:email" json:"email"`
+	SessionID   string     `gorm:"index;size:255;column:session_id" json:"-"` // IdP 会话 (sid)，用于后端通道登出
+	LastLoginAt *time.Time `gorm:"column:last_login_at" json:"last_login_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (SSOIdentity) TableName() string {
+	return "sso_identities"
+}
+
+// RBACRole 自定义角色，内置角色定义在 rbac 包中不入库
+type RBACRole struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null;size:64;column:name" json:"name"`
+	Description string    `gorm:"size:255;column:description" json:"description"`
+	Permissions string    `gorm:"type:text;column:permissions" json:"permissions"` // 权限列表 (JSON 数组)，支持 bots:* 与 * 通配
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (RBACRole) TableName() string {
+	return "rbac_roles"
+}
+
+// RBACRoleBinding 将角色授予用户，可限定在某个机器人、群组或企业范围内
+type RBACRoleBinding struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	UserID    uint      `gorm:"index;not null;column:user_id" json:"user_id"`
+	Role      string    `gorm:"index;not null;size:64;column:role" json:"role"`
+	ScopeType string    `gorm:"size:20;not null;default:'global';column:scope_type" json:"scope_type"` // global, bot, group, enterprise
+	ScopeID   string    `gorm:"size:64;column:scope_id" json:"scope_id"`                               // 机器人 SelfID、群号或企业 ID，global 时为空
+	CreatedBy string    `gorm:"size:255;column:created_by" json:"created_by"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (RBACRoleBinding) TableName() string {
+	return "rbac_role_bindings"
+}
+
+// APIKey 服务账号的 API Key，仅保存哈希。完整 Key 形如 bmk_<Prefix>_<secret>，Prefix 用于识别与查找
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	AccountID  uint       `gorm:"index;not null;column:account_id" json:"account_id"` // 所属服务账号 (users.id)
+	Name       string     `gorm:"size:100;column:name" json:"name"`
+	Prefix     string     `gorm:"uniqueIndex;not null;size:16;column:prefix" json:"prefix"`
+	Hash       string     `gorm:"not null;size:64;column:hash" json:"-"`           // SHA-256(完整 Key)
+	Scopes     string     `gorm:"type:text;column:scopes" json:"scopes"`           // 权限上限 (JSON 数组)，为空时等同服务账号的全部权限
+	AllowedIPs string     `gorm:"type:text;column:allowed_ips" json:"allowed_ips"` // 来源 IP 白名单 (JSON 数组，IP 或 CIDR)，为空时不限制
+	ExpiresAt  *time.Time `gorm:"index;column:expires_at" json:"expires_at"`       // 为空时永不过期
+	RotatedTo  uint       `gorm:"column:rotated_to" json:"rotated_to,omitempty"`   // 轮换后的新 Key ID
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:64;column:last_used_ip" json:"last_used_ip"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	CreatedBy  string     `gorm:"size:255;column:created_by" json:"created_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// APIKeyAuditLog 通过 API Key 发起的请求记录
+type APIKeyAuditLog struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	KeyID     uint      `gorm:"index;column:key_id" json:"key_id"`
+	KeyPrefix string    `gorm:"size:16;column:key_prefix" json:"key_prefix"`
+	AccountID uint      `gorm:"index;column:account_id" json:"account_id"`
+	Method    string    `gorm:"size:10;column:method" json:"method"`
+	Path      string    `gorm:"size:255;column:path" json:"path"`
+	Status    int       `gorm:"column:status" json:"status"`
+	IP        string    `gorm:"size:64;column:ip" json:"ip"`
+	CreatedAt time.Time `gorm:"index;column:created_at" json:"created_at"`
+}
+
+func (APIKeyAuditLog) TableName() string {
+	return "api_key_audit_logs"
+}
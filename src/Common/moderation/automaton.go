@@ -0,0 +1,94 @@
+package moderation
+
+// automaton 按 rune 构建的 Aho-Corasick 自动机，一次扫描即可找出文本中所有模式串的出现位置
+type automaton struct {
+	nodes []acNode
+	lens  []int // 各模式串的 rune 长度
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	// out 以该节点结尾的模式串下标 (含沿失败链可达的模式串)
+	out []int32
+}
+
+// match 一次命中：pattern 为模式串下标，[start, end) 为归一化文本中的 rune 区间
+type match struct {
+	pattern    int
+	start, end int
+}
+
+// newAutomaton 为 patterns 构建自动机，空串被忽略
+func newAutomaton(patterns [][]rune) *automaton {
+	a := &automaton{nodes: []acNode{{}}, lens: make([]int, len(patterns))}
+	for i, p := range patterns {
+		a.lens[i] = len(p)
+		if len(p) == 0 {
+			continue
+		}
+		cur := int32(0)
+		for _, r := range p {
+			nxt, ok := a.nodes[cur].next[r]
+			if !ok {
+				nxt = int32(len(a.nodes))
+				a.nodes = append(a.nodes, acNode{})
+				if a.nodes[cur].next == nil {
+					a.nodes[cur].next = make(map[rune]int32)
+				}
+				a.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		a.nodes[cur].out = append(a.nodes[cur].out, int32(i))
+	}
+
+	// 按层序 (BFS) 计算失败指针，父节点的失败指针总是先于子节点确定
+	queue := make([]int32, 1, len(a.nodes))
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[cur].next {
+			queue = append(queue, child)
+			if cur == 0 {
+				continue // 第一层节点的失败指针为根
+			}
+			f := a.nodes[cur].fail
+			for {
+				if nxt, ok := a.nodes[f].next[r]; ok {
+					a.nodes[child].fail = nxt
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = a.nodes[f].fail
+			}
+			a.nodes[child].out = append(a.nodes[child].out, a.nodes[a.nodes[child].fail].out...)
+		}
+	}
+	return a
+}
+
+// find 返回 text 中所有模式串的命中 (可重叠)
+func (a *automaton) find(text []rune) []match {
+	var matches []match
+	cur := int32(0)
+	for i, r := range text {
+		for {
+			if nxt, ok := a.nodes[cur].next[r]; ok {
+				cur = nxt
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = a.nodes[cur].fail
+		}
+		for _, p := range a.nodes[cur].out {
+			// 同一节点的输出可能来自失败链上更短的模式串，起点按各自长度计算
+			matches = append(matches, match{pattern: int(p), start: i + 1 - a.lens[p], end: i + 1})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,259 @@
+// Package moderation 提供敏感词过滤引擎：词库按层级 (系统/机器人/群) 与作用范围组织，
+// 归一化后构建一次 Aho-Corasick 自动机，消息只需扫描一遍即可得到全部命中及其处置动作
+package moderation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// 词库层级
+const (
+	LevelSystem = "system" // 对所有消息生效
+	LevelRobot  = "robot"  // 对指定机器人 (Scope 为 SelfID，空表示全部机器人) 收到的消息生效
+	LevelGroup  = "group"  // 对指定群 (Scope 为群号，空表示全部群) 的群消息生效
+)
+
+// 匹配方式
+const (
+	ModeExact  = "exact"  // 消息中任意位置出现 (归一化后比较)
+	ModePrefix = "prefix" // 消息以该词开头 (归一化后比较)
+	ModeRegex  = "regex"  // 对原文做正则匹配
+)
+
+// 处置动作，优先级 block > escalate > mask > warn
+const (
+	ActionBlock    = "block"    // 拦截消息
+	ActionEscalate = "escalate" // 拦截消息并提交人工复核
+	ActionMask     = "mask"     // 将命中部分替换为 * 后放行
+	ActionWarn     = "warn"     // 放行，仅在消息上标注命中信息
+)
+
+var actionRank = map[string]int{ActionWarn: 1, ActionMask: 2, ActionEscalate: 3, ActionBlock: 4}
+
+// Rule 一条敏感词规则
+type Rule struct {
+	Word   string `json:"word"`
+	Level  string `json:"level"`            // system、robot、group，默认 system
+	Scope  string `json:"scope,omitempty"`  // robot 层为机器人 SelfID，group 层为群号，空表示该层全部
+	Mode   string `json:"mode,omitempty"`   // exact、prefix、regex，默认 exact
+	Action string `json:"action,omitempty"` // block、escalate、mask、warn，空时使用 Options.DefaultAction
+}
+
+// Options 引擎构建选项
+type Options struct {
+	// Modes 启用的匹配方式，为空表示全部启用；未启用方式的规则不参与匹配
+	Modes []string
+	// DefaultAction 规则未指定动作时使用的动作，默认 block
+	DefaultAction string
+	// DisablePinyin 关闭汉字词的全拼变体匹配
+	DisablePinyin bool
+}
+
+// Target 被检查消息的来源，GroupID 为空表示私聊
+type Target struct {
+	BotID   string
+	GroupID string
+}
+
+// Hit 一次命中，[Start, End) 为原文中的 rune 区间
+type Hit struct {
+	Rule  Rule
+	Start int
+	End   int
+}
+
+// Result 检查结果，Action 为全部命中中优先级最高的动作，无命中时为空
+type Result struct {
+	Action string
+	Hits   []Hit
+}
+
+// Engine 由一个版本的词库构建的只读过滤引擎，可并发使用
+type Engine struct {
+	version string
+	rules   []Rule
+	ac      *automaton
+	// patterns[i] 为自动机第 i 个模式串对应的规则下标
+	patterns []int
+	regexes  []compiledRegex
+}
+
+type compiledRegex struct {
+	re   *regexp.Regexp
+	rule int
+}
+
+// Version 计算词库与选项的版本标识，内容不变时版本不变，用于判断是否需要重建引擎
+func Version(rules []Rule, opts Options) string {
+	data, _ := json.Marshal(struct {
+		Rules []Rule
+		Opts  Options
+	}{rules, opts})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// New 构建过滤引擎。无法编译的正则规则会被跳过，并在返回的 error 中汇总，引擎本身仍然可用
+func New(rules []Rule, opts Options) (*Engine, error) {
+	e := &Engine{version: Version(rules, opts)}
+	enabled := func(mode string) bool {
+		if len(opts.Modes) == 0 {
+			return true
+		}
+		for _, m := range opts.Modes {
+			if m == mode {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		patterns [][]rune
+		errs     []error
+	)
+	for _, r := range rules {
+		if r.Word == "" {
+			continue
+		}
+		if r.Level == "" {
+			r.Level = LevelSystem
+		}
+		if r.Mode == "" {
+			r.Mode = ModeExact
+		}
+		if r.Action == "" {
+			r.Action = opts.DefaultAction
+		}
+		if _, ok := actionRank[r.Action]; !ok {
+			r.Action = ActionBlock
+		}
+		if !enabled(r.Mode) {
+			continue
+		}
+
+		idx := len(e.rules)
+		switch r.Mode {
+		case ModeRegex:
+			re, err := regexp.Compile(r.Word)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("sensitive word regex %q: %w", r.Word, err))
+				continue
+			}
+			e.regexes = append(e.regexes, compiledRegex{re: re, rule: idx})
+		case ModeExact, ModePrefix:
+			word := NormalizeString(r.Word)
+			if word == "" {
+				continue
+			}
+			variants := []string{word}
+			if !opts.DisablePinyin {
+				variants = append(variants, PinyinVariants(word)...)
+			}
+			for _, v := range variants {
+				patterns = append(patterns, []rune(v))
+				e.patterns = append(e.patterns, idx)
+			}
+		default:
+			errs = append(errs, fmt.Errorf("sensitive word %q: unknown match mode %q", r.Word, r.Mode))
+			continue
+		}
+		e.rules = append(e.rules, r)
+	}
+	e.ac = newAutomaton(patterns)
+	return e, errors.Join(errs...)
+}
+
+// Version 返回构建该引擎的词库版本
+func (e *Engine) Version() string {
+	return e.version
+}
+
+// Empty 引擎中没有任何规则
+func (e *Engine) Empty() bool {
+	return len(e.rules) == 0
+}
+
+// applies 判断规则是否对目标消息生效
+func (r Rule) applies(t Target) bool {
+	switch r.Level {
+	case LevelRobot:
+		return r.Scope == "" || r.Scope == t.BotID
+	case LevelGroup:
+		return t.GroupID != "" && (r.Scope == "" || r.Scope == t.GroupID)
+	default:
+		return true
+	}
+}
+
+// Check 检查文本并返回对目标消息生效的全部命中
+func (e *Engine) Check(text string, t Target) Result {
+	var res Result
+	add := func(rule, start, end int) {
+		r := e.rules[rule]
+		if !r.applies(t) {
+			return
+		}
+		res.Hits = append(res.Hits, Hit{Rule: r, Start: start, End: end})
+		if actionRank[r.Action] > actionRank[res.Action] {
+			res.Action = r.Action
+		}
+	}
+
+	if len(e.patterns) > 0 {
+		n := Normalize(text)
+		for _, m := range e.ac.find(n.Runes) {
+			rule := e.patterns[m.pattern]
+			if e.rules[rule].Mode == ModePrefix && m.start != 0 {
+				continue
+			}
+			add(rule, n.Offsets[m.start], n.Offsets[m.end-1]+1)
+		}
+	}
+	for _, c := range e.regexes {
+		for _, loc := range c.re.FindAllStringIndex(text, -1) {
+			start := utf8.RuneCountInString(text[:loc[0]])
+			add(c.rule, start, start+utf8.RuneCountInString(text[loc[0]:loc[1]]))
+		}
+	}
+	return res
+}
+
+// Mask 将动作为 mask 的命中区间替换为 *，text 须为产生该结果的原文
+func (r Result) Mask(text string) string {
+	var runes []rune
+	for _, h := range r.Hits {
+		if h.Rule.Action != ActionMask || h.Start >= h.End {
+			continue
+		}
+		if runes == nil {
+			runes = []rune(text)
+		}
+		for i := h.Start; i < h.End && i < len(runes); i++ {
+			runes[i] = '*'
+		}
+	}
+	if runes == nil {
+		return text
+	}
+	return string(runes)
+}
+
+// Words 返回命中的去重敏感词，用于日志与审计
+func (r Result) Words() []string {
+	seen := make(map[string]bool, len(r.Hits))
+	words := make([]string, 0, len(r.Hits))
+	for _, h := range r.Hits {
+		if !seen[h.Rule.Word] {
+			seen[h.Rule.Word] = true
+			words = append(words, h.Rule.Word)
+		}
+	}
+	return words
+}
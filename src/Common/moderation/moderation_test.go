@@ -0,0 +1,167 @@
+package moderation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"fullwidth", "ＡＢＣ１２３", "abci2e"},
+		{"zero width", "微\u200b信\u200d号\ufeff", "微信号"},
+		{"separators", "微 信.号-", "微信号"},
+		{"traditional", "賭博網站", "赌博网站"},
+		{"cyrillic", "саѕіnо", "casino"},
+		{"greek", "ΒΕΤΑ", "beta"},
+		{"accents", "cásìnò", "casino"},
+		{"leet", "c@s1n0", "casino"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeString(tt.in); got != tt.want {
+				t.Fatalf("NormalizeString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOffsets(t *testing.T) {
+	n := Normalize("a 微\u200b信")
+	if want := []int{0, 2, 4}; !reflect.DeepEqual(n.Offsets, want) {
+		t.Fatalf("offsets = %v, want %v", n.Offsets, want)
+	}
+}
+
+func TestPinyinVariants(t *testing.T) {
+	got := PinyinVariants("赌博")
+	if len(got) == 0 || got[0] != "dubo" {
+		t.Fatalf("PinyinVariants(赌博) = %v, want dubo first", got)
+	}
+	if v := PinyinVariants("赌"); v != nil {
+		t.Fatalf("single character should have no variants, got %v", v)
+	}
+	if v := PinyinVariants("赌a"); v != nil {
+		t.Fatalf("mixed word should have no variants, got %v", v)
+	}
+	if v := PinyinVariants("行行行行行"); len(v) > maxPinyinVariants {
+		t.Fatalf("variants = %d, want at most %d", len(v), maxPinyinVariants)
+	}
+}
+
+func TestAutomatonOverlapping(t *testing.T) {
+	a := newAutomaton([][]rune{[]rune("he"), []rune("she"), []rune("hers"), []rune("his")})
+	got := a.find([]rune("ushers"))
+	want := []match{{pattern: 1, start: 1, end: 4}, {pattern: 0, start: 2, end: 4}, {pattern: 2, start: 2, end: 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("find = %v, want %v", got, want)
+	}
+}
+
+func TestEngineEvasion(t *testing.T) {
+	e, err := New([]Rule{
+		{Word: "赌博网站"},
+		{Word: "casino"},
+		{Word: "代开发票", Action: ActionEscalate},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "这里有赌博网站", "赌博网站"},
+		{"spaces", "赌 博 网 站", "赌博网站"},
+		{"zero width", "赌\u200b博\u200c网\u200d站", "赌博网站"},
+		{"punctuation", "赌.博-网*站", "赌博网站"},
+		{"traditional", "賭博網站", "赌博网站"},
+		{"pinyin", "du bo wang zhan", "赌博网站"},
+		{"fullwidth", "ＣＡＳＩＮＯ", "casino"},
+		{"homoglyph", "саsіnо", "casino"},
+		{"leet", "c4s1n0", "casino"},
+		{"accents", "cásínó", "casino"},
+		{"escalate", "代 开 發 票", "代开发票"},
+		{"clean", "今天天气不错", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.Check(tt.text, Target{BotID: "1"})
+			var got string
+			if len(res.Hits) > 0 {
+				got = res.Hits[0].Rule.Word
+			}
+			if got != tt.want {
+				t.Fatalf("Check(%q) hit %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineScopesAndActions(t *testing.T) {
+	e, err := New([]Rule{
+		{Word: "spam", Level: LevelSystem, Action: ActionWarn},
+		{Word: "广告", Level: LevelRobot, Scope: "100", Action: ActionMask},
+		{Word: "加群", Level: LevelGroup, Scope: "200", Action: ActionBlock},
+		{Word: "私聊", Level: LevelGroup, Action: ActionEscalate},
+		{Word: "买", Mode: ModePrefix, Action: ActionBlock},
+		{Word: `\d{11}`, Mode: ModeRegex, Action: ActionMask},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		target Target
+		action string
+	}{
+		{"system everywhere", "spam", Target{BotID: "1"}, ActionWarn},
+		{"robot scope matches", "广告", Target{BotID: "100"}, ActionMask},
+		{"robot scope other bot", "广告", Target{BotID: "101"}, ""},
+		{"group scope matches", "加群", Target{BotID: "1", GroupID: "200"}, ActionBlock},
+		{"group scope other group", "加群", Target{BotID: "1", GroupID: "201"}, ""},
+		{"group rule in private", "私聊", Target{BotID: "1"}, ""},
+		{"unscoped group rule", "私聊", Target{BotID: "1", GroupID: "300"}, ActionEscalate},
+		{"prefix at start", "买 号", Target{BotID: "1"}, ActionBlock},
+		{"prefix not at start", "我要买", Target{BotID: "1"}, ""},
+		{"regex", "电话13800138000", Target{BotID: "1"}, ActionMask},
+		{"highest action wins", "spam 加群", Target{BotID: "1", GroupID: "200"}, ActionBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.Check(tt.text, tt.target).Action; got != tt.action {
+				t.Fatalf("Check(%q) action = %q, want %q", tt.text, got, tt.action)
+			}
+		})
+	}
+}
+
+func TestResultMask(t *testing.T) {
+	e, _ := New([]Rule{{Word: "广告", Action: ActionMask}, {Word: "注意", Action: ActionWarn}}, Options{})
+	text := "注意：广\u200b告位招租"
+	// 广、零宽字符、告三个 rune 被整体遮盖，warn 命中不改动原文
+	if got, want := e.Check(text, Target{}).Mask(text), "注意：***位招租"; got != want {
+		t.Fatalf("Mask = %q, want %q", got, want)
+	}
+}
+
+func TestModesAndInvalidRegex(t *testing.T) {
+	e, err := New([]Rule{{Word: "abc", Mode: ModeRegex}, {Word: "(", Mode: ModeRegex}, {Word: "xyz"}}, Options{Modes: []string{ModeRegex}})
+	if err == nil {
+		t.Fatal("expected an error for the invalid regex")
+	}
+	if e.Check("xyz", Target{}).Action != "" {
+		t.Fatal("exact rules must be skipped when only regex mode is enabled")
+	}
+	if e.Check("abc", Target{}).Action != ActionBlock {
+		t.Fatal("regex rule should still match")
+	}
+	if Version(nil, Options{}) == Version([]Rule{{Word: "x"}}, Options{}) {
+		t.Fatal("version must change with the word list")
+	}
+}
@@ -0,0 +1,81 @@
+package moderation
+
+import (
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalized 归一化后的文本，Offsets[i] 为 Runes[i] 在原文 rune 序列中的下标，用于把命中位置映射回原文打码
+type Normalized struct {
+	Runes   []rune
+	Offsets []int
+}
+
+// Normalize 将文本折叠为统一形式，词库与消息使用同一规则，变形写法因此落到相同的字符序列上：
+//   - 兼容分解 (NFKD)：全角/半角、圈字母、数学字母等统一为基本字符，并去掉附加符号 (é → e)
+//   - 去除零宽字符、空白、标点与符号，"微 信"、"微.信"、"微<U+200B>信" 都折叠为 "微信"
+//   - 大小写、形近字母 (西里尔/希腊字母、leet 数字) 统一为小写拉丁字母
+//   - 繁体字折叠为简体字
+func Normalize(s string) Normalized {
+	src := []rune(s)
+	out := Normalized{
+		Runes:   make([]rune, 0, len(src)),
+		Offsets: make([]int, 0, len(src)),
+	}
+	var buf [norm.MaxSegmentSize]byte
+	for i, r := range src {
+		if r < unicode.MaxASCII {
+			if r = foldRune(r); r != 0 {
+				out.Runes = append(out.Runes, r)
+				out.Offsets = append(out.Offsets, i)
+			}
+			continue
+		}
+		for _, d := range string(norm.NFKD.AppendString(buf[:0], string(r))) {
+			if d = foldRune(d); d != 0 {
+				out.Runes = append(out.Runes, d)
+				out.Offsets = append(out.Offsets, i)
+			}
+		}
+	}
+	return out
+}
+
+// NormalizeString 返回归一化后的字符串，用于构建词库
+func NormalizeString(s string) string {
+	return string(Normalize(s).Runes)
+}
+
+// foldRune 折叠单个字符，返回 0 表示该字符应被忽略
+func foldRune(r rune) rune {
+	if isNoise(r) {
+		return 0
+	}
+	if f, ok := homoglyphs[r]; ok {
+		return f
+	}
+	r = unicode.ToLower(r)
+	if f, ok := homoglyphs[r]; ok {
+		return f
+	}
+	if f, ok := traditional[r]; ok {
+		return f
+	}
+	return r
+}
+
+// isNoise 判断是否为插入在敏感词中间用于规避检测的字符
+func isNoise(r rune) bool {
+	switch {
+	case r == '@' || r == '$':
+		return false // leet 写法，由 homoglyphs 折叠
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r):
+		return true // 附加符号与零宽字符 (U+200B、U+200D、U+FEFF 等)
+	case unicode.IsSpace(r), unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsControl(r):
+		return true
+	case r == '\u115F' || r == '\u1160' || r == '\u3164' || r == '\uFFA0':
+		return true // 韩文填充符常被当作空白使用
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package moderation
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// maxPinyinVariants 单个敏感词最多生成的拼音变体数，多音字组合过多时截断
+const maxPinyinVariants = 8
+
+var (
+	pinyinOnce  sync.Once
+	pinyinTable map[rune][]string
+)
+
+// readings 返回汉字的全部拼音读音 (小写、不带声调)，未收录的字返回 nil
+func readings(r rune) []string {
+	pinyinOnce.Do(func() {
+		pinyinTable = make(map[rune][]string, 21000)
+		for _, line := range strings.Split(pinyinData, "\n") {
+			py, chars, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			for _, c := range chars {
+				pinyinTable[c] = append(pinyinTable[c], py)
+			}
+		}
+	})
+	return pinyinTable[r]
+}
+
+// PinyinVariants 为全部由汉字组成的词生成全拼写法，如 "法轮" → ["falun"]；
+// 多音字会展开为多个读音组合，最多 maxPinyinVariants 个。单字词的拼音误伤太多 (如 "cao")，
+// 与含非汉字或未收录汉字的词一样返回 nil
+func PinyinVariants(word string) []string {
+	if len([]rune(word)) < 2 {
+		return nil
+	}
+	variants := []string{""}
+	for _, r := range word {
+		if !unicode.Is(unicode.Han, r) {
+			return nil
+		}
+		rs := readings(r)
+		if len(rs) == 0 {
+			return nil
+		}
+		next := make([]string, 0, len(variants)*len(rs))
+		for _, v := range variants {
+			for _, py := range rs {
+				if len(next) == maxPinyinVariants {
+					break
+				}
+				next = append(next, v+py)
+			}
+		}
+		variants = next
+	}
+	return variants
+}
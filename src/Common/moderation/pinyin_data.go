@@ -0,0 +1,468 @@
+// Code generated from BotWorker/Infrastructure/Tools/Pinyin.cs; DO NOT EDIT.
+
+package moderation
+
+// pinyinData 每行为 "拼音 汉字列表"，多音字在各读音下分别出现
+const pinyinData = `a 吖阿啊锕錒嗄腌
+ai 暧僾嬡懓薆叆曖嗌懝碍愛賹塧馤隘硋砹嫒鱫呆璦嗳乂堨焥欬皧靉餲鑀譺礙瑷瞹爱鴱閡啀皚敳敱嘊溰嵦凒癌捱溾鎄锿伌挨壒唉哀哎皑靄埃娾艾鯦霭譪藹濭躷矮昹毐騃蔼噯剴隑
+an 埯岸犴碪銨罯晻揞铵唵按頇垵俺隌貋儑痷暗遃垾錌黯洝婩堓豻胺案荌屽誝雸鹌侒峖桉氨庵谙媕萻葊腤蓭鞌盫玵諳鴳厰啽鶕安鵪鞍鮟馣闇盦韽
+ang 醠昻枊卬岇骯肮盎昂
+ao 袄坳蝹鴁郩襖媼岙媪奡芺抝熬嚻鏕慠镺嶴驁鼇骜鏊謸擙岰澳扷墺澚奧嫯奥傲懊眑嶅嗸嗷隞厫敖廒泑垇熝爊軪柪凹拗鷔梎鏖滶鰲鳌翺謷翶螯聱獓磝蔜璈摮遨獒翱
+ba 魃墢鼥钯菝靶把颰軷胈詙釛坝灞癹跋矲鲃炦耙皅鮊鲌霸壩弝覇鮁罷鲅罢爸欛朳笆捌茇疤鈀岜粑玐哵扒叭巴仈八吧夿抜拔芭坺紦犮叐豝峇羓柭魞釟蚆丷妭
+bai 庍拝败拜敗稗粺薭韛贁襬鞁挀伯掰白百佰柏捭竡粨絔摆栢擺
+ban 钣扮伴半办瓪覂魬舨姅靽蝂怑拌绊秚湴版鉡粄辦瓣跘絆般扳攽鈑班板颁斑搬斒頒瘢螁螌坂朌褩岅昄阪搫鳻肦籓辬癍
+bang 傍蒡棓硥谤塝徬稖棒蜯镑艕嫎鎊埲蚄蛖捠蚌謗邫浜峀垹玤梆邦幚縍膀鞤幇绑綁榜牓幫帮騯
+bao 靌宀鴇勽报豹抱寶寳藵賲緥鳵菢褓爆駂曓飽報炮刨瀑蚫儤忁袌鮑虣髱暴骲靤鉋鑤蕔飹鲍枹剥齙襃勹褒苞龅煲笣剝胞孢佨闁饱寚葆媬堢堡珤裦保包怉宝薄铇雹嫑窇鸨
+be 萡葛
+bei 軰褙犕蛽蓓禙碚辈輩琲焙惫僃備珼梖愂韝偹貝唄呗臂鄁誖棑骳杮鐴鐾鞴糒憊鋇哱鹎喺杯諀偝錍备北藣椑碑揹悲桮盃卑陂鵯倍被柸悖鉳俻钡背苝昁邶狈贝垻牬狽
+ben 炃畚楍翉夲坌捹笨輽燌桳奙獖苯泍贲渀奔逩撪犇賁錛喯锛本倴
+beng 迸埄菶琣鞛琫泵逬跰塴甏镚甭錋鏰蹦崩搒伻祊奟绷絣閍痭嘣綳繃嗙嵭傰挷
+bi 綼罼獘潷幤箆蔽襅縪馝駜髲壁嬖廦楅篳幣薜觱避篦鉍咇鮅煏痹痺腷蓖蓽蜌弊跸箅閟飶辟滭熚獙碧稫滗裨鸊鷩鶝闬閈祕鴓怶旇翍畐笓鮩肸畁詖鄪襣泌秘彃铋肶鏎濞臂蹕鞞髀奰璧鄨饆踾襞斃鞸韠躃躄魓贔驆鷝鼊繴舭朼佊妣沘疕柀俾笔箃粊匕啚筆鄙聛貏箄崥魮娝粃鎞庳嗶贲皀屄偪毴逼豍夶鲾比鵖鰏悂鈚柲荸鼻嬶秕螕堛毙狴畢袐婢敝梐萆萞陛閉弼弻吡愊彼湢皕禆筚貱赑閇佖币匂愎荜必闭坒庇诐邲妼疪枈畀苾毖胇哔毕珌怭
+bian 忭抃汴覵苄汳弁鶣疺辧藊峅辨釆卞辡鴘鯿變辯辮辫緶艑便閞遍缏覍昪変变辩煸邉獱蝙編箯鍽牑笾萹编猵砭褊糄甂扁边稨碥貶鳊窆惼贬炞鯾臱揙籩鞭邊匾
+biao 驫鑣驃飊飈飇飆镳摽鏢錶穮膔篻僄徱表婊褾檦諘俵鳔鰾贆熛裱墂彪标飑骉髟淲麃脿臕幖滮蔈藨猋謤骠瀌颷儦飙镖瘭膘標飚爂
+bie 别徶襒蹩穪瘪彆莂癟蛂憋別蟞龞虌鼈鱉鳖
+bin 殡氞訜玢檳顮鑌膑繽鬓蠙髩擯鬂霦髌傧髕鬢殯屏臏滨摈瀕邠砏宾彬椕汃缤槟瑸豩豳斌璸賓虨濵濱儐镔賔濒
+bing 寎並并棅琕癝癛偋綆庰燷併稟垪倂栤病竝傡摒誁靐疒屏餅鞆拼栟陃邴丙幷鋲怲掤仌兵冰氷槟鉼餠梹眪鈵禀抦窉饼炳柄昺昞苪秉蛃
+bo 鎛葧踣鋍镈壆馞駮豰嚗懪簙僰礡駁馛艊膊愽煿馎鉑鈸猼搏餺鹁蚾箔牔饽湐蔢檘襎蘗譒糪檗擘孹簸瓝箥鵓彴穛袹茀肑鑮礴襮欂髉髆犦跛袚蹳磻餑播撥嶓僠鉢碆菠紴渤驋缽钵玻波拨癶趵卜啵薄剥般柏百袯浡博鱍淿桲铂钹瓟舶挬亳郣胉勃孛秡侼脖帗伯盋犻驳帛泊狛苩仢
+bu 瓿鈈埠部荹捗悑埗勏钚歨廍怖歩蔀踄郶篰餢簿尃箁抪柨布庯藤咘埔晡步堡卜峬逋鈽誧餔鵏秿陠鯆轐鳪醭佈擈鸔钸吥補捕哺补卟輹不獛
+ca 攃嚓采财礤擦遪礸
+cai 蔡寀彩採睬綵棌縩草跴婇菜才埰揌材财踩倸偲戝裁猜采財
+cal 乲
+can 黲爘惨慘噆憯黪蠺灿粲儏澯薒蠶璨殘謲孱燦骖参蝅飡慙喰湌傪嬠驂嵾飱残蚕惭慚餐參
+cang 濸艙螥罉藏欌伧鑶賶沧獊滄嵢凔舱苍仓倉蒼
+cao 襙艹艸草愺懆騲慅慒鄵鏪肏操曹撡糙曺嘈嶆蓸槽褿艚螬漕
+ce 憡惻厠策萴筞蓛刂箣廁墄册萗冊侧厕恻测荝敇粣側拺測
+cen 参硶涔梣膥岑
+ceng 驓蹭曾層层噌竲
+cha 察镲衩搽檫鑔碴岔槎靫猹褨喳汊侘诧剎姹紁詫刹楂茬奼訍嵖差嚓叉扠芆杈臿偛嗏垞茶肞查插鎈鍤疀艖锸銟査
+chai 豺祡囆蠆瘥袃虿喍柴侪犲釵钗拆差儕
+chan 摌剗谄產産铲阐浐剷镵幝蒇丳旵产韂澶繟饞鑱讒艬蕆刬骣单顫嵼羼躔颤懴摲硟懺鏟諂墠讇灛囅闡醦冁簅燀閳忏攙缠煘嬋馋禅湹棎孱谗婵獑嚵幨襜鋓裧覘搀掺梴觇纒滻脠劖纏摻蝉巉壥瀺蟾儳蟬瀍鄽潹誗酁毚鋋廛辿潺緾磛禪儃
+chang 敞鋿厂場僘昶场尙棖鱨鲿蟐暢嚐償鏛倡倘鲳膓韔誯畼唱鬯廠畅玚怅惝鋹氅悵菖錩锠裮琩椙閶瑺阊鼚淐娼倀昌伥晿甞猖鯧嫦腸萇瓺徜兏嘗长常肠苌尝偿
+chao 炒嘲窲罺轈晁吵潮剿煼眧巐粆仦耖趠觘欩漅麨抄怊钞焯超鈔鄛樔绰綤牊巢巣朝繛弨
+che 聅掣硩徹澈勶瞮爡尺烢頙喢車撤迠车砗唓硨蛼扯偖撦坼莗彻
+chen 曟磣夦墋碜趻諶鷐麎踸嚫霃螴薼贂衬疢龀趁榇齔谶襯讖瀋称瘎煁齓棽賝塵樄伧傖郴琛嗔綝諃尘臣忱沉谌蔯抻辰揨訦晨宸陳莐陈烥敐茞
+cheng 椉程筬洆塖裎惩挰溗絾堘铖脭窚荿珹埕乘碀逞掁瀓峸摚秤騁睈庱骋悜塍懲誠鯎檙橙澄鋮盛酲畻騬偁緽撑靗憆赪琤牚橕蛏浾棦柽泟阷稱称宬撐铛枨娍城爯瞠乗诚承呈成鏳檉郕赬丞頳蟶竀鏿饓鐺
+chi 灻饬斥叱赤抶恜炽彳耻翄翅勅侈齒褫鉹裭歯欼蚇恥胣烾餝豉慗嗤垑匙敕歗鷘饎趩懘熾鶒瘛痓遫翤憏雴鉓腟痸傺飭湁啻翨媸黐彲麶攡齝妛癡鴟螭瞝蚳吃魑摛訵笞眵瓻鸱蚩哧侙杘齿痴篪卶肔呎叺鵄謘摴遲踟墀馳遅驰尺貾彨池迟岻茌持竾淔筂弛
+chong 傭重隀浺虫崇崈蟲宠埫寵沖銃褈铳忡冲蹖充茺珫翀舂罿摏憃憧衝嘃艟
+chou 燽擣讐讎躊嬦疇雠丑臭籌丒吜杽侴瞅醜魗遚殠雔儔矁犨踌懤紬篘犫跾掫仇俦栦惆绸酬畴絒愁皗稠筹酧抽瘳綢菗
+chu 椘亍处竌怵绌璴豖竐拀処齼礎檚儲濋俶褚斶齭楚鄐畜觕矗觸黜臅歜儊憷敊閦踀触搐琡傗處絀珿蟵齣蜍雛耡锄豠蒢滁厨禇刍趎貙樗初岀出屮橻榋除櫥楮储础杵媰躕蒭蹰鉏躇櫉幮懨橱鋤篨廚犓雏鶵
+chuai 嘬踹膪搋揣膗
+chuan 钏甎舛喘堾踳汌輲玔荈釧賗巛串剶川僢穿暷瑏传舡猭遄傳椽歂船氚
+chuang 幢闖仺创怆刱剏剙愴傸漺創摐闯磢疮窓牎牕瘡窻床牀噇刅窗
+chui 棰菙椎錘顀箠槌陲桘垂圌龡炊吹锤捶
+chun 純鹑滣犉脣漘莼偆淳醇醕鯙蒓萶蠢睶惷浱鶉萅唇賰鰆旾春媋暙椿槆纯杶陙瑃鶞櫄輴橁蝽箺
+chuo 酫磭鋜婼齱鑡齪歠擉龊輟辵绰辍嚽踔啜綽娕娖惙涰逴戳
+ci 佌鷀皉此磁兹鶿辭朿鴜栨濨嬨礠次佽刺刾茦莿絘赐螆賜蛓伺餈辝庛垐飺呲玼疵趀偨縒跐髊齹枱甆珁差柌祠茨瓷詞慈辞鈶雌鹚糍辤词
+cis 嗭
+cong 誴鏦騘驄聦從従婃孮徖悰淙繱漎賨賩樷藂叢灇欉爜憁謥从琮茐蟌匆囪苁枞怱悤棇焧葱楤漗聡囱忩篵瞛聪蔥聰瑽丛樬樅暰骢璁
+cou 湊辏凑輳楱腠
+cu 蹴趗憱醋瘯簇鼀蹵顣蹙誎縬徂麁蔟麤粗殂蔖促猝媨瘄麄
+cuan 篹熶篡殩簒竄爨攒窜撺镩攢蹿汆攛躥鑹攅櫕巑
+cui 臎焠啛悴淬萃毳啐瘁粹膵脆竁繀琗粋脺翠膬榱乼崔催凗疩摧脃獕磪鏙漼慛璀皠熣忰翆墔
+cun 竴忖蹲籿寸邨刌澊皴村存
+cuo 错剉剒厝夎挫莝莡措脞锉棤銼錯逪遳蓌嵳襊瑳磋撮蹉蒫虘嵯痤矬醝鹺搓鹾
+da 鎉蟽燵鞑薘靼跶置躂亣達詚瘩鐽韃龖龘搨繨打大眔橽汏沓答荅觰嗒剳溚疸咑哒耷搭噠撘鎝笚矺褡笪畣逹墶匒荙羍炟垯怛妲迖迏达
+dai 軩艜戴鴏鮘緿曃叇軚瑇黛貸垈廗簤黱蹛袋瀻霴襶靆螮蝳跢箉怠骀代帒蚮呆獃懛歹逮大轪侢岱甙埭紿傣帶绐軑帯贷玳殆柋待带迨
+dan 蜑啗弹惮淡蛋啿窞腅啖氮疍柦诞泹沊帎但旦譂繵黮賧癚黵甔石萏撢醈弾惔倓蟺饏霮贉鴠觛僤赕駳餤禫澹憺憚彈嘾髧噉嚪耽儋頕鄲褝箪匰瘅殚媅單勯郸酖耼砃眈単担单妉丹愖抌誕躭衴丼聃擔澸撣馾亶膽紞黕胆玬狚襌殫掸癉簞瓭卩娊噡聸伔刐亻
+dang 菪趤蕩瞊碭潒档瓽垱荡逿壋檔璗盪礑簜蘯闣愓嵣雼砀蟷偒噹挡宕当珰裆儅澢璫襠簹艡欓圵筜當氹讜凼灙黨譡擋谠党
+dao 盜幬盗悼到椡帱島菿蹈道箌禱叼稲捯翢噵稻衜檤衟翿軇纛嶹瓙虭壔岛裯刀叨屶忉氘舠釖魛导隝隯導槝鱽嶋陦搗禂祷捣宲倒嶌
+de 锝的棏鍀德徳悳淂得恴嘚惪
+dei 得
+dem 揼
+den 扥扽
+deng 凳櫈澂等邓僜鄧隥嶝瞪镫戥磴豋墱鐙登澄噔嬁燈璒竳簦艠覴蹬灯
+di 梊埊娣递逓偙啇焍眱祶第帝谛坔釱菂弚軧聜骶鯳坘厎旳地苐鉪弟媂杕玓怟枤赿墆踶弔嵽諟珶渧腣揥締疐俤蔕提詆覿蹏墬睇缔蒂僀禘遞諦墑嶳摕碲蝃遰慸甋棣馰迪牴菧踧镝廸狄觌苖鍉唙敌涤荻梑笛籴羝的仾低奃彽袛藋埞鞮隄堤趆嘀滴磾碮啲呧樀蹢鏑泜诋靮阺鸐坻底弤抵拞柢邸篴滌髢嫡蔋蔐頔鬄敵氐嚁藡豴糴掋砥魡
+dia 嗲
+dian 扂奠淀惦婝唸壂玷蜔垫店坫甸佃电钿磹奌橝腍驔簟琔嵮殿靛澱橂墊電鈿癜厧丶敁傎槙滇瘨颠蹎巅顚顛癫巓巔踮掂攧痶點蕇蒧碘齻敟婰点典槇椣癲
+diao 釣掉调訋窎钓扚伄铞誂鵃吊鈟竨銱雿調瘹窵鑃嬥絩鸼錭鋽彫刁屌叼汈刟凋弴蛁琱貂碉鳭鯛奝殦矵鵰鼦淍簓鲷鮉雕颩
+die 鰈镻諜曡鲽蹀蝶艓褋牒碟疉螲蜨眰疊氎渉崼鮙跕鐡怢槢柣牃疂眣挃褺啑殜跌跮苵迭垤峌恎绖胅瓞耊耋戜叠趃爹臷詄絰畳揲惵幉堞喋谍
+dim 嚸
+ding 啶飣訂萣定矴饤订磸釘忊椗腚碇锭碠錠铤鋌掟钉耵聢虰丁仃叮帄玎甼疔盯奵町嵿酊顶頂鼎鼑薡鐤顁艼濎靪
+diu 铥銩丟丢
+dong 峒胴戙凍迵胨栋諌峝硐垌侗冻动湩恫霘姛騆勭衕絧動狫崠駧詷働腖棟挏烔氡菄笗涷崬娻埬氭蕫倲洞苳東咚冬东鸫董昸蝀箽懂墥揰岽夂鼕徚鶫鶇鯟嬞鮗
+dou 閗痘酘脰毭鬥饾窦凟逗梪鬦鋀餖斣闘竇鬭鬬兠荳鬪唗橷浢瞗剅都兜蔸篼侸艔鈄豆吺乧郖斗蚪陡枓抖阧
+du 暏黩笁賭覩陼睹赌篤琽帾笃襩襡芏鍍樚独堵镀讟督顿靯晵剫蠹螙殬妒渡秺荰度妬肚杜蠧厾獨読裻犊椟读錖毒牍都醏嘟阇剢黷闍涜韣韥韇鑟渎髑匵贕豄讀騳瀆瓄犢牘殰櫝皾嬻
+duan 瑖腶碫锻緞毈簖籪斷躖煆褍煅鍛偳剬椴篅媏端鍴缎段断塅短葮
+dug 叾
+dui 憝對綐碓怼陮濧祋隊薱镦懟瀩譈譵憞鋭鎚杸痽対搥垖嵟堆磓頧鴭兌埻謉錞队对兑兊鐜塠
+dun 遁逇砘盾炖沌囤腞庉頓碷遯潡燉踲楯伅顿钝腯敦鈍躉惇蜳墩墪壿撴獤噸鐓橔盹犜礅蹲蹾驐吨趸撉
+duo 硾吋刴剁陊亸陏饳沲橢垜鬌綞躲躱柮鵽驮嚲嶞缍尮嫷媠駄詑貀憜墯桗墮飿跺跥惰舵堕隓崜敓铎夺仛掇嚉裰敚敠哆剟茤咄多度椯趓毲垛埵挆夛喥挅哚朶朵奲踱凙痥奪鈬敪鮵鐸跿沰
+e 搹愕萼豟軶湂遏詻搤琧腭崿卾僫廅堮阏鄂軛谔硆堊偔饿蚅砨恶蝁鱷悪匎鳄鑩遌苊齾蘁魥礘搕啈齃齶鶚讍擜鹗蕚遻頞颚鰐噩櫮覨諤餩鍔歞锷餓峩额頟鋨誐磀蛾鹅锇鈋睋訛峉珴魤匼顎阿呃妸妿莪婀涐讹吪囮俄峨娥娿呝歺姶迗岋阨厄阸屵砐轭咢咹垩額扼皒鵝鵞譌騀戹鰪惡欸枙砈鵈玀閜砵佮
+ei 诶誒
+en 嗯恩摁峎蒽奀唔煾
+eng 鞥
+er 毦邇薾駬餌鉺爾珥弍栮饵洱迩铒趰二弐佴刵贰衈貳誀樲髶貮尒耳嬭耏尓尔而児侕兒陑洏荋栭胹唲袻鸸咡儿峏粫輭杒鲕轜鴯鮞髵隭輀聏陾
+fa 瞂罰法閥罸汎反鍅灋砝珐琺蕟筏藅髮發傠佱发発彂髪橃乏伐姂垡栰罚醗沷阀
+fan 攵舩矾反仮返伋瀿辺渢瀪氾鷭蠜鐇犭礬盕蹯蘩販饭羳婏嬔範嬎滼飰軓軬払笵梵訉畈范泛奿犯飯憣凡蕃帆鱕繙飜颿藩凢旙轓幡嬏墦勫番犿忛贩旛燔繁翻璠凣橎樊緐煩棥釩柉薠笲杋籵钒舤烦舧匥
+fang 鶭访倣纺昉昘仿瓬眆旊紡舫髣放彷妨魴訪淓鲂肪方坊芳钫邡堏鈁錺鴋埅枋防房牥
+fei 狒剕厞费镄疿肺沸废吠襏篚屝蕜櫠翡榧誹靅斐昲胏柹髴紼笰鼣蕡廃鐨濷癈曊廢痱費芾渄霏蜚緋裶靟猆扉鲱绯非婔婓啡飛妃俷悱菲胐飞餥匪朏蟦萉棐蜰腓騛淝肥奜飝鯡馡诽騑暃
+fen 轒馩黂粉豮瞓鐼豶鼖鼢燓橨黺僨魵羵粪隫鱝瀵鲼糞膹愤憤分偾秎忿奋弅坋份奮昐雰鈖酚躮棻朆竕紛芬纷帉吩墳幩翂羒馚氛餴焚棼蚠蒶梤坟饙蚡錀妢岎汾枌
+feng 鏠綘逢馮浲捀冯堸凮豊麷飌靊蘴寷缝赗灃俸酆碸蘕鴌鳳鳯煈賵湗艂甮奉凤諷唪讽縫焨沨峰峯風砜盽疯桻枫烽沣妦凬凨仹风鎽封犎豐檒鋒鄷丰僼篈偑蜂楓锋葑猦溄崶琒瘋
+fiao 覅
+fo 坲梻仏佛
+fou 垺紑缶否缹缻雬鴀芣
+fu 斧辅捬釡釜妇俯椨俌撫郙焤盙腑滏輔拊鬴簠黼蚥讣付腐鮲稪缚箙负韍幞澓蝠鴔諨輻複癁弣黻鵩坿汱酻弗畉絥抚甫府鮄鍑赋褔赙緮蕧蝜蝮賦駙縛鲋賻腹鍢鳆覆馥鰒軵邚柎父萯脯鮒冨咐竎阜驸复峊祔訃負赴禣偩附副婦蚹傅媍富蛗詂榑椱復袝琈芙綒鄜孵豧敷膚麩麬麱鈇烰跗粰璷伕乀伏凫甶冹刜孚扶懯砆鳧覄芾佛夫邞呋姇枎玞筟怤糐胕荂衭娐荴旉紨趺酜麸稃肤幅畗砩蚨匐桴涪符紱翇艴菔浮袱罦棴咈葍鳺綍艀蜉辐鉘鉜颫虙俘福栿怫拂服泭绂苻垘柫氟郛哹鳬绋韨洑茯罘岪祓彿玸炥
+ga 錷呷夹魀尬玍尕嘠轧釓钆夾嘎伽旮尜噶
+gai 絠鎅钙匄匃摡丐蓋乢盖葢鈣概槩槪漑瓂芥戤峐溉改该郂陔姟侅荄晐賅畡祴絯該豥赅垓賌忋
+gan 鱤篢豃扞扜簳鰔擀橄趕澉感稈笴鳡敢骭桿盰錎佄贑灨贛赣紺幹干詌赶淦凎绀汵旰檊玕凲亁粓酐疳竿尲坩泔攼迀芉忓甘衦倝柑乹皯秆肝尴仠杆苷諴釬尷筸漧鳱飦尶魐矸虷
+gang 鋼港扛缸鎠岗崗頏犺掆杠焵筻槓戆戅罁钢戇罓罡綱冈冮刚阬纲肛釭牨疘矼剛堈堽犅棡岡
+gao 檺藳藁筶澔鎬縞镐稾獔吿鋯稿勂诰郜峼祮祰锆暠誥告槀缟禞髙槁羔皐臯滜睾膏槹橰篙糕餻稁高櫜搞皋菒杲鼛韟鷎夰鷱獋槔
+ge 觡鮯詥秴鞷轕韚鎘櫊騔諽骼韐鞈镉閣搿滆佫箇膈虼合蛤哈吤獦鉻匌铬硌個嘅各个鲄舸哿葛閤槅猲塥疙嗝彁阁割鸽袼胳哥戨牱歌牫肐戓纥犵圪戈盖额紇革隔裓臵愅鬲格搁敋鴐挌呄杚滒咯鎶鴿謌擱鴚
+gei 给給
+gen 亘亙搄揯根茛哏跟艮
+geng 郉郠哽埂峺挭耿莄梗絙骾鯁更堩暅颈鲠浭刯绠鶊畊耕掶菮椩縆絚羹赓賡鹒庚緪焿
+geu 啹
+gib 喼
+go 嗰
+gong 珙渱巩幊汞拱唝红拲魟栱輁鞏嗊銾供共贡羾貢熕龔龚慐功塨觵工弓厷攻杛糼肱宫躳髸公宮匔碽觥愩匑躬蚣恭侊
+gou 訽购够姤垢夠诟构坸蚼耈媾句豿彀詬遘雊構煹觏撀覯購茩笱緱傋袧篝耉钩缑鈎溝鉤褠勾簼鞲冓枸沟搆玽苟狗岣耇鸜鴝軥佝抅泃
+gu 餶皷縎糓薣瞽臌榖硲濲嘏鼔鼓鈷榾詁焸愲尳嗀雇蛌馉祻贾盬顧鯝鲴錮僱頋锢稒棝瀔羖蛊牿梏崮崓堌顾凅故怘固痼唂箍毂酤辜軲軱蓇菇笟鸪箛唃觚轱柧泒沽孤姑咕估脵抇罛骨淈啒傦钴蛄罟嫴牯峠股谷诂汩轂橭逧篐扢鴣苽菰鶻鹘古鮕
+gua 褂剮坬啩寡剐卦诖挂掛罣罫詿括緺叧絓颪冎鴰瓜刮胍鸹煱趏劀銽騧呱諣栝歄焻
+guai 枴叏恠怪柺拐乖夬
+guan 掼樌遦潅摜慣祼悹涫盥泴惯悺纶貫罆雚鏆灌爟矔贯罐鑵鸛鱹礶莞瓘懽萖關鳏観癏丱鰥蒄窤倌覌官观关鹳瘝卝棺毌觀冠輨鳤躀館錧舘管筦痯馆鱞
+guang 臦銧黆欟趪挄广広犷臩櫎逛輄撗廣俇炚僙獷光灮炗炛咣垙珖洸茪桄硄烡姯胱
+gui 攱朹祪猤媯刽攰蟡庪昋柜刿簋厬詭觤蛫湀炅匭撌鬼軌癸垝晷襘傀陒禬嶡趹赽檜絵桧鱥鱖劊鳜贵櫃瞶槶劌瞆跪蓕貴筀椢桂鞼胿槻嶲鲑閨摫嫢郌瑰椝規袿槼亀龟珪帰闺茥皈邽规诡妫雟归硅鴃圭庋轨氿宄嬀璝匦鴂槣螝龜瞡潙鮭櫰巂歸鬶瓌鬹櫷佹膭
+gun 睴鲧鯀琯棍睔謴璭輥棞袞鮌衮磙绲辊滚蓘滾緄蔉惃
+guo 幗惈果慖馘虢膕蔮聝淉摑綶漍猓菓馃椁褁過粿裹輠餜錁过腘槨聒蜾掴呙埚郭啯楇鈛锅墎瘑嘓彉囯蝈圀国囻崞囶國帼簂矌鐹彍鍋
+ha 哈蛤鉿妎铪
+hai 骇嗐咳嗨饚嚡駴餀氦害還孩亥駭骸还海胲烸塰酼醢
+hal 乤
+han 菡汉皔涆琀焊晥莟猂晘捍悍睅汗銲厈糮鬫蔊旱颔翰喊澣鶾瀚雗譀駻暵螒傼撼憾鋎熯蜭漢撖蛿顄谽肣咁邯邗榦憨欦鼾凾馠函酣蚶哻顸爳兯頷浫魽筨罕椷馯韓鋡含蜬唅甝韩嵅娢澏圅寒浛崡晗梒涵焓
+hang 颃魧吭沆航行絎筕貥巷笐绗垳杭斻苀迒蚢夯
+hao 浩聕滈皓傐貉淏號耗皡悎恏哠晧暤暭皞薃皥颢灏顥鰝灝鄗藃濠昦皜蒿蠔昊茠嚆薅竓蚝毫椃嗥獆噑豪虠镐好号嘷諕郝譹籇壕嚎曍儫呺
+he 皬鑉龢餄荷魺鶡贺螛隺垎覈齕鞨麧貉篕頜澕寉爀闔翯翮鶴謞鶮靏鸖靎靍癋壑焃鹤赫褐熇碋煂嗃賀湼燺蠚盇柇曷峆河姀和咊劾籺合禾訶喝欱抲呵诃纥礉鹖何熆阂阖鉌楁颌訸粭惒龁嗬萂菏盒渮涸啝盍盉核敆哬饸
+hei 嗨潶嬒黒黑嘿
+hen 詪恨狠很鞎痕拫噷佷
+heng 鑅胻横橫衡鴴堼蘅鸻珩鵆亨烆恨行哼悙涥脝姮恆恒桁
+hong 鸿潂彋鋐篊鞃鉷蕻谼綋鈜葓霐竤弘谹紭硔粠讧耾閎闂闀澒澋撔舼訌黉晎哄葒瓨黌鴻霟閧渹吰红妅仜轟鍧輷宏焢嚝揈軣烘訇轰灴翝翃硡荭紘浤薨虹汯紅竑洪娂叿宖垬闳玒泓玜苰纮
+hou 后呴洉郈犼厚垕後逅候鱟堠鲘鲎吼豞鄇侯翵鮜腄矦喉帿猴葔瘊鍭銗篌糇翭骺餱鯸睺
+hu 户戶弖鯱帍錿互戸岵芐护沪箎虍冴乕鰗鶘鶦沍礐瓡汻鄠滸怙虎浒唬萀琥虝俿穫瓠護鳠韄頀鱯簄濩鳸觷魱冱鹱滹鬍鸌嫭昈枑祜笏粐婟鍙綔戽嫮摢滬蔰槴熩扈謼惚淴虖雽寣弧和轷芔戯戱鹄鵠囫雐忽歑瀫核鋘乎匢唿垀匫昒曶泘苸烀嘑呼衚瑚嘝蔛鹕槲箶煳蝴螜縠狐醐軤觳鍸餬糊斛頶楜胡壷焀喖壺湖瓳猢葫媩壶絗
+hua 话觟畵嬅畫婳崋杹話黊画桦劃摦槬樺嫿諙繣舙蘳譮檴豁璍化澅蘤划鏵花芲埖婲椛硴糀錵蕐砉嘩鷨驊誮撶华猾滑铧華骅姡哗螖
+huai 蘹壞蘾壊咶坏徊佪怀懷褱懐踝褢槐淮耲
+huan 宦唤换浣涣烉奐梙緩患肒奂焕攌渙缓睆闤圜鉮幻鲩煥瑍蠸圂鰀鯶鯇藧愌擐逭槵瘓豢痪換瞏嵈喚瞣貛荁狟洹峘环还貆漶桓讙酄歡獾嚾鵍鴅歓欢鬟驩糫懁萈鐶轘繯鹮镮鍰豲環綄萑堚缳寏雈羦锾阛寰澴
+huang 恍簧炾鐄鷬鰉騜鱑鳇餭諻蟥宺榥穔鍠縨惶磺鎤皝曂滉兤謊晃詤愰幌谎奛晄皩皇媓堭喤黄黃隍崲偟肓慌塃衁荒巟癀怳凰潢徨艎璜熿獚篁墴瑝煌蝗楻遑葟湟锽
+hui 蔧毀滙詯賄嘒誨圚寭慧憓槥惠潓蕙暳秽恚诲繪徻烩贿翙晦會喙缋恵阓匯彙彚彗顪蟪鏸闠孈鐬靧譓譿翽銊叀僡懳荟楎韢篲澮獩璤薈薉諱儶燴橞餯嚖瞺穢繢櫘檅噅瀈翚辉暉琿禈媈绘婎噕翬輝麾徽隳幑拻桧徊鰴灰灳诙揮恢睳挥虺晖烣珲豗咴詼鮰藱悔毇檓燬蜖泋譭烜卉屷汇会讳浍洃廽煇毁蛕囘回廻恛洄逥蛔囬痐茴烠迴
+hun 餛诨繉觨鼲混梱湷轋俒倱掍諢焝慁魂婚溷荤涽渾昬惛阍惽棔葷閽焄蔒睯忶浑馄睧昏
+huo 掝嚯镬霍奯禍湱旤惑祸瀖謔貨耯藿蠖嚿曤臛癨矐剨靃獲篧擭鑊豁謋閄和吙耠锪鍃攉騞搉佸秮活火货获眓劐伙俰咟捇或沎夥鈥钬邩
+ji 芰伎芶忌妓际坖纪技记计旡勣梞剂偈迹绩彑剤鏶畟旣悸徛寄記觊紒季計茍紀济洎既峜哜继雦鶺鍓殛岌莋吇淁尐几雧系躤鷑祭霵覬轚籍艥嵴脊蟣穖擠撠魢麂戟幾颳掎済挤虮泲犱妀丮愱己鱾骥鰿鰶霽蘻鱀蘮繼鯽鯚訐繫糭穧癠懻廭齌繋瀱汥蹐荠奇诘蘎暩寂績鱭鯯驥蟿懠鬾褀帺櫭魝蹟齍裚穊稩禝漈漃暨墍鵋跡霁蓟継痵兾蔇葪臮惎際縘璾檵鮆罽濟檕嚌髻誋薊跽穄曁劑冀鲫稷鲚萕襀稽鷄機憿墼齑躸賫積緝錤畿樭撃嘰銈箕毄僟觭耭鞿躋癪鐖譤鶏韲璣雞稘櫅鄿賷羁簊磯擊隮譏叽鸡矶芨肌玑机圾鳮饥剞刉击讥丌枸给襋蒺乩喞禨嗘赍缉筓犄敧攲枅嵆咭基飢笄积屐姬唧跻嵇焏皍嫉塉集湒極棘戢齎脨趌楖卙偮觙疾揤級笈谻箿輯螏濈檝橶鹡鞊踖楫蕀蝍瘠潗嶯銡膌耤槉激蕺羈簎畸刏谿踑璂踦諅諔鸄垍齏鑙覉鑇虀羇狤辑庴叝急覊姞堲卽郆佶亟极即级蠀汲忣彶吉及亼
+jia 鵊忦戞岬甲叚鴶胛蛺斚玾頰頬铗跲贾颊蛱脥戛茄鋏夓莢跏駕價榢幏嫁架驾稼假钾檟瘕槚榎鉀賈斝徦婽价枷葭猳袈耞梜痂浹家犌浃珈迦泇佳抸加筴腵恝伽埉夹荚郟毠唊郏扴圿袷笳挾貑嘉镓裌鉫挟豭糘鎵麚椵
+jian 見饯剑洊侟橏见牮徤戬柬件荐贱俴健剣栫涧珔剱谏渐涀襉袸舰謭戩碱儉翦撿檢藆襇趝謇釼瞼礆蹇鹻醎銭偂堿鹼簡譾繭蠒瀽鹸鰎鬋鹹襺鑳諓磵礀螹鍳濺繝瀳鏩艦轞鑑瞯鑬餞鐱揵蔪橌廴譖鋻建賎擶賤彅鑒劎旔楗毽溅腱臶葥践鉴键僭榗瞷劍寋墹澗箭糋槛踺劒劔橺薦諫鍵漸鳽搛椾煎瑊睷碊缣蒹監箋樫緘殱鲣葏鹣熸篯縑艱鞬餰馢麉瀐鞯鳒蕳姧踐谫戋奸尖幵坚歼间冿戔肩靬姦間兼监堅惤猏笺菅菺牋犍缄葌蕑艰趼拣枧俭茧倹挸捡笕减剪帴梘囝礛湕熞検減睑硷裥詃锏弿瑐筧简絸检鶼揀湔覸鵳瀸櫼殲鰜籛韀鰹囏虃鑯鈃籈譼銒韉钘纎攕傔黚閒鐧鐗揃
+jiang 耩降杢弜夅匠匞塂顜槳膙绛獎蔣奬强講犟奨葁洚謽櫤醬蔃醤弶嵹滰摾酱絳袶糨畕缰彊壃螀漿僵薑豇将浆茳姜江勥翞摪蒋殭奖桨橿糡將讲鱂韁繮疆礓疅鳉螿畺
+jiao 皦孂蟜鵤繳譑纐攪灚鱎潐蹻撹釥纟糸鉸湬煍噭恔賋摷敽踋矯劋侥腳缴曒璬暞覐酵趭轎醮譥皭獥觉嬓覺趫敎校徼敫釂滘餃叫呌訆珓轿藠窖角較嘂嘦斠漖噍较燋蟭嬌嶕嶣憍澆嘄蕉跤膲礁穚鮫鹪簥膠浇敿勦芁交郊姣艽峧鲛茭骄胶椒焳蛟娇矫佼挢狡绞饺晈臫皎絞脚铰搅筊轇剿僬笅驕鐎鷦鷮儌撟挍教嚼嫶萩嘐憢焦櫵骹
+jie 搩絜洯蠽杰蠞解蠘鉣姐檞媎觧巀踕毑頡竭蓵鲒潔羯幯飷鮚榤擳嶻擮礍鍻誱藉堺楐琾蛶骱犗唶魪借繲雃嶰詰褯截誡玠介岕庎戒芥屆徣斺蚧界畍疥砎衸诫丯届鶛孑街煯稭鞂蝔椄癤嫅节節袓謯階卪擑痎碣蜐价阶疖皆脻掲湝秸菨喈嗟堦媘接蛣莭偼婕崨捷滐桝結袺颉嵥楬楶讦揭睫傑劫刦媫刧岊昅刼劼疌衱诘拮洁结迼倢桀
+jin 槿劤盡笒卺斳婜儘饉謹蓳瑾尽緊馑漌錦晉今廑缙進祲赆烬煡晋劲荩勁枃侭进近妗浸矜惍紟珒埐觔衿钅砛琻津釒金兓斤巾溍荕濜锦谨厪僅菫堇紧琎仅堻襟黅鹶璡嶜筋嫤巹浕嬧臸馸齽贐覲璶燼藎壗嚍賮縉慬搢禁濅墐寖瑨僸凚歏殣觐噤靳
+jing 弪径迳浄胫凈弳痉竞净婙燝逕妌憬擏警蟼頸璟暻憼璄幜婧頚璥儆竟陘儬葝竸競鏡瀞靜镜曔誩静桱獍境靖傹竧痙敬脛竫淨梷坓荊粳腈稉景菁汫猄旍旌惊婛經涇晶荆秔亰茎经泾京巠坙坕劲莖睛颈穽剄肼汬経兢刭井宑靑仱鯨精聙橸阱鵛徑鶁麖鼱驚麠鲸
+jiong 煚澃窘颎綗煛燛褧顈蘔宭蘏僒烱埛扃絅駉駫冏浻逈熲銄囧迥侰炯扄冋
+jiu 镹厩桕倃柾柩疚臼旧韮酒就麔匛匓舅僦廏廐慦殧紤鹫救匶齨鷲咎舊糺鳩揫揪啾萛摎赳鸠朻纠勼丩韭廄阄奺舏玖究灸汣乣乆稵九鬏糾久杦鬮
+jou 欍
+ju 昛拠怚拒邭歫粔苣洰具炬秬钜俱倨剧耟怇蒟蚷冣櫸蒩埧榉榘筥椇聥舉龃擧岠齟襷籧郰欅句巨讵姖踽鐻據澽遽鋸屨颶簴躆忂懼鮔爠坥螶葅珇据挙椐醵锯惧詎距焣犋跙鉅飓懅豦壉愳窭聚駏劇勮屦踞埾虡艍婮崌掬梮涺柜跔锔鶋雎陱蜛踘鋦駒鮈鴡鞠鞫裾驹腒莒鶪伡俥凥匊居婅苴娵倶挶捄疽痀眗砠罝趄狙鼳趜躹閰檋駶鵙蹫鵴巈蓻鼰驧臄趉琚橘咀弆沮举矩蘜侷揟拘郥粷諊泦狊桔毩淗毱菊郹椈輂犑焗湨局
+juan 睠罥絭睊鄄眷绢狷桊劵絹弮讂倦淃韏圈卷镌隽奆惓縳慻棬圏羂獧餋蔨襈捐鵑蕋娟涓脧裐鹃勬鋑鋗鎸鐫蠲勌菤姢埢踡瓹锩錈捲埍帣呟朘鞙梋臇
+jue 蟩爑譎蹷鶌矍鐝蟨爝蕝觼灍镢爵镼橛蕨瘚獗爴熦彏弡憠匷嚼潏誳倔蹶橜訣孒穱吷啳戄璚钁躩貜龣矡欔鷢玃攫疦决珏挗玨玦泬芵決抉诀砄刔孓亅屫屩撧撅噘覚憰角觉氒絶繑绝嶥谲瑴勪鈌絕厥傕逫欮虳劂捔觖蚗崛掘斍桷殌焆
+jun 郡珺焌骏馂晙捃俊陖畯雋呁埈峻竣箘箟蜠儁寯懏餕燇鵔葰隽駿鵘皲鵕均汮军姰龟袀軍钧莙桾君菌鲪麕麏鮶蚐麇鈞銞銁覠皹皸筠碅鍕
+ka 卡胩鉲裃垰佧哢衉喀咖咯呿咔
+kai 勓輆炌锴暟鎧闓颽喫噄烗愾鎎愒欯楷忾開愷鍇开奒锎鐦凯剀慨塏揩蒈垲凱铠闿恺
+kal 乫
+kan 看塪輡竷轗衎欿崁墈阚瞰磡闞惂輱侃矙栞莰刊埳勘龛堪嵁龕槛檻冚坎砍偘戡
+kang 钪闶閌扛摃邟伉亢囥鱇炕鈧抗粇匟鏮砊康嫝嵻漮槺穅躿糠慷忼
+kao 焅烤犒鲓靠鯌栲铐鮳髛銬洘尻嵪訄薧攷考拷
+ke 剋坷敤克歁悈袔渴刻嵑渇炣嶱可磆毼殼勀岢缂緙艐硞锞騍翗碦礊愙勊骒氪堁课尅娔恪客溘疴犐颏軻萪痾棵嵙稞趷轲胢牁柯苛蚵屙課揢钶髁珂窠科簻窼嗑壳咳顆颗鈳榼礚薖醘樖瞌磕蝌頦
+ken 錹肯褃裉掯頎懇硍貇豤啃恳肻肎墾垦
+keng 鍞铿硻誙銵硜摼揁殸硁挳坑坈吭劥踁妔鏗牼
+keo 巪
+keol 乬
+keos 唟
+keum 厼
+ki 怾
+kong 椌躻羫鞚控恐孔宆埪空鵼錓倥崆悾硿箜
+kos 廤
+kou 簆筘釦寇宼窛滱鷇蔲瞉剾冦蔻眍摳敂芤彄瞘劶竘口叩扣怐抠
+ku 裤秙齁捁库俈绔庫袴喾絝瘔褲鮬楛嚳搰酷刳矻郀枯哭桍堀泏苦窋崫骷窟跍圐狜
+kua 垮舿骻跨胯挎顝銙誇晇姱夸恗咵侉
+kuai 鱠筷儈鄶噲廥獪膾糩蕢塊脍旝凷狯擓蒯璯巜会圦块快侩郐鲙哙駃
+kuan 欵窾窽歀款鑧髋寬寛宽梡髖
+kuang 矿絖眶砿眖框軦昿黋況岲贶鉱鋛壙懬曠礦穬纊鑛纩絋旷鄺恇貺况劻诓邼哐洭筐筺誆軭狂狅儣圹匩邝爌懭夼鵟誑軠軖匡诳
+kui 愧躨尯媿喟欳匮愦蹞頍跬煃鍨虁溃籄卼聭篑鑎饋鐀鍷聵餽簣樻蒉聩潰憒嬇嘳匱馈殨窺逵晆奎岿蘬巋鄈虧窥聧蘷盔夔刲亏傀闚櫆悝頄藈頯蝰睽魁楑喹暌戣骙葵揆楏騤馗
+kun 阃悃鹍鶤鵾鯤騉鲲錕祵睏臗硱稇裍壼稛綑閫困涃捆醌閸猑壸昆晜堃堒婫崑菎裈焜琨褌崐髨瑻蜫潉尡髡锟裩坤髠熴
+kuo 鬠擴濶闊鞟韕懖鞹頢葀霩扩阔廓漷拡括桰筈萿蛞秳
+la 擸辢鬎辣蝲臈攋蝋爉臘櫴瓎镴鯻儠鱲蠟落磖蜡鑞搚鞡旯瘌垃拉柆菈邋翋溂楋揧啦腊砬剌嚹藞喇揦
+lai 賚籟鶆顂勑誺赉唻睞鯠麳濑賴頼癞鵣瀨瀬籁藾襰睐騋癩俫錸赖來倈崃徕涞莱郲婡崍筙来庲箂铼琜棶淶徠梾逨猍萊
+lan 漤擥惏孄懶懒壈醂罱覧榄缆揽浨览澜韊覽嚂襽钄爁煉湅糷瓓爤爛濫壏滥燗孏烂嬾蘫顲灠纜爦欖攬欗躝藍燣懢篮斓儖褴厱襕蓝谰葻嵐婪栏拦岚兰暕鑭礷阑镧讕籣灡襴欄斕蘭襤闌囒璼繿譋幱攔瀾灆籃
+lang 朖螂鋃鎯阆閬蒗悢哴躴烺塱蓢樃誏朤俍脼莨浪埌郎郒朗唥啷勆郞欴狼嫏廊艆羮蜋桹筤锒稂硠瑯榔蓈琅
+lao 咾蛯铑硓珯栳荖老姥落佬銠恅橑鮱唠嘮烙耢澇橯耮軂耂络嗠涝捞铹酪粩撈劳労牢窂哰崂浶勞痨僗顟憦嫪髝嶗鐒醪蟧磱癆憥簩轑朥
+le 簕玏泐竻勒樂鳓鰳砳艻韷了氻楽饹餎牞仂阞乐叻忇扐
+lei 壘蠝礌鸓儽讄蘽塁灅礨矋櫐纍癗酹儡蕾蕌藟錑磊鑸黍祱禷蘱纇淚颣肋擂頪銇累涙类泪類畾轠蘲礧羸瓃櫑镭壨檑鐳樏蔂缧嫘雷頛勒縲攂傫絫垒诔耒厽罍腂鑘磥洡瘣靁鼺虆誄鱩欙纝
+leng 輘睖愣堎倰冷踜稜碐楞塄棱薐
+li 粝痢棙厤凓傈蛎蛠歴疠瑮粒笠蚸詈雳慄搮溧蒚蒞鉝鳨厯厲暦琍塛綟隶砅疬栎栃荔俐轹苙瓅沴枥戾岦俪涖唳莅秝砾砺茘猁悷栵栛栗悧娳郦珕轣櫟盭礫糲蠣癧礰酈鷅麜囇攦瓑讈爏攭瓥靂鱱靋觻鱳叓蝷赲曞例藜轢儮勵曆歷篥隷鴗巁檪濿粟癘磿皪鬁蜧櫔爄犡禲蠇嚦壢攊瀝礪藶麗櫪隸謧盠竰釐犛苈蔾鋫鲡黎篱縭罹蠡蟍孷醨嚟邌離鯏鏫鯬鵹黧囄灕蘺錅喱瓈唎粚刕厘剓梨狸荲骊悡梸璃菞樆棃犂鹂剺漓睝筣缡艃蓠蜊嫠糎犁莉鋰鲤澧禮鯉蟸醴鳢邐鱧欐欚裏脷利力历厉屴立吏朸丽蠫励氂坜沥銐劙呖粴廲鑗籬驪鱺鸝婯儷矖纚离褵浬孋裡理穲逦峲娌哩峛俚里李礼锂
+lia 俩
+lian 蔹嬚斂歛臉鄻襝璉蘝匲蘞羷摙裣脸琏鬑僆槤籨籢薟鍊敛稴鰱萰攣孌纞戀鰊瀲鏈殮錬練练潋瑓楝链媡堜殓浰恋炼澰联匳劆覝熑奩蓮漣溓慩噒裢廉梿連莲涟怜帘连鐮嫾奁嗹簾亷蠊憐镰鎌謰蹥臁聯燫櫣螊聫譧聨褳鲢濂縺翴聮濓薕磏
+liang 緉踉靚靓倞魎蜽亮裲輌魉谅辆喨晾湸煷掚諒輛鍄駺量涼冫啢簗良梁椋辌粮粱墚綡倆凉輬两唡両兩俩樑糧
+liao 镠鏐镽僇鷯飉尞髎爎賿豂聊炓簝蟟蹘廖廫遼蟉撂料鐐镣繚瞭爒蓼鄝釕钌尥僚寮嫽嘹膋漻嶚嵺寥辽潦樛蹽燎屪憀璙疗嶛竂療窷了鹩敹憭膫撩暸獠缭
+lie 颲奊蛚猟睙聗趔巤煭鮤獵犣躐鬛劦劽猎脟鬣哷咧裂捩鴷毟挘埓忚列劣冽浖峢挒洌茢迾埒烮烈姴
+lin 凜撛廩廪懔臨澟懍凛僯疄菻涁魿蹸檁閵亃橉林鱗悋轥躪躙躏甐膦檩蔺賃焛赁恡吝伈藺琳潾嶙隣鄰粼箖獜痳粦淋崊拎临邻啉麟碄麐驎晽遴鏻瀶轔鳞翷璘斴暽壣燐繗辚霖瞵磷
+ling 霗齢瀮霛燯酃鹷鴒鲮霝錂蕶澪駖霊蔆綾零鈴鯪嶺龄袊炩呤另令裬領岭醽軨领靇孁阾龗麢爧欞靈櫺齡蘦朎彾秢砱皊凌瓴玲柃昤苓竛泠灵岺姈夌坽囹蓤刢閝棱狑詅伶铃跉祾衑蛉菱舲聆翎羚婈紷笭琌淩棂掕崚鸰陵绫
+liu 驑蒥飀柳栁珋鐂桺桞鶹鰡鎏騮麍餾鎦绺鹨飅六塯藰碌飂鷚鬸雡翏廇锍畂嬼羀橮鋶罶熮綹霤溜旒嵧媹裗硫畱琉蓅留刘流瀏浏畄沠蹓熘磟旈镏懰遛駵澑癅疁璢橊駠嚠磂瘤瑬劉飗瑠榴骝馏鹠
+lo 漏咯囖
+long 聾鸗驡鑨躘豅龓礱蠪矓籠梇襱礲滝蠬壟癃瓏贚衖拢竉徿壠嚨隴儱垅垄陇朧攏爖隆聋笼砻眬胧湰栊昽茏篭咙屸龙谾珑鏧泷嶐櫳霳蘢瀧巄漋龍窿蕯巃曨槞
+lou 篓甊摟嶁塿嵝簍膢喽耬搂陋漏瘘镂瘺鏤偻嘍屚溇露婁漊楼窶娄蒌廔慺蔞遱樓軁耧蝼艛螻髏謱髅鞻熡
+lu 稑碌睩盝剹祿勠賂路漉塶廘摝琭辂箓輅陸甪陆侓绿彔峍勎娽錄椂粶淕淥渌硉菉逯鹿赂虂潞簵鯥鵦鵱麓鏴鹭籙騄鷺緑攄禄蕗坴圥騼錴戮膟觮趢踛辘醁簬録蔍璐簏螰鴼簶蹗轆穋瀘纑鈩鲈魲盧嚧壚舻攎玈獹蘆櫨爐瓐臚矑廬芦录露氌氇噜撸嚕颅卢轳垆枦泸炉胪舮鸬擼镥虜滷瀂蓾鑥澛擄鲁磠樐櫓艣鏀艪鐪罏栌橹鑪艫蠦魯轤硵顱髗鱸鸕黸虏掳鹵挔捛卤籚庐塷
+luan 圞癵虊臠鑾癴灤鸞圝乿乱釠亂挛羉卵栾鸾灓娈峦脔滦曫鵉奱孿巒銮孪欒
+lun 蜦棆綸婨腀踚論碖輪磮鯩耣稐埨溣论侖伦崙菕抡仑囵沦纶倫陯淪圇崘轮惀掄
+luo 荦硦泺峈络蛒蓏洛茖曪蠃瘰骆裸犖躶雒捋駱跞挼纙濼摞鮥洜臝漯砢落絡笿珞鵅猡骡箩锣腡椤逻镙脶頱罗啰囉倮烙癳萝籮咯螺剆攞驘饠鑼鸁鏍邏蘿騾覼儸覶羅欏
+lv 捋穞寠濾褸卛滤穭褛儢履鋁垏箻縷寽虑率绿嵂氯葎慮勴繂櫖爈鑢膐綠馿閭驢鷜曥藘偻氀瞜榈闾驴瘻律膂櫚祣僂缕屢絽屡稆焒梠旅侶郘侣呂吕铝
+lve 鋝圙擽掠鋢畧略稤锊剠
+ma 嘜遤瑪螞鰢傌榪鎷杩祃閁睰禡罵駡礣鬕抹碼獁骂孖鷌吗嗎嘛妈溤痲麻嫲蔴犘玛媽蟆亇蚂馬犸马尛蟇码
+mai 麦勱脈麥衇賣邁霡迈脉売霢荬卖埋佅买貍買嘪蕒鷶唛劢霾
+man 漫慢幔墁谩曼鏋蟎獌螨蟃襔缦蔄蔓熳澷縵鏝蘰鰻謾姏滿镘悗满蛮颟顢埋慲摱馒槾樠瞒僈瞞満睌矕蠻鬘鬗鳗饅鞔屘
+mang 硭漭甿铓鋩駹蘉氓庬鹲鸏莽莾壾蟒蠎牻釯茻龒痝狵朚邙吂忙汒芒尨哤杧盲厖恾笀茫浝娏牤杗
+mangmi 匁
+mao 袤覒耄帽秏貿媢贸冒冐冃卯鄚毣皃愗暓楙毷瑁貌鄮蝐懋獏萺瞀鉚芼霿髦貓毛矛枆牦茅旄渵軞酕堥緢蓩髳茂笷铆昴泖锚峁錨戼冇鉾罞茆鶜蟊猫
+mas 唜
+me 庅濹孭嚒麼嚜嚰么麽
+mei 浼嵄黣鎂躾燘嬍镁媺眊媄昧挴美凂每毎羙猸渼鬽腜坆氼櫗蝞篃魅抺煝妹跊痗寐媚眛袂祙沬睸莓睂湈湄黴媒槑郿脢葿梅嵋脄眉栂苺玫枚没沒珻矀攟鶥堳攗楣鎇徾霉鋂镅酶塺禖瑂煤楳鹛蘪
+men 懣怋玣殙闷焖悶暪燜蒙们虋懑钔鍆椚們扪門閅捫菛璊穈门
+meng 矒懜勐矇溕夣鼆蒙顭猛靀饛锰艋蜢艨鯭錳黽瓾夢孟梦霥懵擝蠓鯍掹懞虻冡莔萌萠盟甍儚橗檬蕄蝱鄳鄸幪濛獴曚朦礞氋瞢
+meo 踎
+mi 泌覓淧密秘宻峚灖觅幂覔宓沕汨蔝渳孊瀰銤谧濗哋蜜蝆蓂鑖羃簚櫁謐藌幦樒鼏塓蔤熐漞榓嘧覛禰幎滵冪袮靡麋麊縻糜彌醚謎麛谜蒾迷祢弥冞眯瞇咪獼葞詸粎猕脒爢敉洣弭沵侎芈羋米镾擟蘼醾醿鸍檷籋罙釄瓕
+mian 冕腼葂缅湎愐娩勔緬偭糆喕鮸渑澠靦麵面麪麫麺綿眄靣棉芇眠婂绵蝒媔勉緜嬵檰櫋汅矈黾免俛丏厸矏蠠矊沔
+miao 渺邈缈篎緲藐妙庙竗鶓廟淼庿嫹描苗媌鹋秒鱙喵訬仯杪眇瞄
+mie 滅懱鱴衊蠛篾吀蔑搣灭哶咩薎幭
+min 敏悯闽敃勄泯皿闵刡冺笢抿笽湣閔愍敯閩慜憫潣簢緡鰵碈僶鳘旼民垊姄賯旻緍玟苠珉盿冧罠鍲岷崏缗錉鈱痻瑉暋琝琘捪湏
+ming 命覭榠銘鳴瞑螟佲凕慏酩姳掵暝名詺猽明鸣洺眀茗朙眳铭鄍嫇溟冥
+miu 繆谬謬缪
+mo 粖蓦漠寞塻嗼貃蛨絈貊眽莈秣砞眿眜皌枺銆帞默昩圽驀莫艒耱纆礳爅鏌蟔黙貘墨縸陌魩镆瞙瞐瘼暯嫼藦嫫謩茉糢怽橅魹摩膜模擵馍謨谟攠戂嬷嬤嚤摸没冒摹末沫殁歿妺磨劰饃抹瀎脉魔蘑髍貉劘饝嚩懡麿狢貈
+mol 乮
+mou 眸呣鞪麰鴾某鍪謀谋恈劺侔牟蛑哞缪蝥洠
+mu 凩钼蚞莯苜牧炑募沐睦目狇雮墓幕仫楘鉬慕樢霂穆幙慔嘸暮木模呒毪氁母亩牡姆拇牳踇峔牟朷畆坶鉧畮砪畞畝胟畒
+na 钠內軜袦捺笝豽納鈉嗱蒳靹呐篛衲娜訤魶拿雫拏肭挐誽镎鎿乸蒘哪郍那吶妠纳詉秅
+nai 錼倷釢奈柰萘渿耐鼐褦迺摨孻廼熋螚搱乃奶艿氖疓妳腉
+nan 赧煵諵難萳嫨揇湳腩戁楠婻难蝻枬囡男遖枏暔侽南柟娚畘莮喃抩
+nang 攮饢齉儾灢曩擃崀搑乪欜涳瀼嚢囔囊蠰鬞馕
+nao 闹堖垴恼悩脑匘脳嶩惱嫐瑙碯獿婥淖閙鬧臑腦挠孬檂巙蝚怓憹峱硇铙巎蛲碙撓獶蟯夒譊鐃猱呶
+ne 訥眲抐讷呢
+nei 娞氝内浽鯘腇餒鮾馁
+nem 焾
+nen 内嫩媆嫰
+neng 竜能
+neus 莻
+ngag 鈪
+ngai 銰
+ngam 啱
+ni 隬迡伲儞鑈狔柅擬愵馜鉨孴晲旎昵薿溺孨灄嬺膩縌暱惄睨胒拟嫟堄眤痆匿逆腻苨棿蚭猊婗埿铌跜秜郳倪籾泥怩坭尼妮你齯鈮濔聻淣腝蛪屔臡伱麑鯢鲵霓貎觬蜺抳
+nia 尿
+nian 辗撚撵碾輦簐攆辇躎輾廿埝艌粘卄蹨哖念蔫年淰拈秥鮎鲶黏鯰姩捻鲇跈涊鵇秊
+niang 娘釀酿嬢醸
+niao 蔦嬝脲尿茑褭嫋鳥嬲袅鸟尦茮裊
+nie 嚙囁蘖櫱孽孼闑齧聶囓蹑颞錜鎳巕糱蠥躡鑷顳諗囐銸鋷讘臲嵲糵苶捏揑乜摰圼篞枿陧涅聂臬啮惗隉喦敜嗫嶭踂帇槷踗踙镊镍菍
+nin 您恁脌囜拰
+ning 矃鑏鬡鸋甯澝鬤擰橣佞侫泞寗聹濘聍拧檸宁咛柠寍寕嬣狞獰寜嚀凝儜寧薴
+niu 杻靵拗鈕莥紐钮炄狃沑扭忸牜纽牛妞
+nong 挵襛醲欁癑農繷廾穠挊蕽齈弄浓农膿哝莀脓秾儂辳噥濃禯侬
+nou 槈譳鐞鎒嬬檽啂譨羺獳耨
+nu 砮搙傉怒胬弩努孥駑笯驽奴伮
+nuan 餪奻软渜煗暖
+nun 黁
+nung 燶
+nuo 穤諾蹃糑懦懧愞儺糯堧耎稬糥梛袲挪榒傩搻橠喏掿逽搦锘诺
+nv 钕衄朒恧衂籹女釹
+nve 瘧硸疟虐
+o 噢哦
+ou 耦敺漚齵澫吘呕偶沤嘔蓲蕅藕怄慪腢歐讴欧殴瓯醧塸鷗毆熰鏂膒鴎櫙藲謳甌鸥
+pa 琶筢潖跁帕怕爬耙袙趴妑扒舥啪葩帊杷掱
+pai 犤湃鎃蒎派哌迫輫牌猅排徘俳拍簰
+pak 磗
+pan 闆泮沜判冸眫叛坢溿洀盻牉盼畔詊鋬襻鑻炍胖宷頖袢鵥踫眅畨攀膰番爿柈盘媻幋蹣潘蒰鞶鎜蟠瀊蹒縏磐盤槃
+pang 旁胖鰟篣螃鳑龐舽蠭髈龎耪炐逄磅覫雱膀彷庞汸沗胮乓滂膖霶趽夆厐肨
+pao 泡疱跑窌炮奅皰砲萢麭礟犥袍颮礮脬麅軳拋刨咆垉鞄狍炰爮匏庖抛褜
+pei 姵帔佩沛伂茷旆嶏攈浿珮配笩蓜俖馷裴霈轡斾辔醅妚呸怌肧賠衃婄抷阫錇胚駍陪裵锫赔毰培陫
+pen 翸呠葐湓濆盆歕衯噴喷瓫
+peng 槰蟚髼韸膨篷輣澎蟛樥鵬鹏蓬稝憉鬅硼韼皏鬔鑝淜熢摓捧淎剻椪碰纄胓嘭漰閛軯硑磞砰抨恲泙怦漨掽匉烹竼椖塳彭塜堋弸倗棚朋芃梈輧軿荓莑
+peol 浌
+phas 巼
+phdeng 闏
+phoi 乶
+phos 喸
+pi 罴鵧圮仳庀朇匹蠯鷿猈琵鼙羆簲貔螷篺壀隦膍腗苉睥脾焷蜱淠铍豼譬疈甓嚊澼僻潎睤媲嫓渒脴屁闢鈲辟擗顖嚭癖噽鴄銢痞揊炋劈銔鉟釽豾耚紕秠秛砒磇狉伾披坯邳纰批蚽伓皮否裨陂狓疲榌駓埤啤陴蚍毘毗毞枇岯芘礔噼郫阰魾錃憵礕霹鲏鮍丕髬鈹
+pian 駢騙徧腁覑谝騈貵諞片骗騗蹁篇魸偏骈骿囨扁媥犏翩胼楄楩賆諚便
+piao 皫嫖瓢莩瞟殍顠飃票勡嘌闝慓飘薸骠剽彯缥磦旚縹醥翲螵飄魒漂
+pie 暼丿嫳鐅苤瞥撆氕覕撇
+pin 牝矉颦顰蘋玭品朩嚬汖聘频榀拼嬪薲姘礗穦馪頻贫貧嫔驞拚
+ping 甁焩幈塀瓶蚲帲缾屛鮃萍聠蓱蛢評鲆慿呯箳憑娦凴甹玶簈乒俜娉涄砯艵竮頩冖帡评凭坪岼苹郱屏洴枰平
+po 敀岶廹屰駊鉕钷粕尀笸洦珀烞皛砶奤蒪魄叵迫朴破颇櫇昢陂泊繁鏺钋坡岥釙溌巿謈皤鄱泼婆酦攴頗醱潑嘙
+pou 抔哣咅裒掊抙颒剖頮犃捊
+ppun 兺哛
+pu 鏷圑普烳浦埔圃朴溥襥諩纀贌镤濮暜谱氆檏镨譜蹼鐠铺舖舗曝巬穙樸瀑瞨扑堡暴巭炇痡駇噗撲鋪潽襆脯僕璞蜅墣酺蒲蒱菐菩仆莆匍葡圤
+qi 鯕芑岂屺企邔耆袳豈扺薺乞启呇杞玘啟唘起啓啔荠鳍绮盀棨蕲螧鲯濝藄檱櫀簱臍騎騏鵸蘄麡鶀麒籏纃艩蠐鬐騹魕鰭玂弃器汔棄湆湇葺碛摖暣甈碶唭憇訖憩磜磧磩罊趞洓慼欫螇禥噐迄綺諬簯闙梩婍鼜悽槭气夡気綮汽芞呮泣炁盵咠契砌栔氣讫鶈嘁慽榿漆緀磎諆諿霋蹊岓鏚紪碕傶軙荎饑亓祁齐圻岐魌郪缉稽七沏妻恓迉倛齊栖僛缼褄娸戚捿桼淒萋朞期棲欺凄桤蛴猉畦跂釮骐骑嵜棊棋琦渏祺萁愭碁鬿旗粸綥綦綨緕蜝忯琪疧芪亝其奇斉歧祇祈軝蜞淇柒竒埼掑肵崎剘颀蚚蚔蚑脐旂斊
+qia 冾帢磍髂硈殎洽恰卡掐鞐酠跒拤搳愘葜圶
+qian 煔蚙潛鰬燂灊肷籖葴鍼騚浅錢淺嵰遣槏墘榩膁廞掮乾軡媊鎆鉗濳箝潜羬橬黔钳騝鈐槧嬱皘蒨塹歉綪纤儙椠篏輤篟壍縴竏钱蔳芡谴缱譴鑓繾欠嗛伣蜸俔茜倩悓堑嵌棈刋僉拪粁蚈铅牽釺搴鈆汧愆签鉛骞鹐慳谦扦钎虔千仟阡圱臤奷茾汘芊迁佥岍杄悭圲岒韆鋟扡杴孅藖谸籤朁前忴扲拑撁荨牵歬鏲遷钤鬝諐褰謙顅檶攐櫏簽鵮攓騫鬜箞攑
+qiang 謒廧薔强牆樯艢檣嬙漒蔷嫱蘠墙嗆墻繦強蜣熗呛羻襁炝抢繈摤墏羥搶羟唴戗腔獇嗴跄琷溬枪玱戕羌鶬鎗嶈鸧猐錆鏹鏘斨啌蹡蹌篬槍锵锖瑲牄戧镪
+qiao 藮譙巧墧磽礄蕎顦鐈瞧癄犞橋鞒嘺憔愀竅谯樵誚槗俏踃躈鞩翹韒撬鞘髚髜翘窍帩峭诮雀墝偢僺鄥鍫缲燆橇幧墽頝锹鍬劁碻鄡跷郻硗悄壳陗僑敲硚睄踍菬繰桥荞荍侨乔嶠峤鞽喬鐰塙毃鏒橾喿蹺趬
+qie 郤箧鍥緁锲篋踥穕藒鯜鐑竊籡郄朅稧妾悏帹怯切苆癿茄聺且詧伽厒趄匧窃倿愜挈惬笡慊
+qien 伽
+qin 鳹梫笉昑坅溱鬵蠄懄懃螓瘽沁澿揿檎赾擒抋噙琹鈊藽瀙撳搇吣唚寑吢櫬儭螼寢寝锓菣綅欽寴鮼駸顉親骎誛衾嵚媇菳钦亲靲鈙嶔雂嗪嫀侵鈂勤禽琴捦耹秦矝珡庈芩菦芹埁
+qing 樈顷苘殑黥棾擎暒氰请箐晴檠頃請檾謦庆凊碃慶磬罄櫦濪硘蜻掅倾亲鶄青鲭轻情卿郬圊埥氫淸靘氢清勍夝漀庼鑋鯖輕廎傾軽甠
+qiong 焪睘藑蛩蛬煢熍窮儝憌橩瓊竆琁嬛銎琼焭跫卭邛穷惸茕桏笻筇赹穹藭
+qiu 梂殏毬球釻巯盚釚俅皳逑莍紌浗唒酋遒觓銶訅醔糗搝赇厹鰽蠤鵭鯄賕鮂煪崷璆虯觩巰裘蛷絿鼽恘趥穐蝵緧篍鹙楸媝鳅秌寈坵邱丠丘湫龟仇泅湭蚯鶖虬秋蟗肍玌犰扏叴求櫹蘒龝鱃鰍鰌逎囚鞧鞦
+qu 蘧灈戵欋氍躣衢鼩蠷癯臞璩磲璖蕖葋絇渠鸲蠼覻菃衐淭刞祛胊迲鼁覰闃麮閴趣觑覷耝鑺去齲龋竬詓娶取曲蟝臒阒屈蛐粬筁躯蛆區胠浀抾詘岨驱朐阹袪岖匤伹区匚趍苣岴佉诎趋斪劬佢跼厺紶鱋鰸驅黢麹嶇瞿麴憈駈駆髷趨麯軀
+quan 颧顴巏灥權鰁齤鬈鳈闎縓譔椦銓権醛綣蜷葲勸勧牶券巻虇牷绻烇畎甽犬孉劝鐉荃洤泉诠权拳腃姾騡駩圈悛恮輇全峑跧詮佺瑔辁搼楾絟犈湶铨硂痊婘筌觠
+que 埆鹊愨慤燩闋闕鵲殻榷阙礭崅碏缺蒛瘸卻悫確雀炔确阕皵却
+qun 逡帬裠群羣踆峮囷夋裙
+r 儿
+ra 亽罖
+ram 囕
+ran 繎冄冉姌苒染珃媣燃蒅衻肰呥髯袇蚦袡蚺然髥嘫
+rang 譲懹让爙攘壤嚷孃壌穣獽禳瓤穰躟讓
+rao 橈娆繞绕遶擾隢扰嬈犪襓桡饶荛饒
+re 渃熱热惹若
+ren 紝餁任扨纫妊牣纴肕轫韧饪衽讱訒軔梕袵絍靭靱韌飪認仭紉鵀屻綛人仁壬忈朲忎秂芢鈓仞銋姙忍荏栠栣荵秹稔躵刃刄认魜
+reng 辸礽扔芿仍
+ri 日驲囸釰鈤馹
+rong 穁嬫駥螎融縙嶸蝾曧瑢镕爃鎔瀜媶冗宂傇穃熔褣蠑容氄肜栄狨绒茙荣峵毧烿嵘溶茸蓉絨榵戎摉榮搈嫆羢榕
+rou 肉瓇騥鰇鶔楺韖鞣宍蹂煣柔鍒輮禸粈媃揉葇瑈腬糅渘
+ru 汝入擩鄏顬辱偄鱬肗扖缛乳込杁洳嗕溽蓐鳰褥縟筎醹媷侞嶿蕠如颥帤茹桇袽铷渪銣薷襦燸鴽濡孺嚅鴑蠕儒曘
+ruan 軟壖瑌礝瓀蝡碝软朊阮緛
+rui 芮蕊壡叡銳睿瑞锐蚋枘蘃蘂撋橤繠蕤緌甤桵惢
+run 润闰橍潤閏閠
+ruo 嵶鶸鰯箬爇蒻叒焫鄀楉弱偌若鰙
+sa 脎萨隡馺薩灑櫒颯卅靸訯洒撒桬仨飒
+sai 鳃賽僿赛嗮簺顋鰓嘥腮毸塞毢噻
+san 氵伞傘馓橵繖彡俕糤閐潵散弎饊糣厁三叁毵毶毿犙鬖糂糝壭
+sang 槡鎟喪丧磉顙颡褬嗓桑桒搡
+sao 臊矂氉鱢埽髞嫂掃扫溞鰠搔骚缫繅鳋颾騒騷瘙
+se 栜繬穑澀璱瀒穡轖譅飋愬瘷溹穯鎍鏼色涩啬渋濏歮嗇塞瑟歰銫澁懎擌濇铯
+sed 裇
+sei 聓
+sen 森
+seng 鬙僧
+seo 閪
+seon 縇
+sha 煞鯋賖啥傻儍繌倽唼歃翜翣閯霎厦廈杉鯊閷萐猀鲨杀沙纱乷刹砂唦殺紗裟魦禅挱繺莎蔱硰痧铩樧
+shai 酾簛曬晒摋釃簁篩筛色
+shan 椫訕赸陝扇善閃銏骟僐傓疝讪饍笧曏覢熌睒晱鄯汕繕衫陕鱣鱔灗鳝騸鐥贍蟮缮赡謆磰膳墡樿敾擅嬗譱圸軕痁舢珊狦姗芟杣笘删钐邖山襂凵穇纔单闪埏杉羶刪栅柵姍炶釤羴鯅膻檆曑搧苫澘剼閊嘇幓煽潸跚
+shang 垧坰丄晌赏賞鑜上仩尚绱緔裳鬺恦觞謪伤商塲傷墒慯滳蔏殤熵螪觴殇
+shao 少杓潲芍勺玿韶劭卲邵绍哨旓紹袑烧娋弰梢柖焼稍筲鮹蛸輎蕱燒髾艄捎
+she 滠摄慑弽赦設涉慴涻摵蔎韘懾麝欇射蠂騇挕猞攝厙奢赊輋賒檨畲舌佘设蛥磼社折舍捨厍舎蛇
+shen 谂谉婶渖矧審邥頣魫訠矤审榊弞槮棯鰰曋渗神叅沈祳蜃莘什葚瘮滲瘆慎愼胂脤瞫眘甚昚侺肾哂讅覾嬸腎身娠籸穼珅氠柛诜绅籶峷呻侁扟屾参申糁叄堔鋠鰺妽燊鯵鵢伸甡鯓鲹駪薓蔘甧詵紳眒砷深兟椮葠裑訷罧蓡
+sheng 繩胜渻偗眚绳憴譝圣縄鼪橳省晟晠剰盛勝嵊聖墭蕂賸鵿剩榺生鍟貹乘渑升阩呏声斘昇枡泩苼焺鉎聲身殅甥湦笙陹曻陞竔珄牲
+shi 视烒恃饰冟室恀试拭枾柿眂贳栻眎眡呩莳适礻屎榁鉂駛笶饣筮蒔士氏势世丗仕市示卋式事侍舐釋煶噬嬕澨諡遾螫簭適襫誓鰘佀鎩是似殖葹兘籂嗜逝铈視釈弑揓谥貰奭勢轼弒睗試軾鈰鉃飾舓释鶳詩瑡鳲蝨鲺濕鍦石鰤獅襹籭魳失褷匙十什鯴施鳾觢尸师呞溼诗驶蓍屍蒒浉狮師絁湤湿溮邿鸤鰣始實蝕鉽篒鲥鮖鼫鉐鼭榯時史矢乨豕使虱辻識飠佦时竍识实旹溡峕拾炻遈蚀食埘寔嵵湜祏塒実
+shou 授痩壽瘦綬夀獸绶鏉售獣垨兽收膄守収首艏醻寿受狩手
+shu 澍丨署戍束沭述屬树曙怷属鱰蠴籔竖癙裋薯潻蜀鼡鼠糬潄鱪忄捒虪鶐鏣錰濖樹蒁數荗漱墅腧竪数術絉庻庶恕豎柕疎鄃軗菽焂梳紓殊書疏倏淑姝枢叔纾抒殳书距术黍倐孰暑贖璹熟掓赎舒婌秫尗陎鵨鮛輸蔬摅樞塾踈输綀毺毹瀭
+shua 唰耍刷誜
+shuai 蟀率缞縗衰摔甩帅帥
+shuan 栓腨涮拴闩閂
+shuang 鸘灀傱鏯縔樉慡塽骦驦孀双爽雙艭孇騻欆礵鷞鹴霜
+shui 涚氺睡説裞税稅说閖水誰脽谁帨涗
+shun 橓鬊瞬瞚順舜顺吮蕣瞤
+shuo 鎙蒴槊碩爍鑠燿朔搠洬矟欶说說妁铄数硕烁
+si 汜俟驷饲泗孠価祀泤姒罒伺娰肂亖巳灬死銯似覗蕼兕瀃儩駟禩飼鈻貄洍嗣柶竢釲耜笥飤四涘鷉牭肆思鉰禗楒蛳缌絲斯媤飔鼶恖寺泀咝私糹司丝厶愢鸶澌凘鷥鐁騦颸蟴蟖鍶螄俬緦撕廝噝嘶锶罳禠榹厮蕬
+so 螦
+sol 乺
+song 讼耸竦愯嵷慫聳鎹駷庺鬆宋诵送颂訟頌誦餸悚松菘忪枀枩娀柗倯凇檧怂梥濍憽蜙硹嵩淞崧
+sou 餿嗽瞍騪叜叟嗾颼擞薮擻櫢瘶鎪艘藪鄋傁醙凁捜嗖廀廋搜溲蒐蓃馊飕摗獀螋锼
+su 遡樎憟遬趚觫蔌榡溸鹔肅樕驌愫溯僳蹜鷫嫊缩膆埣謖鱐潥藗簌璛縤餗鋉宿稣卹櫯蘓蘇鯂玊窣俗酥甦苏赎夙塑穌粛塐嗉谡訴粟囌骕诉殐速素珟涑肃泝傃
+suan 笇狻算蒜筭匴痠祘酸
+sui 煫賥誶穂澻嬘碎禭歳歲譢砕隧檖璲穗穟襚邃旞繐繸鐆鐩祟岁燧眭谇亗尿夊芕虽倠哸荾滖睢濉鞖遂髓髄荽瀡雖瓍隨遀簑随隋绥綏
+sun 鎨笋隼筍損潠箰巺蓀损榫荪蕵畃狲孫飧搎猻槂薞孙
+suo 所摍缩趖簔縮髿蓑挲莏鮻羧嗦睃梭傞娑唆嗍莎唢桫蜶逤嵗鏁鎻鎖瑣暛嗩锁琑琐溑索
+ta 狧踏誻撻禢毾榻遝闼嚃闥崉錔嚺濌蹋鞜挞鞳涾譶躢傝闒澾嗒阘侤沓她他它祂咜趿铊塌榙鳎溻獺獭墖蹹遢褟塔鰨鉈
+tae 襨
+tai 抬忲夳冭太呔汰鈦籉薹忕态肽钛泰粏酞溙燤態台擡舦孡坮嬯漦囼胎駘檯斄邰苔臺儓咍炱鮐颱箈鲐跆菭炲
+tan 袒貚忐墰钽鐔埮菼坦鷤罎醰譚壜毯探藫罈譠暺顃弹歎舕碳嘆僋叹炭鉭緂襢璮醓憻憳嗿湠貪癱灘攤擹瘫潬镡滩嘽舑痑啴怹贪檀坍澹曇摊錟蕁壇談潭憛墵谭谈昙郯婒覃榃痰锬坛
+tang 篖醣伖攩螳鶶饄鎕赯糛踼糃糖帑橖膛螗曭磄漡燙趟摥烫钂餳爣倘戃儻鎲镋躺傥淌矘鐋坣饧鏜镗鼞耥唐鞺蝪蹚薚羰嘡湯铴餹闛煻劏堂膅禟瑭漟榶隚蓎啺搪汤塘樘鄌棠溏傏
+tao 裪祹萄淘梼啕陶桃咷洮綯讨迯逃蜪鞀醄鞉鋾駣檮騊绹討套涛縚鼗涭叨濤轁燾仐弢绦掏絛詜嫍幍慆謟搯饕焘鞱縧饀飸韬瑫槄滔韜
+tap 畓
+te 忒脦螣蟘鋱慝貣特忑犆铽
+tei 忒
+teng 虅駦謄儯藤霯騰驣縢籐鰧滕漛誊腾幐痋疼鼟膯籘邆
+teo 唞
+teul 朰
+ti 體倜洟剃屉戻衹鯷悌挮体鳀穉涕鶗替騠鮷鶙歒楴鐟籊瓋嚔鬀嚏薙逷殢逖褅裼厗惖題掦惕悐屜髰骵啼偍绨磃虒扌褆姼媞軆鷈躰鵜鍗銻徥锑梯剔擿趧踢崹謕蹄题蝭蕛緹漽徲稊瑅嗁鹈遆罤惿缇提醍綈
+tian 倎唺悿殄淟晪捵忝鍩寘餂鷏琠瑱畠铦鷆兲舚睼掭舔銛腆娗錪賟覥睓觍紾靔闐添婖酟黇靝呑瞋田屇沺恬畋畑磌天盷璳鴫窴碵阗甜胋甛搷菾湉塡填
+tiao 宨頫螩鯈齠鰷趒銚儵鞗鎥晀朓脁窕窱眺粜跳糶鲦条覜芀调旫佻庣挑祧聎岹萔髫岧龆鋚苕樤蓨蓚笤條祒迢蜩
+tie 僣鐵餮飻呫驖鴩帖怗貼聑萜贴蛈铁
+tin 挺
+ting 蜓珵諪蝏聤霆閮楟梃葶榳圢侹筳涏鼮烶珽脡颋誔頲艇挺听侱嵉铤厅汀耓厛烃烴綎鞓聴停廰聽渟廳邒廷婷亭庁庭莛聼
+tol 乭
+tong 赨氃慟鉖僮鉵銅餇鲖潼獞曈朣橦酮犝膧瞳穜鮦眮统捅桶筒綂統痛粡恸爞童憅垌峒囲炵通痌嗵蓪熥冂燑仝桐铜秱蚒樋浵同晍茼佟哃庝峂彤狪砼
+tou 敨斢黈蘣埱綉钭妵透鍮紏偸头投骰緰頭偷
+tu 鈯酴鶟鵌駼鍎馟潳廜鷋圖圡筡瘏圗钍嵞鵵堍莵兔蒤釷鷵汢吐鋵土菟兎迌湥宊瑹凸禿秃突涋鼵堗塗痜葖嶀鵚梌稌捸揬唋屠途荼涂徒庩峹凃图図捈
+tuan 畽鏄糰鷒鷻嫥鱄圕貒褖檲彖湪团疃抟篿湍猯煓蓴団團塼慱摶槫漙剸
+tui 蜕頽俀脮腿蹆骽退娧駾蛻褪僓穨煺藬忒蹪蓷焞騩墤颓尵頹頺魋蘈隤推
+tun 坉氽軘飩鲀魨霕臋豘黗豚臀噋饨忳囤汭吞旽啍朜暾屯芚
+tuo 跎橐鼍騨鼧鴕鮀驒駞踻槖馱鼉酡椭堶紽鸵碢柝驝袉籜蘀箨毻跅毤唾迆拓鵎楕庹妥軃駝萚侂脱涶託袥莌捝挩飥咃侻杔饦汑托讬乇砤拖柁拕砣馲驼沱岮坨陁陀佗棁脫舃鱓鋖牠驮魠
+wa 譁膃帓娃瓦佤邷瓲韎靺袜聉襪腽姽韈韤咓嗢媧哇窐劸徍挖洼娲窊嗗蛙屲搲砙溛漥窪鼃攨畖
+wai 喎竵顡外歪瀤咼崴
+wan 脘鍐鋔輓綰綩碗皖琬椀晼盌薍莞绾梚晩惋婉莬菀杤掔晚脕贎鎫贃錽鋄翫腕惌妧卐万孯鄤輐畹夗魭萬湾丸捥埦灣壪卍彎豌刓睕挽塆帵婠剜弯乛夘闗関蜿邜唍潫宛汍抏蚖頑貦琓烷顽完倇捖芄岏忨玩笂紈纨
+wang 盳棢魍蛧網蝄暀誷輞迬琞妄忘旺望朢焹菵迋亡惘辋尩尪尫瀇仼彺莣蚟抂网忹往罔徃王汪枉
+wei 緯鍡濻儰薳頠韑諉蔿鮪爲踓壝韙颹瀢韡亹斖鲔蜹骪卫未位茟偉躗味崣梶硊萎隗骩廆徫暐愇碨猥葦蒍骫椲煒瑋痿腲艉韪偽蘶饖錗餧鮇螱褽餵魏藯鏏衞鳚罻讆讏躛荱蜼硙轊圩遗诿微霨墛畏胃叞軎尉菋谓喂媦渭謂煟苿蔚慰熭犚磑緭蝟衛懀濊璏猬为桅鳂癐鰃楲嵔浘危巍恑薇囗嶶韦围帏沩违闱峗峞洈為韋撝揻崴威烓偎逶隇隈喴媁媙燰揋蜲渨煀葨葳椳溦煨詴縅蝛覣愄伟寪觹觽觿欈違趡磈瓗膸僞鰖纬伪尾涠芛鰄委炜玮洧娓捤撱圍苇霺唯帷惟喡嵬幃湋溈琟潍維闈犩覹维蓶癓鮠鍏濰醀潿鄬
+wen 歾鴍刎鼤呅吻芠闦忞闅蟁閿螡鳼呚渂馼魰閺肳聞昷顐璺絻搵問汶问忟穩穏稳脗桽紊呡抆妏辒缊褞鰛鎾鳁輼豱緼榲鰮瑥溫殟榅塭瘒温瘟紋雯鈫阌珳饂蚉蕰闻砇炆薀蚊縕纹藴鴖亠文彣
+weng 鹟蓊罋甕蕹瓮暡聬瞈齆塕奣滃鶲螉嗡翁鎓嵡
+wo 艧握幄捾臥肟沃渥踠濣雘嚄仴硪楃腛瞃瓁龌齷枂馧卧偓蒦斡涡龏媉堝濄薶捼倭莴唩涹渦猧萵踒婑捰挝喔婐我涴蝸撾蜗窩窝
+wu 务躌噁鵡膴甒啎逜雺渞揾坞勿儛捂阢戊塢摀霚旿玝侮倵粅娬牾珷錻熓碔鹉瑦舞嫵廡潕伆鼿嵨溩雾寤熃誤鹜悟窹靰霧齀騖鶩芴怃迕鋈悞扤岉杌忢物矹敄奦務骛悮晤焐婺嵍痦隖屼误诬誈誣箼螐鴮鎢鰞毋杅釫幠譕蟱墲亾兦无兀呜武忤恶扝乌圬弙嗚邬鄔巫洿钨烏趶剭窏屋污瞴鼯鷡俉憮橆铻鋙鯃陚娒乄午仵伍吳杇妩庑莁梧呉芜吾五吴鵐郚娪洖浯鹀璑珸蕪蜈祦禑茣無
+xi 驨鰼襲霫騱飁鳛隰檄葈鎴謵騽葸銑蓰訢薂漇铣鈢囍喜徙玺洗枲杫屣习熂憙酅鼷蠵鸂鑴憘暿鱚咥娭瘜雭趘郋席習袭觋媳椺蒵蓆嶍漝覡醯黖赩舄趇隙慀滊禊綌隟犔潟澙塈覤阋戲磶虩餼鬩嚱霼衋細闟犧晳蕮卌禧諰壐縰謑蟢蹝璽躧鉩欪钑椞匸橲戏屃系饩呬忥怬细係恄绤釳鈒渓焟屖息悕晞氥浠牺狶莃唽悉唏淅饻烯焁焈琋硒菥赥釸傒惁晰爔桸吸犀蹊茜扱糦宩獡蜤燍夕兮汐奚覀欷希扸卥昔析矽穸肹俙徆怸郗西瞦餙凞樨橀歙熹熺熻窸羲螅錫膝栖犠惜蟋豀豯貕繥鯑鵗譆鏭隵巇曦燨厀礂嬉睎稀粞翕翖鄎嵠徯溪煕皙蒠嘻嬆舾噏餏豨蜥緆熙熄榽僖锡
+xia 舝黠鎋霞鍜轄赮縖蕸碬辖騢瑕筪叶縀圷暇侠疜罅懗嚇鏬夏鶷吓下丅昰翈睱梺傄匣郃魻鰕蝦狎閕瞎谺虾厦唬俠遐颬狹溊敮硤舺硖珨陿烚峽陜狭炠柙峡祫
+xian 顕幰韅攇燹櫶蘚玁顯灦搟县岘现臽鍌烍线苋尟狝显险毨粯猃限険蚬筅藓尠禒蜆跣箲險獫獮赻鏾撊線鋧憲餡豏瀗羡獻僴霰鼸脇軐県縣忺冼臔馅宪陥哯垷娨峴晛莧誢現綫睍絤缐献羨腺僩姭陷韱纖嘕鲜暹韯憸鍁僊褼祆鮮馦蹮廯譣鶱襳躚繊氙见姺仙仚屳先奾伭佡僲秈苮枮籼珗莶掀酰纤賢鹇嫌甉銜嫺嫻憪閑誸鷳癇癎礥贒鑦鱻鷴锨澖咞鷼蛝跹闲妶弦贤咸挦涎衔痫胘娴娹婱舷蚿縿啣
+xiang 鮝鯗響饗饟傢飨相鱶蠁鲞想跭饷响亯向橡享餉嶑詳蚃嚮鬨鋞鱌鐌勨襐姠像項萫缿象珦项巷蟓厢薌楿鄕葙缃湘廂箱鄉郷香乡纕蘘勷儴青翔鄊详芗緗絴祥栙降晑佭襄骧膷庠忀驤麘欀鑲镶鱜瓖
+xiao 潇蠨洨崤淆誵晓小筱暁鴵烋笹哮肖虈毊鷍囂髇蟰簫嚣筿斅瀟傚削啸澩斆髐熽蟏誟嘨嘋詨嘯敩曉笑涍校效俲咲効孝皢謏篠滧哓焇梟婋啋鸮逍虓绡消恷庨猇枵宵侾枭灲灱橚潚箾颵莦蟂驍鵁宯銷簘穘骁萧魈蕭膮彇霄鴞獢撨嘵窙痚痟硝硣翛萷销揱綃歊箫
+xie 褉禼亵媟屟渫僁谢塮榍榭焎絬械徢偰屓屑娎卨血炨洩缷卸噧揳炧夑藛绁爕蝑屭躠齂齥齘蠏蟹瀣躞瀉屧謝褻燮邂薤薢糏獬澥懈廨韰暬鞢協熁綊瑎携愶嗋翓斜衺脋拹恊膎胁谐邪旪协蠍蝎歇楔些解挟榝祄垥寫泻泄灺伳碿偕烲勰冩写纈脅魼龤蝢齛讗撷擕缬鞋諧燲擷鞵襭攜緳
+xin 馫舋鑫囟枔襑潃阠伩孞炘信脪衅焮心馨訫邤妡薪噺釁忻芯辛昕杺鋅盺俽惞锌新歆欣嬜
+xing 擤醒睲裄鉶铏硎哘洐型兴涬娙興杏姓幸性荇倖莕悻臖緈侀婞腥省陉行狌星垶骍猩瑆蛵觪箵惺形邢煋刑篂嬹鯹騂謃觲曐鮏皨
+xiong 诇訩詾哅敻熊詗忷胸雄洶汹讻芎匈兇兄凶恟胷
+xiu 宿珛岫秀臰糔滫绣朽嗅綇袖琇锈璓繍螑鏥鏽齅苬溴庥鎀臭休俢咻繡烌羞脙鸺臹鵂修貅饈飍鮴髹髤銝樇馐鏅
+xu 欰勗殈烅珬勖敍敘烼绪酗垿续洫昫恤叙沀序旭鉥嘼滀喣醑盢盨伵吁煦汿芧怴續藚瞲緖稸聟漵瞁壻潊糈槒賉蓄続慉訹絮溆朂婿緒虚噓魆需稰嘘頊窢楈須虛欻幁墟谞须虗顼鄦胥侐盱疞旴圩繻蓿畜媭栩欨嬃詡暊湑珝冔诩许訏蒣徐俆譃歔縃許蕦歘諝魖鬚戌鑐偦姁驉
+xuan 玄选癬癣昡玆漩泫夐選懸璿檈暶嫙炫繏蜁旋璇鉉悬絃渲駨贙鏇縼镟颴碹绚楦絢衒眴琄铉袨眩蔙軒蓒瑄煊暄萲萱揎愃睻谖愋晅宣咺昍轩吅煖痃喧譞玹媗鰚儇蠉蘐藼翾矎駽鍹箮塇怰券翧蝖蕿諠諼禤
+xue 谑鷽鳕踅雪樰膤艝轌鱈血泧狘烕燢趐瀥嶨桖噱雤疶蒆靴薛削穴斈乴鸴学岤峃茓泶袕坹學鞾
+xun 洵浚濬鶽驯讯卂撏攳馴彐侚鱘鱏蟳璕燖训樳訙潯燅奞噚稄鑂顨蕈噀賐愻遜訊巽伨咰訓殉逊迿狥徇迅汛殾薫爋壦蘍矄臐燻曛薰獯纁嚑勋勳勲窨熏塤埙鲟坃巡壎詢醺鄩揗循尋偱珣毥桪栒郇紃浔恂峋询杊旬寻勛荀
+ya 蕥挜掗哑呾轧潝圠疋輵瘂雅痖齖厊釾顔庌亚訝啞漄聐鼼椻窫稏圔猰氬氩婭穵砑娅玡亞迓犽讶襾揠鸭亜唖鐚鵶壓鴨錏鴉垭铔桠鸦庘压丫圧呀劜衙椏崕睚瑘押俹猚崖堐蚜笌琊伢涯枒拁孲疨岈埡芽厓牙
+yan 裺夵溎阭齗龂酀隁掞豜唁黶齴喭豣烻麲妟牪姲彥砚宴晏艳鼹罨彦檿扊揜棪渰渷琰隒椼燄演覎蝘魇褗黤曮巘鼴顩黭噞齞躽鶠鰋甗厴黡魘儼驠餍軅爓醶騴鷃灔觾讌饜驗鷰艷艶顑龑殷醼暥觃灎懕釅灩豔豓讞灧嬿筵酽焔谚堰敥焰焱猒硯葕雁滟鳫厭贋燕嚥験騐曕鬳墕諺熖湺鴈愝谳嬊验赝妍弇硽慇黰橪阽挻唌廵讠円延盐严篶言訁岩昖沿炎郔姸娫狿研莚娮闫阉厌嵃阏淊咽恹剦烟珚胭偣崦淹黫嫣厃嬮閹醃樮焉漹菸鄢煙傿腌湮臙嶖乵巚欕礹鹽麣黬偐贗菴剡嬐崄嶮巗衍酓郾萒眼掩抁炏沇琂兗俨奄兖匽厣嵓碞詽楌揅塩蔅綖訮嵒阎閆巖硏偃蜒簷孍壧麙颜櫩巌壛檐虤嚴閻顏厳
+yang 飬勜仰养奍氧岟鍚鐊炴坱阳鸉痒鰑駚颺鴹輰諹瘍禓霷癢胦煬怏樣漾様詇羕样羪礢紻瀁攁懩養柍氱慃軮楧傟恙鸯阦羊扬婸瑒霙佒鴦歍旸鉠鍈眏殃泱抰姎咉央楊秧鞅敭雵暘杨蛘揚崸崵陽眻珜烊羏佯炀劷氜疡钖垟徉昜洋飏
+yao 尭珧倄轺峣姚尧肴窑嗂垚傜堯揺殽摇軺爻媱徭愮谣葽猺玅撽幺夭吆妖枖祅疟喓铫楆腰邀宎侥僥蕘匋恌訞齩舀窔袎要药烄鴢葯鷕詏騕闄榚蓔溔崾婹偠穾曣搖籥钥箹怮鑰讑筄耀熎矅藥艞曜薬鹞獟覞纅瑶謡謠繇餚窯磘徺鎐飖嶤瑤榣暚摿遥遙窈靿嶢咬窅餆柼鳐苭狕殀杳抭岆仸蘨窰烑踰鷂鰩顤噛颻
+ye 曄曅歋燁擖僷皣殗瞱靥擛馌業墷掖腋谒液烨偞鄴嶪葉爗鄓枽抴黦鸈驜靨鵺饁煠礏嶫擪鍱瞸曗擫嚈餣謁澲鎑峫也虵蠱擨鎁鋣爺铘揶埜爷野倻吔耶蠮潱噎暍椰亪捓业捙頁洂枼亱夜邺页楪曳冶叶咽緤鐷啘殕熀瓛壄漜嘢晔
+yen 岃膶
+yi 袣訳訲萟翌翊羛異殹悥埸痬谊竩益浳浥欭栺栧挹悒垼勚湙唈獈溢意亄骮鈠軼跇詍缢焲豙殔棭晹敡幆隿釴逸豛佾蛡异苅耴杙曵役呓劮佚伿呹忔呭屹伇亦议匇仡艺忆義襗芅诣衵羿疫玴浂枻弈帠帟奕译驿邑绎炈泆枍易怿怈峄妷轶俋贀瀷鶍鶃鶂鯣霬豷繹繶檍鎰議藝藙癔鮨貖臆翼翳燡讛镱虉懿燱誼兿阣焬癦樴坄齸蘙鷾譯鷧驛襼鷊鷁鐿囈饐醳曎刈蓺瘞熼熤熠毅槸撎億駅歝蜴鹢膉瘗潩榏廙嫕勩詣裛裔靾殪斁寱褹螠薏艗縊穓瞖瘱镒澺鹝曀懌憶嶧嬑墿圛劓黓肄燚熈沶怡宜侇诒沂宐夷圯羠仪饴彵迱狏銕鴺珆戺箷鉇釶匜迻椬媐萓移痍袘胰眙桋扅狋宧衪贻荑瓵柂恞弬峓姨咦耛巸衤訑郼畩猗洢咿祎依吚医壹伊揖辷弌一褘袆业尾蛇弋抑衣瑿杝壱悘黳鷖譩黟醫毉檹铱鹥拸夁噫嬄銥稦漪嫛禕蛜欹繄旑苡艤礒檥螘敼輢旖鳦鉯顗椅轙崺偯逘扆倚釔蚁舣庡苢鈘笖亿义辥絏紲謚儗掜輗欥蟻昳阤歖锜冝艾蛦醷嬟陭肊齮睪飴顊簃嶷寲頥螔彜彛嶬遺鮧儀疑頉跠誃椸暆遗貽詒矣叕熪裿攺佁钇颐彝已乙迤以錡钀頤鸃觺酏鏔栘讉籎謻彞貤乁
+yin 引璌螾鷣齦垠赺尹吲釿霪淾饮隐蚓縯蔩滛鈝银猌鈏碒誾夤蟫訚銀龈噖殥嚚檭鄞癊印茚洕胤湚廕垽慭堷憖憗懚檼阴訡酳嶾隠靷飮朄趛檃隂隱梀濥蘟癮讔輑櫽飲瘾絪銦裀铟陻堙婣凐筃氤歅溵禋蒑蔭瘖愔姻垔淫鮣乚囙因秵侌陰洇茵荫音骃栶阥訔斦泿圁峾崟狺苂荶烎唫婬寅磤殷崯粌闉緸鞇珢霒駰濦諲霠吟韾喑玪伒乑噾犾
+ying 營嬴蝿潆蝇熒縈瑩螢濙濚濴覮巆蓥赢藀萦鞕攍迎茔荥荧莹盈营滢営溁溋萾僌塋楹萤癭影潁瘿穎頴巊媵鐛颕鱦映暎硬膡贏盁廮瀯瀛瀠蠅櫿灐籝灜摬蛍颖瀅矨郢浧梬颍攚籯賏嘤撄甇緓缨罂鹦鎣瑛韹樱璎噟罃褮鴬蝧莺耺焽旲应応英偀锳珱碤啨婴媖愥渶朠嫈桜绬煐蠳鷪軈鷹鸎鸚滎譍鑍鶧夃俓泂嵤桯嬰謍瀴應膺韺甖鹰嚶孆纓攖罌蘡櫻瓔礯譻鶯孾
+yo 嚛喲唷哟
+yong 栐愑惥傛恿涌柡埇詠勈勇俑泳怺咏甬悀踴永鱅醟砽苚用佣湧鯒硧鲬禜踊慂蛹愹彮塎墉壅噰銿牅槦滽擁嫞庸雍鄘嗈邕拥筩癰慵揘鰫痈澭嵱喁鳙鷛饔郺廱鏞雝癕灉镛臃
+you 蜏羐莠梄聈脜铕槱蒏銪戭牖牗黝湵羗邎櫾峟栯庮有遊羑脩友丣卣苃酉甴貁姷祐诱迶唀宥亴囿釉酭鼬誘蝣鮋蚴幼痏褎褏銹柚牰右禉佑侑孧狖糿哊又汓逌泈櫌蓧蚘沋汼嚘蝤尣冘尢尤由揂悠魷輶妋优忧攸耰幽瀀麀滺憂優鄾纋呦猶蕕懮犹駀輏鲉鈾猷鱿游逰訧蚰偤峳怞楢铀肬怣邮疣油浟秞莜莤莸郵斿
+yu 悆惐欲堉淢域喐预钰馭砡鹆峪淯遇俼秗浴棛窳狱飫裕琙焴矞棜袬御庽寓喻喅阈逳棫龉熨峿穥齬蘌麌斔穀愈蟈蕷語與瘐瑀楀斞嶼驭彧郁育饫忬妪芋閼圫昱玉獝衘翑肀鐍僪聿櫲驈欎霱鐭轝譽燏饇鱊鵒繘篽礜癒鹬魊蘛灪寙噳籲儥嫗谕吁鬻爩籞鬱鬰軉欝鸒鷸鴪灹預禦蜮蜟緎瘉獄毓銉嶎隩鈺誉蓣蒮罭稢煜戫鋊滪鴧鴥閾錥諭燠輍鳿礇遹豫薁蓹稶慾噊澦竽馀酑谀狳娱娯逾茰釪禺兪俞鱼臾盂於娛崳腴硢畭畬楰揄愉渔嵎萸堬堣魚雩隅隃欤嵛迃玙瘀盓淤唹虶紆亐穻丂迂纡尉具谷貐傴陓颙荢扵妤余伃邘于箊顒玗鱮汚汙悇媮婾桙渝媀騟予藇蝺頨澞湡伛貗宇喩芌萮鸆鷠鰅鯲衧舁萭鄅敔庾圉匬偊与圄挧禹骬俣俁雨羽屿祤虞蕍羭歶褕舆窬睮蝓觎艅瑜歈榆籅语愚楡漁鍝髃鮽謣旟牏諛礖輿螸覦璵雓餘魣歟嬩懙
+yuan 緣蝯謜羱黿薗螈榬塬鎱轅橼榞蒝獂猿溒媴圓園鼋鈨櫞院猨缘源逺湲辕願噮裫愿禐瑗掾衏垸怨苑肙邍遠盶远嫄褤褑媛傆厵鶰鶢騵妴鸳駌蜵鳶箢鹓蜎蒬棩葾淵渕鋺渁渆眢悁弲冤剈鸢囦嫚裷椽縁援渊酛寃圎鴛厡袁笎圆員原貟爰垣杬沅灁园鵷鼝蝝鼘喛楥元贠邧员芫嬽
+yue 跃樾嬳閲閱鉞粵阅鈅粤跀篗捳鸑越嶽籆瀹蘥爚龠躍籰龥鸙躒刖玥钺禴曰悅軏乐钥噦曱约約矱彟彠矆妜髺岳哕蚎悦哾蚏恱礿抈岄汋戉月趯
+yui 乐
+yun 磒霣馻齫齳殞緷孕运抎隕鈗喗殒玧狁夽沄枟餫允陨韵荺蒀韻蘊韞腪韗醞賱醖熨郓韫慍運愠惲傊酝鄆恽蕴氳抣妘囩呍伝匀勻云纭晕赟氲贇頵繧奫蒕煴轀涒暈澐熅芸縜篔橒蕓熉蒷筼溳郧昀鋆愪畇秐眃涢紜耘鄖雲
+za 韴襍雜雑雥囋杂扎砸咂咋匝沯臢帀沞拶桚紮鉔臜噈
+zai 载在載崽再扗洅傤酨债儎甾仔灾哉栽烖渽溨睵賳宰災
+zan 饡瓉賛赞錾鄼濽蹔酂贊鏨瓒灒瓚暫讚讃酇襸鐕暂兂糌簪禶簮篸撍咱偺揝昝寁儧攒儹趱趲喒穳
+zang 脏驵駔奘弉塟葬臟臓賘銺藏贓臧賍赃羘牂蔵匨髒贜
+zao 煰慥梍造唣艁皂竈唕噪簉燥竃趮躁澡皁譟凿薻遭糟蹧鑿早枣傮醩藻栆璪灶璅棗蚤
+ze 齰昃皟瞔礋謮賾蠌齚赜鸅讁葃澤仄夨歵汄諎昗捑崱庂伬擇啫樍则択沢择泎泽责迮啧帻笮舴責溭嘖嫧幘箦蔶則
+zei 蠈鰂賊贼鲗鱡
+zen 怎谮囎譛
+zeng 磳罾繒譄鱛縡鬷锃贈鋥甑増赠矰曾鄫增憎缯橧熷璔曽
+zha 铡厏鮓踷鲝鲊砟苲揷挿譗鍘霅鮺牐炸閘宱柞拃醡榨搾詐咤痄眨轧奓蚻诈乍吒蚱抯劄楂渣闸偧哳皶挓溠迊紥蹅餷馇查柤箑軋揸耫箚喳囃藸樝扎齇齄譇皻摣札
+zhai 翟窄鉙骴簀瘵寨债宅砦夈檡債择粂捚斋斎榸齋摘侧
+zhan 琖战佔占欃蹍醆嫸榐嶃栈搌湛斬盞綻驏蘸轏虦虥戰菚嶘桟戦嶄棧崭绽偡站輚蛅薝嶦谵閚詹趈邅惉栴粘盏旃颭沾颤詀斩毡霑飐讝氈覱鸇魙瞻鳣饘譫旜鹯驙展氊
+zhang 杖扙仗丈鐣鞝礃漲掌涨胀瘴幥账粀帳脹痮墇幛瘬瞕帐障仉賬章嶂張鄣嫜彰镸慞长漳獐粻蔁遧仧张暲長傽涱麞騿鏱蟑餦鱆樟璋
+zhao 赵照詔罀棹旐狣笊罩瞾垗肁肇肈趙曌燳櫂羄啅着枛召鮡钊诏兆嘲鼌鼂謿佋朝妱巶招昭沼皽炤瑵找爫鍣駋鉊釗盄爪
+zhe 者轍謺厇讋襵鮿謫嚞輙辙锗蟄赭褶鍺这柘浙這淛蔗樜鹧鷓埑磔蟅螫折袩輒舍着蜇嫬遮嗻摂歽砓籷虴摺谪哲詟蛰喆辄晢悊啠晣粍
+zhen 軫聄縥萙裖稹診震縝昣覙袗眕疹诊轸弫姫枕抮辴眹箴屒畛紖桢缜鎮鴆镇鋴賑誫敶蜄絼栚赈鬒朕振鸩陣挋纼阵圳迧謓嫃塦栕楨斟搸遉湞寊酙桭敒偵針獉真砧帪貞珎珍浈侦针贞趂駗椹祯鍖眞甄幀帧鎭揕侲鱵朾轃錱薽臻蓁禎胗蒖鉁靕榛殝瑧禛潧樼澵
+zheng 睜憕整撜愸晸塣拯糽氶徎癥錚抍挣掙正证诤郑政症証鄭鴊諍踭篜證征眐烝狰炡峥钲怔凧姃佂争脀徵鬇爭徰嶒箏埩蒸筝媜铮聇睁猙崢崝鉦
+zhi 狾智畤駤梽掷徝轾贽袟秩觗桎致猘秲秷窒紩袠貭铚鸷傂崻晊彘帜翐治筫滞亊鶨銴至芖志忮扻豸厔炙帙挚质郅俧峙庢庤栉洷祑陟娡徏垁瓆制旘瀄緻隲鴙儨劕懥擲櫛懫踬櫍質觶騭礩豑騺驇躓鷙鑕豒偫迣贄瘈蛭骘廌滍稙稚置跱輊锧雉槜鋕潌痣製覟誌銍幟憄摯潪熫稺膣觯滯执禔綕榰蜘馶鳷謢鴲織蘵鼅禵殖鉄梔侄坧直姪値值聀釞埴執职植只栀遟痔识氏之支卮汁巵知织搘徔椥祗秓秖胑胝衼倁疷祬秪脂隻枝肢紙帋沚纸芷抧祉茋咫恉指枳洔坁淽轵歭訨趾軹黹酯藢襧汦胵絷芝砋慹蹠蟙職摭膱軄漐縶嬂馽址疻跖墌踯凪阯旨劧止徴觝絺禃戠埶秇茝躑
+zhong 尰煄踵種肿瘇腫冢塚喠种迚緟歱重終衆諥筗堹眾媑蚛仲衶茽祌狆妕众偅汷幒籦忪徸蝩伀刣妐彸忠炂终柊盅螽鐘中衳鼨鍾蹱鴤螤锺蔠鈡衷舯钟
+zhou 昼伷冑绉宙咒呪咮纣薵詶椆疛睭菷紂驟鯞甃帚晭籀籒骤縐噣籕皺胄詋葤粙酎皱晝荮駎诪粥喌週矪徟婤郮赒珘侜炿洲周诌州肘箒辀諏啁舟碡轴輈烐軸僽輖謅盩嚋駲霌銂譸賙妯
+zhu 杼坾苎助住纻苧贮驻壴柱佇殶炷柷嘱馵主祝宔拄砫詝煑伫濐麈瞩囑矚尌罜篫筯墸箸翥樦鋳鉒築爥霔麆鑄櫡注飳駐嵀眝祩竚莇紵紸渚蛀疰筑註貯跓軴铸羜銖絑蛛誅跦槠櫧蝫硃橥諸豬駯鮢瀦潴洙著蕏藷朱劯煮铢斀袾茱株珠诸猪邾诛灟瘃蓫燭蠋躅鱁舳孎曯斸欘钃劅櫫侏劚鸀蠾蠩秼鯺騶鼄笜薥朮竹竺炢茿烛逐鴸
+zhua 檛膼爪抓髽
+zhuai 跩睉拽
+zhuan 饌簨灷啭堟蒃瑑僎撰馔囀传籑轉僝篆専专耑竱砖專鄟瑼磚諯蟤顓颛转転膞
+zhuang 焋丬撞壮粧壯状狀漴梉幢壵庄庒妆妝荘娤桩莊装樁糚裝
+zhui 坠諈畷膇墜綴赘縋醊錣餟礈缒轛鑆贅錐甀追骓锥隹騅鵻缀倕埀腏笍娷沝椎惴
+zhun 訰准準綧湻衠稕谆窀肫迍宒凖諄
+zhuo 斱諁禚斲擆撯罬諑硺镯琢椓晫窡濁擢濯鵫灂蠗鐲籗鷟籱烵謶浊斮斵炪浞梲缴着拙倬捉桌涿棳琸窧槕蠿诼娺啄鐯矠酌丵卓茁圴妰犳斫灼
+zi 姊茈泚籽杍姉鎡鰦矷秄呰子孳芓訿齜鶅鯔頿秭鍿牸頾訾茡鲻咨字漬胾胔眦眥渍剚倳耔自橴榟滓紫啙釨梓笫虸恣紎椔嵫嗞赼谘缁秶淄崰湽赀姕栥茲髭兹姿茊孜吱菑薋资澬滋錙輺諮鼒輜龇镃鈭緇鄑粢趦锱辎葘孶禌觜貲資趑
+zo 唨
+zong 蓗摠捴搃揔愡惣傯偬总緫蓯潈総糉潀熜疭綜錝瘲縱粽潨碂倊燪昮纵縂縦鏓鍯總猔堫嵸椶朡腙棕惾稯嵏葼骔综宗枞倧鑁嵕騌鯼鯮鬉猣鬃緃蹤豵熧踪踨蝬翪緵磫騣
+zou 齺鲰赱走鯐搊揍芻黀奏菆辶鯫驺陬棷棸鄒邹緅鄹诹
+zu 箤靻诅踤踿镞鏃阻俎爼祖詛崪鎺组組錊稡菹族伜倅紣顇卆足卒哫崒綷租
+zuan 籫纘欑攥赚鑚賺钻鑽纂缵躦躜劗鉆繤纉
+zui 檌酔晬最祽罪辠蕞醉栬檇枠穝嶵蟕堆厜絊樶纗嶉槯噿濢璻嘴嶊嗺睟
+zun 捘鳟銌僔噂撙拵鷷繜譐鐏罇樽遵嶟尊墫鶎鱒
+zuo 做岝岞怍侳祚胙唑阼袏左葄蓙飵座稓糳撮咗昨秨繓椊坐筰鈼阝佐酢作捽`
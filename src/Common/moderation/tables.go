@@ -0,0 +1,63 @@
+package moderation
+
+// traditionalPairs 常用繁体字与简体字对照，每两个字符为一组 (繁, 简)；只收录敏感词中常见的字，
+// 未收录的字按原样比较
+const traditionalPairs = "" +
+	"愛爱礙碍襖袄罷罢擺摆敗败頒颁辦办幫帮綁绑寶宝飽饱報报貝贝備备筆笔畢毕幣币閉闭邊边編编變变標标錶表別别賓宾餅饼撥拨補补財财" +
+	"參参慘惨蠶蚕艙舱層层產产長长場场廠厂車车徹彻塵尘陳陈襯衬稱称懲惩遲迟齒齿衝冲蟲虫醜丑籌筹處处觸触傳传創创純纯詞词辭辞從从" +
+	"叢丛錯错達达帶带貸贷單单擔担膽胆彈弹當当黨党檔档島岛導导燈灯鄧邓敵敌遞递點点電电墊垫釣钓調调疊叠頂顶訂订東东動动凍冻鬥斗" +
+	"獨独讀读賭赌對对隊队噸吨頓顿奪夺鵝鹅兒儿爾尔發发罰罚閥阀範范飯饭販贩訪访紡纺飛飞廢废費费紛纷墳坟奮奋憤愤糞粪豐丰風风瘋疯" +
+	"鋒锋馮冯縫缝鳳凤婦妇復复負负該该蓋盖幹干趕赶剛刚鋼钢綱纲崗岗個个給给鞏巩貢贡溝沟構构購购夠够穀谷顧顾颳刮關关觀观館馆慣惯" +
+	"廣广規规歸归櫃柜貴贵國国過过鍋锅還还漢汉號号閤合賀贺紅红後后護护滬沪畫画話话劃划懷怀壞坏歡欢環环換换黃黄謊谎揮挥輝辉會会" +
+	"匯汇彙汇賄贿穢秽繪绘葷荤渾浑獲获貨货禍祸擊击機机積积雞鸡極极級级幾几擠挤計计記记際际繼继濟济紀纪價价駕驾夾夹艱艰堅坚監监" +
+	"減减檢检簡简見见艦舰劍剑漸渐鍵键將将獎奖講讲醬酱膠胶驕骄嬌娇腳脚餃饺絞绞轎轿較较階阶節节潔洁結结誡诫屆届緊紧僅仅謹谨進进" +
+	"盡尽勁劲經经驚惊競竞鏡镜糾纠舊旧舉举劇剧據据懼惧絕绝軍军開开凱凯顆颗殼壳課课懇恳摳抠庫库誇夸塊块寬宽礦矿虧亏擴扩闊阔臘腊" +
+	"蠟蜡來来賴赖蘭兰攔拦藍蓝籃篮覽览懶懒爛烂撈捞勞劳樂乐壘垒類类淚泪離离裡里裏里禮礼歷历曆历麗丽勵励厲厉聯联連连蓮莲憐怜簾帘" +
+	"練练煉炼臉脸戀恋糧粮兩两輛辆諒谅療疗遼辽獵猎鄰邻臨临靈灵齡龄領领劉刘龍龙樓楼婁娄盧卢爐炉錄录陸陆驢驴侶侣屢屡縷缕慮虑濾滤" +
+	"綠绿亂乱掄抡輪轮論论羅罗蘿萝邏逻鑼锣騾骡絡络媽妈馬马碼码罵骂嗎吗買买麥麦賣卖邁迈脈脉蠻蛮滿满貓猫錨锚鉚铆貿贸麼么沒没門门" +
+	"悶闷們们夢梦彌弥謎谜綿绵緬缅廟庙滅灭憫悯鳴鸣銘铭謬谬畝亩鈉钠納纳難难撓挠腦脑惱恼鬧闹內内擬拟膩腻釀酿鳥鸟聶聂鑷镊寧宁擰拧" +
+	"濃浓農农諾诺歐欧毆殴嘔呕盤盘龐庞賠赔噴喷鵬鹏騙骗飄飘頻频貧贫蘋苹憑凭評评潑泼頗颇撲扑樸朴譜谱齊齐騎骑豈岂啟启氣气棄弃牽牵" +
+	"鉛铅遷迁簽签謙谦錢钱鉗钳潛潜淺浅譴谴槍枪嗆呛牆墙薔蔷強强搶抢橋桥喬乔僑侨翹翘竅窍竊窃親亲輕轻氫氢傾倾頃顷請请慶庆瓊琼窮穷" +
+	"區区軀躯驅驱趨趋權权勸劝卻却確确讓让饒饶擾扰繞绕熱热認认榮荣軟软銳锐潤润灑洒薩萨傘伞喪丧騷骚掃扫殺杀紗纱篩筛曬晒刪删閃闪" +
+	"陝陕贍赡傷伤賞赏燒烧紹绍賒赊攝摄懾慑設设紳绅審审嬸婶腎肾滲渗聲声繩绳勝胜聖圣師师獅狮濕湿詩诗時时實实識识駛驶勢势適适釋释" +
+	"飾饰視视試试壽寿獸兽樞枢輸输書书贖赎屬属術术樹树帥帅雙双誰谁稅税順顺說说碩硕爍烁絲丝飼饲鬆松聳耸頌颂訟讼誦诵蘇苏訴诉肅肃" +
+	"雖虽隨随歲岁孫孙損损筍笋縮缩瑣琐鎖锁獺獭撻挞態态攤摊貪贪癱瘫灘滩壇坛譚谭談谈嘆叹湯汤燙烫濤涛絛绦討讨騰腾謄誊銻锑題题體体" +
+	"屜屉條条貼贴鐵铁廳厅聽听烴烃銅铜統统頭头禿秃圖图塗涂團团頹颓蛻蜕脫脱鴕鸵馱驮駝驼橢椭窪洼襪袜彎弯灣湾頑顽萬万網网韋韦違违" +
+	"圍围為为濰潍維维葦苇偉伟偽伪緯纬謂谓衛卫溫温聞闻紋纹穩稳問问甕瓮撾挝蝸蜗渦涡窩窝臥卧嗚呜鎢钨烏乌誣诬無无蕪芜吳吴塢坞霧雾" +
+	"務务誤误錫锡犧牺襲袭習习銑铣戲戏細细蝦虾轄辖峽峡俠侠狹狭廈厦嚇吓鮮鲜纖纤鹹咸賢贤銜衔閑闲顯显險险現现獻献縣县餡馅羨羡憲宪" +
+	"線线廂厢鑲镶鄉乡詳详響响項项蕭萧囂嚣銷销曉晓嘯啸協协挾挟攜携脅胁諧谐寫写瀉泻謝谢鋅锌釁衅興兴洶汹鏽锈繡绣虛虚噓嘘須须許许" +
+	"敘叙緒绪續续軒轩懸悬選选癬癣絢绚學学勳勋詢询尋寻馴驯訓训訊讯遜逊壓压鴉鸦鴨鸭啞哑亞亚訝讶閹阉煙烟鹽盐嚴严顏颜閻阎豔艳艷艳" +
+	"厭厌硯砚彥彦諺谚驗验鴦鸯楊杨揚扬瘍疡陽阳癢痒養养樣样瑤瑶搖摇堯尧遙遥窯窑謠谣藥药爺爷頁页業业葉叶醫医銥铱頤颐遺遗儀仪蟻蚁" +
+	"藝艺億亿憶忆義义詣诣議议誼谊譯译異异繹绎蔭荫陰阴銀银飲饮隱隐櫻樱嬰婴鷹鹰應应纓缨瑩莹螢萤營营熒荧蠅蝇贏赢穎颖喲哟擁拥傭佣" +
+	"癰痈踴踊詠咏湧涌優优憂忧郵邮鈾铀猶犹遊游誘诱輿舆魚鱼漁渔娛娱與与嶼屿語语籲吁禦御獄狱譽誉預预馭驭鴛鸳淵渊轅辕園园員员圓圆" +
+	"緣缘遠远願愿約约躍跃鑰钥嶽岳粵粤悅悦閱阅雲云鄖郧勻匀隕陨運运蘊蕴醞酝暈晕韻韵雜杂災灾載载攢攒暫暂贊赞贓赃臟脏髒脏鑿凿棗枣" +
+	"竈灶責责擇择則则澤泽賊贼贈赠紮扎劄札軋轧鍘铡閘闸詐诈齋斋債债氈毡盞盏斬斩輾辗嶄崭棧栈戰战綻绽張张漲涨帳帐賬账脹胀趙赵蟄蛰" +
+	"轍辙鍺锗這这貞贞針针偵侦診诊鎮镇陣阵掙挣睜睁猙狞爭争幀帧鄭郑證证織织職职執执紙纸摯挚擲掷幟帜質质滯滞鐘钟終终種种腫肿眾众" +
+	"謅诌軸轴皺皱晝昼驟骤豬猪諸诸誅诛燭烛矚瞩囑嘱貯贮鑄铸築筑註注駐驻專专磚砖轉转賺赚樁桩莊庄裝装妝妆壯壮狀状錐锥贅赘墜坠綴缀" +
+	"諄谆準准濁浊茲兹資资漬渍蹤踪綜综總总縱纵鄒邹詛诅組组鑽钻腸肠嘗尝償偿沖冲雛雏蔥葱聰聪湊凑毀毁兇凶賽赛賤贱屍尸澀涩輩辈藉借" +
+	"巖岩侖仑僥侥儂侬儕侪傑杰佔占吶呐嘰叽噁恶惡恶嚨咙壩坝奧奥孃娘嫻娴寵宠岡冈嶺岭廬庐弒弑徵征戶户撐撑擋挡斂敛暱昵曖暧棟栋榦干" +
+	"氾泛淪沦漿浆潰溃煩烦牘牍猻狲瑪玛瘡疮睏困矯矫禎祯稟禀竇窦筧笕糰团罈坛羶膻脣唇臺台颱台檯台蒼苍薦荐虜虏蝨虱衊蔑袞衮覺觉訛讹" +
+	"詭诡諜谍譏讥豎竖蹕跸軌轨輯辑迴回週周醃腌釘钉銬铐鍛锻鏈链鐳镭閒闲闆板隸隶靜静韓韩頸颈顛颠颶飓饑饥髮发鬍胡魯鲁鯊鲨鴻鸿鶴鹤" +
+	"麵面黴霉鼴鼹齣出龜龟"
+
+// traditional 繁体 → 简体
+var traditional = func() map[rune]rune {
+	rs := []rune(traditionalPairs)
+	m := make(map[rune]rune, len(rs)/2)
+	for i := 0; i+1 < len(rs); i += 2 {
+		m[rs[i]] = rs[i+1]
+	}
+	return m
+}()
+
+// homoglyphs 形近字符 → 小写拉丁字母：西里尔/希腊字母常被用来替换拉丁字母，数字与符号则是 leet 写法
+var homoglyphs = map[rune]rune{
+	// 西里尔字母
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'һ': 'h', 'н': 'h', 'і': 'i', 'ј': 'j', 'к': 'k', 'м': 'm',
+	'о': 'o', 'р': 'p', 'с': 'c', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	// 希腊字母
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// leet
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
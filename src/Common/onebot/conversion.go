@@ -57,6 +57,8 @@ func V12ToInternal(v12Msg V12RawMessage) types.InternalMessage {
 		SubType:     v12Msg.SubType,
 		SenderName:  v12Msg.User.Nickname,
 		Extras:      extras,
+		TraceParent: v12Msg.TraceParent,
+		TraceState:  v12Msg.TraceState,
 	}
 }
 
@@ -81,6 +83,31 @@ func V11ToInternal(v11Msg V11RawMessage, platform string) types.InternalMessage
 		}
 	}
 
+	var extras map[string]any
+	if v11Msg.NoticeType != "" {
+		extras = map[string]any{"notice_type": v11Msg.NoticeType}
+		if v11Msg.CardAction != nil {
+			extras["card_action"] = v11Msg.CardAction
+		}
+	}
+	// 人工接管的会话：标记随消息进入 Worker 队列，下游据此跳过 AI 自动回复
+	if v11Msg.Handoff {
+		if extras == nil {
+			extras = make(map[string]any)
+		}
+		extras["handoff"] = true
+	}
+	// API 响应：保留 data 供等待 echo 的调用方读取
+	if len(v11Msg.Data) > 0 {
+		var data any
+		if err := json.Unmarshal(v11Msg.Data, &data); err == nil {
+			if extras == nil {
+				extras = make(map[string]any)
+			}
+			extras["data"] = data
+		}
+	}
+
 	return types.InternalMessage{
 		ID:          utils.ToString(v11Msg.MessageID),
 		Time:        timeToUnix(v11Msg.Time),
@@ -95,6 +122,13 @@ func V11ToInternal(v11Msg V11RawMessage, platform string) types.InternalMessage
 		RawMessage:  rawMessage,
 		SenderName:  v11Msg.Sender.Nickname,
 		SubType:     v11Msg.SubType,
+		Echo:        utils.ToString(v11Msg.Echo),
+		Status:      utils.ToString(v11Msg.Status),
+		Msg:         v11Msg.Msg,
+		Retcode:     int(utils.ToInt64(v11Msg.Retcode)),
+		Extras:      extras,
+		TraceParent: v11Msg.TraceParent,
+		TraceState:  v11Msg.TraceState,
 	}
 }
 
@@ -1,6 +1,7 @@
 package onebot
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -48,3 +49,43 @@ func TestConvertLegacyPlaceholders(t *testing.T) {
 		})
 	}
 }
+
+func TestV11ToInternalKeepsAPIResponse(t *testing.T) {
+	raw := V11RawMessage{
+		Status:  "failed",
+		Retcode: json.Number("100"),
+		Msg:     "group muted",
+		Echo:    "campaign|1|send_group_msg",
+		Data:    json.RawMessage(`{"message_id":42}`),
+	}
+	msg := V11ToInternal(raw, "qq")
+	if msg.Echo != "campaign|1|send_group_msg" || msg.Status != "failed" || msg.Retcode != 100 || msg.Msg != "group muted" {
+		t.Fatalf("response fields lost: %+v", msg)
+	}
+	data, ok := msg.Extras["data"].(map[string]any)
+	if !ok || data["message_id"] != float64(42) {
+		t.Fatalf("data = %#v", msg.Extras["data"])
+	}
+}
+
+func TestV11ToInternalKeepsHandoff(t *testing.T) {
+	var raw V11RawMessage
+	if err := json.Unmarshal([]byte(`{"post_type":"message","message_type":"private","user_id":"v1","message":"hi","self_id":"web","handoff":true}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+	msg := V11ToInternal(raw, "web")
+	if !msg.IsHandoff() {
+		t.Fatalf("handoff flag lost: %+v", msg.Extras)
+	}
+	// 转发给 Worker 的队列消息同样带上标记
+	queued, _ := json.Marshal(msg.ToV11Map())
+	var event Event
+	if err := json.Unmarshal(queued, &event); err != nil || !event.Handoff {
+		t.Fatalf("handoff flag should be forwarded to workers, got %s (%v)", queued, err)
+	}
+
+	raw.Handoff = false
+	if plain := V11ToInternal(raw, "web"); plain.IsHandoff() {
+		t.Fatal("messages without the flag should not be treated as handed off")
+	}
+}
@@ -1,6 +1,7 @@
 package onebot
 
 import (
+	"BotMatrix/common/types"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -56,32 +57,36 @@ func (f FlexibleInt64) MarshalJSON() ([]byte, error) {
 }
 
 type Event struct {
-	Time          int64         `json:"time"`
-	SelfID        FlexibleInt64 `json:"self_id"`
-	PostType      string        `json:"post_type"`
-	MessageType   string        `json:"message_type,omitempty"`
-	SubType       string        `json:"sub_type,omitempty"`
-	MessageID     FlexibleInt64 `json:"message_id,omitempty"`
-	UserID        FlexibleInt64 `json:"user_id,omitempty"`
-	GroupID       FlexibleInt64 `json:"group_id,omitempty"`
-	Dice          int           `json:"dice,omitempty"`
-	Anonymous     any           `json:"anonymous,omitempty"`
-	Message       any           `json:"message,omitempty"`
-	RawMessage    string        `json:"raw_message,omitempty"`
-	Font          int           `json:"font,omitempty"`
-	Sender        Sender        `json:"sender,omitempty"`
-	NoticeType    string        `json:"notice_type,omitempty"`
-	OperatorID    FlexibleInt64 `json:"operator_id,omitempty"`
-	File          File          `json:"file,omitempty"`
-	RequestType   string        `json:"request_type,omitempty"`
-	Flag          string        `json:"flag,omitempty"`
-	Comment       string        `json:"comment,omitempty"`
-	Approved      bool          `json:"approved,omitempty"`
-	MetaEventType string        `json:"meta_event_type,omitempty"`
-	EventName     string        `json:"event_name,omitempty"`
-	Platform      string        `json:"platform,omitempty"`
-	TargetUserID  string        `json:"target_user_id,omitempty"`
-	TargetGroupID string        `json:"target_group_id,omitempty"`
+	Time          int64             `json:"time"`
+	SelfID        FlexibleInt64     `json:"self_id"`
+	PostType      string            `json:"post_type"`
+	MessageType   string            `json:"message_type,omitempty"`
+	SubType       string            `json:"sub_type,omitempty"`
+	MessageID     FlexibleInt64     `json:"message_id,omitempty"`
+	UserID        FlexibleInt64     `json:"user_id,omitempty"`
+	GroupID       FlexibleInt64     `json:"group_id,omitempty"`
+	Dice          int               `json:"dice,omitempty"`
+	Anonymous     any               `json:"anonymous,omitempty"`
+	Message       any               `json:"message,omitempty"`
+	RawMessage    string            `json:"raw_message,omitempty"`
+	Font          int               `json:"font,omitempty"`
+	Sender        Sender            `json:"sender,omitempty"`
+	NoticeType    string            `json:"notice_type,omitempty"`
+	OperatorID    FlexibleInt64     `json:"operator_id,omitempty"`
+	File          File              `json:"file,omitempty"`
+	RequestType   string            `json:"request_type,omitempty"`
+	Flag          string            `json:"flag,omitempty"`
+	Comment       string            `json:"comment,omitempty"`
+	Approved      bool              `json:"approved,omitempty"`
+	MetaEventType string            `json:"meta_event_type,omitempty"`
+	EventName     string            `json:"event_name,omitempty"`
+	Platform      string            `json:"platform,omitempty"`
+	TargetUserID  string            `json:"target_user_id,omitempty"`
+	TargetGroupID string            `json:"target_group_id,omitempty"`
+	CardAction    *types.CardAction `json:"card_action,omitempty"`
+	Handoff       bool              `json:"handoff,omitempty"`     // 会话已转人工，不触发 AI 回复
+	TraceParent   string            `json:"traceparent,omitempty"` // W3C 追踪上下文，见 common/tracing
+	TraceState    string            `json:"tracestate,omitempty"`
 }
 
 func (e *Event) UnmarshalJSON(data []byte) error {
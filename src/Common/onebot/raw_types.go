@@ -1,6 +1,7 @@
 package onebot
 
 import (
+	"BotMatrix/common/types"
 	"encoding/json"
 )
 
@@ -8,27 +9,31 @@ import (
 
 // V11RawMessage represents a raw OneBot v11 message/event
 type V11RawMessage struct {
-	PostType      string          `json:"post_type"`
-	MessageType   string          `json:"message_type"`
-	MessageID     any             `json:"message_id"`
-	Time          any             `json:"time"`
-	SelfID        any             `json:"self_id"` // Can be string or int64
-	SubType       string          `json:"sub_type"`
-	UserID        any             `json:"user_id"`  // Can be string or int64
-	GroupID       any             `json:"group_id"` // Can be string or int64
-	Message       any             `json:"message"`  // Can be string or []MessageSegment
-	RawMessage    string          `json:"raw_message"`
-	Font          int             `json:"font"`
-	Sender        V11RawSender    `json:"sender"`
-	MetaEventType string          `json:"meta_event_type"`
-	NoticeType    string          `json:"notice_type"`
-	RequestType   string          `json:"request_type"`
-	Status        any             `json:"status"`
-	Retcode       any             `json:"retcode"`
-	Data          json.RawMessage `json:"data"`
-	Echo          any             `json:"echo"`
-	Msg           string          `json:"msg"`
-	Wording       string          `json:"wording"`
+	PostType      string            `json:"post_type"`
+	MessageType   string            `json:"message_type"`
+	MessageID     any               `json:"message_id"`
+	Time          any               `json:"time"`
+	SelfID        any               `json:"self_id"` // Can be string or int64
+	SubType       string            `json:"sub_type"`
+	UserID        any               `json:"user_id"`  // Can be string or int64
+	GroupID       any               `json:"group_id"` // Can be string or int64
+	Message       any               `json:"message"`  // Can be string or []MessageSegment
+	RawMessage    string            `json:"raw_message"`
+	Font          int               `json:"font"`
+	Sender        V11RawSender      `json:"sender"`
+	MetaEventType string            `json:"meta_event_type"`
+	NoticeType    string            `json:"notice_type"`
+	RequestType   string            `json:"request_type"`
+	Status        any               `json:"status"`
+	Retcode       any               `json:"retcode"`
+	Data          json.RawMessage   `json:"data"`
+	Echo          any               `json:"echo"`
+	Msg           string            `json:"msg"`
+	Wording       string            `json:"wording"`
+	CardAction    *types.CardAction `json:"card_action,omitempty"`
+	Handoff       bool              `json:"handoff,omitempty"` // 会话已转人工 (WebBot)
+	TraceParent   string            `json:"traceparent,omitempty"`
+	TraceState    string            `json:"tracestate,omitempty"`
 }
 
 // V11RawSender represents sender info in OneBot v11
@@ -68,6 +73,8 @@ type V12RawMessage struct {
 	Data          json.RawMessage `json:"data"`
 	Echo          any             `json:"echo"`
 	Msg           string          `json:"msg"`
+	TraceParent   string          `json:"traceparent,omitempty"`
+	TraceState    string          `json:"tracestate,omitempty"`
 }
 
 // V12RawUser represents user info in OneBot v12
@@ -23,6 +23,7 @@ type SendMessageParams struct {
 	ID          any    `json:"id,omitempty"`
 	Platform    string `json:"platform,omitempty"`
 	SelfID      string `json:"self_id,omitempty"`
+	TraceParent string `json:"traceparent,omitempty"` // 回复所属的追踪上下文，见 common/tracing
 }
 
 type DeleteMessageParams struct {
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"BotMatrix/common/metrics"
 	"BotMatrix/common/plugin/policy"
 )
 
@@ -56,6 +57,7 @@ func (pm *PluginManager) monitorPlugin(plugin *Plugin) {
 		_, err := os.FindProcess(plugin.Process.Pid)
 		if err != nil {
 			plugin.State = "crashed"
+			metrics.PluginCrashes.WithLabelValues(plugin.ID).Inc()
 			pm.restartPlugin(plugin)
 			return
 		}
@@ -77,6 +79,7 @@ func (pm *PluginManager) restartPlugin(plugin *Plugin) {
 
 	plugin.RestartCount++
 	plugin.LastRestart = time.Now()
+	metrics.PluginRestarts.WithLabelValues(plugin.ID).Inc()
 	pm.StartPlugin(plugin.ID, plugin.Config.Version)
 }
 
@@ -310,21 +313,26 @@ func (pm *PluginManager) DispatchEvent(event *EventMessage) {
 	}
 }
 
-// DispatchEventToPlugin routes an event to a specific plugin version
+// DispatchEventToPlugin routes an event to a specific plugin version.
+// An empty version selects the latest loaded version.
 func (pm *PluginManager) DispatchEventToPlugin(id string, version string, event *EventMessage) {
 	pm.mutex.Lock()
 	versions, ok := pm.plugins[id]
 	pm.mutex.Unlock()
 
-	if !ok {
+	if !ok || len(versions) == 0 {
 		return
 	}
 
 	var target *Plugin
-	for _, v := range versions {
-		if v.Config.Version == version {
-			target = v
-			break
+	if version == "" {
+		target = versions[len(versions)-1]
+	} else {
+		for _, v := range versions {
+			if v.Config.Version == version {
+				target = v
+				break
+			}
 		}
 	}
 
@@ -3,6 +3,8 @@ package policy
 var CenterActionWhitelist = map[string]bool{
 	"send_message":      true,
 	"send_notification": true,
+	"send_card":         true,
+	"update_card":       true,
 	"update_config":     true,
 	"restart_plugin":    true,
 	"stop_plugin":       true,
@@ -3,6 +3,8 @@ package policy
 var WorkerActionWhitelist = map[string]bool{
 	"send_message":      true,
 	"send_notification": true,
+	"send_card":         true,
+	"update_card":       true,
 	"record_data":       true,
 	"query_data":        true,
 	"storage.get":       true,
@@ -0,0 +1,169 @@
+package rbac
+
+import (
+	"sort"
+	"strings"
+)
+
+// Permission 权限标识，格式为 资源:操作
+type Permission string
+
+const (
+	SystemRead      Permission = "system:read"      // 查看系统状态、统计、日志
+	SystemManage    Permission = "system:manage"    // 清理日志、数据修复等维护操作
+	ConfigRead      Permission = "config:read"      // 查看系统配置与路由规则
+	ConfigWrite     Permission = "config:write"     // 修改系统配置、Redis 与路由规则
+	UsersManage     Permission = "users:manage"     // 管理后台用户
+	RBACManage      Permission = "rbac:manage"      // 管理角色与授权
+	APIKeysManage   Permission = "apikeys:manage"   // 管理服务账号与 API Key
+	BotsRead        Permission = "bots:read"        // 查看机器人及其联系人
+	BotsManage      Permission = "bots:manage"      // 配置机器人、群组与成员
+	MessagesRead    Permission = "messages:read"    // 查看消息记录
+	MessagesSend    Permission = "messages:send"    // 通过机器人发送消息
+	DockerManage    Permission = "docker:manage"    // 管理 Docker 容器
+	PluginsRead     Permission = "plugins:read"     // 查看插件
+	PluginsInstall  Permission = "plugins:install"  // 安装与删除插件
+	PluginsManage   Permission = "plugins:manage"   // 启停与重载插件
+	KnowledgeRead   Permission = "knowledge:read"   // 检索知识库
+	KnowledgeWrite  Permission = "knowledge:write"  // 上传与同步知识库文档
+	TasksRead       Permission = "tasks:read"       // 查看任务与执行记录
+	TasksCreate     Permission = "tasks:create"     // 创建与修改任务
+	AIRead          Permission = "ai:read"          // 查看模型、智能体与记忆
+	AIManage        Permission = "ai:manage"        // 管理模型、智能体与记忆
+	AuditRead       Permission = "audit:read"       // 查看工具调用审计
+	AuditApprove    Permission = "audit:approve"    // 审批高风险工具调用
+	EmployeesRead   Permission = "employees:read"   // 查看数字员工、部门与实验
+	EmployeesManage Permission = "employees:manage" // 管理数字员工、部门与实验
+	IdentitiesRead  Permission = "identities:read"  // 查看跨平台身份
+	IdentitiesWrite Permission = "identities:write" // 合并、拆分与编辑身份
+	ShadowManage    Permission = "shadow:manage"    // 管理影子执行规则
+	FissionRead     Permission = "fission:read"     // 查看裂变活动与统计
+	FissionManage   Permission = "fission:manage"   // 配置裂变活动
+
+	// All 匹配全部权限
+	All Permission = "*"
+)
+
+// PermissionInfo 权限目录项，供前端展示
+type PermissionInfo struct {
+	Permission  Permission `json:"permission"`
+	Description string     `json:"description"`
+}
+
+// Catalog 全部已知权限
+var Catalog = []PermissionInfo{
+	{SystemRead, "查看系统状态、统计、日志"},
+	{SystemManage, "清理日志、数据修复等维护操作"},
+	{ConfigRead, "查看系统配置与路由规则"},
+	{ConfigWrite, "修改系统配置、Redis 与路由规则"},
+	{UsersManage, "管理后台用户"},
+	{RBACManage, "管理角色与授权"},
+	{APIKeysManage, "管理服务账号与 API Key"},
+	{BotsRead, "查看机器人及其联系人"},
+	{BotsManage, "配置机器人、群组与成员"},
+	{MessagesRead, "查看消息记录"},
+	{MessagesSend, "通过机器人发送消息"},
+	{DockerManage, "管理 Docker 容器"},
+	{PluginsRead, "查看插件"},
+	{PluginsInstall, "安装与删除插件"},
+	{PluginsManage, "启停与重载插件"},
+	{KnowledgeRead, "检索知识库"},
+	{KnowledgeWrite, "上传与同步知识库文档"},
+	{TasksRead, "查看任务与执行记录"},
+	{TasksCreate, "创建与修改任务"},
+	{AIRead, "查看模型、智能体与记忆"},
+	{AIManage, "管理模型、智能体与记忆"},
+	{AuditRead, "查看工具调用审计"},
+	{AuditApprove, "审批高风险工具调用"},
+	{EmployeesRead, "查看数字员工、部门与实验"},
+	{EmployeesManage, "管理数字员工、部门与实验"},
+	{IdentitiesRead, "查看跨平台身份"},
+	{IdentitiesWrite, "合并、拆分与编辑身份"},
+	{ShadowManage, "管理影子执行规则"},
+	{FissionRead, "查看裂变活动与统计"},
+	{FissionManage, "配置裂变活动"},
+}
+
+// Role 角色：一组权限
+type Role struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"built_in"`
+}
+
+// 内置角色，不可修改或删除
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+// BuiltinRoles 内置角色定义。operator 面向客户运维人员，通常限定在其自有机器人或企业范围内授予
+var BuiltinRoles = []Role{
+	{Name: RoleAdmin, Description: "全部权限", Permissions: []Permission{All}, BuiltIn: true},
+	{Name: RoleOperator, Description: "运维自有机器人：消息、插件、知识库与任务", BuiltIn: true, Permissions: []Permission{
+		BotsRead, BotsManage, MessagesRead, MessagesSend,
+		PluginsRead, PluginsInstall, PluginsManage,
+		KnowledgeRead, KnowledgeWrite, TasksRead, TasksCreate,
+		AuditRead, AuditApprove,
+	}},
+	{Name: RoleViewer, Description: "只读访问", Permissions: []Permission{"*:read"}, BuiltIn: true},
+}
+
+func builtinRole(name string) (Role, bool) {
+	for _, r := range BuiltinRoles {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Role{}, false
+}
+
+// Match 判断授予的权限 (可含通配) 是否覆盖所需权限。
+// 支持 * (全部)、bots:* (某资源的全部操作) 与 *:read (全部资源的某操作)
+func (p Permission) Match(required Permission) bool {
+	if p == All || p == required {
+		return true
+	}
+	gr, ga, ok := strings.Cut(string(p), ":")
+	if !ok {
+		return false
+	}
+	rr, ra, ok := strings.Cut(string(required), ":")
+	if !ok {
+		return false
+	}
+	return (gr == "*" || gr == rr) && (ga == "*" || ga == ra)
+}
+
+// Valid 判断权限是否为已知权限或合法通配
+func (p Permission) Valid() bool {
+	if p == All {
+		return true
+	}
+	for _, info := range Catalog {
+		if p.Match(info.Permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// expand 将通配权限展开为目录中的具体权限，结果已排序
+func expand(perms []Permission) []Permission {
+	set := make(map[Permission]bool)
+	for _, p := range perms {
+		for _, info := range Catalog {
+			if p.Match(info.Permission) {
+				set[info.Permission] = true
+			}
+		}
+	}
+	out := make([]Permission, 0, len(set))
+	for p := range set {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
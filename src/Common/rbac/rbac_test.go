@@ -0,0 +1,244 @@
+package rbac
+
+import (
+	"BotMatrix/common/models"
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}, &models.RBACRole{}, &models.RBACRoleBinding{}, &models.DigitalEmployee{}, &models.GroupCache{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&models.User{ID: 1, Username: "operator", PasswordHash: "x"})
+	db.Create(&models.User{ID: 2, Username: "auditor", PasswordHash: "x"})
+	return NewService(db), db
+}
+
+func TestPermissionMatch(t *testing.T) {
+	cases := []struct {
+		grant, required Permission
+		want            bool
+	}{
+		{All, DockerManage, true},
+		{"bots:*", BotsManage, true},
+		{"bots:*", PluginsRead, false},
+		{"*:read", PluginsRead, true},
+		{"*:read", PluginsInstall, false},
+		{BotsRead, BotsRead, true},
+		{BotsRead, BotsManage, false},
+	}
+	for _, c := range cases {
+		if got := c.grant.Match(c.required); got != c.want {
+			t.Errorf("%s.Match(%s) = %v, want %v", c.grant, c.required, got, c.want)
+		}
+	}
+	if Permission("bots:fly").Valid() || !Permission("bots:*").Valid() {
+		t.Fatal("unexpected permission validity")
+	}
+}
+
+func TestScopedAuthorization(t *testing.T) {
+	svc, db := newTestService(t)
+	db.Create(&models.DigitalEmployee{ID: 1, EnterpriseID: 7, BotID: "bot-ent", EmployeeID: "E1", Name: "客服"})
+	db.Create(&models.GroupCache{GroupID: "g1", BotID: "bot-a"})
+
+	if _, err := svc.Bind(1, RoleOperator, Scope{Type: ScopeBot, ID: "bot-a"}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Bind(1, RoleOperator, Scope{Type: ScopeEnterprise, ID: "7"}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	a, err := svc.Authorize(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		perm  Permission
+		scope Scope
+		want  bool
+	}{
+		{BotsManage, Scope{Type: ScopeBot, ID: "bot-a"}, true},
+		{BotsManage, Scope{Type: ScopeBot, ID: "bot-b"}, false},
+		{BotsManage, Scope{Type: ScopeGroup, ID: "g1"}, true},    // 群组属于 bot-a
+		{BotsManage, Scope{Type: ScopeBot, ID: "bot-ent"}, true}, // 机器人属于企业 7
+		{BotsManage, Global, false},
+		{DockerManage, Scope{Type: ScopeBot, ID: "bot-a"}, false},
+	}
+	for _, c := range checks {
+		if got := a.Allowed(c.perm, c.scope); got != c.want {
+			t.Errorf("Allowed(%s, %s) = %v, want %v", c.perm, c.scope, got, c.want)
+		}
+	}
+	if !a.AllowedAnywhere(BotsRead) || a.AllowedAnywhere(DockerManage) {
+		t.Fatal("unexpected AllowedAnywhere result")
+	}
+	if a.AllowedAll(BotsManage, []Scope{{Type: ScopeBot, ID: "bot-a"}, {Type: ScopeBot, ID: "bot-b"}}) {
+		t.Fatal("all scopes must be covered")
+	}
+
+	admin, _ := svc.Authorize(99, true)
+	if !admin.Allowed(DockerManage, Global) {
+		t.Fatal("admins hold every permission")
+	}
+}
+
+func TestCustomRolesAndEffective(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	if err := svc.SaveRole(Role{Name: RoleAdmin, Permissions: []Permission{All}}); err == nil {
+		t.Fatal("built-in roles must be read-only")
+	}
+	if err := svc.SaveRole(Role{Name: "auditor", Permissions: []Permission{"bots:fly"}}); err == nil {
+		t.Fatal("unknown permissions must be rejected")
+	}
+	if err := svc.SaveRole(Role{Name: "auditor", Description: "审计", Permissions: []Permission{AuditRead, "bots:*"}}); err != nil {
+		t.Fatal(err)
+	}
+	binding, err := svc.Bind(2, "auditor", Global, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Bind(2, RoleViewer, Scope{Type: ScopeBot, ID: "bot-a"}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Bind(2, "missing", Global, "admin"); err == nil {
+		t.Fatal("binding an unknown role must fail")
+	}
+
+	a, _ := svc.Authorize(2, false)
+	effective := make(map[Permission][]Scope)
+	for _, p := range a.Effective() {
+		effective[p.Permission] = p.Scopes
+	}
+	if s := effective[BotsManage]; len(s) != 1 || s[0] != Global {
+		t.Fatalf("bots:manage scopes = %v", s)
+	}
+	if s := effective[PluginsRead]; len(s) != 1 || s[0].ID != "bot-a" {
+		t.Fatalf("plugins:read scopes = %v", s)
+	}
+	if _, ok := effective[DockerManage]; ok {
+		t.Fatal("docker:manage must not be granted")
+	}
+
+	// 更新角色后缓存立即失效
+	if err := svc.SaveRole(Role{Name: "auditor", Permissions: []Permission{AuditRead}}); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := svc.Authorize(2, false); a.Allowed(BotsManage, Global) {
+		t.Fatal("stale permissions after role update")
+	}
+	if err := svc.Unbind(binding.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteRole("auditor"); err != nil {
+		t.Fatal(err)
+	}
+	if bindings, _ := svc.Bindings(2); len(bindings) != 1 {
+		t.Fatalf("bindings = %+v", bindings)
+	}
+}
+
+func TestScopesFromRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/admin/batch_send?group_id=g1", bytes.NewBufferString(`{"bot_id":"bot-a","enterprise_id":7}`))
+	r.Header.Set("Content-Type", "application/json")
+	scopes, err := ScopesFromRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Scope{{ScopeBot, "bot-a"}, {ScopeGroup, "g1"}, {ScopeEnterprise, "7"}}
+	if !reflect.DeepEqual(scopes, want) {
+		t.Fatalf("scopes = %v", scopes)
+	}
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != `{"bot_id":"bot-a","enterprise_id":7}` {
+		t.Fatalf("body not restored: %q", body)
+	}
+
+	if scopes, err := ScopesFromRequest(httptest.NewRequest("GET", "/api/admin/docker/list", nil)); scopes != nil || err != nil {
+		t.Fatalf("scopes = %v, err = %v", scopes, err)
+	}
+}
+
+func TestScopesFromRequestBodyAndQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		want        []Scope
+		wantErr     error
+	}{
+		{name: "query and body agree", target: "/x?bot_id=A", contentType: "application/json", body: `{"bot_id":"A"}`, want: []Scope{{ScopeBot, "A"}}},
+		{name: "query and body disagree", target: "/x?bot_id=A", contentType: "application/json", body: `{"bot_id":"B"}`, wantErr: ErrScopeConflict},
+		{name: "numeric body conflicts with query", target: "/x?self_id=1", body: `{"self_id":2}`, wantErr: ErrScopeConflict},
+		{name: "json body without json content type", target: "/x", contentType: "text/plain", body: ` {"bot_id":"B"}`, want: []Scope{{ScopeBot, "B"}}},
+		{name: "large numeric id kept exact", target: "/x", body: `{"self_id":9007199254740993}`, want: []Scope{{ScopeBot, "9007199254740993"}}},
+		{name: "bot_id and self_id both checked", target: "/x?self_id=S", contentType: "application/json", body: `{"bot_id":"B"}`, want: []Scope{{ScopeBot, "B"}, {ScopeBot, "S"}}},
+		{name: "same bot_id and self_id", target: "/x?bot_id=B&self_id=B", want: []Scope{{ScopeBot, "B"}}},
+		{name: "form body", target: "/x?bot_id=A", contentType: "application/x-www-form-urlencoded", body: "bot_id=B", wantErr: ErrScopeConflict},
+		{name: "malformed json", target: "/x", contentType: "application/json", body: `{"bot_id":`, wantErr: ErrScopeBody},
+		{name: "non json body ignored", target: "/x?group_id=g", contentType: "text/plain", body: "hello", want: []Scope{{ScopeGroup, "g"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			scopes, err := ScopesFromRequest(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(scopes, tt.want) {
+				t.Fatalf("scopes = %v, want %v", scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopesFromRequestLargeBody(t *testing.T) {
+	// 超过上限的 JSON 请求体无法确定范围，拒绝而不是截断
+	large := `{"bot_id":"B","data":"` + strings.Repeat("x", maxScopeBody) + `"}`
+	r := httptest.NewRequest("POST", "/x", strings.NewReader(large))
+	if _, err := ScopesFromRequest(r); !errors.Is(err, ErrScopeBody) {
+		t.Fatalf("err = %v, want ErrScopeBody", err)
+	}
+
+	// 非 JSON 的大请求体 (如上传) 完整还原
+	upload := strings.Repeat("y", maxScopeBody+100)
+	r = httptest.NewRequest("POST", "/x?bot_id=A", strings.NewReader(upload))
+	r.Header.Set("Content-Type", "application/octet-stream")
+	scopes, err := ScopesFromRequest(r)
+	if err != nil || len(scopes) != 1 {
+		t.Fatalf("scopes = %v, err = %v", scopes, err)
+	}
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != upload {
+		t.Fatalf("body truncated to %d bytes", len(body))
+	}
+}
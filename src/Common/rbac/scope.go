@@ -0,0 +1,202 @@
+package rbac
+
+import (
+	"BotMatrix/common/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 授权范围类型
+const (
+	ScopeGlobal     = "global"
+	ScopeBot        = "bot"
+	ScopeGroup      = "group"
+	ScopeEnterprise = "enterprise"
+)
+
+// Scope 授权范围。企业包含其数字员工关联的机器人，机器人包含其所在的群组
+type Scope struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+}
+
+// Global 全局范围
+var Global = Scope{Type: ScopeGlobal}
+
+func (s Scope) String() string {
+	if s.Type == ScopeGlobal || s.Type == "" {
+		return ScopeGlobal
+	}
+	return s.Type + ":" + s.ID
+}
+
+// Validate 检查范围类型与 ID
+func (s Scope) Validate() error {
+	switch s.Type {
+	case ScopeGlobal:
+		if s.ID != "" {
+			return fmt.Errorf("global scope must not have an id")
+		}
+	case ScopeBot, ScopeGroup, ScopeEnterprise:
+		if s.ID == "" {
+			return fmt.Errorf("%s scope requires an id", s.Type)
+		}
+	default:
+		return fmt.Errorf("unknown scope type %q", s.Type)
+	}
+	return nil
+}
+
+// Resolver 解析资源之间的归属关系
+type Resolver interface {
+	// BotEnterprise 返回机器人所属企业 ID
+	BotEnterprise(botID string) (string, bool)
+	// GroupBots 返回所在群组的机器人
+	GroupBots(groupID string) []string
+}
+
+// DBResolver 通过数字员工 (DigitalEmployee.BotId → EnterpriseId) 与群缓存 (GroupCache) 解析归属
+type DBResolver struct {
+	DB *gorm.DB
+}
+
+func (r DBResolver) BotEnterprise(botID string) (string, bool) {
+	var emp models.DigitalEmployee
+	if err := r.DB.Select("EnterpriseId").Where("BotId = ?", botID).Limit(1).Find(&emp).Error; err != nil || emp.EnterpriseID == 0 {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(emp.EnterpriseID), 10), true
+}
+
+func (r DBResolver) GroupBots(groupID string) []string {
+	var bots []string
+	r.DB.Model(&models.GroupCache{}).Where("GroupId = ?", groupID).Distinct().Pluck("BotId", &bots)
+	return bots
+}
+
+// maxScopeBody 解析请求体中范围字段时读取的最大字节数
+const maxScopeBody = 1 << 20
+
+// scopeKeys 请求中表示资源范围的字段
+var scopeKeys = []string{"bot_id", "self_id", "group_id", "enterprise_id"}
+
+var (
+	// ErrScopeConflict 查询参数与请求体中的范围字段不一致
+	ErrScopeConflict = errors.New("scope parameters in query and body disagree")
+	// ErrScopeBody 请求体过大或无法解析，无法确定请求涉及的范围
+	ErrScopeBody = errors.New("request body scope cannot be determined")
+)
+
+// replayBody 先返回已读取的部分，再继续读取原始请求体
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// ScopesFromRequest 从查询参数与请求体中提取请求涉及的资源范围
+// (bot_id/self_id、group_id、enterprise_id)，请求体读取后会被完整还原。
+// 请求体不论 Content-Type，以 { 开头即按 JSON 解析 (处理函数同样如此解码)，表单按 urlencoded 解析；
+// 同一字段在查询参数与请求体中取值不同时返回 ErrScopeConflict，JSON/表单请求体过大或格式错误时返回 ErrScopeBody。
+// bot_id 与 self_id 同时出现且不同时两个范围都需校验。没有任何范围字段时返回 nil
+func ScopesFromRequest(r *http.Request) ([]Scope, error) {
+	values := make(map[string]string)
+	q := r.URL.Query()
+	for _, key := range scopeKeys {
+		if v := q.Get(key); v != "" {
+			values[key] = v
+		}
+	}
+
+	body, err := bodyScopeValues(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range scopeKeys {
+		v, ok := body[key]
+		if !ok {
+			continue
+		}
+		if qv, ok := values[key]; ok && qv != v {
+			return nil, fmt.Errorf("%w: %s", ErrScopeConflict, key)
+		}
+		values[key] = v
+	}
+
+	var scopes []Scope
+	if v, ok := values["bot_id"]; ok {
+		scopes = append(scopes, Scope{Type: ScopeBot, ID: v})
+	}
+	if v, ok := values["self_id"]; ok && v != values["bot_id"] {
+		scopes = append(scopes, Scope{Type: ScopeBot, ID: v})
+	}
+	if v, ok := values["group_id"]; ok {
+		scopes = append(scopes, Scope{Type: ScopeGroup, ID: v})
+	}
+	if v, ok := values["enterprise_id"]; ok {
+		scopes = append(scopes, Scope{Type: ScopeEnterprise, ID: v})
+	}
+	return scopes, nil
+}
+
+// bodyScopeValues 读取请求体中的范围字段并还原请求体
+func bodyScopeValues(r *http.Request) (map[string]string, error) {
+	if r.Body == nil || r.Body == http.NoBody || r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return nil, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxScopeBody+1))
+	r.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScopeBody, err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	isJSON := bytes.HasPrefix(trimmed, []byte("{"))
+	isForm := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	if !isJSON && !isForm {
+		return nil, nil
+	}
+	if len(data) > maxScopeBody {
+		return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrScopeBody, maxScopeBody)
+	}
+
+	values := make(map[string]string)
+	if isForm && !isJSON {
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrScopeBody, err)
+		}
+		for _, key := range scopeKeys {
+			if v := form.Get(key); v != "" {
+				values[key] = v
+			}
+		}
+		return values, nil
+	}
+
+	var body map[string]any
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScopeBody, err)
+	}
+	for _, key := range scopeKeys {
+		switch v := body[key].(type) {
+		case string:
+			if v != "" {
+				values[key] = v
+			}
+		case json.Number:
+			values[key] = v.String()
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,401 @@
+package rbac
+
+import (
+	"BotMatrix/common/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// cacheTTL 用户有效权限的缓存时长；本进程内的授权变更会立即失效缓存
+const cacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_\-]{1,63}$`)
+
+// ErrRoleNotFound 角色不存在
+var ErrRoleNotFound = errors.New("role not found")
+
+// Grant 一条生效的授权：某个角色在某个范围内授予的权限
+type Grant struct {
+	Permission Permission `json:"permission"`
+	Scope      Scope      `json:"scope"`
+	Role       string     `json:"role"`
+}
+
+// Service 角色与授权管理
+type Service struct {
+	db       *gorm.DB
+	resolver Resolver
+
+	mu    sync.Mutex
+	cache map[uint]cachedGrants
+}
+
+type cachedGrants struct {
+	grants  []Grant
+	expires time.Time
+}
+
+// NewService 创建授权服务，使用数据库解析机器人、群组与企业的归属关系
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, resolver: DBResolver{DB: db}, cache: make(map[uint]cachedGrants)}
+}
+
+// SetResolver 替换归属关系解析器
+func (s *Service) SetResolver(r Resolver) {
+	s.resolver = r
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[uint]cachedGrants)
+	s.mu.Unlock()
+}
+
+// Roles 返回内置角色与自定义角色
+func (s *Service) Roles() ([]Role, error) {
+	roles := append([]Role(nil), BuiltinRoles...)
+	var custom []models.RBACRole
+	if err := s.db.Order("name").Find(&custom).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range custom {
+		roles = append(roles, toRole(c))
+	}
+	return roles, nil
+}
+
+// Role 按名称获取角色
+func (s *Service) Role(name string) (Role, error) {
+	if r, ok := builtinRole(name); ok {
+		return r, nil
+	}
+	var row models.RBACRole
+	if err := s.db.Where("name = ?", name).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Role{}, ErrRoleNotFound
+		}
+		return Role{}, err
+	}
+	return toRole(row), nil
+}
+
+func toRole(row models.RBACRole) Role {
+	var perms []Permission
+	json.Unmarshal([]byte(row.Permissions), &perms)
+	return Role{Name: row.Name, Description: row.Description, Permissions: perms}
+}
+
+// SaveRole 创建或更新自定义角色
+func (s *Service) SaveRole(role Role) error {
+	if _, ok := builtinRole(role.Name); ok {
+		return fmt.Errorf("built-in role %q cannot be modified", role.Name)
+	}
+	if !roleNamePattern.MatchString(role.Name) {
+		return fmt.Errorf("invalid role name %q", role.Name)
+	}
+	if len(role.Permissions) == 0 {
+		return fmt.Errorf("role %q has no permissions", role.Name)
+	}
+	for _, p := range role.Permissions {
+		if !p.Valid() {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	data, _ := json.Marshal(role.Permissions)
+
+	var row models.RBACRole
+	err := s.db.Where("name = ?", role.Name).First(&row).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		row = models.RBACRole{Name: role.Name, Description: role.Description, Permissions: string(data)}
+		err = s.db.Create(&row).Error
+	case err == nil:
+		err = s.db.Model(&row).Updates(map[string]any{"description": role.Description, "permissions": string(data)}).Error
+	}
+	if err == nil {
+		s.invalidate()
+	}
+	return err
+}
+
+// DeleteRole 删除自定义角色及其全部授权
+func (s *Service) DeleteRole(name string) error {
+	if _, ok := builtinRole(name); ok {
+		return fmt.Errorf("built-in role %q cannot be deleted", name)
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("name = ?", name).Delete(&models.RBACRole{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRoleNotFound
+		}
+		return tx.Where("role = ?", name).Delete(&models.RBACRoleBinding{}).Error
+	})
+	if err == nil {
+		s.invalidate()
+	}
+	return err
+}
+
+// Bind 在指定范围内将角色授予用户，重复授权直接返回已有记录
+func (s *Service) Bind(userID uint, role string, scope Scope, createdBy string) (*models.RBACRoleBinding, error) {
+	if scope.Type == "" {
+		scope = Global
+	}
+	if err := scope.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.Role(role); err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.db.Select("id").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+
+	binding := models.RBACRoleBinding{UserID: userID, Role: role, ScopeType: scope.Type, ScopeID: scope.ID}
+	var existing models.RBACRoleBinding
+	if err := s.db.Where(&binding).First(&existing).Error; err == nil {
+		return &existing, nil
+	}
+	binding.CreatedBy = createdBy
+	if err := s.db.Create(&binding).Error; err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return &binding, nil
+}
+
+// Unbind 撤销一条授权
+func (s *Service) Unbind(id uint) error {
+	res := s.db.Delete(&models.RBACRoleBinding{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("binding %d not found", id)
+	}
+	s.invalidate()
+	return nil
+}
+
+// Bindings 列出用户的授权，userID 为 0 时列出全部
+func (s *Service) Bindings(userID uint) ([]models.RBACRoleBinding, error) {
+	var bindings []models.RBACRoleBinding
+	q := s.db.Order("id")
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	err := q.Find(&bindings).Error
+	return bindings, err
+}
+
+// Authorize 计算用户的有效授权。isAdmin 为兼容原有的管理员标记，视为全局 admin 角色
+func (s *Service) Authorize(userID uint, isAdmin bool) (*Authorization, error) {
+	a := &Authorization{UserID: userID, Admin: isAdmin, resolver: s.resolver}
+	if isAdmin {
+		return a, nil
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		a.Grants = cached.grants
+		return a, nil
+	}
+
+	bindings, err := s.Bindings(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]Role)
+	for _, b := range bindings {
+		role, ok := roles[b.Role]
+		if !ok {
+			if role, err = s.Role(b.Role); err != nil {
+				if errors.Is(err, ErrRoleNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			roles[b.Role] = role
+		}
+		for _, p := range role.Permissions {
+			a.Grants = append(a.Grants, Grant{Permission: p, Scope: Scope{Type: b.ScopeType, ID: b.ScopeID}, Role: b.Role})
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[userID] = cachedGrants{grants: a.Grants, expires: time.Now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return a, nil
+}
+
+// Authorization 某个用户的有效授权
+type Authorization struct {
+	UserID   uint
+	Admin    bool
+	Grants   []Grant
+	Limit    []Permission // 权限上限 (如 API Key 的 scopes)，为空时不限制
+	resolver Resolver
+}
+
+// limited 判断权限是否超出上限
+func (a *Authorization) limited(p Permission) bool {
+	if len(a.Limit) == 0 {
+		return false
+	}
+	for _, l := range a.Limit {
+		if l.Match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allowed 判断是否在指定范围内拥有权限。全局授权覆盖所有范围，
+// 企业授权覆盖其机器人，机器人授权覆盖其所在群组
+func (a *Authorization) Allowed(p Permission, scope Scope) bool {
+	if a == nil || a.limited(p) {
+		return false
+	}
+	if a.Admin {
+		return true
+	}
+	for _, g := range a.Grants {
+		if g.Permission.Match(p) && a.covers(g.Scope, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedAll 判断是否在全部给定范围内拥有权限，scopes 为空时要求全局授权
+func (a *Authorization) AllowedAll(p Permission, scopes []Scope) bool {
+	if len(scopes) == 0 {
+		return a.Allowed(p, Global)
+	}
+	for _, s := range scopes {
+		if !a.Allowed(p, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowedAnywhere 判断是否在任意范围内拥有权限，用于按范围过滤结果的列表接口
+func (a *Authorization) AllowedAnywhere(p Permission) bool {
+	if a == nil || a.limited(p) {
+		return false
+	}
+	if a.Admin {
+		return true
+	}
+	for _, g := range a.Grants {
+		if g.Permission.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authorization) covers(grant, target Scope) bool {
+	if grant.Type == ScopeGlobal || grant.Type == "" {
+		return true
+	}
+	if grant == target {
+		return true
+	}
+	if a.resolver == nil {
+		return false
+	}
+	switch target.Type {
+	case ScopeBot:
+		if grant.Type == ScopeEnterprise {
+			ent, ok := a.resolver.BotEnterprise(target.ID)
+			return ok && ent == grant.ID
+		}
+	case ScopeGroup:
+		for _, bot := range a.resolver.GroupBots(target.ID) {
+			if a.covers(grant, Scope{Type: ScopeBot, ID: bot}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ScopedPermission 有效权限及其生效范围
+type ScopedPermission struct {
+	Permission Permission `json:"permission"`
+	Scopes     []Scope    `json:"scopes"`
+}
+
+// Effective 按权限汇总生效范围 (通配已展开)，供前端决定展示哪些功能
+func (a *Authorization) Effective() []ScopedPermission {
+	if a.Admin {
+		out := make([]ScopedPermission, 0, len(Catalog))
+		for _, info := range Catalog {
+			if !a.limited(info.Permission) {
+				out = append(out, ScopedPermission{Permission: info.Permission, Scopes: []Scope{Global}})
+			}
+		}
+		return out
+	}
+	byPerm := make(map[Permission][]Scope)
+	for _, g := range a.Grants {
+		for _, p := range expand([]Permission{g.Permission}) {
+			if a.limited(p) {
+				continue
+			}
+			scopes := byPerm[p]
+			if containsScope(scopes, Global) {
+				continue
+			}
+			if g.Scope.Type == ScopeGlobal {
+				byPerm[p] = []Scope{Global}
+			} else if !containsScope(scopes, g.Scope) {
+				byPerm[p] = append(scopes, g.Scope)
+			}
+		}
+	}
+	out := make([]ScopedPermission, 0, len(byPerm))
+	for p, scopes := range byPerm {
+		out = append(out, ScopedPermission{Permission: p, Scopes: scopes})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Permission < out[j].Permission })
+	return out
+}
+
+func containsScope(scopes []Scope, s Scope) bool {
+	for _, x := range scopes {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithAuthorization 将有效授权存入 context，供处理函数按范围过滤数据
+func WithAuthorization(ctx context.Context, a *Authorization) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+// FromContext 读取请求的有效授权，未经过权限中间件时返回 nil
+func FromContext(ctx context.Context) *Authorization {
+	a, _ := ctx.Value(contextKey{}).(*Authorization)
+	return a
+}
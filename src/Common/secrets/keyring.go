@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 主密钥来源：BOTMATRIX_MASTER_KEY 直接给出密钥，BOTMATRIX_MASTER_KEY_FILE 或配置项 secrets_master_key_file 指向密钥文件
+const (
+	EnvMasterKey     = "BOTMATRIX_MASTER_KEY"
+	EnvMasterKeyFile = "BOTMATRIX_MASTER_KEY_FILE"
+)
+
+// ErrNoMasterKey 未配置主密钥
+var ErrNoMasterKey = errors.New("secrets: no master key configured")
+
+// Keyring 主密钥集合。第一个密钥为主密钥，用于加密新的数据密钥；
+// 其余为轮换前的旧密钥，只用于解密尚未重新加密的记录
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// NewKeyring 由 32 字节的 AES-256 密钥创建密钥集合，第一个为主密钥
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoMasterKey
+	}
+	k := &Keyring{keys: make(map[string][]byte, len(keys))}
+	for i, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("secrets: master key #%d must be 32 bytes, got %d", i+1, len(key))
+		}
+		id := KeyID(key)
+		if i == 0 {
+			k.primary = id
+		}
+		k.keys[id] = append([]byte(nil), key...)
+	}
+	return k, nil
+}
+
+// ParseKeyring 解析以逗号或换行分隔的 base64 (或 64 位十六进制) 密钥，# 开头的行为注释
+func ParseKeyring(s string) (*Keyring, error) {
+	var keys [][]byte
+	for _, line := range strings.Split(strings.ReplaceAll(s, ",", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := decodeKey(line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+// LoadKeyring 依次从环境变量 BOTMATRIX_MASTER_KEY、BOTMATRIX_MASTER_KEY_FILE 与 file 加载主密钥，都未配置时返回 ErrNoMasterKey
+func LoadKeyring(file string) (*Keyring, error) {
+	if v := os.Getenv(EnvMasterKey); v != "" {
+		return ParseKeyring(v)
+	}
+	if v := os.Getenv(EnvMasterKeyFile); v != "" {
+		file = v
+	}
+	if file == "" {
+		return nil, ErrNoMasterKey
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: read master key file: %w", err)
+	}
+	return ParseKeyring(string(data))
+}
+
+// GenerateKey 生成新的 base64 主密钥
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// KeyID 返回密钥指纹，记录在密文旁用于轮换时定位主密钥
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// PrimaryID 返回主密钥指纹
+func (k *Keyring) PrimaryID() string {
+	return k.primary
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("secrets: master key %s is not loaded", id)
+	}
+	return key, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	if len(s) == 64 {
+		if key, err := hex.DecodeString(s); err == nil {
+			return key, nil
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: master key must be base64 or hex: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,155 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"BotMatrix/common/models"
+
+	"gorm.io/gorm"
+)
+
+// Finding 迁移时发现的一处明文密钥
+type Finding struct {
+	Table  string `json:"table"`
+	Column string `json:"column"` // 平台账号配置为 Config.<字段名>
+	RowID  uint   `json:"row_id"`
+	Name   string `json:"name"` // 写入存储时使用的密钥名
+}
+
+// plaintextColumn 保存明文密钥的列
+type plaintextColumn struct {
+	model  any
+	table  string
+	column string
+	name   func(id uint) string
+}
+
+var plaintextColumns = []plaintextColumn{
+	{&models.AIProvider{}, "AIProvider", "ApiKey", func(id uint) string { return fmt.Sprintf("ai/provider-%d", id) }},
+	{&models.AIModel{}, "AIModel", "ApiKey", func(id uint) string { return fmt.Sprintf("ai/model-%d", id) }},
+	{&models.MCPServer{}, "MCPServer", "ApiKey", func(id uint) string { return fmt.Sprintf("mcp/server-%d", id) }},
+	{&models.Enterprise{}, "Enterprise", "PrivateKey", func(id uint) string { return fmt.Sprintf("b2b/enterprise-%d", id) }},
+}
+
+// MigratePlaintext 查找业务表中的明文密钥，写入存储并把原列替换为引用；dryRun 时只返回发现的位置。
+// 处理 AI 提供商与模型的 API Key、MCP 服务的 API Key、企业 B2B 私钥以及平台账号配置中的敏感字段
+func MigratePlaintext(ctx context.Context, db *gorm.DB, s *Store, by string, dryRun bool) ([]Finding, error) {
+	var findings []Finding
+	for _, col := range plaintextColumns {
+		if !db.Migrator().HasTable(col.model) {
+			continue
+		}
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		err := db.WithContext(ctx).Table(col.table).
+			Select(fmt.Sprintf(`"Id" AS id, "%s" AS value`, col.column)).
+			Where(fmt.Sprintf(`"%s" <> '' AND "%s" NOT LIKE ?`, col.column, col.column), RefPrefix+"%").
+			Scan(&rows).Error
+		if err != nil {
+			return findings, fmt.Errorf("scan %s.%s: %w", col.table, col.column, err)
+		}
+		for _, row := range rows {
+			f := Finding{Table: col.table, Column: col.column, RowID: row.ID, Name: col.name(row.ID)}
+			if !dryRun {
+				if err := s.Put(ctx, f.Name, row.Value, by); err != nil {
+					return findings, err
+				}
+				if err := db.WithContext(ctx).Table(col.table).Where(`"Id" = ?`, row.ID).Update(col.column, Ref(f.Name)).Error; err != nil {
+					return findings, err
+				}
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	more, err := migratePlatformAccounts(ctx, db, s, by, dryRun)
+	return append(findings, more...), err
+}
+
+// migratePlatformAccounts 平台账号的 Config 为 JSON，逐个迁移其中的敏感字段 (AppSecret、Token、EncodingAESKey 等)
+func migratePlatformAccounts(ctx context.Context, db *gorm.DB, s *Store, by string, dryRun bool) ([]Finding, error) {
+	if !db.Migrator().HasTable(&models.PlatformAccount{}) {
+		return nil, nil
+	}
+	var accounts []models.PlatformAccount
+	if err := db.WithContext(ctx).Where(`"Config" <> ''`).Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("scan PlatformAccount.Config: %w", err)
+	}
+	var findings []Finding
+	for _, acc := range accounts {
+		var cfg map[string]any
+		if err := json.Unmarshal([]byte(acc.Config), &cfg); err != nil {
+			continue
+		}
+		keys := make([]string, 0, len(cfg))
+		for k := range cfg {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		changed := false
+		for _, k := range keys {
+			v, ok := cfg[k].(string)
+			if !ok || v == "" || IsRef(v) || !IsSensitiveKey(k) {
+				continue
+			}
+			f := Finding{Table: "PlatformAccount", Column: "Config." + k, RowID: acc.ID, Name: fmt.Sprintf("platform/%d/%s", acc.ID, snakeCase(k))}
+			if !dryRun {
+				if err := s.Put(ctx, f.Name, v, by); err != nil {
+					return findings, err
+				}
+				cfg[k] = Ref(f.Name)
+				changed = true
+			}
+			findings = append(findings, f)
+		}
+		if changed {
+			data, _ := json.Marshal(cfg)
+			if err := db.WithContext(ctx).Model(&models.PlatformAccount{}).Where(`"Id" = ?`, acc.ID).Update("Config", string(data)).Error; err != nil {
+				return findings, err
+			}
+		}
+	}
+	return findings, nil
+}
+
+// ResolveJSON 解析 JSON 对象中值为引用的字段，用于平台账号等以 JSON 保存的配置
+func ResolveJSON(ctx context.Context, raw string) (map[string]any, error) {
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return nil, err
+	}
+	for k, v := range cfg {
+		if s, ok := v.(string); ok && IsRef(s) {
+			plain, err := Resolve(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			cfg[k] = plain
+		}
+	}
+	return cfg, nil
+}
+
+// snakeCase 将 AppSecret、encodingAESKey 等驼峰字段名转换为 app_secret、encoding_aes_key
+func snakeCase(s string) string {
+	out := make([]byte, 0, len(s)+4)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			prevLower := i > 0 && (s[i-1] >= 'a' && s[i-1] <= 'z' || s[i-1] >= '0' && s[i-1] <= '9')
+			nextLower := i+1 < len(s) && s[i+1] >= 'a' && s[i+1] <= 'z'
+			if i > 0 && (prevLower || (nextLower && s[i-1] >= 'A' && s[i-1] <= 'Z')) && out[len(out)-1] != '_' {
+				out = append(out, '_')
+			}
+			c += 'a' - 'A'
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
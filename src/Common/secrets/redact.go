@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Mask 脱敏占位符。管理接口提交该值表示保持原值不变
+const Mask = "********"
+
+// sensitiveNames 字段名 (小写、下划线风格) 等于或以 "_"+name 结尾时视为敏感字段
+var sensitiveNames = []string{
+	"password", "passwd", "pwd", "secret", "token", "api_key", "apikey",
+	"private_key", "access_key", "aes_key", "encrypt_key", "translate_key", "credential", "credentials",
+}
+
+// IsSensitiveKey 判断 JSON 字段名是否为敏感字段，如 api_key、jwt_secret、client_secret、redis_pwd、AppSecret
+func IsSensitiveKey(name string) bool {
+	name = snakeCase(strings.ReplaceAll(name, "-", "_"))
+	for _, s := range sensitiveNames {
+		if name == s || strings.HasSuffix(name, "_"+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactValue 脱敏单个值：空值与密钥引用原样返回，其余替换为 Mask
+func RedactValue(value string) string {
+	if value == "" || IsRef(value) {
+		return value
+	}
+	return Mask
+}
+
+// Redact 返回 v 的脱敏副本 (JSON 结构)：敏感字段的非空字符串值替换为 Mask，
+// 值为 JSON 对象字符串的字段 (如平台账号 config) 会递归处理
+func Redact(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return redact(out)
+}
+
+// RedactJSON 脱敏 JSON 文本，无法解析时返回 Mask 以免泄露
+func RedactJSON(data []byte) []byte {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []byte(`"` + Mask + `"`)
+	}
+	out, _ := json.Marshal(redact(v))
+	return out
+}
+
+func redact(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if s, ok := item.(string); ok {
+				if IsSensitiveKey(k) {
+					val[k] = RedactValue(s)
+				} else if nested, ok := embeddedObject(s); ok {
+					out, _ := json.Marshal(redact(nested))
+					val[k] = string(out)
+				}
+				continue
+			}
+			val[k] = redact(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = redact(item)
+		}
+	}
+	return v
+}
+
+// RestoreMasked 将 patch 中值为 Mask 的敏感字段替换为 current 中同一路径的原值，
+// 使管理界面回传脱敏后的数据时不会覆盖真实密钥
+func RestoreMasked(patch, current []byte) ([]byte, error) {
+	var p, c any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &c); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(restore(p, c))
+}
+
+func restore(p, c any) any {
+	switch val := p.(type) {
+	case map[string]any:
+		cur, _ := c.(map[string]any)
+		for k, item := range val {
+			if s, ok := item.(string); ok && s == Mask && IsSensitiveKey(k) {
+				if old, ok := cur[k]; ok {
+					val[k] = old
+				} else {
+					delete(val, k)
+				}
+				continue
+			}
+			val[k] = restore(item, cur[k])
+		}
+	case []any:
+		cur, _ := c.([]any)
+		for i, item := range val {
+			var old any
+			if i < len(cur) {
+				old = cur[i]
+			}
+			val[i] = restore(item, old)
+		}
+	}
+	return p
+}
+
+func embeddedObject(s string) (map[string]any, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") {
+		return nil, false
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, false
+	}
+	return m, true
+}
@@ -0,0 +1,226 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"BotMatrix/common/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newTestStore(t *testing.T, keys ...[]byte) (*gorm.DB, *Store) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	ring, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(db, ring, Options{})
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return db, s
+}
+
+func TestEnvelopeEncryptionAndRefs(t *testing.T) {
+	ctx := context.Background()
+	db, s := newTestStore(t, testKey(1))
+
+	if err := s.Put(ctx, "ai/openai-prod", "sk-live-123", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	var row Secret
+	db.Where("name = ?", "ai/openai-prod").First(&row)
+	if bytes.Contains(row.Ciphertext, []byte("sk-live-123")) || row.MasterKeyID != KeyID(testKey(1)) || len(row.WrappedKey) == 0 {
+		t.Fatalf("stored row = %+v", row)
+	}
+
+	got, err := s.Resolve(ctx, Ref("ai/openai-prod"))
+	if err != nil || got != "sk-live-123" {
+		t.Fatalf("Resolve = %q, %v", got, err)
+	}
+	if got, _ := s.Resolve(ctx, "plain-value"); got != "plain-value" {
+		t.Fatalf("plaintext passthrough = %q", got)
+	}
+	if _, err := s.Resolve(ctx, Ref("missing")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing ref err = %v", err)
+	}
+	if err := s.Put(ctx, "Bad Name", "x", ""); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("invalid name err = %v", err)
+	}
+
+	// 密文绑定密钥名，挪到其他记录后无法解密
+	other := row
+	other.ID, other.Name = 0, "ai/copied"
+	db.Create(&other)
+	if _, err := s.Get(ctx, "ai/copied"); err == nil {
+		t.Fatal("ciphertext moved to another name was decrypted")
+	}
+
+	// 覆盖写入后缓存失效
+	if err := s.Put(ctx, "ai/openai-prod", "sk-live-456", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get(ctx, "ai/openai-prod"); got != "sk-live-456" {
+		t.Fatalf("after overwrite = %q", got)
+	}
+}
+
+func TestRotateReencryptsWithNewPrimary(t *testing.T) {
+	ctx := context.Background()
+	db, old := newTestStore(t, testKey(1))
+	for _, name := range []string{"a", "b/c"} {
+		if err := old.Put(ctx, name, "value-"+name, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ring, _ := NewKeyring(testKey(2), testKey(1))
+	s := New(db, ring, Options{})
+	n, err := s.Rotate(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Rotate = %d, %v", n, err)
+	}
+
+	// 轮换后只保留新主密钥也能解密
+	onlyNew, _ := NewKeyring(testKey(2))
+	fresh := New(db, onlyNew, Options{})
+	for _, name := range []string{"a", "b/c"} {
+		if got, err := fresh.Get(ctx, name); err != nil || got != "value-"+name {
+			t.Fatalf("Get(%s) after rotate = %q, %v", name, got, err)
+		}
+	}
+	list, _ := fresh.List(ctx, "")
+	for _, sec := range list {
+		if sec.MasterKeyID != KeyID(testKey(2)) || sec.Version != 2 || sec.Ciphertext != nil {
+			t.Fatalf("listed secret = %+v", sec)
+		}
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	newKey, _ := GenerateKey()
+	ring, err := ParseKeyring("# 主密钥\n" + newKey + "\n" + strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ring.keys) != 2 || ring.PrimaryID() == KeyID(bytes.Repeat([]byte{0xab}, 32)) {
+		t.Fatalf("keyring = %+v", ring)
+	}
+	if _, err := ParseKeyring("dG9vLXNob3J0"); err == nil {
+		t.Fatal("short key accepted")
+	}
+	if _, err := ParseKeyring(" "); !errors.Is(err, ErrNoMasterKey) {
+		t.Fatalf("empty keyring err = %v", err)
+	}
+}
+
+func TestRedactAndRestoreMasked(t *testing.T) {
+	cfg := map[string]any{
+		"jwt_secret": "s3cret",
+		"redis_pwd":  "",
+		"oidc":       map[string]any{"client_id": "bm", "client_secret": "cs"},
+		"knowledge_sources": []any{
+			map[string]any{"type": "imap", "password": "mailpw"},
+		},
+		"rag_rerank_api_key": Ref("ai/rerank"),
+		"public_key":         "pub",
+		"config":             `{"AppID":"wx1","AppSecret":"wxsecret"}`,
+		"salary_token":       42,
+		"encrypt_key":        "feishu-ek",
+	}
+	out, _ := json.Marshal(Redact(cfg))
+	for _, leak := range []string{"s3cret", "cs", "mailpw", "wxsecret", "feishu-ek"} {
+		if bytes.Contains(out, []byte(`"`+leak+`"`)) || bytes.Contains(out, []byte(`\"`+leak+`\"`)) {
+			t.Fatalf("%s leaked: %s", leak, out)
+		}
+	}
+	for _, keep := range []string{`"public_key":"pub"`, `"rag_rerank_api_key":"secret://ai/rerank"`, `"salary_token":42`, `"redis_pwd":""`, `AppID`} {
+		if !bytes.Contains(out, []byte(keep)) {
+			t.Fatalf("%s missing: %s", keep, out)
+		}
+	}
+
+	current, _ := json.Marshal(cfg)
+	restored, err := RestoreMasked(out, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back map[string]any
+	json.Unmarshal(restored, &back)
+	if back["jwt_secret"] != "s3cret" || back["oidc"].(map[string]any)["client_secret"] != "cs" ||
+		back["knowledge_sources"].([]any)[0].(map[string]any)["password"] != "mailpw" {
+		t.Fatalf("restored = %s", restored)
+	}
+}
+
+func TestMigratePlaintext(t *testing.T) {
+	ctx := context.Background()
+	db, s := newTestStore(t, testKey(1))
+	if err := db.AutoMigrate(&models.AIProvider{}, &models.Enterprise{}, &models.PlatformAccount{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&models.AIProvider{Name: "openai", Type: "openai", APIKey: "sk-plain"})
+	db.Create(&models.AIProvider{Name: "migrated", Type: "openai", APIKey: Ref("ai/shared")})
+	db.Create(&models.AIProvider{Name: "ollama", Type: "ollama"})
+	db.Create(&models.Enterprise{Name: "acme", Code: "acme", PrivateKey: "b2b-signing-key"})
+	db.Create(&models.PlatformAccount{Platform: "wechat_mp", Config: `{"AppID":"wx1","AppSecret":"wxsecret","EncodingAESKey":"aes"}`})
+
+	findings, err := MigratePlaintext(ctx, db, s, "migrate", true)
+	if err != nil || len(findings) != 4 {
+		t.Fatalf("dry run = %+v, %v", findings, err)
+	}
+	var p models.AIProvider
+	db.First(&p, 1)
+	if p.APIKey != "sk-plain" {
+		t.Fatalf("dry run modified row: %q", p.APIKey)
+	}
+
+	if _, err := MigratePlaintext(ctx, db, s, "migrate", false); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&p, 1)
+	if p.APIKey != Ref("ai/provider-1") {
+		t.Fatalf("provider key = %q", p.APIKey)
+	}
+	if v, _ := s.Resolve(ctx, p.APIKey); v != "sk-plain" {
+		t.Fatalf("resolved provider key = %q", v)
+	}
+	var e models.Enterprise
+	db.First(&e)
+	if v, _ := s.Resolve(ctx, e.PrivateKey); v != "b2b-signing-key" || !IsRef(e.PrivateKey) {
+		t.Fatalf("enterprise key = %q -> %q", e.PrivateKey, v)
+	}
+	var acc models.PlatformAccount
+	db.First(&acc)
+	SetDefault(s)
+	defer SetDefault(nil)
+	resolved, err := ResolveJSON(ctx, acc.Config)
+	if err != nil || resolved["AppSecret"] != "wxsecret" || resolved["EncodingAESKey"] != "aes" || resolved["AppID"] != "wx1" {
+		t.Fatalf("platform config %s -> %v, %v", acc.Config, resolved, err)
+	}
+	if strings.Contains(acc.Config, "wxsecret") {
+		t.Fatalf("platform config still has plaintext: %s", acc.Config)
+	}
+
+	// 再次运行不会重复迁移
+	if again, _ := MigratePlaintext(ctx, db, s, "migrate", true); len(again) != 0 {
+		t.Fatalf("second run found %+v", again)
+	}
+}
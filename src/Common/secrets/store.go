@@ -0,0 +1,347 @@
+// Package secrets 提供信封加密的密钥存储。
+// 每个密钥使用独立的 AES-256-GCM 数据密钥加密，数据密钥再由主密钥加密后与密文一起保存；
+// 业务表中只保存 secret://<name> 形式的引用，在使用时解析为明文
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefPrefix 密钥引用前缀，如 secret://ai/openai-prod
+const RefPrefix = "secret://"
+
+var (
+	// ErrNotFound 密钥不存在
+	ErrNotFound = errors.New("secrets: secret not found")
+	// ErrNoStore 未配置密钥存储，无法解析引用
+	ErrNoStore = errors.New("secrets: secret store is not configured")
+	// ErrInvalidName 密钥名不合法
+	ErrInvalidName = errors.New("secrets: name must match [a-z0-9][a-z0-9._/-]*")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]{0,190}$`)
+
+// Secret 加密保存的密钥
+type Secret struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:191;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	Ciphertext  []byte    `json:"-"` // nonce + 数据密钥加密的值
+	WrappedKey  []byte    `json:"-"` // nonce + 主密钥加密的数据密钥
+	MasterKeyID string    `gorm:"size:16;index" json:"master_key_id"`
+	Version     int       `json:"version"` // 每次写入或轮换加一
+	UpdatedBy   string    `gorm:"size:64" json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Secret) TableName() string {
+	return "secrets"
+}
+
+// Ref 返回密钥引用
+func Ref(name string) string {
+	return RefPrefix + name
+}
+
+// IsRef 判断值是否为密钥引用
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+// ParseRef 从引用中取出密钥名
+func ParseRef(value string) (string, bool) {
+	if !IsRef(value) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, RefPrefix), true
+}
+
+// Options 存储参数，零值字段使用默认值
+type Options struct {
+	CacheTTL time.Duration // 解密结果的缓存时间，默认 1 分钟；其他实例写入后最多延迟该时间生效
+}
+
+func (o Options) withDefaults() Options {
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = time.Minute
+	}
+	return o
+}
+
+type cached struct {
+	value   string
+	expires time.Time
+}
+
+// Store 密钥存储
+type Store struct {
+	db   *gorm.DB
+	keys *Keyring
+	opts Options
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+// New 创建密钥存储
+func New(db *gorm.DB, keys *Keyring, opts Options) *Store {
+	return &Store{db: db, keys: keys, opts: opts.withDefaults(), cache: make(map[string]cached)}
+}
+
+// Migrate 创建密钥表
+func (s *Store) Migrate() error {
+	return s.db.AutoMigrate(&Secret{})
+}
+
+// Put 写入或覆盖密钥，每次写入都生成新的数据密钥
+func (s *Store) Put(ctx context.Context, name, value, by string) error {
+	if !namePattern.MatchString(name) {
+		return ErrInvalidName
+	}
+	var existing Secret
+	err := s.db.WithContext(ctx).Where("name = ?", name).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	sec := existing
+	sec.Name = name
+	sec.UpdatedBy = by
+	sec.Version++
+	if err := s.seal(&sec, value); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Save(&sec).Error; err != nil {
+		return err
+	}
+	s.forget(name)
+	return nil
+}
+
+// Get 解密并返回密钥明文
+func (s *Store) Get(ctx context.Context, name string) (string, error) {
+	s.mu.Lock()
+	if c, ok := s.cache[name]; ok && time.Now().Before(c.expires) {
+		s.mu.Unlock()
+		return c.value, nil
+	}
+	s.mu.Unlock()
+
+	var sec Secret
+	if err := s.db.WithContext(ctx).Where("name = ?", name).First(&sec).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return "", err
+	}
+	value, err := s.open(&sec)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.cache[name] = cached{value: value, expires: time.Now().Add(s.opts.CacheTTL)}
+	s.mu.Unlock()
+	return value, nil
+}
+
+// Delete 删除密钥
+func (s *Store) Delete(ctx context.Context, name string) error {
+	res := s.db.WithContext(ctx).Where("name = ?", name).Delete(&Secret{})
+	if res.Error != nil {
+		return res.Error
+	}
+	s.forget(name)
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return nil
+}
+
+// List 返回全部密钥的元数据 (不含明文)，prefix 非空时只返回该前缀下的密钥
+func (s *Store) List(ctx context.Context, prefix string) ([]Secret, error) {
+	q := s.db.WithContext(ctx).Omit("ciphertext", "wrapped_key").Order("name")
+	if prefix != "" {
+		q = q.Where("name LIKE ?", prefix+"%")
+	}
+	var list []Secret
+	err := q.Find(&list).Error
+	return list, err
+}
+
+// Resolve 将引用解析为明文，非引用的值原样返回
+func (s *Store) Resolve(ctx context.Context, value string) (string, error) {
+	name, ok := ParseRef(value)
+	if !ok {
+		return value, nil
+	}
+	return s.Get(ctx, name)
+}
+
+// Rotate 用当前主密钥和新的数据密钥重新加密所有记录，返回处理的条数。
+// 轮换时把新主密钥放在密钥列表首位、旧主密钥保留在后面，完成后即可移除旧密钥
+func (s *Store) Rotate(ctx context.Context) (int, error) {
+	var ids []uint
+	if err := s.db.WithContext(ctx).Model(&Secret{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var sec Secret
+			if err := tx.First(&sec, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			value, err := s.open(&sec)
+			if err != nil {
+				return err
+			}
+			sec.Version++
+			if err := s.seal(&sec, value); err != nil {
+				return err
+			}
+			return tx.Select("ciphertext", "wrapped_key", "master_key_id", "version", "updated_at").Save(&sec).Error
+		})
+		if err != nil {
+			return n, fmt.Errorf("secrets: rotate #%d: %w", id, err)
+		}
+		n++
+	}
+	s.mu.Lock()
+	s.cache = make(map[string]cached)
+	s.mu.Unlock()
+	return n, nil
+}
+
+// seal 生成数据密钥加密 value，并用主密钥加密数据密钥；密钥名作为附加数据，防止密文被挪到其他记录
+func (s *Store) seal(sec *Secret, value string) error {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	ct, err := encrypt(dataKey, []byte(value), []byte(sec.Name))
+	if err != nil {
+		return err
+	}
+	master, err := s.keys.key(s.keys.PrimaryID())
+	if err != nil {
+		return err
+	}
+	wrapped, err := encrypt(master, dataKey, []byte(sec.Name))
+	if err != nil {
+		return err
+	}
+	sec.Ciphertext = ct
+	sec.WrappedKey = wrapped
+	sec.MasterKeyID = s.keys.PrimaryID()
+	return nil
+}
+
+func (s *Store) open(sec *Secret) (string, error) {
+	master, err := s.keys.key(sec.MasterKeyID)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := decrypt(master, sec.WrappedKey, []byte(sec.Name))
+	if err != nil {
+		return "", fmt.Errorf("secrets: unwrap data key of %s: %w", sec.Name, err)
+	}
+	value, err := decrypt(dataKey, sec.Ciphertext, []byte(sec.Name))
+	if err != nil {
+		return "", fmt.Errorf("secrets: decrypt %s: %w", sec.Name, err)
+	}
+	return string(value), nil
+}
+
+func (s *Store) forget(name string) {
+	s.mu.Lock()
+	delete(s.cache, name)
+	s.mu.Unlock()
+}
+
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func decrypt(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore *Store
+)
+
+// SetDefault 设置进程内使用的默认存储
+func SetDefault(s *Store) {
+	defaultMu.Lock()
+	defaultStore = s
+	defaultMu.Unlock()
+}
+
+// Default 返回默认存储，未配置主密钥时为 nil
+func Default() *Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// Resolve 使用默认存储解析引用，非引用的值 (包括尚未迁移的明文) 原样返回
+func Resolve(ctx context.Context, value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+	s := Default()
+	if s == nil {
+		return "", ErrNoStore
+	}
+	return s.Resolve(ctx, value)
+}
+
+// Seal 将明文写入默认存储并返回引用。未配置存储、值为空、已是引用或为脱敏占位符时原样返回
+func Seal(ctx context.Context, name, value, by string) (string, error) {
+	s := Default()
+	if s == nil || value == "" || value == Mask || IsRef(value) {
+		return value, nil
+	}
+	if err := s.Put(ctx, name, value, by); err != nil {
+		return "", err
+	}
+	return Ref(name), nil
+}
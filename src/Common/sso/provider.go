@@ -0,0 +1,405 @@
+// Package sso 实现 OIDC 单点登录：授权码 + PKCE 流程、ID Token 校验、
+// 用户即时开通与 IdP 组到 RBAC 角色的映射，以及后端通道登出
+package sso
+
+import (
+	"BotMatrix/common/config"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyRefreshInterval 遇到未知 kid 时重新拉取 JWKS 的最小间隔
+const keyRefreshInterval = 10 * time.Second
+
+// backchannelLogoutEvent 后端通道登出令牌中 events 声明的固定键
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Identity ID Token 中解析出的用户身份
+type Identity struct {
+	Issuer    string
+	Subject   string
+	SessionID string
+	Username  string
+	Email     string
+	Name      string
+	Groups    []string
+	Claims    jwt.MapClaims
+}
+
+// Tokens 授权码换取的令牌
+type Tokens struct {
+	IDToken     string `json:"id_token"`
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Provider OIDC 身份提供方客户端，发现文档与签名公钥在首次使用时拉取并缓存
+type Provider struct {
+	cfg    config.OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// NewProvider 创建 IdP 客户端，未配置的可选项使用默认值
+func NewProvider(cfg config.OIDCConfig) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Config 返回补全默认值后的配置
+func (p *Provider) Config() config.OIDCConfig {
+	return p.cfg
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	var d discovery
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimRight(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery issuer mismatch: %q", d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is incomplete")
+	}
+	p.mu.Lock()
+	p.meta = &d
+	p.mu.Unlock()
+	return &d, nil
+}
+
+// AuthCodeURL 构造授权请求地址 (response_type=code，S256 PKCE)
+func (p *Provider) AuthCodeURL(ctx context.Context, state *LoginState) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	v.Set("state", state.State)
+	v.Set("nonce", state.Nonce)
+	v.Set("code_challenge", Challenge(state.Verifier))
+	v.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// Exchange 以授权码与 PKCE verifier 换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Tokens, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token exchange failed: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var tokens Tokens
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return &tokens, nil
+}
+
+// VerifyIDToken 校验 ID Token 的签名、issuer、audience、有效期与 nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Identity, error) {
+	claims, err := p.verify(ctx, raw, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, errors.New("id_token azp mismatch")
+		}
+	}
+	return p.identity(claims)
+}
+
+// VerifyLogoutToken 校验后端通道登出令牌 (OpenID Connect Back-Channel Logout 1.0)，
+// 返回需要登出的 subject 与 IdP 会话，二者至少有一个
+func (p *Provider) VerifyLogoutToken(ctx context.Context, raw string) (issuer, subject, sid string, err error) {
+	claims, err := p.verify(ctx, raw)
+	if err != nil {
+		return "", "", "", err
+	}
+	events, _ := claims["events"].(map[string]any)
+	if _, ok := events[backchannelLogoutEvent]; !ok {
+		return "", "", "", errors.New("logout_token has no back-channel logout event")
+	}
+	if _, ok := claims["nonce"]; ok {
+		return "", "", "", errors.New("logout_token must not contain a nonce")
+	}
+	subject, _ = claims["sub"].(string)
+	sid, _ = claims["sid"].(string)
+	if subject == "" && sid == "" {
+		return "", "", "", errors.New("logout_token has neither sub nor sid")
+	}
+	return p.cfg.Issuer, subject, sid, nil
+}
+
+func (p *Provider) verify(ctx context.Context, raw string, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	opts = append(opts,
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+func (p *Provider) identity(claims jwt.MapClaims) (*Identity, error) {
+	id := &Identity{Issuer: p.cfg.Issuer, Claims: claims}
+	id.Subject, _ = claims["sub"].(string)
+	if id.Subject == "" {
+		return nil, errors.New("id_token has no subject")
+	}
+	id.SessionID, _ = claims["sid"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	id.Username, _ = claims[p.cfg.UsernameClaim].(string)
+	if id.Username == "" {
+		id.Username = id.Email
+	}
+	if id.Username == "" {
+		id.Username = id.Subject
+	}
+	id.Groups = stringList(claims[p.cfg.GroupsClaim])
+	return id, nil
+}
+
+// stringList 兼容字符串数组与以空格或逗号分隔的字符串两种声明格式
+func stringList(v any) []string {
+	switch x := v.(type) {
+	case []any:
+		out := make([]string, 0, len(x))
+		for _, e := range x {
+			if s, ok := e.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return strings.FieldsFunc(x, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return nil
+}
+
+// EndSessionURL 返回 IdP 的登出地址 (RP-Initiated Logout)，IdP 不支持时返回空
+func (p *Provider) EndSessionURL(ctx context.Context) string {
+	meta, err := p.discover(ctx)
+	if err != nil || meta.EndSessionEndpoint == "" {
+		return ""
+	}
+	v := url.Values{}
+	v.Set("client_id", p.cfg.ClientID)
+	if p.cfg.PostLogoutRedirectURL != "" {
+		v.Set("post_logout_redirect_uri", p.cfg.PostLogoutRedirectURL)
+	}
+	return meta.EndSessionEndpoint + "?" + v.Encode()
+}
+
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	k, ok := p.lookup(kid)
+	stale := time.Since(p.keysFetched) >= keyRefreshInterval
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		if pub, err := j.publicKey(); err == nil {
+			keys[j.Kid] = pub
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys, p.keysFetched = keys, time.Now()
+	if k, ok := p.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup 按 kid 查找公钥；令牌未带 kid 且 JWKS 只有一个公钥时使用该公钥
+func (p *Provider) lookup(kid string) (crypto.PublicKey, bool) {
+	if k, ok := p.keys[kid]; ok {
+		return k, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+}
@@ -0,0 +1,224 @@
+package sso
+
+import (
+	"BotMatrix/common/config"
+	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BindingSource SSO 同步的角色授权的 created_by 标记。每次登录只增删带该标记的授权，不影响手工授权
+const BindingSource = "sso"
+
+// ErrUserDisabled 用户已被禁用或删除
+var ErrUserDisabled = errors.New("user is disabled")
+
+// Service 用户即时开通与角色同步
+type Service struct {
+	db    *gorm.DB
+	cfg   config.OIDCConfig
+	roles *rbac.Service
+	now   func() time.Time
+}
+
+// NewService 创建服务，roles 为空时不同步角色授权
+func NewService(db *gorm.DB, cfg config.OIDCConfig, roles *rbac.Service) *Service {
+	if cfg.EnterpriseRole == "" {
+		cfg.EnterpriseRole = rbac.RoleOperator
+	}
+	return &Service{db: db, cfg: cfg, roles: roles, now: time.Now}
+}
+
+// Provision 按 IdP 身份 (issuer + subject) 查找用户，首次登录时即时创建，
+// 并按配置同步管理员标记与角色授权。不会按用户名关联已有的本地账号
+func (s *Service) Provision(id *Identity) (*models.User, error) {
+	var link models.SSOIdentity
+	var user models.User
+	now := s.now()
+
+	err := s.db.Where("issuer = ? AND subject = ?", id.Issuer, id.Subject).First(&link).Error
+	switch {
+	case err == nil:
+		if err := s.db.First(&user, link.UserID).Error; err != nil {
+			return nil, ErrUserDisabled
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			username, err := s.username(tx, id)
+			if err != nil {
+				return err
+			}
+			user = models.User{Username: username, PasswordHash: "!", Active: true, SessionVersion: 1}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			link = models.SSOIdentity{UserID: user.ID, Issuer: id.Issuer, Subject: id.Subject, CreatedAt: now}
+			return tx.Create(&link).Error
+		})
+		if err != nil {
+			return nil, fmt.Errorf("provision user: %w", err)
+		}
+		log.Printf("[SSO] Provisioned user %s for %s", user.Username, id.Subject)
+	default:
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrUserDisabled
+	}
+
+	s.db.Model(&link).Updates(map[string]any{"email": id.Email, "session_id": id.SessionID, "last_login_at": now})
+
+	if len(s.cfg.AdminGroups) > 0 {
+		admin := intersects(id.Groups, s.cfg.AdminGroups)
+		if admin != user.IsAdmin {
+			updates := map[string]any{"is_admin": admin}
+			if !admin {
+				// 降级时使已签发的 Token 失效
+				updates["session_version"] = user.SessionVersion + 1
+			}
+			if err := s.db.Model(&user).Updates(updates).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.syncBindings(user.ID, id); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// username 优先使用 IdP 提供的用户名；已被占用 (包括本地账号) 时追加由 subject 派生的后缀
+func (s *Service) username(tx *gorm.DB, id *Identity) (string, error) {
+	base := strings.TrimSpace(id.Username)
+	if len(base) > 200 {
+		base = base[:200]
+	}
+	sum := sha256.Sum256([]byte(id.Issuer + "\x00" + id.Subject))
+	candidates := []string{base, base + "-" + hex.EncodeToString(sum[:3])}
+	for _, name := range candidates {
+		if name == "" || strings.HasPrefix(name, "svc:") {
+			continue
+		}
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", name).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("username %q is already taken", base)
+}
+
+type grant struct {
+	role  string
+	scope rbac.Scope
+}
+
+// syncBindings 使带 BindingSource 标记的授权与 IdP 声明一致
+func (s *Service) syncBindings(userID uint, id *Identity) error {
+	if s.roles == nil {
+		return nil
+	}
+	var wants []grant
+	for _, m := range s.cfg.RoleMappings {
+		if !intersects(id.Groups, []string{m.Group}) {
+			continue
+		}
+		scope := rbac.Scope{Type: m.ScopeType, ID: m.ScopeID}
+		if scope.Type == "" {
+			scope = rbac.Global
+		}
+		wants = append(wants, grant{m.Role, scope})
+	}
+	if s.cfg.EnterpriseClaim != "" {
+		for _, ent := range stringList(id.Claims[s.cfg.EnterpriseClaim]) {
+			wants = append(wants, grant{s.cfg.EnterpriseRole, rbac.Scope{Type: rbac.ScopeEnterprise, ID: ent}})
+		}
+	}
+
+	existing, err := s.roles.Bindings(userID)
+	if err != nil {
+		return err
+	}
+	have := make(map[grant]bool)
+	for _, b := range existing {
+		g := grant{b.Role, rbac.Scope{Type: b.ScopeType, ID: b.ScopeID}}
+		if b.CreatedBy == BindingSource && !containsGrant(wants, g) {
+			if err := s.roles.Unbind(b.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		have[g] = true
+	}
+	for _, g := range wants {
+		if have[g] {
+			continue
+		}
+		if _, err := s.roles.Bind(userID, g.role, g.scope, BindingSource); err != nil {
+			// 映射配置错误 (如角色不存在) 不阻止登录
+			log.Printf("[SSO] Failed to grant role %s (%s:%s) to user %d: %v", g.role, g.scope.Type, g.scope.ID, userID, err)
+		}
+		have[g] = true
+	}
+	return nil
+}
+
+// SessionUsers 返回与 IdP subject 或会话 (sid) 关联的用户，供后端通道登出使用
+func (s *Service) SessionUsers(issuer, subject, sid string) ([]models.User, error) {
+	q := s.db.Model(&models.SSOIdentity{}).Where("issuer = ?", issuer)
+	switch {
+	case subject != "" && sid != "":
+		q = q.Where("subject = ? AND session_id = ?", subject, sid)
+	case subject != "":
+		q = q.Where("subject = ?", subject)
+	default:
+		q = q.Where("session_id = ?", sid)
+	}
+	var ids []uint
+	if err := q.Pluck("user_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := s.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// Linked 判断用户是否通过 SSO 开通
+func (s *Service) Linked(userID uint) bool {
+	var count int64
+	s.db.Model(&models.SSOIdentity{}).Where("user_id = ?", userID).Count(&count)
+	return count > 0
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsGrant(gs []grant, g grant) bool {
+	for _, x := range gs {
+		if x == g {
+			return true
+		}
+	}
+	return false
+}
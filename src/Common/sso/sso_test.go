@@ -0,0 +1,304 @@
+package sso
+
+import (
+	"BotMatrix/common/config"
+	"BotMatrix/common/models"
+	"BotMatrix/common/rbac"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// mockIdP 最小化的 OIDC 身份提供方：发现、JWKS、授权 (自动同意) 与令牌端点
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims jwt.MapClaims // 下次签发的 ID Token 中的用户声明
+	codes  map[string]pendingCode
+}
+
+type pendingCode struct {
+	challenge, nonce, redirect string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]pendingCode)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+			"end_session_endpoint":   idp.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("response_type") != "code" {
+			http.Error(w, "PKCE required", http.StatusBadRequest)
+			return
+		}
+		code := "code-" + q.Get("state")
+		idp.mu.Lock()
+		idp.codes[code] = pendingCode{q.Get("code_challenge"), q.Get("nonce"), q.Get("redirect_uri")}
+		idp.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mu.Lock()
+		pending, ok := idp.codes[r.Form.Get("code")]
+		delete(idp.codes, r.Form.Get("code"))
+		claims := jwt.MapClaims{}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		idp.mu.Unlock()
+		if !ok || Challenge(r.Form.Get("code_verifier")) != pending.challenge || r.Form.Get("redirect_uri") != pending.redirect {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims["nonce"] = pending.nonce
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "at", "token_type": "Bearer", "id_token": idp.sign(t, claims),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *mockIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	now := time.Now()
+	claims["iss"] = idp.URL
+	claims["aud"] = "botmatrix"
+	claims["iat"] = now.Unix()
+	if _, ok := claims["events"]; !ok {
+		claims["exp"] = now.Add(time.Hour).Unix()
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// login 走完一次浏览器登录流程，返回校验后的身份
+func (idp *mockIdP) login(t *testing.T, p *Provider, claims jwt.MapClaims) *Identity {
+	idp.mu.Lock()
+	idp.claims = claims
+	idp.mu.Unlock()
+
+	ctx := context.Background()
+	state, err := NewLoginState("/console", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie := state.Encode("secret")
+
+	authURL, err := p.AuthCodeURL(ctx, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, _ := url.Parse(resp.Header.Get("Location"))
+
+	restored, err := DecodeLoginState(cookie, "secret", callback.Query().Get("state"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := p.Exchange(ctx, callback.Query().Get("code"), restored.Verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := p.VerifyIDToken(ctx, tokens.IDToken, restored.Nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func newTestService(t *testing.T, cfg config.OIDCConfig) (*Service, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.User{}, &models.SSOIdentity{}, &models.RBACRole{}, &models.RBACRoleBinding{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(db, cfg, rbac.NewService(db)), db
+}
+
+func TestLoginProvisionsAndSyncsRoles(t *testing.T) {
+	idp := newMockIdP(t)
+	p := NewProvider(config.OIDCConfig{
+		Issuer:          idp.URL,
+		ClientID:        "botmatrix",
+		RedirectURL:     "https://nexus.example.com/api/auth/oidc/callback",
+		AdminGroups:     []string{"bm-admins"},
+		RoleMappings:    []config.OIDCRoleMapping{{Group: "support", Role: rbac.RoleViewer}},
+		EnterpriseClaim: "tenant",
+	})
+	svc, db := newTestService(t, p.Config())
+	db.Create(&models.User{Username: "alice", PasswordHash: "x", Active: true})
+
+	id := idp.login(t, p, jwt.MapClaims{
+		"sub": "u-1", "sid": "s-1", "preferred_username": "alice", "email": "alice@example.com",
+		"groups": []string{"bm-admins", "support"}, "tenant": "ent-7",
+	})
+	user, err := svc.Provision(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username == "alice" || !strings.HasPrefix(user.Username, "alice-") {
+		t.Fatalf("SSO user must not take over the local account, got %q", user.Username)
+	}
+	db.First(user, user.ID)
+	if !user.IsAdmin || user.PasswordHash != "!" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+	bindings, _ := svc.roles.Bindings(user.ID)
+	if len(bindings) != 2 {
+		t.Fatalf("want viewer + enterprise operator bindings, got %+v", bindings)
+	}
+
+	// 组变更后再次登录：同一用户，撤销管理员并收回不再匹配的授权，手工授权保留
+	svc.roles.Bind(user.ID, rbac.RoleOperator, rbac.Scope{Type: rbac.ScopeBot, ID: "10001"}, "admin")
+	id = idp.login(t, p, jwt.MapClaims{"sub": "u-1", "preferred_username": "alice", "groups": []string{"support"}})
+	again, err := svc.Provision(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != user.ID {
+		t.Fatal("returning user must map to the same account")
+	}
+	db.First(again, user.ID)
+	if again.IsAdmin || again.SessionVersion != user.SessionVersion+1 {
+		t.Fatalf("demotion must clear is_admin and revoke sessions: %+v", again)
+	}
+	bindings, _ = svc.roles.Bindings(user.ID)
+	var roles []string
+	for _, b := range bindings {
+		roles = append(roles, b.Role+"@"+b.ScopeType)
+	}
+	if strings.Join(roles, ",") != "viewer@global,operator@bot" {
+		t.Fatalf("bindings after resync: %v", roles)
+	}
+
+	// 禁用的用户不能通过 SSO 登录
+	db.Model(&models.User{}).Where("id = ?", user.ID).Update("active", false)
+	if _, err := svc.Provision(id); err != ErrUserDisabled {
+		t.Fatalf("disabled user: %v", err)
+	}
+}
+
+func TestTokenValidation(t *testing.T) {
+	idp := newMockIdP(t)
+	p := NewProvider(config.OIDCConfig{Issuer: idp.URL, ClientID: "botmatrix", RedirectURL: "https://nexus/cb"})
+	ctx := context.Background()
+
+	if _, err := p.Exchange(ctx, "code-unknown", "verifier"); err == nil {
+		t.Fatal("unknown code must be rejected")
+	}
+
+	raw := idp.sign(t, jwt.MapClaims{"sub": "u-1", "nonce": "n1"})
+	if _, err := p.VerifyIDToken(ctx, raw, "n2"); err == nil {
+		t.Fatal("nonce mismatch must be rejected")
+	}
+	if _, err := p.VerifyIDToken(ctx, raw[:len(raw)-4]+"AAAA", "n1"); err == nil {
+		t.Fatal("bad signature must be rejected")
+	}
+	other := NewProvider(config.OIDCConfig{Issuer: idp.URL, ClientID: "someone-else"})
+	if _, err := other.VerifyIDToken(ctx, raw, "n1"); err == nil {
+		t.Fatal("audience mismatch must be rejected")
+	}
+
+	logout := idp.sign(t, jwt.MapClaims{"sub": "u-1", "sid": "s-1", "events": map[string]any{backchannelLogoutEvent: map[string]any{}}})
+	iss, sub, sid, err := p.VerifyLogoutToken(ctx, logout)
+	if err != nil || iss != idp.URL || sub != "u-1" || sid != "s-1" {
+		t.Fatalf("logout token: %v %s %s %s", err, iss, sub, sid)
+	}
+	if _, _, _, err := p.VerifyLogoutToken(ctx, raw); err == nil {
+		t.Fatal("an ID token is not a logout token")
+	}
+	if u := p.EndSessionURL(ctx); !strings.HasPrefix(u, idp.URL+"/logout?client_id=botmatrix") {
+		t.Fatalf("end session URL %q", u)
+	}
+}
+
+func TestLoginStateAndBackchannelLookup(t *testing.T) {
+	now := time.Now()
+	state, _ := NewLoginState("//evil.example.com", now)
+	if state.Redirect != "/" {
+		t.Fatalf("open redirect not blocked: %q", state.Redirect)
+	}
+	cookie := state.Encode("secret")
+	if _, err := DecodeLoginState(cookie, "other", state.State, now); err == nil {
+		t.Fatal("signature must be checked")
+	}
+	if _, err := DecodeLoginState(cookie, "secret", "forged", now); err == nil {
+		t.Fatal("state must match")
+	}
+	if _, err := DecodeLoginState(cookie, "secret", state.State, now.Add(StateTTL+time.Minute)); err == nil {
+		t.Fatal("expired state must be rejected")
+	}
+
+	svc, _ := newTestService(t, config.OIDCConfig{})
+	user, err := svc.Provision(&Identity{Issuer: "https://idp", Subject: "u-9", SessionID: "s-9", Username: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range [][2]string{{"u-9", ""}, {"", "s-9"}, {"u-9", "s-9"}} {
+		users, err := svc.SessionUsers("https://idp", c[0], c[1])
+		if err != nil || len(users) != 1 || users[0].ID != user.ID {
+			t.Fatalf("lookup %v: %v %v", c, users, err)
+		}
+	}
+	if users, _ := svc.SessionUsers("https://idp", "", "other"); len(users) != 0 {
+		t.Fatal("unrelated session must not match")
+	}
+	if !svc.Linked(user.ID) {
+		t.Fatal("provisioned user must be linked")
+	}
+}
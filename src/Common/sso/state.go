@@ -0,0 +1,101 @@
+package sso
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// StateTTL 从发起登录到 IdP 回调的最长时间
+const StateTTL = 10 * time.Minute
+
+// LoginState 一次登录流程的 state、nonce 与 PKCE verifier。
+// 以签名 Cookie 的形式保存在浏览器中，回调可由任意 BotNexus 实例处理
+type LoginState struct {
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Redirect string `json:"r"` // 登录成功后跳转的站内路径
+	Expires  int64  `json:"e"`
+}
+
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// NewLoginState 生成新的登录状态
+func NewLoginState(redirect string, now time.Time) (*LoginState, error) {
+	s := &LoginState{Redirect: SafeRedirect(redirect), Expires: now.Add(StateTTL).Unix()}
+	var err error
+	if s.State, err = randomString(24); err != nil {
+		return nil, err
+	}
+	if s.Nonce, err = randomString(24); err != nil {
+		return nil, err
+	}
+	// RFC 7636 要求 verifier 为 43-128 个字符
+	if s.Verifier, err = randomString(48); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Challenge 计算 PKCE S256 code_challenge
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func sign(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte("oidc-state:"+secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Encode 序列化并签名，用作 Cookie 值
+func (s *LoginState) Encode(secret string) string {
+	data, _ := json.Marshal(s)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + sign(payload, secret)
+}
+
+// DecodeLoginState 校验签名、有效期与回调中的 state 参数
+func DecodeLoginState(value, secret, state string, now time.Time) (*LoginState, error) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(sign(payload, secret))) {
+		return nil, errors.New("invalid login state")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.New("invalid login state")
+	}
+	var s LoginState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errors.New("invalid login state")
+	}
+	if now.Unix() > s.Expires {
+		return nil, errors.New("login state expired")
+	}
+	if subtle.ConstantTimeCompare([]byte(s.State), []byte(state)) != 1 {
+		return nil, errors.New("state mismatch")
+	}
+	return &s, nil
+}
+
+// SafeRedirect 只允许站内路径，防止登录流程被用作开放重定向
+func SafeRedirect(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
@@ -86,6 +86,10 @@ func (a *AIParser) MatchSkillByLLM(ctx context.Context, input string, modelID ui
 			if gid, ok := parseCtx["effective_group_id"].(string); ok && gid != "" {
 				filter.OwnerType = "group"
 				filter.OwnerID = gid
+			} else if uid, ok := parseCtx["nexus_uid"].(string); ok && uid != "" {
+				// 个人知识库授权随跨平台身份迁移
+				filter.OwnerType = "user"
+				filter.OwnerID = uid
 			} else if uid, ok := parseCtx["user_id"].(string); ok && uid != "" {
 				filter.OwnerType = "user"
 				filter.OwnerID = uid
@@ -96,7 +100,11 @@ func (a *AIParser) MatchSkillByLLM(ctx context.Context, input string, modelID ui
 		if err == nil && len(chunks) > 0 {
 			ragContext = "\n\n### 参考文档 (RAG):\n"
 			for i, chunk := range chunks {
-				ragContext += fmt.Sprintf("[%d] 来源: %s\n%s\n", i+1, chunk.Source, chunk.Content)
+				n := i + 1
+				if chunk.Citation != nil {
+					n = chunk.Citation.Index
+				}
+				ragContext += fmt.Sprintf("[%d] 来源: %s\n%s\n", n, chunk.Source, chunk.Content)
 			}
 			log.Printf("[AI-Task] RAG retrieved %d chunks for query: %s", len(chunks), input)
 		}
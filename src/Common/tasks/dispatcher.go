@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"BotMatrix/common/log"
+	"BotMatrix/common/metrics"
 	"BotMatrix/common/models"
 	"BotMatrix/common/types"
 	"context"
@@ -91,6 +92,7 @@ func (d *Dispatcher) Dispatch(execution models.Execution) {
 	// 2. 获取关联的 Task
 	var task models.Task
 	if err := d.db.Preload("Tags").First(&task, execution.TaskID).Error; err != nil {
+		metrics.TaskExecutions.WithLabelValues(metrics.Unknown, string(models.ExecFailed)).Inc()
 		d.updateStatus(execution.ID, models.ExecFailed, fmt.Errorf("task not found: %v", err))
 		return
 	}
@@ -106,6 +108,7 @@ func (d *Dispatcher) Dispatch(execution models.Execution) {
 	// 4. 查找处理器并执行
 	handler, ok := d.actions[task.ActionType]
 	if !ok {
+		metrics.TaskExecutions.WithLabelValues(metrics.Value(task.ActionType), string(models.ExecFailed)).Inc()
 		d.updateStatus(execution.ID, models.ExecFailed, fmt.Errorf("unknown action type: %s", task.ActionType))
 		return
 	}
@@ -119,18 +122,21 @@ func (d *Dispatcher) Dispatch(execution models.Execution) {
 		}
 
 		if execution.RetryCount >= execution.MaxRetries {
+			metrics.TaskExecutions.WithLabelValues(metrics.Value(task.ActionType), string(models.ExecDead)).Inc()
 			updates["status"] = models.ExecDead
 			d.updateStatusDetailed(execution.ID, updates, err)
 		} else {
 			// 计算下次重试时间 (指数退避)
 			nextRetry := time.Now().Add(time.Duration(execution.RetryCount*execution.RetryCount) * time.Minute)
 			execution.NextRetryTime = &nextRetry
+			metrics.TaskExecutions.WithLabelValues(metrics.Value(task.ActionType), string(models.ExecFailed)).Inc()
 			updates["status"] = models.ExecFailed
 			updates["next_retry_time"] = nextRetry
 			d.updateStatusDetailed(execution.ID, updates, err)
 		}
 	} else {
 		// 6. 成功处理
+		metrics.TaskExecutions.WithLabelValues(metrics.Value(task.ActionType), string(models.ExecSuccess)).Inc()
 		d.updateStatus(execution.ID, models.ExecSuccess, nil)
 	}
 }
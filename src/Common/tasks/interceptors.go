@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"BotMatrix/common/identity"
 	"BotMatrix/common/models"
 	"BotMatrix/common/types"
 	"log"
@@ -33,6 +34,8 @@ type InterceptorManager struct {
 	db           *gorm.DB
 	ai           *AIParser
 	interceptors []Interceptor
+	Shadow       *ShadowManager
+	Identity     *identity.Service
 }
 
 func NewInterceptorManager(db *gorm.DB, ai *AIParser) *InterceptorManager {
@@ -40,12 +43,14 @@ func NewInterceptorManager(db *gorm.DB, ai *AIParser) *InterceptorManager {
 		db:           db,
 		ai:           ai,
 		interceptors: make([]Interceptor, 0),
+		Shadow:       NewShadowManager(db),
+		Identity:     identity.NewService(db),
 	}
 	// 注册默认拦截器
 	im.Add(&StrategyInterceptor{})
-	im.Add(&IdentityInterceptor{})
+	im.Add(&IdentityInterceptor{Identity: im.Identity})
 	im.Add(&SemanticRoutingInterceptor{})
-	im.Add(&ShadowInterceptor{})
+	im.Add(&ShadowInterceptor{Shadow: im.Shadow})
 	return im
 }
 
@@ -119,8 +124,10 @@ func (s *StrategyInterceptor) BeforeDispatch(ctx *InterceptorContext) (bool, err
 	return true, nil
 }
 
-// IdentityInterceptor 统一身份拦截器 (NexusUID 映射)
-type IdentityInterceptor struct{}
+// IdentityInterceptor 统一身份拦截器 (NexusUID 映射)，首次发言的用户自动建档
+type IdentityInterceptor struct {
+	Identity *identity.Service
+}
 
 func (i *IdentityInterceptor) Name() string { return "Identity" }
 func (i *IdentityInterceptor) BeforeDispatch(ctx *InterceptorContext) (bool, error) {
@@ -128,15 +135,27 @@ func (i *IdentityInterceptor) BeforeDispatch(ctx *InterceptorContext) (bool, err
 		return true, nil
 	}
 
-	var identity models.UserIdentity
-	err := ctx.DB.Where("platform = ? AND platform_uid = ?", ctx.Platform, ctx.UserID).First(&identity).Error
-	if err == nil {
+	var nexusUID string
+	if i.Identity != nil && ctx.Message.MessageType != "" {
+		if id, err := i.Identity.Ensure(ctx.Platform, ctx.UserID, ctx.Message.SenderName); err == nil {
+			nexusUID = id.NexusUID
+		} else {
+			log.Printf("[Interceptor] Failed to ensure identity for %s:%s: %v", ctx.Platform, ctx.UserID, err)
+		}
+	} else {
+		var identity models.UserIdentity
+		if err := ctx.DB.Where("platform = ? AND platform_uid = ?", ctx.Platform, ctx.UserID).First(&identity).Error; err == nil {
+			nexusUID = identity.NexusUID
+		}
+	}
+
+	if nexusUID != "" {
 		// 注入统一身份 ID
 		if ctx.Message.Extras == nil {
 			ctx.Message.Extras = make(map[string]any)
 		}
-		ctx.Message.Extras["nexus_uid"] = identity.NexusUID
-		log.Printf("[Interceptor] Identity mapped: %s:%s -> %s", ctx.Platform, ctx.UserID, identity.NexusUID)
+		ctx.Message.Extras["nexus_uid"] = nexusUID
+		log.Printf("[Interceptor] Identity mapped: %s:%s -> %s", ctx.Platform, ctx.UserID, nexusUID)
 	}
 
 	return true, nil
@@ -147,9 +166,9 @@ type SemanticRoutingInterceptor struct{}
 
 func (s *SemanticRoutingInterceptor) Name() string { return "SemanticRouting" }
 func (s *SemanticRoutingInterceptor) BeforeDispatch(ctx *InterceptorContext) (bool, error) {
-	// 仅对文本消息进行语义识别
+	// 仅对文本消息进行语义识别；人工接管的会话不做意图识别，避免触发 AI 回复
 	msg := ctx.Message.RawMessage
-	if msg == "" {
+	if msg == "" || ctx.Message.IsHandoff() {
 		return true, nil
 	}
 
@@ -188,10 +207,26 @@ func (s *SemanticRoutingInterceptor) BeforeDispatch(ctx *InterceptorContext) (bo
 }
 
 // ShadowInterceptor 影子执行拦截器 (A/B 测试)
-type ShadowInterceptor struct{}
+// 命中规则的消息会在 Extras 中标记影子 Worker，由分发方额外投递一份副本
+type ShadowInterceptor struct {
+	Shadow *ShadowManager
+}
 
 func (s *ShadowInterceptor) Name() string { return "Shadow" }
 func (s *ShadowInterceptor) BeforeDispatch(ctx *InterceptorContext) (bool, error) {
-	// 影子执行逻辑...
+	if s.Shadow == nil || ctx.Message == nil || ctx.Message.PostType != "message" {
+		return true, nil
+	}
+
+	rule := s.Shadow.MatchRule(s.Shadow.Rules(), ctx)
+	if rule == nil {
+		return true, nil
+	}
+
+	if ctx.Message.Extras == nil {
+		ctx.Message.Extras = make(map[string]any)
+	}
+	ctx.Message.Extras["shadow_worker_id"] = rule.TargetWorkerID
+	ctx.Message.Extras["shadow_rule_id"] = rule.ID
 	return true, nil
 }
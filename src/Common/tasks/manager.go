@@ -63,8 +63,8 @@ func NewTaskManager(db *gorm.DB, rdb *redis.Client, botManager BotManager, sourc
 		&models.TaskTag{},
 		&models.Strategy{},
 		&models.AIDraft{},
-		&models.UserIdentity{},
 		&models.ShadowRule{},
+		&models.ShadowRecord{},
 	)
 	if err != nil {
 		log.Printf("[TaskManager] AutoMigrate failed: %v", err)
@@ -75,6 +75,9 @@ func NewTaskManager(db *gorm.DB, rdb *redis.Client, botManager BotManager, sourc
 	tagging := NewTaggingManager(db)
 	ai := NewAIParser()
 	interceptors := NewInterceptorManager(db, ai)
+	if err := interceptors.Identity.Migrate(); err != nil {
+		log.Printf("[TaskManager] Identity migrate failed: %v", err)
+	}
 
 	tm := &TaskManager{
 		DB:           db,
@@ -104,6 +107,9 @@ func (tm *TaskManager) Start(startScheduler bool) {
 	if tm.Syncer != nil {
 		tm.Syncer.Start(context.Background())
 	}
+	if tm.Interceptors != nil && tm.Interceptors.Shadow != nil {
+		tm.Interceptors.Shadow.Start(context.Background())
+	}
 }
 
 func (tm *TaskManager) Stop() {
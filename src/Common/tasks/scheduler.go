@@ -20,6 +20,9 @@ type Scheduler struct {
 	stopChan   chan struct{}
 	wg         sync.WaitGroup
 	interval   time.Duration
+
+	// ShouldRun 返回 false 时跳过本轮扫描，集群模式下只由 leader 扫描；nil 表示始终扫描
+	ShouldRun func() bool
 }
 
 func NewScheduler(db *gorm.DB, dispatcher *Dispatcher) *Scheduler {
@@ -55,6 +58,9 @@ func (s *Scheduler) run() {
 		case <-s.stopChan:
 			return
 		case <-ticker.C:
+			if s.ShouldRun != nil && !s.ShouldRun() {
+				continue
+			}
 			s.scanAndTrigger()
 		}
 	}
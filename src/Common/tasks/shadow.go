@@ -0,0 +1,503 @@
+package tasks
+
+import (
+	"BotMatrix/common/log"
+	"BotMatrix/common/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultShadowWindow 镜像消息后等待双方响应的时间窗口
+	DefaultShadowWindow = 30 * time.Second
+	// DefaultShadowRefresh 规则缓存的刷新间隔，管理接口修改规则后会立即刷新
+	DefaultShadowRefresh = 30 * time.Second
+	defaultShadowErrors  = 5
+)
+
+// ShadowAction 一次被记录的 Worker 动作
+type ShadowAction struct {
+	Action    string         `json:"action"`
+	Message   string         `json:"message,omitempty"`
+	Params    map[string]any `json:"params,omitempty"`
+	LatencyMs int64          `json:"latency_ms"`
+}
+
+// ShadowTarget 动作的会话定位信息，用于把动作关联回被镜像的消息
+type ShadowTarget struct {
+	SelfID  string
+	GroupID string
+	UserID  string
+}
+
+func (t ShadowTarget) key() string {
+	if t.GroupID != "" {
+		return t.SelfID + "|g|" + t.GroupID
+	}
+	return t.SelfID + "|p|" + t.UserID
+}
+
+// shadowSession 一条被镜像消息的对比会话
+type shadowSession struct {
+	record  models.ShadowRecord
+	started time.Time
+	primary []ShadowAction
+	shadow  []ShadowAction
+}
+
+// ShadowManager 管理影子执行：按规则镜像流量、截获影子 Worker 的动作、记录对比结果，
+// 并在影子 Worker 连续出错时自动停用规则
+type ShadowManager struct {
+	db      *gorm.DB
+	Window  time.Duration
+	Refresh time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*shadowSession // 会话 key -> 最近一次镜像
+	workers  map[string]uint           // 需要截获动作的 Worker ID -> 规则 ID
+	retired  map[string]time.Time      // 规则已停用、仍在等待在途动作的 Worker -> 停用时间
+	rules    []models.ShadowRule       // 已启用规则的缓存
+	loadedAt time.Time
+}
+
+func NewShadowManager(db *gorm.DB) *ShadowManager {
+	sm := &ShadowManager{
+		db:       db,
+		Window:   DefaultShadowWindow,
+		Refresh:  DefaultShadowRefresh,
+		sessions: make(map[string]*shadowSession),
+		workers:  make(map[string]uint),
+		retired:  make(map[string]time.Time),
+	}
+	sm.LoadRules()
+	return sm
+}
+
+// Rules 返回已启用的规则，缓存超过 Refresh 时从数据库重新加载
+func (sm *ShadowManager) Rules() []models.ShadowRule {
+	sm.mu.Lock()
+	rules, fresh := sm.rules, time.Since(sm.loadedAt) < sm.Refresh
+	sm.mu.Unlock()
+	if fresh {
+		return rules
+	}
+	return sm.LoadRules()
+}
+
+// LoadRules 从数据库加载已启用的规则并刷新缓存。
+// 规则停用后其影子 Worker 仍留在截获名单中，直到断开连接或在途动作超过时间窗口，避免迟到的回复发给真实用户
+func (sm *ShadowManager) LoadRules() []models.ShadowRule {
+	if sm.db == nil {
+		return nil
+	}
+	var rules []models.ShadowRule
+	if err := sm.db.Where("is_enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		log.Printf("[Shadow] Failed to load rules: %v", err)
+		sm.mu.Lock()
+		defer sm.mu.Unlock()
+		return sm.rules
+	}
+
+	active := make(map[string]uint, len(rules))
+	for _, r := range rules {
+		if r.TargetWorkerID != "" {
+			active[r.TargetWorkerID] = r.ID
+		}
+	}
+	now := time.Now()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for id := range sm.workers {
+		if _, ok := active[id]; !ok {
+			if _, ok := sm.retired[id]; !ok {
+				sm.retired[id] = now
+			}
+		}
+	}
+	for id, ruleID := range active {
+		sm.workers[id] = ruleID
+		delete(sm.retired, id)
+	}
+	sm.rules = rules
+	sm.loadedAt = now
+	return rules
+}
+
+// ReleaseWorker 影子 Worker 断开连接后调用，规则已停用时将其移出截获名单
+func (sm *ShadowManager) ReleaseWorker(workerID string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, ok := sm.retired[workerID]; ok {
+		delete(sm.retired, workerID)
+		delete(sm.workers, workerID)
+	}
+}
+
+// pruneRetiredLocked 移出停用超过时间窗口且没有进行中对比会话的 Worker，调用方需持有 sm.mu
+func (sm *ShadowManager) pruneRetiredLocked(now time.Time) {
+	for id, since := range sm.retired {
+		if now.Sub(since) <= sm.Window {
+			continue
+		}
+		busy := false
+		for _, s := range sm.sessions {
+			if s.record.ShadowWorkerID == id {
+				busy = true
+				break
+			}
+		}
+		if !busy {
+			delete(sm.retired, id)
+			delete(sm.workers, id)
+		}
+	}
+}
+
+// MatchRule 返回第一条命中当前消息的规则
+func (sm *ShadowManager) MatchRule(rules []models.ShadowRule, ctx *InterceptorContext) *models.ShadowRule {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.TargetWorkerID == "" || !matchShadowPattern(rule.MatchPattern, ctx) {
+			continue
+		}
+		if !inShadowTraffic(rule, ctx) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// matchShadowPattern 支持逗号分隔的多个 glob 模式，匹配 bot_<self_id>、group_<group_id>、
+// user_<user_id>、platform_<platform>，空模式匹配所有消息
+func matchShadowPattern(pattern string, ctx *InterceptorContext) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	candidates := []string{"bot_" + ctx.SelfID, "platform_" + ctx.Platform}
+	if ctx.GroupID != "" {
+		candidates = append(candidates, "group_"+ctx.GroupID)
+	}
+	if ctx.UserID != "" {
+		candidates = append(candidates, "user_"+ctx.UserID)
+	}
+	for _, p := range strings.Split(pattern, ",") {
+		p = strings.TrimSpace(p)
+		for _, c := range candidates {
+			if ok, _ := path.Match(p, c); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inShadowTraffic 按消息 ID 哈希采样，同一条消息的判定结果稳定
+func inShadowTraffic(rule *models.ShadowRule, ctx *InterceptorContext) bool {
+	if rule.TrafficPercent <= 0 {
+		return false
+	}
+	if rule.TrafficPercent >= 100 {
+		return true
+	}
+	key := ctx.Message.ID
+	if key == "" {
+		key = fmt.Sprintf("%s:%s:%d", ctx.UserID, ctx.GroupID, ctx.Message.Time)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(fmt.Sprintf("%d:%s", rule.ID, key)))
+	return int(h.Sum32()%100) < rule.TrafficPercent
+}
+
+// IsShadowWorker 判断 Worker 是否为影子 Worker，影子 Worker 的动作不会真正发送
+func (sm *ShadowManager) IsShadowWorker(workerID string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	_, ok := sm.workers[workerID]
+	return ok
+}
+
+// Begin 在消息同时投递给主 Worker 与影子 Worker 后开启一次对比会话
+func (sm *ShadowManager) Begin(ruleID uint, primaryWorkerID, shadowWorkerID string, ctx *InterceptorContext) {
+	msg := ctx.Message
+	s := &shadowSession{
+		started: time.Now(),
+		record: models.ShadowRecord{
+			RuleID:          ruleID,
+			MessageID:       msg.ID,
+			Platform:        ctx.Platform,
+			SelfID:          ctx.SelfID,
+			GroupID:         ctx.GroupID,
+			UserID:          ctx.UserID,
+			Content:         msg.RawMessage,
+			PrimaryWorkerID: primaryWorkerID,
+			ShadowWorkerID:  shadowWorkerID,
+		},
+	}
+	target := ShadowTarget{SelfID: ctx.SelfID, GroupID: ctx.GroupID, UserID: ctx.UserID}
+
+	sm.mu.Lock()
+	sm.workers[shadowWorkerID] = ruleID
+	delete(sm.retired, shadowWorkerID)
+	// 同一会话的新消息会结束上一轮对比
+	prev := sm.sessions[target.key()]
+	sm.sessions[target.key()] = s
+	sm.mu.Unlock()
+
+	if prev != nil {
+		sm.finalize(prev)
+	}
+	sm.Flush(false)
+}
+
+// RecordAction 记录 Worker 针对被镜像会话发出的动作。
+// 返回 true 表示该动作来自影子 Worker，调用方必须截获而不是发送给机器人。
+// workerID 为空时无法区分主 Worker 与影子 Worker，落在进行中对比会话内的动作一律截获
+func (sm *ShadowManager) RecordAction(workerID string, target ShadowTarget, action ShadowAction) bool {
+	if workerID == "" {
+		sm.mu.Lock()
+		s := sm.sessions[target.key()]
+		open := s != nil && time.Since(s.started) <= sm.Window
+		sm.mu.Unlock()
+		if open {
+			log.Printf("[Shadow] Dropped action %s without worker_id in shadowed conversation %s", action.Action, target.key())
+		}
+		return open
+	}
+
+	sm.mu.Lock()
+	_, isShadow := sm.workers[workerID]
+	s := sm.sessions[target.key()]
+	if s != nil && time.Since(s.started) <= sm.Window {
+		action.LatencyMs = time.Since(s.started).Milliseconds()
+		switch workerID {
+		case s.record.ShadowWorkerID:
+			s.shadow = append(s.shadow, action)
+			isShadow = true
+		case s.record.PrimaryWorkerID:
+			s.primary = append(s.primary, action)
+		default:
+			// 主 Worker 由负载均衡选出时 ID 可能为空，非影子 Worker 的动作都视为主路径
+			if !isShadow {
+				s.primary = append(s.primary, action)
+			}
+		}
+	}
+	sm.mu.Unlock()
+
+	if isShadow {
+		log.Printf("[Shadow] Captured action %s from shadow worker %s", action.Action, workerID)
+		sm.ReportSuccess(workerID)
+	}
+	return isShadow
+}
+
+// Flush 持久化已超过时间窗口的会话；force 为 true 时持久化全部会话
+func (sm *ShadowManager) Flush(force bool) {
+	var done []*shadowSession
+	sm.mu.Lock()
+	for k, s := range sm.sessions {
+		if force || time.Since(s.started) > sm.Window {
+			done = append(done, s)
+			delete(sm.sessions, k)
+		}
+	}
+	sm.pruneRetiredLocked(time.Now())
+	sm.mu.Unlock()
+
+	for _, s := range done {
+		sm.finalize(s)
+	}
+}
+
+// Start 定期持久化过期会话
+func (sm *ShadowManager) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(sm.Window / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				sm.Flush(true)
+				return
+			case <-ticker.C:
+				sm.Flush(false)
+			}
+		}
+	}()
+}
+
+func (sm *ShadowManager) finalize(s *shadowSession) {
+	rec := buildShadowRecord(s)
+	if sm.db == nil {
+		return
+	}
+	if err := sm.db.Create(&rec).Error; err != nil {
+		log.Printf("[Shadow] Failed to save record for message %s: %v", rec.MessageID, err)
+	}
+}
+
+// buildShadowRecord 汇总双方的动作并计算差异
+func buildShadowRecord(s *shadowSession) models.ShadowRecord {
+	rec := s.record
+	rec.CreatedAt = s.started
+	rec.PrimaryLatencyMs, rec.PrimaryReply = summarizeActions(s.primary)
+	rec.ShadowLatencyMs, rec.ShadowReply = summarizeActions(s.shadow)
+	rec.ReplyMatch = strings.TrimSpace(rec.PrimaryReply) == strings.TrimSpace(rec.ShadowReply)
+	rec.ActionsMatch = reflect.DeepEqual(actionNames(s.primary), actionNames(s.shadow))
+
+	primary, _ := json.Marshal(s.primary)
+	shadow, _ := json.Marshal(s.shadow)
+	rec.PrimaryActions = string(primary)
+	rec.ShadowActions = string(shadow)
+	return rec
+}
+
+// summarizeActions 返回首个动作的延迟与所有消息动作拼接后的回复文本
+func summarizeActions(actions []ShadowAction) (int64, string) {
+	if len(actions) == 0 {
+		return -1, ""
+	}
+	var replies []string
+	for _, a := range actions {
+		if a.Message != "" {
+			replies = append(replies, a.Message)
+		}
+	}
+	return actions[0].LatencyMs, strings.Join(replies, "\n")
+}
+
+func actionNames(actions []ShadowAction) []string {
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, a.Action)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReportError 记录影子 Worker 的一次错误，连续错误达到上限时自动停用规则
+func (sm *ShadowManager) ReportError(workerID string, reason string) {
+	sm.mu.Lock()
+	ruleID, ok := sm.workers[workerID]
+	sm.mu.Unlock()
+	if !ok || sm.db == nil {
+		return
+	}
+
+	var rule models.ShadowRule
+	if err := sm.db.First(&rule, ruleID).Error; err != nil {
+		return
+	}
+	rule.ErrorCount++
+	maxErrors := rule.MaxErrors
+	if maxErrors <= 0 {
+		maxErrors = defaultShadowErrors
+	}
+	updates := map[string]any{"error_count": rule.ErrorCount}
+	if rule.IsEnabled && rule.ErrorCount >= maxErrors {
+		updates["is_enabled"] = false
+		updates["disabled_reason"] = fmt.Sprintf("auto disabled after %d consecutive errors: %s", rule.ErrorCount, reason)
+		log.Printf("[Shadow] Rule %s disabled: shadow worker %s failed %d times (%s)", rule.Name, workerID, rule.ErrorCount, reason)
+	} else {
+		log.Printf("[Shadow] Shadow worker %s error (%d/%d): %s", workerID, rule.ErrorCount, maxErrors, reason)
+	}
+	sm.db.Model(&models.ShadowRule{}).Where("id = ?", rule.ID).Updates(updates)
+
+	if _, disabled := updates["is_enabled"]; disabled {
+		sm.LoadRules()
+	}
+}
+
+// ReportSuccess 影子 Worker 正常响应时清零连续错误计数
+func (sm *ShadowManager) ReportSuccess(workerID string) {
+	sm.mu.Lock()
+	ruleID, ok := sm.workers[workerID]
+	sm.mu.Unlock()
+	if !ok || sm.db == nil {
+		return
+	}
+	sm.db.Model(&models.ShadowRule{}).Where("id = ? AND error_count > 0", ruleID).Update("error_count", 0)
+}
+
+// ShadowReport 规则维度的对比汇总
+type ShadowReport struct {
+	RuleID              uint                  `json:"rule_id"`
+	Total               int                   `json:"total"`
+	PrimaryReplied      int                   `json:"primary_replied"`
+	ShadowReplied       int                   `json:"shadow_replied"`
+	ReplyMatchRate      float64               `json:"reply_match_rate"`
+	ActionsMatchRate    float64               `json:"actions_match_rate"`
+	AvgPrimaryLatencyMs float64               `json:"avg_primary_latency_ms"`
+	AvgShadowLatencyMs  float64               `json:"avg_shadow_latency_ms"`
+	Records             []models.ShadowRecord `json:"records"`
+}
+
+// Report 生成规则的差异报告，limit 控制返回的明细条数 (汇总基于 since 之后的全部记录)
+func (sm *ShadowManager) Report(ruleID uint, since time.Time, limit int) (*ShadowReport, error) {
+	if sm.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	sm.Flush(false)
+
+	var records []models.ShadowRecord
+	query := sm.db.Where("rule_id = ?", ruleID)
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+	if err := query.Order("id DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	report := summarizeShadowRecords(records)
+	report.RuleID = ruleID
+	if limit > 0 && len(report.Records) > limit {
+		report.Records = report.Records[:limit]
+	}
+	return report, nil
+}
+
+func summarizeShadowRecords(records []models.ShadowRecord) *ShadowReport {
+	report := &ShadowReport{Total: len(records), Records: records}
+	if len(records) == 0 {
+		return report
+	}
+
+	var replyMatch, actionsMatch int
+	var primaryLatency, shadowLatency int64
+	for _, r := range records {
+		if r.ReplyMatch {
+			replyMatch++
+		}
+		if r.ActionsMatch {
+			actionsMatch++
+		}
+		if r.PrimaryLatencyMs >= 0 {
+			report.PrimaryReplied++
+			primaryLatency += r.PrimaryLatencyMs
+		}
+		if r.ShadowLatencyMs >= 0 {
+			report.ShadowReplied++
+			shadowLatency += r.ShadowLatencyMs
+		}
+	}
+	report.ReplyMatchRate = float64(replyMatch) / float64(len(records))
+	report.ActionsMatchRate = float64(actionsMatch) / float64(len(records))
+	if report.PrimaryReplied > 0 {
+		report.AvgPrimaryLatencyMs = float64(primaryLatency) / float64(report.PrimaryReplied)
+	}
+	if report.ShadowReplied > 0 {
+		report.AvgShadowLatencyMs = float64(shadowLatency) / float64(report.ShadowReplied)
+	}
+	return report
+}
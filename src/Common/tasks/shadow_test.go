@@ -0,0 +1,243 @@
+package tasks
+
+import (
+	"BotMatrix/common/models"
+	"BotMatrix/common/types"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newShadowTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ShadowRule{}, &models.ShadowRecord{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestMatchShadowPattern(t *testing.T) {
+	ctx := &InterceptorContext{Platform: "qq", SelfID: "1001", GroupID: "2002", UserID: "3003", Message: &types.InternalMessage{}}
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"", true},
+		{"*", true},
+		{"bot_1001", true},
+		{"group_20*", true},
+		{"user_9*, platform_qq", true},
+		{"group_9*", false},
+		{"bot_1002,user_1", false},
+	}
+	for _, tt := range tests {
+		if got := matchShadowPattern(tt.pattern, ctx); got != tt.want {
+			t.Errorf("pattern %q: want %v, got %v", tt.pattern, tt.want, got)
+		}
+	}
+}
+
+func TestShadowTrafficPercentIsStable(t *testing.T) {
+	rule := &models.ShadowRule{ID: 1, TrafficPercent: 50}
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		ctx := &InterceptorContext{Message: &types.InternalMessage{ID: time.Unix(int64(i), 0).String()}}
+		first := inShadowTraffic(rule, ctx)
+		if first != inShadowTraffic(rule, ctx) {
+			t.Fatal("sampling must be deterministic for the same message")
+		}
+		if first {
+			hits++
+		}
+	}
+	if hits < 400 || hits > 600 {
+		t.Errorf("expected roughly half of the traffic, got %d/1000", hits)
+	}
+
+	if inShadowTraffic(&models.ShadowRule{TrafficPercent: 0}, &InterceptorContext{Message: &types.InternalMessage{ID: "x"}}) {
+		t.Error("0% traffic should never be mirrored")
+	}
+}
+
+func TestShadowInterceptorMarksMessage(t *testing.T) {
+	db := newShadowTestDB(t)
+	db.Create(&models.ShadowRule{Name: "canary", TargetWorkerID: "worker-next", MatchPattern: "group_*", IsEnabled: true, TrafficPercent: 100})
+
+	interceptor := &ShadowInterceptor{Shadow: NewShadowManager(db)}
+	msg := &types.InternalMessage{ID: "m1", PostType: "message", GroupID: "2002"}
+	ok, err := interceptor.BeforeDispatch(&InterceptorContext{SelfID: "1001", GroupID: "2002", Message: msg})
+	if !ok || err != nil {
+		t.Fatalf("shadow interceptor must never block: %v %v", ok, err)
+	}
+	if msg.Extras["shadow_worker_id"] != "worker-next" {
+		t.Errorf("expected shadow worker to be set, got %v", msg.Extras)
+	}
+
+	private := &types.InternalMessage{ID: "m2", PostType: "message"}
+	interceptor.BeforeDispatch(&InterceptorContext{SelfID: "1001", UserID: "3003", Message: private})
+	if _, ok := private.Extras["shadow_worker_id"]; ok {
+		t.Error("private message should not match group_* rule")
+	}
+}
+
+func TestShadowSessionRecordsBothSides(t *testing.T) {
+	db := newShadowTestDB(t)
+	rule := models.ShadowRule{Name: "canary", TargetWorkerID: "shadow", IsEnabled: true, TrafficPercent: 100}
+	db.Create(&rule)
+	sm := NewShadowManager(db)
+
+	ctx := &InterceptorContext{SelfID: "1001", GroupID: "2002", UserID: "3003", Message: &types.InternalMessage{ID: "m1", RawMessage: "hi"}}
+	sm.Begin(rule.ID, "primary", "shadow", ctx)
+
+	target := ShadowTarget{SelfID: "1001", GroupID: "2002"}
+	if sm.RecordAction("primary", target, ShadowAction{Action: "send_group_msg", Message: "hello"}) {
+		t.Error("primary actions must not be captured")
+	}
+	if !sm.RecordAction("shadow", target, ShadowAction{Action: "send_group_msg", Message: "hello!"}) {
+		t.Error("shadow actions must be captured")
+	}
+	// Shadow workers are captured even outside of a session
+	if !sm.RecordAction("shadow", ShadowTarget{SelfID: "1001", UserID: "9"}, ShadowAction{Action: "delete_msg"}) {
+		t.Error("shadow actions outside a session must still be captured")
+	}
+
+	sm.Flush(true)
+	report, err := sm.Report(rule.ID, time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if report.Total != 1 || report.PrimaryReplied != 1 || report.ShadowReplied != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	rec := report.Records[0]
+	if rec.PrimaryReply != "hello" || rec.ShadowReply != "hello!" {
+		t.Errorf("unexpected replies: %q vs %q", rec.PrimaryReply, rec.ShadowReply)
+	}
+	if rec.ReplyMatch || !rec.ActionsMatch {
+		t.Errorf("expected reply mismatch and action match, got %v %v", rec.ReplyMatch, rec.ActionsMatch)
+	}
+}
+
+func TestShadowRuleAutoDisable(t *testing.T) {
+	db := newShadowTestDB(t)
+	rule := models.ShadowRule{Name: "canary", TargetWorkerID: "shadow", IsEnabled: true, TrafficPercent: 100, MaxErrors: 2}
+	db.Create(&rule)
+	sm := NewShadowManager(db)
+
+	sm.ReportError("shadow", "timeout")
+	sm.ReportSuccess("shadow")
+	sm.ReportError("shadow", "timeout")
+
+	var got models.ShadowRule
+	db.First(&got, rule.ID)
+	if !got.IsEnabled || got.ErrorCount != 1 {
+		t.Fatalf("success should reset the error streak, got enabled=%v errors=%d", got.IsEnabled, got.ErrorCount)
+	}
+
+	sm.ReportError("shadow", "timeout")
+	db.First(&got, rule.ID)
+	if got.IsEnabled || got.DisabledReason == "" {
+		t.Errorf("expected rule to be disabled, got %+v", got)
+	}
+	if len(sm.Rules()) != 0 {
+		t.Error("disabled rule must no longer mirror traffic")
+	}
+	// 在途回复仍需截获，直到 Worker 断开连接
+	if !sm.IsShadowWorker("shadow") {
+		t.Error("worker of a disabled rule must stay captured until it drains")
+	}
+	sm.ReleaseWorker("shadow")
+	if sm.IsShadowWorker("shadow") {
+		t.Error("disconnected worker of a disabled rule should be released")
+	}
+}
+
+func TestShadowRulesAreCached(t *testing.T) {
+	db := newShadowTestDB(t)
+	db.Create(&models.ShadowRule{Name: "canary", TargetWorkerID: "shadow", IsEnabled: true, TrafficPercent: 100})
+	sm := NewShadowManager(db)
+
+	var queries int
+	db.Callback().Query().Before("gorm:query").Register("count_queries", func(*gorm.DB) { queries++ })
+	for i := 0; i < 10; i++ {
+		if len(sm.Rules()) != 1 {
+			t.Fatal("expected the cached rule")
+		}
+	}
+	if queries != 0 {
+		t.Errorf("rules reloaded %d times within the refresh interval", queries)
+	}
+
+	db.Model(&models.ShadowRule{}).Where("1 = 1").Update("is_enabled", false)
+	sm.Refresh = 0
+	if len(sm.Rules()) != 0 || queries != 1 {
+		t.Errorf("stale cache must reload: rules=%d queries=%d", len(sm.Rules()), queries)
+	}
+}
+
+func TestShadowWorkerDrainsAfterWindow(t *testing.T) {
+	db := newShadowTestDB(t)
+	rule := models.ShadowRule{Name: "canary", TargetWorkerID: "shadow", IsEnabled: true, TrafficPercent: 100}
+	db.Create(&rule)
+	sm := NewShadowManager(db)
+	sm.Window = 20 * time.Millisecond
+
+	sm.Begin(rule.ID, "primary", "shadow", &InterceptorContext{SelfID: "1001", GroupID: "2002", Message: &types.InternalMessage{ID: "m1"}})
+	db.Model(&rule).Update("is_enabled", false)
+	sm.LoadRules()
+
+	if !sm.RecordAction("shadow", ShadowTarget{SelfID: "1001", GroupID: "2002"}, ShadowAction{Action: "send_group_msg"}) {
+		t.Fatal("in-flight reply of a disabled rule must be captured")
+	}
+	time.Sleep(30 * time.Millisecond)
+	sm.Flush(false)
+	if sm.IsShadowWorker("shadow") {
+		t.Error("retired worker should be released once its sessions drained")
+	}
+}
+
+func TestShadowCapturesUnattributedActions(t *testing.T) {
+	db := newShadowTestDB(t)
+	rule := models.ShadowRule{Name: "canary", TargetWorkerID: "shadow", IsEnabled: true, TrafficPercent: 100}
+	db.Create(&rule)
+	sm := NewShadowManager(db)
+	sm.Begin(rule.ID, "", "shadow", &InterceptorContext{SelfID: "1001", GroupID: "2002", Message: &types.InternalMessage{ID: "m1"}})
+
+	if !sm.RecordAction("", ShadowTarget{SelfID: "1001", GroupID: "2002"}, ShadowAction{Action: "send_group_msg"}) {
+		t.Error("actions without worker id in a shadowed conversation must not be sent")
+	}
+	if sm.RecordAction("", ShadowTarget{SelfID: "1001", GroupID: "other"}, ShadowAction{Action: "send_group_msg"}) {
+		t.Error("actions outside shadowed conversations must be delivered")
+	}
+}
+
+func TestSemanticRoutingSkipsHandoffSessions(t *testing.T) {
+	db := newShadowTestDB(t)
+	db.Exec("CREATE TABLE digital_employees (bot_id TEXT)")
+	db.Exec("INSERT INTO digital_employees (bot_id) VALUES ('web_bot')")
+
+	route := func(extras map[string]any) map[string]any {
+		ctx := &InterceptorContext{
+			SelfID:  "web_bot",
+			DB:      db,
+			Message: &types.InternalMessage{PostType: "message", RawMessage: "你好", Extras: extras},
+		}
+		if ok, _ := (&SemanticRoutingInterceptor{}).BeforeDispatch(ctx); !ok {
+			t.Fatal("semantic routing should never block messages")
+		}
+		return ctx.Message.Extras
+	}
+
+	if extras := route(nil); extras["intent_hint"] != "chat" {
+		t.Fatalf("digital employee messages should be routed to AI chat, got %v", extras)
+	}
+	// 人工接管期间消息照常转发，但不带 AI 意图标记
+	if extras := route(map[string]any{"handoff": true}); extras["intent_hint"] != nil || extras["is_digital_employee"] != nil {
+		t.Fatalf("handed-off sessions should skip AI routing, got %v", extras)
+	}
+}
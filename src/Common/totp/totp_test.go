@@ -0,0 +1,57 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量 (取后 6 位)
+func TestCodeRFCVectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := Code(secret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("t=%d: got %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateRejectsReplayAndDrift(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	code, _ := Code(secret, Step(now))
+
+	step, ok := Validate(secret, code, now, 0)
+	if !ok || step != Step(now) {
+		t.Fatal("current code must validate")
+	}
+	if _, ok := Validate(secret, code, now, step); ok {
+		t.Fatal("a code must not be accepted twice")
+	}
+	if _, ok := Validate(secret, code, now.Add(30*time.Second), 0); !ok {
+		t.Fatal("one step of clock skew must be tolerated")
+	}
+	if _, ok := Validate(secret, code, now.Add(2*time.Minute), 0); ok {
+		t.Fatal("stale code must be rejected")
+	}
+	if _, ok := Validate(secret, "12345", now, 0); ok {
+		t.Fatal("short code must be rejected")
+	}
+	if u := URL("BotMatrix", "alice", secret); !strings.HasPrefix(u, "otpauth://totp/BotMatrix:alice?") {
+		t.Fatalf("unexpected URL %s", u)
+	}
+}
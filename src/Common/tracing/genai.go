@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartLLM 开始一次大模型请求 span，属性遵循 OpenTelemetry GenAI 语义约定；system 为提供商类型，如 openai、ollama
+func StartLLM(ctx context.Context, system, model string, stream bool) (context.Context, trace.Span) {
+	return Start(ctx, "chat "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.system", system),
+			attribute.String("gen_ai.request.model", model),
+			attribute.Bool("gen_ai.request.stream", stream),
+		))
+}
+
+// RecordUsage 在大模型请求 span 上记录 token 用量与结束原因
+func RecordUsage(span trace.Span, inputTokens, outputTokens int, finishReason string) {
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", inputTokens),
+		attribute.Int("gen_ai.usage.output_tokens", outputTokens),
+	)
+	if finishReason != "" {
+		span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{finishReason}))
+	}
+}
+
+// StartTool 开始一次工具执行 span
+func StartTool(ctx context.Context, name, callID string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.operation.name", "execute_tool"),
+		attribute.String("gen_ai.tool.name", name),
+	}
+	if callID != "" {
+		attrs = append(attrs, attribute.String("gen_ai.tool.call.id", callID))
+	}
+	return Start(ctx, "execute_tool "+name, trace.WithAttributes(attrs...))
+}
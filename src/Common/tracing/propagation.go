@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+
+	"BotMatrix/common/types"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 追踪上下文在 OneBot 消息中的字段名 (W3C Trace Context)
+const (
+	KeyTraceParent = "traceparent"
+	KeyTraceState  = "tracestate"
+)
+
+// 消息内的传播固定使用 W3C 格式，未启用导出的进程也会原样透传上游的追踪上下文
+var propagator = propagation.TraceContext{}
+
+// Inject 将 ctx 中的追踪上下文写入消息，下游环节据此继续同一条 trace；ctx 不含 span 时保留原值
+func Inject(ctx context.Context, msg *types.InternalMessage) {
+	if tp, ts, ok := encode(ctx); ok {
+		msg.TraceParent, msg.TraceState = tp, ts
+	}
+}
+
+// Extract 返回带有消息中追踪上下文的 ctx
+func Extract(ctx context.Context, msg types.InternalMessage) context.Context {
+	return decode(ctx, msg.TraceParent, msg.TraceState)
+}
+
+// InjectAction 将追踪上下文写入 Worker 下发的动作
+func InjectAction(ctx context.Context, action *types.InternalAction) {
+	if tp, ts, ok := encode(ctx); ok {
+		action.TraceParent, action.TraceState = tp, ts
+	}
+}
+
+// ExtractAction 返回带有动作中追踪上下文的 ctx，动作本身未携带时读取 params 中的 traceparent
+func ExtractAction(ctx context.Context, action types.InternalAction) context.Context {
+	if action.TraceParent == "" {
+		return ExtractMap(ctx, action.Params)
+	}
+	return decode(ctx, action.TraceParent, action.TraceState)
+}
+
+// InjectMap 将追踪上下文写入 OneBot 格式的 map，用于 Redis 队列负载以及经 WebSocket 转发的事件与动作
+func InjectMap(ctx context.Context, m map[string]any) {
+	tp, ts, ok := encode(ctx)
+	if !ok || m == nil {
+		return
+	}
+	m[KeyTraceParent] = tp
+	if ts != "" {
+		m[KeyTraceState] = ts
+	} else {
+		delete(m, KeyTraceState)
+	}
+}
+
+// ExtractMap 返回带有 map 中追踪上下文的 ctx
+func ExtractMap(ctx context.Context, m map[string]any) context.Context {
+	tp, _ := m[KeyTraceParent].(string)
+	ts, _ := m[KeyTraceState].(string)
+	return decode(ctx, tp, ts)
+}
+
+func encode(ctx context.Context) (traceParent, traceState string, ok bool) {
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return "", "", false
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier[KeyTraceParent], carrier[KeyTraceState], carrier[KeyTraceParent] != ""
+}
+
+// ContextWithTraceParent 返回带有给定 traceparent/tracestate 的 ctx，用于自带字段的消息类型 (如 onebot.Event)
+func ContextWithTraceParent(ctx context.Context, traceParent, traceState string) context.Context {
+	return decode(ctx, traceParent, traceState)
+}
+
+// TraceParent 返回 ctx 中 span 的 traceparent/tracestate，ctx 不含 span 时为空
+func TraceParent(ctx context.Context) (traceParent, traceState string) {
+	traceParent, traceState, _ = encode(ctx)
+	return traceParent, traceState
+}
+
+func decode(ctx context.Context, traceParent, traceState string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if traceParent == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{KeyTraceParent: traceParent}
+	if traceState != "" {
+		carrier[KeyTraceState] = traceState
+	}
+	return propagator.Extract(ctx, carrier)
+}
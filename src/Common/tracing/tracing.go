@@ -0,0 +1,218 @@
+// Package tracing 基于 OpenTelemetry 的分布式追踪。
+// 一条消息从适配器经 Nexus 路由、Redis 队列到 Worker 处理、大模型请求与工具调用，
+// 各环节通过 InternalMessage、队列负载与 InternalAction 中的 W3C traceparent 字段串联为同一条 trace
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"BotMatrix/common/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName 各组件创建 span 使用的 instrumentation 名称
+const TracerName = "BotMatrix"
+
+// 导出器类型
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Options 追踪参数，零值字段使用默认值
+type Options struct {
+	ServiceName string            // 服务名，如 BotNexus、BotWorker、TelegramBot
+	Exporter    string            // none (默认), stdout, file, otlp
+	Endpoint    string            // OTLP/HTTP 地址，host:port 或完整 URL
+	Insecure    bool              // OTLP 使用 HTTP
+	Headers     map[string]string // OTLP 请求头
+	File        string            // file 导出器的输出文件
+	SampleRatio float64           // 根 span 的采样比例，0 表示全部采样
+
+	// SpanExporter 直接指定导出器 (如测试中的内存导出器)，优先于 Exporter，span 结束时同步导出
+	SpanExporter sdktrace.SpanExporter
+	// Writer stdout 导出器的输出，默认 os.Stdout
+	Writer io.Writer
+}
+
+func (o Options) withDefaults() Options {
+	if o.ServiceName == "" {
+		o.ServiceName = "BotMatrix"
+	}
+	if o.Exporter == "" {
+		o.Exporter = ExporterNone
+	}
+	if o.SampleRatio <= 0 || o.SampleRatio > 1 {
+		o.SampleRatio = 1
+	}
+	if o.Writer == nil {
+		o.Writer = os.Stdout
+	}
+	return o
+}
+
+// FromConfig 由配置文件中的 tracing 段生成参数
+func FromConfig(service string, cfg config.TracingConfig) Options {
+	return Options{
+		ServiceName: service,
+		Exporter:    cfg.Exporter,
+		Endpoint:    cfg.Endpoint,
+		Insecure:    cfg.Insecure,
+		Headers:     cfg.Headers,
+		File:        cfg.File,
+		SampleRatio: cfg.SampleRatio,
+	}
+}
+
+// FromEnv 由环境变量生成参数，用于没有 config.json 的适配器进程：
+// TRACING_EXPORTER、OTEL_EXPORTER_OTLP_ENDPOINT (设置后默认使用 otlp)、OTEL_EXPORTER_OTLP_INSECURE、
+// TRACING_FILE、TRACING_SAMPLE_RATIO，OTEL_SERVICE_NAME 覆盖 service
+func FromEnv(service string) Options {
+	opts := Options{
+		ServiceName: service,
+		Exporter:    os.Getenv("TRACING_EXPORTER"),
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Insecure:    os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true",
+		File:        os.Getenv("TRACING_FILE"),
+	}
+	if v := os.Getenv("OTEL_SERVICE_NAME"); v != "" {
+		opts.ServiceName = v
+	}
+	if opts.Exporter == "" && opts.Endpoint != "" {
+		opts.Exporter = ExporterOTLP
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("TRACING_SAMPLE_RATIO"), 64); err == nil {
+		opts.SampleRatio = v
+	}
+	return opts
+}
+
+// Init 设置全局 TracerProvider 与 W3C 传播器，返回的 shutdown 在进程退出前调用以导出剩余 span。
+// 导出器为 none 时不记录 span，但仍会透传上游的追踪上下文
+func Init(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	opts = opts.withDefaults()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	noop := func(context.Context) error { return nil }
+	if opts.SpanExporter == nil && opts.Exporter == ExporterNone {
+		return noop, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", opts.ServiceName)))
+	if err != nil {
+		return noop, err
+	}
+	tpOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	}
+
+	var closer io.Closer
+	if opts.SpanExporter != nil {
+		tpOpts = append(tpOpts, sdktrace.WithSyncer(opts.SpanExporter))
+	} else {
+		exp, c, err := newExporter(ctx, opts)
+		if err != nil {
+			return noop, err
+		}
+		closer = c
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(exp))
+	}
+
+	tp := sdktrace.NewTracerProvider(tpOpts...)
+	otel.SetTracerProvider(tp)
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(opts.Writer))
+		return exp, nil, err
+	case ExporterFile:
+		if opts.File == "" {
+			return nil, nil, fmt.Errorf("tracing: file exporter requires a file path")
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tracing: open %s: %w", opts.File, err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exp, f, nil
+	case ExporterOTLP:
+		if opts.Endpoint == "" {
+			return nil, nil, fmt.Errorf("tracing: otlp exporter requires an endpoint")
+		}
+		var httpOpts []otlptracehttp.Option
+		if strings.Contains(opts.Endpoint, "://") {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		} else {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			httpOpts = append(httpOpts, otlptracehttp.WithHeaders(opts.Headers))
+		}
+		exp, err := otlptracehttp.New(ctx, httpOpts...)
+		return exp, nil, err
+	default:
+		return nil, nil, fmt.Errorf("tracing: unknown exporter %q", opts.Exporter)
+	}
+}
+
+// Tracer 返回全局 tracer，未调用 Init 时为空实现
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start 开始一个 span
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End 结束 span，err 非空时记录错误并标记为失败
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+// RecordError 记录错误并将 span 标记为失败，err 为空时不做处理
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
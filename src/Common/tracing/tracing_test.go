@@ -0,0 +1,246 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"BotMatrix/common/onebot"
+	"BotMatrix/common/types"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func initMemory(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	shutdown, err := Init(context.Background(), Options{ServiceName: "test", SpanExporter: exp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shutdown(context.Background()) })
+	return exp
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %q not found", name)
+	return tracetest.SpanStub{}
+}
+
+func attr(s tracetest.SpanStub, key string) (string, bool) {
+	for _, kv := range s.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+// 模拟 适配器 -> Nexus -> Redis 队列 -> Worker -> 大模型/工具 -> 回复动作 的完整链路，各环节只通过消息字段传递上下文
+func TestTraceAcrossHops(t *testing.T) {
+	exp := initMemory(t)
+
+	// 适配器: 上报事件
+	adapterCtx, adapterSpan := Start(context.Background(), "adapter.send_to_nexus", trace.WithSpanKind(trace.SpanKindProducer))
+	event := map[string]any{"post_type": "message", "message_type": "private", "user_id": "42", "raw_message": "hi"}
+	InjectMap(adapterCtx, event)
+	adapterSpan.End()
+
+	raw, _ := json.Marshal(event)
+	var v11 onebot.V11RawMessage
+	if err := json.Unmarshal(raw, &v11); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nexus: 处理并写入队列
+	msg := onebot.V11ToInternal(v11, "telegram")
+	nexusCtx, nexusSpan := Start(Extract(context.Background(), msg), "nexus.handle_message", trace.WithSpanKind(trace.SpanKindConsumer))
+	Inject(nexusCtx, &msg)
+	queueMsg := msg.ToV11Map()
+	nexusSpan.End()
+
+	payload, _ := json.Marshal(queueMsg)
+	var received map[string]any
+	if err := json.Unmarshal(payload, &received); err != nil {
+		t.Fatal(err)
+	}
+
+	// Worker: 消费队列并调用大模型与工具
+	workerCtx, workerSpan := Start(ExtractMap(context.Background(), received), "worker.process_message", trace.WithSpanKind(trace.SpanKindConsumer))
+	llmCtx, llmSpan := StartLLM(workerCtx, "openai", "gpt-4o", false)
+	RecordUsage(llmSpan, 120, 30, "tool_calls")
+	llmSpan.End()
+	_, toolSpan := StartTool(llmCtx, "search", "call_1")
+	End(toolSpan, errors.New("timeout"))
+
+	reply := types.InternalAction{Action: "send_msg", Params: map[string]any{"message": "hello"}}
+	InjectAction(workerCtx, &reply)
+	workerSpan.End()
+
+	// Nexus: 下发动作
+	_, actionSpan := Start(ExtractAction(context.Background(), reply), "nexus.send_action send_msg")
+	actionSpan.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 6 {
+		t.Fatalf("expected 6 spans, got %d", len(spans))
+	}
+	traceID := spans[0].SpanContext.TraceID()
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != traceID {
+			t.Fatalf("span %s left the trace", s.Name)
+		}
+	}
+
+	parentOf := map[string]string{
+		"nexus.handle_message":       "adapter.send_to_nexus",
+		"worker.process_message":     "nexus.handle_message",
+		"chat gpt-4o":                "worker.process_message",
+		"execute_tool search":        "chat gpt-4o",
+		"nexus.send_action send_msg": "worker.process_message",
+	}
+	for child, parent := range parentOf {
+		c, p := spanByName(t, spans, child), spanByName(t, spans, parent)
+		if c.Parent.SpanID() != p.SpanContext.SpanID() {
+			t.Errorf("%s: parent is not %s", child, parent)
+		}
+		if !c.Parent.IsRemote() && child != "chat gpt-4o" && child != "execute_tool search" {
+			t.Errorf("%s: parent should be remote", child)
+		}
+	}
+
+	llm := spanByName(t, spans, "chat gpt-4o")
+	if v, _ := attr(llm, "gen_ai.usage.input_tokens"); v != "120" {
+		t.Errorf("input tokens = %q", v)
+	}
+	if v, _ := attr(llm, "gen_ai.usage.output_tokens"); v != "30" {
+		t.Errorf("output tokens = %q", v)
+	}
+	tool := spanByName(t, spans, "execute_tool search")
+	if tool.Status.Code != codes.Error {
+		t.Errorf("tool span status = %v", tool.Status.Code)
+	}
+	if v, _ := attr(tool, "gen_ai.tool.call.id"); v != "call_1" {
+		t.Errorf("tool call id = %q", v)
+	}
+}
+
+func TestPropagationWithoutExporter(t *testing.T) {
+	shutdown, err := Init(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	m := map[string]any{KeyTraceParent: tp, KeyTraceState: "vendor=1"}
+	ctx := ExtractMap(context.Background(), m)
+	if got, ts := TraceParent(ctx); got != tp || ts != "vendor=1" {
+		t.Fatalf("round trip = %q %q", got, ts)
+	}
+
+	// 不记录 span 时，下游仍拿到上游的上下文
+	_, span := Start(ctx, "noop")
+	msg := types.InternalMessage{}
+	Inject(trace.ContextWithSpan(ctx, span), &msg)
+	span.End()
+	if !strings.Contains(msg.TraceParent, "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("trace id lost: %q", msg.TraceParent)
+	}
+
+	// ctx 不含 span 时保留原值
+	Inject(context.Background(), &msg)
+	if msg.TraceParent == "" {
+		t.Fatal("existing traceparent was cleared")
+	}
+	if ExtractMap(context.Background(), map[string]any{}) != context.Background() {
+		t.Fatal("empty map should not change ctx")
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	shutdown, err := Init(context.Background(), Options{ServiceName: "BotWorker", Exporter: ExporterFile, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := Start(context.Background(), "worker.route_skill")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stub struct {
+		Name     string
+		Resource []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&stub); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	if stub.Name != "worker.route_skill" {
+		t.Fatalf("span name = %q", stub.Name)
+	}
+	found := false
+	for _, kv := range stub.Resource {
+		if kv.Key == "service.name" && kv.Value.Value == "BotWorker" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("service.name missing: %s", data)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	for _, opts := range []Options{
+		{Exporter: ExporterFile},
+		{Exporter: ExporterOTLP},
+		{Exporter: "zipkin"},
+	} {
+		if _, err := Init(context.Background(), opts); err == nil {
+			t.Errorf("Init(%+v) should fail", opts)
+		}
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	shutdown, err := Init(context.Background(), Options{SpanExporter: exp, SampleRatio: 0.000001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	// 上游已采样时子 span 随父 span 采样
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, span := Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "child")
+	span.End()
+	if len(exp.GetSpans()) != 1 {
+		t.Fatalf("sampled parent not honoured")
+	}
+}
@@ -135,6 +135,13 @@ type ChatRequest struct {
 	Temperature float32   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
+	// StreamOptions 流式请求选项 (include_usage 让最后一个分片携带用量)
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions 流式请求选项
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // ChatResponse 对话响应
@@ -187,18 +194,69 @@ type SearchFilter struct {
 
 // DocChunk 搜索返回的文档切片
 type DocChunk struct {
-	ID      string  `json:"id"`
-	Content string  `json:"content"`
-	Source  string  `json:"source"`
-	Score   float64 `json:"score"`
-	Title   string  `json:"title"`
-	DocID   uint    `json:"doc_id"`
+	ID         string    `json:"id"`
+	Content    string    `json:"content"`
+	Source     string    `json:"source"`
+	Score      float64   `json:"score"`                // 融合/重排后的相关度，仅用于排序
+	Similarity float64   `json:"similarity,omitempty"` // 与查询的向量余弦相似度，仅向量召回的切片有值
+	Title      string    `json:"title"`
+	DocID      uint      `json:"doc_id"`
+	Citation   *Citation `json:"citation,omitempty"`
+}
+
+// Citation 切片的引用来源，机器人可按 Index 渲染为脚注
+type Citation struct {
+	Index   int    `json:"index"` // 脚注编号，从 1 开始
+	DocID   uint   `json:"doc_id"`
+	ChunkID uint   `json:"chunk_id"`
+	Title   string `json:"title"`
+	Source  string `json:"source"`
+	Start   int    `json:"start"` // 切片在文档正文中的起止字符偏移
+	End     int    `json:"end"`
+}
+
+// Footnote 渲染单条脚注，如 "[1] 产品手册 (manual.md)"
+func (c Citation) Footnote() string {
+	if c.Source == "" || c.Source == c.Title {
+		return fmt.Sprintf("[%d] %s", c.Index, c.Title)
+	}
+	return fmt.Sprintf("[%d] %s (%s)", c.Index, c.Title, c.Source)
+}
+
+// CitationFootnotes 渲染检索结果的脚注列表；cited 非空时只保留回答中实际引用的编号
+func CitationFootnotes(chunks []DocChunk, cited string) string {
+	var lines []string
+	for _, c := range chunks {
+		if c.Citation == nil {
+			continue
+		}
+		if cited != "" && !strings.Contains(cited, fmt.Sprintf("[%d]", c.Citation.Index)) {
+			continue
+		}
+		lines = append(lines, c.Citation.Footnote())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// KnowledgeContext 渲染注入对话的知识库参考内容，编号与 CitationFootnotes 一致
+func KnowledgeContext(chunks []DocChunk) string {
+	var sb strings.Builder
+	sb.WriteString("参考知识库内容 (引用时请在句末标注对应编号，如 [1])：\n")
+	for i, chunk := range chunks {
+		if chunk.Citation != nil {
+			sb.WriteString(fmt.Sprintf("[%d] 来源: %s\n%s\n", chunk.Citation.Index, chunk.Citation.Title, chunk.Content))
+		} else {
+			sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, chunk.Content))
+		}
+	}
+	return sb.String()
 }
 
 // ChatStreamResponse 流式响应增量
 type ChatStreamResponse struct {
 	ID      string         `json:"id"`
 	Choices []StreamChoice `json:"choices"`
+	Usage   *UsageInfo     `json:"usage,omitempty"` // 仅在请求 include_usage 时由最后一个分片返回
 	Error   error          `json:"-"`
 }
 
@@ -210,9 +268,54 @@ type StreamChoice struct {
 
 // MessageDelta 消息增量
 type MessageDelta struct {
-	Role      Role       `json:"role,omitempty"`
-	Content   string     `json:"content,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role             Role            `json:"role,omitempty"`
+	Content          string          `json:"content,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"` // 推理模型 (如 DeepSeek-R1) 的思考过程
+	ToolCalls        []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// ToolCallDelta 流式工具调用分片，同一调用的参数分散在多个分片中，按 Index 拼接
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// AgentEventType 智能体流式事件类型
+type AgentEventType string
+
+const (
+	AgentEventDelta     AgentEventType = "delta"      // 回复文本增量
+	AgentEventReasoning AgentEventType = "reasoning"  // 模型思考过程增量
+	AgentEventTrace     AgentEventType = "trace"      // 本轮调用工具前的中间推理文本
+	AgentEventToolStart AgentEventType = "tool_start" // 开始执行工具
+	AgentEventToolEnd   AgentEventType = "tool_end"   // 工具执行完成
+	AgentEventDone      AgentEventType = "done"       // 运行结束 (携带最终回复与累计用量)
+	AgentEventError     AgentEventType = "error"      // 运行失败
+)
+
+// AgentEvent 智能体流式运行过程中的事件
+type AgentEvent struct {
+	Type         AgentEventType `json:"type"`
+	Step         int            `json:"step"`
+	Content      string         `json:"content,omitempty"`
+	ToolCall     *ToolCall      `json:"tool_call,omitempty"`
+	Result       string         `json:"result,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Usage        *UsageInfo     `json:"usage,omitempty"`
+	FinishReason string         `json:"finish_reason,omitempty"`
+	RunID        string         `json:"run_id,omitempty"` // 等待审批时挂起的运行 ID
+}
+
+// AgentStreamer 支持流式智能体循环的 AI 服务
+type AgentStreamer interface {
+	ChatAgentStream(ctx context.Context, modelID uint, messages []Message, tools []Tool) (<-chan AgentEvent, error)
+}
+
+// EmployeeChatStreamer 支持流式输出数字员工回复的 AI 服务
+type EmployeeChatStreamer interface {
+	ChatWithEmployeeStream(employee *models.DigitalEmployeeGORM, msg InternalMessage, targetOrgID uint, onEvent func(AgentEvent)) (string, error)
 }
 
 // KnowledgeBase 知识库核心接口
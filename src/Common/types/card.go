@@ -0,0 +1,52 @@
+package types
+
+// Card is a platform-neutral interactive card produced by skills and plugins.
+// Adapters render it into their native format (Feishu message card, DingTalk ActionCard, ...).
+type Card struct {
+	ID       string        `json:"id"`                  // Stable card ID, used for in-place updates and callbacks
+	PluginID string        `json:"plugin_id,omitempty"` // Originating plugin, callbacks are routed back to it
+	Title    string        `json:"title"`
+	Theme    string        `json:"theme,omitempty"` // blue, green, orange, red, grey
+	Elements []CardElement `json:"elements,omitempty"`
+	Buttons  []CardButton  `json:"buttons,omitempty"`
+	Operator string        `json:"operator,omitempty"` // User bound to signed callback links (DingTalk ActionCard), reported as the acting user
+	LinkTTL  int           `json:"link_ttl,omitempty"` // Validity of signed callback links in seconds, defaults to one hour
+}
+
+// CardElement is a single block in the card body
+type CardElement struct {
+	Type     string      `json:"type"`                // markdown, text, divider, image, progress, fields
+	Content  string      `json:"content,omitempty"`   // markdown/text content or image alt
+	ImageURL string      `json:"image_url,omitempty"` // image key (Feishu) or URL (DingTalk)
+	Progress int         `json:"progress,omitempty"`  // 0-100, for progress elements
+	Fields   []CardField `json:"fields,omitempty"`
+}
+
+// CardField is a label/value pair rendered side by side
+type CardField struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// CardButton is an interactive button. Buttons with an Action trigger a callback,
+// buttons with only a URL open a link.
+type CardButton struct {
+	Text   string         `json:"text"`
+	Action string         `json:"action,omitempty"`
+	Style  string         `json:"style,omitempty"` // primary, danger, default
+	URL    string         `json:"url,omitempty"`
+	Value  map[string]any `json:"value,omitempty"`
+}
+
+// CardAction is a button callback reported by an adapter
+type CardAction struct {
+	CardID    string         `json:"card_id"`
+	PluginID  string         `json:"plugin_id,omitempty"`
+	Action    string         `json:"action"`
+	Value     map[string]any `json:"value,omitempty"`
+	FormValue map[string]any `json:"form_value,omitempty"`
+	Platform  string         `json:"platform"`
+	UserID    string         `json:"user_id"`
+	GroupID   string         `json:"group_id,omitempty"`
+	MessageID string         `json:"message_id,omitempty"`
+}
@@ -97,3 +97,24 @@ type DockerEvent struct {
 	Status      string    `json:"status"`
 	Timestamp   time.Time `json:"timestamp"`
 }
+
+// ToolApprovalEvent notifies approvers that an agent run is waiting for tool approval
+type ToolApprovalEvent struct {
+	Type        string             `json:"type"` // Always "tool_approval"
+	RunID       string             `json:"run_id"`
+	Platform    string             `json:"platform"`
+	BotID       string             `json:"bot_id"`
+	UserID      string             `json:"user_id"`
+	GroupID     string             `json:"group_id"`
+	MessageType string             `json:"message_type"`
+	Tools       []ToolApprovalItem `json:"tools"`
+	ExpireTime  time.Time          `json:"expire_time"`
+}
+
+// ToolApprovalItem is a tool call in a ToolApprovalEvent, approved or rejected by its audit log ID
+type ToolApprovalItem struct {
+	AuditID   uint   `json:"audit_id"`
+	ToolName  string `json:"tool_name"`
+	Arguments string `json:"arguments"`
+	RiskLevel string `json:"risk_level"`
+}
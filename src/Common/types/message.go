@@ -46,6 +46,10 @@ type InternalMessage struct {
 	Msg         string           `json:"msg"`          // Error message or info
 	MetaType    string           `json:"meta_type"`    // heartbeat, lifecycle
 	Extras      map[string]any   `json:"extras"`       // Additional platform-specific fields
+
+	// W3C trace context of the hop that produced the message, see common/tracing
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
 }
 
 // InternalAction is the unified action format used within BotMatrix
@@ -62,6 +66,17 @@ type InternalAction struct {
 	MessageType string `json:"message_type,omitempty"`
 	DetailType  string `json:"detail_type,omitempty"`
 	Message     any    `json:"message,omitempty"`
+
+	// W3C trace context of the hop that issued the action
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+// IsHandoff reports whether the conversation has been handed off to a human
+// agent (e.g. a WebBot visitor session), in which case AI must not reply
+func (m *InternalMessage) IsHandoff() bool {
+	handoff, _ := m.Extras["handoff"].(bool)
+	return handoff
 }
 
 // ToV11Map converts internal message to OneBot v11 compatible map
@@ -123,6 +138,7 @@ func (m *InternalMessage) ToV11Map() map[string]any {
 		res["sender"] = sender
 	}
 
+	m.putTraceContext(res)
 	return res
 }
 
@@ -168,9 +184,20 @@ func (m *InternalMessage) ToV12Map() map[string]any {
 		res["echo"] = m.Echo
 	}
 
+	m.putTraceContext(res)
 	return res
 }
 
+// putTraceContext copies the trace context into an outgoing OneBot map
+func (m *InternalMessage) putTraceContext(res map[string]any) {
+	if m.TraceParent != "" {
+		res["traceparent"] = m.TraceParent
+	}
+	if m.TraceState != "" {
+		res["tracestate"] = m.TraceState
+	}
+}
+
 // BuildV11String converts structured segments to CQ code string
 func (m *InternalMessage) BuildV11String() string {
 	var sb strings.Builder
@@ -21,6 +21,7 @@ const (
 // PrivacyFilter 处理敏感信息的识别与替换
 type PrivacyFilter struct {
 	patterns map[SensitiveType]*regexp.Regexp
+	order    []SensitiveType // 按添加顺序匹配，占位符编号稳定
 	mu       sync.RWMutex
 }
 
@@ -35,13 +36,21 @@ func NewPrivacyFilter() *PrivacyFilter {
 
 func (f *PrivacyFilter) initDefaultPatterns() {
 	// 简单手机号正则
-	f.patterns[SensitivePhone] = regexp.MustCompile(`(1[3-9]\d{9})`)
+	f.setPattern(SensitivePhone, regexp.MustCompile(`(1[3-9]\d{9})`))
 	// 邮箱正则
-	f.patterns[SensitiveEmail] = regexp.MustCompile(`([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})`)
+	f.setPattern(SensitiveEmail, regexp.MustCompile(`([a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,})`))
 	// 身份证正则 (简单 18 位)
-	f.patterns[SensitiveIDCard] = regexp.MustCompile(`([1-9]\d{5}[1-9]\d{3}((0\d)|(1[0-2]))(([0|1|2]\d)|3[0-1])\d{3}([0-9]|X))`)
+	f.setPattern(SensitiveIDCard, regexp.MustCompile(`([1-9]\d{5}[1-9]\d{3}((0\d)|(1[0-2]))(([0|1|2]\d)|3[0-1])\d{3}([0-9]|X))`))
 	// IP 地址正则
-	f.patterns[SensitiveIP] = regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`)
+	f.setPattern(SensitiveIP, regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`))
+}
+
+// setPattern 设置某类敏感信息的正则，调用方需持有写锁 (初始化时除外)
+func (f *PrivacyFilter) setPattern(sType SensitiveType, re *regexp.Regexp) {
+	if _, exists := f.patterns[sType]; !exists {
+		f.order = append(f.order, sType)
+	}
+	f.patterns[sType] = re
 }
 
 // MaskContext 包含一次掩码操作的上下文，用于后续还原
@@ -66,7 +75,8 @@ func (f *PrivacyFilter) Mask(text string, ctx *MaskContext) string {
 	defer f.mu.RUnlock()
 
 	result := text
-	for sType, pattern := range f.patterns {
+	for _, sType := range f.order {
+		pattern := f.patterns[sType]
 		result = pattern.ReplaceAllStringFunc(result, func(match string) string {
 			// 如果已经替换过，直接返回对应的占位符
 			if placeholder, ok := ctx.MaskedMap[match]; ok {
@@ -101,6 +111,6 @@ func (f *PrivacyFilter) AddCustomPattern(name string, pattern string) error {
 	}
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.patterns[SensitiveType(name)] = re
+	f.setPattern(SensitiveType(name), re)
 	return nil
 }
@@ -28,6 +28,11 @@ type UserClaims struct {
 	Username       string `json:"username"`
 	IsAdmin        bool   `json:"is_admin"`
 	SessionVersion int    `json:"session_version"`
+
+	// 通过 API Key 认证时填充，不写入 JWT
+	APIKeyID     uint     `json:"-"`
+	APIKeyPrefix string   `json:"-"`
+	Scopes       []string `json:"-"` // Key 的权限上限，为空时不额外限制
 	jwt.RegisteredClaims
 }
 
@@ -45,7 +45,8 @@ type WorkerMessage struct {
 	Error        string             `json:"error"`
 	TaskID       any                `json:"task_id"`
 	ExecutionID  any                `json:"execution_id"`
-	Metadata     map[string]any     `json:"metadata"` // 额外元数据
+	Metadata     map[string]any     `json:"metadata"`           // 额外元数据
+	Approval     *ToolApprovalEvent `json:"approval,omitempty"` // type=tool_approval 时的审批通知
 
 	// Common OneBot fields that might appear at top level in passive replies
 	GroupID     string `json:"group_id"`
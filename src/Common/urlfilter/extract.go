@@ -0,0 +1,147 @@
+// Package urlfilter 从消息中提取链接并按域名策略判定是否放行：支持无协议头的裸域名、
+// 国际化域名 (IDN/punycode)、CQ 码与分享卡片中的链接，以及短链展开
+package urlfilter
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"BotMatrix/common/types"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Link 从消息中提取出的一个链接
+type Link struct {
+	// Raw 消息中出现的原始写法
+	Raw string
+	// URL 规范化后的链接：补全协议头，主机名转为小写 punycode
+	URL string
+	// Host 小写 punycode 主机名，不含端口
+	Host string
+}
+
+var (
+	// schemeURL 带协议头的链接
+	schemeURL = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s<>"'` + "`" + `，。、；！？（）【】《》「」,\[\]]+`)
+	// bareDomain 不带协议头的域名，可带端口与路径；标签允许拉丁、西里尔、希腊字母以覆盖同形异义域名，
+	// 不含汉字，避免把紧贴域名的中文并入主机名。中文域名需带协议头或使用 punycode 写法
+	bareDomain = regexp.MustCompile(`(?i)(?:[\p{Latin}\p{Cyrillic}\p{Greek}\p{N}](?:[\p{Latin}\p{Cyrillic}\p{Greek}\p{N}-]{0,61}[\p{Latin}\p{Cyrillic}\p{Greek}\p{N}])?\.)+(?:xn--[a-z0-9-]{1,59}|\p{Latin}{2,63})(?::\d{1,5})?(?:/[^\s<>"'` + "`" + `，。、；！？（）【】《》「」,\[\]]*)?`)
+	// unescaper 还原分享卡片 JSON 中转义的斜杠，以及常被用来代替 "." 规避检测的全角、中文句号
+	unescaper = strings.NewReplacer(`\/`, "/", "。", ".", "．", ".", "｡", ".", "[.]", ".", "(.)", ".", "（.）", ".")
+)
+
+// Extract 提取文本中的全部链接 (去重)，CQ 码中转义的字符会先被还原。无协议头的写法只有在顶级域名属于公共后缀列表时才视为域名，
+// 以免把 "file.txt"、"v1.2" 之类的文本误判为链接
+func Extract(text string) []Link {
+	text = unescaper.Replace(html.UnescapeString(text))
+	var links []Link
+	seen := make(map[string]bool)
+	add := func(l Link) {
+		// 仅协议头不同的链接视为同一个
+		if key := canonical(l.URL); !seen[key] {
+			seen[key] = true
+			links = append(links, l)
+		}
+	}
+
+	covered := make([]bool, len(text))
+	for _, loc := range schemeURL.FindAllStringIndex(text, -1) {
+		raw := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?")
+		if l, ok := parse(raw, true); ok {
+			add(l)
+		}
+		for i := loc[0]; i < loc[1]; i++ {
+			covered[i] = true
+		}
+	}
+	for _, loc := range bareDomain.FindAllStringIndex(text, -1) {
+		if covered[loc[0]] {
+			continue
+		}
+		// 邮箱地址中的域名不作为链接
+		if loc[0] > 0 && text[loc[0]-1] == '@' {
+			continue
+		}
+		raw := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?")
+		if l, ok := parse(raw, false); ok {
+			add(l)
+		}
+	}
+	return links
+}
+
+// FromMessage 提取消息中全部链接，包括原始文本、CQ 码参数，以及 share、link、json、xml 等分享类消息段中的字段
+func FromMessage(msg types.InternalMessage) []Link {
+	var sb strings.Builder
+	sb.WriteString(msg.RawMessage)
+	for _, seg := range msg.Message {
+		collectStrings(&sb, seg.Data)
+	}
+	return Extract(sb.String())
+}
+
+// collectStrings 递归收集消息段数据中的字符串，以空白分隔
+func collectStrings(sb *strings.Builder, v any) {
+	switch d := v.(type) {
+	case string:
+		sb.WriteByte(' ')
+		sb.WriteString(d)
+	case map[string]any:
+		for _, x := range d {
+			collectStrings(sb, x)
+		}
+	case []any:
+		for _, x := range d {
+			collectStrings(sb, x)
+		}
+	case map[string]string:
+		for _, x := range d {
+			collectStrings(sb, x)
+		}
+	}
+}
+
+// parse 规范化一个候选链接，hasScheme 为 false 时要求顶级域名为已知公共后缀
+func parse(raw string, hasScheme bool) (Link, bool) {
+	s := raw
+	if !hasScheme {
+		s = "http://" + raw
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Hostname() == "" {
+		return Link{}, false
+	}
+	host, ok := ASCIIHost(u.Hostname())
+	if !ok {
+		return Link{}, false
+	}
+	if !hasScheme {
+		if _, icann := publicsuffix.PublicSuffix(host); !icann || !strings.Contains(host, ".") {
+			return Link{}, false
+		}
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if port := u.Port(); port != "" {
+		u.Host = host + ":" + port
+	} else {
+		u.Host = host
+	}
+	return Link{Raw: raw, URL: u.String(), Host: host}, true
+}
+
+// ASCIIHost 将主机名转为小写 punycode 形式，如 "例子.中国" → "xn--fsqu00a.xn--fiqs8s"
+func ASCIIHost(host string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", false
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", false
+	}
+	return ascii, true
+}
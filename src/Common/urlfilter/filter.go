@@ -0,0 +1,74 @@
+package urlfilter
+
+import (
+	"context"
+	"sync"
+)
+
+// maxResolveCache 短链展开结果缓存的最大条目数，超出后整体清空
+const maxResolveCache = 1024
+
+// Filter 域名策略与短链展开的组合，可并发使用
+type Filter struct {
+	policy   *Policy
+	resolver Resolver
+	short    map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]resolved
+}
+
+type resolved struct {
+	decision Decision
+	blocked  bool
+}
+
+// NewFilter 创建链接过滤器；resolver 为 nil 时不展开短链，shortDomains 为空时使用 DefaultShortLinkDomains
+func NewFilter(policy *Policy, resolver Resolver, shortDomains []string) *Filter {
+	if len(shortDomains) == 0 {
+		shortDomains = DefaultShortLinkDomains
+	}
+	f := &Filter{policy: policy, resolver: resolver, short: make(map[string]bool, len(shortDomains)), cache: make(map[string]resolved)}
+	for _, d := range shortDomains {
+		if host, ok := ASCIIHost(d); ok {
+			f.short[host] = true
+		}
+	}
+	return f
+}
+
+// Check 依次判定链接，返回第一个被拦截的判定结果；短链会同时判定展开过程中的每一跳，
+// 因此白名单中放行短链服务域名并不会放过其指向 (或途经) 的黑名单站点
+func (f *Filter) Check(ctx context.Context, links []Link) (Decision, bool) {
+	for _, l := range links {
+		if d := f.policy.Evaluate(l); !d.Allowed {
+			return d, true
+		}
+		if f.resolver == nil || !f.short[l.Host] {
+			continue
+		}
+		if d, blocked := f.expand(ctx, l); blocked {
+			return d, true
+		}
+	}
+	return Decision{}, false
+}
+
+// expand 展开短链并判定每一跳，结果按原链接缓存
+func (f *Filter) expand(ctx context.Context, l Link) (Decision, bool) {
+	f.mu.Lock()
+	r, hit := f.cache[l.URL]
+	f.mu.Unlock()
+	if hit {
+		return r.decision, r.blocked
+	}
+
+	r.decision, r.blocked = resolveDecision(ctx, f.resolver, f.policy, l)
+	f.mu.Lock()
+	if len(f.cache) >= maxResolveCache {
+		f.cache = make(map[string]resolved)
+	}
+	f.cache[l.URL] = r
+	f.mu.Unlock()
+	return r.decision, r.blocked
+}
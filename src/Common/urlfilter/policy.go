@@ -0,0 +1,173 @@
+package urlfilter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 匹配方式
+const (
+	ModeExact        = "exact"         // 主机名或完整链接 (忽略协议头与末尾斜杠) 相同
+	ModeDomainSuffix = "domain_suffix" // 主机名等于该域名或为其子域名
+	ModeRegex        = "regex"         // 正则匹配完整链接，仅对含正则元字符 (. 除外) 的条目生效
+)
+
+// Options 策略构建选项
+type Options struct {
+	Whitelist []string
+	Blacklist []string
+	// Modes 启用的匹配方式，为空表示全部启用
+	Modes []string
+	// WhitelistFirst 为 true 时白名单优先：同时命中黑白名单的链接放行；否则黑名单优先
+	WhitelistFirst bool
+}
+
+// Decision 单个链接的判定结果
+type Decision struct {
+	Link    Link
+	Allowed bool
+	// Mode 命中条目所用的匹配方式，未命中任何条目时为空
+	Mode string
+	// Entry 命中的名单条目
+	Entry string
+}
+
+// Policy 由黑白名单编译出的只读域名策略，可并发使用
+type Policy struct {
+	whitelist, blacklist entries
+	whitelistFirst       bool
+}
+
+type entries struct {
+	exact   map[string]string // 规范化后的主机名或链接 → 原条目
+	suffix  []suffixEntry
+	regexes []regexEntry
+}
+
+type suffixEntry struct {
+	domain, entry string
+}
+
+type regexEntry struct {
+	re    *regexp.Regexp
+	entry string
+}
+
+// NewPolicy 编译黑白名单。无法编译的正则条目会被跳过，并在返回的 error 中汇总
+func NewPolicy(opts Options) (*Policy, error) {
+	enabled := map[string]bool{ModeExact: true, ModeDomainSuffix: true, ModeRegex: true}
+	if len(opts.Modes) > 0 {
+		enabled = make(map[string]bool, len(opts.Modes))
+		for _, m := range opts.Modes {
+			enabled[m] = true
+		}
+	}
+	var errs []error
+	compile := func(list []string) entries {
+		es := entries{exact: make(map[string]string)}
+		for _, entry := range list {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			if isPattern(strings.TrimPrefix(entry, "*.")) {
+				if enabled[ModeRegex] {
+					re, err := regexp.Compile(entry)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("url filter regex %q: %w", entry, err))
+						continue
+					}
+					es.regexes = append(es.regexes, regexEntry{re: re, entry: entry})
+				}
+				continue
+			}
+			key := canonical(entry)
+			if key == "" {
+				continue
+			}
+			if enabled[ModeExact] && !strings.HasPrefix(key, "*.") {
+				es.exact[key] = entry
+			}
+			if enabled[ModeDomainSuffix] && !strings.Contains(key, "/") {
+				es.suffix = append(es.suffix, suffixEntry{domain: strings.TrimPrefix(key, "*."), entry: entry})
+			}
+		}
+		return es
+	}
+	p := &Policy{
+		whitelist:      compile(opts.Whitelist),
+		blacklist:      compile(opts.Blacklist),
+		whitelistFirst: opts.WhitelistFirst,
+	}
+	return p, errors.Join(errs...)
+}
+
+// isPattern 判断条目是否为正则表达式；域名中的 "." 不算元字符
+func isPattern(entry string) bool {
+	plain := strings.ReplaceAll(entry, ".", "")
+	return regexp.QuoteMeta(plain) != plain
+}
+
+// canonical 将名单条目或链接规范化为 "主机名[/路径]"：去掉协议头、末尾斜杠，主机名转为小写 punycode
+func canonical(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	host, path, _ := strings.Cut(s, "/")
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	wildcard := strings.HasPrefix(host, "*.")
+	ascii, ok := ASCIIHost(strings.TrimPrefix(host, "*."))
+	if !ok {
+		return ""
+	}
+	if wildcard {
+		ascii = "*." + ascii
+	}
+	if path = strings.TrimRight(path, "/"); path != "" {
+		return ascii + "/" + path
+	}
+	return ascii
+}
+
+// match 返回链接命中的条目
+func (es entries) match(l Link) (mode, entry string, ok bool) {
+	if e, ok := es.exact[l.Host]; ok {
+		return ModeExact, e, true
+	}
+	if e, ok := es.exact[canonical(l.URL)]; ok {
+		return ModeExact, e, true
+	}
+	for _, s := range es.suffix {
+		if l.Host == s.domain || strings.HasSuffix(l.Host, "."+s.domain) {
+			return ModeDomainSuffix, s.entry, true
+		}
+	}
+	for _, r := range es.regexes {
+		if r.re.MatchString(l.URL) {
+			return ModeRegex, r.entry, true
+		}
+	}
+	return "", "", false
+}
+
+// Evaluate 判定单个链接：先检查优先名单，命中即返回；未命中任何名单的链接放行
+func (p *Policy) Evaluate(l Link) Decision {
+	first, second := p.blacklist, p.whitelist
+	firstAllowed := false
+	if p.whitelistFirst {
+		first, second = p.whitelist, p.blacklist
+		firstAllowed = true
+	}
+	if mode, entry, ok := first.match(l); ok {
+		return Decision{Link: l, Allowed: firstAllowed, Mode: mode, Entry: entry}
+	}
+	if mode, entry, ok := second.match(l); ok {
+		return Decision{Link: l, Allowed: !firstAllowed, Mode: mode, Entry: entry}
+	}
+	return Decision{Link: l, Allowed: true}
+}
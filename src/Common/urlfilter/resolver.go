@@ -0,0 +1,143 @@
+package urlfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// DefaultShortLinkDomains 常见短链服务域名
+var DefaultShortLinkDomains = []string{
+	"t.cn", "url.cn", "dwz.cn", "suo.im", "bit.ly", "t.co", "tinyurl.com", "goo.gl", "is.gd", "ow.ly", "v.gd", "b23.tv",
+}
+
+// Resolver 逐跳展开短链
+type Resolver interface {
+	// Resolve 每得到一个跳转目标即调用 visit，visit 返回 false 时停止展开，不再请求该目标
+	Resolve(ctx context.Context, rawURL string, visit func(hop string) bool) error
+}
+
+var (
+	// ErrTooManyRedirects 短链跳转次数超过上限
+	ErrTooManyRedirects = errors.New("urlfilter: too many redirects")
+	// ErrForbiddenAddress 跳转目标解析到内网、回环或链路本地地址
+	ErrForbiddenAddress = errors.New("urlfilter: refusing to connect to a non-public address")
+)
+
+// HTTPResolver 通过 HEAD 请求逐跳读取 Location 头展开短链，不下载页面内容
+type HTTPResolver struct {
+	Client  *http.Client
+	MaxHops int
+}
+
+// NewHTTPResolver 创建 HTTP 短链解析器，timeout 为整个展开过程的超时时间。
+// 连接在拨号时校验实际连接的 IP，拒绝内网、回环与链路本地地址 (防止借短链探测内网)，且不使用环境变量中的代理
+func NewHTTPResolver(timeout time.Duration) *HTTPResolver {
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseNonPublic}
+	return &HTTPResolver{
+		Client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		MaxHops: 5,
+	}
+}
+
+// refuseNonPublic 拨号前检查 DNS 解析后的目标地址
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !isPublic(ip.Unmap()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// cgnat 运营商级 NAT 共享地址段 (RFC 6598)
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+func isPublic(ip netip.Addr) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnat.Contains(ip)
+}
+
+// Resolve 实现 Resolver
+func (r *HTTPResolver) Resolve(ctx context.Context, rawURL string, visit func(hop string) bool) error {
+	current := rawURL
+	for hop := 0; hop < r.MaxHops; hop++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, current, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := r.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || loc == "" {
+			return nil
+		}
+		next, err := resp.Request.URL.Parse(loc)
+		if err != nil {
+			return err
+		}
+		current = next.String()
+		if !visit(current) {
+			return nil
+		}
+		if next.Scheme != "http" && next.Scheme != "https" {
+			return nil
+		}
+	}
+	return ErrTooManyRedirects
+}
+
+// StaticResolver 按固定映射逐跳展开短链，未收录的链接不展开，用于测试与离线环境
+type StaticResolver map[string]string
+
+// Resolve 实现 Resolver
+func (r StaticResolver) Resolve(_ context.Context, rawURL string, visit func(hop string) bool) error {
+	current := rawURL
+	for hop := 0; hop < 5; hop++ {
+		target, ok := r[current]
+		if !ok {
+			return nil
+		}
+		if !visit(target) {
+			return nil
+		}
+		current = target
+	}
+	return ErrTooManyRedirects
+}
+
+// resolveDecision 展开短链并依次判定每一跳，返回第一个被拦截的判定结果
+func resolveDecision(ctx context.Context, r Resolver, p *Policy, l Link) (Decision, bool) {
+	var blocked *Decision
+	r.Resolve(ctx, l.URL, func(hop string) bool {
+		target, ok := parse(hop, true)
+		if !ok {
+			return false
+		}
+		if d := p.Evaluate(target); !d.Allowed {
+			blocked = &d
+			return false
+		}
+		return true
+	})
+	if blocked == nil {
+		return Decision{}, false
+	}
+	return *blocked, true
+}
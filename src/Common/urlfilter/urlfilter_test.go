@@ -0,0 +1,257 @@
+package urlfilter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"BotMatrix/common/types"
+)
+
+func hosts(links []Link) []string {
+	var hs []string
+	for _, l := range links {
+		hs = append(hs, l.Host)
+	}
+	return hs
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"scheme", "看看 https://Example.com/a?b=1 吧", []string{"example.com"}},
+		{"bare domain", "加我 evil.com/xyz 领红包", []string{"evil.com"}},
+		{"bare domain next to chinese", "访问example.org网站", []string{"example.org"}},
+		{"subdomain and port", "api.foo.co.uk:8080/path", []string{"api.foo.co.uk"}},
+		{"chinese full stop", "evil。com", []string{"evil.com"}},
+		{"bracket dot", "evil[.]com", []string{"evil.com"}},
+		{"idn with scheme", "http://例子.中国/", []string{"xn--fsqu00a.xn--fiqs8s"}},
+		{"punycode", "xn--fsqu00a.xn--fiqs8s", []string{"xn--fsqu00a.xn--fiqs8s"}},
+		{"cyrillic homograph", "аpple.com", []string{"xn--pple-43d.com"}},
+		{"cq share", "[CQ:share,url=https://share.example.net/x&#44;y,title=hi]", []string{"share.example.net"}},
+		{"escaped json", `{"jumpUrl":"https:\/\/card.example.cn\/p"}`, []string{"card.example.cn"}},
+		{"email ignored", "mail me at user@example.com", nil},
+		{"file names ignored", "see main.go and v1.2 and notes.txt", nil},
+		{"dedup", "a.com a.com https://a.com", []string{"a.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hosts(Extract(tt.text)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Extract(%q) hosts = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromMessageSegments(t *testing.T) {
+	msg := types.InternalMessage{
+		Message: []types.MessageSegment{
+			{Type: "text", Data: map[string]any{"text": "hello"}},
+			{Type: "share", Data: map[string]any{"url": "https://shared.example.com/a", "title": "t"}},
+			{Type: "json", Data: map[string]any{"data": `{"meta":{"news":{"jumpUrl":"https:\/\/news.example.org\/1"}}}`}},
+		},
+	}
+	got := hosts(FromMessage(msg))
+	want := map[string]bool{"shared.example.com": true, "news.example.org": true}
+	if len(got) != len(want) {
+		t.Fatalf("hosts = %v, want %v", got, want)
+	}
+	for _, h := range got {
+		if !want[h] {
+			t.Fatalf("unexpected host %q in %v", h, got)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	link := func(s string) Link {
+		ls := Extract(s)
+		if len(ls) != 1 {
+			t.Fatalf("Extract(%q) = %v", s, ls)
+		}
+		return ls[0]
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		url     string
+		allowed bool
+		mode    string
+	}{
+		{"exact host", Options{Blacklist: []string{"evil.com"}, Modes: []string{ModeExact}}, "https://evil.com/x", false, ModeExact},
+		{"exact does not cover subdomains", Options{Blacklist: []string{"evil.com"}, Modes: []string{ModeExact}}, "a.evil.com", true, ""},
+		{"exact full url", Options{Blacklist: []string{"https://ok.com/bad/"}, Modes: []string{ModeExact}}, "http://ok.com/bad", false, ModeExact},
+		{"domain suffix", Options{Blacklist: []string{"evil.com"}, Modes: []string{ModeDomainSuffix}}, "cdn.evil.com/a", false, ModeDomainSuffix},
+		{"suffix is label aligned", Options{Blacklist: []string{"evil.com"}, Modes: []string{ModeDomainSuffix}}, "notevil.com", true, ""},
+		{"wildcard entry", Options{Blacklist: []string{"*.evil.com"}}, "x.evil.com", false, ModeDomainSuffix},
+		{"idn entry matches punycode", Options{Blacklist: []string{"例子.中国"}}, "xn--fsqu00a.xn--fiqs8s", false, ModeExact},
+		{"regex", Options{Blacklist: []string{`^https?://[^/]*casino`}}, "www.casino123.com", false, ModeRegex},
+		{"regex disabled", Options{Blacklist: []string{`^https?://[^/]*casino`}, Modes: []string{ModeExact}}, "www.casino123.com", true, ""},
+		{"whitelist first", Options{Whitelist: []string{"good.evil.com"}, Blacklist: []string{"evil.com"}, WhitelistFirst: true}, "good.evil.com", true, ModeExact},
+		{"blacklist first", Options{Whitelist: []string{"good.evil.com"}, Blacklist: []string{"evil.com"}}, "good.evil.com", false, ModeDomainSuffix},
+		{"unlisted", Options{Blacklist: []string{"evil.com"}}, "fine.org", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := p.Evaluate(link(tt.url))
+			if d.Allowed != tt.allowed || d.Mode != tt.mode {
+				t.Fatalf("Evaluate(%q) = allowed %v mode %q, want %v %q", tt.url, d.Allowed, d.Mode, tt.allowed, tt.mode)
+			}
+		})
+	}
+
+	if _, err := NewPolicy(Options{Blacklist: []string{"(bad"}}); err == nil {
+		t.Fatal("expected an error for the invalid regex entry")
+	}
+}
+
+type countingResolver struct {
+	StaticResolver
+	calls int
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, u string, visit func(string) bool) error {
+	r.calls++
+	return r.StaticResolver.Resolve(ctx, u, visit)
+}
+
+func TestFilterExpandsShortLinks(t *testing.T) {
+	p, _ := NewPolicy(Options{Blacklist: []string{"evil.com"}, Whitelist: []string{"t.cn"}})
+	r := &countingResolver{StaticResolver: StaticResolver{"http://t.cn/abc": "https://login.evil.com/phish"}}
+	f := NewFilter(p, r, nil)
+
+	for i := 0; i < 2; i++ {
+		d, blocked := f.Check(context.Background(), Extract("点这里 t.cn/abc"))
+		if !blocked || d.Link.Host != "login.evil.com" {
+			t.Fatalf("short link should be blocked by its target, got %+v blocked=%v", d, blocked)
+		}
+	}
+	if r.calls != 1 {
+		t.Fatalf("resolver calls = %d, want 1 (cached)", r.calls)
+	}
+
+	if _, blocked := f.Check(context.Background(), Extract("t.cn/other")); blocked {
+		t.Fatal("unresolvable short link should be allowed")
+	}
+	if _, blocked := NewFilter(p, nil, nil).Check(context.Background(), Extract("t.cn/abc")); blocked {
+		t.Fatal("without a resolver short links are not expanded")
+	}
+}
+
+func TestFilterChecksEveryHop(t *testing.T) {
+	p, _ := NewPolicy(Options{Blacklist: []string{"tracker.bad"}})
+	var visited []string
+	r := StaticResolver{
+		"http://t.cn/abc":            "https://tracker.bad/r?to=x",
+		"https://tracker.bad/r?to=x": "https://example.com/landing",
+	}
+	d, blocked := NewFilter(p, r, nil).Check(context.Background(), Extract("t.cn/abc"))
+	if !blocked || d.Link.Host != "tracker.bad" {
+		t.Fatalf("intermediate hop should be blocked, got %+v blocked=%v", d, blocked)
+	}
+
+	// 被拦截的一跳不会继续展开
+	r.Resolve(context.Background(), "http://t.cn/abc", func(hop string) bool {
+		visited = append(visited, hop)
+		return false
+	})
+	if len(visited) != 1 {
+		t.Fatalf("visited = %v, want only the first hop", visited)
+	}
+}
+
+func TestHTTPResolver(t *testing.T) {
+	var srv *httptest.Server
+	var requested []string
+	var mu sync.Mutex
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/s":
+			http.Redirect(w, r, "/hop", http.StatusFound)
+		case "/hop":
+			http.Redirect(w, r, srv.URL+"/landing", http.StatusMovedPermanently)
+		case "/loop":
+			http.Redirect(w, r, srv.URL+"/loop", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	// 测试服务器位于回环地址，使用不带地址校验的客户端
+	r := NewHTTPResolver(2 * time.Second)
+	r.Client = &http.Client{Timeout: 2 * time.Second, CheckRedirect: r.Client.CheckRedirect}
+
+	var hops []string
+	collect := func(hop string) bool {
+		hops = append(hops, hop)
+		return true
+	}
+	if err := r.Resolve(context.Background(), srv.URL+"/s", collect); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(hops) != 2 || hops[0] != srv.URL+"/hop" || hops[1] != srv.URL+"/landing" {
+		t.Fatalf("hops = %v", hops)
+	}
+	if err := r.Resolve(context.Background(), srv.URL+"/loop", func(string) bool { return true }); err != ErrTooManyRedirects {
+		t.Fatalf("loop err = %v, want ErrTooManyRedirects", err)
+	}
+
+	// visit 拒绝的一跳不会被请求
+	requested = nil
+	r.Resolve(context.Background(), srv.URL+"/s", func(string) bool { return false })
+	if len(requested) != 1 || requested[0] != "/s" {
+		t.Fatalf("requested = %v, want only /s", requested)
+	}
+}
+
+func TestHTTPResolverRefusesNonPublicAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("loopback server must not be contacted")
+	}))
+	defer srv.Close()
+
+	err := NewHTTPResolver(2*time.Second).Resolve(context.Background(), srv.URL+"/s", func(string) bool { return true })
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("err = %v, want ErrForbiddenAddress", err)
+	}
+
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.ip).Unmap()); got != tt.public {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
@@ -186,6 +186,12 @@ func ToInt64(v any) int64 {
 		return int64(val)
 	case float64:
 		return int64(val)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return int64(f)
 	case string:
 		var i int64
 		fmt.Sscanf(val, "%d", &i)
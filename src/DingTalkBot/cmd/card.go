@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"BotMatrix/common/bot"
+	"BotMatrix/common/types"
+
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/card"
+)
+
+const dingTalkAPI = "https://api.dingtalk.com"
+
+var (
+	// cardStore maps card IDs to the DingTalk outTrackId that carries them
+	cardStore = bot.NewCardStore(7 * 24 * time.Hour)
+
+	tokenMu       sync.Mutex
+	accessToken   string
+	tokenExpireAt time.Time
+)
+
+// getAppAccessToken returns a cached enterprise app access token for the OpenAPI
+func getAppAccessToken() (string, error) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+
+	if accessToken != "" && time.Now().Before(tokenExpireAt) {
+		return accessToken, nil
+	}
+
+	botService.Mu.RLock()
+	body, _ := json.Marshal(map[string]string{
+		"appKey":    dingTalkCfg.ClientID,
+		"appSecret": dingTalkCfg.ClientSecret,
+	})
+	botService.Mu.RUnlock()
+
+	resp, err := httpClient.Post(dingTalkAPI+"/v1.0/oauth2/accessToken", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken string `json:"accessToken"`
+		ExpireIn    int64  `json:"expireIn"`
+		Message     string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("get access token failed: %s", result.Message)
+	}
+
+	accessToken = result.AccessToken
+	// Refresh five minutes early
+	tokenExpireAt = time.Now().Add(time.Duration(result.ExpireIn)*time.Second - 5*time.Minute)
+	return accessToken, nil
+}
+
+// callOpenAPI sends a JSON request to the DingTalk OpenAPI with the app access token
+func callOpenAPI(method, path string, payload any) (map[string]any, error) {
+	token, err := getAppAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(method, dingTalkAPI+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-acs-dingtalk-access-token", token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]any
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("dingtalk api %s returned %s: %v", path, resp.Status, result["message"])
+	}
+	return result, nil
+}
+
+func streamModeEnabled() bool {
+	botService.Mu.RLock()
+	defer botService.Mu.RUnlock()
+	return dingTalkCfg.ClientID != "" && dingTalkCfg.ClientSecret != ""
+}
+
+// cardCallbackSecret is the key used to sign ActionCard callback links
+func cardCallbackSecret() string {
+	botService.Mu.RLock()
+	defer botService.Mu.RUnlock()
+	if dingTalkCfg.Secret != "" {
+		return dingTalkCfg.Secret
+	}
+	return dingTalkCfg.ClientSecret
+}
+
+func sendDingTalkCard(params map[string]any, echo string) {
+	pluginID, _ := params["plugin_id"].(string)
+	c, err := bot.ParseCard(params["card"], pluginID)
+	if err != nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+
+	groupID, _ := params["group_id"].(string)
+	userID, _ := params["user_id"].(string)
+
+	// Enterprise robot: interactive card, supports in-place updates and stream callbacks
+	if streamModeEnabled() && (groupID != "" || userID != "") {
+		sendInteractiveCard(c, groupID, userID, echo)
+		return
+	}
+
+	// Custom robot: webhook ActionCard with signed callback links. Links can be opened by anyone
+	// holding them, so the acting user is bound into the signed payload; private cards default to the recipient.
+	if c.Operator == "" && groupID == "" {
+		c.Operator = userID
+	}
+	botService.Mu.RLock()
+	callbackURL := dingTalkCfg.CardCallbackURL
+	botService.Mu.RUnlock()
+
+	msg := bot.RenderDingTalkActionCard(c, callbackURL, cardCallbackSecret())
+	result, err := postWebhook(msg)
+	if err != nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+
+	cardStore.Put(bot.CardRef{CardID: c.ID, PluginID: c.PluginID, Target: groupID})
+	result["card_id"] = c.ID
+	botService.SendToNexus(map[string]any{"status": "ok", "data": result, "echo": echo})
+}
+
+func sendInteractiveCard(c *types.Card, groupID, userID, echo string) {
+	cardJSON, _ := json.Marshal(bot.RenderDingTalkStandardCard(c))
+
+	botService.Mu.RLock()
+	robotCode := dingTalkCfg.RobotCode
+	if robotCode == "" {
+		robotCode = dingTalkCfg.ClientID
+	}
+	botService.Mu.RUnlock()
+
+	req := map[string]any{
+		"cardTemplateId": "StandardCard",
+		"outTrackId":     c.ID,
+		"robotCode":      robotCode,
+		"cardData": map[string]any{
+			"cardParamMap": map[string]string{"sys_full_json_obj": string(cardJSON)},
+		},
+	}
+	target := groupID
+	if groupID != "" {
+		req["conversationType"] = 1
+		req["openConversationId"] = groupID
+	} else {
+		target = userID
+		receiver, _ := json.Marshal(map[string]string{"userId": userID})
+		req["conversationType"] = 0
+		req["singleChatReceiver"] = string(receiver)
+	}
+
+	result, err := callOpenAPI(http.MethodPost, "/v1.0/im/interactiveCards/send", req)
+	if err != nil {
+		log.Printf("Failed to send DingTalk interactive card: %v", err)
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+
+	cardStore.Put(bot.CardRef{CardID: c.ID, PluginID: c.PluginID, MessageID: c.ID, Target: target})
+	botService.SendToNexus(map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"card_id": c.ID,
+			"result":  result["result"],
+		},
+		"echo": echo,
+	})
+}
+
+func updateDingTalkCard(params map[string]any, echo string) {
+	pluginID, _ := params["plugin_id"].(string)
+	c, err := bot.ParseCard(params["card"], pluginID)
+	if err != nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+
+	ref, ok := cardStore.Get(c.ID)
+	if !ok {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": "unknown card: " + c.ID, "echo": echo})
+		return
+	}
+	if ref.MessageID == "" {
+		// Webhook ActionCards cannot be edited after sending
+		botService.SendToNexus(map[string]any{"status": "failed", "message": "card update requires stream mode", "echo": echo})
+		return
+	}
+
+	cardJSON, _ := json.Marshal(bot.RenderDingTalkStandardCard(c))
+	_, err = callOpenAPI(http.MethodPut, "/v1.0/im/interactiveCards", map[string]any{
+		"outTrackId": ref.MessageID,
+		"cardData": map[string]any{
+			"cardParamMap": map[string]string{"sys_full_json_obj": string(cardJSON)},
+		},
+		"cardOptions": map[string]any{"updateCardDataByKey": false},
+	})
+	if err != nil {
+		botService.Error("Failed to update DingTalk card %s: %v", c.ID, err)
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+
+	cardStore.Put(ref)
+	botService.SendToNexus(map[string]any{"status": "ok", "data": map[string]any{"card_id": c.ID}, "echo": echo})
+}
+
+// handleStreamCardCallback handles interactive card callbacks delivered over the stream connection
+func handleStreamCardCallback(ctx context.Context, req *card.CardRequest) (*card.CardResponse, error) {
+	value := req.CardActionData.CardPrivateData.Params
+	cardID, pluginID, action, extra := bot.ParseCardButtonValue(value)
+	if cardID == "" {
+		cardID = req.OutTrackId
+	}
+	if action == "" && len(req.CardActionData.CardPrivateData.ActionIdList) > 0 {
+		action = req.CardActionData.CardPrivateData.ActionIdList[0]
+	}
+
+	reportCardAction(types.CardAction{
+		CardID:    cardID,
+		PluginID:  pluginID,
+		Action:    action,
+		Value:     extra,
+		UserID:    req.UserId,
+		GroupID:   req.SpaceId,
+		MessageID: req.OutTrackId,
+	})
+	return &card.CardResponse{}, nil
+}
+
+// handleCardCallbackHTTP handles card callbacks delivered over HTTP:
+//   - GET: signed ActionCard links (see bot.RenderDingTalkActionCard)
+//   - POST: card callbacks signed with the DingTalk timestamp/sign headers; rejected when no client secret is configured
+func handleCardCallbackHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		if err := bot.VerifyCardCallback(cardCallbackSecret(), query, time.Now()); err != nil {
+			botService.Warn("Rejected DingTalk card link from %s: %v", r.RemoteAddr, err)
+			http.Error(w, "invalid or expired link", http.StatusUnauthorized)
+			return
+		}
+
+		value := make(map[string]any)
+		for k := range query {
+			value[k] = query.Get(k)
+		}
+		for _, k := range []string{"ts", "exp", "sign", "operator"} {
+			delete(value, k)
+		}
+		cardID, pluginID, action, extra := bot.ParseCardButtonValue(value)
+
+		// 链接不携带点击者身份，以签名中绑定的操作人为准
+		reportCardAction(types.CardAction{
+			CardID:   cardID,
+			PluginID: pluginID,
+			Action:   action,
+			Value:    extra,
+			UserID:   query.Get("operator"),
+		})
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head><body style="text-align:center;padding-top:40px;font-family:sans-serif">已提交，可以关闭此页面</body></html>`)
+
+	case http.MethodPost:
+		botService.Mu.RLock()
+		secret := dingTalkCfg.ClientSecret
+		botService.Mu.RUnlock()
+
+		if err := bot.VerifyDingTalkSignature(r.Header.Get("timestamp"), r.Header.Get("sign"), secret, time.Now()); err != nil {
+			botService.Warn("Rejected DingTalk card callback from %s: %v", r.RemoteAddr, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req card.CardRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.Unmarshal([]byte(req.Content), &req.CardActionData)
+
+		resp, _ := handleStreamCardCallback(r.Context(), &req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func reportCardAction(ca types.CardAction) {
+	if ca.CardID == "" {
+		log.Printf("Ignoring DingTalk card callback without card id")
+		return
+	}
+	if ca.PluginID == "" {
+		if ref, ok := cardStore.Get(ca.CardID); ok {
+			ca.PluginID = ref.PluginID
+		}
+	}
+	if ca.GroupID == "" {
+		if ref, ok := cardStore.Get(ca.CardID); ok {
+			ca.GroupID = ref.Target
+		}
+	}
+	ca.Platform = "DingTalk"
+
+	log.Printf("Card action: card=%s action=%s user=%s", ca.CardID, ca.Action, ca.UserID)
+	botService.SendToNexus(bot.NewCardActionEvent(dingTalkCfg.SelfID, ca))
+}
@@ -30,6 +30,10 @@ type DingTalkConfig struct {
 	// Stream Mode (Enterprise Robot)
 	ClientID     string `json:"client_id"`     // AppKey
 	ClientSecret string `json:"client_secret"` // AppSecret
+	RobotCode    string `json:"robot_code"`    // Optional: defaults to ClientID
+
+	// Public URL of /dingtalk/card, used by ActionCard buttons in Webhook mode
+	CardCallbackURL string `json:"card_callback_url"`
 
 	SelfID string `json:"self_id"` // Optional: manually set SelfID
 }
@@ -64,6 +68,13 @@ func main() {
 			Fields: []bot.ConfigField{
 				{Label: "AppKey (Client ID)", ID: "client_id", Type: "text", Value: dingTalkCfg.ClientID},
 				{Label: "AppSecret (Client Secret)", ID: "client_secret", Type: "password", Value: dingTalkCfg.ClientSecret},
+				{Label: "RobotCode (可选)", ID: "robot_code", Type: "text", Value: dingTalkCfg.RobotCode},
+			},
+		},
+		{
+			Title: "互动卡片",
+			Fields: []bot.ConfigField{
+				{Label: "卡片回调地址 (Webhook 模式, 指向 /dingtalk/card)", ID: "card_callback_url", Type: "text", Value: dingTalkCfg.CardCallbackURL},
 			},
 		},
 		{
@@ -76,6 +87,10 @@ func main() {
 		},
 	})
 
+	// Card button callbacks delivered over HTTP
+	botService.Mux.HandleFunc("/dingtalk/card", handleCardCallbackHTTP)
+	cardStore.StartPruner(botService.Ctx, time.Hour)
+
 	go botService.StartHTTPServer()
 
 	restartBot()
@@ -112,6 +127,16 @@ func loadConfig() {
 		dingTalkCfg.ClientSecret = envClientSecret
 	}
 
+	// Resolve secret:// references; config.json keeps the references
+	if err := botService.ResolveSecrets(map[string]*string{
+		"bot_token":     &dingTalkCfg.BotToken,
+		"access_token":  &dingTalkCfg.AccessToken,
+		"secret":        &dingTalkCfg.Secret,
+		"client_secret": &dingTalkCfg.ClientSecret,
+	}); err != nil {
+		log.Printf("Failed to resolve secrets: %v", err)
+	}
+
 	// Generate a SelfID if not set
 	if dingTalkCfg.SelfID == "" {
 		key := dingTalkCfg.AccessToken
@@ -170,6 +195,7 @@ func startStreamClient(ctx context.Context) {
 			return payload.NewSuccessDataFrameResponse(), nil
 		}),
 	)
+	cli.RegisterCardCallbackRouter(handleStreamCardCallback)
 
 	err := cli.Start(ctx)
 	if err != nil {
@@ -259,6 +285,10 @@ func handleNexusCommand(data []byte) {
 		if text != "" {
 			sendDingTalkMessage(text, cmd.Echo)
 		}
+	case "send_card":
+		sendDingTalkCard(cmd.Params, cmd.Echo)
+	case "update_card":
+		updateDingTalkCard(cmd.Params, cmd.Echo)
 	case "get_login_info":
 		botService.SendToNexus(map[string]interface{}{
 			"status": "ok",
@@ -272,13 +302,34 @@ func handleNexusCommand(data []byte) {
 }
 
 func sendDingTalkMessage(text, echo string) {
+	msg := map[string]any{
+		"msgtype": "text",
+		"text": map[string]string{
+			"content": text,
+		},
+	}
+
+	result, err := postWebhook(msg)
+	if err != nil {
+		log.Printf("Failed to send DingTalk message: %v", err)
+		return
+	}
+	botService.SendToNexus(map[string]any{
+		"status": "ok",
+		"data":   result,
+		"echo":   echo,
+	})
+}
+
+// postWebhook posts a message to the custom robot webhook, signing it if a secret is configured
+func postWebhook(msg map[string]any) (map[string]any, error) {
 	botService.Mu.RLock()
 	accessToken := dingTalkCfg.AccessToken
 	secret := dingTalkCfg.Secret
 	botService.Mu.RUnlock()
 
 	if accessToken == "" {
-		return
+		return nil, fmt.Errorf("webhook access token is not configured")
 	}
 
 	apiURL := "https://oapi.dingtalk.com/robot/send?access_token=" + accessToken
@@ -291,26 +342,15 @@ func sendDingTalkMessage(text, echo string) {
 		apiURL += fmt.Sprintf("&timestamp=%d&sign=%s", timestamp, url.QueryEscape(signature))
 	}
 
-	msg := map[string]any{
-		"msgtype": "text",
-		"text": map[string]string{
-			"content": text,
-		},
-	}
 	payload, _ := json.Marshal(msg)
 
 	resp, err := httpClient.Post(apiURL, "application/json", bytes.NewBuffer(payload))
 	if err != nil {
-		log.Printf("Failed to send DingTalk message: %v", err)
-		return
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var result map[string]any
 	json.NewDecoder(resp.Body).Decode(&result)
-	botService.SendToNexus(map[string]any{
-		"status": "ok",
-		"data":   result,
-		"echo":   echo,
-	})
+	return result, nil
 }
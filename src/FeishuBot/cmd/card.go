@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"BotMatrix/common/bot"
+	"BotMatrix/common/types"
+
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// cardStore maps card IDs to the Feishu message that carries them
+var cardStore = bot.NewCardStore(7 * 24 * time.Hour)
+
+// feishuCardCallback is a schema 2.0 card.action.trigger callback posted to the card request URL
+type feishuCardCallback struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Token     string `json:"token"` // url_verification only
+	Schema    string `json:"schema"`
+	Header    struct {
+		EventType string `json:"event_type"`
+		Token     string `json:"token"`
+	} `json:"header"`
+	Event *callback.CardActionTriggerRequest `json:"event"`
+}
+
+func sendFeishuCard(receiveID, receiveIDType string, params map[string]any, echo string) {
+	pluginID, _ := params["plugin_id"].(string)
+	card, err := bot.ParseCard(params["card"], pluginID)
+	if err != nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+	if larkClient == nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": "feishu client not ready", "echo": echo})
+		return
+	}
+
+	content, _ := json.Marshal(bot.RenderFeishuCard(card))
+	resp, err := larkClient.Im.Message.Create(context.Background(), larkim.NewCreateMessageReqBuilder().
+		ReceiveIdType(receiveIDType).
+		Body(larkim.NewCreateMessageReqBodyBuilder().
+			MsgType(larkim.MsgTypeInteractive).
+			ReceiveId(receiveID).
+			Content(string(content)).
+			Build()).
+		Build())
+
+	if err != nil {
+		log.Printf("Failed to send Feishu card: %v", err)
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+	if !resp.Success() {
+		log.Printf("Feishu API error: %d - %s", resp.Code, resp.Msg)
+		botService.SendToNexus(map[string]any{"status": "failed", "message": resp.Msg, "echo": echo})
+		return
+	}
+
+	messageID := *resp.Data.MessageId
+	cardStore.Put(bot.CardRef{
+		CardID:    card.ID,
+		PluginID:  card.PluginID,
+		MessageID: messageID,
+		Target:    receiveID,
+	})
+
+	botService.SendToNexus(map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"message_id": messageID,
+			"card_id":    card.ID,
+		},
+		"echo": echo,
+	})
+}
+
+func updateFeishuCard(params map[string]any, echo string) {
+	pluginID, _ := params["plugin_id"].(string)
+	card, err := bot.ParseCard(params["card"], pluginID)
+	if err != nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+
+	ref, ok := cardStore.Get(card.ID)
+	if !ok {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": "unknown card: " + card.ID, "echo": echo})
+		return
+	}
+	if larkClient == nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": "feishu client not ready", "echo": echo})
+		return
+	}
+
+	content, _ := json.Marshal(bot.RenderFeishuCard(card))
+	resp, err := larkClient.Im.Message.Patch(context.Background(), larkim.NewPatchMessageReqBuilder().
+		MessageId(ref.MessageID).
+		Body(larkim.NewPatchMessageReqBodyBuilder().
+			Content(string(content)).
+			Build()).
+		Build())
+
+	if err != nil {
+		botService.Error("Failed to update Feishu card %s: %v", card.ID, err)
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
+		return
+	}
+	if !resp.Success() {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": resp.Msg, "echo": echo})
+		return
+	}
+
+	cardStore.Put(ref)
+	botService.SendToNexus(map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"message_id": ref.MessageID,
+			"card_id":    card.ID,
+		},
+		"echo": echo,
+	})
+}
+
+// handleCardActionTrigger handles card callbacks delivered over the long connection
+func handleCardActionTrigger(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	if event.Event == nil || event.Event.Action == nil {
+		return nil, nil
+	}
+	reportCardAction(event.Event.Operator, event.Event.Action, event.Event.Context)
+	return &callback.CardActionTriggerResponse{
+		Toast: &callback.Toast{Type: "success", Content: "已提交"},
+	}, nil
+}
+
+// handleCardCallbackHTTP handles card callbacks delivered to the HTTP card request URL.
+// Requests are rejected unless the X-Lark-Signature (when an encrypt key is configured)
+// and the verification token match. Without either, callbacks cannot be authenticated and are refused.
+func handleCardCallbackHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	botService.Mu.RLock()
+	encryptKey := feishuCfg.EncryptKey
+	verToken := feishuCfg.VerificationToken
+	botService.Mu.RUnlock()
+
+	if encryptKey == "" && verToken == "" {
+		botService.Warn("Rejected Feishu card callback from %s: neither encrypt key nor verification token is configured", r.RemoteAddr)
+		http.Error(w, "card callback verification is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if encryptKey != "" {
+		if !bot.VerifyFeishuSignature(r.Header.Get("X-Lark-Request-Timestamp"), r.Header.Get("X-Lark-Request-Nonce"),
+			encryptKey, body, r.Header.Get("X-Lark-Signature")) {
+			botService.Warn("Rejected Feishu card callback with invalid signature from %s", r.RemoteAddr)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		var encrypted struct {
+			Encrypt string `json:"encrypt"`
+		}
+		if json.Unmarshal(body, &encrypted) == nil && encrypted.Encrypt != "" {
+			body, err = larkevent.EventDecrypt(encrypted.Encrypt, encryptKey)
+			if err != nil {
+				http.Error(w, "decrypt failed", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	var cb feishuCardCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token := cb.Header.Token
+	if token == "" {
+		token = cb.Token
+	}
+	if verToken != "" && token != verToken {
+		botService.Warn("Rejected Feishu card callback with invalid token from %s", r.RemoteAddr)
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if cb.Type == "url_verification" {
+		json.NewEncoder(w).Encode(map[string]string{"challenge": cb.Challenge})
+		return
+	}
+
+	if cb.Header.EventType != "card.action.trigger" || cb.Event == nil || cb.Event.Action == nil {
+		http.Error(w, "unsupported callback", http.StatusBadRequest)
+		return
+	}
+	reportCardAction(cb.Event.Operator, cb.Event.Action, cb.Event.Context)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"toast": map[string]string{"type": "success", "content": "已提交"},
+	})
+}
+
+func reportCardAction(operator *callback.Operator, action *callback.CallBackAction, cbCtx *callback.Context) {
+	cardID, pluginID, actionName, value := bot.ParseCardButtonValue(action.Value)
+	if cardID == "" {
+		log.Printf("Ignoring Feishu card callback without card_id: %v", action.Value)
+		return
+	}
+	if pluginID == "" {
+		if ref, ok := cardStore.Get(cardID); ok {
+			pluginID = ref.PluginID
+		}
+	}
+
+	ca := types.CardAction{
+		CardID:    cardID,
+		PluginID:  pluginID,
+		Action:    actionName,
+		Value:     value,
+		FormValue: action.FormValue,
+		Platform:  "Feishu",
+	}
+	if operator != nil {
+		ca.UserID = operator.OpenID
+	}
+	if cbCtx != nil {
+		ca.GroupID = cbCtx.OpenChatID
+		ca.MessageID = cbCtx.OpenMessageID
+	}
+
+	log.Printf("Card action: card=%s action=%s user=%s", ca.CardID, ca.Action, ca.UserID)
+	botService.SendToNexus(bot.NewCardActionEvent(feishuCfg.AppID, ca))
+}
@@ -49,8 +49,8 @@ func main() {
 			Fields: []bot.ConfigField{
 				{Label: "App ID", ID: "app_id", Type: "text", Value: feishuCfg.AppID},
 				{Label: "App Secret", ID: "app_secret", Type: "password", Value: feishuCfg.AppSecret},
-				{Label: "Encrypt Key (可选)", ID: "encrypt_key", Type: "password", Value: feishuCfg.EncryptKey},
-				{Label: "Verification Token (可选)", ID: "verification_token", Type: "password", Value: feishuCfg.VerificationToken},
+				{Label: "Encrypt Key (卡片回调需与 Verification Token 至少配置一项)", ID: "encrypt_key", Type: "password", Value: feishuCfg.EncryptKey},
+				{Label: "Verification Token (卡片回调需与 Encrypt Key 至少配置一项)", ID: "verification_token", Type: "password", Value: feishuCfg.VerificationToken},
 			},
 		},
 		{
@@ -62,6 +62,10 @@ func main() {
 		},
 	})
 
+	// Card button callbacks delivered over HTTP (card request URL)
+	botService.Mux.HandleFunc("/feishu/card", handleCardCallbackHTTP)
+	cardStore.StartPruner(botService.Ctx, time.Hour)
+
 	go botService.StartHTTPServer()
 
 	restartBot()
@@ -97,6 +101,16 @@ func loadConfig() {
 	if envVerToken := os.Getenv("VERIFICATION_TOKEN"); envVerToken != "" {
 		feishuCfg.VerificationToken = envVerToken
 	}
+
+	// Resolve secret:// references; config.json keeps the references
+	if err := botService.ResolveSecrets(map[string]*string{
+		"bot_token":          &feishuCfg.BotToken,
+		"app_secret":         &feishuCfg.AppSecret,
+		"encrypt_key":        &feishuCfg.EncryptKey,
+		"verification_token": &feishuCfg.VerificationToken,
+	}); err != nil {
+		log.Printf("Failed to resolve secrets: %v", err)
+	}
 }
 
 func restartBot() {
@@ -147,7 +161,8 @@ func startLarkWS(ctx context.Context) {
 		OnP2MessageReceiveV1(func(ctx context.Context, event *larkim.P2MessageReceiveV1) error {
 			handleMessage(ctx, event)
 			return nil
-		})
+		}).
+		OnP2CardActionTrigger(handleCardActionTrigger)
 
 	// Create WebSocket Client
 	cli := larkws.NewClient(appID, appSecret,
@@ -263,6 +278,14 @@ func handleNexusCommand(data []byte) {
 		if userID != "" && message != "" {
 			sendFeishuMessage(userID, "open_id", message, cmd.Echo)
 		}
+	case "send_card":
+		if groupID, _ := cmd.Params["group_id"].(string); groupID != "" {
+			sendFeishuCard(groupID, "chat_id", cmd.Params, cmd.Echo)
+		} else if userID, _ := cmd.Params["user_id"].(string); userID != "" {
+			sendFeishuCard(userID, "open_id", cmd.Params, cmd.Echo)
+		}
+	case "update_card":
+		updateFeishuCard(cmd.Params, cmd.Echo)
 	case "delete_msg":
 		msgID, _ := cmd.Params["message_id"].(string)
 		if msgID != "" {
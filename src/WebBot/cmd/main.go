@@ -3,40 +3,67 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"BotMatrix/common/bot"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
 // WebAppConfig 结构化存储每个 App 的配置
 type WebAppConfig struct {
-	AppKey     string `json:"app_key"`
-	Title      string `json:"title"`
-	ThemeColor string `json:"theme_color"`
-	WelcomeMsg string `json:"welcome_msg"`
-	BotSelfID  string `json:"bot_self_id"` // 该 App 对应的虚拟机器人 ID
+	AppKey         string   `json:"app_key"`
+	Title          string   `json:"title"`
+	ThemeColor     string   `json:"theme_color"`
+	WelcomeMsg     string   `json:"welcome_msg"`
+	BotSelfID      string   `json:"bot_self_id"`     // 该 App 对应的虚拟机器人 ID
+	Secret         string   `json:"secret"`          // 访客令牌签名密钥
+	IdentitySecret string   `json:"identity_secret"` // 客户网站身份 JWT 的 HS256 密钥，留空则不接受客户身份
+	AllowedOrigins []string `json:"allowed_origins"` // 允许嵌入的来源，留空表示不限制
+	TokenTTLHours  int      `json:"token_ttl_hours"`
+	MaxUploadMB    int      `json:"max_upload_mb"`
 }
 
 // WebConfig 整体配置
 type WebConfig struct {
 	bot.BotConfig
-	Apps []WebAppConfig `json:"apps"`
+	Apps         []WebAppConfig `json:"apps"`
+	DataDir      string         `json:"data_dir"`      // 会话记录与上传文件的存储目录
+	PublicURL    string         `json:"public_url"`    // 对外访问地址，用于生成上传文件链接
+	HistoryLimit int            `json:"history_limit"` // 每个访客保留的消息条数
 }
 
+// WebUser 一个在线访客，同一访客可在多个标签页同时连接
 type WebUser struct {
-	ID       string          `json:"id"`
-	Conn     *websocket.Conn `json:"-"`
-	AppKey   string          `json:"app_key"`
-	LastSeen time.Time       `json:"last_seen"`
-	Nickname string          `json:"nickname"`
-	Mu       sync.Mutex
+	ID         string    `json:"id"`
+	AppKey     string    `json:"app_key"`
+	CustomerID string    `json:"customer_id,omitempty"`
+	LastSeen   time.Time `json:"last_seen"`
+	Nickname   string    `json:"nickname"`
+	Mu         sync.Mutex
+	conns      map[*websocket.Conn]struct{}
+	active     int // 仍在读循环中的连接数，受 usersMu 保护，归零时才从在线列表移除
+}
+
+// send 向访客的所有连接推送一帧
+func (u *WebUser) send(v any) {
+	data, _ := json.Marshal(v)
+	u.Mu.Lock()
+	defer u.Mu.Unlock()
+	for conn := range u.conns {
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			conn.Close()
+			delete(u.conns, conn)
+		}
+	}
 }
 
 var (
@@ -45,10 +72,14 @@ var (
 	appsMap    = make(map[string]WebAppConfig) // 快速查找
 	appsMu     sync.RWMutex
 	upgrader   = websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: func(r *http.Request) bool {
+			app, err := lookupApp(r.URL.Query().Get("app_key"))
+			return err == nil && originAllowed(app, r.Header.Get("Origin"))
+		},
 	}
 	users   = make(map[string]*WebUser)
 	usersMu sync.RWMutex
+	history *historyStore
 )
 
 func main() {
@@ -64,6 +95,14 @@ func main() {
 			Fields: []bot.ConfigField{
 				{Label: "BotNexus 地址", ID: "nexus_addr", Type: "text", Value: webCfg.NexusAddr},
 				{Label: "服务端口 (LogPort)", ID: "log_port", Type: "number", Value: webCfg.LogPort},
+				{Label: "对外访问地址 (Public URL)", ID: "public_url", Type: "text", Value: webCfg.PublicURL},
+			},
+		},
+		{
+			Title: "会话存储",
+			Fields: []bot.ConfigField{
+				{Label: "数据目录", ID: "data_dir", Type: "text", Value: webCfg.DataDir},
+				{Label: "每个访客保留消息数", ID: "history_limit", Type: "number", Value: webCfg.HistoryLimit},
 			},
 		},
 		{
@@ -77,6 +116,8 @@ func main() {
 	})
 
 	botService.Mux.HandleFunc("/ws/widget", handleWidgetWebSocket)
+	botService.Mux.HandleFunc("/widget/upload", handleWidgetUpload)
+	botService.Mux.HandleFunc("/widget/files/", handleWidgetFile)
 	botService.Mux.Handle("/widget/", http.StripPrefix("/widget/", http.FileServer(http.Dir("widget"))))
 
 	go botService.StartHTTPServer()
@@ -105,6 +146,11 @@ func loadConfig() {
 		appsMap[app.AppKey] = app
 	}
 	appsMu.Unlock()
+
+	if webCfg.DataDir == "" {
+		webCfg.DataDir = "data"
+	}
+	history = newHistoryStore(webCfg.DataDir, webCfg.HistoryLimit)
 }
 
 func restartBot() {
@@ -136,64 +182,174 @@ func handleNexusCommand(data []byte) {
 		return
 	}
 
+	userID, _ := cmd.Params["user_id"].(string)
+
 	switch cmd.Action {
 	case "send_msg":
-		userID, _ := cmd.Params["user_id"].(string)
+		msg := HistoryMessage{Type: "text", From: "bot"}
+		msg.Content, _ = cmd.Params["content"].(string)
+		if t, _ := cmd.Params["type"].(string); t == "image" || t == "file" {
+			msg.Type = t
+			msg.URL, _ = cmd.Params["url"].(string)
+			msg.FileName, _ = cmd.Params["file_name"].(string)
+		}
+		if from, _ := cmd.Params["from"].(string); from == "agent" {
+			msg.From = "agent"
+		}
+		msg.Nickname, _ = cmd.Params["nickname"].(string)
+		sendToWebUser(userID, msg, cmd.Echo)
+	case "send_typing":
+		typing, _ := cmd.Params["typing"].(bool)
+		if user := onlineUser(userID); user != nil {
+			user.send(map[string]any{"type": "typing", "typing": typing})
+		}
+		replyNexus(cmd.Echo, nil, nil)
+	case "send_stream":
+		streamID, _ := cmd.Params["stream_id"].(string)
+		delta, _ := cmd.Params["delta"].(string)
+		done, _ := cmd.Params["done"].(bool)
+		status, _ := cmd.Params["status"].(string)
 		content, _ := cmd.Params["content"].(string)
-		sendToWebUser(userID, content, cmd.Echo)
+		result, err := sendStreamDelta(userID, streamID, delta, status, content, done)
+		replyNexus(cmd.Echo, result, err)
+	case "set_handoff":
+		enabled, _ := cmd.Params["enabled"].(bool)
+		agent, _ := cmd.Params["agent"].(string)
+		err := setHandoff(userID, enabled, agent)
+		replyNexus(cmd.Echo, nil, err)
 	}
 }
 
-func handleWidgetWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func replyNexus(echo string, data any, err error) {
+	if echo == "" {
+		return
+	}
 	if err != nil {
+		botService.SendToNexus(map[string]any{"status": "failed", "message": err.Error(), "echo": echo})
 		return
 	}
+	botService.SendToNexus(map[string]any{"status": "ok", "data": data, "echo": echo})
+}
 
-	appKey := r.URL.Query().Get("app_key")
-	userID := r.URL.Query().Get("user_id")
-
-	appsMu.RLock()
-	app, ok := appsMap[appKey]
-	appsMu.RUnlock()
+func onlineUser(userID string) *WebUser {
+	usersMu.RLock()
+	defer usersMu.RUnlock()
+	return users[userID]
+}
 
+// attachUser 取得 (或创建) 访客的在线记录并占用一个连接名额，与 detachUser 成对调用
+func attachUser(visitor Visitor, appKey, nickname string) *WebUser {
+	usersMu.Lock()
+	defer usersMu.Unlock()
+	user, ok := users[visitor.ID]
 	if !ok {
-		conn.WriteJSON(map[string]string{"error": "Invalid AppKey"})
-		conn.Close()
+		user = &WebUser{
+			ID:         visitor.ID,
+			AppKey:     appKey,
+			CustomerID: visitor.CustomerID,
+			Nickname:   nickname,
+			conns:      make(map[*websocket.Conn]struct{}),
+		}
+		users[visitor.ID] = user
+	}
+	user.LastSeen = time.Now()
+	user.active++
+	return user
+}
+
+// detachUser 移除本连接；访客的最后一个连接断开时才将其移出在线列表。
+// 计数与移除都在 usersMu 下完成，避免同一访客新打开的连接挂在已被移除的记录上
+func detachUser(user *WebUser, conn *websocket.Conn) {
+	user.Mu.Lock()
+	delete(user.conns, conn)
+	user.Mu.Unlock()
+
+	usersMu.Lock()
+	user.active--
+	empty := user.active == 0
+	if empty && users[user.ID] == user {
+		delete(users, user.ID)
+	}
+	usersMu.Unlock()
+	if empty {
+		history.Evict(user.ID)
+	}
+}
+
+func handleWidgetWebSocket(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	appKey := query.Get("app_key")
+
+	app, err := lookupApp(appKey)
+	if err != nil {
+		http.Error(w, "Invalid AppKey", http.StatusForbidden)
+		return
+	}
+	if !originAllowed(app, r.Header.Get("Origin")) {
+		botService.Warn("Rejected widget connection for %s from origin %q", appKey, r.Header.Get("Origin"))
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
 		return
 	}
 
-	if userID == "" {
-		userID = uuid.New().String()
+	// 不再信任客户端传入的 user_id，身份只来自签名令牌或客户身份 JWT
+	visitor, err := resolveVisitor(app, query.Get("identity"), query.Get("token"))
+	if err != nil {
+		botService.Warn("Rejected widget identity for %s: %v", appKey, err)
+		http.Error(w, "Invalid identity", http.StatusUnauthorized)
+		return
+	}
+	token, err := issueVisitorToken(app, visitor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	user := &WebUser{
-		ID:       userID,
-		Conn:     conn,
-		AppKey:   appKey,
-		LastSeen: time.Now(),
-		Nickname: "Visitor_" + userID[:4],
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
 	}
+	conn.SetReadLimit(64 * 1024)
 
-	usersMu.Lock()
-	users[userID] = user
-	usersMu.Unlock()
+	meta, err := history.UpdateMeta(visitor.ID, func(m *visitorMeta) {
+		m.AppKey = appKey
+		m.CustomerID = visitor.CustomerID
+		if visitor.Nickname != "" {
+			m.Nickname = visitor.Nickname
+		}
+		m.LastSeen = time.Now().Unix()
+	})
+	if err != nil {
+		botService.Error("Failed to persist visitor %s: %v", visitor.ID, err)
+	}
 
+	user := attachUser(visitor, appKey, meta.Nickname)
+
+	since, _ := strconv.ParseInt(query.Get("since"), 10, 64)
+
+	// 先写初始化与历史，再加入推送集合，保证补发的消息在新消息之前
+	user.Mu.Lock()
 	conn.WriteJSON(map[string]any{
 		"type": "init",
 		"data": map[string]any{
-			"user_id": userID,
+			"user_id": visitor.ID,
+			"token":   token,
 			"title":   app.Title,
 			"theme":   app.ThemeColor,
 			"welcome": app.WelcomeMsg,
+			"handoff": meta.Handoff,
+			"agent":   meta.Agent,
 		},
 	})
+	conn.WriteJSON(map[string]any{
+		"type":     "history",
+		"messages": history.Since(visitor.ID, since),
+	})
+	user.conns[conn] = struct{}{}
+	user.Mu.Unlock()
 
 	defer func() {
 		conn.Close()
-		usersMu.Lock()
-		delete(users, userID)
-		usersMu.Unlock()
+		detachUser(user, conn)
 	}()
 
 	for {
@@ -203,42 +359,108 @@ func handleWidgetWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var webMsg struct {
+			Type    string `json:"type"`
 			Content string `json:"content"`
 		}
 		if err := json.Unmarshal(message, &webMsg); err != nil {
 			continue
 		}
 
-		botService.SendToNexus(map[string]any{
-			"post_type":    "message",
-			"message_type": "private",
-			"user_id":      userID,
-			"message":      webMsg.Content,
-			"self_id":      app.BotSelfID,
-			"app_key":      appKey,
-			"nickname":     user.Nickname,
-			"time":         time.Now().Unix(),
-		})
+		switch webMsg.Type {
+		case "", "text":
+			if strings.TrimSpace(webMsg.Content) == "" {
+				continue
+			}
+			forwardVisitorMessage(app, user, HistoryMessage{Type: "text", Content: webMsg.Content})
+		case "handoff":
+			// 访客主动请求人工客服
+			if err := setHandoff(visitor.ID, true, ""); err != nil {
+				botService.Error("Failed to set handoff for %s: %v", visitor.ID, err)
+			}
+			botService.SendToNexus(map[string]any{
+				"post_type":   "notice",
+				"notice_type": "webbot_handoff",
+				"user_id":     visitor.ID,
+				"self_id":     app.BotSelfID,
+				"app_key":     appKey,
+				"time":        time.Now().Unix(),
+			})
+		}
 	}
 }
 
-func sendToWebUser(userID, content, echo string) {
-	usersMu.RLock()
-	user, ok := users[userID]
-	usersMu.RUnlock()
+// forwardVisitorMessage 记录访客消息，同步到访客的其它标签页并转发给 BotNexus
+func forwardVisitorMessage(app WebAppConfig, user *WebUser, msg HistoryMessage) {
+	msg.From = "visitor"
+	msg.Nickname = user.Nickname
+	msg, err := history.Append(user.ID, msg)
+	if err != nil {
+		botService.Error("Failed to persist message for %s: %v", user.ID, err)
+	}
+	user.send(map[string]any{"type": "message", "message": msg})
+
+	content := msg.Content
+	switch msg.Type {
+	case "image":
+		content = "[CQ:image,file=" + cqEscape(msg.URL) + "]"
+	case "file":
+		content = "[CQ:file,file=" + cqEscape(msg.URL) + ",name=" + cqEscape(msg.FileName) + "]"
+	}
 
-	if !ok {
+	event := map[string]any{
+		"post_type":    "message",
+		"message_type": "private",
+		"message_id":   fmt.Sprintf("%s_%d", user.ID, msg.Seq),
+		"user_id":      user.ID,
+		"message":      content,
+		"self_id":      app.BotSelfID,
+		"app_key":      app.AppKey,
+		"nickname":     user.Nickname,
+		"handoff":      history.Meta(user.ID).Handoff,
+		"time":         msg.Time,
+	}
+	if user.CustomerID != "" {
+		event["customer_id"] = user.CustomerID
+	}
+	botService.SendToNexus(event)
+}
+
+func cqEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "[", "&#91;", "]", "&#93;", ",", "&#44;").Replace(s)
+}
+
+// sendToWebUser 持久化回复并推送给在线访客；访客离线时消息保留在记录中，重连后补发
+func sendToWebUser(userID string, msg HistoryMessage, echo string) {
+	if !visitorIDPattern.MatchString(userID) || !history.Exists(userID) {
+		replyNexus(echo, nil, fmt.Errorf("unknown visitor: %s", userID))
 		return
 	}
 
-	msg, _ := json.Marshal(map[string]any{
-		"type":    "text",
-		"content": content,
-		"from":    "bot",
-		"time":    time.Now().Unix(),
-	})
+	msg, err := history.Append(userID, msg)
+	if err != nil {
+		botService.Error("Failed to persist reply for %s: %v", userID, err)
+	}
 
-	user.Mu.Lock()
-	user.Conn.WriteMessage(websocket.TextMessage, msg)
-	user.Mu.Unlock()
+	delivered := false
+	if user := onlineUser(userID); user != nil {
+		user.send(map[string]any{"type": "typing", "typing": false})
+		user.send(map[string]any{"type": "message", "message": msg})
+		delivered = true
+	}
+	replyNexus(echo, map[string]any{"message_id": fmt.Sprintf("%s_%d", userID, msg.Seq), "delivered": delivered}, nil)
+}
+
+// setHandoff 切换访客的人工接管状态，接管期间上报的消息带 handoff 标记供下游跳过自动回复
+func setHandoff(userID string, enabled bool, agent string) error {
+	if !visitorIDPattern.MatchString(userID) || !history.Exists(userID) {
+		return fmt.Errorf("unknown visitor: %s", userID)
+	}
+	meta, err := history.UpdateMeta(userID, func(m *visitorMeta) {
+		m.Handoff = enabled
+		m.Agent = agent
+	})
+	if user := onlineUser(userID); user != nil {
+		user.send(map[string]any{"type": "handoff", "enabled": meta.Handoff, "agent": meta.Agent})
+	}
+	return err
 }
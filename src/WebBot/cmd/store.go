@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const defaultHistoryLimit = 200
+
+// HistoryMessage 一条访客会话记录
+type HistoryMessage struct {
+	Seq      int64  `json:"seq"`
+	Type     string `json:"type"` // text, image, file
+	From     string `json:"from"` // visitor, bot, agent
+	Nickname string `json:"nickname,omitempty"`
+	Content  string `json:"content,omitempty"`
+	URL      string `json:"url,omitempty"`
+	FileName string `json:"file_name,omitempty"`
+	Time     int64  `json:"time"`
+}
+
+// visitorMeta 访客的持久化状态
+type visitorMeta struct {
+	AppKey     string `json:"app_key"`
+	CustomerID string `json:"customer_id,omitempty"`
+	Nickname   string `json:"nickname,omitempty"`
+	Handoff    bool   `json:"handoff"`
+	Agent      string `json:"agent,omitempty"`
+	LastSeen   int64  `json:"last_seen"`
+}
+
+type visitorLog struct {
+	messages []HistoryMessage
+	lastSeq  int64
+	meta     visitorMeta
+}
+
+// historyStore 按访客持久化会话记录 (每个访客一个 jsonl 文件)，
+// 离线期间到达的回复同样写入，重连时按 seq 补发
+type historyStore struct {
+	dir   string
+	limit int
+
+	mu       sync.Mutex
+	visitors map[string]*visitorLog
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+func newHistoryStore(dir string, limit int) *historyStore {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return &historyStore{dir: dir, limit: limit, visitors: make(map[string]*visitorLog)}
+}
+
+func (s *historyStore) basePath(visitorID string) string {
+	return filepath.Join(s.dir, "history", unsafePathChars.ReplaceAllString(visitorID, "_"))
+}
+
+// load 读取访客记录，调用方需持有 s.mu
+func (s *historyStore) load(visitorID string) *visitorLog {
+	if l, ok := s.visitors[visitorID]; ok {
+		return l
+	}
+
+	l := &visitorLog{}
+	base := s.basePath(visitorID)
+	if data, err := os.ReadFile(base + ".meta.json"); err == nil {
+		json.Unmarshal(data, &l.meta)
+	}
+	if f, err := os.Open(base + ".jsonl"); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var m HistoryMessage
+			if json.Unmarshal(scanner.Bytes(), &m) == nil {
+				l.messages = append(l.messages, m)
+				l.lastSeq = m.Seq
+			}
+		}
+		f.Close()
+	}
+	if len(l.messages) > s.limit {
+		l.messages = l.messages[len(l.messages)-s.limit:]
+	}
+	s.visitors[visitorID] = l
+	return l
+}
+
+// Append 追加一条记录并分配 seq
+func (s *historyStore) Append(visitorID string, m HistoryMessage) (HistoryMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.load(visitorID)
+	l.lastSeq++
+	m.Seq = l.lastSeq
+	if m.Time == 0 {
+		m.Time = time.Now().Unix()
+	}
+	l.messages = append(l.messages, m)
+
+	if err := os.MkdirAll(filepath.Join(s.dir, "history"), 0755); err != nil {
+		return m, err
+	}
+
+	// 超出上限两倍时压缩文件，避免无限增长
+	if len(l.messages) > s.limit*2 {
+		l.messages = append([]HistoryMessage(nil), l.messages[len(l.messages)-s.limit:]...)
+		return m, s.rewrite(visitorID, l)
+	}
+
+	f, err := os.OpenFile(s.basePath(visitorID)+".jsonl", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+	line, _ := json.Marshal(m)
+	_, err = f.Write(append(line, '\n'))
+	return m, err
+}
+
+func (s *historyStore) rewrite(visitorID string, l *visitorLog) error {
+	path := s.basePath(visitorID) + ".jsonl"
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, m := range l.messages {
+		line, _ := json.Marshal(m)
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+	return os.Rename(tmp, path)
+}
+
+// Since 返回 seq 大于 since 的记录
+func (s *historyStore) Since(visitorID string, since int64) []HistoryMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.load(visitorID)
+	var out []HistoryMessage
+	for _, m := range l.messages {
+		if m.Seq > since {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (s *historyStore) Meta(visitorID string) visitorMeta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(visitorID).meta
+}
+
+// UpdateMeta 修改并持久化访客状态
+func (s *historyStore) UpdateMeta(visitorID string, fn func(*visitorMeta)) (visitorMeta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.load(visitorID)
+	fn(&l.meta)
+
+	if err := os.MkdirAll(filepath.Join(s.dir, "history"), 0755); err != nil {
+		return l.meta, err
+	}
+	data, _ := json.Marshal(l.meta)
+	return l.meta, os.WriteFile(s.basePath(visitorID)+".meta.json", data, 0644)
+}
+
+// Exists 判断访客是否存在（在线或有持久化记录）
+func (s *historyStore) Exists(visitorID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.visitors[visitorID]; ok {
+		return true
+	}
+	_, err := os.Stat(s.basePath(visitorID) + ".meta.json")
+	return err == nil
+}
+
+// Evict 释放离线访客的内存缓存
+func (s *historyStore) Evict(visitorID string) {
+	s.mu.Lock()
+	delete(s.visitors, visitorID)
+	s.mu.Unlock()
+}
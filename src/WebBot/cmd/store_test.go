@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHistoryStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s := newHistoryStore(dir, 3)
+	vid := "0b6b2c0e-8a4f-4f0e-9d0c-0e1a2b3c4d5e"
+
+	for _, text := range []string{"a", "b", "c"} {
+		if _, err := s.Append(vid, HistoryMessage{Type: "text", From: "visitor", Content: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.UpdateMeta(vid, func(m *visitorMeta) { m.AppKey, m.Handoff = "app1", true }); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开 (相当于重启) 后记录、seq 与访客状态都保留
+	s2 := newHistoryStore(dir, 3)
+	if !s2.Exists(vid) {
+		t.Fatal("visitor not found after reopen")
+	}
+	got := s2.Since(vid, 1)
+	if len(got) != 2 || got[0].Seq != 2 || got[0].Content != "b" || got[1].Content != "c" || got[0].Time == 0 {
+		t.Fatalf("since 1 = %+v", got)
+	}
+	if meta := s2.Meta(vid); meta.AppKey != "app1" || !meta.Handoff {
+		t.Fatalf("meta = %+v", meta)
+	}
+	m, err := s2.Append(vid, HistoryMessage{Type: "text", From: "bot", Content: "d"})
+	if err != nil || m.Seq != 4 {
+		t.Fatalf("append after reopen = %+v, %v", m, err)
+	}
+	if s2.Exists("c_00000000000000000000000000000000") {
+		t.Fatal("unknown visitor reported as existing")
+	}
+}
+
+func TestHistoryStoreCompaction(t *testing.T) {
+	dir := t.TempDir()
+	s := newHistoryStore(dir, 2)
+	vid := "0b6b2c0e-8a4f-4f0e-9d0c-0e1a2b3c4d5e"
+	for i := 0; i < 7; i++ {
+		if _, err := s.Append(vid, HistoryMessage{Type: "text", From: "bot"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 超出上限的旧记录被丢弃，seq 继续递增
+	s.Evict(vid)
+	got := s.Since(vid, 0)
+	if len(got) != 2 || got[0].Seq != 6 || got[1].Seq != 7 {
+		t.Fatalf("after compaction = %+v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const streamIdleTimeout = 10 * time.Minute
+
+type pendingStream struct {
+	text      strings.Builder
+	updatedAt time.Time
+}
+
+var (
+	streams   = make(map[string]*pendingStream)
+	streamsMu sync.Mutex
+)
+
+// sendStreamDelta 推送 AI 流式回复的增量；结束时将完整内容作为一条消息写入记录，
+// 离线访客重连后只会看到完整消息。status 为工具执行等进度提示，不计入内容；
+// 结束时若提供 content，则以其作为最终回复 (替换掉调用工具前的中间文本)
+func sendStreamDelta(userID, streamID, delta, status, content string, done bool) (map[string]any, error) {
+	if streamID == "" {
+		return nil, fmt.Errorf("stream_id is required")
+	}
+	if !visitorIDPattern.MatchString(userID) || !history.Exists(userID) {
+		return nil, fmt.Errorf("unknown visitor: %s", userID)
+	}
+
+	key := userID + "/" + streamID
+	now := time.Now()
+
+	streamsMu.Lock()
+	for k, s := range streams {
+		if now.Sub(s.updatedAt) > streamIdleTimeout {
+			delete(streams, k)
+		}
+	}
+	s, ok := streams[key]
+	if !ok {
+		s = &pendingStream{}
+		streams[key] = s
+	}
+	s.text.WriteString(delta)
+	s.updatedAt = now
+	full := s.text.String()
+	if content != "" {
+		full = content
+	}
+	if done {
+		delete(streams, key)
+	}
+	streamsMu.Unlock()
+
+	frame := map[string]any{"type": "stream", "stream_id": streamID, "delta": delta, "done": done}
+	if status != "" {
+		frame["status"] = status
+	}
+	result := map[string]any{"stream_id": streamID}
+	if done {
+		msg, err := history.Append(userID, HistoryMessage{Type: "text", From: "bot", Content: full})
+		if err != nil {
+			return nil, err
+		}
+		frame["message"] = msg
+		result["message_id"] = fmt.Sprintf("%s_%d", userID, msg.Seq)
+	}
+
+	if user := onlineUser(userID); user != nil {
+		user.send(frame)
+	}
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const defaultTokenTTL = 30 * 24 * time.Hour
+
+// visitorIDPattern 访客 ID 只能由服务端生成 (uuid 或 c_ 前缀的哈希)，同时用作文件名
+var visitorIDPattern = regexp.MustCompile(`^(c_[0-9a-f]{32}|[0-9a-f-]{36})$`)
+
+// VisitorClaims 访客令牌，由 WebBot 按 app_key 签发
+type VisitorClaims struct {
+	VisitorID  string `json:"vid"`
+	CustomerID string `json:"cid,omitempty"`
+	Nickname   string `json:"nick,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// CustomerClaims 客户网站签发的身份令牌 (HS256)，sub 为客户系统内的用户 ID
+type CustomerClaims struct {
+	Name string `json:"name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Visitor 一次连接解析出的访客身份
+type Visitor struct {
+	ID         string
+	CustomerID string
+	Nickname   string
+}
+
+var (
+	fallbackSecretOnce sync.Once
+	fallbackSecret     []byte
+)
+
+// appSecret 返回 App 的令牌签名密钥；未配置时使用进程级随机密钥（重启后访客令牌失效）
+func appSecret(app WebAppConfig) []byte {
+	if app.Secret != "" {
+		return []byte(app.Secret)
+	}
+	fallbackSecretOnce.Do(func() {
+		fallbackSecret = make([]byte, 32)
+		rand.Read(fallbackSecret)
+		botService.Warn("WebBot app secret is not configured, visitor tokens will not survive a restart")
+	})
+	return fallbackSecret
+}
+
+func issueVisitorToken(app WebAppConfig, v Visitor) (string, error) {
+	ttl := defaultTokenTTL
+	if app.TokenTTLHours > 0 {
+		ttl = time.Duration(app.TokenTTLHours) * time.Hour
+	}
+	now := time.Now()
+	claims := &VisitorClaims{
+		VisitorID:  v.ID,
+		CustomerID: v.CustomerID,
+		Nickname:   v.Nickname,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{app.AppKey},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(appSecret(app))
+}
+
+// parseVisitorToken 校验访客令牌，令牌只在签发它的 app_key 下有效
+func parseVisitorToken(app WebAppConfig, tokenString string) (Visitor, error) {
+	claims := &VisitorClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+		return appSecret(app), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience(app.AppKey), jwt.WithExpirationRequired())
+	if err != nil {
+		return Visitor{}, err
+	}
+	if !visitorIDPattern.MatchString(claims.VisitorID) {
+		return Visitor{}, errors.New("invalid visitor id")
+	}
+	return Visitor{ID: claims.VisitorID, CustomerID: claims.CustomerID, Nickname: claims.Nickname}, nil
+}
+
+// parseCustomerIdentity 校验客户网站传入的身份 JWT，并映射为稳定的访客 ID
+func parseCustomerIdentity(app WebAppConfig, tokenString string) (Visitor, error) {
+	if app.IdentitySecret == "" {
+		return Visitor{}, errors.New("customer identity is not enabled for this app")
+	}
+	claims := &CustomerClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+		return []byte(app.IdentitySecret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return Visitor{}, err
+	}
+	if claims.Subject == "" {
+		return Visitor{}, errors.New("identity token has no subject")
+	}
+
+	sum := sha256.Sum256([]byte(app.AppKey + "\x00" + claims.Subject))
+	nickname := claims.Name
+	if nickname == "" {
+		nickname = claims.Subject
+	}
+	return Visitor{
+		ID:         "c_" + hex.EncodeToString(sum[:16]),
+		CustomerID: claims.Subject,
+		Nickname:   nickname,
+	}, nil
+}
+
+// resolveVisitor 按优先级解析访客身份：客户身份 JWT > 访客令牌 > 新访客
+func resolveVisitor(app WebAppConfig, identity, token string) (Visitor, error) {
+	if identity != "" {
+		return parseCustomerIdentity(app, identity)
+	}
+	if token != "" {
+		if v, err := parseVisitorToken(app, token); err == nil {
+			return v, nil
+		}
+	}
+	id := uuid.New().String()
+	return Visitor{ID: id, Nickname: "Visitor_" + id[:4]}, nil
+}
+
+// originAllowed 检查请求来源是否在 App 的白名单内，支持 https://*.example.com 形式的通配
+func originAllowed(app WebAppConfig, origin string) bool {
+	if len(app.AllowedOrigins) == 0 {
+		return true
+	}
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, allowed := range app.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.EqualFold(scheme, u.Scheme) && strings.HasSuffix(strings.ToLower(u.Host), "."+strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupApp(appKey string) (WebAppConfig, error) {
+	appsMu.RLock()
+	defer appsMu.RUnlock()
+	app, ok := appsMap[appKey]
+	if !ok {
+		return WebAppConfig{}, fmt.Errorf("invalid app key: %s", appKey)
+	}
+	return app, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVisitorTokenRoundTrip(t *testing.T) {
+	app := WebAppConfig{AppKey: "app1", Secret: "s3cret"}
+	v := Visitor{ID: "0b6b2c0e-8a4f-4f0e-9d0c-0e1a2b3c4d5e", CustomerID: "u1", Nickname: "Alice"}
+
+	token, err := issueVisitorToken(app, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseVisitorToken(app, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != v {
+		t.Fatalf("visitor = %+v, want %+v", got, v)
+	}
+
+	// 其他 App 的密钥或 app_key 都不能通过校验
+	if _, err := parseVisitorToken(WebAppConfig{AppKey: "app1", Secret: "other"}, token); err == nil {
+		t.Fatal("token accepted with a different secret")
+	}
+	if _, err := parseVisitorToken(WebAppConfig{AppKey: "app2", Secret: "s3cret"}, token); err == nil {
+		t.Fatal("token accepted for a different app key")
+	}
+}
+
+func TestVisitorTokenRejected(t *testing.T) {
+	app := WebAppConfig{AppKey: "app1", Secret: "s3cret"}
+	sign := func(claims *VisitorClaims, method jwt.SigningMethod, key any) string {
+		s, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	valid := func(vid string, exp *jwt.NumericDate) *VisitorClaims {
+		return &VisitorClaims{VisitorID: vid, RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{"app1"},
+			ExpiresAt: exp,
+		}}
+	}
+	vid := "0b6b2c0e-8a4f-4f0e-9d0c-0e1a2b3c4d5e"
+	future := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	cases := map[string]string{
+		"expired":      sign(valid(vid, jwt.NewNumericDate(time.Now().Add(-time.Minute))), jwt.SigningMethodHS256, []byte("s3cret")),
+		"no expiry":    sign(valid(vid, nil), jwt.SigningMethodHS256, []byte("s3cret")),
+		"bad vid":      sign(valid("../../etc/passwd", future), jwt.SigningMethodHS256, []byte("s3cret")),
+		"wrong method": sign(valid(vid, future), jwt.SigningMethodHS512, []byte("s3cret")),
+		"garbage":      "not-a-token",
+	}
+	for name, token := range cases {
+		if _, err := parseVisitorToken(app, token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+func TestVisitorTokenTTL(t *testing.T) {
+	app := WebAppConfig{AppKey: "app1", Secret: "s3cret", TokenTTLHours: 2}
+	token, err := issueVisitorToken(app, Visitor{ID: "0b6b2c0e-8a4f-4f0e-9d0c-0e1a2b3c4d5e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &VisitorClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl <= time.Hour || ttl > 2*time.Hour {
+		t.Fatalf("ttl = %s, want 2h", ttl)
+	}
+}
+
+func TestResolveVisitor(t *testing.T) {
+	app := WebAppConfig{AppKey: "app1", Secret: "s3cret", IdentitySecret: "idsecret"}
+
+	// 客户身份映射为稳定的访客 ID
+	identity, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &CustomerClaims{
+		Name:             "Bob",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "cust-42", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString([]byte("idsecret"))
+	a, err := resolveVisitor(app, identity, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := resolveVisitor(app, identity, "")
+	if a.ID != b.ID || a.CustomerID != "cust-42" || a.Nickname != "Bob" || !visitorIDPattern.MatchString(a.ID) {
+		t.Fatalf("customer visitor = %+v / %+v", a, b)
+	}
+
+	// 无效的访客令牌按新访客处理
+	v, err := resolveVisitor(app, "", "broken")
+	if err != nil || v.ID == "" || !visitorIDPattern.MatchString(v.ID) {
+		t.Fatalf("new visitor = %+v, %v", v, err)
+	}
+	if _, err := resolveVisitor(WebAppConfig{AppKey: "app1", Secret: "s3cret"}, identity, ""); err == nil {
+		t.Fatal("customer identity accepted without identity_secret")
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	app := WebAppConfig{AllowedOrigins: []string{"https://shop.example.com", "https://*.example.org"}}
+	cases := map[string]bool{
+		"https://shop.example.com": true,
+		"https://a.example.org":    true,
+		"http://a.example.org":     false,
+		"https://example.org":      false,
+		"https://evil.com":         false,
+		"":                         false,
+	}
+	for origin, want := range cases {
+		if got := originAllowed(app, origin); got != want {
+			t.Errorf("originAllowed(%q) = %v, want %v", origin, got, want)
+		}
+	}
+	if !originAllowed(WebAppConfig{}, "https://any.site") {
+		t.Error("empty allow list should allow every origin")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultMaxUploadMB = 10
+
+// uploadTypes 允许上传的文件类型 (按内容嗅探) 及保存时使用的扩展名
+var uploadTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+}
+
+var uploadNamePattern = regexp.MustCompile(`^[0-9a-f]{32}\.[a-z]+$`)
+
+func uploadDir() string {
+	return filepath.Join(webCfg.DataDir, "uploads")
+}
+
+// setCORS 为白名单内的来源设置跨域头，组件脚本运行在客户站点上
+func setCORS(w http.ResponseWriter, app WebAppConfig, origin string) bool {
+	if !originAllowed(app, origin) {
+		return false
+	}
+	if origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Add("Vary", "Origin")
+	}
+	return true
+}
+
+// handleWidgetUpload 接收访客上传的图片/文件，作为一条消息转发给 BotNexus
+func handleWidgetUpload(w http.ResponseWriter, r *http.Request) {
+	app, err := lookupApp(r.URL.Query().Get("app_key"))
+	if err != nil {
+		http.Error(w, "Invalid AppKey", http.StatusForbidden)
+		return
+	}
+	if !setCORS(w, app, r.Header.Get("Origin")) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	visitor, err := parseVisitorToken(app, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	maxMB := app.MaxUploadMB
+	if maxMB <= 0 {
+		maxMB = defaultMaxUploadMB
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxMB)<<20+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "File too large or missing", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxMB)<<20+1))
+	if err != nil || len(data) > maxMB<<20 {
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	ext, ok := uploadTypes[contentType]
+	if !ok {
+		http.Error(w, "Unsupported file type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// 随机文件名不可猜测，访客之间无法互相访问上传内容
+	nameBytes := make([]byte, 16)
+	rand.Read(nameBytes)
+	name := hex.EncodeToString(nameBytes) + ext
+
+	if err := os.MkdirAll(uploadDir(), 0755); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(uploadDir(), name), data, 0644); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	baseURL := strings.TrimRight(webCfg.PublicURL, "/")
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+
+	msg := HistoryMessage{Type: "file", URL: baseURL + "/widget/files/" + name, FileName: filepath.Base(header.Filename)}
+	if strings.HasPrefix(contentType, "image/") {
+		msg.Type = "image"
+	}
+
+	user := onlineUser(visitor.ID)
+	if user == nil {
+		user = &WebUser{ID: visitor.ID, AppKey: app.AppKey, CustomerID: visitor.CustomerID, Nickname: history.Meta(visitor.ID).Nickname}
+	}
+	forwardVisitorMessage(app, user, msg)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"url": msg.URL, "type": msg.Type})
+}
+
+// handleWidgetFile 提供上传文件下载，非图片一律作为附件下载
+func handleWidgetFile(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/widget/files/")
+	if !uploadNamePattern.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(uploadDir(), name))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "image/") {
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
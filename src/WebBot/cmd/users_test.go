@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDetachUserKeepsOtherConnectionsOnline(t *testing.T) {
+	history = newHistoryStore(t.TempDir(), 10)
+	visitor := Visitor{ID: "0b6b2c0e-8a4f-4f0e-9d0c-0e1a2b3c4d5e"}
+	defer delete(users, visitor.ID)
+
+	// 同一访客打开两个标签页
+	tab1, tab2 := &websocket.Conn{}, &websocket.Conn{}
+	user := attachUser(visitor, "app1", "")
+	user.conns[tab1] = struct{}{}
+	if attachUser(visitor, "app1", "") != user {
+		t.Fatal("connections of one visitor should share the online record")
+	}
+	user.conns[tab2] = struct{}{}
+
+	// 关闭一个标签页后访客仍在线，另一个连接保留
+	detachUser(user, tab1)
+	if onlineUser(visitor.ID) != user {
+		t.Fatal("visitor should stay online while another tab is connected")
+	}
+	if _, ok := user.conns[tab2]; !ok || len(user.conns) != 1 {
+		t.Fatalf("only the closed connection should be removed, got %d", len(user.conns))
+	}
+
+	// 新连接在升级完成前 (尚未加入推送集合) 也占用名额，最后一个旧连接断开不会把访客移出在线列表
+	pending := attachUser(visitor, "app1", "")
+	detachUser(user, tab2)
+	if onlineUser(visitor.ID) != pending || pending != user {
+		t.Fatal("a connecting tab should keep the visitor online")
+	}
+
+	detachUser(pending, &websocket.Conn{})
+	if onlineUser(visitor.ID) != nil {
+		t.Fatal("visitor should go offline after the last connection closes")
+	}
+}
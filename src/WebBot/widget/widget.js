@@ -53,10 +53,16 @@
     chatWindow.innerHTML = `
         <div style="padding: 20px; background: ${themeColor}; color: white; display: flex; justify-content: space-between; align-items: center;">
             <div style="font-weight: bold;">${title}</div>
-            <div id="webbot-close" style="cursor: pointer; opacity: 0.8;">✕</div>
+            <div style="display: flex; gap: 12px; align-items: center;">
+                <div id="webbot-handoff" title="Talk to a human" style="cursor: pointer; opacity: 0.8; font-size: 12px;">👤</div>
+                <div id="webbot-close" style="cursor: pointer; opacity: 0.8;">✕</div>
+            </div>
         </div>
+        <div id="webbot-banner" style="display: none; padding: 6px 15px; font-size: 12px; background: #fff8e1; color: #8a6d3b;"></div>
         <div id="webbot-messages" style="flex: 1; overflow-y: auto; padding: 15px; background: #f8f9fa; display: flex; flex-direction: column; gap: 10px;"></div>
-        <div style="padding: 15px; border-top: 1px solid #eee; display: flex; gap: 10px; background: white;">
+        <div id="webbot-typing" style="display: none; padding: 0 15px 6px; font-size: 12px; color: #888; background: #f8f9fa;">...</div>
+        <div style="padding: 15px; border-top: 1px solid #eee; display: flex; gap: 10px; background: white; align-items: center;">
+            <label style="cursor: pointer; color: #888; font-size: 18px;" title="Upload">📎<input id="webbot-file" type="file" accept="image/*,.pdf,.zip,.txt" style="display: none;"></label>
             <input id="webbot-input" type="text" placeholder="Type a message..." style="flex: 1; border: 1px solid #ddd; padding: 8px 12px; border-radius: 20px; outline: none;">
             <button id="webbot-send" style="background: ${themeColor}; color: white; border: none; padding: 8px 15px; border-radius: 20px; cursor: pointer; font-weight: bold;">Send</button>
         </div>
@@ -67,9 +73,15 @@
     document.body.appendChild(container);
 
     // State
+    // 访客令牌由服务端签发并按 app_key 隔离，客户网站可通过 data-identity 传入已登录用户的身份 JWT
+    const identity = script.getAttribute('data-identity') || '';
+    const tokenKey = 'webbot_token_' + appKey;
     let isOpen = false;
     let ws = null;
-    let userId = localStorage.getItem('webbot_user_id');
+    let token = localStorage.getItem(tokenKey) || '';
+    let lastSeq = 0;
+    let httpBase = '';
+    const streams = {};
 
     // Toggle Chat
     button.onclick = () => {
@@ -91,7 +103,7 @@
     document.getElementById('webbot-close').onclick = button.onclick;
 
     function connect() {
-        if (ws && ws.readyState === WebSocket.OPEN) return;
+        if (ws && (ws.readyState === WebSocket.OPEN || ws.readyState === WebSocket.CONNECTING)) return;
 
         // 如果 serverAddr 不包含协议，自动补全
         let wsUrl = serverAddr;
@@ -99,18 +111,37 @@
             const protocol = window.location.protocol === 'https:' ? 'wss://' : 'ws://';
             wsUrl = protocol + wsUrl;
         }
-        
-        wsUrl += `/ws/widget?app_key=${appKey}&user_id=${userId || ''}`;
-        ws = new WebSocket(wsUrl);
+        httpBase = wsUrl.replace(/^ws/, 'http');
+
+        // since: 只补发本页面尚未显示的消息（包括离线期间到达的回复）
+        const params = new URLSearchParams({ app_key: appKey, token: token, since: String(lastSeq) });
+        if (identity) params.set('identity', identity);
+        ws = new WebSocket(`${wsUrl}/ws/widget?${params.toString()}`);
 
         ws.onmessage = (e) => {
             const msg = JSON.parse(e.data);
-            if (msg.type === 'init') {
-                userId = msg.data.user_id;
-                localStorage.setItem('webbot_user_id', userId);
-                if (msg.data.welcome) addMessage('bot', msg.data.welcome);
-            } else if (msg.type === 'text') {
-                addMessage(msg.from === 'bot' ? 'bot' : 'other', msg.content, msg.from);
+            switch (msg.type) {
+                case 'init':
+                    token = msg.data.token;
+                    localStorage.setItem(tokenKey, token);
+                    if (lastSeq === 0 && msg.data.welcome) addBubble('bot', msg.data.welcome);
+                    setHandoff(msg.data.handoff, msg.data.agent);
+                    break;
+                case 'history':
+                    (msg.messages || []).forEach(renderMessage);
+                    break;
+                case 'message':
+                    renderMessage(msg.message);
+                    break;
+                case 'typing':
+                    document.getElementById('webbot-typing').style.display = msg.typing ? 'block' : 'none';
+                    break;
+                case 'stream':
+                    renderStream(msg);
+                    break;
+                case 'handoff':
+                    setHandoff(msg.enabled, msg.agent);
+                    break;
             }
         };
 
@@ -120,7 +151,65 @@
         };
     }
 
-    function addMessage(type, content, nickname) {
+    function renderMessage(m) {
+        if (!m || m.seq <= lastSeq) return;
+        lastSeq = m.seq;
+        document.getElementById('webbot-typing').style.display = 'none';
+
+        const type = m.from === 'visitor' ? 'self' : (m.from === 'agent' ? 'other' : 'bot');
+        const nickname = m.from === 'agent' ? (m.nickname || 'Agent') : '';
+        const bubble = addBubble(type, m.type === 'text' ? m.content : '', nickname);
+        if (m.type === 'image' && /^https?:\/\//.test(m.url)) {
+            const img = document.createElement('img');
+            img.src = m.url;
+            img.style.cssText = 'max-width: 100%; border-radius: 8px; display: block;';
+            bubble.appendChild(img);
+        } else if (m.type === 'file' && /^https?:\/\//.test(m.url)) {
+            const link = document.createElement('a');
+            link.href = m.url;
+            link.target = '_blank';
+            link.rel = 'noopener';
+            link.innerText = m.file_name || m.url;
+            link.style.color = 'inherit';
+            bubble.appendChild(link);
+        }
+    }
+
+    function renderStream(msg) {
+        let bubble = streams[msg.stream_id];
+        if (!bubble) {
+            document.getElementById('webbot-typing').style.display = 'none';
+            bubble = streams[msg.stream_id] = addBubble('bot', '');
+        }
+        // 工具执行等进度提示显示在回复下方，收到新的增量后清除
+        const status = bubble.querySelector('.webbot-stream-status');
+        if (status) status.remove();
+        bubble.lastChild.innerText += msg.delta || '';
+        if (msg.status && !msg.done) {
+            const statusDiv = document.createElement('div');
+            statusDiv.className = 'webbot-stream-status';
+            statusDiv.style.cssText = 'font-size: 12px; color: #888; font-style: italic; margin-top: 4px;';
+            statusDiv.innerText = msg.status;
+            bubble.appendChild(statusDiv);
+        }
+        const messages = document.getElementById('webbot-messages');
+        messages.scrollTop = messages.scrollHeight;
+        if (msg.done) {
+            delete streams[msg.stream_id];
+            if (msg.message) {
+                bubble.lastChild.innerText = msg.message.content;
+                lastSeq = Math.max(lastSeq, msg.message.seq);
+            }
+        }
+    }
+
+    function setHandoff(enabled, agent) {
+        const banner = document.getElementById('webbot-banner');
+        banner.style.display = enabled ? 'block' : 'none';
+        banner.innerText = agent ? `${agent} is now chatting with you` : 'Connecting you to a human agent...';
+    }
+
+    function addBubble(type, content, nickname) {
         const msgDiv = document.createElement('div');
         const isSelf = type === 'self';
         const isBot = type === 'bot';
@@ -152,20 +241,42 @@
         const messages = document.getElementById('webbot-messages');
         messages.appendChild(msgDiv);
         messages.scrollTop = messages.scrollHeight;
+        return msgDiv;
     }
 
     const input = document.getElementById('webbot-input');
     const sendBtn = document.getElementById('webbot-send');
+    const fileInput = document.getElementById('webbot-file');
 
+    // 消息以服务端回显为准，保证多标签页和重连后的顺序一致
     const sendMessage = () => {
         const text = input.value.trim();
         if (!text || !ws || ws.readyState !== WebSocket.OPEN) return;
 
         ws.send(JSON.stringify({ type: 'text', content: text }));
-        addMessage('self', text);
         input.value = '';
     };
 
+    fileInput.onchange = () => {
+        const file = fileInput.files[0];
+        fileInput.value = '';
+        if (!file || !token) return;
+
+        const form = new FormData();
+        form.append('file', file);
+        fetch(`${httpBase}/widget/upload?app_key=${encodeURIComponent(appKey)}`, {
+            method: 'POST',
+            headers: { 'Authorization': 'Bearer ' + token },
+            body: form
+        }).then(resp => {
+            if (!resp.ok) return resp.text().then(t => addBubble('other', 'Upload failed: ' + t));
+        }).catch(err => addBubble('other', 'Upload failed: ' + err));
+    };
+
+    document.getElementById('webbot-handoff').onclick = () => {
+        if (ws && ws.readyState === WebSocket.OPEN) ws.send(JSON.stringify({ type: 'handoff' }));
+    };
+
     sendBtn.onclick = sendMessage;
     input.onkeypress = (e) => { if (e.key === 'Enter') sendMessage(); };
 
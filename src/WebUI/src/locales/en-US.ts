@@ -187,6 +187,7 @@ export default {
   'ai_title': 'Title',
   'ai_title_gen': 'Session Title Generation',
   'ai_token_usage': 'Token Usage',
+  'ai_tool_running': 'Running tool',
   'ai_topic': 'Topic',
   'ai_translate_enabled': 'Enable Real-time AI Translation',
   'ai_trial': 'AI Trial',
@@ -1081,6 +1082,11 @@ export default {
   'login_error': 'Login Error',
   'login_error_auth': 'Invalid username or password',
   'login_error_generic': 'Login failed, please try again later',
+  'or': 'or',
+  'sso_login': 'Sign in with SSO',
+  'sso_login_failed': 'SSO login failed',
+  'totp_code': '6-digit code',
+  'totp_invalid': 'Invalid verification code',
   'login_failed': 'Login failed',
   'login_menu': 'Login',
   'login_now': 'Login Now',
@@ -187,6 +187,7 @@ export default {
   'ai_title': '職位',
   'ai_title_gen': 'セッションタイトルの生成',
   'ai_token_usage': 'Token 消費',
+  'ai_tool_running': 'ツールを実行中',
   'ai_topic': 'トピック',
   'ai_translate_enabled': 'リアルタイム AI 翻訳を有効にする',
   'ai_trial': 'エージェント試用',
@@ -1073,6 +1074,11 @@ export default {
   'login_error': 'ログインエラー',
   'login_error_auth': 'ユーザー名またはパスワードが正しくありません',
   'login_error_generic': 'ログインに失敗しました。後でもう一度お試しください',
+  'or': 'または',
+  'sso_login': 'SSO でログイン',
+  'sso_login_failed': 'SSO ログインに失敗しました',
+  'totp_code': '6 桁の確認コード',
+  'totp_invalid': '確認コードが正しくありません',
   'login_failed': 'ログインに失敗しました',
   'login_menu': 'ログイン',
   'login_now': '今すぐログイン',
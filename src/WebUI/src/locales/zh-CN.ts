@@ -190,6 +190,7 @@ export default {
   'ai_title': '职位',
   'ai_title_gen': '会话标题生成',
   'ai_token_usage': 'Token 消耗',
+  'ai_tool_running': '正在调用工具',
   'ai_topic': '对话主题',
   'ai_translate_enabled': '开启实时 AI 翻译',
   'ai_trial': '智能体试用',
@@ -1100,6 +1101,11 @@ export default {
   'login_error': '登入错误',
   'login_error_auth': '使用者名或密码错误',
   'login_error_generic': '登入失败，请稍后再试',
+  'or': '或',
+  'sso_login': '单点登录 (SSO)',
+  'sso_login_failed': '单点登录失败',
+  'totp_code': '6 位动态验证码',
+  'totp_invalid': '动态验证码错误',
   'login_failed': '登入失败',
   'login_menu': '登入',
   'login_now': '立即登入',
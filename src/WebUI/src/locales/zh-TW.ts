@@ -196,6 +196,7 @@ export default {
   'ai_title': '職位',
   'ai_title_gen': '會話標題生成',
   'ai_token_usage': 'Token 消耗',
+  'ai_tool_running': '正在呼叫工具',
   'ai_topic': '對話主題',
   'ai_translate_enabled': '開啟即時 AI 翻譯',
   'ai_trial': '智慧體試用',
@@ -1082,6 +1083,11 @@ export default {
   'login_error': '登入錯誤',
   'login_error_auth': '使用者名或密碼錯誤',
   'login_error_generic': '登入失敗，請稍後再試',
+  'or': '或',
+  'sso_login': '單一登入 (SSO)',
+  'sso_login_failed': '單一登入失敗',
+  'totp_code': '6 位動態驗證碼',
+  'totp_invalid': '動態驗證碼錯誤',
   'login_failed': '登入失敗',
   'login_menu': '登入',
   'login_now': '立即登入',
@@ -179,6 +179,12 @@ const router = createRouter({
       component: () => import('@/views/auth/Register.vue'),
       meta: { layout: 'blank', title: 'title.register' }
     },
+    {
+      path: '/auth/sso',
+      name: 'sso-callback',
+      component: () => import('@/views/auth/SSOCallback.vue'),
+      meta: { layout: 'blank', title: 'title.login' }
+    },
     {
       path: '/auth/token-login',
       name: 'token-login',
@@ -51,9 +51,21 @@ export const useAuthStore = defineStore('auth', {
       localStorage.removeItem('wxbot_token');
       localStorage.removeItem('wxbot_role');
     },
-    async login(username: string, password: string) {
+    // Ends the session on the server (revoking every issued token) and returns the IdP logout URL for SSO users
+    async signOut() {
+      let endSessionUrl = '';
       try {
-        const { data } = await api.post('/api/login', { username, password });
+        const { data } = await api.post('/api/logout');
+        endSessionUrl = data?.data?.end_session_url || '';
+      } catch (error) {
+        console.warn('Server logout failed:', error);
+      }
+      this.logout();
+      return endSessionUrl;
+    },
+    async login(username: string, password: string, totpCode = '') {
+      try {
+        const { data } = await api.post('/api/login', { username, password, totp_code: totpCode || undefined });
         if (data.success && data.data?.token) {
           const loginInfo = data.data;
           this.setToken(loginInfo.token);
@@ -66,6 +78,9 @@ export const useAuthStore = defineStore('auth', {
         return false;
       } catch (error: any) {
         console.error('Login failed:', error);
+        if (error.response?.data?.data?.totp_required) {
+          throw new Error(totpCode ? 'totp_invalid' : 'totp_required');
+        }
         throw new Error(error.response?.data?.error || error.response?.data?.message || 'login_failed');
       }
     },
@@ -99,6 +114,11 @@ export const useAuthStore = defineStore('auth', {
         throw error;
       }
     },
+    // Stores the token handed over by the OIDC callback and loads the user
+    async loginWithSSOToken(token: string) {
+      this.setToken(token);
+      return this.checkAuth();
+    },
     async loginWithMagicToken(token: string) {
       try {
         const { data } = await api.post('/api/login/magic', { token });
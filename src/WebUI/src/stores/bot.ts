@@ -7,6 +7,7 @@ export const useBotStore = defineStore('bot', {
     bots: [] as any[],
     messages: [] as any[],
     stats: {} as any,
+    pendingApprovals: [] as any[],
     pendingRequests: new Map<string, { resolve: Function; reject: Function; timeout: number }>(),
   }),
   actions: {
@@ -820,6 +821,11 @@ export const useBotStore = defineStore('bot', {
           };
           this.messages.push(resultMessage);
         }
+      } else if (message.type === 'tool_approval') {
+        // 高风险工具等待审批，按 audit_id 调用 /api/admin/audit/tools/approve 或 reject
+        if (!this.pendingApprovals.some(a => a.run_id === message.run_id)) {
+          this.pendingApprovals.push(message);
+        }
       } else if (message.type === 'worker_update') {
         // 处理 Worker 更新广播
         // 可以在这里更新 workers 列表状态
@@ -76,6 +76,21 @@ func (c *Context) SendImage(url string) {
 	})
 }
 
+// SendCard sends an interactive card (rendered by Feishu/DingTalk adapters).
+// Button callbacks come back to this plugin as "on_card_action" events.
+func (c *Context) SendCard(card map[string]any) {
+	c.CallAction("send_card", map[string]any{
+		"card": card,
+	})
+}
+
+// UpdateCard replaces a previously sent card in place, matched by the card "id"
+func (c *Context) UpdateCard(card map[string]any) {
+	c.CallAction("update_card", map[string]any{
+		"card": card,
+	})
+}
+
 // AddAction adds a custom action
 func (c *Context) AddAction(actionType string, payload map[string]any) {
 	c.CallAction(actionType, payload)
@@ -256,7 +271,7 @@ func (p *Plugin) HasPermission(action string) bool {
 
 	// Essential built-in actions are always allowed
 	switch action {
-	case "send_message", "send_image", "storage.get", "storage.set":
+	case "send_message", "send_image", "send_card", "update_card", "storage.get", "storage.set":
 		return true
 	}
 
@@ -291,6 +306,11 @@ func (p *Plugin) OnMessage(handler Handler) {
 	p.On("on_message", handler)
 }
 
+// OnCardAction registers a handler for card button callbacks
+func (p *Plugin) OnCardAction(handler Handler) {
+	p.On("on_card_action", handler)
+}
+
 // OnIntent registers a handler for a specific intent name
 func (p *Plugin) OnIntent(intentName string, handler Handler) {
 	p.On("intent_"+intentName, handler)
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"BotMatrix/common/ai"
+	"BotMatrix/common/ai/rag"
+	"BotMatrix/common/ai/rag/eval"
+	"BotMatrix/common/types"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// EvalConfig 一组被评测的 RAG 参数，对应 -config / -compare 指定的 JSON 文件
+type EvalConfig struct {
+	Name            string  `json:"name"`
+	Splitter        string  `json:"splitter"`   // hierarchical (默认), flat, recursive
+	ChunkSize       int     `json:"chunk_size"` // 默认 1000
+	ChildSize       int     `json:"child_size"` // 默认 400
+	Overlap         int     `json:"overlap"`
+	Reranker        string  `json:"reranker"` // 空 (不重排), cross-encoder, llm
+	RerankEndpoint  string  `json:"rerank_endpoint"`
+	RerankModel     string  `json:"rerank_model"`
+	MinSimilarity   float64 `json:"min_similarity"`
+	MinScore        float64 `json:"min_score"`
+	MMRLambda       float64 `json:"mmr_lambda"`
+	DupThreshold    float64 `json:"dup_threshold"`
+	QueryRefinement bool    `json:"query_refinement"` // 检索前由对话模型改写问题 (需要 -chat-model)
+}
+
+type evalOptions struct {
+	golden, corpus          string
+	k                       int
+	embedURL, embedModel    string
+	chatURL, chatModel      string
+	apiKey                  string
+	grader                  string
+	extensions              []string
+	failOnRegression        bool
+	markdownOut, jsonOut    string
+	configPath, comparePath string
+}
+
+func handleEval() {
+	fset := flag.NewFlagSet("eval", flag.ExitOnError)
+	var opts evalOptions
+	var exts string
+	fset.StringVar(&opts.golden, "golden", "golden.json", "Golden question set (JSON)")
+	fset.StringVar(&opts.corpus, "corpus", "docs", "Directory indexed as the knowledge base")
+	fset.StringVar(&exts, "ext", ".md,.txt", "File extensions to index")
+	fset.StringVar(&opts.configPath, "config", "", "Baseline RAG config (JSON), defaults to the built-in settings")
+	fset.StringVar(&opts.comparePath, "compare", "", "Candidate RAG config (JSON) to diff against the baseline")
+	fset.IntVar(&opts.k, "k", 5, "Number of retrieved chunks per question")
+	fset.StringVar(&opts.embedURL, "embed-url", os.Getenv("BM_EVAL_EMBED_URL"), "OpenAI-compatible base URL for embeddings, empty for the offline hash embedding")
+	fset.StringVar(&opts.embedModel, "embed-model", "text-embedding-3-small", "Embedding model")
+	fset.StringVar(&opts.chatURL, "chat-url", os.Getenv("BM_EVAL_CHAT_URL"), "OpenAI-compatible base URL for answers and grading (defaults to -embed-url)")
+	fset.StringVar(&opts.chatModel, "chat-model", "", "Chat model used to answer questions, empty to evaluate retrieval only")
+	fset.StringVar(&opts.apiKey, "api-key", os.Getenv("BM_EVAL_API_KEY"), "API key for the model endpoints")
+	fset.StringVar(&opts.grader, "grader", "", "Answer grader: llm or stub (defaults to llm when -chat-model is set)")
+	fset.BoolVar(&opts.failOnRegression, "fail-on-regression", false, "Exit with status 1 when the candidate regresses on any question")
+	fset.StringVar(&opts.markdownOut, "out", "", "Write the Markdown report to this file instead of stdout")
+	fset.StringVar(&opts.jsonOut, "json", "", "Also write the raw results as JSON")
+	fset.Parse(os.Args[2:])
+	for _, e := range strings.Split(exts, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			opts.extensions = append(opts.extensions, e)
+		}
+	}
+	if opts.chatURL == "" {
+		opts.chatURL = opts.embedURL
+	}
+
+	if err := runEval(context.Background(), opts); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runEval(ctx context.Context, opts evalOptions) error {
+	set, err := eval.LoadGoldenSet(opts.golden)
+	if err != nil {
+		return err
+	}
+	base, err := loadEvalConfig(opts.configPath, "baseline")
+	if err != nil {
+		return err
+	}
+
+	var chat *chatService
+	if opts.chatModel != "" {
+		chat = &chatService{adapter: ai.NewOpenAIAdapter(opts.chatURL, opts.apiKey), model: opts.chatModel}
+	}
+
+	baseReport, err := evaluateConfig(ctx, opts, base, set, chat)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if opts.markdownOut != "" {
+		f, err := os.Create(opts.markdownOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if opts.comparePath == "" {
+		baseReport.WriteMarkdown(out)
+		return writeEvalJSON(opts.jsonOut, baseReport)
+	}
+
+	candidate, err := loadEvalConfig(opts.comparePath, "candidate")
+	if err != nil {
+		return err
+	}
+	candidateReport, err := evaluateConfig(ctx, opts, candidate, set, chat)
+	if err != nil {
+		return err
+	}
+	diff := eval.Compare(baseReport, candidateReport)
+	diff.WriteMarkdown(out)
+	if err := writeEvalJSON(opts.jsonOut, diff); err != nil {
+		return err
+	}
+	if opts.failOnRegression && len(diff.Regressions) > 0 {
+		return fmt.Errorf("%d question(s) regressed", len(diff.Regressions))
+	}
+	return nil
+}
+
+func loadEvalConfig(path, name string) (EvalConfig, error) {
+	cfg := EvalConfig{Name: name}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	if cfg.Name == "" {
+		cfg.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return cfg, nil
+}
+
+// evaluateConfig 用一组参数在独立的内存数据库中重建知识库并运行问题集
+func evaluateConfig(ctx context.Context, opts evalOptions, cfg EvalConfig, set *eval.GoldenSet, chat *chatService) (*eval.Report, error) {
+	fmt.Fprintf(os.Stderr, "[%s] indexing %s...\n", cfg.Name, opts.corpus)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+
+	vectorDir, err := os.MkdirTemp("", "bm-eval-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(vectorDir)
+
+	var es rag.EmbeddingService = eval.HashEmbedding{}
+	if opts.embedURL != "" {
+		es = &embeddingService{adapter: ai.NewOpenAIAdapter(opts.embedURL, opts.apiKey), model: opts.embedModel}
+	}
+	var aiSvc types.AIService
+	if chat != nil && cfg.QueryRefinement {
+		aiSvc = chat
+	}
+
+	kb := rag.NewPostgresKnowledgeBase(db, es, aiSvc, 0)
+	kb.SetVectorStoreConfig("embedded", filepath.Join(vectorDir, "vectors.hnsw"))
+	kb.SetSearchOptions(rag.SearchOptions{
+		MinSimilarity: cfg.MinSimilarity,
+		MinScore:      cfg.MinScore,
+		MMRLambda:     cfg.MMRLambda,
+		DupThreshold:  cfg.DupThreshold,
+	})
+	switch cfg.Reranker {
+	case "":
+	case "cross-encoder":
+		kb.SetReranker(rag.NewCrossEncoderReranker(cfg.RerankEndpoint, opts.apiKey, cfg.RerankModel))
+	case "llm":
+		if chat == nil {
+			return nil, fmt.Errorf("[%s] the llm reranker requires -chat-model", cfg.Name)
+		}
+		kb.SetReranker(rag.NewLLMReranker(chat, 0))
+	default:
+		return nil, fmt.Errorf("[%s] unknown reranker %q", cfg.Name, cfg.Reranker)
+	}
+	if err := kb.Setup(); err != nil {
+		return nil, err
+	}
+
+	indexer := rag.NewIndexer(kb, nil, 0)
+	if err := indexer.SetChunkOptions(rag.ChunkOptions{
+		Splitter:  cfg.Splitter,
+		ChunkSize: cfg.ChunkSize,
+		ChildSize: cfg.ChildSize,
+		Overlap:   cfg.Overlap,
+	}); err != nil {
+		return nil, fmt.Errorf("[%s] %v", cfg.Name, err)
+	}
+	docs, err := indexCorpus(ctx, indexer, opts.corpus, opts.extensions)
+	if err != nil {
+		return nil, err
+	}
+	var chunks int64
+	db.Model(&rag.KnowledgeChunk{}).Count(&chunks)
+	fmt.Fprintf(os.Stderr, "[%s] indexed %d documents into %d chunks, running %d questions...\n", cfg.Name, docs, chunks, len(set.Questions))
+
+	runner := &eval.Runner{KB: kb, K: opts.k}
+	if chat != nil {
+		model := eval.AIServiceModel{Svc: chat}
+		runner.Answerer = eval.ChatAnswerer{Model: model}
+		switch opts.grader {
+		case "", "llm":
+			runner.Grader = eval.LLMGrader{Model: model}
+		case "stub":
+			runner.Grader = eval.OverlapGrader{}
+		default:
+			return nil, fmt.Errorf("unknown grader %q", opts.grader)
+		}
+	}
+
+	report, err := runner.Run(ctx, cfg.Name, set)
+	if err != nil {
+		return nil, err
+	}
+	report.Config = cfg
+	return report, nil
+}
+
+// indexCorpus 以相对 corpus 的路径作为来源索引全部文档，便于问题集按路径引用期望文档
+func indexCorpus(ctx context.Context, indexer *rag.Indexer, dir string, extensions []string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		matched := false
+		for _, e := range extensions {
+			if strings.ToLower(e) == ext {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		source := filepath.ToSlash(rel)
+		if err := indexer.IndexContent(ctx, filepath.Base(path), source, content, "doc", "system", "system", "system"); err != nil {
+			return fmt.Errorf("index %s: %v", source, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func writeEvalJSON(path string, v any) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return eval.WriteJSON(f, v)
+}
+
+// embeddingService 调用 OpenAI 兼容的 /embeddings 接口
+type embeddingService struct {
+	adapter *ai.OpenAIAdapter
+	model   string
+}
+
+func (s *embeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := s.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (s *embeddingService) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	return s.GenerateEmbedding(ctx, query)
+}
+
+func (s *embeddingService) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := s.adapter.CreateEmbedding(ctx, ai.EmbeddingRequest{Model: s.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// chatService 以 OpenAI 兼容接口实现知识库与评测用到的 Chat 方法。
+// 其余 types.AIService 方法在 CLI 中不会被调用，未实现
+type chatService struct {
+	types.AIService
+	adapter *ai.OpenAIAdapter
+	model   string
+}
+
+func (s *chatService) Chat(ctx context.Context, modelID uint, messages []types.Message, tools []types.Tool) (*types.ChatResponse, error) {
+	return s.adapter.Chat(ctx, ai.ChatRequest{Model: s.model, Messages: messages, Tools: tools})
+}
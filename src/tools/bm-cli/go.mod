@@ -8,4 +8,6 @@ replace BotMatrix/common => ../../Common
 require (
 	github.com/fsnotify/fsnotify v1.7.0
 	BotMatrix/common v0.0.0
+	github.com/glebarez/sqlite v1.11.0
+	gorm.io/gorm v1.31.1
 )
@@ -79,6 +79,8 @@ func main() {
 		handleGen()
 	case "run":
 		handleRun()
+	case "eval":
+		handleEval()
 	case "help":
 		printUsage()
 	default:
@@ -96,6 +98,7 @@ func printUsage() {
 	fmt.Println("  bm-cli test [dir]    Run automated tests defined in tests.json")
 	fmt.Println("  bm-cli gen <prompt> [--lang go|python]  Generate a plugin using natural language")
 	fmt.Println("  bm-cli run [dir]     Run plugin in a sandbox with a Web-based simulator")
+	fmt.Println("  bm-cli eval --golden <file> --corpus <dir> [--config base.json] [--compare candidate.json]  Evaluate RAG retrieval and answers")
 	fmt.Println("  bm-cli help")
 }
 